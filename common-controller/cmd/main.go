@@ -18,6 +18,8 @@
 package main
 
 import (
+	"os"
+
 	logger "github.com/sirupsen/logrus"
 	commoncontroller "github.com/wso2/apk/common-controller/commoncontroller"
 	config "github.com/wso2/apk/common-controller/internal/config"
@@ -28,10 +30,14 @@ import (
 
 func main() {
 	conf := config.ReadConfigs()
+	// common-controller migrate status|up|down [steps] manages the schema of the database and exits.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(migrate(conf, os.Args[2:]))
+	}
 	logger.Info("Starting the Web server")
 	go web.StartWebServer()
 	go server.StartInternalServer()
-	if conf.CommonController.Database.Enabled {
+	if database.IsEnabled(conf) {
 		logger.Info("Starting the Database connection")
 		database.ConnectToDB()
 	}
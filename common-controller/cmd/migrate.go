@@ -0,0 +1,84 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	config "github.com/wso2/apk/common-controller/internal/config"
+	"github.com/wso2/apk/common-controller/internal/database"
+)
+
+const migrateUsage = "usage: common-controller migrate status | up | down [steps]"
+
+// migrate runs the migrate command on the configured database and returns the exit code.
+func migrate(conf *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	switch args[0] {
+	case "status":
+		statuses, err := database.MigrationStatuses(conf)
+		if err != nil {
+			return migrateFailed(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tDESCRIPTION\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Description, state, status.AppliedAt)
+		}
+		w.Flush()
+	case "up":
+		applied, err := database.MigrateUp(conf)
+		if err != nil {
+			return migrateFailed(err)
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		reverted, err := database.MigrateDown(conf, steps)
+		if err != nil {
+			return migrateFailed(err)
+		}
+		fmt.Printf("Reverted %d migrations\n", reverted)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+func migrateFailed(err error) int {
+	fmt.Fprintln(os.Stderr, "Migration failed:", err)
+	return 1
+}
@@ -21,11 +21,14 @@ require (
 	github.com/envoyproxy/go-control-plane/envoy v1.32.5-0.20250622153809-434b6986176d
 	github.com/envoyproxy/go-control-plane/ratelimit v0.1.1-0.20250805143705-d51f8590a549
 	github.com/gin-gonic/gin v1.10.0
+	github.com/go-sql-driver/mysql v1.9.3
 	github.com/grpc-ecosystem/go-grpc-middleware v1.4.0
+	github.com/microsoft/go-mssqldb v1.9.2
 	github.com/pelletier/go-toml v1.9.5
 	github.com/redis/go-redis/v9 v9.7.1
 	github.com/wso2/apk/adapter v0.0.0-20250227061136-f432d0d0f334
 	github.com/wso2/apk/common-go-libs v0.0.0-20250227062715-19715f9c5f76
+	go.etcd.io/bbolt v1.4.3
 	google.golang.org/grpc v1.74.2
 )
 
@@ -35,6 +38,7 @@ replace github.com/wso2/apk/common-go-libs => ../common-go-libs
 
 require (
 	cel.dev/expr v0.24.0 // indirect
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/blang/semver/v4 v4.0.0 // indirect
 	github.com/bytedance/sonic v1.12.9 // indirect
 	github.com/bytedance/sonic/loader v0.2.3 // indirect
@@ -50,6 +54,8 @@ require (
 	github.com/go-playground/validator/v10 v10.25.0 // indirect
 	github.com/go-task/slim-sprig/v3 v3.0.0 // indirect
 	github.com/goccy/go-json v0.10.5 // indirect
+	github.com/golang-sql/civil v0.0.0-20220223132316-b832511892a9 // indirect
+	github.com/golang-sql/sqlexp v0.1.0 // indirect
 	github.com/google/btree v1.1.3 // indirect
 	github.com/google/gnostic-models v0.6.9 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
@@ -41,7 +41,7 @@ var defaultConfig = &Config{
 			EventPort:            18000,
 			RestPort:             18001,
 			RetryInterval:        5,
-			Persistence:          persistence{Type: "K8s"},
+			Persistence:          persistence{Type: "K8s", Path: "/home/wso2/data/controlplane.db"},
 			EnableAPIPropagation: false,
 			APIsRestPath:         "/apis",
 		},
@@ -51,12 +51,13 @@ var defaultConfig = &Config{
 			Port:    18006,
 		},
 		Database: database{
-			Enabled:  false,
-			Name:     "DATAPLANE",
-			Username: "wso2carbon",
-			Password: "wso2carbon",
-			Host:     "wso2apk-db-service.apk",
-			Port:     5432,
+			Enabled:     false,
+			Name:        "DATAPLANE",
+			Username:    "wso2carbon",
+			Password:    "wso2carbon",
+			Host:        "wso2apk-db-service.apk",
+			Port:        5432,
+			AutoMigrate: true,
 			PoolOptions: dbPool{
 				PoolMaxConns:              4,
 				PoolMinConns:              0,
@@ -66,6 +67,10 @@ var defaultConfig = &Config{
 				PoolMaxConnLifetimeJitter: "1s",
 			},
 		},
+		Redis: redis{
+			RevokedTokenStream:       "wso2:apk:revoked_tokens",
+			RevokedTokenStreamMaxLen: 100000,
+		},
 		DeployResourcesWithClusterRoleBindings: true,
 	},
 }
@@ -64,7 +64,10 @@ type controlplane struct {
 	APIsRestPath         string
 }
 type persistence struct {
+	// Type is where the control plane artifacts are kept: K8s, PostgreSQL (or DB), MySQL, MSSQL or Embedded.
 	Type string
+	// Path is the file of the Embedded persistence.
+	Path string
 }
 type internalAPIServer struct {
 	Port int64
@@ -88,15 +91,19 @@ type operator struct {
 }
 
 type redis struct {
-	Host                string
-	Port                string
-	Username            string
-	Password            string
-	UserCertPath        string
-	UserKeyPath         string
-	CACertPath          string
-	TLSEnabled          bool
-	RevokedTokenChannel string
+	Host         string
+	Port         string
+	Username     string
+	Password     string
+	UserCertPath string
+	UserKeyPath  string
+	CACertPath   string
+	TLSEnabled   bool
+	// RevokedTokenStream is the Redis stream the revoked tokens are appended to, for the enforcers to consume.
+	RevokedTokenStream string
+	// RevokedTokenStreamMaxLen is the approximate number of revocations kept in the stream. Enforcers that fall
+	// further behind reload the revoked tokens from their keys.
+	RevokedTokenStreamMaxLen int64
 }
 
 type sts struct {
@@ -122,9 +129,14 @@ type database struct {
 	Password    string
 	Host        string
 	Port        int
+	// AutoMigrate applies the pending schema migrations at startup. When disabled, the common controller
+	// does not use the database until they are applied with the migrate command.
+	AutoMigrate bool
 	PoolOptions dbPool
 }
 
+// dbPool configures the connection pool of the database. MySQL and MSSQL use the maximum number of
+// connections, their lifetime and idle time, the other options only apply to PostgreSQL.
 type dbPool struct {
 	// PoolMaxConns is the maximum size of the pool. The default is the greater of 4 or runtime.NumCPU()
 	PoolMaxConns int
@@ -0,0 +1,400 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wso2/apk/common-go-libs/pkg/server/model"
+)
+
+// conformanceTarget opens a Repository the conformance tests run against. Opening it again must
+// return a Repository over the same artifacts.
+type conformanceTarget struct {
+	name string
+	open func(t *testing.T) Repository
+}
+
+// conformanceTargets returns the embedded store, and the SQL databases the DSN of which is set in
+// APK_TEST_POSTGRES_DSN, APK_TEST_MYSQL_DSN or APK_TEST_MSSQL_DSN. The SQL databases are emptied
+// before each test.
+func conformanceTargets(t *testing.T) []conformanceTarget {
+	targets := []conformanceTarget{{
+		name: PersistenceEmbedded,
+		open: func() func(t *testing.T) Repository {
+			path := filepath.Join(t.TempDir(), "controlplane.db")
+			return func(t *testing.T) Repository {
+				repository, err := openEmbeddedRepository(path)
+				require.NoError(t, err)
+				return repository
+			}
+		}(),
+	}}
+	for _, sqlTarget := range []struct {
+		name    string
+		env     string
+		dialect dialect
+	}{
+		{PersistencePostgreSQL, "APK_TEST_POSTGRES_DSN", postgresDialect},
+		{PersistenceMySQL, "APK_TEST_MYSQL_DSN", mysqlDialect},
+		{PersistenceMSSQL, "APK_TEST_MSSQL_DSN", mssqlDialect},
+	} {
+		dsn := os.Getenv(sqlTarget.env)
+		if dsn == "" {
+			t.Logf("Skipping %s as %s is not set", sqlTarget.name, sqlTarget.env)
+			continue
+		}
+		d := sqlTarget.dialect
+		targets = append(targets, conformanceTarget{
+			name: sqlTarget.name,
+			open: func(t *testing.T) Repository {
+				db, err := sql.Open(d.driverName, dsn)
+				require.NoError(t, err)
+				repository, err := newSQLRepository(d, db, nil)
+				require.NoError(t, err)
+				return repository
+			},
+		})
+	}
+	return targets
+}
+
+// runConformance runs the test against a fresh Repository of each target.
+func runConformance(t *testing.T, test func(t *testing.T, target conformanceTarget, repository Repository)) {
+	for _, target := range conformanceTargets(t) {
+		t.Run(target.name, func(t *testing.T) {
+			repository := target.open(t)
+			t.Cleanup(func() { repository.Close() })
+			require.NoError(t, repository.Transact(context.Background(), func(tx Tx) error {
+				if err := tx.DeleteAllApplications(); err != nil {
+					return err
+				}
+				return tx.DeleteAllSubscriptions()
+			}))
+			test(t, target, repository)
+		})
+	}
+}
+
+func transact(t *testing.T, repository Repository, fn func(tx Tx) error) error {
+	t.Helper()
+	return repository.Transact(context.Background(), fn)
+}
+
+func mustTransact(t *testing.T, repository Repository, fn func(tx Tx) error) {
+	t.Helper()
+	require.NoError(t, transact(t, repository, fn))
+}
+
+func applications(t *testing.T, repository Repository) []model.Application {
+	t.Helper()
+	var result []model.Application
+	mustTransact(t, repository, func(tx Tx) (err error) {
+		result, err = tx.GetAllApplications()
+		return err
+	})
+	sort.Slice(result, func(i, j int) bool { return result[i].UUID < result[j].UUID })
+	return result
+}
+
+func subscriptions(t *testing.T, repository Repository) []model.Subscription {
+	t.Helper()
+	var result []model.Subscription
+	mustTransact(t, repository, func(tx Tx) (err error) {
+		result, err = tx.GetAllSubscriptions()
+		return err
+	})
+	sort.Slice(result, func(i, j int) bool { return result[i].UUID < result[j].UUID })
+	return result
+}
+
+func applicationMappings(t *testing.T, repository Repository) []model.ApplicationMapping {
+	t.Helper()
+	var result []model.ApplicationMapping
+	mustTransact(t, repository, func(tx Tx) (err error) {
+		result, err = tx.GetAllApplicationMappings()
+		return err
+	})
+	sort.Slice(result, func(i, j int) bool { return result[i].UUID < result[j].UUID })
+	return result
+}
+
+func keyMappings(t *testing.T, repository Repository) []model.ApplicationKeyMapping {
+	t.Helper()
+	var result []model.ApplicationKeyMapping
+	mustTransact(t, repository, func(tx Tx) (err error) {
+		result, err = tx.GetAllApplicationKeyMappings()
+		return err
+	})
+	sort.Slice(result, func(i, j int) bool {
+		return string(keyMappingKey(result[i])) < string(keyMappingKey(result[j]))
+	})
+	return result
+}
+
+func testApplication(uuid string) model.Application {
+	return model.Application{UUID: uuid, Name: "app-" + uuid, Owner: "admin", OrganizationID: "default",
+		Attributes: map[string]string{"tier": "Unlimited"}}
+}
+
+func testSubscription(uuid string) model.Subscription {
+	return model.Subscription{UUID: uuid, SubscribedAPI: &model.SubscribedAPI{Name: "PizzaShack", Version: "1.0.0"},
+		SubStatus: "ACTIVE", Organization: "default", RatelimitTier: "Unlimited"}
+}
+
+func testKeyMapping(appUUID, keyType string) model.ApplicationKeyMapping {
+	return model.ApplicationKeyMapping{ApplicationUUID: appUUID, SecurityScheme: "OAuth2", ApplicationIdentifier: "client-" + keyType,
+		KeyType: keyType, EnvID: "Default", OrganizationID: "default"}
+}
+
+// seed adds the applications a1 and a2, the subscriptions s1 and s2, the mappings m1 of a1 to s1,
+// m2 of a2 to s1 and m3 of a1 to s2, and the production and sandbox key mappings of a1 and a2.
+func seed(t *testing.T, repository Repository) {
+	t.Helper()
+	mustTransact(t, repository, func(tx Tx) error {
+		for _, add := range []func() error{
+			func() error { return tx.AddApplication(testApplication("a1")) },
+			func() error { return tx.AddApplication(testApplication("a2")) },
+			func() error { return tx.AddSubscription(testSubscription("s1")) },
+			func() error { return tx.AddSubscription(testSubscription("s2")) },
+			func() error {
+				return tx.AddApplicationMapping(model.ApplicationMapping{UUID: "m1", ApplicationRef: "a1", SubscriptionRef: "s1"})
+			},
+			func() error {
+				return tx.AddApplicationMapping(model.ApplicationMapping{UUID: "m2", ApplicationRef: "a2", SubscriptionRef: "s1"})
+			},
+			func() error {
+				return tx.AddApplicationMapping(model.ApplicationMapping{UUID: "m3", ApplicationRef: "a1", SubscriptionRef: "s2"})
+			},
+			func() error { return tx.AddApplicationKeyMapping(testKeyMapping("a1", "PRODUCTION")) },
+			func() error { return tx.AddApplicationKeyMapping(testKeyMapping("a1", "SANDBOX")) },
+			func() error { return tx.AddApplicationKeyMapping(testKeyMapping("a2", "PRODUCTION")) },
+		} {
+			if err := add(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func mappingUUIDs(applicationMappings []model.ApplicationMapping) []string {
+	uuids := []string{}
+	for _, applicationMapping := range applicationMappings {
+		uuids = append(uuids, applicationMapping.UUID)
+	}
+	return uuids
+}
+
+func TestConformance_Applications(t *testing.T) {
+	runConformance(t, func(t *testing.T, _ conformanceTarget, repository Repository) {
+		noAttributes := model.Application{UUID: "a2", Name: "plain", Owner: "admin", OrganizationID: "default"}
+		mustTransact(t, repository, func(tx Tx) error {
+			if err := tx.AddApplication(testApplication("a1")); err != nil {
+				return err
+			}
+			return tx.AddApplication(noAttributes)
+		})
+		assert.Equal(t, []model.Application{testApplication("a1"), noAttributes}, applications(t, repository))
+
+		updated := model.Application{UUID: "a1", Name: "renamed", Owner: "alice", OrganizationID: "default",
+			Attributes: map[string]string{"owner-team": "pizza"}}
+		mustTransact(t, repository, func(tx Tx) error { return tx.UpdateApplication(updated) })
+		assert.Equal(t, []model.Application{updated, noAttributes}, applications(t, repository),
+			"the attributes are replaced")
+
+		mustTransact(t, repository, func(tx Tx) error { return tx.UpdateApplication(testApplication("missing")) })
+		assert.Len(t, applications(t, repository), 2, "updating a missing application does not add it")
+
+		err := transact(t, repository, func(tx Tx) error { return tx.AddApplication(testApplication("a1")) })
+		assert.True(t, errors.Is(err, ErrDuplicate), "got %v", err)
+
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteApplication("a1") })
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteApplication("missing") })
+		assert.Equal(t, []model.Application{noAttributes}, applications(t, repository))
+	})
+}
+
+func TestConformance_Subscriptions(t *testing.T) {
+	runConformance(t, func(t *testing.T, _ conformanceTarget, repository Repository) {
+		mustTransact(t, repository, func(tx Tx) error {
+			if err := tx.AddSubscription(testSubscription("s1")); err != nil {
+				return err
+			}
+			return tx.AddSubscription(model.Subscription{UUID: "s2", SubStatus: "BLOCKED"})
+		})
+		assert.Equal(t, []model.Subscription{
+			testSubscription("s1"),
+			{UUID: "s2", SubStatus: "BLOCKED", SubscribedAPI: &model.SubscribedAPI{}},
+		}, subscriptions(t, repository))
+
+		updated := testSubscription("s1")
+		updated.SubStatus = "BLOCKED"
+		updated.SubscribedAPI.Version = "2.0.0"
+		mustTransact(t, repository, func(tx Tx) error { return tx.UpdateSubscription(updated) })
+		mustTransact(t, repository, func(tx Tx) error { return tx.UpdateSubscription(testSubscription("missing")) })
+		assert.Equal(t, updated, subscriptions(t, repository)[0])
+		assert.Len(t, subscriptions(t, repository), 2)
+
+		err := transact(t, repository, func(tx Tx) error { return tx.AddSubscription(testSubscription("s1")) })
+		assert.True(t, errors.Is(err, ErrDuplicate), "got %v", err)
+
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteSubscription("s1") })
+		assert.Len(t, subscriptions(t, repository), 1)
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteAllSubscriptions() })
+		assert.Empty(t, subscriptions(t, repository))
+	})
+}
+
+func TestConformance_ApplicationMappings(t *testing.T) {
+	runConformance(t, func(t *testing.T, _ conformanceTarget, repository Repository) {
+		seed(t, repository)
+		assert.Equal(t, []string{"m1", "m2", "m3"}, mappingUUIDs(applicationMappings(t, repository)))
+
+		for name, applicationMapping := range map[string]model.ApplicationMapping{
+			"missing application":  {UUID: "m4", ApplicationRef: "missing", SubscriptionRef: "s1"},
+			"missing subscription": {UUID: "m4", ApplicationRef: "a2", SubscriptionRef: "missing"},
+		} {
+			err := transact(t, repository, func(tx Tx) error { return tx.AddApplicationMapping(applicationMapping) })
+			assert.True(t, errors.Is(err, ErrMissingReference), "%s: got %v", name, err)
+		}
+		for name, applicationMapping := range map[string]model.ApplicationMapping{
+			"same uuid":                         {UUID: "m1", ApplicationRef: "a2", SubscriptionRef: "s2"},
+			"same application and subscription": {UUID: "m4", ApplicationRef: "a1", SubscriptionRef: "s1"},
+		} {
+			err := transact(t, repository, func(tx Tx) error { return tx.AddApplicationMapping(applicationMapping) })
+			assert.True(t, errors.Is(err, ErrDuplicate), "%s: got %v", name, err)
+		}
+
+		updated := model.ApplicationMapping{UUID: "m2", ApplicationRef: "a2", SubscriptionRef: "s2", OrganizationID: "default"}
+		mustTransact(t, repository, func(tx Tx) error { return tx.UpdateApplicationMapping(updated) })
+		assert.Equal(t, updated, applicationMappings(t, repository)[1])
+		err := transact(t, repository, func(tx Tx) error {
+			return tx.UpdateApplicationMapping(model.ApplicationMapping{UUID: "m2", ApplicationRef: "missing", SubscriptionRef: "s2"})
+		})
+		assert.True(t, errors.Is(err, ErrMissingReference), "got %v", err)
+
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteApplicationMapping("m1") })
+		assert.Equal(t, []string{"m2", "m3"}, mappingUUIDs(applicationMappings(t, repository)))
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteAllApplicationMappings() })
+		assert.Empty(t, applicationMappings(t, repository))
+		assert.Len(t, applications(t, repository), 2)
+		assert.Len(t, subscriptions(t, repository), 2)
+	})
+}
+
+func TestConformance_KeyMappings(t *testing.T) {
+	runConformance(t, func(t *testing.T, _ conformanceTarget, repository Repository) {
+		seed(t, repository)
+		assert.Equal(t, []model.ApplicationKeyMapping{
+			testKeyMapping("a1", "PRODUCTION"), testKeyMapping("a1", "SANDBOX"), testKeyMapping("a2", "PRODUCTION"),
+		}, keyMappings(t, repository))
+
+		updated := testKeyMapping("a1", "SANDBOX")
+		updated.ApplicationIdentifier = "rotated"
+		mustTransact(t, repository, func(tx Tx) error { return tx.UpdateApplicationKeyMapping(updated) })
+		assert.Equal(t, []model.ApplicationKeyMapping{
+			testKeyMapping("a1", "PRODUCTION"), updated, testKeyMapping("a2", "PRODUCTION"),
+		}, keyMappings(t, repository), "only the key mapping of the key type is updated")
+
+		err := transact(t, repository, func(tx Tx) error { return tx.AddApplicationKeyMapping(testKeyMapping("a1", "PRODUCTION")) })
+		assert.True(t, errors.Is(err, ErrDuplicate), "got %v", err)
+		err = transact(t, repository, func(tx Tx) error { return tx.AddApplicationKeyMapping(testKeyMapping("missing", "PRODUCTION")) })
+		assert.True(t, errors.Is(err, ErrMissingReference), "got %v", err)
+
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteApplicationKeyMapping(testKeyMapping("a1", "PRODUCTION")) })
+		assert.Equal(t, []model.ApplicationKeyMapping{updated, testKeyMapping("a2", "PRODUCTION")}, keyMappings(t, repository))
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteAllApplicationKeyMappings() })
+		assert.Empty(t, keyMappings(t, repository))
+	})
+}
+
+func TestConformance_Cascades(t *testing.T) {
+	runConformance(t, func(t *testing.T, _ conformanceTarget, repository Repository) {
+		seed(t, repository)
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteApplication("a1") })
+		assert.Equal(t, []string{"m2"}, mappingUUIDs(applicationMappings(t, repository)))
+		assert.Equal(t, []model.ApplicationKeyMapping{testKeyMapping("a2", "PRODUCTION")}, keyMappings(t, repository))
+
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteSubscription("s1") })
+		assert.Empty(t, applicationMappings(t, repository))
+		assert.Len(t, applications(t, repository), 1)
+
+		seed2 := func(tx Tx) error {
+			if err := tx.AddApplication(testApplication("a1")); err != nil {
+				return err
+			}
+			if err := tx.AddSubscription(testSubscription("s1")); err != nil {
+				return err
+			}
+			return tx.AddApplicationMapping(model.ApplicationMapping{UUID: "m1", ApplicationRef: "a1", SubscriptionRef: "s1"})
+		}
+		mustTransact(t, repository, seed2)
+		mustTransact(t, repository, func(tx Tx) error { return tx.DeleteAllApplications() })
+		assert.Empty(t, applications(t, repository))
+		assert.Empty(t, applicationMappings(t, repository))
+		assert.Empty(t, keyMappings(t, repository))
+		assert.Len(t, subscriptions(t, repository), 2, "deleting the applications keeps the subscriptions")
+	})
+}
+
+func TestConformance_Rollback(t *testing.T) {
+	runConformance(t, func(t *testing.T, _ conformanceTarget, repository Repository) {
+		errAbort := errors.New("abort")
+		err := transact(t, repository, func(tx Tx) error {
+			if err := tx.AddApplication(testApplication("a1")); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		assert.ErrorIs(t, err, errAbort)
+		assert.Empty(t, applications(t, repository))
+
+		err = transact(t, repository, func(tx Tx) error {
+			if err := tx.AddSubscription(testSubscription("s1")); err != nil {
+				return err
+			}
+			return tx.AddApplicationMapping(model.ApplicationMapping{UUID: "m1", ApplicationRef: "missing", SubscriptionRef: "s1"})
+		})
+		assert.True(t, errors.Is(err, ErrMissingReference), "got %v", err)
+		assert.Empty(t, subscriptions(t, repository), "the writes before the rejected one are rolled back")
+	})
+}
+
+func TestConformance_Reopen(t *testing.T) {
+	runConformance(t, func(t *testing.T, target conformanceTarget, repository Repository) {
+		seed(t, repository)
+		require.NoError(t, repository.Ping(context.Background()))
+		require.NoError(t, repository.Close())
+
+		reopened := target.open(t)
+		defer reopened.Close()
+		assert.Len(t, applications(t, reopened), 2)
+		assert.Len(t, subscriptions(t, reopened), 2)
+		assert.Len(t, applicationMappings(t, reopened), 3)
+		assert.Len(t, keyMappings(t, reopened), 3)
+	})
+}
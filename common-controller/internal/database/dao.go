@@ -18,70 +18,98 @@
 package database
 
 import (
-	"github.com/jackc/pgx/v5"
-	"github.com/wso2/apk/common-controller/internal/loggers"
+	"context"
+	"database/sql"
+
 	"github.com/wso2/apk/common-go-libs/pkg/server/model"
 )
 
-// deployApplicationAttributes deploys application attributes
-func deployApplicationwithAttributes(tx pgx.Tx, application model.Application) error {
-	PrepareQueries(tx, insertApplication, insertApplicationAttributes)
-	err := AddApplication(tx, application.UUID, application.Name, application.Owner, application.OrganizationID)
-	if err != nil {
-		loggers.LoggerAPI.Error("Error while adding application ", err)
+// sqlTx is the Tx of the SQL databases.
+type sqlTx struct {
+	ctx     context.Context
+	tx      *sql.Tx
+	dialect dialect
+}
+
+// exec executes a query
+func (t *sqlTx) exec(query string, args ...interface{}) error {
+	_, err := t.tx.ExecContext(t.ctx, t.dialect.rebind(query), args...)
+	return t.dialect.wrap(err)
+}
+
+// query executes a query and returns the rows
+func (t *sqlTx) query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(t.ctx, t.dialect.rebind(query), args...)
+}
+
+// AddApplication adds an application with its attributes
+func (t *sqlTx) AddApplication(application model.Application) error {
+	if err := t.exec(insertApplication, application.UUID, application.Name, application.Owner, application.OrganizationID); err != nil {
 		return err
 	}
-	for attributeKey, attributeValue := range application.Attributes {
-		err = AddApplicationAttributes(tx, application.UUID, attributeKey, attributeValue)
-		if err != nil {
-			loggers.LoggerAPI.Error("Error while adding application attributes ", err)
-			return err
-		}
-	}
-	return nil
+	return t.addApplicationAttributes(application)
 }
 
-func updateApplicationAttributes(tx pgx.Tx, application model.Application) error {
-	PrepareQueries(tx, insertApplicationAttributes, deleteAllAppAttributes)
-	err := DeleteApplicationAttributes(tx, application.UUID)
-	if err != nil {
-		loggers.LoggerAPI.Error("Error while deleting application attributes ", err)
+// UpdateApplication updates an application and replaces its attributes
+func (t *sqlTx) UpdateApplication(application model.Application) error {
+	var count int
+	if err := t.tx.QueryRowContext(t.ctx, t.dialect.rebind(countApplication), application.UUID).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	if err := t.exec(updateApplication, application.Name, application.Owner, application.OrganizationID, application.UUID); err != nil {
+		return err
+	}
+	if err := t.exec(deleteApplicationAttributes, application.UUID); err != nil {
 		return err
 	}
-	for attributeKey, attributeValue := range application.Attributes {
-		err = AddApplicationAttributes(tx, application.UUID, attributeKey, attributeValue)
-		if err != nil {
-			loggers.LoggerAPI.Error("Error while adding application attributes ", err)
+	return t.addApplicationAttributes(application)
+}
+
+func (t *sqlTx) addApplicationAttributes(application model.Application) error {
+	for name, value := range application.Attributes {
+		if err := t.exec(insertApplicationAttributes, application.UUID, name, value); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// GetAllApplications gets all applications from the database
-func GetAllApplications(tx pgx.Tx) ([]model.Application, error) {
-	rows, err := ExecDBQueryRows(tx, getAllApplicationAttributes)
+// DeleteApplication deletes an application, its attributes and mappings
+func (t *sqlTx) DeleteApplication(uuid string) error {
+	return t.exec(deleteApplication, uuid)
+}
+
+// DeleteAllApplications deletes all applications, their attributes and mappings
+func (t *sqlTx) DeleteAllApplications() error {
+	return t.exec(deleteAllApplications)
+}
+
+// GetAllApplications gets all applications with their attributes
+func (t *sqlTx) GetAllApplications() ([]model.Application, error) {
+	rows, err := t.query(getAllApplicationAttributes)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-
 	appAttributes := make(map[string]map[string]string)
 	for rows.Next() {
-		var uuid string
-		var name string
-		var attrib string
-		err := rows.Scan(&uuid, &name, &attrib)
-		if err != nil {
+		var uuid, name, attribute string
+		if err := rows.Scan(&uuid, &name, &attribute); err != nil {
 			return nil, err
 		}
 		if _, ok := appAttributes[uuid]; !ok {
 			appAttributes[uuid] = map[string]string{}
 		}
-		appAttributes[uuid][name] = attrib
+		appAttributes[uuid][name] = attribute
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	rows, err = ExecDBQueryRows(tx, getAllApplications)
+	rows, err = t.query(getAllApplications)
 	if err != nil {
 		return nil, err
 	}
@@ -89,19 +117,42 @@ func GetAllApplications(tx pgx.Tx) ([]model.Application, error) {
 	var applications []model.Application
 	for rows.Next() {
 		var app model.Application
-		err := rows.Scan(&app.UUID, &app.Name, &app.Owner, &app.OrganizationID)
-		if err != nil {
+		if err := rows.Scan(&app.UUID, &app.Name, &app.Owner, &app.OrganizationID); err != nil {
 			return nil, err
 		}
 		app.Attributes = appAttributes[app.UUID]
 		applications = append(applications, app)
 	}
-	return applications, nil
+	return applications, rows.Err()
+}
+
+// AddSubscription adds a subscription
+func (t *sqlTx) AddSubscription(subscription model.Subscription) error {
+	apiName, apiVersion := subscribedAPI(subscription)
+	return t.exec(insertSubscription, subscription.UUID, apiName, apiVersion, subscription.SubStatus, subscription.Organization,
+		subscription.RatelimitTier)
+}
+
+// UpdateSubscription updates a subscription
+func (t *sqlTx) UpdateSubscription(subscription model.Subscription) error {
+	apiName, apiVersion := subscribedAPI(subscription)
+	return t.exec(updateSubscription, apiName, apiVersion, subscription.SubStatus, subscription.Organization,
+		subscription.RatelimitTier, subscription.UUID)
+}
+
+// DeleteSubscription deletes a subscription and its application mappings
+func (t *sqlTx) DeleteSubscription(uuid string) error {
+	return t.exec(deleteSubscription, uuid)
+}
+
+// DeleteAllSubscriptions deletes all subscriptions and the application mappings
+func (t *sqlTx) DeleteAllSubscriptions() error {
+	return t.exec(deleteAllSubscriptions)
 }
 
-// GetAllSubscription gets all subscriptions from the database
-func GetAllSubscription(tx pgx.Tx) ([]model.Subscription, error) {
-	rows, err := ExecDBQueryRows(tx, getAllSubscriptions)
+// GetAllSubscriptions gets all subscriptions
+func (t *sqlTx) GetAllSubscriptions() ([]model.Subscription, error) {
+	rows, err := t.query(getAllSubscriptions)
 	if err != nil {
 		return nil, err
 	}
@@ -111,39 +162,40 @@ func GetAllSubscription(tx pgx.Tx) ([]model.Subscription, error) {
 		sub := model.Subscription{
 			SubscribedAPI: &model.SubscribedAPI{},
 		}
-
-		err := rows.Scan(&sub.UUID, &sub.SubscribedAPI.Name, &sub.SubscribedAPI.Version, &sub.SubStatus, &sub.Organization, &sub.RatelimitTier)
-		if err != nil {
+		if err := rows.Scan(&sub.UUID, &sub.SubscribedAPI.Name, &sub.SubscribedAPI.Version, &sub.SubStatus, &sub.Organization,
+			&sub.RatelimitTier); err != nil {
 			return nil, err
 		}
 		subscriptions = append(subscriptions, sub)
 	}
-	return subscriptions, nil
+	return subscriptions, rows.Err()
 }
 
-// GetAllApplicationKeyMappings gets all application key mappings from the database
-func GetAllApplicationKeyMappings(tx pgx.Tx) ([]model.ApplicationKeyMapping, error) {
-	rows, err := ExecDBQueryRows(tx, getAllApplicationKeyMappings)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var appKeyMappings []model.ApplicationKeyMapping
-	for rows.Next() {
-		var appKeyMapping model.ApplicationKeyMapping
-		err := rows.Scan(&appKeyMapping.ApplicationUUID, &appKeyMapping.SecurityScheme, &appKeyMapping.ApplicationIdentifier,
-			&appKeyMapping.KeyType, &appKeyMapping.EnvID, &appKeyMapping.OrganizationID)
-		if err != nil {
-			return nil, err
-		}
-		appKeyMappings = append(appKeyMappings, appKeyMapping)
-	}
-	return appKeyMappings, nil
+// AddApplicationMapping adds an application subscription mapping
+func (t *sqlTx) AddApplicationMapping(applicationMapping model.ApplicationMapping) error {
+	return t.exec(insertAppSub, applicationMapping.UUID, applicationMapping.ApplicationRef, applicationMapping.SubscriptionRef,
+		applicationMapping.OrganizationID)
+}
+
+// UpdateApplicationMapping updates an application subscription mapping
+func (t *sqlTx) UpdateApplicationMapping(applicationMapping model.ApplicationMapping) error {
+	return t.exec(updateAppSub, applicationMapping.ApplicationRef, applicationMapping.SubscriptionRef,
+		applicationMapping.OrganizationID, applicationMapping.UUID)
+}
+
+// DeleteApplicationMapping deletes an application subscription mapping
+func (t *sqlTx) DeleteApplicationMapping(uuid string) error {
+	return t.exec(deleteAppSub, uuid)
+}
+
+// DeleteAllApplicationMappings deletes all application subscription mappings
+func (t *sqlTx) DeleteAllApplicationMappings() error {
+	return t.exec(deleteAllAppSub)
 }
 
-// GetAllAppSubs gets all application subscription mappings from the database
-func GetAllAppSubs(tx pgx.Tx) ([]model.ApplicationMapping, error) {
-	rows, err := ExecDBQueryRows(tx, getAllAppSubs)
+// GetAllApplicationMappings gets all application subscription mappings
+func (t *sqlTx) GetAllApplicationMappings() ([]model.ApplicationMapping, error) {
+	rows, err := t.query(getAllAppSubs)
 	if err != nil {
 		return nil, err
 	}
@@ -151,110 +203,60 @@ func GetAllAppSubs(tx pgx.Tx) ([]model.ApplicationMapping, error) {
 	var appSubs []model.ApplicationMapping
 	for rows.Next() {
 		var appSub model.ApplicationMapping
-		err := rows.Scan(&appSub.UUID, &appSub.ApplicationRef, &appSub.SubscriptionRef, &appSub.OrganizationID)
-		if err != nil {
+		if err := rows.Scan(&appSub.UUID, &appSub.ApplicationRef, &appSub.SubscriptionRef, &appSub.OrganizationID); err != nil {
 			return nil, err
 		}
 		appSubs = append(appSubs, appSub)
 	}
-	return appSubs, nil
-}
-
-// AddApplication adds an application to the database
-func AddApplication(tx pgx.Tx, uuid, name, owner, org string) error {
-	return ExecDBQuery(tx, insertApplication, uuid, name, owner, org)
-}
-
-// UpdateApplication updates an application in the database
-func UpdateApplication(tx pgx.Tx, uuid, name, owner, org string) error {
-	return ExecDBQuery(tx, updateApplication, uuid, name, owner, org)
-}
-
-// DeleteApplication deletes an application from the database
-func DeleteApplication(tx pgx.Tx, uuid string) error {
-	return ExecDBQuery(tx, deleteApplication, uuid)
-}
-
-// DeleteAllApplications deletes all applications from the database
-func DeleteAllApplications(tx pgx.Tx) error {
-	return ExecDBQuery(tx, deleteAllApplications)
-}
-
-// AddApplicationAttributes adds attributes to an application in the database
-func AddApplicationAttributes(tx pgx.Tx, appUUID, name, appAttribute string) error {
-	return ExecDBQuery(tx, insertApplicationAttributes, appUUID, name, appAttribute)
-}
-
-// DeleteApplicationAttributes deletes attributes of an application from the database
-func DeleteApplicationAttributes(tx pgx.Tx, appUUID string) error {
-	return ExecDBQuery(tx, deleteApplicationAttributes, appUUID)
-}
-
-// DeleteAllAppAttributes deletes all attributes of all applications from the database
-func DeleteAllAppAttributes(tx pgx.Tx) error {
-	return ExecDBQuery(tx, deleteAllAppAttributes)
-}
-
-// AddSubscription adds a subscription to the database
-func AddSubscription(tx pgx.Tx, uuid, apiName, apiVersion, subStatus, organization string, rateLimitTier string) error {
-	return ExecDBQuery(tx, insertSubscription, uuid, apiName, apiVersion, subStatus, organization, rateLimitTier)
+	return appSubs, rows.Err()
 }
 
-// UpdateSubscription updates a subscription in the database
-func UpdateSubscription(tx pgx.Tx, uuid, apiName, apiVersion, subStatus, organization string, rateLimitTier string) error {
-	return ExecDBQuery(tx, updateSubscription, uuid, apiName, apiVersion, subStatus, organization, rateLimitTier)
+// AddApplicationKeyMapping adds an application key mapping
+func (t *sqlTx) AddApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error {
+	return t.exec(insertApplicationKeyMapping, keyMapping.ApplicationUUID, keyMapping.SecurityScheme,
+		keyMapping.ApplicationIdentifier, keyMapping.KeyType, keyMapping.EnvID, keyMapping.OrganizationID)
 }
 
-// DeleteSubscription deletes a subscription from the database
-func DeleteSubscription(tx pgx.Tx, uuid string) error {
-	return ExecDBQuery(tx, deleteSubscription, uuid)
+// UpdateApplicationKeyMapping updates an application key mapping
+func (t *sqlTx) UpdateApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error {
+	return t.exec(updateApplicationKeyMapping, keyMapping.ApplicationIdentifier, keyMapping.OrganizationID,
+		keyMapping.ApplicationUUID, keyMapping.SecurityScheme, keyMapping.KeyType, keyMapping.EnvID)
 }
 
-// DeleteAllSubscriptions deletes all subscriptions from the database
-func DeleteAllSubscriptions(tx pgx.Tx) error {
-	return ExecDBQuery(tx, deleteAllSubscriptions)
+// DeleteApplicationKeyMapping deletes an application key mapping
+func (t *sqlTx) DeleteApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error {
+	return t.exec(deleteApplicationKeyMapping, keyMapping.ApplicationUUID, keyMapping.SecurityScheme, keyMapping.KeyType,
+		keyMapping.EnvID)
 }
 
-// AddApplicationKeyMapping adds a key mapping to the database
-func AddApplicationKeyMapping(tx pgx.Tx, applicationUUID, securityScheme, applicationIdentifier, keyType, env,
-	organization string) error {
-	return ExecDBQuery(tx, insertApplicationKeyMapping, applicationUUID, securityScheme, applicationIdentifier, keyType,
-		env, organization)
+// DeleteAllApplicationKeyMappings deletes all application key mappings
+func (t *sqlTx) DeleteAllApplicationKeyMappings() error {
+	return t.exec(deleteAllApplicationKeyMappings)
 }
 
-// DeleteApplicationKeyMapping deletes a key mapping from the database
-func DeleteApplicationKeyMapping(tx pgx.Tx, applicationUUID, securityScheme, env string) error {
-	return ExecDBQuery(tx, deleteApplicationKeyMapping, applicationUUID, securityScheme, env)
-}
-
-// UpdateApplicationKeyMapping updates a key mapping in the database
-func UpdateApplicationKeyMapping(tx pgx.Tx, applicationUUID, securityScheme, applicationIdentifier, keyType, env,
-	organization string) error {
-	return ExecDBQuery(tx, updateApplicationKeyMapping, applicationUUID, securityScheme, applicationIdentifier, keyType,
-		env, organization)
-}
-
-// DeleteAllApplicationKeyMappings deletes all key mappings from the database
-func DeleteAllApplicationKeyMappings(tx pgx.Tx) error {
-	return ExecDBQuery(tx, deleteAllApplicationKeyMappings)
-}
-
-// AddAppSub adds an application subscription mapping to the database
-func AddAppSub(tx pgx.Tx, uuid, appUUID, subUUID, organization string) error {
-	return ExecDBQuery(tx, insertAppSub, uuid, appUUID, subUUID, organization)
-}
-
-// UpdateAppSub updates an application subscription mapping in the database
-func UpdateAppSub(tx pgx.Tx, uuid, appUUID, subUUID, organization string) error {
-	return ExecDBQuery(tx, updateAppSub, uuid, appUUID, subUUID, organization)
-}
-
-// DeleteAppSub deletes an application subscription mapping from the database
-func DeleteAppSub(tx pgx.Tx, uuid string) error {
-	return ExecDBQuery(tx, deleteAppSub, uuid)
+// GetAllApplicationKeyMappings gets all application key mappings
+func (t *sqlTx) GetAllApplicationKeyMappings() ([]model.ApplicationKeyMapping, error) {
+	rows, err := t.query(getAllApplicationKeyMappings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var appKeyMappings []model.ApplicationKeyMapping
+	for rows.Next() {
+		var appKeyMapping model.ApplicationKeyMapping
+		if err := rows.Scan(&appKeyMapping.ApplicationUUID, &appKeyMapping.SecurityScheme, &appKeyMapping.ApplicationIdentifier,
+			&appKeyMapping.KeyType, &appKeyMapping.EnvID, &appKeyMapping.OrganizationID); err != nil {
+			return nil, err
+		}
+		appKeyMappings = append(appKeyMappings, appKeyMapping)
+	}
+	return appKeyMappings, rows.Err()
 }
 
-// DeleteAllAppSub deletes all application subscription mappings from the database
-func DeleteAllAppSub(tx pgx.Tx) error {
-	return ExecDBQuery(tx, deleteAllAppSub)
+// subscribedAPI returns the name and version of the API of the subscription.
+func subscribedAPI(subscription model.Subscription) (string, string) {
+	if subscription.SubscribedAPI == nil {
+		return "", ""
+	}
+	return subscription.SubscribedAPI.Name, subscription.SubscribedAPI.Version
 }
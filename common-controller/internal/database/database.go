@@ -19,37 +19,23 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"sync"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/wso2/apk/adapter/pkg/logging"
 	"github.com/wso2/apk/common-controller/internal/config"
 	"github.com/wso2/apk/common-controller/internal/loggers"
 )
 
-var dbPool *pgxpool.Pool
+var (
+	repository     Repository
+	repositoryLock sync.Mutex
+)
 
-// ConnectToDB connects to the database
+// ConnectToDB opens the repository of the configured persistence type and migrates its schema
 func ConnectToDB() {
-	conf := config.ReadConfigs()
-	var err error
-	connString := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?pool_max_conns=%d&pool_min_conns=%d&"+
-		"pool_max_conn_lifetime=%s&pool_max_conn_idle_time=%s&pool_health_check_period=%s&pool_max_conn_lifetime_jitter=%s",
-		conf.CommonController.Database.Username,
-		conf.CommonController.Database.Password,
-		conf.CommonController.Database.Host,
-		conf.CommonController.Database.Port,
-		conf.CommonController.Database.Name,
-		conf.CommonController.Database.PoolOptions.PoolMaxConns,
-		conf.CommonController.Database.PoolOptions.PoolMinConns,
-		conf.CommonController.Database.PoolOptions.PoolMaxConnLifetime,
-		conf.CommonController.Database.PoolOptions.PoolMaxConnIdleTime,
-		conf.CommonController.Database.PoolOptions.PoolHealthCheckPeriod,
-		conf.CommonController.Database.PoolOptions.PoolMaxConnLifetimeJitter)
-	dbPool, err = pgxpool.New(context.Background(), connString)
-	if err != nil {
+	if _, err := getRepository(); err != nil {
 		loggers.LoggerDatabase.ErrorC(logging.ErrorDetails{
 			Message:   fmt.Sprintf("Unable to connect to database: %v", err.Error()),
 			Severity:  logging.CRITICAL,
@@ -58,72 +44,62 @@ func ConnectToDB() {
 	}
 }
 
-// ExecDBQuery executes a database query
-func ExecDBQuery(tx pgx.Tx, query string, args ...interface{}) error {
-	_, err := tx.Exec(context.Background(), query, args...)
-	return err
-}
-
-// ExecDBQueryRows executes a database query and returns a row
-func ExecDBQueryRows(tx pgx.Tx, query string, args ...interface{}) (pgx.Rows, error) {
-	return tx.Query(context.Background(), query, args...)
+// getRepository returns the repository, opening it if it is not open yet
+func getRepository() (Repository, error) {
+	repositoryLock.Lock()
+	defer repositoryLock.Unlock()
+	if repository != nil {
+		return repository, nil
+	}
+	opened, err := Open(config.ReadConfigs())
+	if err != nil {
+		return nil, err
+	}
+	repository = opened
+	return repository, nil
 }
 
 // IsAliveConn checks if the database connection is alive
 func IsAliveConn(ctx context.Context) (isAlive bool) {
-	err := dbPool.Ping(ctx)
-	return err == nil
+	repositoryLock.Lock()
+	defer repositoryLock.Unlock()
+	return repository != nil && repository.Ping(ctx) == nil
 }
 
 // CloseDBConn closes the database connection
 func CloseDBConn() {
-	conf := config.ReadConfigs()
-	if conf.CommonController.Database.Enabled {
-		dbPool.Close()
+	repositoryLock.Lock()
+	defer repositoryLock.Unlock()
+	if repository == nil {
+		return
 	}
-}
-
-// PrepareQueries prepares the queries
-func PrepareQueries(tx pgx.Tx, queries ...string) {
-	for _, query := range queries {
-		_, err := tx.Prepare(context.Background(), query, query)
-		if err != nil {
-			loggers.LoggerAPI.Errorf("Error while preparing query: %s, %s", query, err.Error())
-		}
+	if err := repository.Close(); err != nil {
+		loggers.LoggerAPI.Error("Error while closing the database connection ", err)
 	}
+	repository = nil
 }
 
 // performTransaction performs a transaction
-func performTransaction(fn func(tx pgx.Tx) error) error {
-	ConnectToDB()
-	con := context.Background()
-	tx, err := dbPool.BeginTx(con, pgx.TxOptions{})
+func performTransaction(fn func(tx Tx) error) error {
+	store, err := getRepository()
 	if err != nil {
-		return fmt.Errorf("error while begining the transaction %v", err)
+		return fmt.Errorf("error while connecting to the database: %w", err)
 	}
-	defer func() {
-		if err != nil {
-			loggers.LoggerAPI.Error("Rollback due to error: ", err)
-			err = tx.Rollback(con)
-		} else {
-			err = tx.Commit(con)
-		}
-		if err != nil {
-			loggers.LoggerAPI.Error("Error while commiting the transaction ", err)
-		}
-	}()
-	err = fn(tx)
-	return err
+	if err := store.Transact(context.Background(), fn); err != nil {
+		loggers.LoggerAPI.Error("Rollback due to error: ", err)
+		return err
+	}
+	return nil
 }
 
-// retryTransaction retries a transaction
-func retryUntilTransaction(fn func(tx pgx.Tx) error) error {
+// retryUntilTransaction performs a transaction, retrying it once unless the artifacts were rejected
+// by the store, as those would be rejected again
+func retryUntilTransaction(fn func(tx Tx) error) error {
 	if err := performTransaction(fn); err != nil {
-		loggers.LoggerAPI.Warn("Retrying because of the error: ", err)
-		if strings.Contains(err.Error(), "conn closed") {
-			loggers.LoggerAPI.Info("Reconnecting to DB...")
-			ConnectToDB()
+		if errors.Is(err, ErrDuplicate) || errors.Is(err, ErrMissingReference) {
+			return err
 		}
+		loggers.LoggerAPI.Warn("Retrying because of the error: ", err)
 		return performTransaction(fn)
 	}
 	return nil
@@ -17,7 +17,6 @@
 package database
 
 import (
-	"github.com/jackc/pgx/v5"
 	"github.com/wso2/apk/common-controller/internal/loggers"
 	"github.com/wso2/apk/common-controller/internal/server"
 	"github.com/wso2/apk/common-controller/internal/utils"
@@ -40,11 +39,8 @@ func NewDBArtifactDeployer(mgr manager.Manager) DBDeployer {
 
 // populateMapFromDB populates the map from the database
 func populateMapFromDB() error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, getAllApplications, getAllApplicationAttributes, getAllSubscriptions, getAllApplicationKeyMappings,
-			getAllAppSubs)
-
-		applications, err := GetAllApplications(tx)
+	retryUntilTransaction(func(tx Tx) error {
+		applications, err := tx.GetAllApplications()
 		if err != nil {
 			loggers.LoggerAPI.Error("Error while getting all applications ", err)
 			return err
@@ -53,7 +49,7 @@ func populateMapFromDB() error {
 			server.AddApplication(app)
 		}
 
-		subscriptions, err := GetAllSubscription(tx)
+		subscriptions, err := tx.GetAllSubscriptions()
 		if err != nil {
 			loggers.LoggerAPI.Error("Error while getting all subscriptions ", err)
 			return err
@@ -62,7 +58,7 @@ func populateMapFromDB() error {
 			server.AddSubscription(subscription)
 		}
 
-		appSubs, err := GetAllAppSubs(tx)
+		appSubs, err := tx.GetAllApplicationMappings()
 		if err != nil {
 			loggers.LoggerAPI.Error("Error while getting all app subs ", err)
 			return err
@@ -70,7 +66,7 @@ func populateMapFromDB() error {
 		for _, appSub := range appSubs {
 			server.AddApplicationMapping(appSub)
 		}
-		appKeyMappings, err := GetAllApplicationKeyMappings(tx)
+		appKeyMappings, err := tx.GetAllApplicationKeyMappings()
 		if err != nil {
 			loggers.LoggerAPI.Error("Error while getting all app key mappings ", err)
 			return err
@@ -85,8 +81,8 @@ func populateMapFromDB() error {
 
 // DeployApplication deploys an application
 func (dbDeployer DBDeployer) DeployApplication(application model.Application) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		return deployApplicationwithAttributes(tx, application)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.AddApplication(application)
 	})
 	server.AddApplication(application)
 	utils.SendApplicationEvent(constants.ApplicationCreated, application.UUID, application.Name, application.Owner,
@@ -96,13 +92,8 @@ func (dbDeployer DBDeployer) DeployApplication(application model.Application) er
 
 // UpdateApplication updates an application
 func (dbDeployer DBDeployer) UpdateApplication(application model.Application) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, updateApplication, insertApplicationAttributes, deleteAllAppAttributes)
-		if err := UpdateApplication(tx, application.UUID, application.Name, application.Owner, application.OrganizationID); err != nil {
-			loggers.LoggerAPI.Error("Error while updating application ", err)
-			return err
-		}
-		return updateApplicationAttributes(tx, application)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.UpdateApplication(application)
 	})
 	server.DeleteApplication(application.UUID)
 	server.AddApplication(application)
@@ -113,10 +104,8 @@ func (dbDeployer DBDeployer) UpdateApplication(application model.Application) er
 
 // DeploySubscription deploys a subscription
 func (dbDeployer DBDeployer) DeploySubscription(subscription model.Subscription) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, insertSubscription)
-		return AddSubscription(tx, subscription.UUID, subscription.SubscribedAPI.Name, subscription.SubscribedAPI.Version,
-			subscription.SubStatus, subscription.Organization, subscription.RatelimitTier)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.AddSubscription(subscription)
 	})
 	server.AddSubscription(subscription)
 	utils.SendSubscriptionEvent(constants.SubscriptionCreated, subscription.UUID, subscription.SubStatus, subscription.Organization,
@@ -126,10 +115,8 @@ func (dbDeployer DBDeployer) DeploySubscription(subscription model.Subscription)
 
 // UpdateSubscription updates a subscription
 func (dbDeployer DBDeployer) UpdateSubscription(subscription model.Subscription) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, updateSubscription)
-		return UpdateSubscription(tx, subscription.UUID, subscription.SubscribedAPI.Name, subscription.SubscribedAPI.Version,
-			subscription.SubStatus, subscription.Organization, subscription.RatelimitTier)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.UpdateSubscription(subscription)
 	})
 	server.DeleteSubscription(subscription.UUID)
 	server.AddSubscription(subscription)
@@ -140,10 +127,8 @@ func (dbDeployer DBDeployer) UpdateSubscription(subscription model.Subscription)
 
 // DeployApplicationMappings deploys an application mapping
 func (dbDeployer DBDeployer) DeployApplicationMappings(applicationMapping model.ApplicationMapping) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, insertAppSub)
-		return AddAppSub(tx, applicationMapping.UUID, applicationMapping.ApplicationRef, applicationMapping.SubscriptionRef,
-			applicationMapping.OrganizationID)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.AddApplicationMapping(applicationMapping)
 	})
 	server.AddApplicationMapping(applicationMapping)
 	utils.SendApplicationMappingEvent(constants.ApplicationMappingCreated, applicationMapping.UUID, applicationMapping.ApplicationRef,
@@ -153,11 +138,9 @@ func (dbDeployer DBDeployer) DeployApplicationMappings(applicationMapping model.
 
 // DeployKeyMappings deploys a key mapping
 func (dbDeployer DBDeployer) DeployKeyMappings(keyMapping model.ApplicationKeyMapping) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, insertApplicationKeyMapping)
+	retryUntilTransaction(func(tx Tx) error {
 		if keyMapping.SecurityScheme == constants.OAuth2 {
-			if err := AddApplicationKeyMapping(tx, keyMapping.ApplicationUUID, constants.OAuth2, keyMapping.ApplicationIdentifier,
-				keyMapping.KeyType, keyMapping.EnvID, keyMapping.OrganizationID); err != nil {
+			if err := tx.AddApplicationKeyMapping(keyMapping); err != nil {
 				loggers.LoggerAPI.Error("Error while adding application key mapping ", err)
 				return err
 			}
@@ -188,13 +171,8 @@ func (dbDeployer DBDeployer) DeleteAllSubscriptions() error {
 
 // DeleteApplication deletes an application
 func (dbDeployer DBDeployer) DeleteApplication(applicationID string) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, deleteAllApplications, deleteAllAppAttributes)
-		if err := DeleteApplication(tx, applicationID); err != nil {
-			loggers.LoggerAPI.Error("Error while deleting application ", err)
-			return err
-		}
-		return DeleteApplicationAttributes(tx, applicationID)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.DeleteApplication(applicationID)
 	})
 	server.DeleteApplication(applicationID)
 	utils.SendApplicationEvent(constants.ApplicationDeleted, applicationID, "", "", "", nil)
@@ -203,9 +181,8 @@ func (dbDeployer DBDeployer) DeleteApplication(applicationID string) error {
 
 // DeleteApplicationMappings deletes an application mapping
 func (dbDeployer DBDeployer) DeleteApplicationMappings(applicationMapping string) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, deleteAppSub)
-		return DeleteAppSub(tx, applicationMapping)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.DeleteApplicationMapping(applicationMapping)
 	})
 	server.DeleteApplicationMapping(applicationMapping)
 	utils.SendApplicationMappingEvent(constants.ApplicationMappingDeleted, applicationMapping, "", "", "")
@@ -214,10 +191,8 @@ func (dbDeployer DBDeployer) DeleteApplicationMappings(applicationMapping string
 
 // UpdateApplicationMappings updates an application mapping
 func (dbDeployer DBDeployer) UpdateApplicationMappings(applicationMapping model.ApplicationMapping) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, updateAppSub)
-		return UpdateAppSub(tx, applicationMapping.UUID, applicationMapping.ApplicationRef, applicationMapping.SubscriptionRef,
-			applicationMapping.OrganizationID)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.UpdateApplicationMapping(applicationMapping)
 	})
 	server.DeleteApplicationMapping(applicationMapping.UUID)
 	server.AddApplicationMapping(applicationMapping)
@@ -228,9 +203,8 @@ func (dbDeployer DBDeployer) UpdateApplicationMappings(applicationMapping model.
 
 // DeleteKeyMappings deletes a key mapping
 func (dbDeployer DBDeployer) DeleteKeyMappings(keyMapping model.ApplicationKeyMapping) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, deleteApplicationKeyMapping)
-		return DeleteApplicationKeyMapping(tx, keyMapping.ApplicationUUID, keyMapping.SecurityScheme, keyMapping.EnvID)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.DeleteApplicationKeyMapping(keyMapping)
 	})
 	server.DeleteApplicationKeyMapping(keyMapping)
 	utils.SendApplicationKeyMappingEvent(constants.ApplicationKeyMappingDeleted, keyMapping.ApplicationUUID, keyMapping.SecurityScheme,
@@ -240,9 +214,8 @@ func (dbDeployer DBDeployer) DeleteKeyMappings(keyMapping model.ApplicationKeyMa
 
 // DeleteSubscription deletes a subscription
 func (dbDeployer DBDeployer) DeleteSubscription(subscriptionID string) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, deleteSubscription)
-		return DeleteSubscription(tx, subscriptionID)
+	retryUntilTransaction(func(tx Tx) error {
+		return tx.DeleteSubscription(subscriptionID)
 	})
 	server.DeleteSubscription(subscriptionID)
 	utils.SendSubscriptionEvent(constants.SubscriptionDeleted, subscriptionID, "", "", "", "", "")
@@ -251,16 +224,14 @@ func (dbDeployer DBDeployer) DeleteSubscription(subscriptionID string) error {
 
 // DeployAllApplicationMappings deploys all application mappings
 func (dbDeployer DBDeployer) DeployAllApplicationMappings(applicationMappings model.ApplicationMappingList) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, insertAppSub, deleteAllAppSub)
-		if err := DeleteAllAppSub(tx); err != nil {
+	retryUntilTransaction(func(tx Tx) error {
+		if err := tx.DeleteAllApplicationMappings(); err != nil {
 			loggers.LoggerAPI.Error("Error while deleting all app sub ", err)
 			return err
 		}
 		server.DeleteAllApplicationMappings()
 		for _, applicationMapping := range applicationMappings.List {
-			if err := AddAppSub(tx, applicationMapping.UUID, applicationMapping.ApplicationRef, applicationMapping.SubscriptionRef,
-				applicationMapping.OrganizationID); err != nil {
+			if err := tx.AddApplicationMapping(applicationMapping); err != nil {
 				loggers.LoggerAPI.Error("Error while adding app sub ", err)
 				return err
 			}
@@ -276,19 +247,14 @@ func (dbDeployer DBDeployer) DeployAllApplicationMappings(applicationMappings mo
 
 // DeployAllApplications deploys all key mappings
 func (dbDeployer DBDeployer) DeployAllApplications(applications model.ApplicationList) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, deleteAllApplications, deleteAllAppAttributes, insertApplication, insertApplicationAttributes)
-		if err := DeleteAllApplications(tx); err != nil {
+	retryUntilTransaction(func(tx Tx) error {
+		if err := tx.DeleteAllApplications(); err != nil {
 			loggers.LoggerAPI.Error("Error while deleting all applications ", err)
 			return err
 		}
-		if err := DeleteAllAppAttributes(tx); err != nil {
-			loggers.LoggerAPI.Error("Error while deleting all app attributes ", err)
-			return err
-		}
 
 		for _, application := range applications.List {
-			if err := deployApplicationwithAttributes(tx, application); err != nil {
+			if err := tx.AddApplication(application); err != nil {
 				loggers.LoggerAPI.Error("Error while deploying application with attributes ", err)
 				return err
 			}
@@ -305,11 +271,9 @@ func (dbDeployer DBDeployer) DeployAllApplications(applications model.Applicatio
 
 // UpdateKeyMappings updates a key mapping
 func (dbDeployer DBDeployer) UpdateKeyMappings(keyMapping model.ApplicationKeyMapping) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, updateApplicationKeyMapping)
+	retryUntilTransaction(func(tx Tx) error {
 		if keyMapping.SecurityScheme == constants.OAuth2 {
-			if err := UpdateApplicationKeyMapping(tx, keyMapping.ApplicationUUID, keyMapping.SecurityScheme, keyMapping.ApplicationIdentifier,
-				keyMapping.KeyType, keyMapping.EnvID, keyMapping.OrganizationID); err != nil {
+			if err := tx.UpdateApplicationKeyMapping(keyMapping); err != nil {
 				loggers.LoggerAPI.Error("Error while updating application key mapping ", err)
 				return err
 			}
@@ -326,17 +290,15 @@ func (dbDeployer DBDeployer) UpdateKeyMappings(keyMapping model.ApplicationKeyMa
 
 // DeployAllKeyMappings deploys all key mappings
 func (dbDeployer DBDeployer) DeployAllKeyMappings(keyMappings model.ApplicationKeyMappingList) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, deleteAllApplicationKeyMappings, insertApplicationKeyMapping)
-		if err := DeleteAllApplicationKeyMappings(tx); err != nil {
+	retryUntilTransaction(func(tx Tx) error {
+		if err := tx.DeleteAllApplicationKeyMappings(); err != nil {
 			loggers.LoggerAPI.Error("Error while deleting all application key mappings ", err)
 			return err
 		}
 		server.DeleteAllApplicationKeyMappings()
 		for _, keyMapping := range keyMappings.List {
 			if keyMapping.SecurityScheme == constants.OAuth2 {
-				if err := AddApplicationKeyMapping(tx, keyMapping.ApplicationUUID, constants.OAuth2, keyMapping.ApplicationIdentifier,
-					keyMapping.KeyType, keyMapping.EnvID, keyMapping.OrganizationID); err != nil {
+				if err := tx.AddApplicationKeyMapping(keyMapping); err != nil {
 					loggers.LoggerAPI.Error("Error while adding application key mapping ", err)
 					return err
 				}
@@ -357,16 +319,14 @@ func (dbDeployer DBDeployer) DeployAllKeyMappings(keyMappings model.ApplicationK
 
 // DeployAllSubscriptions deploys all subscriptions
 func (dbDeployer DBDeployer) DeployAllSubscriptions(subscriptions model.SubscriptionList) error {
-	retryUntilTransaction(func(tx pgx.Tx) error {
-		PrepareQueries(tx, deleteAllSubscriptions, insertSubscription)
-		if err := DeleteAllSubscriptions(tx); err != nil {
+	retryUntilTransaction(func(tx Tx) error {
+		if err := tx.DeleteAllSubscriptions(); err != nil {
 			loggers.LoggerAPI.Error("Error while deleting all subscriptions ", err)
 			return err
 		}
 		server.DeleteAllSubscriptions()
 		for _, subscription := range subscriptions.List {
-			if err := AddSubscription(tx, subscription.UUID, subscription.SubscribedAPI.Name, subscription.SubscribedAPI.Version,
-				subscription.SubStatus, subscription.Organization, subscription.RatelimitTier); err != nil {
+			if err := tx.AddSubscription(subscription); err != nil {
 				loggers.LoggerAPI.Error("Error while adding subscription ", err)
 				return err
 			}
@@ -0,0 +1,300 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/wso2/apk/common-controller/internal/config"
+	"github.com/wso2/apk/common-controller/internal/loggers"
+)
+
+// dialect holds what differs between the SQL databases. The queries are written with ? placeholders
+// and rebound to the placeholders of the dialect.
+type dialect struct {
+	// name is the directory of the migrations of the dialect.
+	name       string
+	driverName string
+	// placeholder returns the placeholder of the nth argument, counting from 1.
+	placeholder func(n int) string
+	// createSchemaVersion creates the table recording the applied migrations if it does not exist.
+	createSchemaVersion string
+	// lock takes the lock of the migrations on the connection, waiting for the common controller
+	// holding it, and returns the function releasing it.
+	lock func(ctx context.Context, conn *sql.Conn) (func(), error)
+	// open opens the database of the config.
+	open func(conf *config.Config) (*sql.DB, func(), error)
+	// classify returns ErrDuplicate or ErrMissingReference for the constraint violations of the
+	// database, and nil for the other errors.
+	classify func(err error) error
+}
+
+var postgresDialect = dialect{
+	name:       "postgres",
+	driverName: "pgx",
+	placeholder: func(n int) string {
+		return "$" + strconv.Itoa(n)
+	},
+	createSchemaVersion: "CREATE TABLE IF NOT EXISTS SCHEMA_VERSION (VERSION INTEGER NOT NULL, DESCRIPTION VARCHAR(256), " +
+		"CHECKSUM VARCHAR(64), APPLIED_AT TIMESTAMP DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (VERSION))",
+	lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+		// pg_advisory_lock waits without a timeout, so the lock is polled until migrationLockTimeout.
+		deadline := time.Now().Add(migrationLockTimeout)
+		for {
+			var acquired bool
+			if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockID).Scan(&acquired); err != nil {
+				return nil, err
+			}
+			if acquired {
+				break
+			}
+			if time.Now().After(deadline) {
+				return nil, fmt.Errorf("timed out waiting for the lock %d", migrationLockID)
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(migrationLockRetryInterval):
+			}
+		}
+		return func() {
+			releaseLock(conn, "SELECT pg_advisory_unlock($1)", migrationLockID)
+		}, nil
+	},
+	open: openPostgres,
+	classify: func(err error) error {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return ErrDuplicate
+			case "23503":
+				return ErrMissingReference
+			}
+		}
+		return nil
+	},
+}
+
+var mysqlDialect = dialect{
+	name:       "mysql",
+	driverName: "mysql",
+	placeholder: func(int) string {
+		return "?"
+	},
+	createSchemaVersion: "CREATE TABLE IF NOT EXISTS SCHEMA_VERSION (VERSION INTEGER NOT NULL, DESCRIPTION VARCHAR(256), " +
+		"CHECKSUM VARCHAR(64), APPLIED_AT TIMESTAMP DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (VERSION))",
+	lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+		var acquired sql.NullInt64
+		err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(migrationLockTimeout.Seconds())).
+			Scan(&acquired)
+		if err != nil {
+			return nil, err
+		}
+		if acquired.Int64 != 1 {
+			return nil, fmt.Errorf("timed out waiting for the lock %s", migrationLockName)
+		}
+		return func() {
+			releaseLock(conn, "SELECT RELEASE_LOCK(?)", migrationLockName)
+		}, nil
+	},
+	open: func(conf *config.Config) (*sql.DB, func(), error) {
+		db, err := openPooled("mysql", mysqlDSN(conf), conf)
+		return db, nil, err
+	},
+	classify: func(err error) error {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1062:
+				return ErrDuplicate
+			case 1452:
+				return ErrMissingReference
+			}
+		}
+		return nil
+	},
+}
+
+var mssqlDialect = dialect{
+	name:       "mssql",
+	driverName: "sqlserver",
+	placeholder: func(n int) string {
+		return "@p" + strconv.Itoa(n)
+	},
+	createSchemaVersion: "IF OBJECT_ID('SCHEMA_VERSION', 'U') IS NULL CREATE TABLE SCHEMA_VERSION (VERSION INTEGER NOT NULL, " +
+		"DESCRIPTION VARCHAR(256), CHECKSUM VARCHAR(64), APPLIED_AT DATETIME2 DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (VERSION))",
+	lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+		var result int
+		err := conn.QueryRowContext(ctx, "DECLARE @result INT; EXEC @result = sp_getapplock @Resource = @p1, "+
+			"@LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @p2; SELECT @result",
+			migrationLockName, migrationLockTimeout.Milliseconds()).Scan(&result)
+		if err != nil {
+			return nil, err
+		}
+		if result < 0 {
+			return nil, fmt.Errorf("failed to take the lock %s: sp_getapplock returned %d", migrationLockName, result)
+		}
+		return func() {
+			releaseLock(conn, "EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", migrationLockName)
+		}, nil
+	},
+	open: func(conf *config.Config) (*sql.DB, func(), error) {
+		db, err := openPooled("sqlserver", mssqlDSN(conf), conf)
+		return db, nil, err
+	},
+	classify: func(err error) error {
+		var mssqlErr mssql.Error
+		if errors.As(err, &mssqlErr) {
+			switch mssqlErr.Number {
+			case 2601, 2627:
+				return ErrDuplicate
+			case 547:
+				return ErrMissingReference
+			}
+		}
+		return nil
+	},
+}
+
+// The lock taken while migrating, so that the replicas of the common controller starting together
+// apply each migration once. PostgreSQL identifies advisory locks by a number, the others by name.
+const (
+	migrationLockID      int64 = 0x61706b5f636370 // "apk_ccp"
+	migrationLockName          = "apk_common_controller_migrations"
+	migrationLockTimeout       = 5 * time.Minute
+	// migrationLockRetryInterval is how often a lock that cannot wait with a timeout is polled.
+	migrationLockRetryInterval = time.Second
+)
+
+// releaseLock releases a migration lock, which the database also releases when the connection closes.
+func releaseLock(conn *sql.Conn, query string, arg interface{}) {
+	if _, err := conn.ExecContext(context.Background(), query, arg); err != nil {
+		loggers.LoggerAPI.Error("Error while releasing the migration lock ", err)
+	}
+}
+
+// rebind replaces the ? placeholders of the query with the placeholders of the dialect.
+func (d dialect) rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// wrap marks the constraint violations in err with ErrDuplicate or ErrMissingReference.
+func (d dialect) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	if sentinel := d.classify(err); sentinel != nil {
+		return fmt.Errorf("%w: %v", sentinel, err)
+	}
+	return err
+}
+
+// openPostgres opens PostgreSQL through a pgx pool, which keeps the pool options the common
+// controller had before the other databases were supported.
+func openPostgres(conf *config.Config) (*sql.DB, func(), error) {
+	pool, err := pgxpool.New(context.Background(), postgresDSN(conf))
+	if err != nil {
+		return nil, nil, err
+	}
+	return stdlib.OpenDBFromPool(pool), pool.Close, nil
+}
+
+// openPooled opens a database with the pool options of the config mapped to the database/sql pool.
+func openPooled(driverName, dsn string, conf *config.Config) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	poolOptions := conf.CommonController.Database.PoolOptions
+	db.SetMaxOpenConns(poolOptions.PoolMaxConns)
+	db.SetMaxIdleConns(poolOptions.PoolMaxConns)
+	if lifetime, err := time.ParseDuration(poolOptions.PoolMaxConnLifetime); err == nil {
+		db.SetConnMaxLifetime(lifetime)
+	}
+	if idleTime, err := time.ParseDuration(poolOptions.PoolMaxConnIdleTime); err == nil {
+		db.SetConnMaxIdleTime(idleTime)
+	}
+	return db, nil
+}
+
+func postgresDSN(conf *config.Config) string {
+	database := conf.CommonController.Database
+	query := url.Values{}
+	query.Set("pool_max_conns", strconv.Itoa(database.PoolOptions.PoolMaxConns))
+	query.Set("pool_min_conns", strconv.Itoa(database.PoolOptions.PoolMinConns))
+	query.Set("pool_max_conn_lifetime", database.PoolOptions.PoolMaxConnLifetime)
+	query.Set("pool_max_conn_idle_time", database.PoolOptions.PoolMaxConnIdleTime)
+	query.Set("pool_health_check_period", database.PoolOptions.PoolHealthCheckPeriod)
+	query.Set("pool_max_conn_lifetime_jitter", database.PoolOptions.PoolMaxConnLifetimeJitter)
+	dsn := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(database.Username, database.Password),
+		Host:     net.JoinHostPort(database.Host, strconv.Itoa(database.Port)),
+		Path:     database.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+func mysqlDSN(conf *config.Config) string {
+	database := conf.CommonController.Database
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.User = database.Username
+	mysqlConfig.Passwd = database.Password
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = net.JoinHostPort(database.Host, strconv.Itoa(database.Port))
+	mysqlConfig.DBName = database.Name
+	return mysqlConfig.FormatDSN()
+}
+
+func mssqlDSN(conf *config.Config) string {
+	database := conf.CommonController.Database
+	query := url.Values{}
+	query.Set("database", database.Name)
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(database.Username, database.Password),
+		Host:     net.JoinHostPort(database.Host, strconv.Itoa(database.Port)),
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
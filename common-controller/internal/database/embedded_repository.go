@@ -0,0 +1,377 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wso2/apk/common-go-libs/pkg/server/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+// embeddedSchemaVersion is the version of the layout of the embedded store, kept in the meta bucket
+// so that a later layout can migrate the files of earlier ones.
+const embeddedSchemaVersion = 1
+
+// embeddedOpenTimeout is how long opening the embedded store waits for the file lock, which another
+// common controller sharing the volume would hold.
+const embeddedOpenTimeout = 10 * time.Second
+
+var (
+	metaBucket                  = []byte("meta")
+	applicationsBucket          = []byte("applications")
+	subscriptionsBucket         = []byte("subscriptions")
+	applicationMappingsBucket   = []byte("applicationMappings")
+	applicationKeyMappingBucket = []byte("applicationKeyMappings")
+	schemaVersionKey            = []byte("schemaVersion")
+)
+
+// embeddedRepository is the Repository of single node installs, which keeps the artifacts as JSON in
+// a bbolt file and checks the references between them the way the SQL schema does.
+type embeddedRepository struct {
+	db *bolt.DB
+}
+
+// openEmbeddedRepository opens the embedded store at the path, creating it if it does not exist.
+func openEmbeddedRepository(path string) (Repository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the embedded store: %w", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: embeddedOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the embedded store %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{metaBucket, applicationsBucket, subscriptionsBucket, applicationMappingsBucket,
+			applicationKeyMappingBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		meta := tx.Bucket(metaBucket)
+		if stored := meta.Get(schemaVersionKey); stored != nil {
+			version, err := strconv.Atoi(string(stored))
+			if err != nil {
+				return fmt.Errorf("invalid schema version %q", stored)
+			}
+			if version > embeddedSchemaVersion {
+				return fmt.Errorf("schema version %d is newer than the supported version %d", version, embeddedSchemaVersion)
+			}
+		}
+		return meta.Put(schemaVersionKey, []byte(strconv.Itoa(embeddedSchemaVersion)))
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize the embedded store %s: %w", path, err)
+	}
+	return &embeddedRepository{db: db}, nil
+}
+
+// Transact runs fn in a bbolt read-write transaction
+func (r *embeddedRepository) Transact(_ context.Context, fn func(tx Tx) error) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return fn(&embeddedTx{tx: tx})
+	})
+}
+
+// Ping checks the embedded store is open
+func (r *embeddedRepository) Ping(context.Context) error {
+	return r.db.View(func(*bolt.Tx) error { return nil })
+}
+
+// Close closes the embedded store
+func (r *embeddedRepository) Close() error {
+	return r.db.Close()
+}
+
+// embeddedTx is the Tx of the embedded store.
+type embeddedTx struct {
+	tx *bolt.Tx
+}
+
+// keyMappingKey returns the key of the key mapping, which starts with its application.
+func keyMappingKey(keyMapping model.ApplicationKeyMapping) []byte {
+	return []byte(strings.Join([]string{keyMapping.ApplicationUUID, keyMapping.SecurityScheme, keyMapping.KeyType,
+		keyMapping.EnvID}, "\x00"))
+}
+
+func (t *embeddedTx) exists(bucket []byte, key string) bool {
+	return t.tx.Bucket(bucket).Get([]byte(key)) != nil
+}
+
+func (t *embeddedTx) put(bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return t.tx.Bucket(bucket).Put(key, data)
+}
+
+// forEach calls fn with the values of the bucket decoded into a new T.
+func forEach[T any](t *embeddedTx, bucket []byte, fn func(key []byte, value T) error) error {
+	return t.tx.Bucket(bucket).ForEach(func(key, data []byte) error {
+		var value T
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("failed to decode %s/%s: %w", bucket, key, err)
+		}
+		return fn(key, value)
+	})
+}
+
+// deleteWhere deletes the entries of the bucket the value of which matches.
+func deleteWhere[T any](t *embeddedTx, bucket []byte, match func(value T) bool) error {
+	var keys [][]byte
+	err := forEach(t, bucket, func(key []byte, value T) error {
+		if match(value) {
+			keys = append(keys, bytes.Clone(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := t.tx.Bucket(bucket).Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *embeddedTx) clear(buckets ...[]byte) error {
+	for _, bucket := range buckets {
+		if err := t.tx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+		if _, err := t.tx.CreateBucket(bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddApplication adds an application with its attributes
+func (t *embeddedTx) AddApplication(application model.Application) error {
+	if t.exists(applicationsBucket, application.UUID) {
+		return fmt.Errorf("%w: application %s", ErrDuplicate, application.UUID)
+	}
+	return t.put(applicationsBucket, []byte(application.UUID), application)
+}
+
+// UpdateApplication updates an application and replaces its attributes
+func (t *embeddedTx) UpdateApplication(application model.Application) error {
+	if !t.exists(applicationsBucket, application.UUID) {
+		return nil
+	}
+	return t.put(applicationsBucket, []byte(application.UUID), application)
+}
+
+// DeleteApplication deletes an application and its mappings
+func (t *embeddedTx) DeleteApplication(uuid string) error {
+	if err := t.tx.Bucket(applicationsBucket).Delete([]byte(uuid)); err != nil {
+		return err
+	}
+	err := deleteWhere(t, applicationMappingsBucket, func(applicationMapping model.ApplicationMapping) bool {
+		return applicationMapping.ApplicationRef == uuid
+	})
+	if err != nil {
+		return err
+	}
+	return deleteWhere(t, applicationKeyMappingBucket, func(keyMapping model.ApplicationKeyMapping) bool {
+		return keyMapping.ApplicationUUID == uuid
+	})
+}
+
+// DeleteAllApplications deletes all applications and their mappings
+func (t *embeddedTx) DeleteAllApplications() error {
+	return t.clear(applicationsBucket, applicationMappingsBucket, applicationKeyMappingBucket)
+}
+
+// GetAllApplications gets all applications with their attributes
+func (t *embeddedTx) GetAllApplications() ([]model.Application, error) {
+	var applications []model.Application
+	err := forEach(t, applicationsBucket, func(_ []byte, application model.Application) error {
+		if len(application.Attributes) == 0 {
+			application.Attributes = nil
+		}
+		applications = append(applications, application)
+		return nil
+	})
+	return applications, err
+}
+
+// AddSubscription adds a subscription
+func (t *embeddedTx) AddSubscription(subscription model.Subscription) error {
+	if t.exists(subscriptionsBucket, subscription.UUID) {
+		return fmt.Errorf("%w: subscription %s", ErrDuplicate, subscription.UUID)
+	}
+	return t.put(subscriptionsBucket, []byte(subscription.UUID), normalizeSubscription(subscription))
+}
+
+// UpdateSubscription updates a subscription
+func (t *embeddedTx) UpdateSubscription(subscription model.Subscription) error {
+	if !t.exists(subscriptionsBucket, subscription.UUID) {
+		return nil
+	}
+	return t.put(subscriptionsBucket, []byte(subscription.UUID), normalizeSubscription(subscription))
+}
+
+// DeleteSubscription deletes a subscription and its application mappings
+func (t *embeddedTx) DeleteSubscription(uuid string) error {
+	if err := t.tx.Bucket(subscriptionsBucket).Delete([]byte(uuid)); err != nil {
+		return err
+	}
+	return deleteWhere(t, applicationMappingsBucket, func(applicationMapping model.ApplicationMapping) bool {
+		return applicationMapping.SubscriptionRef == uuid
+	})
+}
+
+// DeleteAllSubscriptions deletes all subscriptions and the application mappings
+func (t *embeddedTx) DeleteAllSubscriptions() error {
+	return t.clear(subscriptionsBucket, applicationMappingsBucket)
+}
+
+// GetAllSubscriptions gets all subscriptions
+func (t *embeddedTx) GetAllSubscriptions() ([]model.Subscription, error) {
+	var subscriptions []model.Subscription
+	err := forEach(t, subscriptionsBucket, func(_ []byte, subscription model.Subscription) error {
+		subscriptions = append(subscriptions, subscription)
+		return nil
+	})
+	return subscriptions, err
+}
+
+// normalizeSubscription returns the subscription with an empty API instead of none, as read back from
+// the SQL databases.
+func normalizeSubscription(subscription model.Subscription) model.Subscription {
+	if subscription.SubscribedAPI == nil {
+		subscription.SubscribedAPI = &model.SubscribedAPI{}
+	}
+	return subscription
+}
+
+// checkApplicationMapping checks the application and subscription of the mapping exist, and that
+// no other mapping is between them.
+func (t *embeddedTx) checkApplicationMapping(applicationMapping model.ApplicationMapping) error {
+	if !t.exists(applicationsBucket, applicationMapping.ApplicationRef) {
+		return fmt.Errorf("%w: application %s", ErrMissingReference, applicationMapping.ApplicationRef)
+	}
+	if !t.exists(subscriptionsBucket, applicationMapping.SubscriptionRef) {
+		return fmt.Errorf("%w: subscription %s", ErrMissingReference, applicationMapping.SubscriptionRef)
+	}
+	return forEach(t, applicationMappingsBucket, func(_ []byte, other model.ApplicationMapping) error {
+		if other.UUID != applicationMapping.UUID && other.ApplicationRef == applicationMapping.ApplicationRef &&
+			other.SubscriptionRef == applicationMapping.SubscriptionRef {
+			return fmt.Errorf("%w: application %s is already mapped to subscription %s", ErrDuplicate,
+				applicationMapping.ApplicationRef, applicationMapping.SubscriptionRef)
+		}
+		return nil
+	})
+}
+
+// AddApplicationMapping adds an application subscription mapping
+func (t *embeddedTx) AddApplicationMapping(applicationMapping model.ApplicationMapping) error {
+	if t.exists(applicationMappingsBucket, applicationMapping.UUID) {
+		return fmt.Errorf("%w: application mapping %s", ErrDuplicate, applicationMapping.UUID)
+	}
+	if err := t.checkApplicationMapping(applicationMapping); err != nil {
+		return err
+	}
+	return t.put(applicationMappingsBucket, []byte(applicationMapping.UUID), applicationMapping)
+}
+
+// UpdateApplicationMapping updates an application subscription mapping
+func (t *embeddedTx) UpdateApplicationMapping(applicationMapping model.ApplicationMapping) error {
+	if !t.exists(applicationMappingsBucket, applicationMapping.UUID) {
+		return nil
+	}
+	if err := t.checkApplicationMapping(applicationMapping); err != nil {
+		return err
+	}
+	return t.put(applicationMappingsBucket, []byte(applicationMapping.UUID), applicationMapping)
+}
+
+// DeleteApplicationMapping deletes an application subscription mapping
+func (t *embeddedTx) DeleteApplicationMapping(uuid string) error {
+	return t.tx.Bucket(applicationMappingsBucket).Delete([]byte(uuid))
+}
+
+// DeleteAllApplicationMappings deletes all application subscription mappings
+func (t *embeddedTx) DeleteAllApplicationMappings() error {
+	return t.clear(applicationMappingsBucket)
+}
+
+// GetAllApplicationMappings gets all application subscription mappings
+func (t *embeddedTx) GetAllApplicationMappings() ([]model.ApplicationMapping, error) {
+	var applicationMappings []model.ApplicationMapping
+	err := forEach(t, applicationMappingsBucket, func(_ []byte, applicationMapping model.ApplicationMapping) error {
+		applicationMappings = append(applicationMappings, applicationMapping)
+		return nil
+	})
+	return applicationMappings, err
+}
+
+// AddApplicationKeyMapping adds an application key mapping
+func (t *embeddedTx) AddApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error {
+	if !t.exists(applicationsBucket, keyMapping.ApplicationUUID) {
+		return fmt.Errorf("%w: application %s", ErrMissingReference, keyMapping.ApplicationUUID)
+	}
+	key := keyMappingKey(keyMapping)
+	if t.tx.Bucket(applicationKeyMappingBucket).Get(key) != nil {
+		return fmt.Errorf("%w: %s key mapping of application %s", ErrDuplicate, keyMapping.SecurityScheme,
+			keyMapping.ApplicationUUID)
+	}
+	return t.put(applicationKeyMappingBucket, key, keyMapping)
+}
+
+// UpdateApplicationKeyMapping updates an application key mapping
+func (t *embeddedTx) UpdateApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error {
+	key := keyMappingKey(keyMapping)
+	if t.tx.Bucket(applicationKeyMappingBucket).Get(key) == nil {
+		return nil
+	}
+	return t.put(applicationKeyMappingBucket, key, keyMapping)
+}
+
+// DeleteApplicationKeyMapping deletes an application key mapping
+func (t *embeddedTx) DeleteApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error {
+	return t.tx.Bucket(applicationKeyMappingBucket).Delete(keyMappingKey(keyMapping))
+}
+
+// DeleteAllApplicationKeyMappings deletes all application key mappings
+func (t *embeddedTx) DeleteAllApplicationKeyMappings() error {
+	return t.clear(applicationKeyMappingBucket)
+}
+
+// GetAllApplicationKeyMappings gets all application key mappings
+func (t *embeddedTx) GetAllApplicationKeyMappings() ([]model.ApplicationKeyMapping, error) {
+	var keyMappings []model.ApplicationKeyMapping
+	err := forEach(t, applicationKeyMappingBucket, func(_ []byte, keyMapping model.ApplicationKeyMapping) error {
+		keyMappings = append(keyMappings, keyMapping)
+		return nil
+	})
+	return keyMappings, err
+}
@@ -0,0 +1,381 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wso2/apk/common-controller/internal/config"
+	"github.com/wso2/apk/common-controller/internal/loggers"
+)
+
+// migrations holds the schema of each dialect as numbered migrations, named
+// <dialect>/<version>_<description>.sql, with the optional <version>_<description>.down.sql
+// reverting them. Migrations are forward-only: each is applied once, in the order of the versions,
+// and recorded in the SCHEMA_VERSION table with the checksum of its script, so a released
+// migration must never be edited. The statements of a migration end with a semicolon at the end
+// of a line.
+//
+//go:embed migrations
+var migrations embed.FS
+
+// MigrationStatus is a migration of the database schema and whether it is applied.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+	// AppliedAt is when the migration was applied, as reported by the database.
+	AppliedAt string
+}
+
+type migration struct {
+	version     int
+	description string
+	file        string
+	// downFile reverts the migration, empty if it cannot be reverted.
+	downFile string
+	checksum string
+}
+
+// appliedMigration is a migration recorded in the SCHEMA_VERSION table.
+type appliedMigration struct {
+	version     int
+	description string
+	checksum    string
+	appliedAt   string
+}
+
+// MigrationStatuses returns the migrations of the configured database.
+func MigrationStatuses(conf *config.Config) (statuses []MigrationStatus, err error) {
+	err = withSQLDatabase(conf, func(r *sqlRepository) error {
+		return r.withMigrationLock(context.Background(), func(conn *sql.Conn) error {
+			statuses, _, err = r.migrationStatuses(context.Background(), conn)
+			return err
+		})
+	})
+	return statuses, err
+}
+
+// MigrateUp applies the pending migrations of the configured database and returns how many were applied.
+func MigrateUp(conf *config.Config) (applied int, err error) {
+	err = withSQLDatabase(conf, func(r *sqlRepository) error {
+		applied, err = r.migrateUp(context.Background())
+		return err
+	})
+	return applied, err
+}
+
+// MigrateDown reverts the last steps migrations of the configured database and returns how many were reverted.
+func MigrateDown(conf *config.Config, steps int) (reverted int, err error) {
+	err = withSQLDatabase(conf, func(r *sqlRepository) error {
+		reverted, err = r.migrateDown(context.Background(), steps)
+		return err
+	})
+	return reverted, err
+}
+
+// withSQLDatabase runs fn with the configured database, opened without migrating it.
+func withSQLDatabase(conf *config.Config, fn func(r *sqlRepository) error) error {
+	if strings.EqualFold(conf.CommonController.ControlPlane.Persistence.Type, PersistenceEmbedded) {
+		return fmt.Errorf("migrations apply to the SQL databases, the %s persistence migrates itself",
+			PersistenceEmbedded)
+	}
+	d, err := sqlDialect(conf)
+	if err != nil {
+		return err
+	}
+	r, err := openSQLDatabase(d, conf)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return fn(r)
+}
+
+// withMigrationLock runs fn with a connection holding the migration lock, after creating the
+// SCHEMA_VERSION table if it does not exist.
+func (r *sqlRepository) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection for the migrations: %w", err)
+	}
+	defer conn.Close()
+	unlock, err := r.dialect.lock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	defer unlock()
+	if _, err := conn.ExecContext(ctx, r.dialect.createSchemaVersion); err != nil {
+		return fmt.Errorf("failed to create the schema version table: %w", err)
+	}
+	return fn(conn)
+}
+
+// migrateUp applies the pending migrations under the migration lock, so that the common
+// controllers starting together apply each of them once.
+func (r *sqlRepository) migrateUp(ctx context.Context) (applied int, err error) {
+	err = r.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		_, pending, err := r.migrationStatuses(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			err := r.runScript(ctx, conn, m.file, func(tx *sqlTx) error {
+				return tx.exec(insertSchemaVersion, m.version, m.description, m.checksum)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply the migration %s: %w", m.file, err)
+			}
+			loggers.LoggerAPI.Infof("Applied the database migration %s", m.file)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// migrateDown reverts the last steps applied migrations, latest first.
+func (r *sqlRepository) migrateDown(ctx context.Context, steps int) (reverted int, err error) {
+	err = r.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		if _, _, err := r.migrationStatuses(ctx, conn); err != nil {
+			return err
+		}
+		applied, err := r.appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		known, err := dialectMigrations(r.dialect.name)
+		if err != nil {
+			return err
+		}
+		for i := len(known) - 1; i >= 0 && reverted < steps; i-- {
+			m := known[i]
+			if _, ok := applied[m.version]; !ok {
+				continue
+			}
+			if m.downFile == "" {
+				return fmt.Errorf("the migration %s cannot be reverted", m.file)
+			}
+			err := r.runScript(ctx, conn, m.downFile, func(tx *sqlTx) error {
+				return tx.exec(deleteSchemaVersion, m.version)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert the migration %s: %w", m.file, err)
+			}
+			loggers.LoggerAPI.Infof("Reverted the database migration %s", m.file)
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// checkMigrated returns an error if the database has pending migrations.
+func (r *sqlRepository) checkMigrated(ctx context.Context) error {
+	return r.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		_, pending, err := r.migrationStatuses(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("the database has %d pending migrations and autoMigrate is disabled, "+
+				"apply them with 'common-controller migrate up'", len(pending))
+		}
+		return nil
+	})
+}
+
+// migrationStatuses returns the migrations of the dialect with the pending ones.
+func (r *sqlRepository) migrationStatuses(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, []migration, error) {
+	known, err := dialectMigrations(r.dialect.name)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, err := r.appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return planMigrations(known, applied)
+}
+
+func (r *sqlRepository) appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, getSchemaVersions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	defer rows.Close()
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var m appliedMigration
+		var description, checksum, appliedAt sql.NullString
+		if err := rows.Scan(&m.version, &description, &checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+		m.description, m.checksum, m.appliedAt = description.String, checksum.String, appliedAt.String
+		applied[m.version] = m
+	}
+	return applied, rows.Err()
+}
+
+// runScript runs the statements of the script and then record in a transaction. The databases
+// that commit DDL implicitly, MySQL, only roll back what the script did after its last DDL.
+func (r *sqlRepository) runScript(ctx context.Context, conn *sql.Conn, file string,
+	record func(tx *sqlTx) error) (err error) {
+	script, err := migrations.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error while beginning the transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				loggers.LoggerAPI.Error("Error while rolling back the migration ", rollbackErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+	for _, statement := range splitStatements(string(script)) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return record(&sqlTx{ctx: ctx, tx: tx, dialect: r.dialect})
+}
+
+// planMigrations returns the status of the known migrations and the pending ones, in the order
+// they are applied. A database migrated by a newer common controller, or with a migration that
+// differs from the one of this common controller, is not migrated any further.
+func planMigrations(known []migration, applied map[int]appliedMigration) ([]MigrationStatus, []migration, error) {
+	latest := 0
+	knownVersions := map[int]migration{}
+	for _, m := range known {
+		knownVersions[m.version] = m
+		latest = m.version
+	}
+	for version, a := range applied {
+		m, ok := knownVersions[version]
+		switch {
+		case version > latest:
+			return nil, nil, fmt.Errorf("the database schema is at version %d, which is newer than the "+
+				"version %d of this common controller", version, latest)
+		case !ok:
+			return nil, nil, fmt.Errorf("the migration %d applied to the database is unknown", version)
+		case a.checksum != "" && a.checksum != m.checksum:
+			return nil, nil, fmt.Errorf("the migration %s differs from the one applied to the database", m.file)
+		}
+	}
+	var statuses []MigrationStatus
+	var pending []migration
+	for _, m := range known {
+		a, ok := applied[m.version]
+		statuses = append(statuses, MigrationStatus{
+			Version:     m.version,
+			Description: m.description,
+			Applied:     ok,
+			AppliedAt:   a.appliedAt,
+		})
+		if !ok {
+			pending = append(pending, m)
+		}
+	}
+	return statuses, pending, nil
+}
+
+// dialectMigrations returns the migrations of the dialect ordered by version.
+func dialectMigrations(dialectName string) ([]migration, error) {
+	dir := path.Join("migrations", dialectName)
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return nil, err
+	}
+	downFiles := map[string]string{}
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".down.sql"); ok {
+			downFiles[name] = path.Join(dir, entry.Name())
+		}
+	}
+	var result []migration
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".sql")
+		if !ok || strings.HasSuffix(name, ".down") {
+			continue
+		}
+		versionPart, description, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(versionPart)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s is not named <version>_<description>.sql", entry.Name())
+		}
+		file := path.Join(dir, entry.Name())
+		script, err := migrations.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		checksum := sha256.Sum256(script)
+		result = append(result, migration{
+			version:     version,
+			description: description,
+			file:        file,
+			downFile:    downFiles[name],
+			checksum:    hex.EncodeToString(checksum[:]),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].version < result[j].version })
+	for i := 1; i < len(result); i++ {
+		if result[i].version == result[i-1].version {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", result[i-1].file, result[i].file)
+		}
+	}
+	return result, nil
+}
+
+// splitStatements splits a migration script into its statements, skipping the comment lines.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSuffix(strings.TrimSpace(current.String()), ";")
+			statements = append(statements, statement)
+			current.Reset()
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
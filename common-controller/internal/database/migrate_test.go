@@ -0,0 +1,125 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements(`-- comment
+CREATE TABLE A (
+    ID INTEGER
+);
+
+IF OBJECT_ID('B', 'U') IS NULL
+CREATE TABLE B (ID INTEGER);
+INSERT INTO B VALUES (1)`)
+	assert.Equal(t, []string{
+		"CREATE TABLE A (\n    ID INTEGER\n)",
+		"IF OBJECT_ID('B', 'U') IS NULL\nCREATE TABLE B (ID INTEGER)",
+		"INSERT INTO B VALUES (1)",
+	}, statements)
+}
+
+func TestDialectMigrations(t *testing.T) {
+	tables := []string{"SUBSCRIPTION", "APPLICATION", "APPLICATION_SUBSCRIPTION_MAPPING", "APPLICATION_KEY_MAPPING",
+		"APPLICATION_ATTRIBUTES"}
+	for _, d := range []dialect{postgresDialect, mysqlDialect, mssqlDialect} {
+		t.Run(d.name, func(t *testing.T) {
+			dialectMigrations, err := dialectMigrations(d.name)
+			require.NoError(t, err)
+			require.NotEmpty(t, dialectMigrations)
+			assert.Equal(t, 1, dialectMigrations[0].version)
+			assert.Equal(t, "initial_schema", dialectMigrations[0].description)
+
+			script, err := migrations.ReadFile(dialectMigrations[0].file)
+			require.NoError(t, err)
+			statements := splitStatements(string(script))
+			require.Len(t, statements, len(tables))
+			for i, table := range tables {
+				assert.Regexp(t, `CREATE TABLE (IF NOT EXISTS )?`+table+` \(`, statements[i])
+				assert.False(t, strings.HasSuffix(statements[i], ";"))
+			}
+		})
+	}
+}
+
+func TestDialectDownMigrations(t *testing.T) {
+	for _, d := range []dialect{postgresDialect, mysqlDialect, mssqlDialect} {
+		t.Run(d.name, func(t *testing.T) {
+			dialectMigrations, err := dialectMigrations(d.name)
+			require.NoError(t, err)
+			script, err := migrations.ReadFile(dialectMigrations[0].downFile)
+			require.NoError(t, err)
+			statements := splitStatements(string(script))
+			require.Len(t, statements, 5)
+			// The tables are dropped before the tables they refer to.
+			assert.Regexp(t, `DROP TABLE (IF EXISTS )?APPLICATION_ATTRIBUTES$`, statements[0])
+			assert.Regexp(t, `DROP TABLE (IF EXISTS )?SUBSCRIPTION$`, statements[4])
+		})
+	}
+}
+
+func TestPlanMigrations(t *testing.T) {
+	known := []migration{
+		{version: 1, description: "initial_schema", file: "migrations/test/0001_initial_schema.sql", checksum: "a"},
+		{version: 2, description: "add_index", file: "migrations/test/0002_add_index.sql", checksum: "b"},
+		{version: 3, description: "add_column", file: "migrations/test/0003_add_column.sql", checksum: "c"},
+	}
+
+	t.Run("pending", func(t *testing.T) {
+		statuses, pending, err := planMigrations(known, map[int]appliedMigration{
+			1: {version: 1, checksum: "a", appliedAt: "2025-01-01T00:00:00Z"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []MigrationStatus{
+			{Version: 1, Description: "initial_schema", Applied: true, AppliedAt: "2025-01-01T00:00:00Z"},
+			{Version: 2, Description: "add_index"},
+			{Version: 3, Description: "add_column"},
+		}, statuses)
+		assert.Equal(t, known[1:], pending)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		_, pending, err := planMigrations(known, map[int]appliedMigration{
+			1: {version: 1, checksum: "a"}, 2: {version: 2, checksum: "b"}, 3: {version: 3},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("newer schema", func(t *testing.T) {
+		_, _, err := planMigrations(known, map[int]appliedMigration{4: {version: 4}})
+		assert.ErrorContains(t, err, "version 4, which is newer than the version 3")
+	})
+
+	t.Run("unknown migration", func(t *testing.T) {
+		_, _, err := planMigrations(known[:1:1], map[int]appliedMigration{0: {version: 0}})
+		assert.ErrorContains(t, err, "migration 0 applied to the database is unknown")
+	})
+
+	t.Run("changed migration", func(t *testing.T) {
+		_, _, err := planMigrations(known, map[int]appliedMigration{2: {version: 2, checksum: "x"}})
+		assert.ErrorContains(t, err, "0002_add_index.sql differs")
+	})
+}
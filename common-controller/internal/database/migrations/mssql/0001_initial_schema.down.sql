@@ -0,0 +1,7 @@
+-- Drops the tables of the control plane artifacts, and the artifacts with them.
+
+IF OBJECT_ID('APPLICATION_ATTRIBUTES', 'U') IS NOT NULL DROP TABLE APPLICATION_ATTRIBUTES;
+IF OBJECT_ID('APPLICATION_KEY_MAPPING', 'U') IS NOT NULL DROP TABLE APPLICATION_KEY_MAPPING;
+IF OBJECT_ID('APPLICATION_SUBSCRIPTION_MAPPING', 'U') IS NOT NULL DROP TABLE APPLICATION_SUBSCRIPTION_MAPPING;
+IF OBJECT_ID('APPLICATION', 'U') IS NOT NULL DROP TABLE APPLICATION;
+IF OBJECT_ID('SUBSCRIPTION', 'U') IS NOT NULL DROP TABLE SUBSCRIPTION;
@@ -0,0 +1,55 @@
+-- The columns of the keys are shorter than in the PostgreSQL schema, to keep the clustered keys
+-- within the 900 bytes SQL Server allows.
+
+IF OBJECT_ID('SUBSCRIPTION', 'U') IS NULL
+CREATE TABLE SUBSCRIPTION (
+    UUID VARCHAR(256) NOT NULL,
+    API_NAME VARCHAR(256),
+    API_VERSION VARCHAR(30),
+    SUB_STATUS VARCHAR(50),
+    ORGANIZATION VARCHAR(100),
+    RATELIMIT_TIER VARCHAR(100),
+    PRIMARY KEY (UUID)
+);
+
+IF OBJECT_ID('APPLICATION', 'U') IS NULL
+CREATE TABLE APPLICATION (
+    UUID VARCHAR(256) NOT NULL,
+    NAME VARCHAR(100),
+    OWNER VARCHAR(100),
+    ORGANIZATION VARCHAR(100),
+    PRIMARY KEY (UUID)
+);
+
+IF OBJECT_ID('APPLICATION_SUBSCRIPTION_MAPPING', 'U') IS NULL
+CREATE TABLE APPLICATION_SUBSCRIPTION_MAPPING (
+    UUID VARCHAR(100) NOT NULL,
+    APPLICATION_UUID VARCHAR(256) NOT NULL,
+    SUBSCRIPTION_UUID VARCHAR(256) NOT NULL,
+    ORGANIZATION VARCHAR(100),
+    FOREIGN KEY (APPLICATION_UUID) REFERENCES APPLICATION (UUID) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY (SUBSCRIPTION_UUID) REFERENCES SUBSCRIPTION (UUID) ON UPDATE CASCADE ON DELETE CASCADE,
+    PRIMARY KEY (APPLICATION_UUID, SUBSCRIPTION_UUID),
+    UNIQUE (UUID)
+);
+
+IF OBJECT_ID('APPLICATION_KEY_MAPPING', 'U') IS NULL
+CREATE TABLE APPLICATION_KEY_MAPPING (
+    APPLICATION_UUID VARCHAR(256) NOT NULL,
+    APPLICATION_IDENTIFIER VARCHAR(512),
+    KEY_TYPE VARCHAR(100) NOT NULL,
+    ENVIRONMENT VARCHAR(256) NOT NULL,
+    SECURITY_SCHEME VARCHAR(100) NOT NULL,
+    ORGANIZATION VARCHAR(100),
+    FOREIGN KEY (APPLICATION_UUID) REFERENCES APPLICATION (UUID) ON UPDATE CASCADE ON DELETE CASCADE,
+    PRIMARY KEY (APPLICATION_UUID, SECURITY_SCHEME, KEY_TYPE, ENVIRONMENT)
+);
+
+IF OBJECT_ID('APPLICATION_ATTRIBUTES', 'U') IS NULL
+CREATE TABLE APPLICATION_ATTRIBUTES (
+    APPLICATION_UUID VARCHAR(256) NOT NULL,
+    NAME VARCHAR(255) NOT NULL,
+    APP_ATTRIBUTE VARCHAR(1024) NOT NULL,
+    FOREIGN KEY (APPLICATION_UUID) REFERENCES APPLICATION (UUID) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (APPLICATION_UUID, NAME)
+);
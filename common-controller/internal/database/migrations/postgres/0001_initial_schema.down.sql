@@ -0,0 +1,7 @@
+-- Drops the tables of the control plane artifacts, and the artifacts with them.
+
+DROP TABLE IF EXISTS APPLICATION_ATTRIBUTES;
+DROP TABLE IF EXISTS APPLICATION_KEY_MAPPING;
+DROP TABLE IF EXISTS APPLICATION_SUBSCRIPTION_MAPPING;
+DROP TABLE IF EXISTS APPLICATION;
+DROP TABLE IF EXISTS SUBSCRIPTION;
@@ -17,33 +17,38 @@
 
 package database
 
+// The queries are written with ? placeholders, which the dialect rebinds.
 const (
-	insertApplication     = "INSERT INTO APPLICATION (UUID, NAME, OWNER, ORGANIZATION) VALUES ($1, $2, $3, $4);"
-	getAllApplications    = "SELECT UUID, NAME, OWNER, ORGANIZATION FROM APPLICATION;"
-	updateApplication     = "UPDATE APPLICATION SET NAME = $2, OWNER = $3, ORGANIZATION = $4 WHERE UUID = $1;"
-	deleteApplication     = "DELETE FROM APPLICATION WHERE UUID = $1;"
-	deleteAllApplications = "DELETE FROM APPLICATION;"
+	insertApplication     = "INSERT INTO APPLICATION (UUID, NAME, OWNER, ORGANIZATION) VALUES (?, ?, ?, ?)"
+	getAllApplications    = "SELECT UUID, NAME, OWNER, ORGANIZATION FROM APPLICATION"
+	updateApplication     = "UPDATE APPLICATION SET NAME = ?, OWNER = ?, ORGANIZATION = ? WHERE UUID = ?"
+	deleteApplication     = "DELETE FROM APPLICATION WHERE UUID = ?"
+	countApplication      = "SELECT COUNT(*) FROM APPLICATION WHERE UUID = ?"
+	deleteAllApplications = "DELETE FROM APPLICATION"
 
-	insertApplicationAttributes = "INSERT INTO APPLICATION_ATTRIBUTES (APPLICATION_UUID, NAME, APP_ATTRIBUTE) VALUES ($1, $2, $3);"
-	getAllApplicationAttributes = "SELECT APPLICATION_UUID, NAME, APP_ATTRIBUTE FROM APPLICATION_ATTRIBUTES;"
-	deleteApplicationAttributes = "DELETE FROM APPLICATION_ATTRIBUTES WHERE APPLICATION_UUID = $1;"
-	deleteAllAppAttributes      = "DELETE FROM APPLICATION_ATTRIBUTES;"
+	insertApplicationAttributes = "INSERT INTO APPLICATION_ATTRIBUTES (APPLICATION_UUID, NAME, APP_ATTRIBUTE) VALUES (?, ?, ?)"
+	getAllApplicationAttributes = "SELECT APPLICATION_UUID, NAME, APP_ATTRIBUTE FROM APPLICATION_ATTRIBUTES"
+	deleteApplicationAttributes = "DELETE FROM APPLICATION_ATTRIBUTES WHERE APPLICATION_UUID = ?"
 
-	insertSubscription     = "INSERT INTO SUBSCRIPTION (UUID, API_NAME, API_VERSION, SUB_STATUS, ORGANIZATION, RATELIMIT_TIER) VALUES ($1, $2, $3, $4, $5, $6);"
-	getAllSubscriptions    = "SELECT UUID, API_NAME, API_VERSION, SUB_STATUS, ORGANIZATION, RATELIMIT_TIER FROM SUBSCRIPTION;"
-	updateSubscription     = "UPDATE SUBSCRIPTION SET API_NAME = $2, API_VERSION = $3, SUB_STATUS = $4, ORGANIZATION = $5, RATELIMIT_TIER = $6 WHERE UUID = $1;"
-	deleteSubscription     = "DELETE FROM SUBSCRIPTION WHERE UUID = $1;"
-	deleteAllSubscriptions = "DELETE FROM SUBSCRIPTION;"
+	insertSubscription     = "INSERT INTO SUBSCRIPTION (UUID, API_NAME, API_VERSION, SUB_STATUS, ORGANIZATION, RATELIMIT_TIER) VALUES (?, ?, ?, ?, ?, ?)"
+	getAllSubscriptions    = "SELECT UUID, API_NAME, API_VERSION, SUB_STATUS, ORGANIZATION, RATELIMIT_TIER FROM SUBSCRIPTION"
+	updateSubscription     = "UPDATE SUBSCRIPTION SET API_NAME = ?, API_VERSION = ?, SUB_STATUS = ?, ORGANIZATION = ?, RATELIMIT_TIER = ? WHERE UUID = ?"
+	deleteSubscription     = "DELETE FROM SUBSCRIPTION WHERE UUID = ?"
+	deleteAllSubscriptions = "DELETE FROM SUBSCRIPTION"
 
-	insertAppSub    = "INSERT INTO APPLICATION_SUBSCRIPTION_MAPPING (UUID, APPLICATION_UUID, SUBSCRIPTION_UUID, ORGANIZATION) VALUES ($1, $2, $3, $4);"
-	getAllAppSubs   = "SELECT UUID, APPLICATION_UUID, SUBSCRIPTION_UUID, ORGANIZATION FROM APPLICATION_SUBSCRIPTION_MAPPING;"
-	updateAppSub    = "UPDATE APPLICATION_SUBSCRIPTION_MAPPING SET APPLICATION_UUID = $2, SUBSCRIPTION_UUID = $3, ORGANIZATION = $4 WHERE UUID = $1;"
-	deleteAppSub    = "DELETE FROM APPLICATION_SUBSCRIPTION_MAPPING WHERE UUID = $1;"
-	deleteAllAppSub = "DELETE FROM APPLICATION_SUBSCRIPTION_MAPPING;"
+	insertAppSub    = "INSERT INTO APPLICATION_SUBSCRIPTION_MAPPING (UUID, APPLICATION_UUID, SUBSCRIPTION_UUID, ORGANIZATION) VALUES (?, ?, ?, ?)"
+	getAllAppSubs   = "SELECT UUID, APPLICATION_UUID, SUBSCRIPTION_UUID, ORGANIZATION FROM APPLICATION_SUBSCRIPTION_MAPPING"
+	updateAppSub    = "UPDATE APPLICATION_SUBSCRIPTION_MAPPING SET APPLICATION_UUID = ?, SUBSCRIPTION_UUID = ?, ORGANIZATION = ? WHERE UUID = ?"
+	deleteAppSub    = "DELETE FROM APPLICATION_SUBSCRIPTION_MAPPING WHERE UUID = ?"
+	deleteAllAppSub = "DELETE FROM APPLICATION_SUBSCRIPTION_MAPPING"
 
-	getAllApplicationKeyMappings    = "SELECT APPLICATION_UUID, SECURITY_SCHEME, APPLICATION_IDENTIFIER, KEY_TYPE, ENVIRONMENT, ORGANIZATION FROM APPLICATION_KEY_MAPPING;"
-	insertApplicationKeyMapping     = "INSERT INTO APPLICATION_KEY_MAPPING (APPLICATION_UUID, SECURITY_SCHEME, APPLICATION_IDENTIFIER, KEY_TYPE, ENVIRONMENT, ORGANIZATION) VALUES ($1, $2, $3, $4, $5, $6);"
-	updateApplicationKeyMapping     = "UPDATE APPLICATION_KEY_MAPPING SET APPLICATION_IDENTIFIER = $3, KEY_TYPE = $4, ORGANIZATION = $6 WHERE APPLICATION_UUID = $1, SECURITY_SCHEME = $2 AND ENVIRONMENT=$5;"
-	deleteApplicationKeyMapping     = "DELETE FROM APPLICATION_KEY_MAPPING WHERE APPLICATION_UUID = $1, SECURITY_SCHEME = $2 AND ENVIRONMENT=$3;"
+	getAllApplicationKeyMappings    = "SELECT APPLICATION_UUID, SECURITY_SCHEME, APPLICATION_IDENTIFIER, KEY_TYPE, ENVIRONMENT, ORGANIZATION FROM APPLICATION_KEY_MAPPING"
+	insertApplicationKeyMapping     = "INSERT INTO APPLICATION_KEY_MAPPING (APPLICATION_UUID, SECURITY_SCHEME, APPLICATION_IDENTIFIER, KEY_TYPE, ENVIRONMENT, ORGANIZATION) VALUES (?, ?, ?, ?, ?, ?)"
+	updateApplicationKeyMapping     = "UPDATE APPLICATION_KEY_MAPPING SET APPLICATION_IDENTIFIER = ?, ORGANIZATION = ? WHERE APPLICATION_UUID = ? AND SECURITY_SCHEME = ? AND KEY_TYPE = ? AND ENVIRONMENT = ?"
+	deleteApplicationKeyMapping     = "DELETE FROM APPLICATION_KEY_MAPPING WHERE APPLICATION_UUID = ? AND SECURITY_SCHEME = ? AND KEY_TYPE = ? AND ENVIRONMENT = ?"
 	deleteAllApplicationKeyMappings = "DELETE FROM APPLICATION_KEY_MAPPING"
+
+	getSchemaVersions   = "SELECT VERSION, DESCRIPTION, CHECKSUM, APPLIED_AT FROM SCHEMA_VERSION ORDER BY VERSION"
+	insertSchemaVersion = "INSERT INTO SCHEMA_VERSION (VERSION, DESCRIPTION, CHECKSUM) VALUES (?, ?, ?)"
+	deleteSchemaVersion = "DELETE FROM SCHEMA_VERSION WHERE VERSION = ?"
 )
@@ -0,0 +1,143 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wso2/apk/common-controller/internal/config"
+	"github.com/wso2/apk/common-go-libs/pkg/server/model"
+)
+
+// Persistence types of the control plane artifacts, set as controlplane.persistence.type.
+const (
+	// PersistenceK8s keeps the artifacts as custom resources.
+	PersistenceK8s = "K8s"
+	// PersistenceDB is the former name of PersistencePostgreSQL.
+	PersistenceDB         = "DB"
+	PersistencePostgreSQL = "PostgreSQL"
+	PersistenceMySQL      = "MySQL"
+	PersistenceMSSQL      = "MSSQL"
+	// PersistenceEmbedded keeps the artifacts in a file of the common controller, for single node installs.
+	PersistenceEmbedded = "Embedded"
+)
+
+var (
+	// ErrDuplicate is returned when an artifact with the same key is already stored.
+	ErrDuplicate = errors.New("artifact already exists")
+	// ErrMissingReference is returned when an artifact refers to an application or a subscription
+	// that is not stored.
+	ErrMissingReference = errors.New("referenced artifact does not exist")
+)
+
+// Repository stores the control plane artifacts. The implementations behave the same, which is
+// checked by the conformance tests: updating or deleting an artifact that is not stored is not an
+// error, and deleting an application or a subscription deletes the mappings that refer to it.
+type Repository interface {
+	// Transact runs fn in a transaction, which is committed if fn returns nil and rolled back otherwise.
+	Transact(ctx context.Context, fn func(tx Tx) error) error
+	// Ping checks the store can be reached.
+	Ping(ctx context.Context) error
+	// Close releases the store.
+	Close() error
+}
+
+// Tx reads and writes the artifacts within a transaction. Applications are stored with their
+// attributes, and key mappings are identified by the application, security scheme, key type and
+// environment.
+type Tx interface {
+	AddApplication(application model.Application) error
+	UpdateApplication(application model.Application) error
+	DeleteApplication(uuid string) error
+	DeleteAllApplications() error
+	GetAllApplications() ([]model.Application, error)
+
+	AddSubscription(subscription model.Subscription) error
+	UpdateSubscription(subscription model.Subscription) error
+	DeleteSubscription(uuid string) error
+	DeleteAllSubscriptions() error
+	GetAllSubscriptions() ([]model.Subscription, error)
+
+	AddApplicationMapping(applicationMapping model.ApplicationMapping) error
+	UpdateApplicationMapping(applicationMapping model.ApplicationMapping) error
+	DeleteApplicationMapping(uuid string) error
+	DeleteAllApplicationMappings() error
+	GetAllApplicationMappings() ([]model.ApplicationMapping, error)
+
+	AddApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error
+	UpdateApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error
+	DeleteApplicationKeyMapping(keyMapping model.ApplicationKeyMapping) error
+	DeleteAllApplicationKeyMappings() error
+	GetAllApplicationKeyMappings() ([]model.ApplicationKeyMapping, error)
+}
+
+// IsDBPersistence reports whether the persistence type keeps the control plane artifacts in a
+// Repository instead of custom resources.
+func IsDBPersistence(persistenceType string) bool {
+	switch {
+	case strings.EqualFold(persistenceType, PersistenceDB),
+		strings.EqualFold(persistenceType, PersistencePostgreSQL),
+		strings.EqualFold(persistenceType, PersistenceMySQL),
+		strings.EqualFold(persistenceType, PersistenceMSSQL),
+		strings.EqualFold(persistenceType, PersistenceEmbedded):
+		return true
+	}
+	return false
+}
+
+// IsEnabled reports whether the common controller uses a Repository, either as the persistence of
+// the control plane artifacts or through the database config.
+func IsEnabled(conf *config.Config) bool {
+	controlPlane := conf.CommonController.ControlPlane
+	return conf.CommonController.Database.Enabled || (controlPlane.Enabled && IsDBPersistence(controlPlane.Persistence.Type))
+}
+
+// Open opens the Repository of the persistence type and migrates its schema. The database config
+// is used for the SQL databases, which are only checked for pending migrations when the database
+// config disables autoMigrate.
+func Open(conf *config.Config) (Repository, error) {
+	persistence := conf.CommonController.ControlPlane.Persistence
+	if strings.EqualFold(persistence.Type, PersistenceEmbedded) {
+		return openEmbeddedRepository(persistence.Path)
+	}
+	d, err := sqlDialect(conf)
+	if err != nil {
+		return nil, err
+	}
+	return openSQLRepository(d, conf)
+}
+
+// sqlDialect returns the dialect of the SQL database of the persistence type. PostgreSQL, the
+// database the common controller used before the persistence type chose one, is also used when the
+// database is enabled for the K8s persistence.
+func sqlDialect(conf *config.Config) (dialect, error) {
+	persistence := conf.CommonController.ControlPlane.Persistence
+	switch {
+	case strings.EqualFold(persistence.Type, PersistenceMySQL):
+		return mysqlDialect, nil
+	case strings.EqualFold(persistence.Type, PersistenceMSSQL):
+		return mssqlDialect, nil
+	case strings.EqualFold(persistence.Type, PersistenceDB), strings.EqualFold(persistence.Type, PersistencePostgreSQL),
+		conf.CommonController.Database.Enabled:
+		return postgresDialect, nil
+	}
+	return dialect{}, fmt.Errorf("persistence type %q is not a database", persistence.Type)
+}
@@ -0,0 +1,107 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/wso2/apk/common-controller/internal/config"
+	"github.com/wso2/apk/common-controller/internal/loggers"
+)
+
+// sqlRepository is the Repository of the SQL databases.
+type sqlRepository struct {
+	db      *sql.DB
+	dialect dialect
+	// onClose releases what the database was opened through, if anything.
+	onClose func()
+}
+
+// openSQLRepository opens the database of the config and applies its pending migrations, or checks
+// there are none when the migrations are not applied automatically.
+func openSQLRepository(d dialect, conf *config.Config) (Repository, error) {
+	repository, err := openSQLDatabase(d, conf)
+	if err != nil {
+		return nil, err
+	}
+	if conf.CommonController.Database.AutoMigrate {
+		_, err = repository.migrateUp(context.Background())
+	} else {
+		err = repository.checkMigrated(context.Background())
+	}
+	if err != nil {
+		repository.Close()
+		return nil, err
+	}
+	return repository, nil
+}
+
+// openSQLDatabase opens the database of the config without migrating it.
+func openSQLDatabase(d dialect, conf *config.Config) (*sqlRepository, error) {
+	db, onClose, err := d.open(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the %s database: %w", d.name, err)
+	}
+	return &sqlRepository{db: db, dialect: d, onClose: onClose}, nil
+}
+
+// newSQLRepository applies the pending migrations of the database and returns its Repository.
+func newSQLRepository(d dialect, db *sql.DB, onClose func()) (Repository, error) {
+	repository := &sqlRepository{db: db, dialect: d, onClose: onClose}
+	if _, err := repository.migrateUp(context.Background()); err != nil {
+		repository.Close()
+		return nil, err
+	}
+	return repository, nil
+}
+
+// Transact runs fn in a transaction
+func (r *sqlRepository) Transact(ctx context.Context, fn func(tx Tx) error) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error while beginning the transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				loggers.LoggerAPI.Error("Error while rolling back the transaction ", rollbackErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("error while committing the transaction: %w", err)
+		}
+	}()
+	return fn(&sqlTx{ctx: ctx, tx: tx, dialect: r.dialect})
+}
+
+// Ping checks the database can be reached
+func (r *sqlRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Close closes the database
+func (r *sqlRepository) Close() error {
+	err := r.db.Close()
+	if r.onClose != nil {
+		r.onClose()
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	query := "UPDATE APPLICATION SET NAME = ?, OWNER = ? WHERE UUID = ?"
+	assert.Equal(t, "UPDATE APPLICATION SET NAME = $1, OWNER = $2 WHERE UUID = $3", postgresDialect.rebind(query))
+	assert.Equal(t, query, mysqlDialect.rebind(query))
+	assert.Equal(t, "UPDATE APPLICATION SET NAME = @p1, OWNER = @p2 WHERE UUID = @p3", mssqlDialect.rebind(query))
+}
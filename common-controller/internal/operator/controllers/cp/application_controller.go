@@ -19,10 +19,12 @@ package cp
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/wso2/apk/adapter/pkg/logging"
 	"github.com/wso2/apk/common-controller/internal/cache"
 	"github.com/wso2/apk/common-controller/internal/loggers"
+	"github.com/wso2/apk/common-controller/internal/operator/status"
 	"github.com/wso2/apk/common-controller/internal/server"
 	"github.com/wso2/apk/common-go-libs/pkg/server/model"
 	"github.com/wso2/apk/common-controller/internal/utils"
@@ -30,6 +32,7 @@ import (
 	"github.com/wso2/apk/common-go-libs/constants"
 	k8error "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -42,16 +45,22 @@ import (
 
 // ApplicationReconciler reconciles a Application object
 type ApplicationReconciler struct {
-	client client.Client
-	Scheme *runtime.Scheme
-	ods    *cache.SubscriptionDataStore
+	client        client.Client
+	Scheme        *runtime.Scheme
+	ods           *cache.SubscriptionDataStore
+	statusUpdater *status.UpdateHandler
+	recorder      record.EventRecorder
+	propagations  *propagations
 }
 
 // NewApplicationController creates a new Application controller instance
-func NewApplicationController(mgr manager.Manager, subscriptionStore *cache.SubscriptionDataStore) error {
+func NewApplicationController(mgr manager.Manager, subscriptionStore *cache.SubscriptionDataStore, statusUpdater *status.UpdateHandler) error {
 	r := &ApplicationReconciler{
-		client: mgr.GetClient(),
-		ods:    subscriptionStore,
+		client:        mgr.GetClient(),
+		ods:           subscriptionStore,
+		statusUpdater: statusUpdater,
+		recorder:      mgr.GetEventRecorderFor(eventRecorderName),
+		propagations:  newPropagations(),
 	}
 	c, err := controller.New(constants.ApplicationController, mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -60,10 +69,15 @@ func NewApplicationController(mgr manager.Manager, subscriptionStore *cache.Subs
 	}
 
 	if err := c.Watch(source.Kind(mgr.GetCache(), &cpv1alpha2.Application{}, &handler.TypedEnqueueRequestForObject[*cpv1alpha2.Application]{},
-		predicate.NewTypedPredicateFuncs(utils.FilterAppByNamespaces([]string{utils.GetOperatorPodNamespace()})))); err != nil {
+		predicate.NewTypedPredicateFuncs(utils.FilterAppByNamespaces([]string{utils.GetOperatorPodNamespace()})),
+		predicate.TypedGenerationChangedPredicate[*cpv1alpha2.Application]{})); err != nil {
 		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2607, logging.BLOCKER, "Error watching Application resources: %v", err.Error()))
 		return err
 	}
+	if err := c.Watch(r.propagations.source()); err != nil {
+		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2607, logging.BLOCKER, "Error watching Application event acknowledgements: %v", err.Error()))
+		return err
+	}
 
 	loggers.LoggerAPKOperator.Debug("Application Controller successfully started. Watching Application Objects...")
 	return nil
@@ -95,6 +109,7 @@ func (applicationReconciler *ApplicationReconciler) Reconcile(ctx context.Contex
 			loggers.LoggerAPKOperator.Debugf("cached Application spec: %v,%v", applicationSpec, found)
 			if found {
 				utils.SendAppDeletionEvent(applicationKey.Name, applicationSpec)
+				applicationReconciler.propagations.delete(applicationKey)
 				applicationReconciler.ods.DeleteApplicationFromStore(applicationKey)
 				server.DeleteApplication(applicationKey.Name)
 			} else {
@@ -103,21 +118,63 @@ func (applicationReconciler *ApplicationReconciler) Reconcile(ctx context.Contex
 		}
 	} else {
 		loggers.LoggerAPKOperator.Debugf("Application cr available in k8s")
-		applicationSpec, found := applicationReconciler.ods.GetApplicationFromStore(applicationKey)
-		if found {
-			// update
-			loggers.LoggerAPKOperator.Debugf("Application in ods")
-			utils.SendAppUpdateEvent(applicationKey.Name, applicationSpec, application.Spec)
-		} else {
-			loggers.LoggerAPKOperator.Debugf("Application in ods consider as update")
-			utils.SendAddApplicationEvent(application)
+		invalid := validateApplication(application.Spec)
+		var delivery *utils.EventDelivery
+		var pending []string
+		if invalid == "" {
+			revision := revisionOf(&application)
+			sent, found := applicationReconciler.propagations.get(applicationKey, revision)
+			if !found {
+				sent = applicationReconciler.sendApplication(application)
+				applicationReconciler.propagations.record(&application, revision, sent)
+			}
+			delivery = &sent
+			pending = utils.PendingEventAcks(sent)
 		}
-		applicationReconciler.ods.AddorUpdateApplicationToStore(applicationKey, application.Spec)
-		applicationReconciler.sendAppUpdates(application, found)
+		reportStatus(applicationReconciler.statusUpdater, applicationReconciler.recorder, &application,
+			acceptedCondition(invalid),
+			resolvedRefsCondition(constants.ReasonResolvedRefs, "The application has no references"),
+			propagatedCondition(delivery, pending))
 	}
 	return ctrl.Result{}, nil
 }
 
+// validateApplication returns why the application spec is not valid, empty if it is. The key mappings
+// of an application are identified by their environment and key type, which must not repeat.
+func validateApplication(spec cpv1alpha2.ApplicationSpec) string {
+	if spec.SecuritySchemes == nil || spec.SecuritySchemes.OAuth2 == nil {
+		return ""
+	}
+	keys := map[string]bool{}
+	for _, env := range spec.SecuritySchemes.OAuth2.Environments {
+		key := env.EnvID + "/" + env.KeyType
+		if keys[key] {
+			return fmt.Sprintf("The OAuth2 security scheme has more than one %s key for the environment %s",
+				env.KeyType, env.EnvID)
+		}
+		keys[key] = true
+	}
+	return ""
+}
+
+// sendApplication sends the events of an application to the enforcers and updates the stores.
+func (applicationReconciler *ApplicationReconciler) sendApplication(application cpv1alpha2.Application) utils.EventDelivery {
+	applicationKey := utils.NamespacedName(&application)
+	applicationSpec, found := applicationReconciler.ods.GetApplicationFromStore(applicationKey)
+	var delivery utils.EventDelivery
+	if found {
+		// update
+		loggers.LoggerAPKOperator.Debugf("Application in ods")
+		delivery = utils.SendAppUpdateEvent(applicationKey.Name, applicationSpec, application.Spec)
+	} else {
+		loggers.LoggerAPKOperator.Debugf("Application in ods consider as update")
+		delivery = utils.SendAddApplicationEvent(application)
+	}
+	applicationReconciler.ods.AddorUpdateApplicationToStore(applicationKey, application.Spec)
+	applicationReconciler.sendAppUpdates(application, found)
+	return delivery
+}
+
 func (applicationReconciler *ApplicationReconciler) sendAppUpdates(application cpv1alpha2.Application, update bool) {
 	resolvedApplication := marshalApplication(application)
 	if update {
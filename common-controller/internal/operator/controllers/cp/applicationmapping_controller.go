@@ -19,17 +19,20 @@ package cp
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/wso2/apk/adapter/pkg/logging"
 	"github.com/wso2/apk/common-controller/internal/cache"
 	"github.com/wso2/apk/common-controller/internal/config"
 	"github.com/wso2/apk/common-controller/internal/loggers"
+	"github.com/wso2/apk/common-controller/internal/operator/status"
 	"github.com/wso2/apk/common-controller/internal/server"
 	"github.com/wso2/apk/common-go-libs/pkg/server/model"
 	k8error "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	k8client "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -49,9 +52,12 @@ import (
 
 // ApplicationMappingReconciler reconciles a ApplicationMapping object
 type ApplicationMappingReconciler struct {
-	client k8client.Client
-	Scheme *runtime.Scheme
-	ods    *cache.SubscriptionDataStore
+	client        k8client.Client
+	Scheme        *runtime.Scheme
+	ods           *cache.SubscriptionDataStore
+	statusUpdater *status.UpdateHandler
+	recorder      record.EventRecorder
+	propagations  *propagations
 }
 
 const (
@@ -60,10 +66,13 @@ const (
 )
 
 // NewApplicationMappingController creates a new Application and Subscription mapping (i.e. ApplicationMapping) controller instance
-func NewApplicationMappingController(mgr manager.Manager, subscriptionStore *cache.SubscriptionDataStore) error {
+func NewApplicationMappingController(mgr manager.Manager, subscriptionStore *cache.SubscriptionDataStore, statusUpdater *status.UpdateHandler) error {
 	r := &ApplicationMappingReconciler{
-		client: mgr.GetClient(),
-		ods:    subscriptionStore,
+		client:        mgr.GetClient(),
+		ods:           subscriptionStore,
+		statusUpdater: statusUpdater,
+		recorder:      mgr.GetEventRecorderFor(eventRecorderName),
+		propagations:  newPropagations(),
 	}
 	ctx := context.Background()
 	conf := config.ReadConfigs()
@@ -79,19 +88,26 @@ func NewApplicationMappingController(mgr manager.Manager, subscriptionStore *cac
 	}
 
 	if err := c.Watch(source.Kind(mgr.GetCache(), &cpv1alpha2.ApplicationMapping{}, &handler.TypedEnqueueRequestForObject[*cpv1alpha2.ApplicationMapping]{},
-		predicate.NewTypedPredicateFuncs(utils.FilterAppMappingByNamespaces([]string{utils.GetOperatorPodNamespace()})))); err != nil {
+		predicate.NewTypedPredicateFuncs(utils.FilterAppMappingByNamespaces([]string{utils.GetOperatorPodNamespace()})),
+		predicate.TypedGenerationChangedPredicate[*cpv1alpha2.ApplicationMapping]{})); err != nil {
 		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2611, logging.BLOCKER, "Error watching ApplicationMapping resources: %v", err.Error()))
 		return err
 	}
+	if err := c.Watch(r.propagations.source()); err != nil {
+		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2611, logging.BLOCKER, "Error watching ApplicationMapping event acknowledgements: %v", err.Error()))
+		return err
+	}
 
-	predicateApp := []predicate.TypedPredicate[*cpv1alpha2.Application]{predicate.NewTypedPredicateFuncs(utils.FilterAppByNamespaces(conf.CommonController.Operator.Namespaces))}
+	predicateApp := []predicate.TypedPredicate[*cpv1alpha2.Application]{predicate.NewTypedPredicateFuncs(utils.FilterAppByNamespaces(conf.CommonController.Operator.Namespaces)),
+		predicate.TypedGenerationChangedPredicate[*cpv1alpha2.Application]{}}
 	if err := c.Watch(source.Kind(mgr.GetCache(), &cpv1alpha2.Application{}, handler.TypedEnqueueRequestsFromMapFunc(r.getApplicationMappingsForApplication),
 		predicateApp...)); err != nil {
 		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2613, logging.BLOCKER, "Error watching Application resources: %v", err))
 		return err
 	}
 
-	predicateSubs := []predicate.TypedPredicate[*cpv1alpha3.Subscription]{predicate.NewTypedPredicateFuncs(utils.FilterSubsByNamespaces(conf.CommonController.Operator.Namespaces))}
+	predicateSubs := []predicate.TypedPredicate[*cpv1alpha3.Subscription]{predicate.NewTypedPredicateFuncs(utils.FilterSubsByNamespaces(conf.CommonController.Operator.Namespaces)),
+		predicate.TypedGenerationChangedPredicate[*cpv1alpha3.Subscription]{}}
 	if err := c.Watch(source.Kind(mgr.GetCache(), &cpv1alpha3.Subscription{}, handler.TypedEnqueueRequestsFromMapFunc(r.getApplicationMappingsForSubscription),
 		predicateSubs...)); err != nil {
 		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2613, logging.BLOCKER, "Error watching Subscription resources: %v", err))
@@ -128,6 +144,7 @@ func (r *ApplicationMappingReconciler) Reconcile(ctx context.Context, req ctrl.R
 				loggers.LoggerAPKOperator.Debugf("Application mapping %s/%s found in operator data store. Deleting from operator data store and sending delete event to server", applicationMappingKey.Namespace, applicationMappingKey.Name)
 				resolvedApplicationMapping := server.GetApplicationMappingFromStore(applicationMappingKey.Name)
 				utils.SendDeleteApplicationMappingEvent(applicationMappingKey.Name, applicationMapping, resolvedApplicationMapping.OrganizationID)
+				r.propagations.delete(applicationMappingKey)
 				r.ods.DeleteApplicationMappingFromStore(applicationMappingKey)
 				server.DeleteApplicationMapping(applicationMappingKey.Name)
 			} else {
@@ -138,21 +155,47 @@ func (r *ApplicationMappingReconciler) Reconcile(ctx context.Context, req ctrl.R
 		var application cpv1alpha2.Application
 		if err := r.client.Get(ctx, types.NamespacedName{Name: string(applicationMapping.Spec.ApplicationRef), Namespace: applicationMapping.Namespace}, &application); err != nil {
 			loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2614, logging.CRITICAL, "Error getting Application: %v", err))
+			if k8error.IsNotFound(err) {
+				r.reportUnresolved(&applicationMapping, constants.ReasonApplicationNotFound,
+					fmt.Sprintf("The application %s is not found", applicationMapping.Spec.ApplicationRef))
+			}
 			return ctrl.Result{}, nil
 		}
 		var subscription cpv1alpha3.Subscription
 		if err := r.client.Get(ctx, types.NamespacedName{Name: string(applicationMapping.Spec.SubscriptionRef), Namespace: applicationMapping.Namespace}, &subscription); err != nil {
 			loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2615, logging.CRITICAL, "Error getting Subscription: %v", err))
+			if k8error.IsNotFound(err) {
+				r.reportUnresolved(&applicationMapping, constants.ReasonSubscriptionNotFound,
+					fmt.Sprintf("The subscription %s is not found", applicationMapping.Spec.SubscriptionRef))
+			}
 			return ctrl.Result{}, nil
 		}
 		loggers.LoggerAPKOperator.Debugf("Reconsile completed Application mapping :%v,Subscription %v application : %v", applicationMapping, subscription, application)
-		sendUpdates(&applicationMapping, application, subscription)
-		utils.SendCreateApplicationMappingEvent(applicationMapping, application, subscription)
-		r.ods.AddorUpdateApplicationMappingToStore(applicationMappingKey, applicationMapping.Spec)
+		revision := revisionOf(&applicationMapping, &application, &subscription)
+		delivery, found := r.propagations.get(applicationMappingKey, revision)
+		if !found {
+			sendUpdates(&applicationMapping, application, subscription)
+			delivery = utils.SendCreateApplicationMappingEvent(applicationMapping, application, subscription)
+			r.ods.AddorUpdateApplicationMappingToStore(applicationMappingKey, applicationMapping.Spec)
+			r.propagations.record(&applicationMapping, revision, delivery)
+		}
+		reportStatus(r.statusUpdater, r.recorder, &applicationMapping,
+			acceptedCondition(""),
+			resolvedRefsCondition(constants.ReasonResolvedRefs, "The application and the subscription are resolved"),
+			propagatedCondition(&delivery, utils.PendingEventAcks(delivery)))
 	}
 	return ctrl.Result{}, nil
 }
 
+// reportUnresolved reports that an application mapping is not sent to the enforcers as it refers to an
+// application or a subscription that does not exist. It is reconciled again when that is created.
+func (r *ApplicationMappingReconciler) reportUnresolved(applicationMapping *cpv1alpha2.ApplicationMapping, reason, message string) {
+	reportStatus(r.statusUpdater, r.recorder, applicationMapping,
+		acceptedCondition(""),
+		resolvedRefsCondition(reason, message),
+		propagatedCondition(nil, nil))
+}
+
 func sendUpdates(applicationMapping *cpv1alpha2.ApplicationMapping, application cpv1alpha2.Application, subscription cpv1alpha3.Subscription) {
 	resolvedApplication := marshalApplication(application)
 	appMapping := marshalApplicationMapping(applicationMapping, resolvedApplication)
@@ -0,0 +1,219 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/wso2/apk/common-controller/internal/operator/status"
+	"github.com/wso2/apk/common-controller/internal/utils"
+	cpv1alpha2 "github.com/wso2/apk/common-go-libs/apis/cp/v1alpha2"
+	cpv1alpha3 "github.com/wso2/apk/common-go-libs/apis/cp/v1alpha3"
+	"github.com/wso2/apk/common-go-libs/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	k8client "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// eventRecorderName is the source of the Kubernetes events of the controllers.
+const eventRecorderName = "common-controller"
+
+// propagations records the events sent for the CRs of a controller, so that reconciling a CR again
+// recomputes its Propagated condition instead of sending its events again. A CR is reconciled again
+// each time the enforcers acknowledge one of its events.
+type propagations struct {
+	mutex sync.Mutex
+	sent  map[types.NamespacedName]propagation
+	acked chan event.GenericEvent
+}
+
+// propagation is the events sent for a revision of a CR, which changes with the CR or the CRs it refers to.
+type propagation struct {
+	revision string
+	delivery utils.EventDelivery
+}
+
+// revisionOf returns the revision of the events of a CR sent with the CRs it refers to.
+func revisionOf(objs ...k8client.Object) string {
+	revisions := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		revisions = append(revisions, fmt.Sprintf("%s/%d", obj.GetUID(), obj.GetGeneration()))
+	}
+	return strings.Join(revisions, ",")
+}
+
+func newPropagations() *propagations {
+	return &propagations{
+		sent:  make(map[types.NamespacedName]propagation),
+		acked: make(chan event.GenericEvent, 1024),
+	}
+}
+
+// source returns the source of the reconcile requests of the CRs whose events are acknowledged.
+func (p *propagations) source() source.Source {
+	return source.Channel(p.acked, &handler.EnqueueRequestForObject{})
+}
+
+// get returns the events sent for a revision of a CR.
+func (p *propagations) get(key types.NamespacedName, revision string) (utils.EventDelivery, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	sent, found := p.sent[key]
+	if !found || sent.revision != revision {
+		return utils.EventDelivery{}, false
+	}
+	return sent.delivery, true
+}
+
+// record records the events sent for a revision of a CR.
+func (p *propagations) record(obj k8client.Object, revision string, delivery utils.EventDelivery) {
+	p.mutex.Lock()
+	p.sent[utils.NamespacedName(obj)] = propagation{revision: revision, delivery: delivery}
+	p.mutex.Unlock()
+	acked := obj.DeepCopyObject().(k8client.Object)
+	utils.NotifyOnEventAcks(delivery, func() {
+		p.acked <- event.GenericEvent{Object: acked}
+	})
+}
+
+// delete forgets the events sent for a deleted CR.
+func (p *propagations) delete(key types.NamespacedName) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.sent, key)
+}
+
+// acceptedCondition returns the Accepted condition of a spec, invalid being why it is not valid.
+func acceptedCondition(invalid string) metav1.Condition {
+	if invalid != "" {
+		return newCondition(constants.ConditionAccepted, metav1.ConditionFalse, constants.ReasonInvalid,
+			invalid+". The enforcers keep the last accepted spec")
+	}
+	return newCondition(constants.ConditionAccepted, metav1.ConditionTrue, constants.ReasonAccepted, "The spec is valid")
+}
+
+// resolvedRefsCondition returns the ResolvedRefs condition, which is true for the ResolvedRefs reason.
+func resolvedRefsCondition(reason, message string) metav1.Condition {
+	conditionStatus := metav1.ConditionFalse
+	if reason == constants.ReasonResolvedRefs {
+		conditionStatus = metav1.ConditionTrue
+	}
+	return newCondition(constants.ConditionResolvedRefs, conditionStatus, reason, message)
+}
+
+// propagatedCondition returns the Propagated condition of the events sent for a CR, nil if none were
+// sent, pending being the connected enforcers that did not acknowledge them. The enforcers an event
+// could not be sent to, and those connecting later, load all the artifacts when they connect.
+func propagatedCondition(delivery *utils.EventDelivery, pending []string) metav1.Condition {
+	if delivery == nil {
+		return newCondition(constants.ConditionPropagated, metav1.ConditionFalse, constants.ReasonNotPropagated,
+			"Not sent to the enforcers as it is not accepted or its references are not resolved")
+	}
+	if delivery.Enforcers == 0 {
+		return newCondition(constants.ConditionPropagated, metav1.ConditionUnknown, constants.ReasonNoEnforcers,
+			"No enforcer is connected, the enforcers load it when they connect")
+	}
+	var failed []string
+	for _, clientID := range pending {
+		if slices.Contains(delivery.Failed, clientID) {
+			failed = append(failed, clientID)
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		return newCondition(constants.ConditionPropagated, metav1.ConditionFalse, constants.ReasonPropagationFailed,
+			fmt.Sprintf("Failed to send to the enforcers %s, which load it when they connect again", strings.Join(failed, ", ")))
+	case len(pending) > 0:
+		return newCondition(constants.ConditionPropagated, metav1.ConditionUnknown, constants.ReasonPendingAck,
+			fmt.Sprintf("Waiting for the enforcers %s to acknowledge it", strings.Join(pending, ", ")))
+	}
+	return newCondition(constants.ConditionPropagated, metav1.ConditionTrue, constants.ReasonPropagated,
+		"Acknowledged by the connected enforcers")
+}
+
+func newCondition(conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// reportStatus records an event for each condition that changed since the CR was read, and sends the
+// conditions to the status update handler. The conditions are not set if the CR changed in the
+// meantime, as its next reconcile sets them.
+func reportStatus(statusUpdater *status.UpdateHandler, recorder record.EventRecorder, obj k8client.Object,
+	conditions ...metav1.Condition) {
+	generation := obj.GetGeneration()
+	_, current, ok := statusOf(obj)
+	if !ok {
+		return
+	}
+	for _, condition := range conditions {
+		previous := meta.FindStatusCondition(*current, condition.Type)
+		if previous != nil && previous.Status == condition.Status && previous.Reason == condition.Reason &&
+			previous.ObservedGeneration == generation {
+			continue
+		}
+		eventType := corev1.EventTypeNormal
+		if condition.Status == metav1.ConditionFalse {
+			eventType = corev1.EventTypeWarning
+		}
+		recorder.Event(obj, eventType, condition.Reason, condition.Message)
+	}
+	statusUpdater.Send(status.Update{
+		NamespacedName: utils.NamespacedName(obj),
+		Resource:       obj.DeepCopyObject().(k8client.Object),
+		UpdateStatus: func(latest k8client.Object) k8client.Object {
+			updated := latest.DeepCopyObject().(k8client.Object)
+			if updated.GetGeneration() != generation {
+				return updated
+			}
+			observedGeneration, updatedConditions, _ := statusOf(updated)
+			*observedGeneration = generation
+			for _, condition := range conditions {
+				condition.ObservedGeneration = generation
+				meta.SetStatusCondition(updatedConditions, condition)
+			}
+			return updated
+		},
+	})
+}
+
+// statusOf returns the status fields of a CR of the controllers.
+func statusOf(obj k8client.Object) (*int64, *[]metav1.Condition, bool) {
+	switch cr := obj.(type) {
+	case *cpv1alpha2.Application:
+		return &cr.Status.ObservedGeneration, &cr.Status.Conditions, true
+	case *cpv1alpha2.ApplicationMapping:
+		return &cr.Status.ObservedGeneration, &cr.Status.Conditions, true
+	case *cpv1alpha3.Subscription:
+		return &cr.Status.ObservedGeneration, &cr.Status.Conditions, true
+	}
+	return nil, nil, false
+}
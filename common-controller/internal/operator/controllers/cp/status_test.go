@@ -0,0 +1,129 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package cp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wso2/apk/common-controller/internal/operator/status"
+	"github.com/wso2/apk/common-controller/internal/utils"
+	cpv1alpha2 "github.com/wso2/apk/common-go-libs/apis/cp/v1alpha2"
+	cpv1alpha3 "github.com/wso2/apk/common-go-libs/apis/cp/v1alpha3"
+	"github.com/wso2/apk/common-go-libs/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestPropagatedCondition(t *testing.T) {
+	condition := propagatedCondition(nil, nil)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, constants.ReasonNotPropagated, condition.Reason)
+
+	condition = propagatedCondition(&utils.EventDelivery{}, nil)
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	assert.Equal(t, constants.ReasonNoEnforcers, condition.Reason)
+
+	delivery := &utils.EventDelivery{Events: []string{"event-1"}, Enforcers: 2, Failed: []string{"enforcer-1"}}
+	condition = propagatedCondition(delivery, []string{"enforcer-1", "enforcer-2"})
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, constants.ReasonPropagationFailed, condition.Reason)
+	assert.Contains(t, condition.Message, "enforcer-1")
+	assert.NotContains(t, condition.Message, "enforcer-2")
+
+	// The enforcer the event could not be sent to disconnected, and loads it when it connects again.
+	condition = propagatedCondition(delivery, []string{"enforcer-2"})
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	assert.Equal(t, constants.ReasonPendingAck, condition.Reason)
+	assert.Contains(t, condition.Message, "enforcer-2")
+
+	condition = propagatedCondition(delivery, nil)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, constants.ReasonPropagated, condition.Reason)
+}
+
+func TestPropagations(t *testing.T) {
+	p := newPropagations()
+	application := &cpv1alpha2.Application{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "apk", UID: "uid-1", Generation: 1}}
+	revision := revisionOf(application)
+	_, found := p.get(utils.NamespacedName(application), revision)
+	assert.False(t, found)
+
+	p.record(application, revision, utils.EventDelivery{Events: []string{"event-1"}, Enforcers: 1})
+	delivery, found := p.get(utils.NamespacedName(application), revision)
+	assert.True(t, found)
+	assert.Equal(t, []string{"event-1"}, delivery.Events)
+
+	application.Generation = 2
+	_, found = p.get(utils.NamespacedName(application), revisionOf(application))
+	assert.False(t, found)
+	// A CR created again with the same name is a new revision.
+	application.UID, application.Generation = "uid-2", 1
+	_, found = p.get(utils.NamespacedName(application), revisionOf(application))
+	assert.False(t, found)
+
+	p.delete(utils.NamespacedName(application))
+	_, found = p.get(utils.NamespacedName(application), revision)
+	assert.False(t, found)
+}
+
+func TestResolvedRefsCondition(t *testing.T) {
+	assert.Equal(t, metav1.ConditionTrue, resolvedRefsCondition(constants.ReasonResolvedRefs, "").Status)
+	assert.Equal(t, metav1.ConditionFalse, resolvedRefsCondition(constants.ReasonRatelimitNotFound, "").Status)
+}
+
+func TestValidateApplication(t *testing.T) {
+	spec := cpv1alpha2.ApplicationSpec{
+		SecuritySchemes: &cpv1alpha2.SecuritySchemes{
+			OAuth2: &cpv1alpha2.SecurityScheme{
+				Environments: []cpv1alpha2.Environment{
+					{EnvID: "Default", KeyType: "PRODUCTION"},
+					{EnvID: "Default", KeyType: "SANDBOX"},
+				},
+			},
+		},
+	}
+	assert.Empty(t, validateApplication(spec))
+	assert.Empty(t, validateApplication(cpv1alpha2.ApplicationSpec{}))
+
+	spec.SecuritySchemes.OAuth2.Environments = append(spec.SecuritySchemes.OAuth2.Environments,
+		cpv1alpha2.Environment{EnvID: "Default", KeyType: "PRODUCTION"})
+	assert.NotEmpty(t, validateApplication(spec))
+	assert.Equal(t, metav1.ConditionFalse, acceptedCondition(validateApplication(spec)).Status)
+}
+
+func TestValidateSubscription(t *testing.T) {
+	assert.Empty(t, validateSubscription(cpv1alpha3.SubscriptionSpec{}))
+	assert.Empty(t, validateSubscription(cpv1alpha3.SubscriptionSpec{API: cpv1alpha3.API{Name: "api", Version: "1.0"}}))
+	assert.NotEmpty(t, validateSubscription(cpv1alpha3.SubscriptionSpec{API: cpv1alpha3.API{Name: "api"}}))
+	assert.NotEmpty(t, validateSubscription(cpv1alpha3.SubscriptionSpec{API: cpv1alpha3.API{Version: "1.0"}}))
+}
+
+func TestReportStatusRecordsChangedConditions(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	application := &cpv1alpha2.Application{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "apk", Generation: 2}}
+	application.Status.Conditions = []metav1.Condition{
+		{Type: constants.ConditionAccepted, Status: metav1.ConditionTrue, Reason: constants.ReasonAccepted, ObservedGeneration: 2},
+	}
+	reportStatus(status.NewUpdateHandler(nil), recorder, application,
+		acceptedCondition(""),
+		propagatedCondition(&utils.EventDelivery{}, nil))
+
+	assert.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Normal "+constants.ReasonNoEnforcers)
+}
@@ -19,40 +19,50 @@ package cp
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/wso2/apk/adapter/pkg/logging"
 	"github.com/wso2/apk/common-controller/internal/cache"
 	// "github.com/wso2/apk/common-controller/internal/config"
 	loggers "github.com/wso2/apk/common-controller/internal/loggers"
+	"github.com/wso2/apk/common-controller/internal/operator/status"
 	"github.com/wso2/apk/common-controller/internal/server"
 	"github.com/wso2/apk/common-go-libs/pkg/server/model"
 	"github.com/wso2/apk/common-controller/internal/utils"
 	"github.com/wso2/apk/common-go-libs/constants"
 	k8error "k8s.io/apimachinery/pkg/api/errors"
-	// "k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	k8client "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
-	// "sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	cpv1alpha3 "github.com/wso2/apk/common-go-libs/apis/cp/v1alpha3"
-	// dpv1alpha3 "github.com/wso2/apk/common-go-libs/apis/dp/v1alpha3"
+	dpv1alpha3 "github.com/wso2/apk/common-go-libs/apis/dp/v1alpha3"
 )
 
 // SubscriptionReconciler reconciles a Subscription object
 type SubscriptionReconciler struct {
-	client client.Client
-	Scheme *runtime.Scheme
-	ods    *cache.SubscriptionDataStore
-	rlODS  *cache.RatelimitDataStore
+	client        client.Client
+	Scheme        *runtime.Scheme
+	ods           *cache.SubscriptionDataStore
+	rlODS         *cache.RatelimitDataStore
+	statusUpdater *status.UpdateHandler
+	recorder      record.EventRecorder
+	propagations  *propagations
+	// ratelimits holds the ratelimit kinds served by the cluster, which are watched for the subscriptions.
+	ratelimits []client.Object
 }
 
 const (
@@ -61,10 +71,13 @@ const (
 )
 
 // NewSubscriptionController creates a new Subscription controller instance.
-func NewSubscriptionController(mgr manager.Manager, subscriptionStore *cache.SubscriptionDataStore) error {
+func NewSubscriptionController(mgr manager.Manager, subscriptionStore *cache.SubscriptionDataStore, statusUpdater *status.UpdateHandler) error {
 	r := &SubscriptionReconciler{
-		client: mgr.GetClient(),
-		ods:    subscriptionStore,
+		client:        mgr.GetClient(),
+		ods:           subscriptionStore,
+		statusUpdater: statusUpdater,
+		recorder:      mgr.GetEventRecorderFor(eventRecorderName),
+		propagations:  newPropagations(),
 	}
 	ctx := context.Background()
 	// conf := config.ReadConfigs()
@@ -79,10 +92,33 @@ func NewSubscriptionController(mgr manager.Manager, subscriptionStore *cache.Sub
 	}
 
 	if err := c.Watch(source.Kind(mgr.GetCache(), &cpv1alpha3.Subscription{}, &handler.TypedEnqueueRequestForObject[*cpv1alpha3.Subscription]{},
-		predicate.NewTypedPredicateFuncs(utils.FilterSubsByNamespaces([]string{utils.GetOperatorPodNamespace()})))); err != nil {
+		predicate.NewTypedPredicateFuncs(utils.FilterSubsByNamespaces([]string{utils.GetOperatorPodNamespace()})),
+		predicate.TypedGenerationChangedPredicate[*cpv1alpha3.Subscription]{})); err != nil {
 		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2609, logging.BLOCKER, "Error watching Subscription resources: %v", err.Error()))
 		return err
 	}
+	if err := c.Watch(r.propagations.source()); err != nil {
+		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2609, logging.BLOCKER, "Error watching Subscription event acknowledgements: %v", err.Error()))
+		return err
+	}
+
+	// The ratelimit CRDs are optional, a ratelimit kind is watched only if the cluster serves it.
+	if served(mgr, &dpv1alpha3.RateLimitPolicy{}) {
+		if err := c.Watch(source.Kind(mgr.GetCache(), &dpv1alpha3.RateLimitPolicy{}, handler.TypedEnqueueRequestsFromMapFunc(r.getSubscriptionsForRatelimit),
+			predicate.TypedGenerationChangedPredicate[*dpv1alpha3.RateLimitPolicy]{})); err != nil {
+			loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2609, logging.BLOCKER, "Error watching RateLimitPolicy resources: %v", err.Error()))
+			return err
+		}
+		r.ratelimits = append(r.ratelimits, &dpv1alpha3.RateLimitPolicy{})
+	}
+	if served(mgr, &dpv1alpha3.AIRateLimitPolicy{}) {
+		if err := c.Watch(source.Kind(mgr.GetCache(), &dpv1alpha3.AIRateLimitPolicy{}, handler.TypedEnqueueRequestsFromMapFunc(r.getSubscriptionsForAIRatelimit),
+			predicate.TypedGenerationChangedPredicate[*dpv1alpha3.AIRateLimitPolicy]{})); err != nil {
+			loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2609, logging.BLOCKER, "Error watching AIRateLimitPolicy resources: %v", err.Error()))
+			return err
+		}
+		r.ratelimits = append(r.ratelimits, &dpv1alpha3.AIRateLimitPolicy{})
+	}
 
 	loggers.LoggerAPKOperator.Debug("Subscription Controller successfully started. Watching Subscription Objects...")
 	return nil
@@ -91,6 +127,8 @@ func NewSubscriptionController(mgr manager.Manager, subscriptionStore *cache.Sub
 //+kubebuilder:rbac:groups=cp.wso2.com,resources=subscriptions,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cp.wso2.com,resources=subscriptions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=cp.wso2.com,resources=subscriptions/finalizers,verbs=update
+//+kubebuilder:rbac:groups=dp.wso2.com,resources=ratelimitpolicies;airatelimitpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -115,19 +153,87 @@ func (subscriptionReconciler *SubscriptionReconciler) Reconcile(ctx context.Cont
 				// Subscription in cache
 				loggers.LoggerAPKOperator.Debugf("Subscription %s/%s not found. Ignoring since object must be deleted", subscriptionKey.Namespace, subscriptionKey.Name)
 				utils.SendDeleteSubscriptionEvent(subscriptionKey.Name, subscription)
+				subscriptionReconciler.propagations.delete(subscriptionKey)
 				subscriptionReconciler.ods.DeleteSubscriptionFromStore(subscriptionKey)
 				server.DeleteSubscription(subscriptionKey.Name)
 				return ctrl.Result{}, nil
 			}
 		}
 	} else {
-		sendSubUpdates(subscription)
-		utils.SendAddSubscriptionEvent(subscription)
-		subscriptionReconciler.ods.AddorUpdateSubscriptionToStore(subscriptionKey, subscription.Spec)
+		invalid := validateSubscription(subscription.Spec)
+		var delivery *utils.EventDelivery
+		var pending []string
+		if invalid == "" {
+			revision := revisionOf(&subscription)
+			sent, found := subscriptionReconciler.propagations.get(subscriptionKey, revision)
+			if !found {
+				sendSubUpdates(subscription)
+				sent = utils.SendAddSubscriptionEvent(subscription)
+				subscriptionReconciler.ods.AddorUpdateSubscriptionToStore(subscriptionKey, subscription.Spec)
+				subscriptionReconciler.propagations.record(&subscription, revision, sent)
+			}
+			delivery = &sent
+			pending = utils.PendingEventAcks(sent)
+		}
+		// A missing ratelimit does not hold the subscription back, the enforcers apply it once it is created.
+		reason, message := subscriptionReconciler.resolveRatelimit(ctx, subscription)
+		reportStatus(subscriptionReconciler.statusUpdater, subscriptionReconciler.recorder, &subscription,
+			acceptedCondition(invalid),
+			resolvedRefsCondition(reason, message),
+			propagatedCondition(delivery, pending))
 	}
 	return ctrl.Result{}, nil
 }
 
+// validateSubscription returns why the subscription spec is not valid, empty if it is.
+func validateSubscription(spec cpv1alpha3.SubscriptionSpec) string {
+	if (spec.API.Name == "") != (spec.API.Version == "") {
+		return "The subscribed API must have both a name and a version"
+	}
+	return ""
+}
+
+// resolveRatelimit returns the ResolvedRefs reason and message of the ratelimit of a subscription, which
+// is a RateLimitPolicy or an AIRateLimitPolicy of the same name in the namespace of the subscription.
+func (subscriptionReconciler *SubscriptionReconciler) resolveRatelimit(ctx context.Context,
+	subscription cpv1alpha3.Subscription) (string, string) {
+	if subscription.Spec.RatelimitRef.Name == "" {
+		return constants.ReasonResolvedRefs, "The subscription has no ratelimit"
+	}
+	ratelimitKey := types.NamespacedName{Name: subscription.Spec.RatelimitRef.Name, Namespace: subscription.Namespace}
+	if len(subscriptionReconciler.ratelimits) == 0 {
+		return constants.ReasonResolvedRefs, fmt.Sprintf("The ratelimit %s is not checked", ratelimitKey.Name)
+	}
+	for _, kind := range subscriptionReconciler.ratelimits {
+		ratelimit := kind.DeepCopyObject().(client.Object)
+		err := subscriptionReconciler.client.Get(ctx, ratelimitKey, ratelimit)
+		switch {
+		case err == nil:
+			return constants.ReasonResolvedRefs, fmt.Sprintf("The ratelimit %s is resolved", ratelimitKey.Name)
+		case !k8error.IsNotFound(err):
+			loggers.LoggerAPKOperator.Errorf("Error getting the ratelimit %s of the subscription %s: %v",
+				ratelimitKey.String(), utils.NamespacedName(&subscription).String(), err)
+			return constants.ReasonResolvedRefs, fmt.Sprintf("The ratelimit %s is not checked", ratelimitKey.Name)
+		}
+	}
+	return constants.ReasonRatelimitNotFound, fmt.Sprintf("The ratelimit %s is not found", ratelimitKey.Name)
+}
+
+// served reports whether the cluster serves the kind of an object.
+func served(mgr manager.Manager, obj client.Object) bool {
+	gvk, err := apiutil.GVKForObject(obj, mgr.GetScheme())
+	if err != nil {
+		return false
+	}
+	if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if !meta.IsNoMatchError(err) {
+			loggers.LoggerAPKOperator.Errorf("Error checking whether the cluster serves %s: %v", gvk.String(), err)
+		}
+		return false
+	}
+	return true
+}
+
 func sendSubUpdates(subscription cpv1alpha3.Subscription) {
 	subList := marshalSubscription(subscription)
 	server.AddSubscription(subList)
@@ -182,63 +288,31 @@ func addSubscriptionControllerIndexes(ctx context.Context, mgr manager.Manager)
 	return nil
 }
 
-// getApplicationMappingsForSubscription triggers the ApplicationMapping controller reconcile method based on the changes detected
-// from Subscription objects. If the changes are done for an API stored in the Operator Data store,
-// func (subscriptionReconciler *SubscriptionReconciler) getSubscriptionForRatelimit(ctx context.Context, obj *dpv1alpha3.RateLimitPolicy) []reconcile.Request {
-// 	ratelimit := obj
-// 	subList := &cpv1alpha3.SubscriptionList{}
-// 	if err := subscriptionReconciler.client.List(ctx, subList, &k8client.ListOptions{
-// 		FieldSelector: fields.OneTermEqualSelector(subscriptionIndex, utils.NamespacedName(ratelimit).String()),
-// 	}); err != nil {
-// 		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2623, logging.CRITICAL, "Unable to find associated Application mappings: %s", utils.NamespacedName(ratelimit).String()))
-// 		return []reconcile.Request{}
-// 	}
-
-// 	if len(subList.Items) == 0 {
-// 		loggers.LoggerAPKOperator.Debugf("ApplicationMappings for Subscription %s/%s not found", ratelimit.Namespace, ratelimit.Name)
-// 		return []reconcile.Request{}
-// 	}
-
-// 	requests := []reconcile.Request{}
-// 	for _, subscription := range subList.Items {
-// 		req := reconcile.Request{
-// 			NamespacedName: types.NamespacedName{
-// 				Name:      subscription.Name,
-// 				Namespace: subscription.Namespace},
-// 		}
-// 		requests = append(requests, req)
-// 		loggers.LoggerAPKOperator.Debugf("Adding reconcile request for ApplicationMapping: %s/%s with Subscription UUID: %v", subscription.Namespace, subscription.Name,
-// 			string(subscription.ObjectMeta.UID))
-// 	}
-// 	return requests
-// }
-
-// // getSubscriptionForAIRatelimit get the associated subscription reconcile request for a AIRatelimit resource change
-// func (subscriptionReconciler *SubscriptionReconciler) getSubscriptionForAIRatelimit(ctx context.Context, obj *dpv1alpha3.AIRateLimitPolicy) []reconcile.Request {
-// 	airatelimit := obj
-// 	subList := &cpv1alpha3.SubscriptionList{}
-// 	if err := subscriptionReconciler.client.List(ctx, subList, &k8client.ListOptions{
-// 		FieldSelector: fields.OneTermEqualSelector(subscriptionToAIRatelimitIndex, utils.NamespacedName(airatelimit).String()),
-// 	}); err != nil {
-// 		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2623, logging.CRITICAL, "Unable to find associated AI ratelimits: %s", utils.NamespacedName(airatelimit).String()))
-// 		return []reconcile.Request{}
-// 	}
-
-// 	if len(subList.Items) == 0 {
-// 		loggers.LoggerAPKOperator.Debugf("AIRatelimit for Subscription %s/%s not found", airatelimit.Namespace, airatelimit.Name)
-// 		return []reconcile.Request{}
-// 	}
-
-// 	requests := []reconcile.Request{}
-// 	for _, subscription := range subList.Items {
-// 		req := reconcile.Request{
-// 			NamespacedName: types.NamespacedName{
-// 				Name:      subscription.Name,
-// 				Namespace: subscription.Namespace},
-// 		}
-// 		requests = append(requests, req)
-// 		loggers.LoggerAPKOperator.Debugf("Adding reconcile request for AIratelimit policy: %s/%s with Subscription UUID: %v", subscription.Namespace, subscription.Name,
-// 			string(subscription.ObjectMeta.UID))
-// 	}
-// 	return requests
-// }
+// getSubscriptionsForRatelimit returns the reconcile requests of the subscriptions referring to a RateLimitPolicy.
+func (subscriptionReconciler *SubscriptionReconciler) getSubscriptionsForRatelimit(ctx context.Context, obj *dpv1alpha3.RateLimitPolicy) []reconcile.Request {
+	return subscriptionReconciler.getSubscriptionsForIndex(ctx, subscriptionRatelimitIndex, obj)
+}
+
+// getSubscriptionsForAIRatelimit returns the reconcile requests of the subscriptions referring to an AIRateLimitPolicy.
+func (subscriptionReconciler *SubscriptionReconciler) getSubscriptionsForAIRatelimit(ctx context.Context, obj *dpv1alpha3.AIRateLimitPolicy) []reconcile.Request {
+	return subscriptionReconciler.getSubscriptionsForIndex(ctx, subscriptionToAIRatelimitIndex, obj)
+}
+
+// getSubscriptionsForIndex returns the reconcile requests of the subscriptions referring to a ratelimit through an index.
+func (subscriptionReconciler *SubscriptionReconciler) getSubscriptionsForIndex(ctx context.Context, index string, ratelimit k8client.Object) []reconcile.Request {
+	subList := &cpv1alpha3.SubscriptionList{}
+	if err := subscriptionReconciler.client.List(ctx, subList, &k8client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(index, utils.NamespacedName(ratelimit).String()),
+	}); err != nil {
+		loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error2623, logging.CRITICAL, "Unable to find associated Subscriptions: %s", utils.NamespacedName(ratelimit).String()))
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, subscription := range subList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: utils.NamespacedName(&subscription)})
+		loggers.LoggerAPKOperator.Debugf("Adding reconcile request for Subscription: %s/%s with ratelimit %s", subscription.Namespace, subscription.Name,
+			utils.NamespacedName(ratelimit).String())
+	}
+	return requests
+}
@@ -19,6 +19,7 @@ package dp
 
 import (
 	"context"
+	"encoding/base64"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -166,7 +167,10 @@ func (routePolicyReconciler *RoutePolicyReconciler) Reconcile(ctx context.Contex
 					loggers.LoggerAPKOperator.Errorf("failed to fetch ConfigMap %s: %v", namespacedName.String(), err)
 					continue
 				}
-				if v, ok := getValueFromMap(cm.Data, param.Key, namespacedName.String()); ok {
+				if v, ok := cm.BinaryData[param.Key]; ok {
+					// Binary values such as WASM modules are passed on base64 encoded.
+					value = base64.StdEncoding.EncodeToString(v)
+				} else if v, ok := getValueFromMap(cm.Data, param.Key, namespacedName.String()); ok {
 					value = v
 				} else {
 					continue
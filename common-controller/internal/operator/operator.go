@@ -43,7 +43,7 @@ import (
 
 	// dpv1alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v1alpha1"
 	// dpv1alpha2 "github.com/wso2/apk/common-go-libs/apis/dp/v1alpha2"
-	// dpv1alpha3 "github.com/wso2/apk/common-go-libs/apis/dp/v1alpha3"
+	dpv1alpha3 "github.com/wso2/apk/common-go-libs/apis/dp/v1alpha3"
 	// dpv1alpha4 "github.com/wso2/apk/common-go-libs/apis/dp/v1alpha4"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -74,7 +74,7 @@ func init() {
 	utilruntime.Must(cpv1alpha2.AddToScheme(scheme))
 	utilruntime.Must(cpv1alpha2.AddToScheme(scheme))
 	utilruntime.Must(cpv1alpha3.AddToScheme(scheme))
-	// utilruntime.Must(dpv1alpha3.AddToScheme(scheme))
+	utilruntime.Must(dpv1alpha3.AddToScheme(scheme))
 	utilruntime.Must(dpv2alpha1.AddToScheme(scheme))
 	utilruntime.Must(eg.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
@@ -158,27 +158,26 @@ func InitOperator(metricsConfig config.Metrics) {
 			"Error creating JWT Issuer controller, error: %v", err))
 	}
 
-	
-	if !(config.CommonController.ControlPlane.Enabled && config.CommonController.ControlPlane.Persistence.Type == "DB") {
-		if err := cpcontrollers.NewApplicationController(mgr, subscriptionStore); err != nil {
+	updateHandler := status.NewUpdateHandler(mgr.GetClient())
+	if err := mgr.Add(updateHandler); err != nil {
+		loggers.LoggerAPKOperator.Errorf("Failed to add status update handler %v", err)
+	}
+
+	if !(config.CommonController.ControlPlane.Enabled && database.IsDBPersistence(config.CommonController.ControlPlane.Persistence.Type)) {
+		if err := cpcontrollers.NewApplicationController(mgr, subscriptionStore, updateHandler); err != nil {
 			loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error3115, logging.MAJOR,
 				"Error creating Application controller, error: %v", err))
 		}
-		if err := cpcontrollers.NewSubscriptionController(mgr, subscriptionStore); err != nil {
+		if err := cpcontrollers.NewSubscriptionController(mgr, subscriptionStore, updateHandler); err != nil {
 			loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error3116, logging.MAJOR,
 				"Error creating Subscription controller, error: %v", err))
 		}
-		if err := cpcontrollers.NewApplicationMappingController(mgr, subscriptionStore); err != nil {
+		if err := cpcontrollers.NewApplicationMappingController(mgr, subscriptionStore, updateHandler); err != nil {
 			loggers.LoggerAPKOperator.ErrorC(logging.PrintError(logging.Error3117, logging.MAJOR,
 				"Error creating Application Mapping controller, error: %v", err))
 		}
 	}
 
-	updateHandler := status.NewUpdateHandler(mgr.GetClient())
-	if err := mgr.Add(updateHandler); err != nil {
-		loggers.LoggerAPKOperator.Errorf("Failed to add status update handler %v", err)
-	}
-
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -196,7 +195,7 @@ func InitOperator(metricsConfig config.Metrics) {
 			if config.CommonController.ControlPlane.Persistence.Type == "K8s" {
 				controlPlane = controlplane.NewK8sArtifactDeployer(mgr)
 
-			} else if config.CommonController.ControlPlane.Persistence.Type == "DB" {
+			} else if database.IsDBPersistence(config.CommonController.ControlPlane.Persistence.Type) {
 				controlPlane = database.NewDBArtifactDeployer(mgr)
 			}
 
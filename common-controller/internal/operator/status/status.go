@@ -21,7 +21,10 @@ import (
 	"context"
 
 	"github.com/wso2/apk/common-controller/internal/loggers"
+	cpv1alpha2 "github.com/wso2/apk/common-go-libs/apis/cp/v1alpha2"
+	cpv1alpha3 "github.com/wso2/apk/common-go-libs/apis/cp/v1alpha3"
 	dpv1alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -94,12 +97,27 @@ func (updateHandler *UpdateHandler) Send(update Update) {
 // isStatusEqual checks if two objects have equivalent status.
 // Supported:
 //   - API
+//   - Application
+//   - ApplicationMapping
+//   - Subscription
 func isStatusEqual(objA, objB interface{}) bool {
 	switch a := objA.(type) {
 	case *dpv1alpha1.API:
 		if b, ok := objB.(*dpv1alpha1.API); ok {
 			return compareAPIs(a, b)
 		}
+	case *cpv1alpha2.Application:
+		if b, ok := objB.(*cpv1alpha2.Application); ok {
+			return equality.Semantic.DeepEqual(a.Status, b.Status)
+		}
+	case *cpv1alpha2.ApplicationMapping:
+		if b, ok := objB.(*cpv1alpha2.ApplicationMapping); ok {
+			return equality.Semantic.DeepEqual(a.Status, b.Status)
+		}
+	case *cpv1alpha3.Subscription:
+		if b, ok := objB.(*cpv1alpha3.Subscription); ok {
+			return equality.Semantic.DeepEqual(a.Status, b.Status)
+		}
 	}
 	return false
 }
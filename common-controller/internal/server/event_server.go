@@ -1,10 +1,14 @@
 package server
 
 import (
+	"context"
+
 	"github.com/wso2/apk/common-controller/internal/loggers"
 	"github.com/wso2/apk/common-controller/internal/utils"
 	apkmgt "github.com/wso2/apk/common-go-libs/pkg/discovery/api/wso2/discovery/service/apkmgt"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // EventServer struct use to hold event server
@@ -28,5 +32,18 @@ func (s EventServer) StreamEvents(req *apkmgt.Request, srv apkmgt.EventStreamSer
 	<-srv.Context().Done()
 	loggers.LoggerAPKOperator.Infof("Connection closed by the client : %v", enforcerID[0])
 	utils.DeleteClientConnection(enforcerID[0])
+	utils.DeleteEventAcks(enforcerID[0])
 	return nil // Client closed the connection
 }
+
+// AckEvent records that the enforcer applied an event of its stream
+func (s EventServer) AckEvent(ctx context.Context, ack *apkmgt.EventAck) (*apkmgt.EventAckResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	enforcerID := md.Get("enforcer-uuid")
+	if len(enforcerID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "the enforcer-uuid metadata is required")
+	}
+	loggers.LoggerAPKOperator.Debugf("Enforcer %s acknowledged the event %s", enforcerID[0], ack.GetEventUuid())
+	utils.AckEvent(enforcerID[0], ack.GetEventUuid())
+	return &apkmgt.EventAckResponse{}, nil
+}
@@ -0,0 +1,111 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package utils
+
+import (
+	"slices"
+	"sync"
+)
+
+var (
+	eventAcksMutex sync.Mutex
+	// pendingEventAcks holds, for each event, the enforcers it was sent to that have not acknowledged it.
+	pendingEventAcks = make(map[string]map[string]bool)
+	// eventAckListeners holds the functions to call once an event is no longer pending.
+	eventAckListeners = make(map[string][]func())
+)
+
+// expectEventAck records that an event is sent to an enforcer, which acknowledges it once applied.
+// It is called before sending the event, so that an early acknowledgement is not missed.
+func expectEventAck(eventUUID, clientID string) {
+	eventAcksMutex.Lock()
+	defer eventAcksMutex.Unlock()
+	if pendingEventAcks[eventUUID] == nil {
+		pendingEventAcks[eventUUID] = make(map[string]bool)
+	}
+	pendingEventAcks[eventUUID][clientID] = true
+}
+
+// AckEvent records that an enforcer applied an event of its stream.
+func AckEvent(clientID, eventUUID string) {
+	eventAcksMutex.Lock()
+	listeners := removeEventAck(eventUUID, clientID)
+	eventAcksMutex.Unlock()
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// DeleteEventAcks drops the acknowledgements pending from an enforcer that disconnected. An
+// enforcer loads all the artifacts when it connects again, so its pending events are not retried.
+func DeleteEventAcks(clientID string) {
+	var listeners []func()
+	eventAcksMutex.Lock()
+	for eventUUID := range pendingEventAcks {
+		listeners = append(listeners, removeEventAck(eventUUID, clientID)...)
+	}
+	eventAcksMutex.Unlock()
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// removeEventAck removes an enforcer from the pending enforcers of an event, returning the listeners
+// of the event if none is pending any longer. The caller holds eventAcksMutex.
+func removeEventAck(eventUUID, clientID string) []func() {
+	clients, found := pendingEventAcks[eventUUID]
+	if !found || !clients[clientID] {
+		return nil
+	}
+	delete(clients, clientID)
+	if len(clients) > 0 {
+		return nil
+	}
+	delete(pendingEventAcks, eventUUID)
+	listeners := eventAckListeners[eventUUID]
+	delete(eventAckListeners, eventUUID)
+	return listeners
+}
+
+// PendingEventAcks returns the connected enforcers that have not acknowledged the events of a delivery.
+func PendingEventAcks(delivery EventDelivery) []string {
+	eventAcksMutex.Lock()
+	defer eventAcksMutex.Unlock()
+	var clientIDs []string
+	for _, eventUUID := range delivery.Events {
+		for clientID := range pendingEventAcks[eventUUID] {
+			if !slices.Contains(clientIDs, clientID) {
+				clientIDs = append(clientIDs, clientID)
+			}
+		}
+	}
+	slices.Sort(clientIDs)
+	return clientIDs
+}
+
+// NotifyOnEventAcks calls a function each time an event of a delivery is no longer pending, which is
+// once all the enforcers it was sent to acknowledged it or disconnected.
+func NotifyOnEventAcks(delivery EventDelivery, listener func()) {
+	eventAcksMutex.Lock()
+	defer eventAcksMutex.Unlock()
+	for _, eventUUID := range delivery.Events {
+		if len(pendingEventAcks[eventUUID]) > 0 {
+			eventAckListeners[eventUUID] = append(eventAckListeners[eventUUID], listener)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventAcks(t *testing.T) {
+	expectEventAck("event-1", "enforcer-1")
+	expectEventAck("event-1", "enforcer-2")
+	expectEventAck("event-2", "enforcer-1")
+	delivery := EventDelivery{Events: []string{"event-1", "event-2"}, Enforcers: 2}
+	notified := 0
+	NotifyOnEventAcks(delivery, func() { notified++ })
+	assert.Equal(t, []string{"enforcer-1", "enforcer-2"}, PendingEventAcks(delivery))
+
+	AckEvent("enforcer-1", "event-1")
+	AckEvent("enforcer-1", "event-unknown")
+	assert.Equal(t, []string{"enforcer-1", "enforcer-2"}, PendingEventAcks(delivery))
+	assert.Equal(t, 0, notified)
+
+	AckEvent("enforcer-1", "event-2")
+	assert.Equal(t, []string{"enforcer-2"}, PendingEventAcks(delivery))
+	assert.Equal(t, 1, notified)
+
+	// The acknowledgements of an enforcer that disconnected are no longer awaited.
+	DeleteEventAcks("enforcer-2")
+	assert.Empty(t, PendingEventAcks(delivery))
+	assert.Equal(t, 2, notified)
+
+	// Listeners are not kept for events that are not pending.
+	NotifyOnEventAcks(delivery, func() { notified++ })
+	assert.Empty(t, eventAckListeners)
+	assert.Empty(t, pendingEventAcks)
+}
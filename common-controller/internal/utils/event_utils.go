@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"slices"
 	time "time"
 
 	"github.com/google/uuid"
@@ -18,11 +19,11 @@ func SendAppDeletionEvent(applicationUUID string, applicationSpec cpv1alpha2.App
 }
 
 // SendApplicationEvent sends an application deletion event to the enforcer
-func SendApplicationEvent(eventType, applicationUUID, applicationName, applicationOwner, organization string, appAttribute map[string]string) {
+func SendApplicationEvent(eventType, applicationUUID, applicationName, applicationOwner, organization string, appAttribute map[string]string) EventDelivery {
 	currentTime := time.Now()
 	milliseconds := currentTime.UnixNano() / int64(time.Millisecond)
 	event := subscription.Event{
-		Uuid:      applicationUUID,
+		Uuid:      uuid.New().String(),
 		Type:      eventType,
 		TimeStamp: milliseconds,
 		Application: &subscription.Application{
@@ -34,38 +35,40 @@ func SendApplicationEvent(eventType, applicationUUID, applicationName, applicati
 		},
 	}
 	loggers.LoggerAPKOperator.Debugf("Sending event to all clients: %v", &event)
-	sendEvent(&event)
+	return sendEvent(&event)
 }
 
 // SendAppUpdateEvent sends an application update event to the enforcer
-func SendAppUpdateEvent(applicationUUID string, oldApplicationSpec cpv1alpha2.ApplicationSpec, newApplicationSpec cpv1alpha2.ApplicationSpec) {
-	SendApplicationEvent(constants.ApplicationUpdated, applicationUUID, oldApplicationSpec.Name, oldApplicationSpec.Owner,
+func SendAppUpdateEvent(applicationUUID string, oldApplicationSpec cpv1alpha2.ApplicationSpec, newApplicationSpec cpv1alpha2.ApplicationSpec) EventDelivery {
+	delivery := SendApplicationEvent(constants.ApplicationUpdated, applicationUUID, oldApplicationSpec.Name, oldApplicationSpec.Owner,
 		oldApplicationSpec.Organization, oldApplicationSpec.Attributes)
 	if oldApplicationSpec.SecuritySchemes != nil {
-		sendDeleteApplicationKeyMappingEvent(applicationUUID, oldApplicationSpec)
+		delivery.add(sendDeleteApplicationKeyMappingEvent(applicationUUID, oldApplicationSpec))
 	}
 	if newApplicationSpec.SecuritySchemes != nil {
-		sendApplicationKeyMappingEvent(applicationUUID, newApplicationSpec)
+		delivery.add(sendApplicationKeyMappingEvent(applicationUUID, newApplicationSpec))
 	}
+	return delivery
 }
 
 // SendAddApplicationEvent sends an application creation event to the enforcer
-func SendAddApplicationEvent(application cpv1alpha2.Application) {
-	SendApplicationEvent(constants.ApplicationCreated, application.ObjectMeta.Name, application.Spec.Name, application.Spec.Owner,
+func SendAddApplicationEvent(application cpv1alpha2.Application) EventDelivery {
+	delivery := SendApplicationEvent(constants.ApplicationCreated, application.ObjectMeta.Name, application.Spec.Name, application.Spec.Owner,
 		application.Spec.Organization, application.Spec.Attributes)
 	if application.Spec.SecuritySchemes != nil {
-		sendApplicationKeyMappingEvent(application.ObjectMeta.Name, application.Spec)
+		delivery.add(sendApplicationKeyMappingEvent(application.ObjectMeta.Name, application.Spec))
 	}
+	return delivery
 }
 
 // SendAddSubscriptionEvent sends an subscription creation event to the enforcer
-func SendAddSubscriptionEvent(sub cpv1alpha3.Subscription) {
-	SendSubscriptionEvent(constants.SubscriptionCreated, sub.ObjectMeta.Name, sub.Spec.SubscriptionStatus,
+func SendAddSubscriptionEvent(sub cpv1alpha3.Subscription) EventDelivery {
+	return SendSubscriptionEvent(constants.SubscriptionCreated, sub.ObjectMeta.Name, sub.Spec.SubscriptionStatus,
 		sub.Spec.Organization, sub.Spec.API.Name, sub.Spec.API.Version, sub.Spec.RatelimitRef.Name)
 }
 
 // SendSubscriptionEvent sends an subscription creation event to the enforcer
-func SendSubscriptionEvent(eventType, subscriptionID, subscriptionStatus, organization, apiName, apiVersion string, ratelimit string) {
+func SendSubscriptionEvent(eventType, subscriptionID, subscriptionStatus, organization, apiName, apiVersion string, ratelimit string) EventDelivery {
 	currentTime := time.Now()
 	milliseconds := currentTime.UnixNano() / int64(time.Millisecond)
 	event := subscription.Event{
@@ -83,7 +86,7 @@ func SendSubscriptionEvent(eventType, subscriptionID, subscriptionStatus, organi
 			RatelimitTier: ratelimit,
 		},
 	}
-	sendEvent(&event)
+	return sendEvent(&event)
 }
 
 // SendDeleteSubscriptionEvent sends an subscription deletion event to the enforcer
@@ -93,13 +96,13 @@ func SendDeleteSubscriptionEvent(subscriptionUUID string, sub cpv1alpha3.Subscri
 }
 
 // SendCreateApplicationMappingEvent sends an application mapping event to the enforcer
-func SendCreateApplicationMappingEvent(applicationMapping cpv1alpha2.ApplicationMapping, application cpv1alpha2.Application, subscriptionCr cpv1alpha3.Subscription) {
-	SendApplicationMappingEvent(constants.ApplicationMappingCreated, applicationMapping.ObjectMeta.Name, applicationMapping.Spec.ApplicationRef,
+func SendCreateApplicationMappingEvent(applicationMapping cpv1alpha2.ApplicationMapping, application cpv1alpha2.Application, subscriptionCr cpv1alpha3.Subscription) EventDelivery {
+	return SendApplicationMappingEvent(constants.ApplicationMappingCreated, applicationMapping.ObjectMeta.Name, applicationMapping.Spec.ApplicationRef,
 		applicationMapping.Spec.SubscriptionRef, application.Spec.Organization)
 }
 
 // SendApplicationMappingEvent sends an application mapping event to the enforcer
-func SendApplicationMappingEvent(eventType, id, applicationRef, subscriptionRef, organization string) {
+func SendApplicationMappingEvent(eventType, id, applicationRef, subscriptionRef, organization string) EventDelivery {
 	currentTime := time.Now()
 	milliseconds := currentTime.UnixNano() / int64(time.Millisecond)
 	event := subscription.Event{
@@ -113,7 +116,7 @@ func SendApplicationMappingEvent(eventType, id, applicationRef, subscriptionRef,
 			Organization:    organization,
 		},
 	}
-	sendEvent(&event)
+	return sendEvent(&event)
 }
 
 // SendDeleteApplicationMappingEvent sends an application mapping deletion event to the enforcer
@@ -123,29 +126,33 @@ func SendDeleteApplicationMappingEvent(applicationMappingUUID string,
 		applicationMappingSpec.ApplicationRef, applicationMappingSpec.SubscriptionRef, organization)
 }
 
-func sendDeleteApplicationKeyMappingEvent(applicationUUID string, applicationKeyMapping cpv1alpha2.ApplicationSpec) {
+func sendDeleteApplicationKeyMappingEvent(applicationUUID string, applicationKeyMapping cpv1alpha2.ApplicationSpec) EventDelivery {
+	var delivery EventDelivery
 	var oauth2SecurityScheme = applicationKeyMapping.SecuritySchemes.OAuth2
 	if oauth2SecurityScheme != nil {
 		for _, env := range oauth2SecurityScheme.Environments {
-			SendApplicationKeyMappingEvent(constants.ApplicationKeyMappingDeleted, applicationUUID, constants.OAuth2,
-				env.AppID, env.KeyType, env.EnvID, applicationKeyMapping.Organization)
+			delivery.add(SendApplicationKeyMappingEvent(constants.ApplicationKeyMappingDeleted, applicationUUID, constants.OAuth2,
+				env.AppID, env.KeyType, env.EnvID, applicationKeyMapping.Organization))
 		}
 	}
+	return delivery
 }
 
-func sendApplicationKeyMappingEvent(applicationUUID string, applicationSpec cpv1alpha2.ApplicationSpec) {
+func sendApplicationKeyMappingEvent(applicationUUID string, applicationSpec cpv1alpha2.ApplicationSpec) EventDelivery {
+	var delivery EventDelivery
 	var oauth2SecurityScheme = applicationSpec.SecuritySchemes.OAuth2
 	if oauth2SecurityScheme != nil {
 		for _, env := range oauth2SecurityScheme.Environments {
-			SendApplicationKeyMappingEvent(constants.ApplicationKeyMappingCreated, applicationUUID, constants.OAuth2,
-				env.AppID, env.KeyType, env.EnvID, applicationSpec.Organization)
+			delivery.add(SendApplicationKeyMappingEvent(constants.ApplicationKeyMappingCreated, applicationUUID, constants.OAuth2,
+				env.AppID, env.KeyType, env.EnvID, applicationSpec.Organization))
 		}
 	}
+	return delivery
 }
 
 // SendApplicationKeyMappingEvent sends an application key mapping event to the enforcer
 func SendApplicationKeyMappingEvent(eventType, applicationUUID, securityScheme, applicationIdentifier, keyType, envID,
-	organization string) {
+	organization string) EventDelivery {
 	currentTime := time.Now()
 	milliseconds := currentTime.UnixNano() / int64(time.Millisecond)
 	event := subscription.Event{
@@ -161,7 +168,7 @@ func SendApplicationKeyMappingEvent(eventType, applicationUUID, securityScheme,
 			Organization:          organization,
 		},
 	}
-	sendEvent(&event)
+	return sendEvent(&event)
 }
 
 // SendRoutePolicyCreatedOrUpdatedEvent sends a route policy creation or update event to the enforcer
@@ -216,16 +223,46 @@ func SendRouteMetadataDeletedEvent(routeMetadata string) {
 	sendEvent(&event)
 }
 
-func sendEvent(event *subscription.Event) {
+// EventDelivery is the outcome of sending events to the enforcers connected to the event stream. The
+// enforcers acknowledge the events they apply, which PendingEventAcks tracks.
+type EventDelivery struct {
+	// Events holds the UUIDs of the events sent.
+	Events []string
+	// Enforcers is the number of enforcers the events were sent to.
+	Enforcers int
+	// Failed holds the enforcers an event could not be sent to.
+	Failed []string
+}
+
+// add merges the outcome of sending further events.
+func (delivery *EventDelivery) add(other EventDelivery) {
+	delivery.Events = append(delivery.Events, other.Events...)
+	if other.Enforcers > delivery.Enforcers {
+		delivery.Enforcers = other.Enforcers
+	}
+	for _, clientID := range other.Failed {
+		if !slices.Contains(delivery.Failed, clientID) {
+			delivery.Failed = append(delivery.Failed, clientID)
+		}
+	}
+}
+
+func sendEvent(event *subscription.Event) EventDelivery {
 	loggers.LoggerAPKOperator.Debugf("Sending event to all clients: %v", event)
+	delivery := EventDelivery{Events: []string{event.Uuid}}
 	for clientID, stream := range GetAllClientConnections() {
+		delivery.Enforcers++
+		// An enforcer the event could not be sent to stays pending until it disconnects.
+		expectEventAck(event.Uuid, clientID)
 		err := stream.Send(event)
 		if err != nil {
 			loggers.LoggerAPKOperator.Errorf("Error sending event to client %s: %v", clientID, err)
+			delivery.Failed = append(delivery.Failed, clientID)
 		} else {
 			loggers.LoggerAPKOperator.Debugf("Event sent to client %s", clientID)
 		}
 	}
+	return delivery
 }
 
 // SendResetEvent sends initial event to the enforcer
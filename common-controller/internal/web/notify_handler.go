@@ -23,6 +23,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"net/http"
+	"net/url"
 	"time"
 	"crypto/tls"
 	"os"
@@ -39,10 +40,38 @@ import (
 
 const tokenRevocationType = "TOKEN_REVOCATION"
 
+const (
+	revokedTokenKeyPrefix   = "wso2:apk:revoked_token"
+	revocationRuleKeyPrefix = "wso2:apk:revocation_rule"
+)
+
+// Revocation rule types, named after the token claims the enforcer matches them against.
+const (
+	revocationRuleSubject     = "sub"
+	revocationRuleClientID    = "client_id"
+	revocationRuleApplication = "application"
+)
+
+// revokeRequest revokes a single token by the token or its jti, or every token of a subject of an issuer,
+// client or application issued before a time.
 type revokeRequest struct {
 	Token string `json:"token"`
 	Jti string `json:"jti"`
 	Expiry int64 `json:"expiry"`
+	Subject string `json:"subject"`
+	Issuer string `json:"issuer"`
+	ClientID string `json:"clientId"`
+	ApplicationID string `json:"applicationId"`
+	IssuedBefore int64 `json:"issuedBefore"`
+}
+
+// revocationRule is the revocation rule evaluated by the enforcers.
+type revocationRule struct {
+	Type         string `json:"type"`
+	Value        string `json:"value"`
+	Issuer       string `json:"issuer,omitempty"`
+	IssuedBefore int64  `json:"issuedBefore"`
+	Expiry       int64  `json:"expiry"`
 }
 
 type jWTClaims struct {
@@ -58,8 +87,8 @@ var (
 	redisUserKeyPath    string
 	redisCACertPath string
 	isTLSEnabled    bool
-	redisRevokedTokenChannel string
-	tokenExpiryDivider = "_##_"
+	redisRevokedTokenStream string
+	redisRevokedTokenStreamMaxLen int64
 	authKeyPath string
 	authKeyHeader string
 	rdb *redis.Client
@@ -76,7 +105,8 @@ func init() {
 	redisUserKeyPath = conf.CommonController.Redis.UserKeyPath
 	redisCACertPath = conf.CommonController.Redis.CACertPath
 	isTLSEnabled = conf.CommonController.Redis.TLSEnabled
-	redisRevokedTokenChannel = conf.CommonController.Redis.RevokedTokenChannel
+	redisRevokedTokenStream = conf.CommonController.Redis.RevokedTokenStream
+	redisRevokedTokenStreamMaxLen = conf.CommonController.Redis.RevokedTokenStreamMaxLen
 	authKeyPath = conf.CommonController.Sts.AuthKeyPath
 	authKeyHeader = conf.CommonController.Sts.AuthKeyHeader
 	utilruntime.Must(initRedisClient())
@@ -147,6 +177,10 @@ func revokeToken(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Error while parsing json payload"})
 		return
 	}
+	if request.Subject != "" || request.ClientID != "" || request.ApplicationID != "" {
+		revokeByRule(c, request)
+		return
+	}
   var jti string;
 	var expiry int64;
 	if request.Token != "" {
@@ -175,16 +209,100 @@ func revokeToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
 }
 
+// revokeByRule revokes every token of a subject, client or application issued before a time. The rule is kept
+// until the given expiry, by which every token it revokes must have expired.
+func revokeByRule(c *gin.Context, request revokeRequest) {
+	rule, err := newRevocationRule(request, time.Now().Unix())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := storeRuleInRedis(rule); err != nil {
+		loggers.LoggerAPI.ErrorC(logging.PrintError(logging.Error3202, logging.MAJOR, "Error adding revocation rule to redis: %v", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to store the revocation rule in Redis cache"})
+		return
+	}
+	loggers.LoggerAPI.Infof("Revoked the tokens of %s %s issued before %d", rule.Type, rule.Value, rule.IssuedBefore)
+	c.JSON(http.StatusOK, gin.H{"message": "Tokens revoked successfully"})
+}
+
+// newRevocationRule creates the revocation rule of the request. The tokens issued until now are revoked unless
+// the request sets an earlier issuedBefore time.
+func newRevocationRule(request revokeRequest, now int64) (*revocationRule, error) {
+	if request.Token != "" || request.Jti != "" {
+		return nil, fmt.Errorf("a token or jti cannot be revoked together with a subject, clientId or applicationId")
+	}
+	var rules []*revocationRule
+	if request.Subject != "" {
+		if request.Issuer == "" {
+			return nil, fmt.Errorf("the issuer of the subject must be given")
+		}
+		rules = append(rules, &revocationRule{Type: revocationRuleSubject, Value: request.Subject, Issuer: request.Issuer})
+	} else if request.Issuer != "" {
+		return nil, fmt.Errorf("an issuer can only be given with a subject")
+	}
+	if request.ClientID != "" {
+		rules = append(rules, &revocationRule{Type: revocationRuleClientID, Value: request.ClientID})
+	}
+	if request.ApplicationID != "" {
+		rules = append(rules, &revocationRule{Type: revocationRuleApplication, Value: request.ApplicationID})
+	}
+	if len(rules) != 1 {
+		return nil, fmt.Errorf("exactly one of subject, clientId and applicationId must be given")
+	}
+	rule := rules[0]
+	rule.IssuedBefore = request.IssuedBefore
+	if rule.IssuedBefore == 0 {
+		rule.IssuedBefore = now
+	}
+	if rule.IssuedBefore < 0 || rule.IssuedBefore > now {
+		return nil, fmt.Errorf("issuedBefore must not be in the future")
+	}
+	rule.Expiry = request.Expiry
+	if rule.Expiry <= now {
+		return nil, fmt.Errorf("expiry must be the time by which the revoked tokens expire")
+	}
+	return rule, nil
+}
+
 func generateKey(jti string) string {
-	return fmt.Sprintf("%s:%s", "wso2:apk:revoked_token", jti)
+	return fmt.Sprintf("%s:%s", revokedTokenKeyPrefix, jti)
+}
+
+// generateRuleKey generates the key of the rule. Subjects are only unique per issuer, so the escaped issuer is
+// part of the key of a subject rule.
+func generateRuleKey(rule *revocationRule) string {
+	if rule.Type == revocationRuleSubject {
+		return fmt.Sprintf("%s:%s:%s:%s", revocationRuleKeyPrefix, rule.Type, url.QueryEscape(rule.Issuer), rule.Value)
+	}
+	return fmt.Sprintf("%s:%s:%s", revocationRuleKeyPrefix, rule.Type, rule.Value)
 }
 
 func storeTokenInRedis(token string, expiry int64) error {
-	key := generateKey(token)
-	err := rdb.Do(context.Background(), "set", key, expiry, "EXAT", expiry).Err()
+	if err := setWithExpiry(generateKey(token), expiry, expiry); err != nil {
+		return err
+	}
+	return addToRevocationStream(map[string]interface{}{"jti": token, "expiry": expiry})
+}
+
+// storeRuleInRedis stores the rule, replacing the rule of the same type and value, and sends it to the enforcers.
+func storeRuleInRedis(rule *revocationRule) error {
+	value, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	if err := setWithExpiry(generateRuleKey(rule), string(value), rule.Expiry); err != nil {
+		return err
+	}
+	return addToRevocationStream(map[string]interface{}{"rule": string(value)})
+}
+
+// setWithExpiry sets the key to expire at the given unix time.
+func setWithExpiry(key string, value interface{}, expiry int64) error {
+	err := rdb.Do(context.Background(), "set", key, value, "EXAT", expiry).Err()
 	if err != nil {
 		loggers.LoggerAPI.Warnf("Error occured while trying to set key with expiry. Error: %+v. \n Trying to use SET and EXPIREAT command...", err)
-		err = rdb.Do(context.Background(), "set", key, expiry).Err()
+		err = rdb.Do(context.Background(), "set", key, value).Err()
 		if err != nil { 
 			loggers.LoggerAPI.Errorf("Error occured while setting the key. Error %+v", err)
 			return err
@@ -195,14 +313,20 @@ func storeTokenInRedis(token string, expiry int64) error {
 			return err
 		}
 	}
-	publishValue := fmt.Sprintf("%s%s%d", token, tokenExpiryDivider, expiry)
-	err = rdb.Do(context.Background(), "publish", redisRevokedTokenChannel, publishValue).Err()
-	if err != nil {
-		return err
-	}
 	return nil
 }
 
+// addToRevocationStream sends a revocation to the enforcers. It is added after the key of the revocation, so an
+// enforcer loading a snapshot of the keys either finds the key or reads the entry after its snapshot version.
+func addToRevocationStream(values map[string]interface{}) error {
+	return rdb.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: redisRevokedTokenStream,
+		MaxLen: redisRevokedTokenStreamMaxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+}
+
 func authenticateTokenRevocationRequest(c *gin.Context) bool {
 	fileContent, err := ioutil.ReadFile(authKeyPath)
 	if err != nil {
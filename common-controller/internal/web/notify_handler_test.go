@@ -0,0 +1,86 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package web
+
+import (
+	"testing"
+)
+
+func TestNewRevocationRule(t *testing.T) {
+	const now = int64(1700000000)
+	tests := []struct {
+		name     string
+		request  revokeRequest
+		expected *revocationRule
+	}{
+		{
+			name:     "subject revoked until now",
+			request:  revokeRequest{Subject: "alice", Issuer: "idp", Expiry: now + 3600},
+			expected: &revocationRule{Type: revocationRuleSubject, Value: "alice", Issuer: "idp", IssuedBefore: now, Expiry: now + 3600},
+		},
+		{
+			name:     "subject issued before",
+			request:  revokeRequest{Subject: "alice", Issuer: "idp", IssuedBefore: now - 60, Expiry: now + 3600},
+			expected: &revocationRule{Type: revocationRuleSubject, Value: "alice", Issuer: "idp", IssuedBefore: now - 60, Expiry: now + 3600},
+		},
+		{
+			name:     "client",
+			request:  revokeRequest{ClientID: "client", Expiry: now + 3600},
+			expected: &revocationRule{Type: revocationRuleClientID, Value: "client", IssuedBefore: now, Expiry: now + 3600},
+		},
+		{
+			name:     "application",
+			request:  revokeRequest{ApplicationID: "app-uuid", Expiry: now + 3600},
+			expected: &revocationRule{Type: revocationRuleApplication, Value: "app-uuid", IssuedBefore: now, Expiry: now + 3600},
+		},
+		{name: "subject without issuer", request: revokeRequest{Subject: "alice", Expiry: now + 3600}},
+		{name: "issuer without subject", request: revokeRequest{ClientID: "client", Issuer: "idp", Expiry: now + 3600}},
+		{name: "more than one selector", request: revokeRequest{Subject: "alice", ClientID: "client", Expiry: now + 3600}},
+		{name: "with a jti", request: revokeRequest{Subject: "alice", Jti: "jti", Expiry: now + 3600}},
+		{name: "issued in the future", request: revokeRequest{Subject: "alice", IssuedBefore: now + 60, Expiry: now + 3600}},
+		{name: "no expiry", request: revokeRequest{Subject: "alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := newRevocationRule(tt.request, now)
+			if tt.expected == nil {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *rule != *tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, rule)
+			}
+		})
+	}
+}
+
+func TestGenerateRuleKey(t *testing.T) {
+	subject := &revocationRule{Type: revocationRuleSubject, Value: "alice", Issuer: "https://idp.example.com"}
+	if key := generateRuleKey(subject); key != "wso2:apk:revocation_rule:sub:https%3A%2F%2Fidp.example.com:alice" {
+		t.Errorf("unexpected subject rule key %s", key)
+	}
+	client := &revocationRule{Type: revocationRuleClientID, Value: "client"}
+	if key := generateRuleKey(client); key != "wso2:apk:revocation_rule:client_id:client" {
+		t.Errorf("unexpected client rule key %s", key)
+	}
+}
@@ -26,6 +26,53 @@ import (
 // RouteMetadataSpec defines the desired state of RouteMetadata
 type RouteMetadataSpec struct {
 	API API `json:"api,omitempty"`
+	// Hostnames are the hostnames the routes of the API are served on.
+	//
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+	// Operations are the operations of the API as the routes match them, along with the
+	// RoutePolicies attached to each of them.
+	//
+	// +optional
+	Operations []Operation `json:"operations,omitempty"`
+	// JWTIssuers are the JWT issuers accepted by the routes of the API.
+	//
+	// +optional
+	JWTIssuers []JWTIssuer `json:"jwtIssuers,omitempty"`
+}
+
+// Operation is an operation of the API as it is matched by a route rule
+type Operation struct {
+	// Hostnames are the hostnames the operation is served on.
+	//
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+	// Method is the HTTP method of the operation. Operations without a method match every method.
+	//
+	// +optional
+	Method string `json:"method,omitempty"`
+	// Path is the path the operation matches.
+	Path string `json:"path"`
+	// PathMatchType is how the path is matched, Exact, PathPrefix or RegularExpression.
+	//
+	// +optional
+	PathMatchType string `json:"pathMatchType,omitempty"`
+	// RoutePolicies are the names of the RoutePolicies attached to the operation, in the order
+	// they are attached to the route rule.
+	//
+	// +optional
+	RoutePolicies []string `json:"routePolicies,omitempty"`
+}
+
+// JWTIssuer is a JWT issuer accepted by the routes of an API
+type JWTIssuer struct {
+	// Name is the name of the JWT provider of the issuer.
+	Name string `json:"name"`
+	// Issuer is the iss claim of the tokens of the issuer. Providers without an issuer accept
+	// tokens of any issuer.
+	//
+	// +optional
+	Issuer string `json:"issuer,omitempty"`
 }
 
 // API represents the API metadata for the RoutePolicy
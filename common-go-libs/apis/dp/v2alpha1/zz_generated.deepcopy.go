@@ -51,6 +51,21 @@ func (in *API) DeepCopy() *API {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JWTIssuer) DeepCopyInto(out *JWTIssuer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JWTIssuer.
+func (in *JWTIssuer) DeepCopy() *JWTIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(JWTIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Mediation) DeepCopyInto(out *Mediation) {
 	*out = *in
@@ -77,6 +92,31 @@ func (in *Mediation) DeepCopy() *Mediation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Operation) DeepCopyInto(out *Operation) {
+	*out = *in
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RoutePolicies != nil {
+		in, out := &in.RoutePolicies, &out.RoutePolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Operation.
+func (in *Operation) DeepCopy() *Operation {
+	if in == nil {
+		return nil
+	}
+	out := new(Operation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Parameter) DeepCopyInto(out *Parameter) {
 	*out = *in
@@ -175,6 +215,23 @@ func (in *RouteMetadataList) DeepCopyObject() runtime.Object {
 func (in *RouteMetadataSpec) DeepCopyInto(out *RouteMetadataSpec) {
 	*out = *in
 	in.API.DeepCopyInto(&out.API)
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]Operation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.JWTIssuers != nil {
+		in, out := &in.JWTIssuers, &out.JWTIssuers
+		*out = make([]JWTIssuer, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteMetadataSpec.
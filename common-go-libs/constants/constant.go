@@ -29,6 +29,28 @@ const (
 	RouteMetadataController      string = "RouteMetadataController"
 )
 
+// Condition types and reasons of the status of the Application, Subscription and ApplicationMapping CRs
+const (
+	// ConditionAccepted tells whether the spec of the CR is valid.
+	ConditionAccepted = "Accepted"
+	// ConditionResolvedRefs tells whether the CRs referred to by the CR exist.
+	ConditionResolvedRefs = "ResolvedRefs"
+	// ConditionPropagated tells whether the connected enforcers acknowledged the CR.
+	ConditionPropagated = "Propagated"
+
+	ReasonAccepted             = "Accepted"
+	ReasonInvalid              = "Invalid"
+	ReasonResolvedRefs         = "ResolvedRefs"
+	ReasonApplicationNotFound  = "ApplicationNotFound"
+	ReasonSubscriptionNotFound = "SubscriptionNotFound"
+	ReasonRatelimitNotFound    = "RatelimitNotFound"
+	ReasonPropagated           = "Propagated"
+	ReasonPropagationFailed    = "PropagationFailed"
+	ReasonPendingAck           = "PendingAck"
+	ReasonNoEnforcers          = "NoEnforcers"
+	ReasonNotPropagated        = "NotPropagated"
+)
+
 // API events related constants
 const (
 	Create string = "CREATED"
@@ -193,6 +215,8 @@ const (
 	MediationURLGuardrail = "URLGuardrail"
 	// MediationRegexGuardrail holds the name of the Regex Guardrail mediation policy.
 	MediationRegexGuardrail = "RegexGuardrail"
+	// MediationPromptInjectionGuardrail holds the name of the Prompt Injection Guardrail mediation policy.
+	MediationPromptInjectionGuardrail = "PromptInjectionGuardrail"
 
 	// MediationSubscriptionRatelimit holds the name of the Subscription Rate Limit mediation policy.
 	MediationSubscriptionRatelimit = "SubscriptionRatelimit"
@@ -206,11 +230,188 @@ const (
 	MediationBackendJWT = "BackendJWT"
 	// MediationGraphQL holds the name of the GraphQL mediation policy.
 	MediationGraphQL = "GraphQL"
+	// MediationAIProviderTranslation holds the name of the AI Provider Translation mediation policy.
+	MediationAIProviderTranslation = "AIProviderTranslation"
+	// MediationSemanticCache holds the name of the Semantic Cache mediation policy.
+	MediationSemanticCache = "SemanticCache"
+	// MediationOPAAuthorization holds the name of the OPA Authorization mediation policy.
+	MediationOPAAuthorization = "OPAAuthorization"
+	// MediationSenderConstrainedToken holds the name of the Sender Constrained Token mediation policy.
+	MediationSenderConstrainedToken = "SenderConstrainedToken"
+	// MediationExternalCustom holds the name of the External Custom mediation policy.
+	MediationExternalCustom = "ExternalCustom"
+	// MediationWASMCustom holds the name of the WASM Custom mediation policy.
+	MediationWASMCustom = "WASMCustom"
 )
 
 const (
 	// GraphQLPolicyKeySchema is the key for specifying the GraphQL schema.
 	GraphQLPolicyKeySchema = "Schema"
+	// GraphQLPolicyKeyPersistedQueries is the key for enabling Automatic Persisted Queries.
+	GraphQLPolicyKeyPersistedQueries = "PersistedQueries"
+	// GraphQLPolicyKeyAllowListOnly is the key for only running the operations in the allow-list.
+	GraphQLPolicyKeyAllowListOnly = "AllowListOnly"
+	// GraphQLPolicyKeyOperationAllowList is the key for specifying the registered operations.
+	GraphQLPolicyKeyOperationAllowList = "OperationAllowList"
+	// GraphQLPolicyKeyPersistedQueryTTLSeconds is the key for specifying how long registered queries are kept.
+	GraphQLPolicyKeyPersistedQueryTTLSeconds = "PersistedQueryTTLSeconds"
+	// GraphQLPolicyKeyMaxDepth is the key for specifying the maximum depth of a query.
+	GraphQLPolicyKeyMaxDepth = "MaxDepth"
+	// GraphQLPolicyKeyMaxComplexity is the key for specifying the maximum complexity of a query.
+	GraphQLPolicyKeyMaxComplexity = "MaxComplexity"
+	// GraphQLPolicyKeyMaxAliases is the key for specifying the maximum number of aliases in a query.
+	GraphQLPolicyKeyMaxAliases = "MaxAliases"
+	// GraphQLPolicyKeyMaxRootFields is the key for specifying the maximum number of root fields in a query.
+	GraphQLPolicyKeyMaxRootFields = "MaxRootFields"
+	// GraphQLPolicyKeyFieldCosts is the key for specifying the JSON map of field costs keyed by Type.field.
+	GraphQLPolicyKeyFieldCosts = "FieldCosts"
+	// GraphQLPolicyKeyIntrospectionDisabledEnvironments is the key for specifying the comma separated
+	// environment types that reject introspection queries.
+	GraphQLPolicyKeyIntrospectionDisabledEnvironments = "IntrospectionDisabledEnvironments"
+	// GraphQLPolicyKeyPersistedQueryBackend is the key for specifying the store of the registered queries,
+	// redis or memory.
+	GraphQLPolicyKeyPersistedQueryBackend = "PersistedQueryBackend"
+)
+
+const (
+	// MediationPolicyKeyEnabled is the key for enabling or disabling a mediation policy.
+	MediationPolicyKeyEnabled = "Enabled"
+	// GuardrailPolicyKeyName is the key for specifying the name of a guardrail reported in assessments.
+	GuardrailPolicyKeyName = "name"
+	// GuardrailPolicyKeyJSONPath is the key for specifying the JSON path of the content a guardrail validates.
+	GuardrailPolicyKeyJSONPath = "jsonPath"
+	// GuardrailPolicyKeyShowAssessment is the key for specifying if the assessment is included in the error response.
+	GuardrailPolicyKeyShowAssessment = "showAssessment"
+
+	// MediationAIModelBasedRoundRobinKeyOnQuotaExceedSuspendDuration is the key for specifying the duration to suspend on quota exceed.
+	MediationAIModelBasedRoundRobinKeyOnQuotaExceedSuspendDuration = "OnQuotaExceedSuspendDuration"
+	// MediationAIModelBasedRoundRobinKeyModelsClusterPair is the key for specifying the AI model and its associated cluster pairs.
+	MediationAIModelBasedRoundRobinKeyModelsClusterPair = "ModelsClusterPair"
+
+	// AIProviderTranslationPolicyKeyTargets is the key for specifying the JSON list of translation targets.
+	AIProviderTranslationPolicyKeyTargets = "Targets"
+
+	// MediationAnalyticsPolicyKeyFieldPolicies is the key for the actions applied to the event fields,
+	// a JSON object such as {"userIp": "truncate", "userName": "hash", "userAgentHeader": "drop"}.
+	MediationAnalyticsPolicyKeyFieldPolicies = "FieldPolicies"
+	// MediationAnalyticsPolicyKeyAllowedProperties is the key for the comma separated custom properties
+	// kept in the events.
+	MediationAnalyticsPolicyKeyAllowedProperties = "AllowedProperties"
+
+	// BackendJWTPolicyKeyTokenTTL is the key for specifying the token time-to-live (TTL) in seconds.
+	BackendJWTPolicyKeyTokenTTL = "TokenTTL"
+	// BackendJWTPolicyKeyCustomClaims is the key for specifying custom claims in the JWT.
+	BackendJWTPolicyKeyCustomClaims = "CustomClaims"
+	// BackendJWTPolicyKeyClaimMapping is the key for specifying claim mapping in the JWT.
+	BackendJWTPolicyKeyClaimMapping = "ClaimMapping"
+
+	// ContentLengthGuardrailPolicyKeyMin is the key for specifying the minimum content length.
+	ContentLengthGuardrailPolicyKeyMin = "min"
+	// ContentLengthGuardrailPolicyKeyMax is the key for specifying the maximum content length.
+	ContentLengthGuardrailPolicyKeyMax = "max"
+	// ContentLengthGuardrailPolicyKeyInverted is the key for specifying if the validation should be inverted.
+	ContentLengthGuardrailPolicyKeyInverted = "invert"
+
+	// OPAAuthorizationPolicyKeyServerURL is the key for specifying the base URL of the OPA server.
+	OPAAuthorizationPolicyKeyServerURL = "serverURL"
+	// OPAAuthorizationPolicyKeyPolicy is the key for specifying the package path of the policy, such as apk/authz.
+	OPAAuthorizationPolicyKeyPolicy = "policy"
+	// OPAAuthorizationPolicyKeyRule is the key for specifying the rule of the policy to evaluate.
+	OPAAuthorizationPolicyKeyRule = "rule"
+	// OPAAuthorizationPolicyKeyAccessKey is the key for specifying the bearer token of the OPA server.
+	OPAAuthorizationPolicyKeyAccessKey = "accessKey"
+	// OPAAuthorizationPolicyKeySendAccessToken is the key for specifying if the access token of the request is sent to OPA.
+	OPAAuthorizationPolicyKeySendAccessToken = "sendAccessToken"
+	// OPAAuthorizationPolicyKeyRequestHeaders is the key for specifying a comma separated list of the request headers sent to OPA, or * to send every header.
+	OPAAuthorizationPolicyKeyRequestHeaders = "requestHeaders"
+	// OPAAuthorizationPolicyKeyAdditionalProperties is the key for specifying a JSON object of static properties added to the input.
+	OPAAuthorizationPolicyKeyAdditionalProperties = "additionalProperties"
+	// OPAAuthorizationPolicyKeyTimeout is the key for specifying the OPA request timeout in milliseconds.
+	OPAAuthorizationPolicyKeyTimeout = "timeoutMs"
+	// OPAAuthorizationPolicyKeyFailOpen is the key for specifying if requests are allowed when OPA cannot be reached.
+	OPAAuthorizationPolicyKeyFailOpen = "failOpen"
+	// OPAAuthorizationPolicyKeyCacheTTL is the key for specifying how long decisions are cached in seconds, 0 to disable caching.
+	OPAAuthorizationPolicyKeyCacheTTL = "cacheTTLSeconds"
+
+	// PIIMaskingGuardrailPolicyKeyPiiEntities is the key for specifying the PII entities and their regex patterns.
+	PIIMaskingGuardrailPolicyKeyPiiEntities = "piiEntities"
+	// PIIMaskingGuardrailPolicyKeyRedactPII is the key for specifying if PII should be redacted instead of masked.
+	PIIMaskingGuardrailPolicyKeyRedactPII = "redactPII"
+
+	// PromptInjectionGuardrailPolicyKeyThreshold is the key for specifying the score at which content is rejected.
+	PromptInjectionGuardrailPolicyKeyThreshold = "threshold"
+	// PromptInjectionGuardrailPolicyKeyScorer is the key for specifying the scorer used to score the content.
+	PromptInjectionGuardrailPolicyKeyScorer = "scorer"
+	// PromptInjectionGuardrailPolicyKeyPatterns is the key for specifying additional patterns for the heuristic scorer.
+	PromptInjectionGuardrailPolicyKeyPatterns = "patterns"
+	// PromptInjectionGuardrailPolicyKeyClassifierEndpoint is the key for specifying the external classifier endpoint.
+	PromptInjectionGuardrailPolicyKeyClassifierEndpoint = "classifierEndpoint"
+	// PromptInjectionGuardrailPolicyKeyTimeout is the key for specifying the classifier timeout in milliseconds.
+	PromptInjectionGuardrailPolicyKeyTimeout = "timeoutMs"
+	// PromptInjectionGuardrailPolicyKeyFailOpen is the key for specifying if payloads pass when scoring fails.
+	PromptInjectionGuardrailPolicyKeyFailOpen = "failOpen"
+
+	// RegexGuardrailPolicyKeyRegex is the key for specifying the regex pattern.
+	RegexGuardrailPolicyKeyRegex = "regex"
+	// RegexGuardrailPolicyKeyInverted is the key for specifying if the validation should be inverted.
+	RegexGuardrailPolicyKeyInverted = "invert"
+
+	// SemanticCachePolicyKeyThreshold is the key for specifying the minimum cosine similarity of a cache hit.
+	SemanticCachePolicyKeyThreshold = "Threshold"
+	// SemanticCachePolicyKeyTTLSeconds is the key for specifying how long completions are cached.
+	SemanticCachePolicyKeyTTLSeconds = "TTLSeconds"
+	// SemanticCachePolicyKeyMaxEntries is the key for specifying the maximum number of cached completions per route.
+	SemanticCachePolicyKeyMaxEntries = "MaxEntries"
+	// SemanticCachePolicyKeyTimeoutMs is the key for specifying the time budget of embedding and lookups.
+	SemanticCachePolicyKeyTimeoutMs = "TimeoutMs"
+	// SemanticCachePolicyKeyScope is the key for specifying who shares the cached completions, application or route.
+	SemanticCachePolicyKeyScope = "Scope"
+
+	// SenderConstrainedTokenPolicyKeyRequiredBinding is the key for specifying the binding the
+	// tokens must have: mtls, dpop, any, or empty to accept unbound tokens.
+	SenderConstrainedTokenPolicyKeyRequiredBinding = "requiredBinding"
+	// SenderConstrainedTokenPolicyKeyDPoPProofMaxAge is the key for specifying how long after it
+	// was issued a DPoP proof is accepted, in seconds.
+	SenderConstrainedTokenPolicyKeyDPoPProofMaxAge = "dpopProofMaxAgeSeconds"
+	// SenderConstrainedTokenPolicyKeyTrustForwardedClientCert is the key for specifying if the
+	// client certificate hash is read from the x-forwarded-client-cert header when Envoy does not
+	// report the certificate of the connection, such as behind a TLS terminating proxy.
+	SenderConstrainedTokenPolicyKeyTrustForwardedClientCert = "trustForwardedClientCert"
+
+	// SentenceCountGuardrailPolicyKeyMin is the key for specifying the minimum sentence count.
+	SentenceCountGuardrailPolicyKeyMin = "min"
+	// SentenceCountGuardrailPolicyKeyMax is the key for specifying the maximum sentence count.
+	SentenceCountGuardrailPolicyKeyMax = "max"
+	// SentenceCountGuardrailPolicyKeyInverted is the key for specifying if the validation should be inverted.
+	SentenceCountGuardrailPolicyKeyInverted = "invert"
+
+	// URLGuardrailPolicyKeyOnlyDNS is the key for specifying if only DNS validation should be performed.
+	URLGuardrailPolicyKeyOnlyDNS = "onlyDNS"
+	// URLGuardrailPolicyKeyTimeout is the key for specifying the timeout for URL validation.
+	URLGuardrailPolicyKeyTimeout = "timeout"
+
+	// WordCountGuardrailPolicyKeyMin is the key for specifying the minimum word count.
+	WordCountGuardrailPolicyKeyMin = "min"
+	// WordCountGuardrailPolicyKeyMax is the key for specifying the maximum word count.
+	WordCountGuardrailPolicyKeyMax = "max"
+	// WordCountGuardrailPolicyKeyInverted is the key for specifying if the validation should be inverted.
+	WordCountGuardrailPolicyKeyInverted = "invert"
+
+	// ExternalCustomPolicyKeyTimeoutMs is the key for specifying the plugin call timeout in milliseconds.
+	ExternalCustomPolicyKeyTimeoutMs = "timeoutMs"
+	// ExternalCustomPolicyKeyDownloadTimeoutMs is the key for specifying the plugin download timeout in milliseconds.
+	ExternalCustomPolicyKeyDownloadTimeoutMs = "downloadTimeoutMs"
+
+	// WASMCustomPolicyKeyModule is the key for specifying the base64 encoded WebAssembly module.
+	WASMCustomPolicyKeyModule = "module"
+	// WASMCustomPolicyKeyMemoryLimitMB is the key for specifying the linear memory limit of an instance in MiB.
+	WASMCustomPolicyKeyMemoryLimitMB = "memoryLimitMb"
+	// WASMCustomPolicyKeyTimeoutMs is the key for specifying the wall-clock timeout of a call in milliseconds.
+	WASMCustomPolicyKeyTimeoutMs = "timeoutMs"
+	// WASMCustomPolicyKeyFuelLimit is the key for specifying the number of instructions a call may run.
+	WASMCustomPolicyKeyFuelLimit = "fuelLimit"
+	// WASMCustomPolicyKeyFailOpen is the key for specifying if requests continue when the module fails.
+	WASMCustomPolicyKeyFailOpen = "failOpen"
 )
 
 const (
@@ -0,0 +1,241 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constants "github.com/wso2/apk/common-go-libs/constants"
+)
+
+// ParameterType is the type a mediation policy parameter value must parse as.
+type ParameterType string
+
+const (
+	// ParameterTypeString accepts any value.
+	ParameterTypeString ParameterType = "string"
+	// ParameterTypeBool accepts values parsed by strconv.ParseBool.
+	ParameterTypeBool ParameterType = "bool"
+	// ParameterTypeInt accepts values parsed by strconv.Atoi.
+	ParameterTypeInt ParameterType = "int"
+	// ParameterTypeFloat accepts values parsed by strconv.ParseFloat.
+	ParameterTypeFloat ParameterType = "float"
+	// ParameterTypeJSON accepts valid JSON documents.
+	ParameterTypeJSON ParameterType = "json"
+)
+
+// ParameterSchema describes a parameter accepted by a mediation policy.
+type ParameterSchema struct {
+	Key      string
+	Type     ParameterType
+	Required bool
+}
+
+// Validate reports whether the value parses as the type of the parameter.
+func (s ParameterSchema) Validate(value string) error {
+	switch s.Type {
+	case ParameterTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a bool", value)
+		}
+	case ParameterTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an int", value)
+		}
+	case ParameterTypeFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q is not a float", value)
+		}
+	case ParameterTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return errors.New("value is not valid JSON")
+		}
+	}
+	return nil
+}
+
+// enabledParameter is the schema of the Enabled flag shared by most built-in policies.
+var enabledParameter = ParameterSchema{Key: constants.MediationPolicyKeyEnabled, Type: ParameterTypeBool}
+
+// guardrailParameters returns the schema of a guardrail policy, followed by the parameters
+// shared by every guardrail.
+func guardrailParameters(parameters ...ParameterSchema) []ParameterSchema {
+	return append(parameters,
+		ParameterSchema{Key: constants.GuardrailPolicyKeyName, Type: ParameterTypeString},
+		ParameterSchema{Key: constants.GuardrailPolicyKeyJSONPath, Type: ParameterTypeString},
+		ParameterSchema{Key: constants.GuardrailPolicyKeyShowAssessment, Type: ParameterTypeBool},
+	)
+}
+
+// BuiltinParameters holds the parameter schemas of the mediation policies built into the
+// enforcer. The enforcer validates the mediations it loads against them, and the control plane
+// validates the mediations it generates, so that invalid policies are refused when they are
+// applied.
+var BuiltinParameters = map[string][]ParameterSchema{
+	constants.MediationAIProvider:             {enabledParameter},
+	constants.MediationSubscriptionRatelimit:  {enabledParameter},
+	constants.MediationSubscriptionValidation: {enabledParameter},
+	constants.MediationAIModelBasedRoundRobin: {
+		enabledParameter,
+		{Key: constants.MediationAIModelBasedRoundRobinKeyOnQuotaExceedSuspendDuration, Type: ParameterTypeInt},
+		{Key: constants.MediationAIModelBasedRoundRobinKeyModelsClusterPair, Type: ParameterTypeJSON},
+	},
+	constants.MediationAnalytics: {
+		enabledParameter,
+		{Key: constants.MediationAnalyticsPolicyKeyFieldPolicies, Type: ParameterTypeJSON},
+		{Key: constants.MediationAnalyticsPolicyKeyAllowedProperties, Type: ParameterTypeString},
+	},
+	constants.MediationBackendJWT: {
+		enabledParameter,
+		{Key: constants.BackendJWTPolicyKeyTokenTTL, Type: ParameterTypeInt},
+		{Key: constants.BackendJWTPolicyKeyCustomClaims, Type: ParameterTypeJSON},
+		{Key: constants.BackendJWTPolicyKeyClaimMapping, Type: ParameterTypeJSON},
+	},
+	constants.MediationGraphQL: {
+		{Key: constants.GraphQLPolicyKeySchema, Type: ParameterTypeString, Required: true},
+		{Key: constants.GraphQLPolicyKeyMaxDepth, Type: ParameterTypeInt},
+		{Key: constants.GraphQLPolicyKeyMaxComplexity, Type: ParameterTypeInt},
+		{Key: constants.GraphQLPolicyKeyMaxAliases, Type: ParameterTypeInt},
+		{Key: constants.GraphQLPolicyKeyMaxRootFields, Type: ParameterTypeInt},
+		{Key: constants.GraphQLPolicyKeyFieldCosts, Type: ParameterTypeJSON},
+		{Key: constants.GraphQLPolicyKeyIntrospectionDisabledEnvironments, Type: ParameterTypeString},
+		{Key: constants.GraphQLPolicyKeyPersistedQueries, Type: ParameterTypeBool},
+		{Key: constants.GraphQLPolicyKeyAllowListOnly, Type: ParameterTypeBool},
+		{Key: constants.GraphQLPolicyKeyOperationAllowList, Type: ParameterTypeJSON},
+		{Key: constants.GraphQLPolicyKeyPersistedQueryTTLSeconds, Type: ParameterTypeInt},
+		{Key: constants.GraphQLPolicyKeyPersistedQueryBackend, Type: ParameterTypeString},
+	},
+	constants.MediationBackendAPIKey: {enabledParameter},
+	constants.MediationWordCountGuardrail: guardrailParameters(
+		ParameterSchema{Key: constants.WordCountGuardrailPolicyKeyMin, Type: ParameterTypeInt},
+		ParameterSchema{Key: constants.WordCountGuardrailPolicyKeyMax, Type: ParameterTypeInt},
+		ParameterSchema{Key: constants.WordCountGuardrailPolicyKeyInverted, Type: ParameterTypeBool},
+	),
+	constants.MediationSentenceCountGuardrail: guardrailParameters(
+		ParameterSchema{Key: constants.SentenceCountGuardrailPolicyKeyMin, Type: ParameterTypeInt},
+		ParameterSchema{Key: constants.SentenceCountGuardrailPolicyKeyMax, Type: ParameterTypeInt},
+		ParameterSchema{Key: constants.SentenceCountGuardrailPolicyKeyInverted, Type: ParameterTypeBool},
+	),
+	constants.MediationContentLengthGuardrail: guardrailParameters(
+		ParameterSchema{Key: constants.ContentLengthGuardrailPolicyKeyMin, Type: ParameterTypeInt},
+		ParameterSchema{Key: constants.ContentLengthGuardrailPolicyKeyMax, Type: ParameterTypeInt},
+		ParameterSchema{Key: constants.ContentLengthGuardrailPolicyKeyInverted, Type: ParameterTypeBool},
+	),
+	constants.MediationPIIMaskingGuardrail: guardrailParameters(
+		ParameterSchema{Key: constants.PIIMaskingGuardrailPolicyKeyRedactPII, Type: ParameterTypeBool},
+		ParameterSchema{Key: constants.PIIMaskingGuardrailPolicyKeyPiiEntities, Type: ParameterTypeJSON},
+	),
+	constants.MediationURLGuardrail: guardrailParameters(
+		ParameterSchema{Key: constants.URLGuardrailPolicyKeyOnlyDNS, Type: ParameterTypeBool},
+		ParameterSchema{Key: constants.URLGuardrailPolicyKeyTimeout, Type: ParameterTypeInt},
+	),
+	constants.MediationRegexGuardrail: guardrailParameters(
+		ParameterSchema{Key: constants.RegexGuardrailPolicyKeyRegex, Type: ParameterTypeString, Required: true},
+		ParameterSchema{Key: constants.RegexGuardrailPolicyKeyInverted, Type: ParameterTypeBool},
+	),
+	constants.MediationPromptInjectionGuardrail: guardrailParameters(
+		ParameterSchema{Key: constants.PromptInjectionGuardrailPolicyKeyThreshold, Type: ParameterTypeFloat},
+		ParameterSchema{Key: constants.PromptInjectionGuardrailPolicyKeyScorer, Type: ParameterTypeString},
+		ParameterSchema{Key: constants.PromptInjectionGuardrailPolicyKeyPatterns, Type: ParameterTypeJSON},
+		ParameterSchema{Key: constants.PromptInjectionGuardrailPolicyKeyClassifierEndpoint, Type: ParameterTypeString},
+		ParameterSchema{Key: constants.PromptInjectionGuardrailPolicyKeyTimeout, Type: ParameterTypeInt},
+		ParameterSchema{Key: constants.PromptInjectionGuardrailPolicyKeyFailOpen, Type: ParameterTypeBool},
+	),
+	constants.MediationAIProviderTranslation: {
+		enabledParameter,
+		{Key: constants.AIProviderTranslationPolicyKeyTargets, Type: ParameterTypeJSON},
+	},
+	constants.MediationSemanticCache: {
+		enabledParameter,
+		{Key: constants.SemanticCachePolicyKeyThreshold, Type: ParameterTypeFloat},
+		{Key: constants.SemanticCachePolicyKeyTTLSeconds, Type: ParameterTypeInt},
+		{Key: constants.SemanticCachePolicyKeyMaxEntries, Type: ParameterTypeInt},
+		{Key: constants.SemanticCachePolicyKeyTimeoutMs, Type: ParameterTypeInt},
+		{Key: constants.SemanticCachePolicyKeyScope, Type: ParameterTypeString},
+	},
+	constants.MediationOPAAuthorization: {
+		enabledParameter,
+		{Key: constants.OPAAuthorizationPolicyKeyServerURL, Type: ParameterTypeString, Required: true},
+		{Key: constants.OPAAuthorizationPolicyKeyPolicy, Type: ParameterTypeString},
+		{Key: constants.OPAAuthorizationPolicyKeyRule, Type: ParameterTypeString},
+		{Key: constants.OPAAuthorizationPolicyKeyAccessKey, Type: ParameterTypeString},
+		{Key: constants.OPAAuthorizationPolicyKeySendAccessToken, Type: ParameterTypeBool},
+		{Key: constants.OPAAuthorizationPolicyKeyRequestHeaders, Type: ParameterTypeString},
+		{Key: constants.OPAAuthorizationPolicyKeyAdditionalProperties, Type: ParameterTypeJSON},
+		{Key: constants.OPAAuthorizationPolicyKeyTimeout, Type: ParameterTypeInt},
+		{Key: constants.OPAAuthorizationPolicyKeyFailOpen, Type: ParameterTypeBool},
+		{Key: constants.OPAAuthorizationPolicyKeyCacheTTL, Type: ParameterTypeInt},
+	},
+	constants.MediationSenderConstrainedToken: {
+		enabledParameter,
+		{Key: constants.SenderConstrainedTokenPolicyKeyRequiredBinding, Type: ParameterTypeString},
+		{Key: constants.SenderConstrainedTokenPolicyKeyDPoPProofMaxAge, Type: ParameterTypeInt},
+		{Key: constants.SenderConstrainedTokenPolicyKeyTrustForwardedClientCert, Type: ParameterTypeBool},
+	},
+	constants.MediationExternalCustom: {
+		{Key: constants.ExternalCustomPolicyKeyTimeoutMs, Type: ParameterTypeInt},
+		{Key: constants.ExternalCustomPolicyKeyDownloadTimeoutMs, Type: ParameterTypeInt},
+	},
+	constants.MediationWASMCustom: {
+		{Key: constants.WASMCustomPolicyKeyModule, Type: ParameterTypeString, Required: true},
+		{Key: constants.WASMCustomPolicyKeyMemoryLimitMB, Type: ParameterTypeInt},
+		{Key: constants.WASMCustomPolicyKeyTimeoutMs, Type: ParameterTypeInt},
+		{Key: constants.WASMCustomPolicyKeyFuelLimit, Type: ParameterTypeInt},
+		{Key: constants.WASMCustomPolicyKeyFailOpen, Type: ParameterTypeBool},
+	},
+}
+
+// ValidateBuiltinParameters validates the parameters of a mediation of a built-in policy against
+// its schema. Mediations of other policies, which may be registered with the enforcer at runtime,
+// are not validated. Parameters read from a ConfigMap or Secret through a valueRef are only
+// checked to be present, as their values are resolved by the enforcer.
+func ValidateBuiltinParameters(m *dpv2alpha1.Mediation) error {
+	var errs []error
+	for _, schema := range BuiltinParameters[m.PolicyName] {
+		param := findParameter(m.Parameters, schema.Key)
+		if param == nil {
+			if schema.Required {
+				errs = append(errs, fmt.Errorf("parameter %s is required", schema.Key))
+			}
+			continue
+		}
+		if param.ValueRef != nil && param.Value == "" {
+			continue
+		}
+		if err := schema.Validate(param.Value); err != nil {
+			errs = append(errs, fmt.Errorf("parameter %s: %w", schema.Key, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid parameters of mediation policy %s: %w", m.PolicyName, errors.Join(errs...))
+	}
+	return nil
+}
+
+func findParameter(params []*dpv2alpha1.Parameter, key string) *dpv2alpha1.Parameter {
+	for _, param := range params {
+		if param != nil && param.Key == key {
+			return param
+		}
+	}
+	return nil
+}
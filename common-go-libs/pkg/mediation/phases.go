@@ -0,0 +1,85 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	constants "github.com/wso2/apk/common-go-libs/constants"
+)
+
+// Phase is a set of ext_proc processing phases a mediation policy runs in.
+type Phase uint8
+
+const (
+	// PhaseRequestHeaders is the request headers phase.
+	PhaseRequestHeaders Phase = 1 << iota
+	// PhaseRequestBody is the request body phase.
+	PhaseRequestBody
+	// PhaseResponseHeaders is the response headers phase.
+	PhaseResponseHeaders
+	// PhaseResponseBody is the response body phase.
+	PhaseResponseBody
+
+	// PhaseAll is every processing phase.
+	PhaseAll = PhaseRequestHeaders | PhaseRequestBody | PhaseResponseHeaders | PhaseResponseBody
+)
+
+// PolicyProcessing describes the processing phases a mediation policy needs from Envoy.
+type PolicyProcessing struct {
+	// Phases are the processing phases the policy runs in.
+	Phases Phase
+	// BufferBody is set when the policy needs the whole body in its body phases.
+	BufferBody bool
+}
+
+// BuiltinPolicies holds the processing needs of the mediation policies built into the enforcer.
+// It lets the control plane derive the ext_proc processing mode of a route without depending on
+// the enforcer, which registers the same phases for each policy.
+var BuiltinPolicies = map[string]PolicyProcessing{
+	constants.MediationAITokenRatelimit:         {Phases: PhaseResponseHeaders | PhaseResponseBody, BufferBody: true},
+	constants.MediationAIProvider:               {Phases: PhaseResponseHeaders | PhaseResponseBody, BufferBody: true},
+	constants.MediationSubscriptionRatelimit:    {Phases: PhaseRequestHeaders},
+	constants.MediationSubscriptionValidation:   {Phases: PhaseRequestHeaders},
+	constants.MediationAIModelBasedRoundRobin:   {Phases: PhaseRequestBody, BufferBody: true},
+	constants.MediationAnalytics:                {Phases: PhaseRequestHeaders},
+	constants.MediationBackendJWT:               {Phases: PhaseRequestHeaders},
+	constants.MediationGraphQL:                  {Phases: PhaseRequestBody, BufferBody: true},
+	constants.MediationBackendAPIKey:            {Phases: PhaseRequestHeaders},
+	constants.MediationWordCountGuardrail:       {Phases: PhaseRequestBody | PhaseResponseBody, BufferBody: true},
+	constants.MediationSentenceCountGuardrail:   {Phases: PhaseRequestBody | PhaseResponseBody, BufferBody: true},
+	constants.MediationContentLengthGuardrail:   {Phases: PhaseRequestBody | PhaseResponseBody, BufferBody: true},
+	constants.MediationPIIMaskingGuardrail:      {Phases: PhaseRequestBody | PhaseResponseBody, BufferBody: true},
+	constants.MediationURLGuardrail:             {Phases: PhaseRequestBody | PhaseResponseBody, BufferBody: true},
+	constants.MediationRegexGuardrail:           {Phases: PhaseRequestBody | PhaseResponseBody, BufferBody: true},
+	constants.MediationPromptInjectionGuardrail: {Phases: PhaseRequestBody | PhaseResponseBody, BufferBody: true},
+	constants.MediationAIProviderTranslation:    {Phases: PhaseRequestBody | PhaseResponseHeaders | PhaseResponseBody, BufferBody: true},
+	constants.MediationSemanticCache:            {Phases: PhaseRequestBody | PhaseResponseBody, BufferBody: true},
+	constants.MediationOPAAuthorization:         {Phases: PhaseRequestHeaders},
+	constants.MediationSenderConstrainedToken:   {Phases: PhaseRequestHeaders},
+	constants.MediationExternalCustom:           {Phases: PhaseAll, BufferBody: true},
+	constants.MediationWASMCustom:               {Phases: PhaseAll, BufferBody: true},
+}
+
+// LookupPolicyProcessing returns the processing needs of the named mediation policy. Policies
+// that are not built in may be registered with the enforcer at runtime, so every phase is
+// assumed to be needed for them, with the bodies buffered.
+func LookupPolicyProcessing(name string) PolicyProcessing {
+	if p, ok := BuiltinPolicies[name]; ok {
+		return p
+	}
+	return PolicyProcessing{Phases: PhaseAll, BufferBody: true}
+}
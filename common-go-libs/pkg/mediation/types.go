@@ -31,3 +31,26 @@ type ExternalOutput struct {
 	StopFurtherProcessing        bool                   `json:"stopFurtherProcessing,omitempty"`
 	Metadata                     map[string]interface{} `json:"metadata,omitempty"`
 }
+
+// Plugin runner server mode contract. The runner serves a single unary gRPC method that carries
+// JSON payloads (content-subtype "json") so that no generated stubs are required on either side.
+const (
+	// PluginRunnerServiceName is the fully qualified gRPC service name of the plugin runner.
+	PluginRunnerServiceName = "wso2.apk.pluginrunner.PluginRunner"
+	// PluginRunnerProcessMethod is the full gRPC method path used to invoke a plugin.
+	PluginRunnerProcessMethod = "/" + PluginRunnerServiceName + "/Process"
+	// PluginRunnerCodec is the gRPC content-subtype used by the plugin runner.
+	PluginRunnerCodec = "json"
+)
+
+// PluginRunnerRequest is the request sent to a long-lived plugin runner.
+type PluginRunnerRequest struct {
+	PluginPath string        `json:"pluginPath"`
+	Symbol     string        `json:"symbol"`
+	Input      ExternalInput `json:"input"`
+}
+
+// PluginRunnerResponse is the response returned by a long-lived plugin runner.
+type PluginRunnerResponse struct {
+	Output ExternalOutput `json:"output"`
+}
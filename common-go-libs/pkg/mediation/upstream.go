@@ -0,0 +1,88 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// UpstreamSettings tunes the Envoy cluster serving an AI provider. It is set as the upstream of
+// the model cluster pairs of the AIModelBasedRoundRobin mediation and of the targets of the
+// AIProviderTranslation mediation, and applied to the cluster by the extension server. Zero
+// values leave the Envoy Gateway defaults in place.
+type UpstreamSettings struct {
+	// HTTP2 makes Envoy use HTTP/2 to the provider.
+	HTTP2 bool `json:"http2,omitempty" yaml:"http2,omitempty"`
+	// PreconnectRatio is the number of connections Envoy keeps open per request in flight, between 1 and 3.
+	PreconnectRatio float64 `json:"preconnectRatio,omitempty" yaml:"preconnectRatio,omitempty"`
+	// OutlierDetection ejects the provider hosts that keep failing.
+	OutlierDetection *OutlierDetection `json:"outlierDetection,omitempty" yaml:"outlierDetection,omitempty"`
+	// CircuitBreaker limits the connections, requests and retries to the provider.
+	CircuitBreaker *CircuitBreaker `json:"circuitBreaker,omitempty" yaml:"circuitBreaker,omitempty"`
+	// RateLimitedRetries is the number of times a request rate limited by the provider with a 429
+	// is retried, waiting as long as the Retry-After header of the response asks for.
+	RateLimitedRetries uint32 `json:"rateLimitedRetries,omitempty" yaml:"rateLimitedRetries,omitempty"`
+}
+
+// OutlierDetection configures the ejection of failing provider hosts. Envoy does not count rate
+// limited responses as failures, those are covered by RateLimitedRetries.
+type OutlierDetection struct {
+	// Consecutive5xx is the number of consecutive 5xx responses that eject a host. Defaults to 5.
+	Consecutive5xx uint32 `json:"consecutive5xx,omitempty" yaml:"consecutive5xx,omitempty"`
+	// ConsecutiveGatewayFailure is the number of consecutive 502, 503 and 504 responses and
+	// connection failures that eject a host. Defaults to 3.
+	ConsecutiveGatewayFailure uint32 `json:"consecutiveGatewayFailure,omitempty" yaml:"consecutiveGatewayFailure,omitempty"`
+	// IntervalSeconds is the time between ejection sweeps. Defaults to 10.
+	IntervalSeconds uint32 `json:"intervalSeconds,omitempty" yaml:"intervalSeconds,omitempty"`
+	// BaseEjectionTimeSeconds is how long a host is ejected for, multiplied by the number of
+	// times it was ejected. Defaults to 30.
+	BaseEjectionTimeSeconds uint32 `json:"baseEjectionTimeSeconds,omitempty" yaml:"baseEjectionTimeSeconds,omitempty"`
+	// MaxEjectionPercent is the most hosts of the cluster that can be ejected. Defaults to 100, as
+	// a provider is usually served by a single host, and the round robin moves to another model
+	// when its cluster has none left.
+	MaxEjectionPercent uint32 `json:"maxEjectionPercent,omitempty" yaml:"maxEjectionPercent,omitempty"`
+}
+
+// CircuitBreaker limits the load on a provider.
+type CircuitBreaker struct {
+	MaxConnections     uint32 `json:"maxConnections,omitempty" yaml:"maxConnections,omitempty"`
+	MaxPendingRequests uint32 `json:"maxPendingRequests,omitempty" yaml:"maxPendingRequests,omitempty"`
+	MaxRequests        uint32 `json:"maxRequests,omitempty" yaml:"maxRequests,omitempty"`
+	MaxRetries         uint32 `json:"maxRetries,omitempty" yaml:"maxRetries,omitempty"`
+	// RetryBudgetPercent limits the retries in flight to a percentage of the requests in flight.
+	// It replaces MaxRetries when set.
+	RetryBudgetPercent float64 `json:"retryBudgetPercent,omitempty" yaml:"retryBudgetPercent,omitempty"`
+	// MinRetryConcurrency is the number of retries the retry budget always allows.
+	MinRetryConcurrency uint32 `json:"minRetryConcurrency,omitempty" yaml:"minRetryConcurrency,omitempty"`
+}
+
+// Validate checks the settings are within the ranges Envoy accepts.
+func (u *UpstreamSettings) Validate() error {
+	var errs []error
+	if u.PreconnectRatio != 0 && (u.PreconnectRatio < 1 || u.PreconnectRatio > 3) {
+		errs = append(errs, fmt.Errorf("preconnectRatio %v is not between 1 and 3", u.PreconnectRatio))
+	}
+	if u.OutlierDetection != nil && u.OutlierDetection.MaxEjectionPercent > 100 {
+		errs = append(errs, fmt.Errorf("outlierDetection.maxEjectionPercent %d is over 100", u.OutlierDetection.MaxEjectionPercent))
+	}
+	if u.CircuitBreaker != nil && (u.CircuitBreaker.RetryBudgetPercent < 0 || u.CircuitBreaker.RetryBudgetPercent > 100) {
+		errs = append(errs, fmt.Errorf("circuitBreaker.retryBudgetPercent %v is not between 0 and 100", u.CircuitBreaker.RetryBudgetPercent))
+	}
+	return errors.Join(errs...)
+}
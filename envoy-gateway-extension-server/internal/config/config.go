@@ -31,6 +31,12 @@ type Server struct {
 	Logger                           logging.Logger
 	ExtensionServerHost            string `envconfig:"EXTENSION_SERVER_HOST" default:"0.0.0.0"`
 	ExtensionServerPort            string `envconfig:"EXTENSION_SERVER_PORT" default:"5005"`
+	// EnforcerExtProcPolicyName is the name of the EnvoyExtensionPolicy that attaches the enforcer ext_proc filter.
+	EnforcerExtProcPolicyName string `envconfig:"ENFORCER_EXT_PROC_POLICY_NAME" default:"enforcer-ext-proc"`
+	// DisableExtProcForUnmediatedRoutes disables the enforcer for routes without mediations, so token
+	// revocation is not checked and forced analytics are not published for them. By default only the
+	// request headers of those routes are sent to the enforcer.
+	DisableExtProcForUnmediatedRoutes bool `envconfig:"DISABLE_EXT_PROC_FOR_UNMEDIATED_ROUTES" default:"false"`
 }
 
 type metrics struct {
@@ -0,0 +1,208 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package extensionserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pb "github.com/envoyproxy/gateway/proto/extension"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	"github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	// routePolicyKind is the kind of the extension resource holding the mediations of a route.
+	routePolicyKind = "RoutePolicy"
+	// extProcPerRouteFilterPrefix prefixes the per route filter config names Envoy Gateway gives the
+	// ext_proc filters of EnvoyExtensionPolicies, which are
+	// envoy.filters.http.ext_proc/envoyextensionpolicy/<namespace>/<name>/extproc/<index>.
+	extProcPerRouteFilterPrefix = "envoy.filters.http.ext_proc/envoyextensionpolicy/"
+)
+
+// routePolicy holds the parts of a RoutePolicy the processing mode is derived from.
+type routePolicy struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		RequestMediation  []routePolicyMediation `json:"requestMediation"`
+		ResponseMediation []routePolicyMediation `json:"responseMediation"`
+	} `json:"spec"`
+}
+
+type routePolicyMediation struct {
+	PolicyName string                 `json:"policyName"`
+	Parameters []routePolicyParameter `json:"parameters"`
+}
+
+type routePolicyParameter struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// parameter returns the value of the mediation parameter.
+func (m *routePolicyMediation) parameter(key string) (string, bool) {
+	for _, p := range m.Parameters {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// routePolicies returns the RoutePolicies among the extension resources of a route.
+func routePolicies(resources []*pb.ExtensionResource) ([]*routePolicy, error) {
+	var policies []*routePolicy
+	for _, resource := range resources {
+		policy := &routePolicy{}
+		if err := json.Unmarshal(resource.GetUnstructuredBytes(), policy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal extension resource: %w", err)
+		}
+		if policy.Kind == routePolicyKind {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// processingMode returns the ext_proc processing mode the mediations of the route policies need,
+// or nil if the route has no mediations. The request headers are always sent for mediated routes,
+// as the enforcer reads the route policy and checks the token in that phase. The rest follows the
+// enforcer, which asks for the bodies and the response headers only when a mediation runs in them.
+func processingMode(policies []*routePolicy) *extprocv3.ProcessingMode {
+	var request, response []routePolicyMediation
+	for _, policy := range policies {
+		request = append(request, policy.Spec.RequestMediation...)
+		response = append(response, policy.Spec.ResponseMediation...)
+	}
+	if len(request) == 0 && len(response) == 0 {
+		return nil
+	}
+	mode := &extprocv3.ProcessingMode{
+		RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
+		ResponseHeaderMode: extprocv3.ProcessingMode_SKIP,
+		RequestBodyMode:    bodySendMode(request, mediation.PhaseRequestBody),
+		ResponseBodyMode:   bodySendMode(response, mediation.PhaseResponseBody),
+	}
+	for _, m := range response {
+		if mediation.LookupPolicyProcessing(m.PolicyName).Phases&mediation.PhaseResponseHeaders != 0 {
+			mode.ResponseHeaderMode = extprocv3.ProcessingMode_SEND
+		}
+	}
+	return mode
+}
+
+// bodySendMode returns BUFFERED if any mediation running in the body phase needs the whole body,
+// STREAMED if the mediations in the phase accept chunks, and NONE if no mediation runs in it.
+func bodySendMode(mediations []routePolicyMediation, phase mediation.Phase) extprocv3.ProcessingMode_BodySendMode {
+	mode := extprocv3.ProcessingMode_NONE
+	for _, m := range mediations {
+		p := mediation.LookupPolicyProcessing(m.PolicyName)
+		if p.Phases&phase == 0 {
+			continue
+		}
+		if p.BufferBody {
+			return extprocv3.ProcessingMode_BUFFERED
+		}
+		mode = extprocv3.ProcessingMode_STREAMED
+	}
+	return mode
+}
+
+// extProcPerRoute returns the per route ext_proc config for the processing mode. Without a mode
+// the route is unmediated, and the enforcer is either disabled or only sent the request headers.
+func (s *Server) extProcPerRoute(mode *extprocv3.ProcessingMode) *extprocv3.ExtProcPerRoute {
+	if mode != nil {
+		return &extprocv3.ExtProcPerRoute{
+			Override: &extprocv3.ExtProcPerRoute_Overrides{
+				Overrides: &extprocv3.ExtProcOverrides{ProcessingMode: mode},
+			},
+		}
+	}
+	if s.cfg.DisableExtProcForUnmediatedRoutes {
+		return &extprocv3.ExtProcPerRoute{
+			Override: &extprocv3.ExtProcPerRoute_Disabled{Disabled: true},
+		}
+	}
+	return &extprocv3.ExtProcPerRoute{
+		Override: &extprocv3.ExtProcPerRoute_Overrides{
+			Overrides: &extprocv3.ExtProcOverrides{
+				ProcessingMode: &extprocv3.ProcessingMode{
+					RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
+					ResponseHeaderMode: extprocv3.ProcessingMode_SKIP,
+					RequestBodyMode:    extprocv3.ProcessingMode_NONE,
+					ResponseBodyMode:   extprocv3.ProcessingMode_NONE,
+				},
+			},
+		},
+	}
+}
+
+// isEnforcerExtProcFilter reports whether the per route filter config name belongs to the enforcer
+// ext_proc filter.
+func (s *Server) isEnforcerExtProcFilter(name string) bool {
+	rest, ok := strings.CutPrefix(name, extProcPerRouteFilterPrefix)
+	if !ok {
+		return false
+	}
+	// <namespace>/<name>/extproc/<index>
+	parts := strings.Split(rest, "/")
+	return len(parts) == 4 && parts[1] == s.cfg.EnforcerExtProcPolicyName
+}
+
+// setExtProcPerRoute sets the per route config of the enforcer ext_proc filter of the route. The
+// filter is only enabled on the routes Envoy Gateway attached the EnvoyExtensionPolicy to, so
+// routes without a per route config are left alone. With overwrite unset, a config set before is
+// kept, so that the hooks running after PostRouteModify do not replace the mode it derived from
+// the route policies.
+func (s *Server) setExtProcPerRoute(route *routev3.Route, mode *extprocv3.ProcessingMode, overwrite bool) error {
+	for name, config := range route.GetTypedPerFilterConfig() {
+		if !s.isEnforcerExtProcFilter(name) {
+			continue
+		}
+		filterConfig := &routev3.FilterConfig{}
+		if err := config.UnmarshalTo(filterConfig); err != nil {
+			return fmt.Errorf("failed to unmarshal the filter config %s: %w", name, err)
+		}
+		if !overwrite && filterConfig.GetConfig().GetTypeUrl() != "" {
+			continue
+		}
+		perRoute, err := anypb.New(s.extProcPerRoute(mode))
+		if err != nil {
+			return err
+		}
+		filterConfig.Config = perRoute
+		if err := config.MarshalFrom(filterConfig); err != nil {
+			return fmt.Errorf("failed to marshal the filter config %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setUnmediatedExtProcPerRoute sets the per route config of the enforcer ext_proc filter for the
+// routes of the virtual host PostRouteModify was not called for, which are the routes without
+// extension resources and so without mediations.
+func (s *Server) setUnmediatedExtProcPerRoute(virtualHost *routev3.VirtualHost) {
+	for _, route := range virtualHost.GetRoutes() {
+		if err := s.setExtProcPerRoute(route, nil, false); err != nil {
+			s.cfg.Logger.Sugar().Errorf("Failed to set the ext_proc config of route %s: %v", route.GetName(), err)
+		}
+	}
+}
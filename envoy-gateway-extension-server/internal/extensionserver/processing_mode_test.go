@@ -0,0 +1,228 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package extensionserver
+
+import (
+	"context"
+	"testing"
+
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	pb "github.com/envoyproxy/gateway/proto/extension"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wso2/apk/envoy-gateway-extension-server/internal/config"
+	"github.com/wso2/apk/envoy-gateway-extension-server/internal/logging"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	enforcerFilter = "envoy.filters.http.ext_proc/envoyextensionpolicy/apk/enforcer-ext-proc/extproc/0"
+	otherFilter    = "envoy.filters.http.ext_proc/envoyextensionpolicy/apk/other/extproc/0"
+)
+
+func newTestServer(disableUnmediated bool) *Server {
+	return New(&config.Server{
+		Logger:                            logging.DefaultLogger(egv1a1.LogLevelInfo),
+		EnforcerExtProcPolicyName:         "enforcer-ext-proc",
+		DisableExtProcForUnmediatedRoutes: disableUnmediated,
+	})
+}
+
+// newRoute returns a route with the per route filter configs Envoy Gateway adds to enable the filters.
+func newRoute(t *testing.T, name string, filters ...string) *routev3.Route {
+	route := &routev3.Route{Name: name, TypedPerFilterConfig: map[string]*anypb.Any{}}
+	for _, filter := range filters {
+		config, err := anypb.New(&routev3.FilterConfig{Config: &anypb.Any{}})
+		require.NoError(t, err)
+		route.TypedPerFilterConfig[filter] = config
+	}
+	return route
+}
+
+// extProcPerRouteOf returns the ext_proc config of the filter, or nil if the filter has none.
+func extProcPerRouteOf(t *testing.T, route *routev3.Route, filter string) *extprocv3.ExtProcPerRoute {
+	filterConfig := &routev3.FilterConfig{}
+	require.NoError(t, route.TypedPerFilterConfig[filter].UnmarshalTo(filterConfig))
+	if filterConfig.GetConfig().GetTypeUrl() == "" {
+		return nil
+	}
+	perRoute := &extprocv3.ExtProcPerRoute{}
+	require.NoError(t, filterConfig.GetConfig().UnmarshalTo(perRoute))
+	return perRoute
+}
+
+func routePolicyResource(policy string) *pb.ExtensionResource {
+	return &pb.ExtensionResource{UnstructuredBytes: []byte(policy)}
+}
+
+func TestProcessingMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   *extprocv3.ProcessingMode
+	}{
+		{
+			name:   "no mediations",
+			policy: `{"kind":"RoutePolicy","spec":{}}`,
+		},
+		{
+			name:   "request headers only",
+			policy: `{"kind":"RoutePolicy","spec":{"requestMediation":[{"policyName":"BackendJWT"}]}}`,
+			want: &extprocv3.ProcessingMode{
+				RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
+				ResponseHeaderMode: extprocv3.ProcessingMode_SKIP,
+				RequestBodyMode:    extprocv3.ProcessingMode_NONE,
+				ResponseBodyMode:   extprocv3.ProcessingMode_NONE,
+			},
+		},
+		{
+			name:   "request and response bodies",
+			policy: `{"kind":"RoutePolicy","spec":{"requestMediation":[{"policyName":"GraphQL"}],"responseMediation":[{"policyName":"RegexGuardrail"}]}}`,
+			want: &extprocv3.ProcessingMode{
+				RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
+				ResponseHeaderMode: extprocv3.ProcessingMode_SKIP,
+				RequestBodyMode:    extprocv3.ProcessingMode_BUFFERED,
+				ResponseBodyMode:   extprocv3.ProcessingMode_BUFFERED,
+			},
+		},
+		{
+			name:   "response headers",
+			policy: `{"kind":"RoutePolicy","spec":{"responseMediation":[{"policyName":"AIProvider"}]}}`,
+			want: &extprocv3.ProcessingMode{
+				RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
+				ResponseHeaderMode: extprocv3.ProcessingMode_SEND,
+				RequestBodyMode:    extprocv3.ProcessingMode_NONE,
+				ResponseBodyMode:   extprocv3.ProcessingMode_BUFFERED,
+			},
+		},
+		{
+			name:   "unknown policy",
+			policy: `{"kind":"RoutePolicy","spec":{"requestMediation":[{"policyName":"CustomPolicy"}]}}`,
+			want: &extprocv3.ProcessingMode{
+				RequestHeaderMode:  extprocv3.ProcessingMode_SEND,
+				ResponseHeaderMode: extprocv3.ProcessingMode_SKIP,
+				RequestBodyMode:    extprocv3.ProcessingMode_BUFFERED,
+				ResponseBodyMode:   extprocv3.ProcessingMode_NONE,
+			},
+		},
+		{
+			name:   "other kind",
+			policy: `{"kind":"RouteMetadata","spec":{"requestMediation":[{"policyName":"GraphQL"}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := routePolicies([]*pb.ExtensionResource{routePolicyResource(tt.policy)})
+			require.NoError(t, err)
+			got := processingMode(policies)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.want.String(), got.String())
+		})
+	}
+}
+
+func TestPostRouteModify_ProcessingMode(t *testing.T) {
+	s := newTestServer(true)
+	route := newRoute(t, "graphql", enforcerFilter, otherFilter)
+	resp, err := s.PostRouteModify(context.Background(), &pb.PostRouteModifyRequest{
+		Route: route,
+		PostRouteContext: &pb.PostRouteExtensionContext{
+			ExtensionResources: []*pb.ExtensionResource{
+				routePolicyResource(`{"kind":"RoutePolicy","metadata":{"name":"p","namespace":"apk"},"spec":{"requestMediation":[{"policyName":"GraphQL"}]}}`),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	perRoute := extProcPerRouteOf(t, resp.Route, enforcerFilter)
+	require.NotNil(t, perRoute)
+	mode := perRoute.GetOverrides().GetProcessingMode()
+	assert.Equal(t, extprocv3.ProcessingMode_BUFFERED, mode.GetRequestBodyMode())
+	assert.Equal(t, extprocv3.ProcessingMode_NONE, mode.GetResponseBodyMode())
+	assert.Nil(t, extProcPerRouteOf(t, resp.Route, otherFilter), "only the enforcer filter is overridden")
+	assert.NotNil(t, resp.Route.Metadata.FilterMetadata["envoy.filters.http.ext_proc"].Fields["ExtensionRefs"])
+}
+
+func TestPostRouteModify_Unmediated(t *testing.T) {
+	resource := routePolicyResource(`{"kind":"RoutePolicy","spec":{}}`)
+
+	resp, err := newTestServer(true).PostRouteModify(context.Background(), &pb.PostRouteModifyRequest{
+		Route:            newRoute(t, "plain", enforcerFilter),
+		PostRouteContext: &pb.PostRouteExtensionContext{ExtensionResources: []*pb.ExtensionResource{resource}},
+	})
+	require.NoError(t, err)
+	assert.True(t, extProcPerRouteOf(t, resp.Route, enforcerFilter).GetDisabled())
+
+	resp, err = newTestServer(false).PostRouteModify(context.Background(), &pb.PostRouteModifyRequest{
+		Route:            newRoute(t, "plain", enforcerFilter),
+		PostRouteContext: &pb.PostRouteExtensionContext{ExtensionResources: []*pb.ExtensionResource{resource}},
+	})
+	require.NoError(t, err)
+	mode := extProcPerRouteOf(t, resp.Route, enforcerFilter).GetOverrides().GetProcessingMode()
+	assert.Equal(t, extprocv3.ProcessingMode_SEND, mode.GetRequestHeaderMode())
+	assert.Equal(t, extprocv3.ProcessingMode_SKIP, mode.GetResponseHeaderMode())
+	assert.Equal(t, extprocv3.ProcessingMode_NONE, mode.GetRequestBodyMode())
+}
+
+func TestPostVirtualHostModify(t *testing.T) {
+	s := newTestServer(true)
+	mediated := newRoute(t, "mediated", enforcerFilter)
+	_, err := s.PostRouteModify(context.Background(), &pb.PostRouteModifyRequest{
+		Route: mediated,
+		PostRouteContext: &pb.PostRouteExtensionContext{
+			ExtensionResources: []*pb.ExtensionResource{
+				routePolicyResource(`{"kind":"RoutePolicy","spec":{"requestMediation":[{"policyName":"GraphQL"}]}}`),
+			},
+		},
+	})
+	require.NoError(t, err)
+	unmediated := newRoute(t, "unmediated", enforcerFilter)
+	notAttached := newRoute(t, "not-attached")
+
+	resp, err := s.PostVirtualHostModify(context.Background(), &pb.PostVirtualHostModifyRequest{
+		VirtualHost: &routev3.VirtualHost{Routes: []*routev3.Route{mediated, unmediated, notAttached}},
+	})
+	require.NoError(t, err)
+	routes := resp.VirtualHost.Routes
+	assert.Equal(t, extprocv3.ProcessingMode_BUFFERED,
+		extProcPerRouteOf(t, routes[0], enforcerFilter).GetOverrides().GetProcessingMode().GetRequestBodyMode(),
+		"the mode derived from the route policy is kept")
+	assert.True(t, extProcPerRouteOf(t, routes[1], enforcerFilter).GetDisabled())
+	assert.Empty(t, routes[2].TypedPerFilterConfig)
+}
+
+func TestPostTranslateModify(t *testing.T) {
+	s := newTestServer(true)
+	req := &pb.PostTranslateModifyRequest{
+		Routes: []*routev3.RouteConfiguration{{
+			Name: "listener",
+			VirtualHosts: []*routev3.VirtualHost{{
+				Routes: []*routev3.Route{newRoute(t, "unmediated", enforcerFilter)},
+			}},
+		}},
+	}
+	resp, err := s.PostTranslateModify(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Routes, 1)
+	assert.True(t, extProcPerRouteOf(t, resp.Routes[0].VirtualHosts[0].Routes[0], enforcerFilter).GetDisabled())
+}
@@ -71,6 +71,7 @@ func (s *Server) PostHTTPListenerModify(ctx context.Context, req *pb.PostHTTPLis
 		} else {
 			// Update the basic auth filter
 			for providerKey, provider := range jwtAuthn.Providers {
+				addDPoPTokenExtraction(provider)
 				// Update the provider with the new passwords
 				jwksSourceSpecifier := provider.GetJwksSourceSpecifier()
 				if remoteJwks, ok := jwksSourceSpecifier.(*jwt_authnv3.JwtProvider_RemoteJwks); ok {
@@ -119,9 +120,9 @@ func findHCM(filterChain *listenerv3.FilterChain) (*hcm.HttpConnectionManager, i
 // PostRouteModify is called after Envoy Gateway is done generating a
 // Route xDS configuration and before that configuration is passed on to
 // Envoy Proxy.
-// This example adds a custom header to the Route as an example.
-// Note: This implementation is not secure, and should not be used to protect
-// anything important.
+// The extension resources of the route are recorded in the route metadata for
+// the enforcer, and the enforcer ext_proc filter is set to process only the
+// phases the mediations of the route policies run in.
 func (s *Server) PostRouteModify(ctx context.Context, req *pb.PostRouteModifyRequest) (*pb.PostRouteModifyResponse, error) {
 	s.cfg.Logger.Info("postRouteModify callback was invoked")
 	s.cfg.Logger.Sugar().Debugf("Received route: %+v", req.Route.Match)
@@ -189,11 +190,99 @@ func (s *Server) PostRouteModify(ctx context.Context, req *pb.PostRouteModifyReq
 			}),
 		},
 	}
+
+	// Send the enforcer only the phases the mediations of the route need.
+	policies, err := routePolicies(req.PostRouteContext.ExtensionResources)
+	if err != nil {
+		s.cfg.Logger.Sugar().Errorf("Failed to read the route policies of route %s: %v", req.Route.Name, err)
+		return &pb.PostRouteModifyResponse{
+			Route: req.Route,
+		}, nil
+	}
+	mode := processingMode(policies)
+	s.cfg.Logger.Sugar().Debugf("Processing mode of route %s: %+v", req.Route.Name, mode)
+	if err := s.setExtProcPerRoute(req.Route, mode, true); err != nil {
+		s.cfg.Logger.Sugar().Errorf("Failed to set the ext_proc config of route %s: %v", req.Route.Name, err)
+	}
+
+	// Record the upstream settings of the AI provider clusters of the route for PostTranslateModify.
+	upstreams, err := aiUpstreams(policies)
+	if err != nil {
+		s.cfg.Logger.Sugar().Errorf("Failed to read the AI upstreams of route %s: %v", req.Route.Name, err)
+	} else if err := setRouteUpstreams(req.Route, upstreams); err != nil {
+		s.cfg.Logger.Sugar().Errorf("Failed to set the AI upstreams of route %s: %v", req.Route.Name, err)
+	}
 	return &pb.PostRouteModifyResponse{
 		Route: req.Route,
 	}, nil
 }
 
+// PostVirtualHostModify is called after Envoy Gateway is done generating a
+// VirtualHost xDS configuration and before that configuration is passed on to
+// Envoy Proxy.
+// The routes of the virtual host without extension resources have no mediations,
+// so the enforcer ext_proc filter is disabled on them.
+func (s *Server) PostVirtualHostModify(ctx context.Context, req *pb.PostVirtualHostModifyRequest) (*pb.PostVirtualHostModifyResponse, error) {
+	s.cfg.Logger.Info("postVirtualHostModify callback was invoked")
+	s.cfg.Logger.Sugar().Debugf("Received virtual host: %+v", req.VirtualHost.GetName())
+	s.setUnmediatedExtProcPerRoute(req.VirtualHost)
+	return &pb.PostVirtualHostModifyResponse{
+		VirtualHost: req.VirtualHost,
+	}, nil
+}
+
+// PostTranslateModify is called after Envoy Gateway is done translating the
+// whole xDS configuration and before that configuration is passed on to Envoy
+// Proxy.
+// The routes without mediations are handled as in PostVirtualHostModify, which
+// covers the routes of the virtual hosts the hook is not called for. The
+// upstream settings PostRouteModify recorded for the AI provider endpoints are
+// applied to the clusters of the routes serving them, which are Envoy Gateway
+// clusters rather than custom backends, so PostClusterModify is not called for
+// them. The other resources
+// are returned as they are.
+func (s *Server) PostTranslateModify(ctx context.Context, req *pb.PostTranslateModifyRequest) (*pb.PostTranslateModifyResponse, error) {
+	s.cfg.Logger.Info("postTranslateModify callback was invoked")
+	for _, routeConfiguration := range req.Routes {
+		for _, virtualHost := range routeConfiguration.GetVirtualHosts() {
+			s.setUnmediatedExtProcPerRoute(virtualHost)
+		}
+	}
+	upstreams, unresolved, err := routeUpstreams(req.Routes, req.Clusters)
+	if err != nil {
+		s.cfg.Logger.Sugar().Errorf("Failed to read the AI upstreams of the routes: %v", err)
+	} else {
+		if len(unresolved) > 0 && len(req.Clusters) == 0 {
+			s.cfg.Logger.Sugar().Warnf("AI upstream settings are not applied as Envoy Gateway sends no clusters to the "+
+				"extension server, enable the cluster translation hook with includeAll: %v", unresolved)
+		} else if len(unresolved) > 0 {
+			s.cfg.Logger.Sugar().Warnf("AI upstream endpoints without a cluster: %v", unresolved)
+		}
+		if err := applyUpstreams(req.Clusters, upstreams); err != nil {
+			s.cfg.Logger.Sugar().Errorf("Failed to apply the AI upstreams: %v", err)
+		}
+	}
+	return &pb.PostTranslateModifyResponse{
+		Clusters:  req.Clusters,
+		Secrets:   req.Secrets,
+		Listeners: req.Listeners,
+		Routes:    req.Routes,
+	}, nil
+}
+
+// addDPoPTokenExtraction makes a provider that reads the token from the default location accept
+// DPoP bound tokens as well, which are sent with the DPoP authorization scheme (RFC 9449). The
+// proof of possession is checked by the SenderConstrainedToken mediation of the enforcer.
+func addDPoPTokenExtraction(provider *jwt_authnv3.JwtProvider) {
+	if len(provider.FromHeaders) > 0 || len(provider.FromParams) > 0 || len(provider.FromCookies) > 0 {
+		return
+	}
+	provider.FromHeaders = []*jwt_authnv3.JwtHeader{
+		{Name: "Authorization", ValuePrefix: "Bearer "},
+		{Name: "Authorization", ValuePrefix: "DPoP "},
+	}
+}
+
 // Tries to find the Basic Authentication HTTP filter in the provided chain
 func findJWTAuthnFilter(chain []*hcm.HttpFilter) (*jwt_authnv3.JwtAuthentication, int, error) {
 	for i, filter := range chain {
@@ -0,0 +1,373 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package extensionserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	constants "github.com/wso2/apk/common-go-libs/constants"
+	"github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	// mediationAIProviderTranslation is the name of the AI Provider Translation mediation policy.
+	mediationAIProviderTranslation = constants.MediationAIProviderTranslation
+	// modelsClusterPairParameter holds the model cluster pairs of the AIModelBasedRoundRobin mediation.
+	modelsClusterPairParameter = "ModelsClusterPair"
+	// targetsParameter holds the targets of the AIProviderTranslation mediation.
+	targetsParameter = "Targets"
+	// upstreamsMetadataKey is the route metadata field the upstream settings of the endpoints the
+	// route sends requests to are recorded in, as a JSON object keyed by endpoint URL.
+	upstreamsMetadataKey = "AIUpstreams"
+	// httpProtocolOptionsName is the extension protocol options key of the upstream HTTP options.
+	httpProtocolOptionsName = "envoy.extensions.upstreams.http.v3.HttpProtocolOptions"
+	// retryAfterHeader is the header AI providers send with rate limited responses.
+	retryAfterHeader = "retry-after"
+	// rateLimitedStatus is the status AI providers rate limit requests with.
+	rateLimitedStatus = 429
+
+	defaultConsecutive5xx            = 5
+	defaultConsecutiveGatewayFailure = 3
+	defaultOutlierInterval           = 10 * time.Second
+	defaultBaseEjectionTime          = 30 * time.Second
+	defaultMaxEjectionPercent        = 100
+)
+
+// endpointUpstream is an endpoint of an AI mediation with the settings of its upstream. The
+// mediations name the endpoint as the cluster, as the enforcer selects endpoints by that name.
+type endpointUpstream struct {
+	Endpoint string                      `json:"clusterName"`
+	Upstream *mediation.UpstreamSettings `json:"upstream"`
+}
+
+// aiUpstreams returns the upstream settings of the endpoints the AI mediations of the route
+// policies send requests to, keyed by endpoint URL. An endpoint listed more than once keeps the
+// first settings.
+func aiUpstreams(policies []*routePolicy) (map[string]*mediation.UpstreamSettings, error) {
+	upstreams := map[string]*mediation.UpstreamSettings{}
+	for _, policy := range policies {
+		for _, m := range policy.Spec.RequestMediation {
+			var key string
+			switch m.PolicyName {
+			case constants.MediationAIModelBasedRoundRobin:
+				key = modelsClusterPairParameter
+			case mediationAIProviderTranslation:
+				key = targetsParameter
+			default:
+				continue
+			}
+			value, ok := m.parameter(key)
+			if !ok || value == "" {
+				continue
+			}
+			var endpoints []endpointUpstream
+			if err := json.Unmarshal([]byte(value), &endpoints); err != nil {
+				return nil, fmt.Errorf("invalid %s of the %s mediation: %w", key, m.PolicyName, err)
+			}
+			for _, endpoint := range endpoints {
+				if endpoint.Endpoint == "" || endpoint.Upstream == nil {
+					continue
+				}
+				if err := endpoint.Upstream.Validate(); err != nil {
+					return nil, fmt.Errorf("invalid upstream of endpoint %s: %w", endpoint.Endpoint, err)
+				}
+				if _, exists := upstreams[endpoint.Endpoint]; !exists {
+					upstreams[endpoint.Endpoint] = endpoint.Upstream
+				}
+			}
+		}
+	}
+	return upstreams, nil
+}
+
+// setRouteUpstreams records the upstream settings in the route metadata, for PostTranslateModify
+// to apply them to the clusters serving the endpoints, and retries the requests the providers
+// rate limit.
+func setRouteUpstreams(route *routev3.Route, upstreams map[string]*mediation.UpstreamSettings) error {
+	if len(upstreams) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(upstreams)
+	if err != nil {
+		return err
+	}
+	metadata := route.Metadata.FilterMetadata[constants.ExternalProcessingNamespace]
+	if metadata == nil {
+		metadata = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		route.Metadata.FilterMetadata[constants.ExternalProcessingNamespace] = metadata
+	}
+	metadata.Fields[upstreamsMetadataKey] = structpb.NewStringValue(string(data))
+
+	var retries uint32
+	for _, upstream := range upstreams {
+		retries = max(retries, upstream.RateLimitedRetries)
+	}
+	action := route.GetRoute()
+	// A retry policy configured for the route, such as by a BackendTrafficPolicy, is kept.
+	if retries == 0 || action == nil || action.RetryPolicy != nil {
+		return nil
+	}
+	action.RetryPolicy = &routev3.RetryPolicy{
+		RetryOn:              "retriable-status-codes",
+		RetriableStatusCodes: []uint32{rateLimitedStatus},
+		NumRetries:           wrapperspb.UInt32(retries),
+		RateLimitedRetryBackOff: &routev3.RetryPolicy_RateLimitedRetryBackOff{
+			ResetHeaders: []*routev3.RetryPolicy_ResetHeader{
+				{Name: retryAfterHeader, Format: routev3.RetryPolicy_SECONDS},
+			},
+		},
+	}
+	return nil
+}
+
+// routeUpstreams resolves the endpoints recorded in the metadata of the routes to the clusters
+// the routes send requests to, returning the upstream settings keyed by cluster name and the
+// endpoints that could not be resolved. Routes are read in order, so a cluster shared by routes
+// or endpoints keeps the first settings.
+func routeUpstreams(routeConfigurations []*routev3.RouteConfiguration, clusters []*clusterv3.Cluster) (map[string]*mediation.UpstreamSettings, []string, error) {
+	clustersByName := make(map[string]*clusterv3.Cluster, len(clusters))
+	for _, cluster := range clusters {
+		clustersByName[cluster.GetName()] = cluster
+	}
+	upstreams := map[string]*mediation.UpstreamSettings{}
+	var unresolved []string
+	for _, routeConfiguration := range routeConfigurations {
+		for _, virtualHost := range routeConfiguration.GetVirtualHosts() {
+			for _, route := range virtualHost.GetRoutes() {
+				value := route.GetMetadata().GetFilterMetadata()[constants.ExternalProcessingNamespace].GetFields()[upstreamsMetadataKey]
+				if value == nil {
+					continue
+				}
+				endpointUpstreams := map[string]*mediation.UpstreamSettings{}
+				if err := json.Unmarshal([]byte(value.GetStringValue()), &endpointUpstreams); err != nil {
+					return nil, nil, fmt.Errorf("invalid upstreams of route %s: %w", route.GetName(), err)
+				}
+				endpoints := make([]string, 0, len(endpointUpstreams))
+				for endpoint := range endpointUpstreams {
+					endpoints = append(endpoints, endpoint)
+				}
+				sort.Strings(endpoints)
+				routeClusters := routeClusterNames(route)
+				for _, endpoint := range endpoints {
+					name := resolveEndpointCluster(endpoint, routeClusters, clustersByName)
+					if name == "" {
+						unresolved = append(unresolved, fmt.Sprintf("%s of route %s", endpoint, route.GetName()))
+						continue
+					}
+					if _, exists := upstreams[name]; !exists {
+						upstreams[name] = endpointUpstreams[endpoint]
+					}
+				}
+			}
+		}
+	}
+	return upstreams, unresolved, nil
+}
+
+// routeClusterNames returns the clusters the route sends requests to. Envoy Gateway names the
+// cluster of an HTTPRoute rule httproute/<namespace>/<name>/rule/<index>.
+func routeClusterNames(route *routev3.Route) []string {
+	action := route.GetRoute()
+	if cluster := action.GetCluster(); cluster != "" {
+		return []string{cluster}
+	}
+	var names []string
+	for _, weighted := range action.GetWeightedClusters().GetClusters() {
+		names = append(names, weighted.GetName())
+	}
+	return names
+}
+
+// resolveEndpointCluster returns the cluster of the route that serves the endpoint URL. That is
+// the cluster with an endpoint at the host and port of the URL, or else the only cluster of the
+// route, which serves every backend of the route, such as the pods of a Kubernetes service.
+func resolveEndpointCluster(endpoint string, routeClusters []string, clusters map[string]*clusterv3.Cluster) string {
+	host, port := endpointAddress(endpoint)
+	for _, name := range routeClusters {
+		cluster, ok := clusters[name]
+		if !ok {
+			continue
+		}
+		for _, locality := range cluster.GetLoadAssignment().GetEndpoints() {
+			for _, lbEndpoint := range locality.GetLbEndpoints() {
+				address := lbEndpoint.GetEndpoint().GetAddress().GetSocketAddress()
+				if address != nil && strings.EqualFold(address.GetAddress(), host) && address.GetPortValue() == port {
+					return name
+				}
+			}
+		}
+	}
+	if len(routeClusters) == 1 {
+		if _, ok := clusters[routeClusters[0]]; ok {
+			return routeClusters[0]
+		}
+	}
+	return ""
+}
+
+// endpointAddress returns the host and port of the endpoint URL, defaulting the port by scheme.
+func endpointAddress(endpoint string) (string, uint32) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", 0
+	}
+	if port, err := strconv.ParseUint(u.Port(), 10, 32); err == nil {
+		return u.Hostname(), uint32(port)
+	}
+	if u.Scheme == "http" {
+		return u.Hostname(), 80
+	}
+	return u.Hostname(), 443
+}
+
+// applyUpstreams applies the upstream settings to the clusters they are keyed by.
+func applyUpstreams(clusters []*clusterv3.Cluster, upstreams map[string]*mediation.UpstreamSettings) error {
+	for _, cluster := range clusters {
+		upstream, ok := upstreams[cluster.GetName()]
+		if !ok {
+			continue
+		}
+		if err := applyUpstream(cluster, upstream); err != nil {
+			return fmt.Errorf("failed to apply the upstream settings of cluster %s: %w", cluster.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// applyUpstream applies the upstream settings to the cluster.
+func applyUpstream(cluster *clusterv3.Cluster, upstream *mediation.UpstreamSettings) error {
+	if upstream.HTTP2 {
+		if err := setHTTP2(cluster); err != nil {
+			return err
+		}
+	}
+	if upstream.PreconnectRatio != 0 {
+		cluster.PreconnectPolicy = &clusterv3.Cluster_PreconnectPolicy{
+			PerUpstreamPreconnectRatio: wrapperspb.Double(upstream.PreconnectRatio),
+		}
+	}
+	if od := upstream.OutlierDetection; od != nil {
+		cluster.OutlierDetection = &clusterv3.OutlierDetection{
+			Consecutive_5Xx:           wrapperspb.UInt32(valueOr(od.Consecutive5xx, defaultConsecutive5xx)),
+			ConsecutiveGatewayFailure: wrapperspb.UInt32(valueOr(od.ConsecutiveGatewayFailure, defaultConsecutiveGatewayFailure)),
+			// Gateway failures are not enforced unless asked to, unlike consecutive 5xx.
+			EnforcingConsecutiveGatewayFailure: wrapperspb.UInt32(100),
+			Interval:                           durationOr(od.IntervalSeconds, defaultOutlierInterval),
+			BaseEjectionTime:                   durationOr(od.BaseEjectionTimeSeconds, defaultBaseEjectionTime),
+			MaxEjectionPercent:                 wrapperspb.UInt32(valueOr(od.MaxEjectionPercent, defaultMaxEjectionPercent)),
+		}
+	}
+	if cb := upstream.CircuitBreaker; cb != nil {
+		setCircuitBreaker(cluster, cb)
+	}
+	return nil
+}
+
+// setHTTP2 makes the cluster use HTTP/2, keeping the other upstream HTTP options of the cluster.
+func setHTTP2(cluster *clusterv3.Cluster) error {
+	options := &httpv3.HttpProtocolOptions{}
+	if existing, ok := cluster.GetTypedExtensionProtocolOptions()[httpProtocolOptionsName]; ok {
+		if err := existing.UnmarshalTo(options); err != nil {
+			return err
+		}
+	}
+	options.UpstreamProtocolOptions = &httpv3.HttpProtocolOptions_ExplicitHttpConfig_{
+		ExplicitHttpConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig{
+			ProtocolConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
+				Http2ProtocolOptions: &corev3.Http2ProtocolOptions{},
+			},
+		},
+	}
+	config, err := anypb.New(options)
+	if err != nil {
+		return err
+	}
+	if cluster.TypedExtensionProtocolOptions == nil {
+		cluster.TypedExtensionProtocolOptions = map[string]*anypb.Any{}
+	}
+	cluster.TypedExtensionProtocolOptions[httpProtocolOptionsName] = config
+	return nil
+}
+
+// setCircuitBreaker sets the limits of the default priority thresholds of the cluster, keeping the
+// limits that are not set.
+func setCircuitBreaker(cluster *clusterv3.Cluster, cb *mediation.CircuitBreaker) {
+	if cluster.CircuitBreakers == nil {
+		cluster.CircuitBreakers = &clusterv3.CircuitBreakers{}
+	}
+	var thresholds *clusterv3.CircuitBreakers_Thresholds
+	for _, t := range cluster.CircuitBreakers.Thresholds {
+		if t.GetPriority() == corev3.RoutingPriority_DEFAULT {
+			thresholds = t
+			break
+		}
+	}
+	if thresholds == nil {
+		thresholds = &clusterv3.CircuitBreakers_Thresholds{Priority: corev3.RoutingPriority_DEFAULT}
+		cluster.CircuitBreakers.Thresholds = append(cluster.CircuitBreakers.Thresholds, thresholds)
+	}
+	if cb.MaxConnections != 0 {
+		thresholds.MaxConnections = wrapperspb.UInt32(cb.MaxConnections)
+	}
+	if cb.MaxPendingRequests != 0 {
+		thresholds.MaxPendingRequests = wrapperspb.UInt32(cb.MaxPendingRequests)
+	}
+	if cb.MaxRequests != 0 {
+		thresholds.MaxRequests = wrapperspb.UInt32(cb.MaxRequests)
+	}
+	if cb.MaxRetries != 0 {
+		thresholds.MaxRetries = wrapperspb.UInt32(cb.MaxRetries)
+	}
+	if cb.RetryBudgetPercent != 0 {
+		thresholds.RetryBudget = &clusterv3.CircuitBreakers_Thresholds_RetryBudget{
+			BudgetPercent: &typev3.Percent{Value: cb.RetryBudgetPercent},
+		}
+		if cb.MinRetryConcurrency != 0 {
+			thresholds.RetryBudget.MinRetryConcurrency = wrapperspb.UInt32(cb.MinRetryConcurrency)
+		}
+	}
+}
+
+func valueOr(value uint32, def uint32) uint32 {
+	if value == 0 {
+		return def
+	}
+	return value
+}
+
+func durationOr(seconds uint32, def time.Duration) *durationpb.Duration {
+	if seconds == 0 {
+		return durationpb.New(def)
+	}
+	return durationpb.New(time.Duration(seconds) * time.Second)
+}
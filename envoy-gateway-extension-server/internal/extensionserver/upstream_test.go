@@ -0,0 +1,220 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package extensionserver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	pb "github.com/envoyproxy/gateway/proto/extension"
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// roundRobinPolicy returns a RoutePolicy with an AIModelBasedRoundRobin mediation over the pairs.
+func roundRobinPolicy(t *testing.T, pairs ...map[string]interface{}) *pb.ExtensionResource {
+	value, err := json.Marshal(pairs)
+	require.NoError(t, err)
+	policy, err := json.Marshal(map[string]interface{}{
+		"kind": "RoutePolicy",
+		"spec": map[string]interface{}{
+			"requestMediation": []map[string]interface{}{{
+				"policyName": "AIModelBasedRoundRobin",
+				"parameters": []map[string]string{{"key": "ModelsClusterPair", "value": string(value)}},
+			}},
+		},
+	})
+	require.NoError(t, err)
+	return routePolicyResource(string(policy))
+}
+
+func TestAIUpstreams(t *testing.T) {
+	policies, err := routePolicies([]*pb.ExtensionResource{
+		roundRobinPolicy(t,
+			map[string]interface{}{"modelName": "gpt-4o", "clusterName": "https://api.openai.com/v1", "upstream": map[string]interface{}{"http2": true}},
+			map[string]interface{}{"modelName": "gpt-4o-mini", "clusterName": "https://api.openai.com/v1", "upstream": map[string]interface{}{"rateLimitedRetries": 2}},
+			map[string]interface{}{"modelName": "mistral", "clusterName": "https://api.mistral.ai/v1"},
+		),
+		routePolicyResource(`{"kind":"RoutePolicy","spec":{"requestMediation":[{"policyName":"AIProviderTranslation",` +
+			`"parameters":[{"key":"Targets","value":"[{\"provider\":\"anthropic\",\"clusterName\":\"https://api.anthropic.com\",\"upstream\":{\"preconnectRatio\":1.5}}]"}]}]}}`),
+	})
+	require.NoError(t, err)
+
+	upstreams, err := aiUpstreams(policies)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*mediation.UpstreamSettings{
+		"https://api.openai.com/v1": {HTTP2: true},
+		"https://api.anthropic.com": {PreconnectRatio: 1.5},
+	}, upstreams)
+}
+
+func TestAIUpstreams_Invalid(t *testing.T) {
+	policies, err := routePolicies([]*pb.ExtensionResource{
+		roundRobinPolicy(t, map[string]interface{}{"clusterName": "https://api.openai.com/v1", "upstream": map[string]interface{}{"preconnectRatio": 5}}),
+	})
+	require.NoError(t, err)
+	_, err = aiUpstreams(policies)
+	assert.Error(t, err)
+}
+
+func TestApplyUpstream(t *testing.T) {
+	cluster := &clusterv3.Cluster{
+		Name: "httproute/default/chat-api/rule/0",
+		CircuitBreakers: &clusterv3.CircuitBreakers{
+			Thresholds: []*clusterv3.CircuitBreakers_Thresholds{{MaxConnections: wrapperspb.UInt32(1024)}},
+		},
+	}
+	err := applyUpstream(cluster, &mediation.UpstreamSettings{
+		HTTP2:            true,
+		PreconnectRatio:  2,
+		OutlierDetection: &mediation.OutlierDetection{Consecutive5xx: 2},
+		CircuitBreaker:   &mediation.CircuitBreaker{MaxRequests: 50, RetryBudgetPercent: 20},
+	})
+	require.NoError(t, err)
+
+	options := &httpv3.HttpProtocolOptions{}
+	require.NoError(t, cluster.TypedExtensionProtocolOptions[httpProtocolOptionsName].UnmarshalTo(options))
+	assert.NotNil(t, options.GetExplicitHttpConfig().GetHttp2ProtocolOptions())
+
+	assert.Equal(t, 2.0, cluster.PreconnectPolicy.PerUpstreamPreconnectRatio.GetValue())
+
+	od := cluster.OutlierDetection
+	assert.Equal(t, uint32(2), od.Consecutive_5Xx.GetValue())
+	assert.Equal(t, uint32(defaultConsecutiveGatewayFailure), od.ConsecutiveGatewayFailure.GetValue())
+	assert.Equal(t, uint32(100), od.MaxEjectionPercent.GetValue())
+	assert.Equal(t, durationpb.New(10*time.Second).AsDuration(), od.Interval.AsDuration())
+
+	require.Len(t, cluster.CircuitBreakers.Thresholds, 1)
+	thresholds := cluster.CircuitBreakers.Thresholds[0]
+	assert.Equal(t, corev3.RoutingPriority_DEFAULT, thresholds.Priority)
+	assert.Equal(t, uint32(1024), thresholds.MaxConnections.GetValue(), "limits that are not set are kept")
+	assert.Equal(t, uint32(50), thresholds.MaxRequests.GetValue())
+	assert.Equal(t, 20.0, thresholds.RetryBudget.BudgetPercent.GetValue())
+}
+
+// dnsCluster returns an Envoy Gateway cluster of an HTTPRoute rule with backends at the addresses.
+func dnsCluster(name string, addresses ...*corev3.SocketAddress) *clusterv3.Cluster {
+	var endpoints []*endpointv3.LbEndpoint
+	for _, address := range addresses {
+		endpoints = append(endpoints, &endpointv3.LbEndpoint{
+			HostIdentifier: &endpointv3.LbEndpoint_Endpoint{Endpoint: &endpointv3.Endpoint{
+				Address: &corev3.Address{Address: &corev3.Address_SocketAddress{SocketAddress: address}},
+			}},
+		})
+	}
+	return &clusterv3.Cluster{
+		Name: name,
+		LoadAssignment: &endpointv3.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints:   []*endpointv3.LocalityLbEndpoints{{LbEndpoints: endpoints}},
+		},
+	}
+}
+
+func socketAddress(host string, port uint32) *corev3.SocketAddress {
+	return &corev3.SocketAddress{Address: host, PortSpecifier: &corev3.SocketAddress_PortValue{PortValue: port}}
+}
+
+func TestResolveEndpointCluster(t *testing.T) {
+	clusters := map[string]*clusterv3.Cluster{}
+	for _, cluster := range []*clusterv3.Cluster{
+		dnsCluster("httproute/default/chat-api/rule/0", socketAddress("api.openai.com", 443)),
+		dnsCluster("httproute/default/chat-api/rule/1", socketAddress("api.mistral.ai", 8443)),
+		{Name: "httproute/default/local-llm/rule/0"},
+	} {
+		clusters[cluster.Name] = cluster
+	}
+	weighted := []string{"httproute/default/chat-api/rule/0", "httproute/default/chat-api/rule/1"}
+
+	assert.Equal(t, "httproute/default/chat-api/rule/0", resolveEndpointCluster("https://api.openai.com/v1", weighted, clusters))
+	assert.Equal(t, "httproute/default/chat-api/rule/1", resolveEndpointCluster("https://api.mistral.ai:8443/v1", weighted, clusters))
+	assert.Empty(t, resolveEndpointCluster("https://api.mistral.ai/v1", weighted, clusters), "the port must match")
+	assert.Empty(t, resolveEndpointCluster("https://api.anthropic.com", weighted, clusters))
+	// The only cluster of a route serves every endpoint of the route, such as a Kubernetes service.
+	assert.Equal(t, "httproute/default/local-llm/rule/0",
+		resolveEndpointCluster("http://llm.models.svc.cluster.local:8080", []string{"httproute/default/local-llm/rule/0"}, clusters))
+	assert.Empty(t, resolveEndpointCluster("https://api.openai.com/v1", []string{"httproute/default/missing/rule/0"}, clusters))
+}
+
+func TestAIUpstreams_RouteToCluster(t *testing.T) {
+	s := newTestServer(true)
+	route := newRoute(t, "httproute/default/chat-api/rule/0/match/0/chat_example_com", enforcerFilter)
+	route.Action = &routev3.Route_Route{Route: &routev3.RouteAction{
+		ClusterSpecifier: &routev3.RouteAction_WeightedClusters{WeightedClusters: &routev3.WeightedCluster{
+			Clusters: []*routev3.WeightedCluster_ClusterWeight{
+				{Name: "httproute/default/chat-api/rule/0", Weight: wrapperspb.UInt32(1)},
+				{Name: "httproute/default/chat-api/rule/1", Weight: wrapperspb.UInt32(1)},
+			},
+		}},
+	}}
+	resp, err := s.PostRouteModify(context.Background(), &pb.PostRouteModifyRequest{
+		Route: route,
+		PostRouteContext: &pb.PostRouteExtensionContext{
+			ExtensionResources: []*pb.ExtensionResource{
+				roundRobinPolicy(t,
+					map[string]interface{}{
+						"modelName":   "gpt-4o",
+						"clusterName": "https://api.openai.com/v1",
+						"upstream": map[string]interface{}{
+							"rateLimitedRetries": 3,
+							"outlierDetection":   map[string]interface{}{},
+						},
+					},
+					map[string]interface{}{
+						"modelName":   "mistral-large",
+						"clusterName": "https://api.mistral.ai/v1",
+						"upstream":    map[string]interface{}{"http2": true},
+					},
+				),
+			},
+		},
+	})
+	require.NoError(t, err)
+	retry := resp.Route.GetRoute().GetRetryPolicy()
+	require.NotNil(t, retry)
+	assert.Equal(t, []uint32{429}, retry.RetriableStatusCodes)
+	assert.Equal(t, uint32(3), retry.NumRetries.GetValue())
+	assert.Equal(t, "retry-after", retry.RateLimitedRetryBackOff.ResetHeaders[0].Name)
+
+	openai := dnsCluster("httproute/default/chat-api/rule/0", socketAddress("api.openai.com", 443))
+	mistral := dnsCluster("httproute/default/chat-api/rule/1", socketAddress("api.mistral.ai", 443))
+	other := dnsCluster("httproute/default/other-api/rule/0", socketAddress("api.openai.com", 443))
+	translated, err := s.PostTranslateModify(context.Background(), &pb.PostTranslateModifyRequest{
+		Clusters: []*clusterv3.Cluster{openai, mistral, other},
+		Routes: []*routev3.RouteConfiguration{{
+			VirtualHosts: []*routev3.VirtualHost{{Routes: []*routev3.Route{resp.Route}}},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, translated.Clusters, 3)
+	assert.NotNil(t, translated.Clusters[0].OutlierDetection)
+	assert.Nil(t, translated.Clusters[0].TypedExtensionProtocolOptions)
+	assert.Nil(t, translated.Clusters[1].OutlierDetection)
+	assert.Contains(t, translated.Clusters[1].TypedExtensionProtocolOptions, httpProtocolOptionsName)
+	assert.Nil(t, translated.Clusters[2].OutlierDetection, "clusters of other routes are not changed")
+}
@@ -0,0 +1,214 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package admin serves the enforcer admin API, which exposes the state held in the enforcer
+// datastores for debugging.
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	subscription "github.com/wso2/apk/adapter/pkg/discovery/api/wso2/discovery/subscription"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/datastore"
+)
+
+const (
+	// organizationQueryParam filters the listed resources by organization.
+	organizationQueryParam = "organization"
+	bearerPrefix           = "Bearer "
+	// redactedValue replaces the parameter values resolved from a Secret or ConfigMap.
+	redactedValue = "<redacted>"
+)
+
+// Server serves the admin API. Every request must carry the configured admin token as a bearer
+// token. The JWT issuer and revoked JTI stores are optional.
+type Server struct {
+	cfg                             *config.Server
+	subAppDatastore                 *datastore.SubscriptionApplicationDataStore
+	routePolicyAndMetadataDatastore *datastore.RoutePolicyAndMetadataDataStore
+	jwtIssuerStore                  *datastore.JWTIssuerStore
+	revokedJTIStore                 *datastore.RevokedJTIStore
+	roundRobinTracker               *datastore.ModelBasedRoundRobinTracker
+}
+
+// RevokedJTI is a revoked token identifier.
+type RevokedJTI struct {
+	JTI       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewServer creates the admin API server.
+func NewServer(cfg *config.Server, subAppDatastore *datastore.SubscriptionApplicationDataStore,
+	routePolicyAndMetadataDatastore *datastore.RoutePolicyAndMetadataDataStore, jwtIssuerStore *datastore.JWTIssuerStore,
+	revokedJTIStore *datastore.RevokedJTIStore) *Server {
+	return &Server{
+		cfg:                             cfg,
+		subAppDatastore:                 subAppDatastore,
+		routePolicyAndMetadataDatastore: routePolicyAndMetadataDatastore,
+		jwtIssuerStore:                  jwtIssuerStore,
+		revokedJTIStore:                 revokedJTIStore,
+		roundRobinTracker:               datastore.GetModelBasedRoundRobinTracker(),
+	}
+}
+
+// Start serves the admin API over TLS with the enforcer certificate. The API is not served when
+// no admin token is configured.
+func (s *Server) Start() {
+	if s.cfg.AdminAPIToken == "" {
+		s.cfg.Logger.Sugar().Error("Admin API token is not configured, hence not starting the admin API")
+		return
+	}
+	server := &http.Server{
+		Addr:              ":" + s.cfg.AdminAPIPort,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.cfg.Logger.Sugar().Infof("Starting the admin API on port %s", s.cfg.AdminAPIPort)
+	if err := server.ListenAndServeTLS(s.cfg.EnforcerPublicKeyPath, s.cfg.EnforcerPrivateKeyPath); err != nil {
+		s.cfg.Logger.Sugar().Errorf("Failed to serve the admin API: %v", err)
+	}
+}
+
+// Handler returns the HTTP handler of the admin API.
+func (s *Server) Handler() http.Handler {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(gin.Recovery(), s.authenticate)
+	api := r.Group("/admin")
+	api.GET("/applications", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"list": s.subAppDatastore.ListApplications(c.Query(organizationQueryParam))})
+	})
+	api.GET("/applicationmappings", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"list": s.subAppDatastore.ListApplicationMappings(c.Query(organizationQueryParam))})
+	})
+	api.GET("/applicationkeymappings", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"list": s.subAppDatastore.ListApplicationKeyMappings(c.Query(organizationQueryParam))})
+	})
+	api.GET("/subscriptions", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"list": s.subAppDatastore.ListSubscriptions(c.Query(organizationQueryParam))})
+	})
+	api.GET("/routemetadata", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"list": s.listRouteMetadata(c.Query(organizationQueryParam))})
+	})
+	api.GET("/routepolicies", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"list": s.listRoutePolicies(c.Query(organizationQueryParam))})
+	})
+	api.GET("/jwtissuers", func(c *gin.Context) {
+		jwtIssuers := make([]*subscription.JWTIssuer, 0)
+		if s.jwtIssuerStore != nil {
+			jwtIssuers = s.jwtIssuerStore.ListJWTIssuers(c.Query(organizationQueryParam))
+		}
+		c.JSON(http.StatusOK, gin.H{"list": jwtIssuers})
+	})
+	api.GET("/suspendedmodels", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"list": s.roundRobinTracker.ListSuspendedModels()})
+	})
+	api.GET("/revokedjtis", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"list": s.listRevokedJTIs()})
+	})
+	api.POST("/resolve", s.resolve)
+	return r
+}
+
+// authenticate rejects requests without the admin token.
+func (s *Server) authenticate(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), bearerPrefix)
+	if !ok || s.cfg.AdminAPIToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminAPIToken)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	c.Next()
+}
+
+// listRouteMetadata returns the route metadata of the organization sorted by namespaced name.
+func (s *Server) listRouteMetadata(organization string) []*dpv2alpha1.RouteMetadata {
+	routeMetadata := s.routePolicyAndMetadataDatastore.ListRouteMetadata()
+	list := make([]*dpv2alpha1.RouteMetadata, 0, len(routeMetadata))
+	for _, namespacedName := range sortedKeys(routeMetadata) {
+		if metadata := routeMetadata[namespacedName]; organization == "" || metadata.Spec.API.Organization == organization {
+			list = append(list, metadata)
+		}
+	}
+	return list
+}
+
+// listRoutePolicies returns the route policies sorted by namespaced name. Route policies are not
+// bound to an organization, so when filtering only the API level policies, which share the name of
+// the route metadata of the API, are returned. The values the common controller resolved from a
+// Secret or ConfigMap are redacted.
+func (s *Server) listRoutePolicies(organization string) []*dpv2alpha1.RoutePolicy {
+	routeMetadata := s.routePolicyAndMetadataDatastore.ListRouteMetadata()
+	policies := s.routePolicyAndMetadataDatastore.ListRoutePolicies()
+	list := make([]*dpv2alpha1.RoutePolicy, 0, len(policies))
+	for _, namespacedName := range sortedKeys(policies) {
+		if organization != "" {
+			metadata, ok := routeMetadata[namespacedName]
+			if !ok || metadata.Spec.API.Organization != organization {
+				continue
+			}
+		}
+		list = append(list, redactRoutePolicy(policies[namespacedName]))
+	}
+	return list
+}
+
+// redactRoutePolicy returns a copy of a route policy without the values of the parameters that refer
+// to another resource, as those hold credentials such as backend API keys.
+func redactRoutePolicy(policy *dpv2alpha1.RoutePolicy) *dpv2alpha1.RoutePolicy {
+	redacted := policy.DeepCopy()
+	for _, mediations := range [][]*dpv2alpha1.Mediation{redacted.Spec.RequestMediation, redacted.Spec.ResponseMediation} {
+		for _, mediation := range mediations {
+			if mediation == nil {
+				continue
+			}
+			for _, param := range mediation.Parameters {
+				if param != nil && param.ValueRef != nil && param.Value != "" {
+					param.Value = redactedValue
+				}
+			}
+		}
+	}
+	return redacted
+}
+
+func (s *Server) listRevokedJTIs() []RevokedJTI {
+	revokedJTIs := make([]RevokedJTI, 0)
+	if s.revokedJTIStore == nil {
+		return revokedJTIs
+	}
+	for jti, expiry := range s.revokedJTIStore.ListRevokedJTIs() {
+		revokedJTIs = append(revokedJTIs, RevokedJTI{JTI: jti, ExpiresAt: expiry})
+	}
+	sort.Slice(revokedJTIs, func(i, j int) bool { return revokedJTIs[i].JTI < revokedJTIs[j].JTI })
+	return revokedJTIs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
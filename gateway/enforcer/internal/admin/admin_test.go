@@ -0,0 +1,329 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	subscription "github.com/wso2/apk/adapter/pkg/discovery/api/wso2/discovery/subscription"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/datastore"
+	"github.com/wso2/apk/gateway/enforcer/internal/mediation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const testAdminToken = "test-admin-token"
+
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+	cfg := config.GetConfig()
+	cfg.AdminAPIToken = testAdminToken
+
+	subAppDatastore := datastore.GetSubAppDataStore(cfg)
+	subAppDatastore.AddApplication(&subscription_model.Application{UUID: "app-1", Name: "PetApp", OrganizationID: "org1"})
+	subAppDatastore.AddApplication(&subscription_model.Application{UUID: "app-2", Name: "OtherApp", OrganizationID: "org2"})
+	subAppDatastore.AddSubscription(&subscription_model.Subscription{UUID: "sub-1", Organization: "org1",
+		SubscribedAPI: &subscription_model.SubscribedAPI{Name: "PetStore", Version: "1.0.0"}})
+	subAppDatastore.AddApplicationMapping(&subscription_model.ApplicationMapping{UUID: "map-1", ApplicationRef: "app-1",
+		SubscriptionRef: "sub-1", OrganizationID: "org1"})
+
+	routeDatastore := datastore.NewRoutePolicyAndMetadataDataStore(cfg)
+	routeDatastore.AddRouteMetadata(&dpv2alpha1.RouteMetadata{
+		ObjectMeta: metav1.ObjectMeta{Name: "petstore", Namespace: "apk"},
+		Spec: dpv2alpha1.RouteMetadataSpec{
+			API:       dpv2alpha1.API{Name: "PetStore", Version: "1.0.0", Organization: "org1", Context: "/pets/1.0.0"},
+			Hostnames: []string{"gw.example.com", "*.pets.example.com"},
+			Operations: []dpv2alpha1.Operation{
+				{Method: "POST", Path: "/pets/1.0.0/pet", PathMatchType: "Exact", RoutePolicies: []string{"petstore", "ai-provider"}},
+				{Method: "GET", Path: "/pets/1.0.0/pet/[^/]+", PathMatchType: "RegularExpression", RoutePolicies: []string{"petstore"}},
+			},
+			JWTIssuers: []dpv2alpha1.JWTIssuer{{Name: "resident", Issuer: "https://idp.example.com"}},
+		},
+	})
+	routeDatastore.AddRouteMetadata(&dpv2alpha1.RouteMetadata{
+		ObjectMeta: metav1.ObjectMeta{Name: "petstore-internal", Namespace: "apk"},
+		Spec: dpv2alpha1.RouteMetadataSpec{
+			API:       dpv2alpha1.API{Name: "PetStoreInternal", Version: "1.0.0", Organization: "org1", Context: "/pets/1.0.0"},
+			Hostnames: []string{"internal.example.com"},
+		},
+	})
+	routeDatastore.AddRouteMetadata(&dpv2alpha1.RouteMetadata{
+		ObjectMeta: metav1.ObjectMeta{Name: "pets-root", Namespace: "apk"},
+		Spec: dpv2alpha1.RouteMetadataSpec{
+			API:        dpv2alpha1.API{Name: "Pets", Version: "1.0.0", Organization: "org2", Context: "/pets"},
+			JWTIssuers: []dpv2alpha1.JWTIssuer{{Name: "other", Issuer: "https://other.example.com"}},
+		},
+	})
+	routeDatastore.AddRoutePolicy(&dpv2alpha1.RoutePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "petstore", Namespace: "apk"},
+		Spec: dpv2alpha1.RoutePolicySpec{
+			RequestMediation:  []*dpv2alpha1.Mediation{{PolicyName: mediation.MediationSubscriptionValidation}},
+			ResponseMediation: []*dpv2alpha1.Mediation{{PolicyName: mediation.MediationAIProvider}},
+		},
+	})
+	routeDatastore.AddRoutePolicy(&dpv2alpha1.RoutePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "ai-provider", Namespace: "apk"},
+		Spec: dpv2alpha1.RoutePolicySpec{
+			RequestMediation: []*dpv2alpha1.Mediation{{
+				PolicyName: mediation.MediationAIModelBasedRoundRobin,
+				Parameters: []*dpv2alpha1.Parameter{
+					{Key: "apiKey", Value: "sk-secret", ValueRef: &gwapiv1.LocalObjectReference{Kind: "Secret", Name: "openai"}},
+					{Key: "model", Value: "gpt-4o"},
+				},
+			}},
+		},
+	})
+
+	jwtIssuerStore := datastore.NewJWTIssuerStore()
+	routeDatastore.TrackJWTIssuers(jwtIssuerStore)
+	revokedJTIStore := datastore.NewRevokedJTIStore()
+	revokedJTIStore.AddJTI("revoked-jti", time.Now().Add(time.Hour))
+	revokedJTIStore.AddJTI("expired-jti", time.Now().Add(-time.Hour))
+
+	return NewServer(cfg, subAppDatastore, routeDatastore, jwtIssuerStore, revokedJTIStore).Handler()
+}
+
+func doRequest(handler http.Handler, method, target, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func decodeList[T any](t *testing.T, recorder *httptest.ResponseRecorder) []T {
+	t.Helper()
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var body struct {
+		List []T `json:"list"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	return body.List
+}
+
+func TestAdminAPI_Authentication(t *testing.T) {
+	handler := newTestServer(t)
+	for _, token := range []string{"", "wrong-token"} {
+		if recorder := doRequest(handler, http.MethodGet, "/admin/applications", "", token); recorder.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: expected status 401, got %d", token, recorder.Code)
+		}
+	}
+	if recorder := doRequest(handler, http.MethodGet, "/admin/applications", "", testAdminToken); recorder.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", recorder.Code)
+	}
+}
+
+func TestAdminAPI_OrganizationFilter(t *testing.T) {
+	handler := newTestServer(t)
+	applications := decodeList[subscription_model.Application](t, doRequest(handler, http.MethodGet, "/admin/applications?organization=org1", "", testAdminToken))
+	if len(applications) != 1 || applications[0].UUID != "app-1" {
+		t.Errorf("expected only the org1 application, got %+v", applications)
+	}
+	if applications := decodeList[subscription_model.Application](t, doRequest(handler, http.MethodGet, "/admin/applications", "", testAdminToken)); len(applications) < 2 {
+		t.Errorf("expected the applications of every organization, got %+v", applications)
+	}
+	metadata := decodeList[dpv2alpha1.RouteMetadata](t, doRequest(handler, http.MethodGet, "/admin/routemetadata?organization=org2", "", testAdminToken))
+	if len(metadata) != 1 || metadata[0].Name != "pets-root" {
+		t.Errorf("expected only the org2 route metadata, got %+v", metadata)
+	}
+	policies := decodeList[dpv2alpha1.RoutePolicy](t, doRequest(handler, http.MethodGet, "/admin/routepolicies?organization=org1", "", testAdminToken))
+	if len(policies) != 1 || policies[0].Name != "petstore" {
+		t.Errorf("expected only the API level policy of org1, got %+v", policies)
+	}
+	if policies := decodeList[dpv2alpha1.RoutePolicy](t, doRequest(handler, http.MethodGet, "/admin/routepolicies", "", testAdminToken)); len(policies) != 2 {
+		t.Errorf("expected every route policy, got %d", len(policies))
+	}
+	issuers := decodeList[subscription.JWTIssuer](t, doRequest(handler, http.MethodGet, "/admin/jwtissuers?organization=org2", "", testAdminToken))
+	if len(issuers) != 1 || issuers[0].Name != "other" {
+		t.Errorf("expected only the org2 issuer, got %+v", issuers)
+	}
+}
+
+func TestAdminAPI_RoutePolicyRedaction(t *testing.T) {
+	handler := newTestServer(t)
+	recorder := doRequest(handler, http.MethodGet, "/admin/routepolicies", "", testAdminToken)
+	if strings.Contains(recorder.Body.String(), "sk-secret") {
+		t.Fatalf("expected the secret value to be redacted, got %s", recorder.Body.String())
+	}
+	policies := decodeList[dpv2alpha1.RoutePolicy](t, recorder)
+	var params []*dpv2alpha1.Parameter
+	for _, policy := range policies {
+		if policy.Name == "ai-provider" {
+			params = policy.Spec.RequestMediation[0].Parameters
+		}
+	}
+	if len(params) != 2 || params[0].Value != redactedValue || params[0].ValueRef == nil || params[1].Value != "gpt-4o" {
+		t.Errorf("expected only the referenced value to be redacted, got %+v", params)
+	}
+
+	// The stored policy keeps the value the mediation uses.
+	policy := &dpv2alpha1.RoutePolicy{Spec: dpv2alpha1.RoutePolicySpec{ResponseMediation: []*dpv2alpha1.Mediation{{
+		Parameters: []*dpv2alpha1.Parameter{{Key: "token", Value: "secret", ValueRef: &gwapiv1.LocalObjectReference{Kind: "Secret", Name: "webhook"}}},
+	}}}}
+	if redacted := redactRoutePolicy(policy); redacted.Spec.ResponseMediation[0].Parameters[0].Value != redactedValue {
+		t.Errorf("expected the response mediation value to be redacted, got %+v", redacted.Spec.ResponseMediation[0].Parameters[0])
+	}
+	if policy.Spec.ResponseMediation[0].Parameters[0].Value != "secret" {
+		t.Errorf("expected the stored policy to keep its value, got %+v", policy.Spec.ResponseMediation[0].Parameters[0])
+	}
+}
+
+func TestAdminAPI_RevokedJTIsAndSuspendedModels(t *testing.T) {
+	handler := newTestServer(t)
+	revokedJTIs := decodeList[RevokedJTI](t, doRequest(handler, http.MethodGet, "/admin/revokedjtis", "", testAdminToken))
+	if len(revokedJTIs) != 1 || revokedJTIs[0].JTI != "revoked-jti" {
+		t.Errorf("expected only the unexpired JTI, got %+v", revokedJTIs)
+	}
+	datastore.GetModelBasedRoundRobinTracker().SuspendModel("PetStore", "/chat", "gpt-4o", time.Minute)
+	suspended := decodeList[datastore.SuspendedModel](t, doRequest(handler, http.MethodGet, "/admin/suspendedmodels", "", testAdminToken))
+	if len(suspended) != 1 || suspended[0].Model != "gpt-4o" || suspended[0].API != "PetStore" {
+		t.Errorf("expected the suspended model, got %+v", suspended)
+	}
+}
+
+func testJWT(claims map[string]interface{}) string {
+	payload, _ := json.Marshal(claims)
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+func TestAdminAPI_Resolve(t *testing.T) {
+	handler := newTestServer(t)
+	token := testJWT(map[string]interface{}{
+		"iss":         "https://idp.example.com",
+		"jti":         "revoked-jti",
+		"application": map[string]interface{}{"uuid": "app-1"},
+	})
+	body, _ := json.Marshal(&ResolveRequest{Host: "gw.example.com", Path: "/pets/1.0.0/findByStatus?status=sold", Token: token})
+	recorder := doRequest(handler, http.MethodPost, "/admin/resolve", string(body), testAdminToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	response := &ResolveResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	if response.Path != "/pets/1.0.0/findByStatus" || len(response.Routes) != 1 {
+		t.Fatalf("expected the longest context to match, got %+v", response)
+	}
+	route := response.Routes[0]
+	if route.RouteMetadata != "apk/petstore" || len(route.RoutePolicies) != 1 || route.RoutePolicies[0] != "apk/petstore" ||
+		route.API.Name != "PetStore" || route.Operation != nil {
+		t.Errorf("unexpected route %+v", route)
+	}
+	if got := route.Mediations["requestHeaders"]; len(got) != 1 || got[0] != mediation.MediationSubscriptionValidation {
+		t.Errorf("unexpected request header mediations %v", got)
+	}
+	if got := route.Mediations["responseBody"]; len(got) != 1 || got[0] != mediation.MediationAIProvider {
+		t.Errorf("unexpected response body mediations %v", got)
+	}
+	if route.JWTIssuer != "resident" {
+		t.Errorf("expected the resident issuer, got %q", route.JWTIssuer)
+	}
+	if route.Subscription == nil || !route.Subscription.Allowed || route.Subscription.Application.UUID != "app-1" || route.Subscription.Subscription.UUID != "sub-1" {
+		t.Errorf("expected the subscription to be validated, got %+v", route.Subscription)
+	}
+	if response.Token == nil || !response.Token.Revoked {
+		t.Errorf("expected the token to be reported revoked, got %+v", response.Token)
+	}
+}
+
+func TestAdminAPI_ResolveHostAndOperation(t *testing.T) {
+	handler := newTestServer(t)
+	resolve := func(req *ResolveRequest) *ResolveResponse {
+		t.Helper()
+		body, _ := json.Marshal(req)
+		recorder := doRequest(handler, http.MethodPost, "/admin/resolve", string(body), testAdminToken)
+		response := &ResolveResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+			t.Fatalf("invalid response body: %v", err)
+		}
+		return response
+	}
+
+	response := resolve(&ResolveRequest{Host: "internal.example.com:9095", Path: "/pets/1.0.0/pet"})
+	if len(response.Routes) != 1 || response.Routes[0].RouteMetadata != "apk/petstore-internal" {
+		t.Fatalf("expected only the API served on the host, got %+v", response.Routes)
+	}
+	response = resolve(&ResolveRequest{Path: "/pets/1.0.0/pet"})
+	if len(response.Routes) != 2 {
+		t.Errorf("expected every API with the context without a host, got %+v", response.Routes)
+	}
+
+	response = resolve(&ResolveRequest{Host: "v1.pets.example.com", Method: "POST", Path: "/pets/1.0.0/pet"})
+	if len(response.Routes) != 1 || response.Routes[0].RouteMetadata != "apk/petstore" {
+		t.Fatalf("expected the wildcard hostname to match, got %+v", response.Routes)
+	}
+	route := response.Routes[0]
+	if route.Operation == nil || route.Operation.Path != "/pets/1.0.0/pet" || route.Operation.Method != "POST" {
+		t.Errorf("expected the POST operation, got %+v", route.Operation)
+	}
+	if len(route.RoutePolicies) != 2 || route.RoutePolicies[1] != "apk/ai-provider" {
+		t.Errorf("expected the RoutePolicies of the operation, got %v", route.RoutePolicies)
+	}
+	if got := route.Mediations["requestBody"]; len(got) != 1 || got[0] != mediation.MediationAIModelBasedRoundRobin {
+		t.Errorf("expected the operation level mediation, got %v", got)
+	}
+
+	response = resolve(&ResolveRequest{Host: "gw.example.com", Method: "GET", Path: "/pets/1.0.0/pet/42"})
+	route = response.Routes[0]
+	if route.Operation == nil || route.Operation.PathMatchType != "RegularExpression" || len(route.RoutePolicies) != 1 {
+		t.Errorf("expected the GET operation, got %+v", route)
+	}
+	if got := route.Mediations["requestBody"]; len(got) != 0 {
+		t.Errorf("expected no operation level mediation, got %v", got)
+	}
+}
+
+func TestAdminAPI_ResolveWithoutMatch(t *testing.T) {
+	handler := newTestServer(t)
+	body, _ := json.Marshal(&ResolveRequest{Path: "/pets", Token: "not-a-jwt"})
+	recorder := doRequest(handler, http.MethodPost, "/admin/resolve", string(body), testAdminToken)
+	response := &ResolveResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("invalid response body: %v", err)
+	}
+	if len(response.Routes) != 1 || response.Routes[0].RouteMetadata != "apk/pets-root" || response.Routes[0].Subscription != nil {
+		t.Errorf("expected the root context without subscription validation, got %+v", response.Routes)
+	}
+	if response.Token == nil || response.Token.Error == "" {
+		t.Errorf("expected a token decoding error, got %+v", response.Token)
+	}
+
+	body, _ = json.Marshal(&ResolveRequest{Path: "/unknown"})
+	if recorder := doRequest(handler, http.MethodPost, "/admin/resolve", string(body), testAdminToken); !strings.Contains(recorder.Body.String(), `"routes":[]`) {
+		t.Errorf("expected no routes, got %s", recorder.Body.String())
+	}
+	if recorder := doRequest(handler, http.MethodPost, "/admin/resolve", `{}`, testAdminToken); recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a path, got %d", recorder.Code)
+	}
+}
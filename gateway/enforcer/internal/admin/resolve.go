@@ -0,0 +1,340 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/gin-gonic/gin"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
+	"github.com/wso2/apk/gateway/enforcer/internal/mediation"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+// ResolveRequest is a request to resolve without sending it through the gateway.
+type ResolveRequest struct {
+	Host string `json:"host"`
+	// Method is the HTTP method of the request. Operations of every method match when it is empty.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path"`
+	// Token is the access token of the request. It is decoded but not verified.
+	Token string `json:"token,omitempty"`
+	// EnvType is the environment type of the route, PRODUCTION or SANDBOX.
+	EnvType      string `json:"envType,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// ResolveResponse is the resolution of a request.
+type ResolveResponse struct {
+	Host string `json:"host"`
+	Path string `json:"path"`
+	// Routes are the APIs served on the host with the longest context matching the path. APIs
+	// deployed without hostnames match every host.
+	Routes []ResolvedRoute `json:"routes"`
+	Token  *ResolvedToken  `json:"token,omitempty"`
+}
+
+// ResolvedRoute is an API the request would be routed to.
+type ResolvedRoute struct {
+	RouteMetadata string             `json:"routeMetadata"`
+	API           ResolvedAPI        `json:"api"`
+	Operation     *ResolvedOperation `json:"operation,omitempty"`
+	// RoutePolicies are the RoutePolicies of the matched operation, or the API level RoutePolicy
+	// when no operation matches.
+	RoutePolicies []string `json:"routePolicies,omitempty"`
+	// Mediations are the mediation policies of the RoutePolicies in the order they run in each phase.
+	Mediations   map[string][]string   `json:"mediations"`
+	JWTIssuer    string                `json:"jwtIssuer,omitempty"`
+	Subscription *ResolvedSubscription `json:"subscription,omitempty"`
+}
+
+// ResolvedAPI identifies the API of a route.
+type ResolvedAPI struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Organization string `json:"organization"`
+	Environment  string `json:"environment,omitempty"`
+	Context      string `json:"context"`
+}
+
+// ResolvedOperation is the operation of an API the request matches.
+type ResolvedOperation struct {
+	Method        string `json:"method,omitempty"`
+	Path          string `json:"path"`
+	PathMatchType string `json:"pathMatchType,omitempty"`
+}
+
+// ResolvedSubscription is the outcome of subscription validation for the token.
+type ResolvedSubscription struct {
+	Allowed      bool                             `json:"allowed"`
+	Application  *subscription_model.Application  `json:"application,omitempty"`
+	Subscription *subscription_model.Subscription `json:"subscription,omitempty"`
+}
+
+// ResolvedToken is the decoded access token.
+type ResolvedToken struct {
+	Claims  map[string]interface{} `json:"claims,omitempty"`
+	Revoked bool                   `json:"revoked"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// resolve shows the route metadata and mediation policies that would apply to a request, and
+// how the token would be validated.
+func (s *Server) resolve(c *gin.Context) {
+	req := &ResolveRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path is required"})
+		return
+	}
+	path, _, _ := strings.Cut(req.Path, "?")
+	response := &ResolveResponse{Host: req.Host, Path: path, Routes: make([]ResolvedRoute, 0)}
+	var claims map[string]interface{}
+	if req.Token != "" {
+		response.Token = &ResolvedToken{}
+		var err error
+		if claims, err = decodeJWTClaims(strings.TrimPrefix(req.Token, bearerPrefix)); err != nil {
+			response.Token.Error = err.Error()
+		} else {
+			response.Token.Claims = claims
+			if jti, ok := claims["jti"].(string); ok && s.revokedJTIStore != nil {
+				response.Token.Revoked = s.revokedJTIStore.IsJTIRevoked(jti)
+			}
+		}
+	}
+
+	host := hostWithoutPort(req.Host)
+	for _, namespacedName := range s.matchRouteMetadata(host, path, req.Organization) {
+		metadata := s.routePolicyAndMetadataDatastore.GetRouteMetadata(namespacedName)
+		if metadata == nil {
+			continue
+		}
+		route := ResolvedRoute{
+			RouteMetadata: namespacedName,
+			API: ResolvedAPI{
+				Name:         metadata.Spec.API.Name,
+				Version:      metadata.Spec.API.Version,
+				Organization: metadata.Spec.API.Organization,
+				Environment:  metadata.Spec.API.Environment,
+				Context:      metadata.Spec.API.Context,
+			},
+			Mediations: map[string][]string{},
+		}
+		routePolicies := []string{namespacedName}
+		if operation := matchOperation(metadata.Spec.Operations, host, req.Method, path); operation != nil {
+			route.Operation = &ResolvedOperation{Method: operation.Method, Path: operation.Path, PathMatchType: operation.PathMatchType}
+			routePolicies = routePolicies[:0]
+			for _, name := range operation.RoutePolicies {
+				routePolicies = append(routePolicies, metadata.Namespace+"/"+name)
+			}
+		}
+		var policies []*dpv2alpha1.RoutePolicy
+		for _, name := range routePolicies {
+			if policy := s.routePolicyAndMetadataDatastore.GetRoutePolicy(name); policy != nil {
+				route.RoutePolicies = append(route.RoutePolicies, name)
+				policies = append(policies, policy)
+			}
+		}
+		route.Mediations = mediationsByPhase(policies)
+		if claims != nil {
+			if issuer, ok := claims["iss"].(string); ok && s.jwtIssuerStore != nil {
+				route.JWTIssuer = s.matchJWTIssuer(metadata.Spec.API.Organization, issuer)
+			}
+			route.Subscription = validateSubscription(metadata, claims, req)
+		}
+		response.Routes = append(response.Routes, route)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// matchRouteMetadata returns the route metadata served on the host with the longest context
+// matching the path.
+func (s *Server) matchRouteMetadata(host, path, organization string) []string {
+	var matched []string
+	longest := -1
+	routeMetadata := s.routePolicyAndMetadataDatastore.ListRouteMetadata()
+	for _, namespacedName := range sortedKeys(routeMetadata) {
+		api := routeMetadata[namespacedName].Spec.API
+		if organization != "" && api.Organization != organization {
+			continue
+		}
+		if !matchHostnames(routeMetadata[namespacedName].Spec.Hostnames, host) {
+			continue
+		}
+		context := strings.TrimSuffix(api.Context, "/")
+		if path != context && !strings.HasPrefix(path, context+"/") {
+			continue
+		}
+		switch {
+		case len(context) > longest:
+			longest = len(context)
+			matched = []string{namespacedName}
+		case len(context) == longest:
+			matched = append(matched, namespacedName)
+		}
+	}
+	return matched
+}
+
+// matchOperation returns the first operation served on the host that matches the method and path,
+// in the order the route rules list them.
+func matchOperation(operations []dpv2alpha1.Operation, host, method, path string) *dpv2alpha1.Operation {
+	for i := range operations {
+		operation := &operations[i]
+		if !matchHostnames(operation.Hostnames, host) {
+			continue
+		}
+		if method != "" && operation.Method != "" && !strings.EqualFold(operation.Method, method) {
+			continue
+		}
+		if matchPath(operation.PathMatchType, operation.Path, path) {
+			return operation
+		}
+	}
+	return nil
+}
+
+// matchPath matches a path the way Gateway API HTTPRoute path matches do.
+func matchPath(matchType, value, path string) bool {
+	switch matchType {
+	case "Exact":
+		return path == value
+	case "RegularExpression":
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		return err == nil && re.MatchString(path)
+	default:
+		prefix := strings.TrimSuffix(value, "/")
+		return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+}
+
+// matchHostnames reports whether the host matches one of the hostnames. Hostnames starting with
+// "*." match any subdomain, and an empty host or hostname list matches everything.
+func matchHostnames(hostnames []string, host string) bool {
+	if host == "" || len(hostnames) == 0 {
+		return true
+	}
+	for _, hostname := range hostnames {
+		if strings.EqualFold(hostname, host) {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(hostname, "*"); ok && len(host) > len(suffix) &&
+			strings.HasSuffix(strings.ToLower(host), strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// matchJWTIssuer returns the name of the JWT issuer of the organization that accepts tokens of the
+// issuer, falling back to an issuer that accepts tokens of any issuer.
+func (s *Server) matchJWTIssuer(organization, issuer string) string {
+	if jwtIssuer := s.jwtIssuerStore.GetJWTIssuerByOrganizationAndIssuer(organization, issuer); jwtIssuer != nil {
+		return jwtIssuer.Name
+	}
+	if jwtIssuer := s.jwtIssuerStore.GetJWTIssuerByOrganizationAndIssuer(organization, ""); jwtIssuer != nil {
+		return jwtIssuer.Name
+	}
+	return ""
+}
+
+// mediationsByPhase returns the mediation policies of the RoutePolicies in the order they run in
+// each phase. The mediations of the RoutePolicies are concatenated as they are for the route.
+func mediationsByPhase(routePolicies []*dpv2alpha1.RoutePolicy) map[string][]string {
+	var requestMediation, responseMediation []*dpv2alpha1.Mediation
+	for _, policy := range routePolicies {
+		requestMediation = append(requestMediation, policy.Spec.RequestMediation...)
+		responseMediation = append(responseMediation, policy.Spec.ResponseMediation...)
+	}
+	phases := []struct {
+		name     string
+		phase    mediation.Phase
+		policies []*dpv2alpha1.Mediation
+	}{
+		{"requestHeaders", mediation.PhaseRequestHeaders, requestMediation},
+		{"requestBody", mediation.PhaseRequestBody, requestMediation},
+		{"responseHeaders", mediation.PhaseResponseHeaders, responseMediation},
+		{"responseBody", mediation.PhaseResponseBody, responseMediation},
+	}
+	mediations := map[string][]string{}
+	for _, phase := range phases {
+		for _, policy := range mediation.PoliciesForPhase(phase.policies, phase.phase) {
+			mediations[phase.name] = append(mediations[phase.name], policy.PolicyName)
+		}
+	}
+	return mediations
+}
+
+// validateSubscription runs subscription validation for the token claims against the API.
+func validateSubscription(metadata *dpv2alpha1.RouteMetadata, claims map[string]interface{}, req *ResolveRequest) *ResolvedSubscription {
+	holder := &requestconfig.Holder{
+		RouteMetadata:        metadata,
+		EnvType:              req.EnvType,
+		JWTAuthnPayloaClaims: claims,
+		RequestHeaders: &envoy_service_proc_v3.HttpHeaders{
+			Headers: &corev3.HeaderMap{
+				Headers: []*corev3.HeaderValue{
+					{Key: ":authority", RawValue: []byte(req.Host)},
+					{Key: ":path", RawValue: []byte(req.Path)},
+				},
+			},
+		},
+	}
+	result := mediation.NewSubscriptionValidation(&dpv2alpha1.Mediation{PolicyName: mediation.MediationSubscriptionValidation}).Process(holder)
+	return &ResolvedSubscription{
+		Allowed:      !result.ImmediateResponse,
+		Application:  holder.MatchedApplication,
+		Subscription: holder.MatchedSubscription,
+	}
+}
+
+// decodeJWTClaims decodes the claims of a JWT without verifying its signature.
+func decodeJWTClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, errors.New("token payload is not base64url encoded")
+	}
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("token payload is not a JSON object")
+	}
+	return claims, nil
+}
@@ -0,0 +1,262 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package aitranslation translates OpenAI compatible chat completion requests to the schemas of
+// other AI providers and translates their responses, including streamed responses, back.
+package aitranslation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ProviderOpenAI is the OpenAI chat completions API. Requests are passed through.
+	ProviderOpenAI = "openai"
+	// ProviderAzureOpenAI is the Azure OpenAI chat completions API, addressed by deployment.
+	ProviderAzureOpenAI = "azure"
+	// ProviderAnthropic is the Anthropic messages API.
+	ProviderAnthropic = "anthropic"
+	// ProviderBedrock is the Amazon Bedrock converse API.
+	ProviderBedrock = "bedrock"
+
+	// EventStreamContentType is the content type of the translated streamed responses.
+	EventStreamContentType = "text/event-stream"
+)
+
+// now returns the current time, replaced in tests.
+var now = time.Now
+
+// Target is a provider requests are translated for.
+type Target struct {
+	// Provider is the schema of the target, one of the Provider* constants.
+	Provider string `json:"provider"`
+	// ClusterName is the upstream cluster serving the target. A target without a cluster name is
+	// the default target of the route.
+	ClusterName string `json:"clusterName,omitempty"`
+	// Model replaces the model of the request when set.
+	Model string `json:"model,omitempty"`
+	// Path replaces the default upstream path of the provider. {model} is replaced by the model.
+	Path string `json:"path,omitempty"`
+	// APIVersion is the Azure api-version or the anthropic-version header value.
+	APIVersion string `json:"apiVersion,omitempty"`
+	// MaxTokens is the completion limit sent to providers requiring one when the request has none.
+	MaxTokens int `json:"maxTokens,omitempty"`
+}
+
+// UpstreamRequest is a translated request.
+type UpstreamRequest struct {
+	// Body is the translated request body.
+	Body []byte
+	// Path is the upstream path, empty to keep the path of the request.
+	Path string
+	// Headers are the headers to set on the upstream request.
+	Headers map[string]string
+}
+
+// Exchange is the state of a translated request, kept to translate the response back.
+type Exchange struct {
+	Target Target
+	// Model is the model requested from the provider.
+	Model string
+	// Stream reports whether the client requested a streamed response.
+	Stream bool
+	// StreamTranslator translates the streamed response, set once the response headers are seen.
+	StreamTranslator StreamTranslator
+}
+
+// TranslatesResponse reports whether the responses of the exchange are translated. OpenAI and
+// Azure OpenAI responses are passed through.
+func (e *Exchange) TranslatesResponse() bool {
+	provider := strings.ToLower(e.Target.Provider)
+	return provider != ProviderOpenAI && provider != ProviderAzureOpenAI
+}
+
+// Provider translates requests to and responses from an AI provider.
+type Provider interface {
+	// TranslateRequest translates an OpenAI chat completion request for the target.
+	TranslateRequest(body []byte, req *ChatCompletionRequest, target *Target) (*UpstreamRequest, error)
+	// TranslateResponse translates a buffered response to an OpenAI chat completion or error. A nil
+	// body means the response needs no translation.
+	TranslateResponse(status int, body []byte, exchange *Exchange) ([]byte, error)
+	// NewStreamTranslator returns the translator of a streamed response with the content type, or
+	// nil when the stream needs no translation.
+	NewStreamTranslator(contentType string, exchange *Exchange) StreamTranslator
+}
+
+// StreamTranslator translates a streamed response to an OpenAI chat completion event stream.
+type StreamTranslator interface {
+	// Write consumes the next chunk of the provider stream and returns the translated events.
+	Write(chunk []byte) []byte
+	// Close returns the remaining translated events at the end of the stream.
+	Close() []byte
+}
+
+var providers = map[string]Provider{
+	ProviderOpenAI:      openAIProvider{},
+	ProviderAzureOpenAI: azureOpenAIProvider{},
+	ProviderAnthropic:   anthropicProvider{},
+	ProviderBedrock:     bedrockProvider{},
+}
+
+// GetProvider returns the named provider.
+func GetProvider(name string) (Provider, bool) {
+	provider, ok := providers[strings.ToLower(name)]
+	return provider, ok
+}
+
+// TranslateRequest translates an OpenAI chat completion request body for the target and returns
+// the translated request along with the exchange used to translate the response.
+func TranslateRequest(body []byte, target *Target) (*UpstreamRequest, *Exchange, error) {
+	provider, ok := GetProvider(target.Provider)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown AI provider %q", target.Provider)
+	}
+	req := &ChatCompletionRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, nil, fmt.Errorf("invalid chat completion request: %w", err)
+	}
+	if target.Model != "" {
+		req.Model = target.Model
+	}
+	upstream, err := provider.TranslateRequest(body, req, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	return upstream, &Exchange{Target: *target, Model: req.Model, Stream: req.Stream}, nil
+}
+
+// TranslateResponse translates a buffered response of the exchange. A nil body means the response
+// needs no translation.
+func TranslateResponse(status int, body []byte, exchange *Exchange) ([]byte, error) {
+	provider, ok := GetProvider(exchange.Target.Provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown AI provider %q", exchange.Target.Provider)
+	}
+	return provider.TranslateResponse(status, body, exchange)
+}
+
+// NewStreamTranslator returns the translator of a streamed response of the exchange, or nil when
+// the stream needs no translation.
+func NewStreamTranslator(contentType string, exchange *Exchange) StreamTranslator {
+	provider, ok := GetProvider(exchange.Target.Provider)
+	if !ok {
+		return nil
+	}
+	return provider.NewStreamTranslator(contentType, exchange)
+}
+
+// openAIError builds an OpenAI error body.
+func openAIError(status int, errorType, message string) []byte {
+	if errorType == "" {
+		errorType = "api_error"
+		if status >= 400 && status < 500 {
+			errorType = "invalid_request_error"
+		}
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	body, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    errorType,
+			"param":   nil,
+			"code":    nil,
+		},
+	})
+	return body
+}
+
+// applyPath expands the path template of the target, or returns the default path.
+func applyPath(target *Target, defaultPath, model string) string {
+	path := target.Path
+	if path == "" {
+		path = defaultPath
+	}
+	return strings.ReplaceAll(path, "{model}", model)
+}
+
+// sseReader splits a server-sent event stream into events across chunks.
+type sseReader struct {
+	pending []byte
+	event   string
+	data    []string
+}
+
+// sseEvent is a dispatched server-sent event.
+type sseEvent struct {
+	Event string
+	Data  string
+}
+
+// write consumes the chunk and returns the events completed by it.
+func (r *sseReader) write(chunk []byte) []sseEvent {
+	r.pending = append(r.pending, chunk...)
+	var events []sseEvent
+	for {
+		newline := bytes.IndexByte(r.pending, '\n')
+		if newline < 0 {
+			return events
+		}
+		line := strings.TrimSuffix(string(r.pending[:newline]), "\r")
+		r.pending = r.pending[newline+1:]
+		if event, ok := r.line(line); ok {
+			events = append(events, event)
+		}
+	}
+}
+
+// close returns the last event when the stream does not end with a blank line.
+func (r *sseReader) close() []sseEvent {
+	var events []sseEvent
+	if len(r.pending) > 0 {
+		if event, ok := r.line(strings.TrimSuffix(string(r.pending), "\r")); ok {
+			events = append(events, event)
+		}
+		r.pending = nil
+	}
+	if event, ok := r.line(""); ok {
+		events = append(events, event)
+	}
+	return events
+}
+
+func (r *sseReader) line(line string) (sseEvent, bool) {
+	if line == "" {
+		if len(r.data) == 0 {
+			r.event = ""
+			return sseEvent{}, false
+		}
+		event := sseEvent{Event: r.event, Data: strings.Join(r.data, "\n")}
+		r.event, r.data = "", nil
+		return event, true
+	}
+	field, value, _ := strings.Cut(line, ":")
+	value = strings.TrimPrefix(value, " ")
+	switch field {
+	case "event":
+		r.event = value
+	case "data":
+		r.data = append(r.data, value)
+	}
+	return sseEvent{}, false
+}
@@ -0,0 +1,384 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package aitranslation
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testChatRequest = `{
+	"model": "gpt-4o",
+	"messages": [
+		{"role": "system", "content": "Be brief."},
+		{"role": "user", "content": "Hi"},
+		{"role": "user", "content": [{"type": "text", "text": "What is APK?"}]}
+	],
+	"max_tokens": 64,
+	"temperature": 0.2,
+	"stop": "END"
+}`
+
+func init() {
+	now = func() time.Time { return time.Unix(1700000000, 0) }
+}
+
+func TestTranslateRequest_Anthropic(t *testing.T) {
+	upstream, exchange, err := TranslateRequest([]byte(testChatRequest), &Target{Provider: ProviderAnthropic, Model: "claude-sonnet"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got anthropicRequest
+	if err := json.Unmarshal(upstream.Body, &got); err != nil {
+		t.Fatalf("invalid translated body: %v", err)
+	}
+	if got.Model != "claude-sonnet" || got.System != "Be brief." || got.MaxTokens != 64 {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Role != "user" || got.Messages[0].Content != "Hi\n\nWhat is APK?" {
+		t.Errorf("expected the user messages merged, got %+v", got.Messages)
+	}
+	if len(got.StopSequences) != 1 || got.StopSequences[0] != "END" {
+		t.Errorf("expected stop sequence END, got %v", got.StopSequences)
+	}
+	if upstream.Path != "/v1/messages" || upstream.Headers["anthropic-version"] != defaultAnthropicVersion {
+		t.Errorf("unexpected path %q or headers %v", upstream.Path, upstream.Headers)
+	}
+	if exchange.Model != "claude-sonnet" || exchange.Stream {
+		t.Errorf("unexpected exchange %+v", exchange)
+	}
+}
+
+func TestTranslateRequest_AnthropicDefaultMaxTokens(t *testing.T) {
+	upstream, _, err := TranslateRequest([]byte(`{"model":"m","messages":[{"role":"user","content":"Hi"}]}`), &Target{Provider: ProviderAnthropic})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(upstream.Body), `"max_tokens":4096`) {
+		t.Errorf("expected the default max_tokens, got %s", upstream.Body)
+	}
+	if _, _, err := TranslateRequest([]byte(`{"model":"m","messages":[{"role":"system","content":"Hi"}]}`), &Target{Provider: ProviderAnthropic}); err == nil {
+		t.Error("expected an error for a request without user messages")
+	}
+}
+
+func TestTranslateRequest_Bedrock(t *testing.T) {
+	body := strings.Replace(testChatRequest, `"max_tokens": 64`, `"max_tokens": 64, "stream": true`, 1)
+	upstream, exchange, err := TranslateRequest([]byte(body), &Target{Provider: ProviderBedrock, Model: "anthropic.claude-3:0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got bedrockRequest
+	if err := json.Unmarshal(upstream.Body, &got); err != nil {
+		t.Fatalf("invalid translated body: %v", err)
+	}
+	if len(got.System) != 1 || got.System[0].Text != "Be brief." {
+		t.Errorf("unexpected system %+v", got.System)
+	}
+	if len(got.Messages) != 1 || len(got.Messages[0].Content) != 2 || got.Messages[0].Content[1].Text != "What is APK?" {
+		t.Errorf("expected the user messages merged, got %+v", got.Messages)
+	}
+	if got.InferenceConfig == nil || *got.InferenceConfig.MaxTokens != 64 || *got.InferenceConfig.Temperature != 0.2 {
+		t.Errorf("unexpected inference config %+v", got.InferenceConfig)
+	}
+	if upstream.Path != "/model/anthropic.claude-3:0/converse-stream" {
+		t.Errorf("unexpected path %q", upstream.Path)
+	}
+	if !exchange.Stream {
+		t.Error("expected a streamed exchange")
+	}
+}
+
+func TestTranslateRequest_OpenAIAndAzure(t *testing.T) {
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"Hi"}],"n":2}`)
+	upstream, exchange, err := TranslateRequest(body, &Target{Provider: ProviderOpenAI})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(upstream.Body) != string(body) || upstream.Path != "" || exchange.TranslatesResponse() {
+		t.Errorf("expected the request passed through, got %s %q", upstream.Body, upstream.Path)
+	}
+
+	upstream, _, err = TranslateRequest(body, &Target{Provider: ProviderAzureOpenAI, Model: "prod deployment"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if upstream.Path != "/openai/deployments/prod%20deployment/chat/completions?api-version="+defaultAzureAPIVersion {
+		t.Errorf("unexpected path %q", upstream.Path)
+	}
+	if !strings.Contains(string(upstream.Body), `"model":"prod deployment"`) || !strings.Contains(string(upstream.Body), `"n":2`) {
+		t.Errorf("expected the model replaced and other fields kept, got %s", upstream.Body)
+	}
+
+	if _, _, err := TranslateRequest(body, &Target{Provider: "unknown"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestTranslateResponse_Anthropic(t *testing.T) {
+	exchange := &Exchange{Target: Target{Provider: ProviderAnthropic}, Model: "claude-sonnet"}
+	body := `{"id":"msg_1","model":"claude-sonnet-4","content":[{"type":"text","text":"APK is "},{"type":"text","text":"a gateway."}],
+		"stop_reason":"max_tokens","usage":{"input_tokens":10,"output_tokens":5}}`
+	translated, err := TranslateResponse(200, []byte(body), exchange)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got ChatCompletionResponse
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatalf("invalid translated body: %v", err)
+	}
+	if got.ID != "msg_1" || got.Model != "claude-sonnet-4" || got.Object != "chat.completion" || got.Created != 1700000000 {
+		t.Errorf("unexpected completion %+v", got)
+	}
+	if len(got.Choices) != 1 || got.Choices[0].Message.Content != "APK is a gateway." || got.Choices[0].FinishReason != "length" {
+		t.Errorf("unexpected choices %+v", got.Choices)
+	}
+	if got.Usage == nil || *got.Usage != (Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}) {
+		t.Errorf("unexpected usage %+v", got.Usage)
+	}
+}
+
+func TestTranslateResponse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		body     string
+		wantType string
+		wantMsg  string
+	}{
+		{"anthropic", ProviderAnthropic, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, "overloaded_error", "Overloaded"},
+		{"bedrock", ProviderBedrock, `{"message":"Too many requests"}`, "invalid_request_error", "Too many requests"},
+		{"empty", ProviderBedrock, ``, "invalid_request_error", "Too Many Requests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translated, err := TranslateResponse(429, []byte(tt.body), &Exchange{Target: Target{Provider: tt.provider}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got struct {
+				Error struct {
+					Type    string `json:"type"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(translated, &got); err != nil {
+				t.Fatalf("invalid translated body: %v", err)
+			}
+			if got.Error.Type != tt.wantType || got.Error.Message != tt.wantMsg {
+				t.Errorf("unexpected error %+v", got.Error)
+			}
+		})
+	}
+}
+
+func TestTranslateResponse_Bedrock(t *testing.T) {
+	exchange := &Exchange{Target: Target{Provider: ProviderBedrock}, Model: "amazon.nova-pro"}
+	body := `{"output":{"message":{"role":"assistant","content":[{"text":"Hello"}]}},"stopReason":"guardrail_intervened",
+		"usage":{"inputTokens":3,"outputTokens":1,"totalTokens":4}}`
+	translated, err := TranslateResponse(200, []byte(body), exchange)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got ChatCompletionResponse
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatalf("invalid translated body: %v", err)
+	}
+	if got.Model != "amazon.nova-pro" || got.Choices[0].Message.Content != "Hello" || got.Choices[0].FinishReason != "content_filter" {
+		t.Errorf("unexpected completion %+v", got)
+	}
+	if got.Usage.TotalTokens != 4 {
+		t.Errorf("expected 4 total tokens, got %d", got.Usage.TotalTokens)
+	}
+	if translated, err := TranslateResponse(200, []byte(body), &Exchange{Target: Target{Provider: ProviderOpenAI}}); err != nil || translated != nil {
+		t.Errorf("expected OpenAI responses passed through, got %s %v", translated, err)
+	}
+}
+
+// translateStream writes the stream to the translator in chunks of the size and returns the
+// chat completion chunks, along with whether the stream ended with [DONE].
+func translateStream(t *testing.T, translator StreamTranslator, stream []byte, size int) ([]ChatCompletionChunk, bool) {
+	t.Helper()
+	var out []byte
+	for start := 0; start < len(stream); start += size {
+		out = append(out, translator.Write(stream[start:min(start+size, len(stream))])...)
+	}
+	out = append(out, translator.Close()...)
+	var chunks []ChatCompletionChunk
+	done := false
+	for _, event := range strings.Split(strings.TrimSuffix(string(out), "\n\n"), "\n\n") {
+		data, ok := strings.CutPrefix(event, "data: ")
+		if !ok {
+			t.Fatalf("unexpected event %q", event)
+		}
+		if done {
+			t.Fatalf("event %q after [DONE]", data)
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		chunk := ChatCompletionChunk{}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, done
+}
+
+func streamContent(chunks []ChatCompletionChunk) (content, finishReason string, usage *Usage) {
+	for _, chunk := range chunks {
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			if choice.FinishReason != nil {
+				finishReason = *choice.FinishReason
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	return content, finishReason, usage
+}
+
+const testAnthropicStream = "event: message_start\n" +
+	`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4","usage":{"input_tokens":12,"output_tokens":1}}}` + "\n\n" +
+	"event: content_block_start\n" +
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+	"event: ping\ndata: {\"type\": \"ping\"}\n\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}` + "\r\n\r\n" +
+	"event: content_block_delta\n" +
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" world"}}` + "\n\n" +
+	"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n" +
+	"event: message_delta\n" +
+	`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}` + "\n\n" +
+	"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+
+func TestAnthropicStreamTranslator(t *testing.T) {
+	exchange := &Exchange{Target: Target{Provider: ProviderAnthropic}, Model: "claude-sonnet", Stream: true}
+	if NewStreamTranslator("application/json", exchange) != nil {
+		t.Fatal("expected no translator for a buffered response")
+	}
+	for _, size := range []int{1, 7, 64, len(testAnthropicStream)} {
+		chunks, done := translateStream(t, NewStreamTranslator("text/event-stream; charset=utf-8", exchange), []byte(testAnthropicStream), size)
+		if !done {
+			t.Errorf("chunk size %d: expected the stream to end with [DONE]", size)
+		}
+		if chunks[0].ID != "msg_1" || chunks[0].Model != "claude-sonnet-4" || chunks[0].Choices[0].Delta.Role != "assistant" {
+			t.Errorf("chunk size %d: unexpected first chunk %+v", size, chunks[0])
+		}
+		content, finishReason, usage := streamContent(chunks)
+		if content != "Hello world" || finishReason != "stop" {
+			t.Errorf("chunk size %d: unexpected content %q or finish reason %q", size, content, finishReason)
+		}
+		if usage == nil || *usage != (Usage{PromptTokens: 12, CompletionTokens: 2, TotalTokens: 14}) {
+			t.Errorf("chunk size %d: unexpected usage %+v", size, usage)
+		}
+	}
+}
+
+func TestAnthropicStreamTranslator_Error(t *testing.T) {
+	stream := "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"
+	translator := NewStreamTranslator(EventStreamContentType, &Exchange{Target: Target{Provider: ProviderAnthropic}})
+	out := string(translator.Write([]byte(stream))) + string(translator.Close())
+	if !strings.Contains(out, `"type":"overloaded_error"`) || strings.Count(out, "[DONE]") != 1 {
+		t.Errorf("expected the error and a single [DONE], got %q", out)
+	}
+}
+
+// eventStreamFrame encodes an AWS event stream message with string headers.
+func eventStreamFrame(headers map[string]string, payload string) []byte {
+	var encodedHeaders []byte
+	for _, name := range []string{":event-type", ":exception-type", ":message-type", ":content-type"} {
+		value, ok := headers[name]
+		if !ok {
+			continue
+		}
+		encodedHeaders = append(encodedHeaders, byte(len(name)))
+		encodedHeaders = append(encodedHeaders, name...)
+		encodedHeaders = append(encodedHeaders, eventStreamHeaderString)
+		encodedHeaders = binary.BigEndian.AppendUint16(encodedHeaders, uint16(len(value)))
+		encodedHeaders = append(encodedHeaders, value...)
+	}
+	total := eventStreamMinLength + len(encodedHeaders) + len(payload)
+	frame := binary.BigEndian.AppendUint32(nil, uint32(total))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(encodedHeaders)))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+	frame = append(frame, encodedHeaders...)
+	frame = append(frame, payload...)
+	return binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+}
+
+func bedrockEvent(eventType, payload string) []byte {
+	return eventStreamFrame(map[string]string{":event-type": eventType, ":message-type": "event", ":content-type": "application/json"}, payload)
+}
+
+func TestBedrockStreamTranslator(t *testing.T) {
+	var stream []byte
+	stream = append(stream, bedrockEvent("messageStart", `{"role":"assistant"}`)...)
+	stream = append(stream, bedrockEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hello"}}`)...)
+	stream = append(stream, bedrockEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":" world"}}`)...)
+	stream = append(stream, bedrockEvent("contentBlockStop", `{"contentBlockIndex":0}`)...)
+	stream = append(stream, bedrockEvent("messageStop", `{"stopReason":"max_tokens"}`)...)
+	stream = append(stream, bedrockEvent("metadata", `{"usage":{"inputTokens":5,"outputTokens":2,"totalTokens":7},"metrics":{"latencyMs":100}}`)...)
+
+	exchange := &Exchange{Target: Target{Provider: ProviderBedrock}, Model: "amazon.nova-pro", Stream: true}
+	for _, size := range []int{1, 13, 100, len(stream)} {
+		chunks, done := translateStream(t, NewStreamTranslator(BedrockEventStreamContentType, exchange), stream, size)
+		if !done {
+			t.Errorf("chunk size %d: expected the stream to end with [DONE]", size)
+		}
+		content, finishReason, usage := streamContent(chunks)
+		if content != "Hello world" || finishReason != "length" {
+			t.Errorf("chunk size %d: unexpected content %q or finish reason %q", size, content, finishReason)
+		}
+		if usage == nil || usage.TotalTokens != 7 || chunks[0].Model != "amazon.nova-pro" {
+			t.Errorf("chunk size %d: unexpected usage %+v", size, usage)
+		}
+	}
+}
+
+func TestBedrockStreamTranslator_Exception(t *testing.T) {
+	stream := bedrockEvent("messageStart", `{"role":"assistant"}`)
+	stream = append(stream, eventStreamFrame(map[string]string{":exception-type": "throttlingException", ":message-type": "exception"},
+		`{"message":"Too many tokens"}`)...)
+	translator := NewStreamTranslator(BedrockEventStreamContentType, &Exchange{Target: Target{Provider: ProviderBedrock}})
+	out := string(translator.Write(stream)) + string(translator.Close())
+	if !strings.Contains(out, `"type":"throttlingException"`) || !strings.Contains(out, "Too many tokens") || strings.Count(out, "[DONE]") != 1 {
+		t.Errorf("expected the exception and a single [DONE], got %q", out)
+	}
+}
+
+func TestBedrockStreamTranslator_CorruptFrame(t *testing.T) {
+	stream := bedrockEvent("contentBlockDelta", `{"delta":{"text":"Hello"}}`)
+	stream[len(stream)-1] ^= 0xff
+	translator := NewStreamTranslator(BedrockEventStreamContentType, &Exchange{Target: Target{Provider: ProviderBedrock}})
+	out := string(translator.Write(stream))
+	if !strings.Contains(out, "checksum mismatch") || !strings.HasSuffix(out, "data: [DONE]\n\n") {
+		t.Errorf("expected a checksum error ending the stream, got %q", out)
+	}
+	if out := translator.Write(bedrockEvent("messageStop", `{}`)); len(out) != 0 {
+		t.Errorf("expected nothing after the stream ended, got %q", out)
+	}
+}
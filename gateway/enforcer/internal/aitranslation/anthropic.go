@@ -0,0 +1,235 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package aitranslation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultAnthropicVersion   = "2023-06-01"
+	defaultAnthropicPath      = "/v1/messages"
+	defaultAnthropicMaxTokens = 4096
+)
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      anthropicUsage `json:"usage"`
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicProvider translates to the Anthropic messages API. System messages are moved to the
+// system prompt and consecutive messages of the same role are merged, as the roles must alternate.
+type anthropicProvider struct{}
+
+func (anthropicProvider) TranslateRequest(_ []byte, req *ChatCompletionRequest, target *Target) (*UpstreamRequest, error) {
+	maxTokens := target.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+	translated := &anthropicRequest{
+		Model:         req.Model,
+		MaxTokens:     req.maxTokens(maxTokens),
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		StopSequences: req.stopSequences(),
+		Stream:        req.Stream,
+	}
+	var system []string
+	for _, message := range req.Messages {
+		text := message.Text()
+		switch message.Role {
+		case "system", "developer":
+			system = append(system, text)
+			continue
+		case "assistant":
+		default:
+			message.Role = "user"
+		}
+		if last := len(translated.Messages) - 1; last >= 0 && translated.Messages[last].Role == message.Role {
+			translated.Messages[last].Content += "\n\n" + text
+			continue
+		}
+		translated.Messages = append(translated.Messages, anthropicMessage{Role: message.Role, Content: text})
+	}
+	if len(translated.Messages) == 0 {
+		return nil, fmt.Errorf("chat completion request has no user or assistant messages")
+	}
+	translated.System = strings.Join(system, "\n\n")
+	body, err := json.Marshal(translated)
+	if err != nil {
+		return nil, err
+	}
+	version := target.APIVersion
+	if version == "" {
+		version = defaultAnthropicVersion
+	}
+	return &UpstreamRequest{
+		Body:    body,
+		Path:    applyPath(target, defaultAnthropicPath, req.Model),
+		Headers: map[string]string{"anthropic-version": version},
+	}, nil
+}
+
+func (anthropicProvider) TranslateResponse(status int, body []byte, exchange *Exchange) ([]byte, error) {
+	if status >= 400 {
+		errorBody := &anthropicError{}
+		_ = json.Unmarshal(body, errorBody)
+		return openAIError(status, errorBody.Error.Type, errorBody.Error.Message), nil
+	}
+	response := &anthropicResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("invalid Anthropic response: %w", err)
+	}
+	var content strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	model := response.Model
+	if model == "" {
+		model = exchange.Model
+	}
+	return newCompletion(response.ID, model, content.String(), anthropicFinishReason(response.StopReason), &Usage{
+		PromptTokens:     response.Usage.InputTokens,
+		CompletionTokens: response.Usage.OutputTokens,
+		TotalTokens:      response.Usage.InputTokens + response.Usage.OutputTokens,
+	}), nil
+}
+
+func (anthropicProvider) NewStreamTranslator(contentType string, exchange *Exchange) StreamTranslator {
+	if !strings.HasPrefix(strings.ToLower(contentType), EventStreamContentType) {
+		return nil
+	}
+	return &anthropicStreamTranslator{writer: newChunkWriter(exchange.Model)}
+}
+
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// anthropicStreamTranslator translates the Anthropic message events to chat completion chunks.
+type anthropicStreamTranslator struct {
+	reader sseReader
+	writer *chunkWriter
+	usage  Usage
+}
+
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID    string         `json:"id"`
+		Model string         `json:"model"`
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (t *anthropicStreamTranslator) Write(chunk []byte) []byte {
+	return t.translate(nil, t.reader.write(chunk))
+}
+
+func (t *anthropicStreamTranslator) Close() []byte {
+	return t.writer.close(t.translate(nil, t.reader.close()))
+}
+
+func (t *anthropicStreamTranslator) translate(buf []byte, events []sseEvent) []byte {
+	for _, sse := range events {
+		event := &anthropicStreamEvent{}
+		if err := json.Unmarshal([]byte(sse.Data), event); err != nil {
+			continue
+		}
+		switch event.Type {
+		case "message_start":
+			t.writer.id = event.Message.ID
+			if event.Message.Model != "" {
+				t.writer.model = event.Message.Model
+			}
+			t.usage.PromptTokens = event.Message.Usage.InputTokens
+			buf = t.writer.role(buf)
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				buf = t.writer.content(buf, event.Delta.Text)
+			}
+		case "message_delta":
+			t.usage.CompletionTokens = event.Usage.OutputTokens
+			buf = t.writer.finish(buf, anthropicFinishReason(event.Delta.StopReason))
+		case "message_stop":
+			t.usage.TotalTokens = t.usage.PromptTokens + t.usage.CompletionTokens
+			usage := t.usage
+			buf = t.writer.close(t.writer.usage(buf, &usage))
+		case "error":
+			buf = t.writer.close(t.writer.fail(buf, event.Error.Type, event.Error.Message))
+		}
+	}
+	return buf
+}
@@ -0,0 +1,228 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package aitranslation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// BedrockEventStreamContentType is the content type of the Bedrock converse stream.
+const BedrockEventStreamContentType = "application/vnd.amazon.eventstream"
+
+type bedrockRequest struct {
+	Messages        []bedrockMessage        `json:"messages"`
+	System          []bedrockContentBlock   `json:"system,omitempty"`
+	InferenceConfig *bedrockInferenceConfig `json:"inferenceConfig,omitempty"`
+}
+
+type bedrockMessage struct {
+	Role    string                `json:"role"`
+	Content []bedrockContentBlock `json:"content"`
+}
+
+type bedrockContentBlock struct {
+	Text string `json:"text"`
+}
+
+type bedrockInferenceConfig struct {
+	MaxTokens     *int     `json:"maxTokens,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type bedrockUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+type bedrockResponse struct {
+	Output struct {
+		Message bedrockMessage `json:"message"`
+	} `json:"output"`
+	StopReason string       `json:"stopReason"`
+	Usage      bedrockUsage `json:"usage"`
+}
+
+// bedrockProvider translates to the Amazon Bedrock converse API. The model is part of the path,
+// and streamed responses use the AWS event stream encoding.
+type bedrockProvider struct{}
+
+func (bedrockProvider) TranslateRequest(_ []byte, req *ChatCompletionRequest, target *Target) (*UpstreamRequest, error) {
+	translated := &bedrockRequest{}
+	for _, message := range req.Messages {
+		text := message.Text()
+		switch message.Role {
+		case "system", "developer":
+			translated.System = append(translated.System, bedrockContentBlock{Text: text})
+			continue
+		case "assistant":
+		default:
+			message.Role = "user"
+		}
+		if last := len(translated.Messages) - 1; last >= 0 && translated.Messages[last].Role == message.Role {
+			translated.Messages[last].Content = append(translated.Messages[last].Content, bedrockContentBlock{Text: text})
+			continue
+		}
+		translated.Messages = append(translated.Messages, bedrockMessage{
+			Role:    message.Role,
+			Content: []bedrockContentBlock{{Text: text}},
+		})
+	}
+	if len(translated.Messages) == 0 {
+		return nil, fmt.Errorf("chat completion request has no user or assistant messages")
+	}
+	config := &bedrockInferenceConfig{
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		StopSequences: req.stopSequences(),
+	}
+	if maxTokens := req.maxTokens(target.MaxTokens); maxTokens > 0 {
+		config.MaxTokens = &maxTokens
+	}
+	if config.MaxTokens != nil || config.Temperature != nil || config.TopP != nil || len(config.StopSequences) > 0 {
+		translated.InferenceConfig = config
+	}
+	body, err := json.Marshal(translated)
+	if err != nil {
+		return nil, err
+	}
+	path := "/model/{model}/converse"
+	if req.Stream {
+		path = "/model/{model}/converse-stream"
+	}
+	return &UpstreamRequest{
+		Body: body,
+		Path: applyPath(target, path, url.PathEscape(req.Model)),
+	}, nil
+}
+
+func (bedrockProvider) TranslateResponse(status int, body []byte, exchange *Exchange) ([]byte, error) {
+	if status >= 400 {
+		errorBody := &struct {
+			Message string `json:"message"`
+		}{}
+		_ = json.Unmarshal(body, errorBody)
+		return openAIError(status, "", errorBody.Message), nil
+	}
+	response := &bedrockResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("invalid Bedrock response: %w", err)
+	}
+	var content strings.Builder
+	for _, block := range response.Output.Message.Content {
+		content.WriteString(block.Text)
+	}
+	return newCompletion("", exchange.Model, content.String(), bedrockFinishReason(response.StopReason),
+		bedrockOpenAIUsage(response.Usage)), nil
+}
+
+func (bedrockProvider) NewStreamTranslator(contentType string, exchange *Exchange) StreamTranslator {
+	if !strings.HasPrefix(strings.ToLower(contentType), BedrockEventStreamContentType) {
+		return nil
+	}
+	return &bedrockStreamTranslator{writer: newChunkWriter(exchange.Model)}
+}
+
+func bedrockFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "guardrail_intervened", "content_filtered":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+func bedrockOpenAIUsage(usage bedrockUsage) *Usage {
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.InputTokens + usage.OutputTokens
+	}
+	return &Usage{PromptTokens: usage.InputTokens, CompletionTokens: usage.OutputTokens, TotalTokens: total}
+}
+
+// bedrockStreamTranslator translates the Bedrock converse stream events to chat completion chunks.
+type bedrockStreamTranslator struct {
+	reader eventStreamReader
+	writer *chunkWriter
+}
+
+type bedrockStreamEvent struct {
+	Delta struct {
+		Text string `json:"text"`
+	} `json:"delta"`
+	StopReason string        `json:"stopReason"`
+	Usage      *bedrockUsage `json:"usage"`
+	Message    string        `json:"message"`
+}
+
+func (t *bedrockStreamTranslator) Write(chunk []byte) []byte {
+	if t.writer.done {
+		return nil
+	}
+	messages, err := t.reader.write(chunk)
+	buf := t.translate(nil, messages)
+	if err != nil {
+		buf = t.writer.close(t.writer.fail(buf, "", err.Error()))
+	}
+	return buf
+}
+
+func (t *bedrockStreamTranslator) Close() []byte {
+	return t.writer.close(nil)
+}
+
+func (t *bedrockStreamTranslator) translate(buf []byte, messages []eventStreamMessage) []byte {
+	for _, message := range messages {
+		if t.writer.done {
+			return buf
+		}
+		event := &bedrockStreamEvent{}
+		_ = json.Unmarshal(message.Payload, event)
+		if messageType := message.Headers[":message-type"]; messageType == "exception" || messageType == "error" {
+			errorType := message.Headers[":exception-type"]
+			if errorType == "" {
+				errorType = message.Headers[":error-code"]
+			}
+			buf = t.writer.close(t.writer.fail(buf, errorType, event.Message))
+			continue
+		}
+		switch message.Headers[":event-type"] {
+		case "messageStart":
+			buf = t.writer.role(buf)
+		case "contentBlockDelta":
+			buf = t.writer.content(buf, event.Delta.Text)
+		case "messageStop":
+			buf = t.writer.finish(buf, bedrockFinishReason(event.StopReason))
+		case "metadata":
+			if event.Usage != nil {
+				buf = t.writer.usage(buf, bedrockOpenAIUsage(*event.Usage))
+			}
+			buf = t.writer.close(buf)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,130 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package aitranslation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+const (
+	eventStreamPreludeLength = 12
+	eventStreamMinLength     = eventStreamPreludeLength + 4
+	eventStreamMaxLength     = 16 << 20
+
+	eventStreamHeaderString = 7
+)
+
+// eventStreamMessage is a decoded message of the AWS event stream encoding. Only string headers
+// are kept.
+type eventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// eventStreamReader decodes AWS event stream messages across chunks. Each message is framed as
+// the total and header lengths, the prelude CRC, the headers, the payload and the message CRC.
+type eventStreamReader struct {
+	pending []byte
+	err     error
+}
+
+// write consumes the chunk and returns the messages completed by it. Once a malformed message is
+// seen the stream cannot be resynchronised and every later call returns the error.
+func (r *eventStreamReader) write(chunk []byte) ([]eventStreamMessage, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.pending = append(r.pending, chunk...)
+	var messages []eventStreamMessage
+	for len(r.pending) >= eventStreamPreludeLength {
+		totalLength := binary.BigEndian.Uint32(r.pending[0:4])
+		headersLength := binary.BigEndian.Uint32(r.pending[4:8])
+		if crc32.ChecksumIEEE(r.pending[0:8]) != binary.BigEndian.Uint32(r.pending[8:12]) {
+			r.err = fmt.Errorf("event stream prelude checksum mismatch")
+			return messages, r.err
+		}
+		if totalLength < eventStreamMinLength || totalLength > eventStreamMaxLength ||
+			headersLength > totalLength-eventStreamMinLength {
+			r.err = fmt.Errorf("invalid event stream message length %d", totalLength)
+			return messages, r.err
+		}
+		if uint32(len(r.pending)) < totalLength {
+			break
+		}
+		frame := r.pending[:totalLength]
+		if crc32.ChecksumIEEE(frame[:totalLength-4]) != binary.BigEndian.Uint32(frame[totalLength-4:]) {
+			r.err = fmt.Errorf("event stream message checksum mismatch")
+			return messages, r.err
+		}
+		headersEnd := eventStreamPreludeLength + headersLength
+		headers, err := decodeEventStreamHeaders(frame[eventStreamPreludeLength:headersEnd])
+		if err != nil {
+			r.err = err
+			return messages, r.err
+		}
+		payload := make([]byte, totalLength-4-headersEnd)
+		copy(payload, frame[headersEnd:totalLength-4])
+		messages = append(messages, eventStreamMessage{Headers: headers, Payload: payload})
+		r.pending = r.pending[totalLength:]
+	}
+	return messages, nil
+}
+
+func decodeEventStreamHeaders(data []byte) (map[string]string, error) {
+	headers := map[string]string{}
+	for len(data) > 0 {
+		nameLength := int(data[0])
+		if len(data) < 1+nameLength+1 {
+			return nil, fmt.Errorf("truncated event stream header")
+		}
+		name := string(data[1 : 1+nameLength])
+		valueType := data[1+nameLength]
+		data = data[2+nameLength:]
+		var size int
+		switch valueType {
+		case 0, 1: // bool true, bool false
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // integer
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, eventStreamHeaderString: // byte array, string
+			if len(data) < 2 {
+				return nil, fmt.Errorf("truncated event stream header %q", name)
+			}
+			size = 2 + int(binary.BigEndian.Uint16(data))
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d", valueType)
+		}
+		if len(data) < size {
+			return nil, fmt.Errorf("truncated event stream header %q", name)
+		}
+		if valueType == eventStreamHeaderString {
+			headers[name] = string(data[2:size])
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
@@ -0,0 +1,279 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package aitranslation
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+const defaultAzureAPIVersion = "2024-10-21"
+
+// ChatCompletionRequest is the part of an OpenAI chat completion request that is translated.
+// Tools and non-text content parts are not translated.
+type ChatCompletionRequest struct {
+	Model               string          `json:"model"`
+	Messages            []ChatMessage   `json:"messages"`
+	MaxTokens           *int            `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int            `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64        `json:"temperature,omitempty"`
+	TopP                *float64        `json:"top_p,omitempty"`
+	Stop                json.RawMessage `json:"stop,omitempty"`
+	Stream              bool            `json:"stream,omitempty"`
+}
+
+// ChatMessage is a message of an OpenAI chat completion request.
+type ChatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// Text returns the text of the message content, joining the text parts of multi-part content.
+func (m ChatMessage) Text() string {
+	var text string
+	if err := json.Unmarshal(m.Content, &text); err == nil {
+		return text
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// maxTokens returns the completion limit of the request, or the fallback when it has none.
+func (r *ChatCompletionRequest) maxTokens(fallback int) int {
+	if r.MaxCompletionTokens != nil {
+		return *r.MaxCompletionTokens
+	}
+	if r.MaxTokens != nil {
+		return *r.MaxTokens
+	}
+	return fallback
+}
+
+// stopSequences returns the stop sequences of the request, which can be a string or a list.
+func (r *ChatCompletionRequest) stopSequences() []string {
+	if len(r.Stop) == 0 {
+		return nil
+	}
+	var stop string
+	if err := json.Unmarshal(r.Stop, &stop); err == nil {
+		if stop == "" {
+			return nil
+		}
+		return []string{stop}
+	}
+	var stops []string
+	_ = json.Unmarshal(r.Stop, &stops)
+	return stops
+}
+
+// ChatCompletionResponse is an OpenAI chat completion.
+type ChatCompletionResponse struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+	Usage   *Usage       `json:"usage,omitempty"`
+}
+
+// ChatChoice is a choice of an OpenAI chat completion.
+type ChatChoice struct {
+	Index        int             `json:"index"`
+	Message      ResponseMessage `json:"message"`
+	FinishReason string          `json:"finish_reason"`
+}
+
+// ResponseMessage is the message of an OpenAI chat completion choice.
+type ResponseMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Usage is the token usage of an OpenAI chat completion.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionChunk is an event of an OpenAI chat completion stream.
+type ChatCompletionChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object"`
+	Created int64         `json:"created"`
+	Model   string        `json:"model"`
+	Choices []ChunkChoice `json:"choices"`
+	Usage   *Usage        `json:"usage,omitempty"`
+}
+
+// ChunkChoice is a choice of an OpenAI chat completion stream event.
+type ChunkChoice struct {
+	Index        int        `json:"index"`
+	Delta        ChunkDelta `json:"delta"`
+	FinishReason *string    `json:"finish_reason"`
+}
+
+// ChunkDelta is the message delta of an OpenAI chat completion stream event.
+type ChunkDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// newCompletion builds an OpenAI chat completion with a single choice.
+func newCompletion(id, model, content, finishReason string, usage *Usage) []byte {
+	body, _ := json.Marshal(&ChatCompletionResponse{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: now().Unix(),
+		Model:   model,
+		Choices: []ChatChoice{{
+			Message:      ResponseMessage{Role: "assistant", Content: content},
+			FinishReason: finishReason,
+		}},
+		Usage: usage,
+	})
+	return body
+}
+
+// chunkWriter writes the events of an OpenAI chat completion stream.
+type chunkWriter struct {
+	id      string
+	model   string
+	created int64
+	done    bool
+}
+
+func newChunkWriter(model string) *chunkWriter {
+	return &chunkWriter{model: model, created: now().Unix()}
+}
+
+func (w *chunkWriter) write(buf []byte, choices []ChunkChoice, usage *Usage) []byte {
+	if choices == nil {
+		choices = []ChunkChoice{}
+	}
+	data, _ := json.Marshal(&ChatCompletionChunk{
+		ID:      w.id,
+		Object:  "chat.completion.chunk",
+		Created: w.created,
+		Model:   w.model,
+		Choices: choices,
+		Usage:   usage,
+	})
+	buf = append(buf, "data: "...)
+	buf = append(buf, data...)
+	return append(buf, "\n\n"...)
+}
+
+func (w *chunkWriter) role(buf []byte) []byte {
+	return w.write(buf, []ChunkChoice{{Delta: ChunkDelta{Role: "assistant"}}}, nil)
+}
+
+func (w *chunkWriter) content(buf []byte, text string) []byte {
+	if text == "" {
+		return buf
+	}
+	return w.write(buf, []ChunkChoice{{Delta: ChunkDelta{Content: text}}}, nil)
+}
+
+func (w *chunkWriter) finish(buf []byte, reason string) []byte {
+	return w.write(buf, []ChunkChoice{{FinishReason: &reason}}, nil)
+}
+
+func (w *chunkWriter) usage(buf []byte, usage *Usage) []byte {
+	return w.write(buf, nil, usage)
+}
+
+func (w *chunkWriter) fail(buf []byte, errorType, message string) []byte {
+	buf = append(buf, "data: "...)
+	buf = append(buf, openAIError(500, errorType, message)...)
+	return append(buf, "\n\n"...)
+}
+
+// close ends the stream once.
+func (w *chunkWriter) close(buf []byte) []byte {
+	if w.done {
+		return buf
+	}
+	w.done = true
+	return append(buf, "data: [DONE]\n\n"...)
+}
+
+// openAIProvider passes requests and responses through, replacing the model of the target.
+type openAIProvider struct{}
+
+func (openAIProvider) TranslateRequest(body []byte, req *ChatCompletionRequest, target *Target) (*UpstreamRequest, error) {
+	upstream := &UpstreamRequest{Body: body}
+	if target.Path != "" {
+		upstream.Path = applyPath(target, "", req.Model)
+	}
+	if target.Model == "" {
+		return upstream, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	fields["model"] = target.Model
+	translated, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	upstream.Body = translated
+	return upstream, nil
+}
+
+func (openAIProvider) TranslateResponse(int, []byte, *Exchange) ([]byte, error) {
+	return nil, nil
+}
+
+func (openAIProvider) NewStreamTranslator(string, *Exchange) StreamTranslator {
+	return nil
+}
+
+// azureOpenAIProvider addresses the deployment named after the model. The schema is the same as
+// OpenAI, so responses are passed through.
+type azureOpenAIProvider struct {
+	openAIProvider
+}
+
+func (p azureOpenAIProvider) TranslateRequest(body []byte, req *ChatCompletionRequest, target *Target) (*UpstreamRequest, error) {
+	upstream, err := p.openAIProvider.TranslateRequest(body, req, &Target{Model: target.Model})
+	if err != nil {
+		return nil, err
+	}
+	apiVersion := target.APIVersion
+	if apiVersion == "" {
+		apiVersion = defaultAzureAPIVersion
+	}
+	upstream.Path = applyPath(target, "/openai/deployments/{model}/chat/completions?api-version="+url.QueryEscape(apiVersion),
+		url.PathEscape(req.Model))
+	return upstream, nil
+}
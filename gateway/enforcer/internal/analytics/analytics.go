@@ -18,14 +18,17 @@
 package analytics
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
 
 	v3 "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v3"
-	"github.com/wso2/apk/gateway/enforcer/internal/analytics/dto"
 	analytics_publisher "github.com/wso2/apk/gateway/enforcer/internal/analytics/publishers"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics/redaction"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // EventCategory represents the category of an event.
@@ -68,6 +71,14 @@ const (
 	AIProviderNameMetadataKey string = "ai:providername"
 	// AIProviderAPIVersionMetadataKey represents the AI provider API version metadata key.
 	AIProviderAPIVersionMetadataKey string = "ai:providerversion"
+
+	// SemanticCacheHitMetadataKey represents the semantic cache hit metadata key.
+	SemanticCacheHitMetadataKey string = "ai:semanticcachehit"
+	// SemanticCacheSavedTokenCountMetadataKey represents the metadata key of the tokens saved by a semantic cache hit.
+	SemanticCacheSavedTokenCountMetadataKey string = "ai:semanticcachesavedtokencount"
+
+	// RedactionPolicyMetadataKey represents the metadata key of the redaction policy of the API, as JSON.
+	RedactionPolicyMetadataKey string = "analytics:redactionpolicy"
 )
 
 // Analytics represents Choreo analytics.
@@ -75,37 +86,73 @@ type Analytics struct {
 	// cfg represents the server configuration.
 	cfg *config.Server
 	// publishers represents the publishers.
-	publishers []analytics_publisher.Publisher
+	publishers []*analytics_publisher.QueuedPublisher
+	// hasher hashes the fields redacted with the hash action.
+	hasher redaction.Hasher
 }
 
 // NewAnalytics creates a new instance of Analytics.
 func NewAnalytics(cfg *config.Server) *Analytics {
-	publishers := make([]analytics_publisher.Publisher, 0)
+	keyRing := redaction.NewKeyRing(cfg.AnalyticsHMACKeyFile)
+	if cfg.AnalyticsHMACKeyFile != "" {
+		if _, err := keyRing.Hash(""); err != nil {
+			cfg.Logger.Error(err, "Hashed analytics fields will be dropped until the HMAC key can be read")
+		}
+	}
+	publishers := make([]*analytics_publisher.QueuedPublisher, 0)
+	for _, instance := range publisherConfigs(cfg) {
+		if !instance.IsEnabled() {
+			continue
+		}
+		publisher, err := analytics_publisher.New(cfg, &instance, keyRing)
+		if err != nil {
+			cfg.Logger.Error(err, fmt.Sprintf("Error while creating analytics publisher %s", instance.Name))
+			continue
+		}
+		publishers = append(publishers, publisher)
+		cfg.Logger.Info(fmt.Sprintf("Analytics publisher %s of type %s added", instance.Name, instance.Type))
+	}
+
+	if len(publishers) == 0 {
+		cfg.Logger.Sugar().Debug("No analytics publishers found. Analytics will not be published.")
+	}
+	return &Analytics{
+		cfg:        cfg,
+		publishers: publishers,
+		hasher:     keyRing,
+	}
+}
+
+// publisherConfigs returns the publishers enabled through the legacy environment variables,
+// followed by the ones of the publishers configuration file.
+func publisherConfigs(cfg *config.Server) []analytics_publisher.Config {
+	var configs []analytics_publisher.Config
 	if cfg.ELKAnalyticsEnabled {
-		publishers = append(publishers, analytics_publisher.NewELK(cfg, cfg.ELKAnalyticsLogLevel))
-		cfg.Logger.Sugar().Debug(fmt.Sprintf("ELK publisher added with log level: %s", cfg.ELKAnalyticsLogLevel))
+		configs = append(configs, analytics_publisher.Config{Name: ELKAnalyticsPublisher, Type: analytics_publisher.TypeELK})
 	}
 	if cfg.MoesifAnalyticsEnabled {
-		publisher := analytics_publisher.NewMoesif(cfg)
-		publishers = append(publishers, publisher)
-		cfg.Logger.Info("Moesif publisher added")
+		configs = append(configs, analytics_publisher.Config{Name: MoesifAnalyticsPublisher, Type: analytics_publisher.TypeMoesif})
 	}
 	if cfg.ChoreoAnalyticsEnabled {
-		publisher := analytics_publisher.NewChoreo(cfg, cfg.ChoreoAnalyticsAuthURL, cfg.ChoreoAnalyticsAuthToken)
-		if publisher == nil {
-			cfg.Logger.Error(nil, "Error while creating Choreo publisher")
+		configs = append(configs, analytics_publisher.Config{Name: DefaultAnalyticsPublisher, Type: analytics_publisher.TypeChoreo})
+	}
+	if cfg.AnalyticsPublishersConfigFile != "" {
+		fileConfigs, err := analytics_publisher.LoadConfig(cfg.AnalyticsPublishersConfigFile)
+		if err != nil {
+			cfg.Logger.Error(err, "Error while loading the analytics publishers configuration")
 		} else {
-			publishers = append(publishers, publisher)
-			cfg.Logger.Info("Choreo publisher added")
+			configs = append(configs, fileConfigs...)
 		}
-	}	
-	
-	if len(publishers) == 0 {
-		cfg.Logger.Sugar().Debug("No analytics publishers found. Analytics will not be published.")
 	}
-	return &Analytics{
-		cfg:         cfg,
-		publishers:  publishers,
+	return configs
+}
+
+// Close publishes the queued events and releases the publishers.
+func (c *Analytics) Close() {
+	for _, publisher := range c.publishers {
+		if err := publisher.Close(); err != nil {
+			c.cfg.Logger.Error(err, fmt.Sprintf("Error while closing analytics publisher %s", publisher.Name()))
+		}
 	}
 }
 
@@ -147,7 +194,16 @@ func (c *Analytics) prepareAnalyticEvent(logEntry *v3.HTTPAccessLogEntry) *dto.E
 			if sv.Fields != nil {
 				c.cfg.Logger.Sugar().Debug(fmt.Sprintf("Filter metadata: %+v", sv))
 				for key, value := range sv.Fields {
-					if value != nil {
+					if value == nil {
+						continue
+					}
+					switch kind := value.GetKind().(type) {
+					case *structpb.Value_NumberValue:
+						// Token counts are published as numbers.
+						keyValuePairsFromMetadata[key] = strconv.FormatFloat(kind.NumberValue, 'f', -1, 64)
+					case *structpb.Value_BoolValue:
+						keyValuePairsFromMetadata[key] = strconv.FormatBool(kind.BoolValue)
+					default:
 						keyValuePairsFromMetadata[key] = value.GetStringValue()
 					}
 				}
@@ -266,6 +322,13 @@ func (c *Analytics) prepareAnalyticEvent(logEntry *v3.HTTPAccessLogEntry) *dto.E
 		c.cfg.Logger.Error(err, "Error converting TotalTokenCountMetadataKey to integer")
 	}
 
+	if keyValuePairsFromMetadata[SemanticCacheHitMetadataKey] == "true" {
+		aiTokenUsage.CacheHit = true
+		if savedToken, err := strconv.Atoi(keyValuePairsFromMetadata[SemanticCacheSavedTokenCountMetadataKey]); err == nil {
+			aiTokenUsage.SavedToken = savedToken
+		}
+	}
+
 	if aiMetadata.VendorName != "" {
 		event.Properties["isEgress"] = true
 		event.Properties["subtype"] = "AIAPI"
@@ -287,7 +350,21 @@ func (c *Analytics) prepareAnalyticEvent(logEntry *v3.HTTPAccessLogEntry) *dto.E
 	hour := time.Now().Hour()
 	aiTokenUsage.Hour = &hour
 	event.Properties["aiTokenUsage"] = aiTokenUsage
-	return event
+	return c.redact(event, keyValuePairsFromMetadata[RedactionPolicyMetadataKey])
+}
+
+// redact applies the redaction policy of the API, set by the Analytics mediation, to the event
+// before it reaches the publishers.
+func (c *Analytics) redact(event *dto.Event, rawPolicy string) *dto.Event {
+	if rawPolicy == "" {
+		return event
+	}
+	policy := &redaction.Policy{}
+	if err := json.Unmarshal([]byte(rawPolicy), policy); err != nil {
+		c.cfg.Logger.Error(err, "Error parsing the analytics redaction policy, dropping the redactable fields")
+		policy = redaction.DropAll()
+	}
+	return policy.Apply(event, c.hasher)
 }
 
 func (c *Analytics) getAnonymousApp() *dto.Application {
@@ -0,0 +1,248 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics/redaction"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultQueueSize     = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+
+	// CategorySuccess matches the events of requests served by the upstream.
+	CategorySuccess = "SUCCESS"
+	// CategoryFault matches the events of requests that failed in the gateway or reaching the upstream.
+	CategoryFault = "FAULT"
+)
+
+// FileConfig is the analytics publishers configuration file.
+type FileConfig struct {
+	Publishers []Config `yaml:"publishers"`
+}
+
+// Config configures an analytics publisher instance.
+type Config struct {
+	// Name identifies the instance in the logs. It defaults to the type.
+	Name string `yaml:"name"`
+	// Type is the registered publisher type.
+	Type string `yaml:"type"`
+	// Enabled defaults to true.
+	Enabled *bool `yaml:"enabled"`
+	// QueueSize is the number of events buffered for the publisher. Events are dropped once it is full.
+	QueueSize int `yaml:"queueSize"`
+	// BatchSize is the maximum number of events handed to a batching publisher at once.
+	BatchSize int `yaml:"batchSize"`
+	// FlushIntervalMs is how long a batching publisher waits for a batch to fill.
+	FlushIntervalMs int `yaml:"flushIntervalMs"`
+	// Filter selects the events sent to the publisher.
+	Filter Filter `yaml:"filter"`
+	// Redaction is applied to the events sent to the publisher, after the policy of the API.
+	Redaction redaction.Policy `yaml:"redaction"`
+	// Properties are the settings of the publisher type. Values may reference environment
+	// variables as ${NAME}, so secrets can be mounted separately.
+	Properties map[string]string `yaml:"properties"`
+}
+
+// Filter selects the events sent to a publisher. An empty list matches every event.
+type Filter struct {
+	Categories    []string `yaml:"categories"`
+	APINames      []string `yaml:"apiNames"`
+	APITypes      []string `yaml:"apiTypes"`
+	Organizations []string `yaml:"organizations"`
+	Environments  []string `yaml:"environments"`
+}
+
+// LoadConfig reads the analytics publishers configuration file.
+func LoadConfig(path string) ([]Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the analytics publishers configuration: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates the analytics publishers configuration.
+func ParseConfig(data []byte) ([]Config, error) {
+	var file FileConfig
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing the analytics publishers configuration: %w", err)
+	}
+	names := map[string]struct{}{}
+	publishers := make([]Config, 0, len(file.Publishers))
+	for i := range file.Publishers {
+		instance := file.Publishers[i]
+		if instance.Type == "" {
+			return nil, fmt.Errorf("analytics publisher %d has no type", i)
+		}
+		if instance.Name == "" {
+			instance.Name = instance.Type
+		}
+		if _, exists := names[instance.Name]; exists {
+			return nil, fmt.Errorf("analytics publisher %s is configured more than once", instance.Name)
+		}
+		names[instance.Name] = struct{}{}
+		if _, ok := GetFactory(instance.Type); !ok {
+			return nil, fmt.Errorf("analytics publisher %s has unknown type %q", instance.Name, instance.Type)
+		}
+		for _, category := range instance.Filter.Categories {
+			if !strings.EqualFold(category, CategorySuccess) && !strings.EqualFold(category, CategoryFault) {
+				return nil, fmt.Errorf("analytics publisher %s filters on unknown category %q", instance.Name, category)
+			}
+		}
+		if err := instance.Redaction.Validate(); err != nil {
+			return nil, fmt.Errorf("analytics publisher %s has an invalid redaction policy: %w", instance.Name, err)
+		}
+		for key, value := range instance.Properties {
+			instance.Properties[key] = os.ExpandEnv(value)
+		}
+		publishers = append(publishers, instance)
+	}
+	return publishers, nil
+}
+
+// IsEnabled reports whether the instance is enabled.
+func (c *Config) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// Property returns the value of the property, or the default value if it is not set.
+func (c *Config) Property(key string, defaultValue string) string {
+	if value, ok := c.Properties[key]; ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// IntProperty returns the property parsed as an integer, or the default value if it is not set.
+func (c *Config) IntProperty(key string, defaultValue int) (int, error) {
+	value := c.Property(key, "")
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("property %s of analytics publisher %s must be an integer: %w", key, c.Name, err)
+	}
+	return parsed, nil
+}
+
+// BoolProperty returns the property parsed as a boolean, or the default value if it is not set.
+func (c *Config) BoolProperty(key string, defaultValue bool) (bool, error) {
+	value := c.Property(key, "")
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("property %s of analytics publisher %s must be a boolean: %w", key, c.Name, err)
+	}
+	return parsed, nil
+}
+
+// MapProperty returns the property parsed as comma separated key=value pairs, as used for headers.
+func (c *Config) MapProperty(key string) map[string]string {
+	values := map[string]string{}
+	for _, pair := range strings.Split(c.Property(key, ""), ",") {
+		k, v, found := strings.Cut(pair, "=")
+		if !found || strings.TrimSpace(k) == "" {
+			continue
+		}
+		values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return values
+}
+
+// ListProperty returns the property parsed as a comma separated list.
+func (c *Config) ListProperty(key string) []string {
+	var values []string
+	for _, value := range strings.Split(c.Property(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (c *Config) queueSize() int {
+	if c.QueueSize > 0 {
+		return c.QueueSize
+	}
+	return defaultQueueSize
+}
+
+func (c *Config) batchSize() int {
+	if c.BatchSize > 0 {
+		return c.BatchSize
+	}
+	return defaultBatchSize
+}
+
+func (c *Config) flushInterval() time.Duration {
+	if c.FlushIntervalMs > 0 {
+		return time.Duration(c.FlushIntervalMs) * time.Millisecond
+	}
+	return defaultFlushInterval
+}
+
+// Matches reports whether the event is selected by the filter.
+func (f *Filter) Matches(event *dto.Event) bool {
+	category := CategorySuccess
+	if isFault(event) {
+		category = CategoryFault
+	}
+	if !matchesAny(f.Categories, category) {
+		return false
+	}
+	var apiName, apiType, organization, environment string
+	if event.API != nil {
+		apiName = event.API.APIName
+		apiType = event.API.APIType
+		organization = event.API.OrganizationID
+		environment = event.API.EnvironmentID
+	}
+	return matchesAny(f.APINames, apiName) && matchesAny(f.APITypes, apiType) &&
+		matchesAny(f.Organizations, organization) && matchesAny(f.Environments, environment)
+}
+
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFault reports whether the response was not sent by the upstream, the way the ELK and Choreo
+// publishers tell faults apart.
+func isFault(event *dto.Event) bool {
+	return event.Target != nil && event.Target.ResponseCodeDetail != "via_upstream"
+}
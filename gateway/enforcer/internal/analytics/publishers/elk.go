@@ -3,9 +3,9 @@ package publishers
 import (
 	"fmt"
 
-	"github.com/wso2/apk/gateway/enforcer/internal/analytics/dto"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/util"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
 )
 
 const (
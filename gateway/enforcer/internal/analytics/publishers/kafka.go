@@ -0,0 +1,173 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+const defaultKafkaWriteTimeout = 10 * time.Second
+
+// messageWriter is the part of kafka.Writer used by the Kafka publisher.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Kafka publishes the events as JSON records to a Kafka topic, keyed by the API id so that the
+// events of an API keep their order.
+//
+// Properties: brokers (comma separated, required), topic (required), requiredAcks (none, one or
+// all), compression (gzip, snappy, lz4 or zstd), writeTimeoutMs, tls, tlsCaFile, saslUsername and
+// saslPassword.
+type Kafka struct {
+	cfg    *config.Server
+	name   string
+	writer messageWriter
+	// timeout bounds a batch write, retries included.
+	timeout time.Duration
+}
+
+// NewKafka creates a new Kafka publisher.
+func NewKafka(cfg *config.Server, instance *Config) (*Kafka, error) {
+	brokers := instance.ListProperty("brokers")
+	if len(brokers) == 0 {
+		return nil, errors.New("property brokers is required")
+	}
+	topic := instance.Property("topic", "")
+	if topic == "" {
+		return nil, errors.New("property topic is required")
+	}
+	timeoutMs, err := instance.IntProperty("writeTimeoutMs", int(defaultKafkaWriteTimeout/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		BatchSize:    instance.batchSize(),
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: time.Duration(timeoutMs) * time.Millisecond,
+		RequiredAcks: kafka.RequireOne,
+	}
+	if acks := instance.Property("requiredAcks", ""); acks != "" {
+		if err := writer.RequiredAcks.UnmarshalText([]byte(acks)); err != nil {
+			return nil, err
+		}
+	}
+	if compression := instance.Property("compression", ""); compression != "" {
+		if err := writer.Compression.UnmarshalText([]byte(compression)); err != nil {
+			return nil, err
+		}
+	}
+	transport, err := newKafkaTransport(instance)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		writer.Transport = transport
+	}
+	return newKafka(cfg, instance.Name, writer, time.Duration(timeoutMs)*time.Millisecond), nil
+}
+
+func newKafka(cfg *config.Server, name string, writer messageWriter, timeout time.Duration) *Kafka {
+	return &Kafka{
+		cfg:     cfg,
+		name:    name,
+		writer:  writer,
+		timeout: timeout,
+	}
+}
+
+func newKafkaTransport(instance *Config) (*kafka.Transport, error) {
+	tlsEnabled, err := instance.BoolProperty("tls", false)
+	if err != nil {
+		return nil, err
+	}
+	username := instance.Property("saslUsername", "")
+	if !tlsEnabled && username == "" {
+		return nil, nil
+	}
+	transport := &kafka.Transport{}
+	if tlsEnabled {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+		if caFile := instance.Property("tlsCaFile", ""); caFile != "" {
+			caCert, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("error reading the Kafka CA certificate: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caCert) {
+				return nil, fmt.Errorf("no certificate found in %s", caFile)
+			}
+			transport.TLS.RootCAs = pool
+		}
+	}
+	if username != "" {
+		transport.SASL = plain.Mechanism{Username: username, Password: instance.Property("saslPassword", "")}
+	}
+	return transport, nil
+}
+
+// Publish publishes a single event to Kafka.
+func (k *Kafka) Publish(event *dto.Event) {
+	if err := k.PublishBatch([]*dto.Event{event}); err != nil {
+		k.cfg.Logger.Error(err, fmt.Sprintf("Error publishing event to Kafka with publisher %s", k.name))
+	}
+}
+
+// PublishBatch publishes the events to Kafka.
+func (k *Kafka) PublishBatch(events []*dto.Event) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			k.cfg.Logger.Error(err, "Error marshalling analytics event")
+			continue
+		}
+		var key []byte
+		if event.API != nil {
+			key = []byte(event.API.APIID)
+		}
+		messages = append(messages, kafka.Message{Key: key, Value: value})
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), k.timeout)
+	defer cancel()
+	return k.writer.WriteMessages(ctx, messages...)
+}
+
+// Close flushes and closes the Kafka writer.
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,115 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+// localBroker stands in for a Kafka cluster, keeping the written records in memory.
+type localBroker struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	err      error
+	closed   bool
+}
+
+func (b *localBroker) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("expected the write to be bounded by a deadline")
+	}
+	b.messages = append(b.messages, msgs...)
+	return nil
+}
+
+func (b *localBroker) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestNewKafka(t *testing.T) {
+	valid := map[string]string{"brokers": "localhost:9092, localhost:9093", "topic": "analytics", "requiredAcks": "all", "compression": "snappy"}
+	publisher, err := NewKafka(newTestConfig(), &Config{Name: "kafka", Properties: valid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := publisher.writer.(*kafka.Writer)
+	if writer.Topic != "analytics" || writer.RequiredAcks != kafka.RequireAll || writer.Compression != kafka.Snappy ||
+		writer.Addr.String() != "localhost:9092,localhost:9093" {
+		t.Errorf("unexpected writer %+v", writer)
+	}
+	_ = publisher.Close()
+
+	invalid := []map[string]string{
+		{"topic": "analytics"},
+		{"brokers": "localhost:9092"},
+		{"brokers": "localhost:9092", "topic": "analytics", "requiredAcks": "some"},
+		{"brokers": "localhost:9092", "topic": "analytics", "compression": "rar"},
+		{"brokers": "localhost:9092", "topic": "analytics", "tls": "true", "tlsCaFile": "/nonexistent/ca.crt"},
+	}
+	for _, properties := range invalid {
+		if _, err := NewKafka(newTestConfig(), &Config{Name: "kafka", Properties: properties}); err == nil {
+			t.Errorf("expected an error for %v", properties)
+		}
+	}
+}
+
+func TestKafka_PublishBatch(t *testing.T) {
+	broker := &localBroker{}
+	publisher := newKafka(newTestConfig(), "kafka", broker, time.Second)
+	queued := NewQueuedPublisher(&newTestConfig().Logger, &Config{Name: "kafka", BatchSize: 2}, publisher, nil)
+	queued.Publish(newTestEvent("orders", "via_upstream"))
+	queued.Publish(newTestEvent("payments", "via_upstream"))
+	queued.Publish(newTestEvent("orders", "via_upstream"))
+	if err := queued.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(broker.messages) != 3 || !broker.closed {
+		t.Fatalf("expected 3 records written before the writer was closed, got %d", len(broker.messages))
+	}
+	message := broker.messages[1]
+	if string(message.Key) != "payments-id" {
+		t.Errorf("expected the record keyed by the API id, got %s", message.Key)
+	}
+	var event dto.Event
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.API.APIName != "payments" || event.ProxyResponseCode != 200 {
+		t.Errorf("unexpected record value %s", message.Value)
+	}
+
+	broker.err = errors.New("leader not available")
+	if err := publisher.PublishBatch([]*dto.Event{newTestEvent("orders", "via_upstream")}); err == nil {
+		t.Error("expected the write error to be returned")
+	}
+}
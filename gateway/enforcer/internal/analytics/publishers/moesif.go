@@ -7,8 +7,8 @@ import (
 
 	"github.com/moesif/moesifapi-go"
 	"github.com/moesif/moesifapi-go/models"
-	"github.com/wso2/apk/gateway/enforcer/internal/analytics/dto"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
 )
 
 const (
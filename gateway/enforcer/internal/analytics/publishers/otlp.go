@@ -0,0 +1,211 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+const (
+	otlpInstrumentationName = "github.com/wso2/apk/gateway/enforcer/analytics"
+	otlpEventName           = "apk.analytics.event"
+
+	// OTLPProtocolGRPC exports the log records with OTLP over gRPC.
+	OTLPProtocolGRPC = "grpc"
+	// OTLPProtocolHTTP exports the log records with OTLP over HTTP.
+	OTLPProtocolHTTP = "http"
+)
+
+// Attribute keys recorded on the analytics log records.
+const (
+	otlpAttributeCategory       = "apk.event.category"
+	otlpAttributeAPIID          = "apk.api.id"
+	otlpAttributeAPIName        = "apk.api.name"
+	otlpAttributeAPIType        = "apk.api.type"
+	otlpAttributeOrganizationID = "apk.organization.id"
+	otlpAttributeEnvironmentID  = "apk.environment.id"
+	otlpAttributeStatusCode     = "http.response.status_code"
+)
+
+// OTLP publishes the events as OpenTelemetry log records. The record body holds the event, and
+// the fields used to filter the events are copied to the attributes.
+//
+// Properties: endpoint (required), protocol (grpc or http), insecure, headers (comma separated
+// key=value pairs) and serviceName.
+type OTLP struct {
+	cfg      *config.Server
+	name     string
+	provider *sdklog.LoggerProvider
+	logger   log.Logger
+}
+
+// NewOTLP creates a new OTLP log publisher.
+func NewOTLP(cfg *config.Server, instance *Config) (*OTLP, error) {
+	exporter, err := newOTLPExporter(instance)
+	if err != nil {
+		return nil, err
+	}
+	processor := sdklog.NewBatchProcessor(exporter,
+		sdklog.WithMaxQueueSize(instance.queueSize()),
+		sdklog.WithExportMaxBatchSize(instance.batchSize()),
+		sdklog.WithExportInterval(instance.flushInterval()))
+	return newOTLP(cfg, instance, processor)
+}
+
+func newOTLP(cfg *config.Server, instance *Config, processor sdklog.Processor) (*OTLP, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(instance.Property("serviceName", cfg.Tracing.ServiceName)),
+		semconv.ServiceInstanceID(cfg.InstanceIdentifier),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(processor), sdklog.WithResource(res))
+	return &OTLP{
+		cfg:      cfg,
+		name:     instance.Name,
+		provider: provider,
+		logger:   provider.Logger(otlpInstrumentationName),
+	}, nil
+}
+
+func newOTLPExporter(instance *Config) (sdklog.Exporter, error) {
+	endpoint := instance.Property("endpoint", "")
+	if endpoint == "" {
+		return nil, fmt.Errorf("property endpoint is required")
+	}
+	insecure, err := instance.BoolProperty("insecure", false)
+	if err != nil {
+		return nil, err
+	}
+	headers := instance.MapProperty("headers")
+	ctx := context.Background()
+	switch protocol := strings.ToLower(instance.Property("protocol", OTLPProtocolGRPC)); protocol {
+	case OTLPProtocolGRPC:
+		opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(endpoint), otlploggrpc.WithHeaders(headers)}
+		if insecure {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		}
+		return otlploggrpc.New(ctx, opts...)
+	case OTLPProtocolHTTP:
+		opts := []otlploghttp.Option{otlploghttp.WithEndpoint(endpoint), otlploghttp.WithHeaders(headers)}
+		if insecure {
+			opts = append(opts, otlploghttp.WithInsecure())
+		}
+		return otlploghttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q, expected %s or %s", protocol, OTLPProtocolGRPC, OTLPProtocolHTTP)
+	}
+}
+
+// Publish emits the event as a log record.
+func (o *OTLP) Publish(event *dto.Event) {
+	body, err := eventLogValue(event)
+	if err != nil {
+		o.cfg.Logger.Error(err, "Error marshalling analytics event")
+		return
+	}
+	var record log.Record
+	record.SetEventName(otlpEventName)
+	record.SetTimestamp(event.RequestTimestamp)
+	record.SetObservedTimestamp(time.Now())
+	category := CategorySuccess
+	record.SetSeverity(log.SeverityInfo)
+	if isFault(event) {
+		category = CategoryFault
+		record.SetSeverity(log.SeverityWarn)
+	}
+	record.SetSeverityText(category)
+	record.SetBody(body)
+	record.AddAttributes(
+		log.String(otlpAttributeCategory, category),
+		log.Int(otlpAttributeStatusCode, event.ProxyResponseCode),
+	)
+	if event.API != nil {
+		record.AddAttributes(
+			log.String(otlpAttributeAPIID, event.API.APIID),
+			log.String(otlpAttributeAPIName, event.API.APIName),
+			log.String(otlpAttributeAPIType, event.API.APIType),
+			log.String(otlpAttributeOrganizationID, event.API.OrganizationID),
+			log.String(otlpAttributeEnvironmentID, event.API.EnvironmentID),
+		)
+	}
+	o.logger.Emit(context.Background(), record)
+}
+
+// Close flushes the pending log records and stops the exporter.
+func (o *OTLP) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return o.provider.Shutdown(ctx)
+}
+
+// eventLogValue converts the event to a log value through its JSON form, so the record body has
+// the same fields as the events of the other publishers.
+func eventLogValue(event *dto.Event) (log.Value, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return log.Value{}, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return log.Value{}, err
+	}
+	return toLogValue(decoded), nil
+}
+
+func toLogValue(v interface{}) log.Value {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		kvs := make([]log.KeyValue, 0, len(value))
+		for key, item := range value {
+			kvs = append(kvs, log.KeyValue{Key: key, Value: toLogValue(item)})
+		}
+		return log.MapValue(kvs...)
+	case []interface{}:
+		items := make([]log.Value, 0, len(value))
+		for _, item := range value {
+			items = append(items, toLogValue(item))
+		}
+		return log.SliceValue(items...)
+	case string:
+		return log.StringValue(value)
+	case bool:
+		return log.BoolValue(value)
+	case float64:
+		if value == float64(int64(value)) {
+			return log.Int64Value(int64(value))
+		}
+		return log.Float64Value(value)
+	default:
+		return log.Value{}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// recordingExporter keeps the exported log records in memory.
+type recordingExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *recordingExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, record := range records {
+		e.records = append(e.records, record.Clone())
+	}
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(context.Context) error { return nil }
+
+func (e *recordingExporter) ForceFlush(context.Context) error { return nil }
+
+func TestNewOTLP(t *testing.T) {
+	for _, protocol := range []string{OTLPProtocolGRPC, OTLPProtocolHTTP} {
+		publisher, err := NewOTLP(newTestConfig(), &Config{Name: "otlp", Properties: map[string]string{
+			"endpoint": "localhost:4317", "protocol": protocol, "insecure": "true", "headers": "x-api-key=secret"}})
+		if err != nil {
+			t.Fatalf("%s: %v", protocol, err)
+		}
+		_ = publisher.provider.Shutdown(context.Background())
+	}
+	if _, err := NewOTLP(newTestConfig(), &Config{Name: "otlp", Properties: map[string]string{"endpoint": "localhost:4317", "protocol": "udp"}}); err == nil {
+		t.Error("expected an unsupported protocol to fail")
+	}
+	if _, err := NewOTLP(newTestConfig(), &Config{Name: "otlp"}); err == nil {
+		t.Error("expected a missing endpoint to fail")
+	}
+}
+
+func TestOTLP_Publish(t *testing.T) {
+	exporter := &recordingExporter{}
+	publisher, err := newOTLP(newTestConfig(), &Config{Name: "otlp"}, sdklog.NewSimpleProcessor(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher.Publish(newTestEvent("orders", "upstream_reset_before_response_started"))
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exporter.records) != 1 {
+		t.Fatalf("expected 1 log record, got %d", len(exporter.records))
+	}
+	record := exporter.records[0]
+	if record.EventName() != otlpEventName || record.Severity() != log.SeverityWarn || record.SeverityText() != CategoryFault {
+		t.Errorf("expected a fault event record, got %s %v %s", record.EventName(), record.Severity(), record.SeverityText())
+	}
+	if !record.Timestamp().Equal(newTestEvent("orders", "").RequestTimestamp) {
+		t.Errorf("expected the request timestamp, got %v", record.Timestamp())
+	}
+	attributes := map[string]log.Value{}
+	record.WalkAttributes(func(kv log.KeyValue) bool {
+		attributes[kv.Key] = kv.Value
+		return true
+	})
+	if attributes[otlpAttributeAPIName].AsString() != "orders" || attributes[otlpAttributeOrganizationID].AsString() != "org1" ||
+		attributes[otlpAttributeStatusCode].AsInt64() != 200 {
+		t.Errorf("unexpected attributes %v", attributes)
+	}
+	body := map[string]log.Value{}
+	for _, kv := range record.Body().AsMap() {
+		body[kv.Key] = kv.Value
+	}
+	if body["proxyResponseCode"].AsInt64() != 200 {
+		t.Errorf("expected the event in the record body, got %v", record.Body())
+	}
+	var apiName string
+	for _, kv := range body["api"].AsMap() {
+		if kv.Key == "apiName" {
+			apiName = kv.Value.AsString()
+		}
+	}
+	if apiName != "orders" {
+		t.Errorf("expected the nested API in the record body, got %v", body["api"])
+	}
+}
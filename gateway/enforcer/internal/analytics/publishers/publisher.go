@@ -1,8 +1,6 @@
 package publishers
 
-import "github.com/wso2/apk/gateway/enforcer/internal/analytics/dto"
+import pubanalytics "github.com/wso2/apk/gateway/enforcer/pkg/analytics"
 
 // Publisher represents an analytics publisher.
-type Publisher interface {
-	Publish(event *dto.Event)
-}
+type Publisher = pubanalytics.Publisher
@@ -0,0 +1,281 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics/redaction"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	pubanalytics "github.com/wso2/apk/gateway/enforcer/pkg/analytics"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+func newTestConfig() *config.Server {
+	return &config.Server{Logger: logging.DefaultLogger(egv1a1.LogLevelError)}
+}
+
+func newTestEvent(apiName string, responseCodeDetail string) *dto.Event {
+	event := &dto.Event{
+		API:               &dto.ExtendedAPI{OrganizationID: "org1", EnvironmentID: "prod"},
+		Target:            &dto.Target{ResponseCodeDetail: responseCodeDetail},
+		ProxyResponseCode: 200,
+		RequestTimestamp:  time.Unix(1700000000, 0).UTC(),
+	}
+	event.API.APIID = apiName + "-id"
+	event.API.APIName = apiName
+	event.API.APIType = "REST"
+	return event
+}
+
+// recordingPublisher records the events it publishes, one by one or in batches.
+type recordingPublisher struct {
+	mu      sync.Mutex
+	events  []*dto.Event
+	batches [][]*dto.Event
+	block   chan struct{}
+	closed  bool
+}
+
+func (r *recordingPublisher) Publish(event *dto.Event) {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingPublisher) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+type batchRecordingPublisher struct {
+	recordingPublisher
+}
+
+func (r *batchRecordingPublisher) PublishBatch(events []*dto.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, events)
+	return nil
+}
+
+func TestParseConfig(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_TOKEN", "secret")
+	configs, err := ParseConfig([]byte(`
+publishers:
+  - type: kafka
+    queueSize: 50
+    filter:
+      categories: [FAULT]
+    properties:
+      brokers: localhost:9092
+      topic: analytics
+  - name: audit
+    type: webhook
+    enabled: false
+    properties:
+      url: http://localhost/events
+      headers: Authorization=Bearer ${TEST_WEBHOOK_TOKEN}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("expected 2 publishers, got %d", len(configs))
+	}
+	if configs[0].Name != TypeKafka || !configs[0].IsEnabled() || configs[0].queueSize() != 50 || configs[0].batchSize() != defaultBatchSize {
+		t.Errorf("unexpected kafka publisher %+v", configs[0])
+	}
+	if configs[1].IsEnabled() {
+		t.Errorf("expected the audit publisher to be disabled")
+	}
+	if headers := configs[1].MapProperty("headers"); headers["Authorization"] != "Bearer secret" {
+		t.Errorf("expected the environment variable expanded in the headers, got %v", headers)
+	}
+
+	invalid := map[string]string{
+		"unknown type":         "publishers: [{type: carrier-pigeon}]",
+		"missing type":         "publishers: [{name: audit}]",
+		"duplicate name":       "publishers: [{type: elk}, {type: elk}]",
+		"unknown category":     "publishers: [{type: elk, filter: {categories: [SLOW]}}]",
+		"unknown field":        "publishers: [{type: elk, queue: 10}]",
+		"not a publisher list": "publishers: elk",
+		"invalid redaction":    "publishers: [{type: elk, redaction: {fields: {userName: truncate}}}]",
+	}
+	for name, data := range invalid {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFilter_Matches(t *testing.T) {
+	success := newTestEvent("orders", "via_upstream")
+	fault := newTestEvent("orders", "upstream_reset_before_response_started")
+	tests := []struct {
+		name    string
+		filter  Filter
+		event   *dto.Event
+		matches bool
+	}{
+		{name: "empty filter", event: success, matches: true},
+		{name: "fault category", filter: Filter{Categories: []string{"fault"}}, event: fault, matches: true},
+		{name: "fault category on success", filter: Filter{Categories: []string{CategoryFault}}, event: success},
+		{name: "api name", filter: Filter{APINames: []string{"payments", "orders"}}, event: success, matches: true},
+		{name: "other api name", filter: Filter{APINames: []string{"payments"}}, event: success},
+		{name: "organization and environment", filter: Filter{Organizations: []string{"org1"}, Environments: []string{"prod"}}, event: success, matches: true},
+		{name: "other environment", filter: Filter{Environments: []string{"dev"}}, event: success},
+		{name: "api type", filter: Filter{APITypes: []string{"GraphQL"}}, event: success},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.matches {
+				t.Errorf("expected %v, got %v", tt.matches, got)
+			}
+		})
+	}
+}
+
+func TestNew_RegisteredPublisher(t *testing.T) {
+	custom := &recordingPublisher{}
+	var instance pubanalytics.Instance
+	pubanalytics.MustRegister(pubanalytics.Registration{Type: "test-custom", New: func(i pubanalytics.Instance) (Publisher, error) {
+		instance = i
+		return custom, nil
+	}})
+	publisher, err := New(newTestConfig(), &Config{Name: "custom", Type: "test-custom", Properties: map[string]string{"url": "http://sink"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Name != "custom" || instance.Property("url", "") != "http://sink" {
+		t.Errorf("expected the instance configuration passed to the factory, got %+v", instance)
+	}
+	publisher.Publish(newTestEvent("orders", "via_upstream"))
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(custom.events) != 1 || !custom.closed {
+		t.Errorf("expected the event published to the custom publisher before it was closed")
+	}
+
+	if _, err := New(newTestConfig(), &Config{Name: "missing", Type: "missing"}, nil); err == nil {
+		t.Error("expected an unknown type to fail")
+	}
+}
+
+func TestQueuedPublisher_DropsWhenFull(t *testing.T) {
+	slow := &recordingPublisher{block: make(chan struct{})}
+	publisher := NewQueuedPublisher(&newTestConfig().Logger, &Config{Name: "slow", QueueSize: 2}, slow, nil)
+
+	done := make(chan struct{})
+	go func() {
+		// The publisher holds one event and queues two, the rest are dropped without blocking.
+		for i := 0; i < 10; i++ {
+			publisher.Publish(newTestEvent("orders", "via_upstream"))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing blocked on a slow publisher")
+	}
+	close(slow.block)
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if published := uint64(len(slow.events)); published+publisher.Dropped() != 10 || publisher.Dropped() < 7 {
+		t.Errorf("expected at most 3 events published, got %d published and %d dropped", published, publisher.Dropped())
+	}
+	// Events published after Close are ignored.
+	publisher.Publish(newTestEvent("orders", "via_upstream"))
+}
+
+func TestQueuedPublisher_Batches(t *testing.T) {
+	batcher := &batchRecordingPublisher{}
+	publisher := NewQueuedPublisher(&newTestConfig().Logger, &Config{Name: "batch", BatchSize: 3, FlushIntervalMs: 60000,
+		Filter: Filter{APINames: []string{"orders"}}}, batcher, nil)
+	for i := 0; i < 7; i++ {
+		publisher.Publish(newTestEvent("orders", "via_upstream"))
+		publisher.Publish(newTestEvent("payments", "via_upstream"))
+	}
+	if err := publisher.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(batcher.batches) != 3 || len(batcher.batches[0]) != 3 || len(batcher.batches[2]) != 1 {
+		t.Errorf("expected batches of 3, 3 and 1 orders events, got %d batches", len(batcher.batches))
+	}
+	for _, batch := range batcher.batches {
+		for _, event := range batch {
+			if event.API.APIName != "orders" {
+				t.Errorf("expected filtered out events to be skipped, got %s", event.API.APIName)
+			}
+		}
+	}
+}
+
+func TestQueuedPublisher_FlushInterval(t *testing.T) {
+	batcher := &batchRecordingPublisher{}
+	publisher := NewQueuedPublisher(&newTestConfig().Logger, &Config{Name: "batch", BatchSize: 100, FlushIntervalMs: 10}, batcher, nil)
+	defer publisher.Close()
+	publisher.Publish(newTestEvent("orders", "via_upstream"))
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		batcher.mu.Lock()
+		flushed := len(batcher.batches)
+		batcher.mu.Unlock()
+		if flushed == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("expected the partial batch to be flushed after the flush interval")
+}
+
+func TestQueuedPublisher_Redaction(t *testing.T) {
+	redacted := &recordingPublisher{}
+	plain := &recordingPublisher{}
+	event := newTestEvent("orders", "via_upstream")
+	event.UserIP = "192.0.2.10"
+	event.Properties = map[string]interface{}{"apiContext": "/orders", "responseSize": 10}
+
+	policy := redaction.Policy{Fields: map[string]redaction.Action{redaction.FieldUserIP: redaction.ActionTruncate},
+		AllowedProperties: []string{"apiContext"}}
+	first := NewQueuedPublisher(&newTestConfig().Logger, &Config{Name: "redacted", Redaction: policy}, redacted, nil)
+	second := NewQueuedPublisher(&newTestConfig().Logger, &Config{Name: "plain"}, plain, nil)
+	first.Publish(event)
+	second.Publish(event)
+	_ = first.Close()
+	_ = second.Close()
+
+	if got := redacted.events[0]; got.UserIP != "192.0.2.0" || len(got.Properties) != 1 {
+		t.Errorf("expected the event redacted for the first publisher, got %s %v", got.UserIP, got.Properties)
+	}
+	if got := plain.events[0]; got.UserIP != "192.0.2.10" || len(got.Properties) != 2 {
+		t.Errorf("expected the event unchanged for the second publisher, got %s %v", got.UserIP, got.Properties)
+	}
+}
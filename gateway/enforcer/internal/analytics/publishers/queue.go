@@ -0,0 +1,178 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics/redaction"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	pubanalytics "github.com/wso2/apk/gateway/enforcer/pkg/analytics"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+// dropLogInterval limits the logs of a full queue to one every dropLogInterval dropped events.
+const dropLogInterval = 1000
+
+// BatchPublisher is a Publisher that sends several events at once. The queue hands it batches
+// instead of single events.
+type BatchPublisher = pubanalytics.BatchPublisher
+
+// Closer is implemented by the publishers that hold resources to release on shutdown.
+type Closer = pubanalytics.Closer
+
+// QueuedPublisher runs a publisher on its own goroutine, fed by a bounded queue. Publish never
+// blocks, so a slow sink only loses its own events.
+type QueuedPublisher struct {
+	name          string
+	publisher     Publisher
+	filter        Filter
+	redaction     *redaction.Policy
+	hasher        redaction.Hasher
+	events        chan *dto.Event
+	batchSize     int
+	flushInterval time.Duration
+	logger        *logging.Logger
+	dropped       atomic.Uint64
+	// mu keeps Publish from sending on the queue once Close has closed it.
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewQueuedPublisher starts the queue of the publisher. The hasher is used by the redaction policy
+// of the publisher.
+func NewQueuedPublisher(logger *logging.Logger, instance *Config, publisher Publisher, hasher redaction.Hasher) *QueuedPublisher {
+	q := &QueuedPublisher{
+		name:          instance.Name,
+		publisher:     publisher,
+		filter:        instance.Filter,
+		redaction:     &instance.Redaction,
+		hasher:        hasher,
+		events:        make(chan *dto.Event, instance.queueSize()),
+		batchSize:     instance.batchSize(),
+		flushInterval: instance.flushInterval(),
+		logger:        logger,
+		done:          make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Name returns the name of the publisher instance.
+func (q *QueuedPublisher) Name() string {
+	return q.name
+}
+
+// Dropped returns the number of events dropped because the queue was full.
+func (q *QueuedPublisher) Dropped() uint64 {
+	return q.dropped.Load()
+}
+
+// Publish queues the event if it matches the filter of the publisher.
+func (q *QueuedPublisher) Publish(event *dto.Event) {
+	if !q.filter.Matches(event) {
+		return
+	}
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return
+	}
+	select {
+	case q.events <- event:
+	default:
+		if dropped := q.dropped.Add(1); dropped%dropLogInterval == 1 {
+			q.logger.Sugar().Warnf("Analytics publisher %s is falling behind, %d events dropped so far", q.name, dropped)
+		}
+	}
+}
+
+// Close stops accepting events, publishes the queued ones and releases the publisher.
+func (q *QueuedPublisher) Close() error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+	q.mu.Unlock()
+	<-q.done
+	if closer, ok := q.publisher.(Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (q *QueuedPublisher) run() {
+	defer close(q.done)
+	batcher, ok := q.publisher.(BatchPublisher)
+	if !ok {
+		for event := range q.events {
+			q.publishOne(q.redaction.Apply(event, q.hasher))
+		}
+		return
+	}
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+	batch := make([]*dto.Event, 0, q.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		q.publishBatch(batcher, batch)
+		batch = make([]*dto.Event, 0, q.batchSize)
+	}
+	for {
+		select {
+		case event, open := <-q.events:
+			if !open {
+				flush()
+				return
+			}
+			batch = append(batch, q.redaction.Apply(event, q.hasher))
+			if len(batch) >= q.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (q *QueuedPublisher) publishOne(event *dto.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			q.logger.Error(fmt.Errorf("panic occurred: %v", r), fmt.Sprintf("Recovered from panic in analytics publisher %s", q.name))
+		}
+	}()
+	q.publisher.Publish(event)
+}
+
+func (q *QueuedPublisher) publishBatch(batcher BatchPublisher, batch []*dto.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			q.logger.Error(fmt.Errorf("panic occurred: %v", r), fmt.Sprintf("Recovered from panic in analytics publisher %s", q.name))
+		}
+	}()
+	if err := batcher.PublishBatch(batch); err != nil {
+		q.logger.Error(err, fmt.Sprintf("Error publishing %d events with analytics publisher %s", len(batch), q.name))
+	}
+}
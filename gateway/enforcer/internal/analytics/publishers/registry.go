@@ -0,0 +1,132 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics/redaction"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	pubanalytics "github.com/wso2/apk/gateway/enforcer/pkg/analytics"
+)
+
+// Built-in publisher types.
+const (
+	TypeChoreo  = pubanalytics.TypeChoreo
+	TypeMoesif  = pubanalytics.TypeMoesif
+	TypeELK     = pubanalytics.TypeELK
+	TypeKafka   = pubanalytics.TypeKafka
+	TypeOTLP    = pubanalytics.TypeOTLP
+	TypeWebhook = pubanalytics.TypeWebhook
+)
+
+// Factory creates a publisher instance from its configuration.
+type Factory func(cfg *config.Server, instance *Config) (Publisher, error)
+
+// Registration declares a publisher built into the enforcer. Other publishers are registered
+// with pkg/analytics.
+type Registration struct {
+	// Type is the publisher type referenced by the publishers configuration.
+	Type string
+	// New creates an instance of the publisher.
+	New Factory
+}
+
+// builtinRegistrations declares the publishers shipped with the enforcer.
+var builtinRegistrations = []Registration{
+	{
+		Type: TypeELK,
+		New: func(cfg *config.Server, instance *Config) (Publisher, error) {
+			return NewELK(cfg, instance.Property("logLevel", cfg.ELKAnalyticsLogLevel)), nil
+		},
+	},
+	{
+		Type: TypeMoesif,
+		New: func(cfg *config.Server, instance *Config) (Publisher, error) {
+			return NewMoesif(cfg), nil
+		},
+	},
+	{
+		Type: TypeChoreo,
+		New: func(cfg *config.Server, instance *Config) (Publisher, error) {
+			publisher := NewChoreo(cfg, instance.Property("authURL", cfg.ChoreoAnalyticsAuthURL),
+				instance.Property("authToken", cfg.ChoreoAnalyticsAuthToken))
+			if publisher == nil {
+				return nil, errors.New("error while creating Choreo publisher")
+			}
+			return publisher, nil
+		},
+	},
+	{
+		Type: TypeKafka,
+		New: func(cfg *config.Server, instance *Config) (Publisher, error) {
+			return NewKafka(cfg, instance)
+		},
+	},
+	{
+		Type: TypeOTLP,
+		New: func(cfg *config.Server, instance *Config) (Publisher, error) {
+			return NewOTLP(cfg, instance)
+		},
+	},
+	{
+		Type: TypeWebhook,
+		New: func(cfg *config.Server, instance *Config) (Publisher, error) {
+			return NewWebhook(cfg, instance)
+		},
+	},
+}
+
+// builtinFactories holds the factories of the built-in publishers by type.
+var builtinFactories = map[string]Factory{}
+
+func init() {
+	for _, r := range builtinRegistrations {
+		builtinFactories[r.Type] = r.New
+	}
+}
+
+// GetFactory returns the factory of the publisher type, built into the enforcer or registered
+// with pkg/analytics.
+func GetFactory(publisherType string) (Factory, bool) {
+	if factory, ok := builtinFactories[publisherType]; ok {
+		return factory, true
+	}
+	r, ok := pubanalytics.Lookup(publisherType)
+	if !ok {
+		return nil, false
+	}
+	return func(_ *config.Server, instance *Config) (Publisher, error) {
+		return r.New(pubanalytics.Instance{Name: instance.Name, Type: instance.Type, Properties: instance.Properties})
+	}, true
+}
+
+// New creates the publisher described by the instance configuration. The publisher is wrapped in
+// its own bounded queue, so a slow sink drops events instead of blocking the access log service.
+func New(cfg *config.Server, instance *Config, hasher redaction.Hasher) (*QueuedPublisher, error) {
+	factory, ok := GetFactory(instance.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown analytics publisher type %q", instance.Type)
+	}
+	publisher, err := factory(cfg, instance)
+	if err != nil {
+		return nil, fmt.Errorf("error creating analytics publisher %s: %w", instance.Name, err)
+	}
+	return NewQueuedPublisher(&cfg.Logger, instance, publisher, hasher), nil
+}
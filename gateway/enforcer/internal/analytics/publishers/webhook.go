@@ -0,0 +1,271 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+const (
+	defaultWebhookTimeout       = 5 * time.Second
+	defaultWebhookMaxRetries    = 3
+	defaultWebhookRetryBackoff  = 500 * time.Millisecond
+	defaultWebhookMaxSpoolFiles = 1000
+
+	spoolFileSuffix = ".json"
+)
+
+// permanentError is a rejection that retrying the same batch cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Webhook posts the events in batches to an HTTP endpoint as a JSON array. Failed requests are
+// retried with an exponential backoff. When the retries run out, the batch is spooled to disk and
+// sent again, oldest first, after the next successful request.
+//
+// Properties: url (required), headers (comma separated key=value pairs), timeoutMs, maxRetries,
+// retryBackoffMs, spoolDir and maxSpoolFiles. Batches are dropped instead of spooled without a
+// spoolDir.
+type Webhook struct {
+	cfg           *config.Server
+	name          string
+	url           string
+	headers       map[string]string
+	client        *http.Client
+	maxRetries    int
+	retryBackoff  time.Duration
+	spoolDir      string
+	maxSpoolFiles int
+	spoolSequence atomic.Uint64
+}
+
+// NewWebhook creates a new webhook publisher.
+func NewWebhook(cfg *config.Server, instance *Config) (*Webhook, error) {
+	url := instance.Property("url", "")
+	if url == "" {
+		return nil, errors.New("property url is required")
+	}
+	timeoutMs, err := instance.IntProperty("timeoutMs", int(defaultWebhookTimeout/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	maxRetries, err := instance.IntProperty("maxRetries", defaultWebhookMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	backoffMs, err := instance.IntProperty("retryBackoffMs", int(defaultWebhookRetryBackoff/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	maxSpoolFiles, err := instance.IntProperty("maxSpoolFiles", defaultWebhookMaxSpoolFiles)
+	if err != nil {
+		return nil, err
+	}
+	spoolDir := instance.Property("spoolDir", "")
+	if spoolDir != "" {
+		if err := os.MkdirAll(spoolDir, 0o750); err != nil {
+			return nil, fmt.Errorf("error creating the spool directory: %w", err)
+		}
+	}
+	return &Webhook{
+		cfg:           cfg,
+		name:          instance.Name,
+		url:           url,
+		headers:       instance.MapProperty("headers"),
+		client:        &http.Client{Timeout: time.Duration(timeoutMs) * time.Millisecond},
+		maxRetries:    maxRetries,
+		retryBackoff:  time.Duration(backoffMs) * time.Millisecond,
+		spoolDir:      spoolDir,
+		maxSpoolFiles: maxSpoolFiles,
+	}, nil
+}
+
+// Publish posts a single event.
+func (w *Webhook) Publish(event *dto.Event) {
+	if err := w.PublishBatch([]*dto.Event{event}); err != nil {
+		w.cfg.Logger.Error(err, fmt.Sprintf("Error publishing event with webhook publisher %s", w.name))
+	}
+}
+
+// PublishBatch posts the events, spooling them if the endpoint cannot be reached.
+func (w *Webhook) PublishBatch(events []*dto.Event) error {
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	if err := w.sendWithRetries(payload); err != nil {
+		var permanent *permanentError
+		if errors.As(err, &permanent) || w.spoolDir == "" {
+			return err
+		}
+		if spoolErr := w.spool(payload); spoolErr != nil {
+			return fmt.Errorf("%w, and spooling the batch failed: %v", err, spoolErr)
+		}
+		w.cfg.Logger.Sugar().Warnf("Spooled %d analytics events of webhook publisher %s: %v", len(events), w.name, err)
+		return nil
+	}
+	w.replaySpool()
+	return nil
+}
+
+func (w *Webhook) sendWithRetries(payload []byte) error {
+	backoff := w.retryBackoff
+	var err error
+	for attempt := 0; attempt <= w.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if err = w.send(payload); err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return err
+		}
+	}
+	return err
+}
+
+func (w *Webhook) send(payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// spool writes the batch to a new file of the spool directory. Once the directory holds
+// maxSpoolFiles batches, the oldest ones are removed.
+func (w *Webhook) spool(payload []byte) error {
+	name := fmt.Sprintf("%020d-%010d%s", time.Now().UnixNano(), w.spoolSequence.Add(1), spoolFileSuffix)
+	tmp, err := os.CreateTemp(w.spoolDir, ".spool-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	// The rename keeps a crash from leaving a partial batch behind.
+	if err := os.Rename(tmp.Name(), filepath.Join(w.spoolDir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	files, err := w.spoolFiles()
+	if err != nil {
+		return err
+	}
+	for len(files) > w.maxSpoolFiles {
+		w.cfg.Logger.Sugar().Warnf("Spool of webhook publisher %s is full, dropping %s", w.name, files[0])
+		if err := os.Remove(filepath.Join(w.spoolDir, files[0])); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// replaySpool sends the spooled batches, oldest first, and stops at the first failure.
+func (w *Webhook) replaySpool() {
+	if w.spoolDir == "" {
+		return
+	}
+	files, err := w.spoolFiles()
+	if err != nil {
+		w.cfg.Logger.Error(err, fmt.Sprintf("Error reading the spool of webhook publisher %s", w.name))
+		return
+	}
+	for _, file := range files {
+		path := filepath.Join(w.spoolDir, file)
+		payload, err := os.ReadFile(path)
+		if err != nil {
+			w.cfg.Logger.Error(err, fmt.Sprintf("Error reading spooled batch %s", path))
+			return
+		}
+		if err := w.send(payload); err != nil {
+			var permanent *permanentError
+			if !errors.As(err, &permanent) {
+				return
+			}
+			w.cfg.Logger.Error(err, fmt.Sprintf("Dropping spooled batch %s rejected by webhook publisher %s", path, w.name))
+		}
+		if err := os.Remove(path); err != nil {
+			w.cfg.Logger.Error(err, fmt.Sprintf("Error removing spooled batch %s", path))
+			return
+		}
+	}
+}
+
+// spoolFiles returns the spooled batches, oldest first.
+func (w *Webhook) spoolFiles() ([]string, error) {
+	entries, err := os.ReadDir(w.spoolDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), spoolFileSuffix) {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,148 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package publishers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+// webhookServer records the batches it accepts. While status is set, it fails the next failures
+// requests with it.
+type webhookServer struct {
+	mu       sync.Mutex
+	batches  [][]dto.Event
+	status   atomic.Int32
+	failures atomic.Int32
+	requests atomic.Int32
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if status := s.status.Load(); status != 0 && s.failures.Add(-1) >= 0 {
+		w.WriteHeader(int(status))
+		return
+	}
+	var batch []dto.Event
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.batches = append(s.batches, batch)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func newTestWebhook(t *testing.T, url string, properties map[string]string) *Webhook {
+	all := map[string]string{"url": url, "headers": "Authorization=Bearer secret", "maxRetries": "2", "retryBackoffMs": "1"}
+	for key, value := range properties {
+		all[key] = value
+	}
+	webhook, err := NewWebhook(newTestConfig(), &Config{Name: "webhook", Properties: all})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return webhook
+}
+
+func TestWebhook_PublishBatch(t *testing.T) {
+	server := &webhookServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	webhook := newTestWebhook(t, ts.URL, nil)
+
+	// The first two attempts fail, the last retry goes through.
+	server.status.Store(http.StatusServiceUnavailable)
+	server.failures.Store(2)
+	if err := webhook.PublishBatch([]*dto.Event{newTestEvent("orders", "via_upstream"), newTestEvent("payments", "via_upstream")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.batches) != 1 || len(server.batches[0]) != 2 || server.batches[0][1].API.APIName != "payments" {
+		t.Errorf("expected the batch posted as a JSON array, got %+v", server.batches)
+	}
+
+	// Client errors are not retried.
+	unauthorized := newTestWebhook(t, ts.URL, map[string]string{"headers": ""})
+	requests := server.requests.Load()
+	if err := unauthorized.PublishBatch([]*dto.Event{newTestEvent("orders", "via_upstream")}); err == nil {
+		t.Error("expected the rejected batch to fail")
+	}
+	if server.requests.Load() != requests+1 {
+		t.Errorf("expected a rejected batch to be sent once, got %d requests", server.requests.Load()-requests)
+	}
+}
+
+func TestWebhook_Spool(t *testing.T) {
+	server := &webhookServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	spoolDir := t.TempDir()
+	webhook := newTestWebhook(t, ts.URL, map[string]string{"spoolDir": spoolDir, "maxSpoolFiles": "2"})
+
+	server.status.Store(http.StatusBadGateway)
+	server.failures.Store(1000)
+	for _, name := range []string{"first", "second", "third"} {
+		if err := webhook.PublishBatch([]*dto.Event{newTestEvent(name, "via_upstream")}); err != nil {
+			t.Fatalf("expected the batch to be spooled, got %v", err)
+		}
+	}
+	files, err := webhook.spoolFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected the spool to keep the 2 newest batches, got %d", len(files))
+	}
+
+	// Once the endpoint recovers, the spooled batches are replayed oldest first.
+	server.status.Store(0)
+	if err := webhook.PublishBatch([]*dto.Event{newTestEvent("fourth", "via_upstream")}); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, batch := range server.batches {
+		names = append(names, batch[0].API.APIName)
+	}
+	if len(names) != 3 || names[0] != "fourth" || names[1] != "second" || names[2] != "third" {
+		t.Errorf("expected the new batch followed by the spooled ones, got %v", names)
+	}
+	if entries, _ := os.ReadDir(spoolDir); len(entries) != 0 {
+		t.Errorf("expected the spool to be empty, got %d files", len(entries))
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	if _, err := NewWebhook(newTestConfig(), &Config{Name: "webhook"}); err == nil {
+		t.Error("expected a missing url to fail")
+	}
+	if _, err := NewWebhook(newTestConfig(), &Config{Name: "webhook", Properties: map[string]string{"url": "http://localhost", "maxRetries": "many"}}); err == nil {
+		t.Error("expected an invalid maxRetries to fail")
+	}
+}
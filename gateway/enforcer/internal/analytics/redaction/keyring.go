@@ -0,0 +1,127 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package redaction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// keyRingReloadInterval is how often the key file is checked for a rotated key.
+const keyRingReloadInterval = 30 * time.Second
+
+// Hasher pseudonymizes the values of the hashed fields.
+type Hasher interface {
+	Hash(value string) (string, error)
+}
+
+// keyFile is the content of the HMAC key file. Keys are base64 encoded. Rotating the key means
+// adding a new key and pointing activeKeyId to it; the previous keys can stay in the file for the
+// consumers that verify older events.
+type keyFile struct {
+	ActiveKeyID string            `json:"activeKeyId"`
+	Keys        map[string]string `json:"keys"`
+}
+
+// KeyRing hashes values with the HMAC-SHA256 of the active key of a key file. The file, usually a
+// mounted secret, is reloaded when it changes, so keys rotate without restarting the enforcer.
+// Hashes are prefixed with the id of the key, so that consumers know which key produced them.
+type KeyRing struct {
+	path string
+
+	mu        sync.Mutex
+	keyID     string
+	key       []byte
+	modTime   time.Time
+	checkedAt time.Time
+	now       func() time.Time
+}
+
+// NewKeyRing creates a key ring reading the key file at path.
+func NewKeyRing(path string) *KeyRing {
+	return &KeyRing{path: path, now: time.Now}
+}
+
+// Hash returns the keyed hash of the value.
+func (k *KeyRing) Hash(value string) (string, error) {
+	keyID, key, err := k.activeKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return keyID + ":" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (k *KeyRing) activeKey() (string, []byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if now := k.now(); k.key == nil || now.Sub(k.checkedAt) >= keyRingReloadInterval {
+		k.checkedAt = now
+		if err := k.reload(); err != nil && k.key == nil {
+			return "", nil, err
+		}
+	}
+	return k.keyID, k.key, nil
+}
+
+// reload reads the key file if it changed since it was last read. The previous key is kept if
+// the file cannot be read, so a partially written secret does not stop the hashing.
+func (k *KeyRing) reload() error {
+	if k.path == "" {
+		return errors.New("no HMAC key file is configured for the analytics field hashing")
+	}
+	info, err := os.Stat(k.path)
+	if err != nil {
+		return fmt.Errorf("error reading the analytics HMAC key file: %w", err)
+	}
+	if k.key != nil && info.ModTime().Equal(k.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return fmt.Errorf("error reading the analytics HMAC key file: %w", err)
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("error parsing the analytics HMAC key file: %w", err)
+	}
+	encoded, ok := file.Keys[file.ActiveKeyID]
+	if !ok {
+		return fmt.Errorf("active HMAC key %q is missing from the key file", file.ActiveKeyID)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("HMAC key %s is not base64 encoded: %w", file.ActiveKeyID, err)
+	}
+	if len(key) < sha256.Size {
+		return fmt.Errorf("HMAC key %s must be at least %d bytes long", file.ActiveKeyID, sha256.Size)
+	}
+	k.keyID = file.ActiveKeyID
+	k.key = key
+	k.modTime = info.ModTime()
+	return nil
+}
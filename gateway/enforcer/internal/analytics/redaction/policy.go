@@ -0,0 +1,177 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package redaction removes or pseudonymizes the personal data of analytics events before they
+// are published.
+package redaction
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+// Action is what a policy does to a field of the events.
+type Action string
+
+const (
+	// ActionDrop removes the field.
+	ActionDrop Action = "drop"
+	// ActionHash replaces the field with its HMAC, so events can still be correlated.
+	ActionHash Action = "hash"
+	// ActionTruncate keeps the network part of an IP address, the /24 of IPv4 and the /48 of IPv6.
+	ActionTruncate Action = "truncate"
+)
+
+// Fields a policy can act on, named after their JSON names in the events.
+const (
+	FieldUserIP           = "userIp"
+	FieldUserAgent        = "userAgentHeader"
+	FieldUserName         = "userName"
+	FieldApplicationID    = "applicationId"
+	FieldApplicationName  = "applicationName"
+	FieldApplicationOwner = "applicationOwner"
+)
+
+// userNameProperty is the custom property that repeats the user name.
+const userNameProperty = "userName"
+
+var fields = map[string]struct{}{
+	FieldUserIP:           {},
+	FieldUserAgent:        {},
+	FieldUserName:         {},
+	FieldApplicationID:    {},
+	FieldApplicationName:  {},
+	FieldApplicationOwner: {},
+}
+
+// Policy describes how the fields of the events are redacted.
+type Policy struct {
+	// Fields maps the fields to the action applied to them.
+	Fields map[string]Action `json:"fields,omitempty" yaml:"fields"`
+	// AllowedProperties lists the custom properties kept in the events. Every property is kept
+	// when the list is empty.
+	AllowedProperties []string `json:"allowedProperties,omitempty" yaml:"allowedProperties"`
+}
+
+// DropAll returns a policy that drops every field it can act on. It is used in place of a policy
+// that cannot be read, so that a mistake does not leak personal data.
+func DropAll() *Policy {
+	policy := &Policy{Fields: map[string]Action{}}
+	for field := range fields {
+		policy.Fields[field] = ActionDrop
+	}
+	return policy
+}
+
+// Validate checks that the policy only uses known fields and actions.
+func (p *Policy) Validate() error {
+	for field, action := range p.Fields {
+		if _, ok := fields[field]; !ok {
+			return fmt.Errorf("unknown analytics field %q", field)
+		}
+		switch action {
+		case ActionDrop, ActionHash:
+		case ActionTruncate:
+			if field != FieldUserIP {
+				return fmt.Errorf("analytics field %s cannot be truncated, only %s can", field, FieldUserIP)
+			}
+		default:
+			return fmt.Errorf("unknown action %q for analytics field %s", action, field)
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the policy leaves the events unchanged.
+func (p *Policy) IsEmpty() bool {
+	return p == nil || (len(p.Fields) == 0 && len(p.AllowedProperties) == 0)
+}
+
+// Apply returns the event redacted by the policy. The event is shared by every publisher, so it is
+// never modified; the parts that change are copied. A field that cannot be hashed is dropped.
+func (p *Policy) Apply(event *dto.Event, hasher Hasher) *dto.Event {
+	if p.IsEmpty() || event == nil {
+		return event
+	}
+	redacted := *event
+	redact := func(field string, value string) string {
+		action, ok := p.Fields[field]
+		if !ok || value == "" {
+			return value
+		}
+		switch action {
+		case ActionHash:
+			if hasher != nil {
+				if hashed, err := hasher.Hash(value); err == nil {
+					return hashed
+				}
+			}
+			return ""
+		case ActionTruncate:
+			return truncateIP(value)
+		default:
+			return ""
+		}
+	}
+
+	redacted.UserIP = redact(FieldUserIP, event.UserIP)
+	redacted.UserAgentHeader = redact(FieldUserAgent, event.UserAgentHeader)
+	redacted.UserName = redact(FieldUserName, event.UserName)
+	if event.Application != nil {
+		application := *event.Application
+		application.ApplicationID = redact(FieldApplicationID, application.ApplicationID)
+		application.ApplicationName = redact(FieldApplicationName, application.ApplicationName)
+		application.ApplicationOwner = redact(FieldApplicationOwner, application.ApplicationOwner)
+		redacted.Application = &application
+	}
+
+	if event.Properties != nil {
+		allowed := make(map[string]struct{}, len(p.AllowedProperties))
+		for _, key := range p.AllowedProperties {
+			allowed[key] = struct{}{}
+		}
+		redacted.Properties = make(map[string]interface{}, len(event.Properties))
+		for key, value := range event.Properties {
+			if _, ok := allowed[key]; len(allowed) > 0 && !ok {
+				continue
+			}
+			if name, ok := value.(string); ok && key == userNameProperty {
+				if name = redact(FieldUserName, name); name == "" {
+					continue
+				}
+				value = name
+			}
+			redacted.Properties[key] = value
+		}
+	}
+	return &redacted
+}
+
+// truncateIP keeps the network part of the address. Values that are not IP addresses, like the
+// UNKNOWN placeholder, are kept as they are.
+func truncateIP(value string) string {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return value
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -0,0 +1,207 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package redaction
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+func newTestEvent() *dto.Event {
+	return &dto.Event{
+		UserIP:          "203.0.113.57",
+		UserAgentHeader: "curl/8.0",
+		UserName:        "alice@example.com",
+		Application: &dto.Application{
+			ApplicationID:    "app-1",
+			ApplicationName:  "Orders App",
+			ApplicationOwner: "alice",
+			KeyType:          "PRODUCTION",
+		},
+		Properties: map[string]interface{}{
+			"userName":     "alice@example.com",
+			"apiContext":   "/orders",
+			"responseSize": uint64(42),
+		},
+	}
+}
+
+// staticHasher hashes values to a fixed marker.
+type staticHasher struct{}
+
+func (staticHasher) Hash(value string) (string, error) {
+	return "hashed(" + value + ")", nil
+}
+
+func writeKeyFile(t *testing.T, path string, activeKeyID string, keys map[string]string) {
+	t.Helper()
+	var entries []string
+	for id, key := range keys {
+		entries = append(entries, `"`+id+`": "`+base64.StdEncoding.EncodeToString([]byte(key))+`"`)
+	}
+	data := `{"activeKeyId": "` + activeKeyID + `", "keys": {` + strings.Join(entries, ", ") + `}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	event := newTestEvent()
+	policy := &Policy{
+		Fields: map[string]Action{
+			FieldUserIP:           ActionTruncate,
+			FieldUserAgent:        ActionDrop,
+			FieldUserName:         ActionHash,
+			FieldApplicationOwner: ActionDrop,
+		},
+		AllowedProperties: []string{"userName", "apiContext"},
+	}
+	redacted := policy.Apply(event, staticHasher{})
+
+	if redacted.UserIP != "203.0.113.0" || redacted.UserAgentHeader != "" || redacted.UserName != "hashed(alice@example.com)" {
+		t.Errorf("unexpected user fields %q %q %q", redacted.UserIP, redacted.UserAgentHeader, redacted.UserName)
+	}
+	if redacted.Application.ApplicationOwner != "" || redacted.Application.ApplicationName != "Orders App" {
+		t.Errorf("unexpected application %+v", redacted.Application)
+	}
+	if len(redacted.Properties) != 2 || redacted.Properties["userName"] != "hashed(alice@example.com)" || redacted.Properties["apiContext"] != "/orders" {
+		t.Errorf("expected the allowed properties with the user name hashed, got %v", redacted.Properties)
+	}
+
+	// The event is shared by the publishers, so it must be left as it was.
+	if event.UserIP != "203.0.113.57" || event.Application.ApplicationOwner != "alice" || len(event.Properties) != 3 ||
+		event.Properties["userName"] != "alice@example.com" {
+		t.Errorf("expected the original event to be unchanged, got %+v", event)
+	}
+}
+
+func TestPolicy_ApplyWithoutHasher(t *testing.T) {
+	policy := &Policy{Fields: map[string]Action{FieldUserName: ActionHash}}
+	redacted := policy.Apply(newTestEvent(), nil)
+	if redacted.UserName != "" {
+		t.Errorf("expected the field to be dropped when it cannot be hashed, got %q", redacted.UserName)
+	}
+	if _, ok := redacted.Properties["userName"]; ok {
+		t.Errorf("expected the user name property to be dropped")
+	}
+
+	var empty *Policy
+	if event := newTestEvent(); empty.Apply(event, nil) != event {
+		t.Error("expected a missing policy to return the event as is")
+	}
+}
+
+func TestTruncateIP(t *testing.T) {
+	tests := map[string]string{
+		"198.51.100.23":                        "198.51.100.0",
+		"2001:db8:85a3:8d3:1319:8a2e:370:7348": "2001:db8:85a3::",
+		"::ffff:192.0.2.128":                   "192.0.2.0",
+		"UNKNOWN":                              "UNKNOWN",
+	}
+	for ip, expected := range tests {
+		if truncated := truncateIP(ip); truncated != expected {
+			t.Errorf("%s: expected %s, got %s", ip, expected, truncated)
+		}
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	valid := &Policy{Fields: map[string]Action{FieldUserIP: ActionTruncate, FieldApplicationID: ActionHash}}
+	if err := valid.Validate(); err != nil {
+		t.Error(err)
+	}
+	invalid := []*Policy{
+		{Fields: map[string]Action{"password": ActionDrop}},
+		{Fields: map[string]Action{FieldUserName: "encrypt"}},
+		{Fields: map[string]Action{FieldUserName: ActionTruncate}},
+	}
+	for _, policy := range invalid {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("expected %v to be invalid", policy.Fields)
+		}
+	}
+	if err := DropAll().Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyRing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeyFile(t, path, "2025-01", map[string]string{"2025-01": strings.Repeat("a", 32)})
+	now := time.Now()
+	keyRing := NewKeyRing(path)
+	keyRing.now = func() time.Time { return now }
+
+	first, err := keyRing.Hash("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := keyRing.Hash("alice"); again != first || !strings.HasPrefix(first, "2025-01:") {
+		t.Errorf("expected a stable hash prefixed with the key id, got %s and %s", first, again)
+	}
+	if other, _ := keyRing.Hash("bob"); other == first {
+		t.Error("expected different values to hash differently")
+	}
+
+	// The rotated key is picked up once the reload interval has passed.
+	writeKeyFile(t, path, "2025-02", map[string]string{"2025-01": strings.Repeat("a", 32), "2025-02": strings.Repeat("b", 32)})
+	future := now.Add(time.Hour)
+	_ = os.Chtimes(path, future, future)
+	if hashed, _ := keyRing.Hash("alice"); hashed != first {
+		t.Errorf("expected the key to be cached until the reload interval, got %s", hashed)
+	}
+	now = now.Add(keyRingReloadInterval)
+	rotated, err := keyRing.Hash("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rotated, "2025-02:") || strings.TrimPrefix(rotated, "2025-02:") == strings.TrimPrefix(first, "2025-01:") {
+		t.Errorf("expected the value hashed with the rotated key, got %s", rotated)
+	}
+
+	// A broken file keeps the previous key.
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(path, future.Add(time.Hour), future.Add(time.Hour))
+	now = now.Add(keyRingReloadInterval)
+	if hashed, err := keyRing.Hash("alice"); err != nil || hashed != rotated {
+		t.Errorf("expected the previous key to be kept, got %s, %v", hashed, err)
+	}
+}
+
+func TestKeyRing_InvalidKeys(t *testing.T) {
+	if _, err := NewKeyRing("").Hash("alice"); err == nil {
+		t.Error("expected hashing without a key file to fail")
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeyFile(t, path, "short", map[string]string{"short": "key"})
+	if _, err := NewKeyRing(path).Hash("alice"); err == nil {
+		t.Error("expected a short key to be rejected")
+	}
+	writeKeyFile(t, path, "missing", map[string]string{"other": strings.Repeat("a", 32)})
+	if _, err := NewKeyRing(path).Hash("alice"); err == nil {
+		t.Error("expected a missing active key to be rejected")
+	}
+}
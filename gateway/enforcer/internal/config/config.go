@@ -53,7 +53,10 @@ type Server struct {
 	RedisHost                        string `envconfig:"REDIS_HOST" default:"redis-master"`
 	RedisPort                        int    `envconfig:"REDIS_PORT" default:"6379"`
 	IsRedisTLSEnabled                bool   `envconfig:"IS_REDIS_TLS_ENABLED" default:"false"`
-	RevokedTokensRedisChannel        string `envconfig:"REDIS_REVOKED_TOKENS_CHANNEL" default:"wso2-kgw-revoked-tokens-channel"`
+	RevokedTokensRedisStream         string `envconfig:"REDIS_REVOKED_TOKENS_STREAM" default:"wso2:apk:revoked_tokens"`
+	RevokedTokensRedisKeyPrefix      string `envconfig:"REDIS_REVOKED_TOKENS_KEY_PREFIX" default:"wso2:apk:revoked_token:"`
+	RevokedTokensRetryInterval       int    `envconfig:"REDIS_REVOKED_TOKENS_RETRY_INTERVAL" default:"1000"` // milliseconds
+	RevocationRulesRedisKeyPrefix    string `envconfig:"REDIS_REVOCATION_RULES_KEY_PREFIX" default:"wso2:apk:revocation_rule:"`
 	RedisKeyFile                     string `envconfig:"REDIS_KEY_FILE" default:"/home/wso2/security/redis/redis.key"`
 	RedisCertFile                    string `envconfig:"REDIS_CERT_FILE" default:"/home/wso2/security/redis/redis.crt"`
 	RedisCaCertFile                  string `envconfig:"REDIS_CA_CERT_FILE" default:"/home/wso2/security/redis/ca.crt"`
@@ -70,6 +73,8 @@ type Server struct {
 	MoesifToken                      string `envconfig:"MOESIF_TOKEN" default:""`
 	ELKAnalyticsEnabled              bool   `envconfig:"ELK_ANALYTICS_ENABLED" default:"false"`
 	ELKAnalyticsLogLevel             string `envconfig:"ELK_ANALYTICS_LOG_LEVEL" default:"INFO"`
+	AnalyticsPublishersConfigFile    string `envconfig:"ANALYTICS_PUBLISHERS_CONFIG_FILE" default:""` // YAML list of publisher instances
+	AnalyticsHMACKeyFile             string `envconfig:"ANALYTICS_HMAC_KEY_FILE" default:""`          // keys of the hashed analytics fields
 	LogLevel                         string `envconfig:"LOG_LEVEL" default:"INFO"`
 	ExternalProcessingPort           string `envconfig:"EXTERNAL_PROCESSING_PORT" default:"8081"`
 	AccessLogServiceServerPort       string `envconfig:"ACCESS_LOG_SERVICE_SERVER_PORT" default:"18090"`
@@ -78,12 +83,21 @@ type Server struct {
 	ExternalProcessingMaxHeaderLimit int    `envconfig:"EXTERNAL_PROCESSING_MAX_HEADER_LIMIT" default:"8192"`
 	Logger                           logging.Logger
 	Metrics                          metrics
+	Tracing                          tracing
 	JWTGeneratorPublicKeyPath        string `envconfig:"JWT_GENERATOR_PUBLIC_CERTIFICATE_PATH" default:"/home/wso2/security/keystore/mg.pem"`
 	JWTGeneratorPrivateKeyPath       string `envconfig:"JWT_GENERATOR_PRIVATE_KEY_PATH" default:"/home/wso2/security/keystore/mg.key"`
+	JWTGeneratorKeysDir              string `envconfig:"JWT_GENERATOR_KEYS_DIR" default:""`     // directory of <kid>.key files, overrides the private key
 	EventhubPublishInterval          int    `envconfig:"EVENTHUB_PUBLISH_INTERVAL" default:"5"` // seconds
 	MoesifPublishInterval            int    `envconfig:"MOESIF_PUBLISH_INTERVAL" default:"5"`   // seconds
 	ExternalCustomMediationEnabled   bool   `envconfig:"EXTERNAL_CUSTOM_MEDIATION_ENABLED" default:"true"`
+	PluginRunnerAddress              string `envconfig:"PLUGIN_RUNNER_ADDRESS" default:""` // e.g. unix:///var/run/apk/plugin-runner.sock
+	PluginRunnerPoolSize             int    `envconfig:"PLUGIN_RUNNER_POOL_SIZE" default:"4"`
+	WASMMediationEnabled             bool   `envconfig:"WASM_MEDIATION_ENABLED" default:"true"`
+	WASMModuleCacheSize              int    `envconfig:"WASM_MODULE_CACHE_SIZE" default:"32"` // compiled modules kept, 0 = unbounded
 	ALSPlainText                     bool   `envconfig:"ALS_PLAINTEXT" default:"true"`
+	AdminAPIEnabled                  bool   `envconfig:"ADMIN_API_ENABLED" default:"false"`
+	AdminAPIPort                     string `envconfig:"ADMIN_API_PORT" default:"9095"`
+	AdminAPIToken                    string `envconfig:"ADMIN_API_TOKEN" default:""` // bearer token required by the admin API
 }
 
 type metrics struct {
@@ -93,6 +107,15 @@ type metrics struct {
 	CollectionInterval int32  `envconfig:"METRICS_COLLECTION_INTERVAL" default:"15"`
 }
 
+type tracing struct {
+	Enabled       bool    `envconfig:"TRACING_ENABLED" default:"false"`
+	Exporter      string  `envconfig:"TRACING_EXPORTER" default:"otlpgrpc"` // otlpgrpc or otlphttp
+	Endpoint      string  `envconfig:"TRACING_ENDPOINT" default:"localhost:4317"`
+	Insecure      bool    `envconfig:"TRACING_INSECURE" default:"true"`
+	SamplingRatio float64 `envconfig:"TRACING_SAMPLING_RATIO" default:"1.0"` // for traces without an incoming sampling decision
+	ServiceName   string  `envconfig:"TRACING_SERVICE_NAME" default:"apk-enforcer"`
+}
+
 // package-level variable and mutex for thread safety
 var (
 	processOnce     sync.Once
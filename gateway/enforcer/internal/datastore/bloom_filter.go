@@ -0,0 +1,91 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package datastore
+
+import (
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// bloomFilterFalsePositiveRate is the false positive rate the filter is sized for.
+const bloomFilterFalsePositiveRate = 0.01
+
+// bloomFilter is a fixed size bloom filter that can be read without locking. It never reports
+// a false negative, so a miss proves a value was never added.
+type bloomFilter struct {
+	bits     []atomic.Uint64
+	hashes   uint64
+	capacity int
+}
+
+// newBloomFilter creates a bloom filter sized to hold the given number of values.
+func newBloomFilter(capacity int) *bloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	size := uint64(math.Ceil(-float64(capacity) * math.Log(bloomFilterFalsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Round(float64(size) / float64(capacity) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+	return &bloomFilter{
+		bits:     make([]atomic.Uint64, (size+63)/64),
+		hashes:   hashes,
+		capacity: capacity,
+	}
+}
+
+// add adds the value to the filter.
+func (b *bloomFilter) add(value string) {
+	h1, h2 := bloomHashes(value)
+	size := uint64(len(b.bits)) * 64
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % size
+		word := &b.bits[bit/64]
+		mask := uint64(1) << (bit % 64)
+		for {
+			old := word.Load()
+			if old&mask != 0 || word.CompareAndSwap(old, old|mask) {
+				break
+			}
+		}
+	}
+}
+
+// mayContain reports whether the value may have been added to the filter.
+func (b *bloomFilter) mayContain(value string) bool {
+	h1, h2 := bloomHashes(value)
+	size := uint64(len(b.bits)) * 64
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % size
+		if b.bits[bit/64].Load()&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes derives the two hashes combined into the probe positions of the value.
+func bloomHashes(value string) (uint64, uint64) {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(value))
+	sum := hash.Sum64()
+	// The second hash is the first rotated, made odd so the probes of a value never repeat a step of zero.
+	return sum, (sum>>33 | sum<<31) | 1
+}
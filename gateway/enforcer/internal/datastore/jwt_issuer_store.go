@@ -18,6 +18,7 @@
 package datastore
 
 import (
+	"sort"
 	"sync"
 
 	subscription "github.com/wso2/apk/adapter/pkg/discovery/api/wso2/discovery/subscription"
@@ -85,3 +86,21 @@ func (s *JWTIssuerStore) GetJWTIssuerCount() int {
 	}
 	return count
 }
+
+// ListJWTIssuers returns the JWTIssuers of the organization, or of every organization when it is empty.
+// This method is thread-safe.
+func (s *JWTIssuerStore) ListJWTIssuers(organization string) []*subscription.JWTIssuer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	jwtIssuers := make([]*subscription.JWTIssuer, 0)
+	for org, orgWiseJWTIssuers := range s.jwtIssuers {
+		if organization != "" && org != organization {
+			continue
+		}
+		for _, jwtIssuer := range orgWiseJWTIssuers {
+			jwtIssuers = append(jwtIssuers, jwtIssuer)
+		}
+	}
+	sort.Slice(jwtIssuers, func(i, j int) bool { return jwtIssuers[i].Issuer < jwtIssuers[j].Issuer })
+	return jwtIssuers
+}
@@ -19,6 +19,7 @@ package datastore
 
 import (
 	"log"
+	"sort"
 	"sync"
 	"time"
 )
@@ -127,6 +128,42 @@ func (r *ModelBasedRoundRobinTracker) SuspendModel(api, resource, model string,
 	r.suspended[api][resource][model] = time.Now().Add(duration)
 }
 
+// SuspendedModel is a model suspended for an API resource.
+type SuspendedModel struct {
+	API            string    `json:"api"`
+	Resource       string    `json:"resource"`
+	Model          string    `json:"model"`
+	SuspendedUntil time.Time `json:"suspendedUntil"`
+}
+
+// ListSuspendedModels returns the models whose suspension has not ended.
+func (r *ModelBasedRoundRobinTracker) ListSuspendedModels() []SuspendedModel {
+	r.Lock()
+	defer r.Unlock()
+	now := time.Now()
+	suspendedModels := make([]SuspendedModel, 0)
+	for api, resources := range r.suspended {
+		for resource, models := range resources {
+			for model, suspendEnd := range models {
+				if now.Before(suspendEnd) {
+					suspendedModels = append(suspendedModels, SuspendedModel{API: api, Resource: resource, Model: model, SuspendedUntil: suspendEnd})
+				}
+			}
+		}
+	}
+	sort.Slice(suspendedModels, func(i, j int) bool {
+		a, b := suspendedModels[i], suspendedModels[j]
+		if a.API != b.API {
+			return a.API < b.API
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Model < b.Model
+	})
+	return suspendedModels
+}
+
 // ReactivateSuspendedModels periodically checks and removes expired suspensions
 func (r *ModelBasedRoundRobinTracker) ReactivateSuspendedModels() {
 	for {
@@ -0,0 +1,235 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Revocation rule types, named after the claims they match.
+const (
+	RevocationRuleSubject     = "sub"
+	RevocationRuleClientID    = "client_id"
+	RevocationRuleApplication = "application"
+)
+
+// RevocationRule revokes every token of a subject, client or application that was issued before a time.
+type RevocationRule struct {
+	// Type is the kind of value the rule matches.
+	Type string `json:"type"`
+	// Value is the subject, client ID or application UUID of the revoked tokens.
+	Value string `json:"value"`
+	// Issuer is the issuer of the revoked tokens of a subject, as subjects are only unique per issuer.
+	Issuer string `json:"issuer,omitempty"`
+	// IssuedBefore is the unix time the tokens must have been issued before to be revoked.
+	IssuedBefore int64 `json:"issuedBefore"`
+	// Expiry is the unix time after which every token the rule revokes has expired.
+	Expiry int64 `json:"expiry"`
+}
+
+// Validate checks that the rule can be evaluated.
+func (r *RevocationRule) Validate() error {
+	switch r.Type {
+	case RevocationRuleSubject, RevocationRuleClientID, RevocationRuleApplication:
+	default:
+		return fmt.Errorf("unknown revocation rule type %q", r.Type)
+	}
+	if r.Value == "" {
+		return fmt.Errorf("revocation rule of type %s has no value", r.Type)
+	}
+	if r.Type == RevocationRuleSubject && r.Issuer == "" {
+		return fmt.Errorf("revocation rule for subject %s has no issuer", r.Value)
+	}
+	if r.IssuedBefore <= 0 || r.Expiry <= 0 {
+		return fmt.Errorf("revocation rule %s=%s must have issuedBefore and expiry times", r.Type, r.Value)
+	}
+	return nil
+}
+
+// ParseRevocationRule parses and validates a revocation rule.
+func ParseRevocationRule(data string) (*RevocationRule, error) {
+	var rule RevocationRule
+	if err := json.Unmarshal([]byte(data), &rule); err != nil {
+		return nil, fmt.Errorf("error parsing revocation rule: %w", err)
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// revocationRuleKey identifies the tokens a rule of a type revokes. The issuer is only set for subjects.
+type revocationRuleKey struct {
+	issuer string
+	value  string
+}
+
+func (r *RevocationRule) key() revocationRuleKey {
+	if r.Type != RevocationRuleSubject {
+		return revocationRuleKey{value: r.Value}
+	}
+	return revocationRuleKey{issuer: r.Issuer, value: r.Value}
+}
+
+// RevocationRuleStore stores the rules that revoke tokens in bulk.
+type RevocationRuleStore struct {
+	rules map[string]map[revocationRuleKey]*RevocationRule // type -> key -> rule
+
+	mutex sync.RWMutex
+}
+
+// NewRevocationRuleStore creates a new instance of RevocationRuleStore.
+func NewRevocationRuleStore() *RevocationRuleStore {
+	return &RevocationRuleStore{
+		rules: make(map[string]map[revocationRuleKey]*RevocationRule),
+	}
+}
+
+// AddRule adds a rule to the store, replacing the rule of the same type, issuer and value.
+func (r *RevocationRuleStore) AddRule(rule *RevocationRule) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.rules[rule.Type] == nil {
+		r.rules[rule.Type] = make(map[revocationRuleKey]*RevocationRule)
+	}
+	r.rules[rule.Type][rule.key()] = rule
+}
+
+// MatchClaims returns the rule that revokes the token with the given claims, if any. A token
+// without an issued at claim is revoked by every rule matching it, as its age cannot be told.
+// Application rules match the application claim and the applications the client_id and azp
+// claims are mapped to by resolveApplication, which returns "" for an unknown client.
+func (r *RevocationRuleStore) MatchClaims(claims map[string]interface{}, resolveApplication func(clientID string) string) *RevocationRule {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if len(r.rules) == 0 || claims == nil {
+		return nil
+	}
+	issuedAt, hasIssuedAt := numericClaim(claims["iat"])
+	now := time.Now().Unix()
+	match := func(ruleType string, key revocationRuleKey) *RevocationRule {
+		if key.value == "" {
+			return nil
+		}
+		rule, ok := r.rules[ruleType][key]
+		if !ok || rule.Expiry <= now || (hasIssuedAt && issuedAt >= rule.IssuedBefore) {
+			return nil
+		}
+		return rule
+	}
+	subject := revocationRuleKey{issuer: stringClaim(claims["iss"]), value: stringClaim(claims["sub"])}
+	if rule := match(RevocationRuleSubject, subject); rule != nil {
+		return rule
+	}
+	clientIDs := []string{stringClaim(claims["client_id"]), stringClaim(claims["azp"])}
+	for _, clientID := range clientIDs {
+		if rule := match(RevocationRuleClientID, revocationRuleKey{value: clientID}); rule != nil {
+			return rule
+		}
+	}
+	if _, ok := r.rules[RevocationRuleApplication]; !ok {
+		return nil
+	}
+	if application, ok := claims["application"].(map[string]interface{}); ok {
+		if rule := match(RevocationRuleApplication, revocationRuleKey{value: stringClaim(application["uuid"])}); rule != nil {
+			return rule
+		}
+	}
+	if resolveApplication == nil {
+		return nil
+	}
+	for _, clientID := range clientIDs {
+		if clientID == "" {
+			continue
+		}
+		if rule := match(RevocationRuleApplication, revocationRuleKey{value: resolveApplication(clientID)}); rule != nil {
+			return rule
+		}
+	}
+	return nil
+}
+
+// ListRules returns the rules that have not expired.
+func (r *RevocationRuleStore) ListRules() []RevocationRule {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	now := time.Now().Unix()
+	rules := make([]RevocationRule, 0)
+	for _, values := range r.rules {
+		for _, rule := range values {
+			if rule.Expiry > now {
+				rules = append(rules, *rule)
+			}
+		}
+	}
+	return rules
+}
+
+// removeExpiredRules removes all expired rules from the store.
+func (r *RevocationRuleStore) removeExpiredRules() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now().Unix()
+	for ruleType, values := range r.rules {
+		for key, rule := range values {
+			if rule.Expiry <= now {
+				delete(values, key)
+			}
+		}
+		if len(values) == 0 {
+			delete(r.rules, ruleType)
+		}
+	}
+}
+
+// StartRevocationRuleStoreCleanup starts a goroutine to remove expired rules from the store periodically.
+func (r *RevocationRuleStore) StartRevocationRuleStoreCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			r.removeExpiredRules()
+		}
+	}()
+}
+
+func stringClaim(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// numericClaim reads a numeric date claim, which arrives as a float64 from the JWT metadata.
+func numericClaim(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
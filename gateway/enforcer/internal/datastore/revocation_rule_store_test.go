@@ -0,0 +1,93 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package datastore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevocationRuleStore_MatchClaims(t *testing.T) {
+	now := time.Now().Unix()
+	expiry := now + 3600
+	store := NewRevocationRuleStore()
+	store.AddRule(&RevocationRule{Type: RevocationRuleSubject, Value: "alice", Issuer: "idp", IssuedBefore: now, Expiry: expiry})
+	store.AddRule(&RevocationRule{Type: RevocationRuleClientID, Value: "compromised-client", IssuedBefore: now, Expiry: expiry})
+	store.AddRule(&RevocationRule{Type: RevocationRuleApplication, Value: "app-uuid", IssuedBefore: now, Expiry: expiry})
+	store.AddRule(&RevocationRule{Type: RevocationRuleSubject, Value: "expired", Issuer: "idp", IssuedBefore: now, Expiry: now - 1})
+	resolveApplication := func(clientID string) string {
+		if clientID == "app-client" {
+			return "app-uuid"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		revoked bool
+	}{
+		{"subject issued before", map[string]interface{}{"iss": "idp", "sub": "alice", "iat": float64(now - 60)}, true},
+		{"subject issued after", map[string]interface{}{"iss": "idp", "sub": "alice", "iat": float64(now + 60)}, false},
+		{"subject without iat", map[string]interface{}{"iss": "idp", "sub": "alice"}, true},
+		{"subject of another issuer", map[string]interface{}{"iss": "other-idp", "sub": "alice", "iat": float64(now - 60)}, false},
+		{"other subject", map[string]interface{}{"iss": "idp", "sub": "bob", "iat": float64(now - 60)}, false},
+		{"client id", map[string]interface{}{"sub": "bob", "client_id": "compromised-client", "iat": float64(now - 60)}, true},
+		{"authorized party", map[string]interface{}{"sub": "bob", "azp": "compromised-client", "iat": float64(now - 60)}, true},
+		{"application", map[string]interface{}{"sub": "bob", "application": map[string]interface{}{"uuid": "app-uuid"}, "iat": float64(now - 60)}, true},
+		{"client id of application", map[string]interface{}{"sub": "bob", "client_id": "app-client", "iat": float64(now - 60)}, true},
+		{"authorized party of application", map[string]interface{}{"sub": "bob", "azp": "app-client", "iat": float64(now - 60)}, true},
+		{"client id of another application", map[string]interface{}{"sub": "bob", "client_id": "other-client", "iat": float64(now - 60)}, false},
+		{"expired rule", map[string]interface{}{"iss": "idp", "sub": "expired", "iat": float64(now - 60)}, false},
+		{"no claims", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if revoked := store.MatchClaims(tt.claims, resolveApplication) != nil; revoked != tt.revoked {
+				t.Errorf("expected revoked=%v, got %v", tt.revoked, revoked)
+			}
+		})
+	}
+
+	store.removeExpiredRules()
+	if rules := store.ListRules(); len(rules) != 3 {
+		t.Errorf("expected the expired rule to be removed, got %v", rules)
+	}
+}
+
+func TestParseRevocationRule(t *testing.T) {
+	rule, err := ParseRevocationRule(`{"type": "client_id", "value": "client", "issuedBefore": 1700000000, "expiry": 1700003600}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Type != RevocationRuleClientID || rule.Value != "client" || rule.IssuedBefore != 1700000000 {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+	invalid := []string{
+		`{"type": "email", "value": "alice@example.com", "issuedBefore": 1700000000, "expiry": 1700003600}`,
+		`{"type": "sub", "issuedBefore": 1700000000, "expiry": 1700003600}`,
+		`{"type": "sub", "value": "alice", "issuedBefore": 1700000000, "expiry": 1700003600}`,
+		`{"type": "sub", "value": "alice", "issuer": "idp", "expiry": 1700003600}`,
+		`sub=alice`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseRevocationRule(data); err == nil {
+			t.Errorf("expected %s to be invalid", data)
+		}
+	}
+}
@@ -1,50 +1,136 @@
 package datastore
 
 import (
+	"hash/fnv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// RevokedJTIStore stores revoked JTIs.
-type RevokedJTIStore struct {
+const (
+	// revokedJTIStoreShards is the number of shards the revoked JTIs are spread across, so that
+	// adding revoked tokens does not block the token checks of unrelated requests.
+	revokedJTIStoreShards = 32
+	// minBloomFilterCapacity is the smallest number of JTIs the bloom filter is sized for.
+	minBloomFilterCapacity = 1024
+)
+
+// revokedJTIShard holds a part of the revoked JTIs.
+type revokedJTIShard struct {
 	revokedJTIs map[string]time.Time // JTI -> expiry time
+	mutex       sync.RWMutex
+}
+
+// RevokedJTIStore stores revoked JTIs. The JTIs are checked against a bloom filter before
+// the store is looked up, so the tokens that were never revoked are accepted without locking
+// even when the revocation set is very large.
+type RevokedJTIStore struct {
+	shards [revokedJTIStoreShards]revokedJTIShard
+	count  atomic.Int64
 
-	mutex sync.RWMutex
+	filter atomic.Pointer[bloomFilter]
+	// filterMutex is held for reading while a JTI is added, and for writing while the filter is
+	// rebuilt, so that no JTI misses the rebuilt filter.
+	filterMutex sync.RWMutex
 }
 
 // NewRevokedJTIStore creates a new instance of RevokedJTIStore.
 func NewRevokedJTIStore() *RevokedJTIStore {
-	return &RevokedJTIStore{
-		revokedJTIs: make(map[string]time.Time),
+	r := &RevokedJTIStore{}
+	for i := range r.shards {
+		r.shards[i].revokedJTIs = make(map[string]time.Time)
 	}
+	r.filter.Store(newBloomFilter(minBloomFilterCapacity))
+	return r
+}
+
+// shard returns the shard holding the JTI.
+func (r *RevokedJTIStore) shard(jti string) *revokedJTIShard {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(jti))
+	return &r.shards[hash.Sum32()%revokedJTIStoreShards]
 }
 
 // AddJTI adds a JTI to the store with the given expiry time.
 func (r *RevokedJTIStore) AddJTI(jti string, expiry time.Time) {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	r.revokedJTIs[jti] = expiry
+	r.filterMutex.RLock()
+	shard := r.shard(jti)
+	shard.mutex.Lock()
+	_, exists := shard.revokedJTIs[jti]
+	shard.revokedJTIs[jti] = expiry
+	shard.mutex.Unlock()
+	if !exists {
+		r.count.Add(1)
+	}
+	filter := r.filter.Load()
+	filter.add(jti)
+	full := r.count.Load() > int64(filter.capacity)
+	r.filterMutex.RUnlock()
+	if full {
+		r.rebuildFilter(true)
+	}
 }
 
 // IsJTIRevoked checks if the given JTI is revoked.
 func (r *RevokedJTIStore) IsJTIRevoked(jti string) bool {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-	_, ok := r.revokedJTIs[jti]
+	if !r.filter.Load().mayContain(jti) {
+		return false
+	}
+	shard := r.shard(jti)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	_, ok := shard.revokedJTIs[jti]
 	return ok
 }
 
+// Len returns the number of JTIs in the store.
+func (r *RevokedJTIStore) Len() int {
+	return int(r.count.Load())
+}
+
 // removeExpiredJTIs removes all expired JTIs from the store.
 func (r *RevokedJTIStore) removeExpiredJTIs() {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	for jti, expiry := range r.revokedJTIs {
-		if time.Now().After(expiry) {
-			delete(r.revokedJTIs, jti)
+	now := time.Now()
+	removed := 0
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mutex.Lock()
+		for jti, expiry := range shard.revokedJTIs {
+			if now.After(expiry) {
+				delete(shard.revokedJTIs, jti)
+				removed++
+			}
 		}
+		shard.mutex.Unlock()
+	}
+	r.count.Add(int64(-removed))
+	if removed > 0 {
+		// Bits cannot be cleared from a bloom filter, so it is rebuilt from the remaining JTIs.
+		r.rebuildFilter(false)
 	}
 }
 
+// rebuildFilter replaces the bloom filter with one sized for twice the current number of JTIs.
+// When onlyIfFull is set, the filter is kept if another caller already grew it.
+func (r *RevokedJTIStore) rebuildFilter(onlyIfFull bool) {
+	r.filterMutex.Lock()
+	defer r.filterMutex.Unlock()
+	count := int(r.count.Load())
+	if onlyIfFull && count <= r.filter.Load().capacity {
+		return
+	}
+	filter := newBloomFilter(max(2*count, minBloomFilterCapacity))
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mutex.RLock()
+		for jti := range shard.revokedJTIs {
+			filter.add(jti)
+		}
+		shard.mutex.RUnlock()
+	}
+	r.filter.Store(filter)
+}
+
 // StartRevokedJTIStoreCleanup starts a goroutine to remove expired JTIs from the store periodically.
 func (r *RevokedJTIStore) StartRevokedJTIStoreCleanup(interval time.Duration) {
 	ticker := time.NewTicker(interval)
@@ -54,3 +140,20 @@ func (r *RevokedJTIStore) StartRevokedJTIStoreCleanup(interval time.Duration) {
 		}
 	}()
 }
+
+// ListRevokedJTIs returns the revoked JTIs that have not expired, with their expiry times.
+func (r *RevokedJTIStore) ListRevokedJTIs() map[string]time.Time {
+	revokedJTIs := make(map[string]time.Time, r.Len())
+	now := time.Now()
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mutex.RLock()
+		for jti, expiry := range shard.revokedJTIs {
+			if now.Before(expiry) {
+				revokedJTIs[jti] = expiry
+			}
+		}
+		shard.mutex.RUnlock()
+	}
+	return revokedJTIs
+}
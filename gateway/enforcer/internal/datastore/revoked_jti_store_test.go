@@ -0,0 +1,99 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package datastore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRevokedJTIStore(t *testing.T) {
+	store := NewRevokedJTIStore()
+	expiry := time.Now().Add(time.Hour)
+	// Enough JTIs to grow the bloom filter a few times.
+	for i := 0; i < 5*minBloomFilterCapacity; i++ {
+		store.AddJTI(fmt.Sprintf("jti-%d", i), expiry)
+	}
+	store.AddJTI("jti-0", expiry)
+	if store.Len() != 5*minBloomFilterCapacity {
+		t.Errorf("expected %d JTIs, got %d", 5*minBloomFilterCapacity, store.Len())
+	}
+	if capacity := store.filter.Load().capacity; capacity < store.Len() {
+		t.Errorf("expected the bloom filter to grow with the store, got capacity %d", capacity)
+	}
+	for i := 0; i < 5*minBloomFilterCapacity; i++ {
+		if !store.IsJTIRevoked(fmt.Sprintf("jti-%d", i)) {
+			t.Fatalf("expected jti-%d to be revoked", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if store.IsJTIRevoked(fmt.Sprintf("other-%d", i)) {
+			t.Fatalf("expected other-%d not to be revoked", i)
+		}
+		if store.filter.Load().mayContain(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 500 {
+		t.Errorf("expected the bloom filter to skip most lookups, got %d false positives", falsePositives)
+	}
+}
+
+func TestRevokedJTIStore_RemoveExpiredJTIs(t *testing.T) {
+	store := NewRevokedJTIStore()
+	for i := 0; i < 3*minBloomFilterCapacity; i++ {
+		store.AddJTI(fmt.Sprintf("expired-%d", i), time.Now().Add(-time.Minute))
+	}
+	store.AddJTI("valid", time.Now().Add(time.Hour))
+	store.removeExpiredJTIs()
+
+	if store.Len() != 1 || !store.IsJTIRevoked("valid") || store.IsJTIRevoked("expired-0") {
+		t.Errorf("expected only the valid JTI to remain, got %v", store.ListRevokedJTIs())
+	}
+	if capacity := store.filter.Load().capacity; capacity != minBloomFilterCapacity {
+		t.Errorf("expected the bloom filter to shrink, got capacity %d", capacity)
+	}
+}
+
+func TestRevokedJTIStore_ConcurrentAccess(t *testing.T) {
+	store := NewRevokedJTIStore()
+	expiry := time.Now().Add(time.Hour)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				jti := fmt.Sprintf("jti-%d-%d", w, i)
+				store.AddJTI(jti, expiry)
+				// A JTI must be revoked as soon as it was added, even while the filter grows.
+				if !store.IsJTIRevoked(jti) {
+					t.Errorf("expected %s to be revoked", jti)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if store.Len() != 8000 {
+		t.Errorf("expected 8000 JTIs, got %d", store.Len())
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"sync"
 
+	subscription "github.com/wso2/apk/adapter/pkg/discovery/api/wso2/discovery/subscription"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/util"
@@ -38,6 +39,7 @@ type RoutePolicyAndMetadataDataStore struct {
 	mu            sync.RWMutex
 	commonControllerRestBaseURL string
 	cfg *config.Server
+	jwtIssuerStore *JWTIssuerStore
 }
 
 // NewRoutePolicyAndMetadataDataStore initializes and returns a new datastore instance.
@@ -50,6 +52,33 @@ func NewRoutePolicyAndMetadataDataStore(cfg *config.Server) *RoutePolicyAndMetad
 	}
 }
 
+// TrackJWTIssuers keeps the JWT issuer store in sync with the JWT issuers of the route metadata.
+func (ds *RoutePolicyAndMetadataDataStore) TrackJWTIssuers(jwtIssuerStore *JWTIssuerStore) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.jwtIssuerStore = jwtIssuerStore
+	ds.refreshJWTIssuers()
+}
+
+// refreshJWTIssuers replaces the JWT issuers of the tracking store with those of the route metadata.
+// The caller must hold the lock.
+func (ds *RoutePolicyAndMetadataDataStore) refreshJWTIssuers() {
+	if ds.jwtIssuerStore == nil {
+		return
+	}
+	jwtIssuers := make([]*subscription.JWTIssuer, 0)
+	for _, metadata := range ds.routeMetadata {
+		for _, jwtIssuer := range metadata.Spec.JWTIssuers {
+			jwtIssuers = append(jwtIssuers, &subscription.JWTIssuer{
+				Name:         jwtIssuer.Name,
+				Organization: metadata.Spec.API.Organization,
+				Issuer:       jwtIssuer.Issuer,
+			})
+		}
+	}
+	ds.jwtIssuerStore.AddJWTIssuers(jwtIssuers)
+}
+
 // AddRoutePolicy adds or updates a RoutePolicy in the datastore.
 func (ds *RoutePolicyAndMetadataDataStore) AddRoutePolicy(policy *dpv2alpha1.RoutePolicy) {
 	ds.cfg.Logger.Sugar().Debugf("Adding/Updating RoutePolicy: %s/%s", policy.Namespace, policy.Name)
@@ -94,6 +123,7 @@ func (ds *RoutePolicyAndMetadataDataStore) AddRouteMetadata(metadata *dpv2alpha1
 		Namespace: metadata.Namespace,
 	}
 	ds.routeMetadata[namespacedName.String()] = metadata
+	ds.refreshJWTIssuers()
 }
 
 // GetRouteMetadata returns a RouteMetadata by UUID. Returns nil if not found.
@@ -113,6 +143,7 @@ func (ds *RoutePolicyAndMetadataDataStore) DeleteRouteMetadata(namespacedName st
 	defer ds.mu.Unlock()
 	if _, exists := ds.routeMetadata[namespacedName]; exists {
 		delete(ds.routeMetadata, namespacedName)
+		ds.refreshJWTIssuers()
 		return nil
 	}
 	return errors.New("route metadata not found")
@@ -210,3 +241,25 @@ func (ds *RoutePolicyAndMetadataDataStore) GetRouteMetadataCount() int {
 	defer ds.mu.RUnlock()
 	return len(ds.routeMetadata)
 }
+
+// ListRoutePolicies returns the route policies keyed by their namespaced names.
+func (ds *RoutePolicyAndMetadataDataStore) ListRoutePolicies() map[string]*dpv2alpha1.RoutePolicy {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	policies := make(map[string]*dpv2alpha1.RoutePolicy, len(ds.routePolicies))
+	for namespacedName, policy := range ds.routePolicies {
+		policies[namespacedName] = policy
+	}
+	return policies
+}
+
+// ListRouteMetadata returns the route metadata keyed by their namespaced names.
+func (ds *RoutePolicyAndMetadataDataStore) ListRouteMetadata() map[string]*dpv2alpha1.RouteMetadata {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	metadata := make(map[string]*dpv2alpha1.RouteMetadata, len(ds.routeMetadata))
+	for namespacedName, routeMetadata := range ds.routeMetadata {
+		metadata[namespacedName] = routeMetadata
+	}
+	return metadata
+}
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"sort"
 	"sync"
 
 	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
@@ -420,3 +421,75 @@ func (ds *SubscriptionApplicationDataStore) GetTotalSubscriptionCount() int {
 	}
 	return count
 }
+
+// ListApplications returns the applications of the organization, or of every organization when it is empty.
+func (ds *SubscriptionApplicationDataStore) ListApplications(org string) []*subscription_model.Application {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	applications := make([]*subscription_model.Application, 0)
+	for orgID, orgApplications := range ds.applications {
+		if org != "" && orgID != org {
+			continue
+		}
+		for _, application := range orgApplications {
+			applications = append(applications, application)
+		}
+	}
+	sort.Slice(applications, func(i, j int) bool { return applications[i].UUID < applications[j].UUID })
+	return applications
+}
+
+// ListApplicationMappings returns the application mappings of the organization, or of every organization when it is empty.
+func (ds *SubscriptionApplicationDataStore) ListApplicationMappings(org string) []*subscription_model.ApplicationMapping {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	mappings := make([]*subscription_model.ApplicationMapping, 0)
+	for orgID, orgMappings := range ds.applicationMappings {
+		if org != "" && orgID != org {
+			continue
+		}
+		for _, applicationMappings := range orgMappings {
+			for _, mapping := range applicationMappings {
+				mappings = append(mappings, mapping)
+			}
+		}
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].UUID < mappings[j].UUID })
+	return mappings
+}
+
+// ListApplicationKeyMappings returns the application key mappings of the organization, or of every organization when it is empty.
+func (ds *SubscriptionApplicationDataStore) ListApplicationKeyMappings(org string) []*subscription_model.ApplicationKeyMapping {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	keyMappings := make([]*subscription_model.ApplicationKeyMapping, 0)
+	for orgID, orgKeyMappings := range ds.applicationKeyMappings {
+		if org != "" && orgID != org {
+			continue
+		}
+		for _, keyMapping := range orgKeyMappings {
+			keyMappings = append(keyMappings, keyMapping)
+		}
+	}
+	sort.Slice(keyMappings, func(i, j int) bool {
+		return keyMappings[i].ApplicationIdentifier < keyMappings[j].ApplicationIdentifier
+	})
+	return keyMappings
+}
+
+// ListSubscriptions returns the subscriptions of the organization, or of every organization when it is empty.
+func (ds *SubscriptionApplicationDataStore) ListSubscriptions(org string) []*subscription_model.Subscription {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	subscriptions := make([]*subscription_model.Subscription, 0)
+	for orgID, orgSubscriptions := range ds.subscriptions {
+		if org != "" && orgID != org {
+			continue
+		}
+		for _, subscription := range orgSubscriptions {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].UUID < subscriptions[j].UUID })
+	return subscriptions
+}
@@ -35,13 +35,16 @@ import (
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/datastore"
 	"github.com/wso2/apk/gateway/enforcer/internal/mediation"
+	"github.com/wso2/apk/gateway/enforcer/internal/ratelimit"
 
 	v31 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/tracing"
 	"github.com/wso2/apk/gateway/enforcer/internal/util"
+	"go.opentelemetry.io/otel/trace"
 	types "k8s.io/apimachinery/pkg/types"
 
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
@@ -62,8 +65,9 @@ type ExternalProcessingServer struct {
 	subscriptionApplicationDatastore *datastore.SubscriptionApplicationDataStore
 	routePolicyAndMetadataDatastore  *datastore.RoutePolicyAndMetadataDataStore
 	// ratelimitHelper                  *ratelimit.AIRatelimitHelper
-	cfg             *config.Server
-	revokedJTIStore *datastore.RevokedJTIStore
+	cfg                 *config.Server
+	revokedJTIStore     *datastore.RevokedJTIStore
+	revocationRuleStore *datastore.RevocationRuleStore
 }
 
 const (
@@ -108,7 +112,8 @@ const (
 func StartExternalProcessingServer(cfg *config.Server,
 	subAppDatastore *datastore.SubscriptionApplicationDataStore,
 	routePolicyAndMetadataDS *datastore.RoutePolicyAndMetadataDataStore,
-	revokedJTIStore *datastore.RevokedJTIStore) {
+	revokedJTIStore *datastore.RevokedJTIStore,
+	revocationRuleStore *datastore.RevocationRuleStore) {
 	kaParams := keepalive.ServerParameters{
 		Time:    time.Duration(cfg.ExternalProcessingKeepAliveTime) * time.Hour, // Ping the client if it is idle for 2 hours
 		Timeout: 20 * time.Second,
@@ -129,7 +134,8 @@ func StartExternalProcessingServer(cfg *config.Server,
 			subAppDatastore,
 			routePolicyAndMetadataDS,
 			cfg,
-			revokedJTIStore})
+			revokedJTIStore,
+			revocationRuleStore})
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.ExternalProcessingPort))
 	if err != nil {
 		cfg.Logger.Error(err, fmt.Sprintf("Failed to listen on port: %s", cfg.ExternalProcessingPort))
@@ -160,6 +166,7 @@ func StartExternalProcessingServer(cfg *config.Server,
 // If an unknown request type is received, it logs the unknown request type.
 func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalProcessor_ProcessServer) error {
 	ctx := srv.Context()
+	traceCtx := ctx
 	requestConfigHolder := &requestconfig.Holder{}
 	for {
 		select {
@@ -176,6 +183,15 @@ func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalPro
 		}
 
 		resp := &envoy_service_proc_v3.ProcessingResponse{}
+		// Continue the trace Envoy propagates in the request headers for all the phases of the request.
+		if requestHeaders := req.GetRequestHeaders(); requestHeaders != nil {
+			traceCtx = tracing.Extract(ctx, requestHeaders.GetHeaders())
+		}
+		phase := string(processingPhaseOf(req))
+		phaseCtx, phaseSpan := tracing.Start(traceCtx, "ext_proc "+phase,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(tracing.AttributePhase.String(phase)))
+		requestConfigHolder.TraceContext = phaseCtx
 
 		// log req.Attributes
 		s.log.Sugar().Debug(fmt.Sprintf("Attributes: %+v", req.Attributes))
@@ -260,28 +276,19 @@ func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalPro
 			}
 
 			// Token revocation
-			if s.revokedJTIStore != nil {
-				if jti, ok := requestConfigHolder.JWTAuthnPayloaClaims["jti"]; ok && jti != nil {
-					if jtiStr, ok := jti.(string); ok {
-						if s.revokedJTIStore.IsJTIRevoked(jtiStr) {
-							s.log.Sugar().Debug("Token is revoked")
-							resp.Response = &envoy_service_proc_v3.ProcessingResponse_ImmediateResponse{
-								ImmediateResponse: &envoy_service_proc_v3.ImmediateResponse{
-									Status: &v32.HttpStatus{
-										Code: v32.StatusCode_Unauthorized,
-									},
-									Body:    []byte("Unauthorized: Token is revoked"),
-									Details: "revoked_token",
-								},
-							}
-							return srv.Send(resp)
-						}
-					} else {
-						s.log.Sugar().Debug("JTI claim is not a string")
-					}
-				} else {
-					s.log.Sugar().Debug("JTI claim not found")
+			if s.isTokenRevoked(requestConfigHolder) {
+				resp.Response = &envoy_service_proc_v3.ProcessingResponse_ImmediateResponse{
+					ImmediateResponse: &envoy_service_proc_v3.ImmediateResponse{
+						Status: &v32.HttpStatus{
+							Code: v32.StatusCode_Unauthorized,
+						},
+						Body:    []byte("Unauthorized: Token is revoked"),
+						Details: "revoked_token",
+					},
 				}
+				err := srv.Send(resp)
+				endPhaseSpan(phaseSpan, requestConfigHolder, resp, err)
+				return err
 			}
 
 			for key, value := range requestConfigHolder.RequestHeaders.Headers.Headers {
@@ -293,40 +300,16 @@ func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalPro
 			responseBodyMode := v31.ProcessingMode_NONE
 			if requestConfigHolder.RoutePolicy != nil {
 				s.log.Sugar().Debugf("RoutePolicy: %+v", requestConfigHolder.RoutePolicy)
-				if requestConfigHolder.RoutePolicy.Spec.RequestMediation != nil {
-					s.log.Sugar().Debugf("Request Mediation Policies: %+v", requestConfigHolder.RoutePolicy.Spec.RequestMediation)
-					for _, policy := range requestConfigHolder.RoutePolicy.Spec.RequestMediation {
-						reqBodyProcessing := mediation.MediationAndRequestBodyProcessing[policy.PolicyName]
-						if reqBodyProcessing {
-							requestBodyMode = v31.ProcessingMode_BUFFERED
-						}
-						s.log.Sugar().Debugf("Processing Mode for Policy %s: RequestBodyMode: %s, ResponseHeaderMode: %s, ResponseBodyMode: %s",
-							policy.PolicyName,
-							requestBodyMode.String(),
-							responseHeaderMode.String(),
-							responseBodyMode.String())
-					}
-				} else {
-					s.log.Sugar().Debugf("No Request Mediation Policies found in RoutePolicy")
-				}
-				if requestConfigHolder.RoutePolicy.Spec.ResponseMediation != nil {
-					s.log.Sugar().Debugf("Response Mediation Policies: %+v", requestConfigHolder.RoutePolicy.Spec.ResponseMediation)
-					for _, policy := range requestConfigHolder.RoutePolicy.Spec.ResponseMediation {
-						if respHeaderProcessing, ok := mediation.MediationAndResponseHeaderProcessing[policy.PolicyName]; ok && respHeaderProcessing {
-							responseHeaderMode = v31.ProcessingMode_SEND
-						}
-						if respBodyProcessing, ok := mediation.MediationAndResponseBodyProcessing[policy.PolicyName]; ok && respBodyProcessing {
-							responseBodyMode = v31.ProcessingMode_BUFFERED
-						}
-
-						s.log.Sugar().Debugf("Processing Mode for Policy %s: ResponseHeaderMode: %s, ResponseBodyMode: %s",
-							policy.PolicyName,
-							responseHeaderMode.String(),
-							responseBodyMode.String())
-					}
-				} else {
-					s.log.Sugar().Debugf("No Response Mediation Policies found in RoutePolicy")
+				// Ask Envoy for the bodies only when the attached policies need them.
+				requestBodyMode = mediation.BodySendMode(requestConfigHolder.RoutePolicy.Spec.RequestMediation, mediation.PhaseRequestBody)
+				if len(mediation.PoliciesForPhase(requestConfigHolder.RoutePolicy.Spec.ResponseMediation, mediation.PhaseResponseHeaders)) > 0 {
+					responseHeaderMode = v31.ProcessingMode_SEND
 				}
+				responseBodyMode = mediation.BodySendMode(requestConfigHolder.RoutePolicy.Spec.ResponseMediation, mediation.PhaseResponseBody)
+				s.log.Sugar().Debugf("Processing Mode: RequestBodyMode: %s, ResponseHeaderMode: %s, ResponseBodyMode: %s",
+					requestBodyMode.String(),
+					responseHeaderMode.String(),
+					responseBodyMode.String())
 			}
 			resp.ModeOverride = &v31.ProcessingMode{
 				RequestBodyMode:    requestBodyMode,
@@ -363,25 +346,23 @@ func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalPro
 				s.log.Sugar().Debugf("RoutePolicy: %+v", requestConfigHolder.RoutePolicy)
 				if requestConfigHolder.RoutePolicy.Spec.RequestMediation != nil {
 					s.log.Sugar().Debugf("Request Mediation Policies: %+v", requestConfigHolder.RoutePolicy.Spec.RequestMediation)
-					for _, policy := range requestConfigHolder.RoutePolicy.Spec.RequestMediation {
-						if mediation.MediationAndRequestHeaderProcessing[policy.PolicyName] {
-							mediation := mediation.CreateMediation(policy)
-							if mediation == nil {
-								s.log.Sugar().Errorf("Failed to create mediation for policy: %+v", policy)
-								continue
-							}
-							mediationResult := mediation.Process(requestConfigHolder)
-							s.log.Sugar().Debugf("Mediation Result: %+v", mediationResult)
-							s.updateRequestConfigBasedOnMediationResults(mediationResult, requestConfigHolder, requestconfig.ProcessingPhaseRequestHeaders)
-							stopProcessingMediations := s.processMediationResultAndPrepareResponse(
-								mediationResult,
-								resp,
-								requestconfig.ProcessingPhaseRequestHeaders,
-								metadata)
-							if stopProcessingMediations {
-								s.log.Sugar().Debug("Stopping further processing of request headers due to immediate response")
-								break
-							}
+					for _, policy := range mediation.PoliciesForPhase(requestConfigHolder.RoutePolicy.Spec.RequestMediation, mediation.PhaseRequestHeaders) {
+						m := mediation.CreateMediation(policy)
+						if m == nil {
+							s.log.Sugar().Errorf("Failed to create mediation for policy: %+v", policy)
+							continue
+						}
+						mediationResult := mediation.ProcessTraced(policy, m, requestConfigHolder)
+						s.log.Sugar().Debugf("Mediation Result: %+v", mediationResult)
+						s.updateRequestConfigBasedOnMediationResults(mediationResult, requestConfigHolder, requestconfig.ProcessingPhaseRequestHeaders)
+						stopProcessingMediations := s.processMediationResultAndPrepareResponse(
+							mediationResult,
+							resp,
+							requestconfig.ProcessingPhaseRequestHeaders,
+							metadata)
+						if stopProcessingMediations {
+							s.log.Sugar().Debug("Stopping further processing of request headers due to immediate response")
+							break
 						}
 					}
 				}
@@ -406,25 +387,23 @@ func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalPro
 				s.log.Sugar().Debugf("RoutePolicy: %+v", requestConfigHolder.RoutePolicy)
 				if requestConfigHolder.RoutePolicy.Spec.RequestMediation != nil {
 					s.log.Sugar().Debugf("Request Mediation Policies: %+v", requestConfigHolder.RoutePolicy.Spec.RequestMediation)
-					for _, policy := range requestConfigHolder.RoutePolicy.Spec.RequestMediation {
-						if mediation.MediationAndRequestBodyProcessing[policy.PolicyName] {
-							mediation := mediation.CreateMediation(policy)
-							if mediation == nil {
-								s.log.Sugar().Errorf("Failed to create mediation for policy: %+v", policy)
-								continue
-							}
-							mediationResult := mediation.Process(requestConfigHolder)
-							s.log.Sugar().Debugf("Mediation Result: %+v", mediationResult)
-							s.updateRequestConfigBasedOnMediationResults(mediationResult, requestConfigHolder, requestconfig.ProcessingPhaseRequestBody)
-							stopProcessingMediations := s.processMediationResultAndPrepareResponse(
-								mediationResult,
-								resp,
-								requestconfig.ProcessingPhaseRequestBody,
-								metadata)
-							if stopProcessingMediations {
-								s.log.Sugar().Debug("Stopping further processing of request headers due to immediate response")
-								break
-							}
+					for _, policy := range mediation.PoliciesForPhase(requestConfigHolder.RoutePolicy.Spec.RequestMediation, mediation.PhaseRequestBody) {
+						m := mediation.CreateMediation(policy)
+						if m == nil {
+							s.log.Sugar().Errorf("Failed to create mediation for policy: %+v", policy)
+							continue
+						}
+						mediationResult := mediation.ProcessTraced(policy, m, requestConfigHolder)
+						s.log.Sugar().Debugf("Mediation Result: %+v", mediationResult)
+						s.updateRequestConfigBasedOnMediationResults(mediationResult, requestConfigHolder, requestconfig.ProcessingPhaseRequestBody)
+						stopProcessingMediations := s.processMediationResultAndPrepareResponse(
+							mediationResult,
+							resp,
+							requestconfig.ProcessingPhaseRequestBody,
+							metadata)
+						if stopProcessingMediations {
+							s.log.Sugar().Debug("Stopping further processing of request headers due to immediate response")
+							break
 						}
 					}
 				}
@@ -449,29 +428,37 @@ func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalPro
 				s.log.Sugar().Debugf("RoutePolicy: %+v", requestConfigHolder.RoutePolicy)
 				if requestConfigHolder.RoutePolicy.Spec.ResponseMediation != nil {
 					s.log.Sugar().Debugf("Request Mediation Policies: %+v", requestConfigHolder.RoutePolicy.Spec.RequestMediation)
-					for _, policy := range requestConfigHolder.RoutePolicy.Spec.ResponseMediation {
-						if mediation.MediationAndResponseHeaderProcessing[policy.PolicyName] {
-							mediation := mediation.CreateMediation(policy)
-							if mediation == nil {
-								s.log.Sugar().Errorf("Failed to create mediation for policy: %+v", policy)
-								continue
-							}
-							mediationResult := mediation.Process(requestConfigHolder)
-							s.log.Sugar().Debugf("Mediation Result: %+v", mediationResult)
-							s.updateRequestConfigBasedOnMediationResults(mediationResult, requestConfigHolder, requestconfig.ProcessingPhaseResponseHeaders)
-							stopProcessingMediations := s.processMediationResultAndPrepareResponse(
-								mediationResult,
-								resp,
-								requestconfig.ProcessingPhaseResponseHeaders,
-								metadata)
-							if stopProcessingMediations {
-								s.log.Sugar().Debug("Stopping further processing of request headers due to immediate response")
-								break
-							}
+					for _, policy := range mediation.PoliciesForPhase(requestConfigHolder.RoutePolicy.Spec.ResponseMediation, mediation.PhaseResponseHeaders) {
+						m := mediation.CreateMediation(policy)
+						if m == nil {
+							s.log.Sugar().Errorf("Failed to create mediation for policy: %+v", policy)
+							continue
+						}
+						mediationResult := mediation.ProcessTraced(policy, m, requestConfigHolder)
+						s.log.Sugar().Debugf("Mediation Result: %+v", mediationResult)
+						s.updateRequestConfigBasedOnMediationResults(mediationResult, requestConfigHolder, requestconfig.ProcessingPhaseResponseHeaders)
+						stopProcessingMediations := s.processMediationResultAndPrepareResponse(
+							mediationResult,
+							resp,
+							requestconfig.ProcessingPhaseResponseHeaders,
+							metadata)
+						if stopProcessingMediations {
+							s.log.Sugar().Debug("Stopping further processing of request headers due to immediate response")
+							break
 						}
 					}
 				}
 			}
+			if _, ok := resp.Response.(*envoy_service_proc_v3.ProcessingResponse_ResponseHeaders); ok &&
+				requestConfigHolder.RoutePolicy != nil && ratelimit.IsEventStream(requestConfigHolder.ResponseHeaders) {
+				// Pass event streams through in chunks instead of buffering the whole stream.
+				if mediation.EventStreamBodySendMode(requestConfigHolder.RoutePolicy.Spec.ResponseMediation) == v31.ProcessingMode_STREAMED {
+					s.log.Sugar().Debug("Streaming the event stream response body")
+					resp.ModeOverride = &v31.ProcessingMode{
+						ResponseBodyMode: v31.ProcessingMode_STREAMED,
+					}
+				}
+			}
 
 		case *envoy_service_proc_v3.ProcessingRequest_ResponseBody:
 			s.log.Sugar().Debug("Response Body Flow")
@@ -492,25 +479,23 @@ func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalPro
 				s.log.Sugar().Debugf("RoutePolicy: %+v", requestConfigHolder.RoutePolicy)
 				if requestConfigHolder.RoutePolicy.Spec.ResponseMediation != nil {
 					s.log.Sugar().Debugf("Request Mediation Policies: %+v", requestConfigHolder.RoutePolicy.Spec.RequestMediation)
-					for _, policy := range requestConfigHolder.RoutePolicy.Spec.ResponseMediation {
-						if mediation.MediationAndResponseBodyProcessing[policy.PolicyName] {
-							mediation := mediation.CreateMediation(policy)
-							if mediation == nil {
-								s.log.Sugar().Errorf("Failed to create mediation for policy: %+v", policy)
-								continue
-							}
-							mediationResult := mediation.Process(requestConfigHolder)
-							s.log.Sugar().Debugf("Mediation Result: %+v", mediationResult)
-							s.updateRequestConfigBasedOnMediationResults(mediationResult, requestConfigHolder, requestconfig.ProcessingPhaseResponseBody)
-							stopProcessingMediations := s.processMediationResultAndPrepareResponse(
-								mediationResult,
-								resp,
-								requestconfig.ProcessingPhaseResponseBody,
-								metadata)
-							if stopProcessingMediations {
-								s.log.Sugar().Debug("Stopping further processing of request headers due to immediate response")
-								break
-							}
+					for _, policy := range mediation.PoliciesForPhase(requestConfigHolder.RoutePolicy.Spec.ResponseMediation, mediation.PhaseResponseBody) {
+						m := mediation.CreateMediation(policy)
+						if m == nil {
+							s.log.Sugar().Errorf("Failed to create mediation for policy: %+v", policy)
+							continue
+						}
+						mediationResult := mediation.ProcessTraced(policy, m, requestConfigHolder)
+						s.log.Sugar().Debugf("Mediation Result: %+v", mediationResult)
+						s.updateRequestConfigBasedOnMediationResults(mediationResult, requestConfigHolder, requestconfig.ProcessingPhaseResponseBody)
+						stopProcessingMediations := s.processMediationResultAndPrepareResponse(
+							mediationResult,
+							resp,
+							requestconfig.ProcessingPhaseResponseBody,
+							metadata)
+						if stopProcessingMediations {
+							s.log.Sugar().Debug("Stopping further processing of request headers due to immediate response")
+							break
 						}
 					}
 				}
@@ -528,10 +513,43 @@ func (s *ExternalProcessingServer) Process(srv envoy_service_proc_v3.ExternalPro
 				},
 			},
 		}
-		if err := srv.Send(resp); err != nil {
+		err = srv.Send(resp)
+		if err != nil {
 			s.log.Sugar().Debug(fmt.Sprintf("send error %v", err))
 		}
+		endPhaseSpan(phaseSpan, requestConfigHolder, resp, err)
+	}
+}
+
+// processingPhaseOf returns the processing phase of the request Envoy sent.
+func processingPhaseOf(req *envoy_service_proc_v3.ProcessingRequest) requestconfig.ProcessingPhase {
+	switch req.Request.(type) {
+	case *envoy_service_proc_v3.ProcessingRequest_RequestHeaders:
+		return requestconfig.ProcessingPhaseRequestHeaders
+	case *envoy_service_proc_v3.ProcessingRequest_RequestBody:
+		return requestconfig.ProcessingPhaseRequestBody
+	case *envoy_service_proc_v3.ProcessingRequest_ResponseHeaders:
+		return requestconfig.ProcessingPhaseResponseHeaders
+	case *envoy_service_proc_v3.ProcessingRequest_ResponseBody:
+		return requestconfig.ProcessingPhaseResponseBody
+	default:
+		return "unknown"
+	}
+}
+
+// endPhaseSpan records the route and the immediate response, if any, on the span of the phase and ends it.
+func endPhaseSpan(span trace.Span, requestConfigHolder *requestconfig.Holder, resp *envoy_service_proc_v3.ProcessingResponse, err error) {
+	if attributes := requestConfigHolder.RequestAttributes; attributes != nil {
+		span.SetAttributes(
+			tracing.AttributeRouteName.String(attributes.RouteName),
+			tracing.AttributeRequestID.String(attributes.RequestID))
+	}
+	if immediateResponse := resp.GetImmediateResponse(); immediateResponse != nil {
+		span.SetAttributes(
+			tracing.AttributeImmediateResponse.Bool(true),
+			tracing.AttributeImmediateResponseCode.Int(int(immediateResponse.GetStatus().GetCode())))
 	}
+	tracing.End(span, err)
 }
 
 func (s *ExternalProcessingServer) updateRequestConfigBasedOnMediationResults(mediationResult *mediation.Result, requestConfigHolder *requestconfig.Holder, processingPhase requestconfig.ProcessingPhase) {
@@ -549,6 +567,7 @@ func (s *ExternalProcessingServer) updateRequestConfigBasedOnMediationResults(me
 					s.log.Sugar().Debugf("Removing header: %s", header.Key)
 				}
 			}
+			requestConfigHolder.RequestHeaders.Headers.Headers = headerValues
 		} else if processingPhase == requestconfig.ProcessingPhaseResponseHeaders {
 			for _, header := range requestConfigHolder.ResponseHeaders.Headers.Headers {
 				if !util.Contains(mediationResult.RemoveHeaders, header.Key) {
@@ -560,26 +579,20 @@ func (s *ExternalProcessingServer) updateRequestConfigBasedOnMediationResults(me
 					s.log.Sugar().Debugf("Removing header: %s", header.Key)
 				}
 			}
+			requestConfigHolder.ResponseHeaders.Headers.Headers = headerValues
 		}
-		requestConfigHolder.RequestHeaders.Headers.Headers = headerValues
 	}
 	if len(mediationResult.AddHeaders) > 0 {
 		s.log.Sugar().Debugf("Adding headers: %v", mediationResult.AddHeaders)
 		if processingPhase == requestconfig.ProcessingPhaseRequestHeaders {
 			for key, value := range mediationResult.AddHeaders {
 				s.log.Sugar().Debugf("Adding header: %s: %s", key, value)
-				requestConfigHolder.RequestHeaders.Headers.Headers = append(requestConfigHolder.RequestHeaders.Headers.Headers, &corev3.HeaderValue{
-					Key:      key,
-					RawValue: []byte(value),
-				})
+				requestConfigHolder.RequestHeaders.Headers.Headers = setHeader(requestConfigHolder.RequestHeaders.Headers.Headers, key, value)
 			}
 		} else if processingPhase == requestconfig.ProcessingPhaseResponseHeaders {
 			for key, value := range mediationResult.AddHeaders {
 				s.log.Sugar().Debugf("Adding header: %s: %s", key, value)
-				requestConfigHolder.ResponseHeaders.Headers.Headers = append(requestConfigHolder.ResponseHeaders.Headers.Headers, &corev3.HeaderValue{
-					Key:      key,
-					RawValue: []byte(value),
-				})
+				requestConfigHolder.ResponseHeaders.Headers.Headers = setHeader(requestConfigHolder.ResponseHeaders.Headers.Headers, key, value)
 			}
 		}
 	}
@@ -593,6 +606,22 @@ func (s *ExternalProcessingServer) updateRequestConfigBasedOnMediationResults(me
 	}
 }
 
+// setHeader replaces the header with the key, or appends it, the same way Envoy applies the
+// header mutations of a mediation result.
+func setHeader(headers []*corev3.HeaderValue, key, value string) []*corev3.HeaderValue {
+	for _, header := range headers {
+		if strings.EqualFold(header.Key, key) {
+			header.Value = ""
+			header.RawValue = []byte(value)
+			return headers
+		}
+	}
+	return append(headers, &corev3.HeaderValue{
+		Key:      key,
+		RawValue: []byte(value),
+	})
+}
+
 func (s *ExternalProcessingServer) processMediationResultAndPrepareResponse(
 	mediationResult *mediation.Result,
 	resp *envoy_service_proc_v3.ProcessingResponse,
@@ -636,6 +665,22 @@ func (s *ExternalProcessingServer) processMediationResultAndPrepareResponse(
 		}
 	}
 	if mediationResult.ImmediateResponse {
+		immediateResponseHeaders := make(map[string]string, len(mediationResult.ImmediateResponseHeaders)+1)
+		for key, value := range mediationResult.ImmediateResponseHeaders {
+			immediateResponseHeaders[key] = value
+		}
+		if mediationResult.ImmediateResponseContentType != "" {
+			immediateResponseHeaders["content-type"] = mediationResult.ImmediateResponseContentType
+		}
+		for key, value := range immediateResponseHeaders {
+			headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+				Header: &corev3.HeaderValue{
+					Key:      key,
+					RawValue: []byte(value),
+				},
+				AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+			})
+		}
 		resp.Response = &envoy_service_proc_v3.ProcessingResponse_ImmediateResponse{
 			ImmediateResponse: &envoy_service_proc_v3.ImmediateResponse{
 				Status: &v32.HttpStatus{
@@ -817,6 +862,48 @@ func ReadGzip(gzipData []byte) (string, error) {
 	return result.String(), nil
 }
 
+// isTokenRevoked checks the token claims against the revoked JTIs and the revocation rules.
+func (s *ExternalProcessingServer) isTokenRevoked(requestConfigHolder *requestconfig.Holder) bool {
+	claims := requestConfigHolder.JWTAuthnPayloaClaims
+	if s.revokedJTIStore != nil {
+		if jti, ok := claims["jti"]; ok && jti != nil {
+			if jtiStr, ok := jti.(string); ok {
+				if s.revokedJTIStore.IsJTIRevoked(jtiStr) {
+					s.log.Sugar().Debug("Token is revoked")
+					return true
+				}
+			} else {
+				s.log.Sugar().Debug("JTI claim is not a string")
+			}
+		} else {
+			s.log.Sugar().Debug("JTI claim not found")
+		}
+	}
+	if s.revocationRuleStore != nil {
+		if rule := s.revocationRuleStore.MatchClaims(claims, s.applicationResolver(requestConfigHolder)); rule != nil {
+			s.log.Sugar().Debugf("Token is revoked by the %s revocation rule for %s", rule.Type, rule.Value)
+			return true
+		}
+	}
+	return false
+}
+
+// applicationResolver maps the client IDs of the request to their applications through the OAuth2
+// application key mappings of the API's organization and environment, as subscription validation does.
+func (s *ExternalProcessingServer) applicationResolver(requestConfigHolder *requestconfig.Holder) func(string) string {
+	if s.subscriptionApplicationDatastore == nil || requestConfigHolder.RouteMetadata == nil {
+		return nil
+	}
+	api := requestConfigHolder.RouteMetadata.Spec.API
+	return func(clientID string) string {
+		appKeyMapKey := util.PrepareApplicationKeyMappingCacheKey(clientID, requestConfigHolder.EnvType, "OAuth2", api.Environment)
+		if appKeyMap := s.subscriptionApplicationDatastore.GetApplicationKeyMapping(api.Organization, appKeyMapKey); appKeyMap != nil {
+			return appKeyMap.ApplicationUUID
+		}
+		return ""
+	}
+}
+
 func (s *ExternalProcessingServer) extractJWTAuthnNamespaceData(data *corev3.Metadata) map[string]interface{} {
 	claims := make(map[string]interface{})
 	filterMatadata := data.GetFilterMetadata()
@@ -941,10 +1028,15 @@ func (s *ExternalProcessingServer) extractExtensionRefsAndRouteAttributes(data m
 	if stringVal, ok := extProcData.Fields["request.id"]; ok {
 		requestID = stringVal.GetStringValue()
 	}
+	peerCertificateDigest := ""
+	if stringVal, ok := extProcData.Fields["connection.sha256_peer_certificate_digest"]; ok {
+		peerCertificateDigest = stringVal.GetStringValue()
+	}
 
 	return extensionRefs, &requestconfig.Attributes{
-		RouteName: routeName,
-		RequestID: requestID,
+		RouteName:             routeName,
+		RequestID:             requestID,
+		PeerCertificateDigest: peerCertificateDigest,
 	}, envType
 }
 
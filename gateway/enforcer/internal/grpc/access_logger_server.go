@@ -38,8 +38,7 @@ type AccessLogServiceServer struct {
 }
 
 // newAccessLogServiceServer creates a new instance of the Access Log Service Server.
-func newAccessLogServiceServer(cfg *config.Server) *AccessLogServiceServer {
-	analytics := analytics.NewAnalytics(cfg)
+func newAccessLogServiceServer(cfg *config.Server, analytics *analytics.Analytics) *AccessLogServiceServer {
 	return &AccessLogServiceServer{
 		cfg:         cfg,
 		analytics:   analytics,
@@ -63,10 +62,11 @@ func (s *AccessLogServiceServer) StreamAccessLogs(stream v3.AccessLogService_Str
 	}
 }
 
-// StartAccessLogServiceServer starts the Access Log Service Server.
-func StartAccessLogServiceServer(cfg *config.Server) {
+// StartAccessLogServiceServer starts the Access Log Service Server. The caller owns the
+// analytics instance and closes it on shutdown.
+func StartAccessLogServiceServer(cfg *config.Server, analytics *analytics.Analytics) {
 	// Create a new instance of the Access Log Service Server
-	accessLogServiceServer := newAccessLogServiceServer(cfg)
+	accessLogServiceServer := newAccessLogServiceServer(cfg, analytics)
 
 	kaParams := keepalive.ServerParameters{
 		Time:    time.Duration(cfg.ExternalProcessingKeepAliveTime) * time.Hour, // Ping the client if it is idle for 2 hours
@@ -39,6 +39,13 @@ import (
 	types "k8s.io/apimachinery/pkg/types"
 )
 
+const (
+	// eventAckTimeout is how long the enforcer waits for the common controller to record an acknowledgement.
+	eventAckTimeout = 5 * time.Second
+	// eventAckBufferSize is the number of acknowledgements queued for the ack worker of a stream.
+	eventAckBufferSize = 1024
+)
+
 // EventingGRPCClient is a client for managing gRPC connections to an eventing service.
 // It includes configuration for retries, TLS, and logging.
 type EventingGRPCClient struct {
@@ -97,9 +104,14 @@ func (c *EventingGRPCClient) InitiateEventingGRPCConnection() {
 		return
 	}
 
+	// Acknowledgements are sent by a worker, so a slow common controller does not hold back events.
+	acks := make(chan string, eventAckBufferSize)
+	go c.sendAcks(ctx, client, acks)
+
 	// Handle incoming messages in a separate goroutine
 	c.log.Info("Connected to the gRPC stream")
 	go func() {
+		defer close(acks)
 		for {
 			resp, err := stream.Recv()
 			if err != nil {
@@ -111,10 +123,33 @@ func (c *EventingGRPCClient) InitiateEventingGRPCConnection() {
 			}
 			c.log.Sugar().Debug(fmt.Sprintf("Received config: %v", resp))
 			c.HandleNotificationEvent(resp)
+			c.queueAck(acks, resp)
 		}
 	}()
 }
 
+// queueAck queues the acknowledgement of an applied event without blocking the receive loop. The
+// acknowledgement is dropped when the queue is full, as it only feeds the status of the CRs.
+func (c *EventingGRPCClient) queueAck(acks chan<- string, event *subscription_proto_model.Event) {
+	select {
+	case acks <- event.Uuid:
+	default:
+		c.log.Sugar().Warn(fmt.Sprintf("Dropped the acknowledgement of the event %s, the ack queue is full", event.Uuid))
+	}
+}
+
+// sendAcks acknowledges the queued events until the queue is closed. The common controller reports
+// the acknowledgements in the status of the CRs.
+func (c *EventingGRPCClient) sendAcks(ctx context.Context, client subscription_service.EventStreamServiceClient, acks <-chan string) {
+	for eventUUID := range acks {
+		ackCtx, cancel := context.WithTimeout(ctx, eventAckTimeout)
+		if _, err := client.AckEvent(ackCtx, &subscription_service.EventAck{EventUuid: eventUUID}); err != nil {
+			c.log.Sugar().Debug(fmt.Sprintf("Failed to acknowledge the event %s: %v", eventUUID, err))
+		}
+		cancel()
+	}
+}
+
 // HandleNotificationEvent translates the Java method to Go
 func (c *EventingGRPCClient) HandleNotificationEvent(event *subscription_proto_model.Event) {
 	switch event.Type {
@@ -200,6 +235,7 @@ func (c *EventingGRPCClient) HandleNotificationEvent(event *subscription_proto_m
 				Namespace: routeMetadata.Namespace,
 			}.String()
 			c.routePolicyMetatadataDatastore.DeleteRouteMetadata(namespacedName)
+			mediation.EvictGraphQLSchema(namespacedName)
 		}
 	default:
 		log.Println("Unknown event type received from the server")
@@ -0,0 +1,102 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	subscription_service "github.com/wso2/apk/common-go-libs/pkg/discovery/api/wso2/discovery/service/apkmgt"
+	subscription_proto_model "github.com/wso2/apk/common-go-libs/pkg/discovery/api/wso2/discovery/subscription"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"google.golang.org/grpc"
+)
+
+// blockingAckClient records the acknowledged events, holding each call until it is released.
+type blockingAckClient struct {
+	subscription_service.EventStreamServiceClient
+	started chan struct{}
+	release chan struct{}
+	mu      sync.Mutex
+	acked   []string
+}
+
+func (b *blockingAckClient) AckEvent(ctx context.Context, in *subscription_service.EventAck, _ ...grpc.CallOption) (*subscription_service.EventAckResponse, error) {
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.acked = append(b.acked, in.EventUuid)
+	return &subscription_service.EventAckResponse{}, nil
+}
+
+func (b *blockingAckClient) ackedEvents() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.acked)
+}
+
+func TestEventingGRPCClient_AcksDoNotBlockEvents(t *testing.T) {
+	c := &EventingGRPCClient{log: config.GetConfig().Logger}
+	client := &blockingAckClient{started: make(chan struct{}, 1), release: make(chan struct{})}
+	acks := make(chan string, eventAckBufferSize)
+	done := make(chan struct{})
+	go func() {
+		c.sendAcks(context.Background(), client, acks)
+		close(done)
+	}()
+
+	// The events are queued while the common controller holds the first acknowledgement.
+	want := []string{"first"}
+	c.queueAck(acks, &subscription_proto_model.Event{Uuid: "first"})
+	<-client.started
+	queued := make(chan struct{})
+	go func() {
+		for i := 0; i < eventAckBufferSize; i++ {
+			event := &subscription_proto_model.Event{Uuid: fmt.Sprintf("event-%d", i)}
+			c.queueAck(acks, event)
+			want = append(want, event.Uuid)
+		}
+		close(queued)
+	}()
+	select {
+	case <-queued:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the events to be queued while an acknowledgement is in flight")
+	}
+	// A full queue drops the acknowledgement rather than blocking.
+	c.queueAck(acks, &subscription_proto_model.Event{Uuid: "dropped"})
+
+	close(client.release)
+	close(acks)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the ack worker to stop once the queue is closed")
+	}
+	if acked := client.ackedEvents(); !slices.Equal(acked, want) {
+		t.Errorf("expected the queued events to be acknowledged in order and the overflow to be dropped, got %d acknowledgements", len(acked))
+	}
+}
@@ -17,53 +17,27 @@
 package jwtbackend
 
 import (
-	"crypto/sha256"
-	"encoding/base64"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
-	"github.com/wso2/apk/gateway/enforcer/internal/util"
 )
 
-// JWKKEy represents the JWK key.
-var JWKKEy jwk.Key
-
-// StartJWKSServer starts a server that serves JWKS.
+// StartJWKSServer starts a server that serves the public keys of the backend JWT key set as a JWKS.
 func StartJWKSServer(cfg *config.Server) {
 	r := gin.Default()
 	gin.SetMode(gin.ReleaseMode)
 
 	r.GET("/jwks", func(c *gin.Context) {
-		jwks := createJWKSSet()
+		jwks, err := GetKeySet().JWKS()
+		if err != nil {
+			cfg.Logger.Sugar().Errorf("Failed to create JWKS: %v", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		// The keys are rotated, so the JWKS is cached only for as long as the key set is.
+		c.Header("Cache-Control", "max-age=30")
 		c.JSON(http.StatusOK, jwks)
 	})
 	r.RunTLS(":9092", cfg.EnforcerPublicKeyPath, cfg.EnforcerPrivateKeyPath)
 }
-
-// ReadAndConvertToJwks reads the public key from the given path and converts it to a JWK.
-func ReadAndConvertToJwks(cfg *config.Server) (jwk.Key, error) {
-	// Decode the PEM data
-	publicCert, _ := util.LoadCertificate(cfg.JWTGeneratorPublicKeyPath)
-
-	// Extract the public key from the certificate
-	pubKey := publicCert.PublicKey
-
-	// Convert the public key to a JWK
-	jwkKey, err := jwk.FromRaw(pubKey)
-	cfg = config.GetConfig()
-	if err != nil {
-		cfg.Logger.Sugar().Errorf("Failed to convert public key to JWK: %v", err)
-		return nil, err
-	}
-	hash := sha256.Sum256(publicCert.RawSubjectPublicKeyInfo)
-	kid := base64.RawURLEncoding.EncodeToString(hash[:])
-	jwkKey.Set(jwk.KeyIDKey, kid)
-	return jwkKey, nil
-}
-func createJWKSSet() jwk.Set {
-	jwks := jwk.NewSet()
-	jwks.AddKey(JWKKEy)
-	return jwks
-}
@@ -0,0 +1,288 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package jwtbackend
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/util"
+)
+
+const (
+	// keyFileSuffix is the suffix of the private key files in the keys directory. The key ID is the
+	// file name without it.
+	keyFileSuffix = ".key"
+	// activeKeysFile lists the IDs of the keys new tokens are signed with, in order of preference.
+	activeKeysFile = "active-kids"
+	// keySetReloadInterval is how often the keys directory is checked for rotated keys.
+	keySetReloadInterval = 30 * time.Second
+)
+
+// SigningKey is a key the backend JWTs are signed with.
+type SigningKey struct {
+	// KeyID is sent as the kid header of the tokens signed with the key.
+	KeyID  string
+	Signer crypto.Signer
+}
+
+// supports reports whether the key can sign with the signing method.
+func (k *SigningKey) supports(method jwt.SigningMethod) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := k.Signer.(*rsa.PrivateKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		key, ok := k.Signer.(*ecdsa.PrivateKey)
+		return ok && key.Curve.Params().BitSize == m.CurveBits
+	default:
+		return false
+	}
+}
+
+// KeySet holds the keys the backend JWTs are signed with.
+//
+// The keys are read from a directory, such as a mounted Kubernetes Secret, holding a <kid>.key
+// PEM file per key and an active-kids file listing the keys to sign with. Every key in the
+// directory is published in the JWKS, so that a key can be added before it becomes active and
+// kept until the tokens signed with it have expired. The directory is checked for changes
+// periodically, so the keys can be rotated by updating the Secret. Without a directory, the
+// single key configured for the JWT generator is used.
+type KeySet struct {
+	dir            string
+	privateKeyPath string
+
+	mu          sync.RWMutex
+	keys        []*SigningKey
+	active      []*SigningKey
+	fingerprint string
+	checkedAt   time.Time
+	now         func() time.Time
+}
+
+var (
+	defaultKeySet     *KeySet
+	defaultKeySetOnce sync.Once
+)
+
+// GetKeySet returns the key set of the enforcer configuration.
+func GetKeySet() *KeySet {
+	defaultKeySetOnce.Do(func() {
+		cfg := config.GetConfig()
+		defaultKeySet = NewKeySet(cfg.JWTGeneratorKeysDir, cfg.JWTGeneratorPrivateKeyPath)
+		if _, err := defaultKeySet.Keys(); err != nil {
+			cfg.Logger.Sugar().Errorf("Failed to load the backend JWT signing keys: %v", err)
+		}
+	})
+	return defaultKeySet
+}
+
+// NewKeySet creates a key set reading the keys directory, or the private key file if there is no directory.
+func NewKeySet(dir string, privateKeyPath string) *KeySet {
+	return &KeySet{dir: dir, privateKeyPath: privateKeyPath, now: time.Now}
+}
+
+// Keys returns every key of the set, sorted by key ID.
+func (k *KeySet) Keys() ([]*SigningKey, error) {
+	if err := k.reload(); err != nil {
+		return nil, err
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys, nil
+}
+
+// SigningKey returns the first active key that can sign with the signing method.
+func (k *KeySet) SigningKey(method jwt.SigningMethod) (*SigningKey, error) {
+	if err := k.reload(); err != nil {
+		return nil, err
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.active {
+		if key.supports(method) {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no active backend JWT signing key supports %s", method.Alg())
+}
+
+// JWKS returns the public keys of the set.
+func (k *KeySet) JWKS() (jwk.Set, error) {
+	keys, err := k.Keys()
+	if err != nil {
+		return nil, err
+	}
+	set := jwk.NewSet()
+	for _, key := range keys {
+		publicKey, err := jwk.FromRaw(key.Signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert key %s to JWK: %w", key.KeyID, err)
+		}
+		_ = publicKey.Set(jwk.KeyIDKey, key.KeyID)
+		_ = publicKey.Set(jwk.KeyUsageKey, jwk.ForSignature)
+		_ = set.AddKey(publicKey)
+	}
+	return set, nil
+}
+
+// reload reads the keys if they were never read, or if the reload interval has passed and the
+// keys changed. The keys read before are kept if the new keys cannot be read.
+func (k *KeySet) reload() error {
+	k.mu.RLock()
+	loaded := k.keys != nil
+	due := k.now().Sub(k.checkedAt) >= keySetReloadInterval
+	k.mu.RUnlock()
+	if loaded && !due {
+		return nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.keys != nil && k.now().Sub(k.checkedAt) < keySetReloadInterval {
+		return nil
+	}
+	k.checkedAt = k.now()
+	fingerprint, err := k.dirFingerprint()
+	if err == nil && k.keys != nil && fingerprint == k.fingerprint {
+		return nil
+	}
+	var keys, active []*SigningKey
+	if err == nil {
+		keys, active, err = k.load()
+	}
+	if err != nil {
+		if k.keys != nil {
+			config.GetConfig().Logger.Sugar().Errorf("Failed to reload the backend JWT signing keys, keeping the previous keys: %v", err)
+			return nil
+		}
+		return err
+	}
+	k.keys, k.active, k.fingerprint = keys, active, fingerprint
+	return nil
+}
+
+// dirFingerprint identifies the contents of the keys directory by the names, sizes and
+// modification times of its files.
+func (k *KeySet) dirFingerprint() (string, error) {
+	if k.dir == "" {
+		return "", nil
+	}
+	entries, err := os.ReadDir(k.dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the keys directory: %w", err)
+	}
+	var fingerprint strings.Builder
+	for _, entry := range entries {
+		// Kubernetes keeps the Secret data in hidden directories linked from the visible files.
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(k.dir, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&fingerprint, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return fingerprint.String(), nil
+}
+
+// load reads the keys and the active keys.
+func (k *KeySet) load() ([]*SigningKey, []*SigningKey, error) {
+	if k.dir == "" {
+		signer, err := util.LoadSigningKey(k.privateKeyPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load the private key: %w", err)
+		}
+		kid, err := thumbprint(signer)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys := []*SigningKey{{KeyID: kid, Signer: signer}}
+		return keys, keys, nil
+	}
+
+	entries, err := os.ReadDir(k.dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the keys directory: %w", err)
+	}
+	byID := map[string]*SigningKey{}
+	var keys []*SigningKey
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || !strings.HasSuffix(name, keyFileSuffix) {
+			continue
+		}
+		signer, err := util.LoadSigningKey(filepath.Join(k.dir, name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load key %s: %w", name, err)
+		}
+		key := &SigningKey{KeyID: strings.TrimSuffix(name, keyFileSuffix), Signer: signer}
+		byID[key.KeyID] = key
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, nil, errors.New("the keys directory has no keys")
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+
+	data, err := os.ReadFile(filepath.Join(k.dir, activeKeysFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, keys, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the active keys: %w", err)
+	}
+	var active []*SigningKey
+	for _, kid := range strings.FieldsFunc(string(data), func(r rune) bool { return r == ',' || r == '\n' || r == ' ' }) {
+		key, ok := byID[kid]
+		if !ok {
+			return nil, nil, fmt.Errorf("active key %s is not in the keys directory", kid)
+		}
+		active = append(active, key)
+	}
+	if len(active) == 0 {
+		return nil, nil, errors.New("no key is active")
+	}
+	return keys, active, nil
+}
+
+// thumbprint derives a key ID from the SHA-256 hash of the public key.
+func thumbprint(signer crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the public key: %w", err)
+	}
+	hash := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
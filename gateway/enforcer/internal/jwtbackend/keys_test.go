@@ -0,0 +1,166 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package jwtbackend
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeKey(t *testing.T, dir string, name string, signer crypto.Signer) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newECKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKeySet_SigningKey(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "rsa-1.key", newRSAKey(t))
+	writeKey(t, dir, "ec-256.key", newECKey(t, elliptic.P256()))
+	writeKey(t, dir, "ec-384.key", newECKey(t, elliptic.P384()))
+	keySet := NewKeySet(dir, "")
+
+	tests := []struct {
+		method jwt.SigningMethod
+		kid    string
+	}{
+		{jwt.SigningMethodRS256, "rsa-1"},
+		{jwt.SigningMethodPS512, "rsa-1"},
+		{jwt.SigningMethodES256, "ec-256"},
+		{jwt.SigningMethodES384, "ec-384"},
+	}
+	for _, tt := range tests {
+		key, err := keySet.SigningKey(tt.method)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.method.Alg(), err)
+		}
+		if key.KeyID != tt.kid {
+			t.Errorf("%s: expected key %s, got %s", tt.method.Alg(), tt.kid, key.KeyID)
+		}
+	}
+	if _, err := keySet.SigningKey(jwt.SigningMethodES512); err == nil {
+		t.Error("expected no key for ES512")
+	}
+}
+
+func TestKeySet_ActiveKeysAndRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "old.key", newRSAKey(t))
+	writeKey(t, dir, "new.key", newRSAKey(t))
+	if err := os.WriteFile(filepath.Join(dir, activeKeysFile), []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	keySet := NewKeySet(dir, "")
+	keySet.now = func() time.Time { return now }
+
+	key, err := keySet.SigningKey(jwt.SigningMethodRS256)
+	if err != nil || key.KeyID != "old" {
+		t.Fatalf("expected the old key to be active, got %v, %v", key, err)
+	}
+	jwks, err := keySet.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwks.Len() != 2 {
+		t.Errorf("expected both keys in the JWKS, got %d", jwks.Len())
+	}
+	if _, found := jwks.LookupKeyID("new"); !found {
+		t.Error("expected the inactive key to be published")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, activeKeysFile), []byte("new,old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := keySet.SigningKey(jwt.SigningMethodRS256); key.KeyID != "old" {
+		t.Errorf("expected the keys to be kept until the reload interval, got %s", key.KeyID)
+	}
+	now = now.Add(keySetReloadInterval)
+	if key, _ := keySet.SigningKey(jwt.SigningMethodRS256); key.KeyID != "new" {
+		t.Errorf("expected the rotated key, got %s", key.KeyID)
+	}
+
+	// An invalid update keeps the keys loaded before.
+	if err := os.WriteFile(filepath.Join(dir, activeKeysFile), []byte("missing"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(keySetReloadInterval)
+	if key, err := keySet.SigningKey(jwt.SigningMethodRS256); err != nil || key.KeyID != "new" {
+		t.Errorf("expected the previous keys to be kept, got %v, %v", key, err)
+	}
+}
+
+func TestKeySet_PrivateKeyFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "mg.key", newRSAKey(t))
+	keySet := NewKeySet("", filepath.Join(dir, "mg.key"))
+
+	keys, err := keySet.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("expected a single key, got %d", len(keys))
+	}
+	kid, err := thumbprint(keys[0].Signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys[0].KeyID != kid {
+		t.Errorf("expected the key ID to be the key thumbprint, got %s", keys[0].KeyID)
+	}
+
+	if _, err := NewKeySet(t.TempDir(), "").Keys(); err == nil {
+		t.Error("expected an error for an empty keys directory")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	datastore "github.com/wso2/apk/gateway/enforcer/internal/datastore"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
@@ -29,9 +30,9 @@ const (
 	// MediationAIModelBasedRoundRobinKeyEnabled is the key for enabling/disabling the AI Model Based Round Robin policy.
 	MediationAIModelBasedRoundRobinKeyEnabled = "Enabled"
 	// MediationAIModelBasedRoundRobinKeyOnQuotaExceedSuspendDuration is the key for specifying the duration to suspend on quota exceed.
-	MediationAIModelBasedRoundRobinKeyOnQuotaExceedSuspendDuration = "OnQuotaExceedSuspendDuration"
+	MediationAIModelBasedRoundRobinKeyOnQuotaExceedSuspendDuration = constantscommon.MediationAIModelBasedRoundRobinKeyOnQuotaExceedSuspendDuration
 	// MediationAIModelBasedRoundRobinKeyModelsClusterPair is the key for specifying the AI model and its associated cluster pairs.
-	MediationAIModelBasedRoundRobinKeyModelsClusterPair = "ModelsClusterPair"
+	MediationAIModelBasedRoundRobinKeyModelsClusterPair = constantscommon.MediationAIModelBasedRoundRobinKeyModelsClusterPair
 )
 
 // ModelClusterPair represents a pair of AI model and its associated cluster.
@@ -87,7 +88,7 @@ func (a *AIModelBasedRoundRobin) Process(requestConfigHolder *requestconfig.Hold
 		a.logger.Sugar().Error("RequestAttributes are not populated in requestConfigHolder, hence cannot process AI Model Based Round Robin")
 		return result
 	}
-	if requestConfigHolder.ProcessingPhase == requestconfig.ProcessingPhaseRequestHeaders {
+	if requestConfigHolder.ProcessingPhase == requestconfig.ProcessingPhaseRequestBody {
 		var modelWeights []datastore.ModelWeight
 		for _, model := range a.ModelsClusterPair {
 			modelWeights = append(modelWeights, datastore.ModelWeight{
@@ -104,7 +105,10 @@ func (a *AIModelBasedRoundRobin) Process(requestConfigHolder *requestconfig.Hold
 		a.logger.Sugar().Debug(fmt.Sprintf("Selected Endpoint: %v", selectedEndpoint))
 		if selectedModel == "" || selectedEndpoint == "" {
 			a.logger.Sugar().Debug("Unable to select a model since all models are suspended. Continue with the user provided model")
+		} else if requestConfigHolder.RequestBody == nil {
+			a.logger.Sugar().Debug("Request body is not available, hence cannot change the model")
 		} else {
+			requestConfigHolder.AI.TargetCluster = selectedEndpoint
 			// change request body to model to selected model
 			httpBody := requestConfigHolder.RequestBody.Body
 			a.logger.Sugar().Debug(fmt.Sprintf("request body before %+v\n", httpBody))
@@ -3,6 +3,7 @@ package mediation
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"io"
 	"strings"
 
@@ -12,7 +13,10 @@ import (
 	"github.com/wso2/apk/gateway/enforcer/internal/analytics"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/ratelimit"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -74,6 +78,13 @@ func NewAIProvider(mediation *dpv2alpha1.Mediation) *AIProvider {
 // Process processes the request configuration for AI token rate limiting.
 func (a *AIProvider) Process(requestConfig *requestconfig.Holder) *Result {
 	result := NewResult()
+	if requestConfig.ProcessingPhase == requestconfig.ProcessingPhaseResponseHeaders {
+		if ratelimit.IsEventStream(requestConfig.ResponseHeaders) {
+			a.logger.Sugar().Debug("Response is an event stream, counting tokens per chunk")
+			requestConfig.AI.StreamTokenCounter = ratelimit.NewSSETokenCounter(a.PromptTokenPath, a.CompletionTokenPath, a.TotalTokenPath, "")
+		}
+		return result
+	}
 	if requestConfig.ProcessingPhase != requestconfig.ProcessingPhaseResponseBody {
 		return result
 	}
@@ -82,6 +93,21 @@ func (a *AIProvider) Process(requestConfig *requestconfig.Holder) *Result {
 		a.logger.Sugar().Debug("No response headers found in requestConfig, skipping analytics processing")
 		return result
 	}
+	if counter := requestConfig.AI.StreamTokenCounter; counter != nil {
+		// Chunks are passed through untouched, the counts are reported once the stream ends.
+		counter.Write(requestConfig.ResponseBody.GetBody())
+		if !requestConfig.ResponseBody.GetEndOfStream() {
+			return result
+		}
+		counter.Close()
+		requestConfig.AI.StreamTokenCounter = nil
+		if tokenCount := counter.TokenCount(); tokenCount != nil {
+			a.addTokenCountMetadata(requestConfig.Context(), result, tokenCount)
+		} else {
+			a.logger.Sugar().Debug("No token usage found in the event stream")
+		}
+		return result
+	}
 	isGzipEncoded := false
 	for _, header := range requestConfig.ResponseHeaders.Headers.Headers {
 		key := header.GetKey()
@@ -112,15 +138,33 @@ func (a *AIProvider) Process(requestConfig *requestconfig.Holder) *Result {
 	bodyString := string(bodyBytes)
 	a.logger.Sugar().Debugf("Response body: %s", bodyString)
 
-	results := gjson.GetMany(bodyString, removeDollarPrefix(a.PromptTokenPath), removeDollarPrefix(a.CompletionTokenPath), removeDollarPrefix(a.TotalTokenPath))
-	if len(results) < 3 {
+	results := gjson.GetMany(bodyString, removeDollarPrefix(a.PromptTokenPath), removeDollarPrefix(a.CompletionTokenPath), removeDollarPrefix(a.TotalTokenPath), "model")
+	if len(results) < 4 {
 		a.logger.Sugar().Errorf("Failed to extract token counts from response body: %v", bodyString)
 		return result
 	}
-	promptTokenCount := results[0].Int()
-	completionTokenCount := results[1].Int()
-	totalTokenCount := results[2].Int()
+	a.addTokenCountMetadata(requestConfig.Context(), result, &ratelimit.TokenCountAndModel{
+		Prompt:     int(results[0].Int()),
+		Completion: int(results[1].Int()),
+		Total:      int(results[2].Int()),
+		Model:      results[3].String(),
+	})
+	return result
+}
 
+// addTokenCountMetadata publishes the token counts for AI rate limiting and analytics. Envoy
+// charges the AI rate limits with the counts, so they are recorded on the span of the policy.
+func (a *AIProvider) addTokenCountMetadata(ctx context.Context, result *Result, tokenCount *ratelimit.TokenCountAndModel) {
+	promptTokenCount := int64(tokenCount.Prompt)
+	completionTokenCount := int64(tokenCount.Completion)
+	totalTokenCount := int64(tokenCount.Total)
+	trace.SpanFromContext(ctx).SetAttributes(
+		tracing.AttributePromptTokens.Int64(promptTokenCount),
+		tracing.AttributeCompletionTokens.Int64(completionTokenCount),
+		tracing.AttributeTotalTokens.Int64(totalTokenCount),
+		tracing.AttributeModel.String(tokenCount.Model),
+	)
+	var err error
 	result.Metadata[constants.PromptTokenCountIDMetadataKey] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(promptTokenCount)}}
 	result.Metadata[constants.CompletionTokenCountIDMetadataKey] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(completionTokenCount)}}
 	result.Metadata[constants.TotalTokenCountIDMetadataKey] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(totalTokenCount)}}
@@ -136,7 +180,9 @@ func (a *AIProvider) Process(requestConfig *requestconfig.Holder) *Result {
 	if err != nil {
 		a.logger.Sugar().Errorf("Error creating structpb value for TotalTokenCountMetadata with value %d: %v", totalTokenCount, err)
 	}
-	return result
+	if tokenCount.Model != "" {
+		result.Metadata[analytics.ModelIDMetadataKey] = structpb.NewStringValue(tokenCount.Model)
+	}
 }
 
 func removeDollarPrefix(s string) string {
@@ -0,0 +1,114 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"github.com/wso2/apk/common-go-libs/constants"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+func createTestAIProvider() *AIProvider {
+	return NewAIProvider(&dpv2alpha1.Mediation{
+		PolicyName:    MediationAIProvider,
+		PolicyVersion: "v1",
+		PolicyID:      "test-ai-provider-policy-id",
+		Parameters: []*dpv2alpha1.Parameter{
+			{Key: AITokenRatelimitPolicyKeyPromptTokenPath, Value: "$.usage.prompt_tokens"},
+			{Key: AITokenRatelimitPolicyKeyCompletionTokenPath, Value: "$.usage.completion_tokens"},
+			{Key: AITokenRatelimitPolicyKeyTotalTokenPath, Value: "$.usage.total_tokens"},
+		},
+	})
+}
+
+func newAIResponseHolder(contentType string) *requestconfig.Holder {
+	return &requestconfig.Holder{
+		ProcessingPhase: requestconfig.ProcessingPhaseResponseHeaders,
+		ResponseHeaders: &envoy_service_proc_v3.HttpHeaders{
+			Headers: &corev3.HeaderMap{
+				Headers: []*corev3.HeaderValue{
+					{Key: ":status", RawValue: []byte("200")},
+					{Key: "content-type", RawValue: []byte(contentType)},
+				},
+			},
+		},
+	}
+}
+
+func TestAIProvider_BufferedResponse(t *testing.T) {
+	a := createTestAIProvider()
+	holder := newAIResponseHolder("application/json")
+	a.Process(holder)
+	holder.ProcessingPhase = requestconfig.ProcessingPhaseResponseBody
+	holder.ResponseBody = &envoy_service_proc_v3.HttpBody{
+		Body:        []byte(`{"model":"gpt-4o","usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}`),
+		EndOfStream: true,
+	}
+	result := a.Process(holder)
+	if got := result.Metadata[constants.TotalTokenCountIDMetadataKey].GetNumberValue(); got != 7 {
+		t.Errorf("expected 7 total tokens, got %v", got)
+	}
+	if got := result.Metadata[analytics.ModelIDMetadataKey].GetStringValue(); got != "gpt-4o" {
+		t.Errorf("expected model gpt-4o, got %q", got)
+	}
+}
+
+func TestAIProvider_EventStreamResponse(t *testing.T) {
+	a := createTestAIProvider()
+	holder := newAIResponseHolder("text/event-stream")
+	a.Process(holder)
+	if holder.AI.StreamTokenCounter == nil {
+		t.Fatalf("expected a token counter for an event stream")
+	}
+
+	chunks := []string{
+		"data: {\"model\":\"gpt-4o\",\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: {\"choices\":[],\"us",
+		"age\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}\n\n",
+		"data: [DONE]\n\n",
+	}
+	holder.ProcessingPhase = requestconfig.ProcessingPhaseResponseBody
+	for i, chunk := range chunks {
+		holder.ResponseBody = &envoy_service_proc_v3.HttpBody{Body: []byte(chunk), EndOfStream: i == len(chunks)-1}
+		result := a.Process(holder)
+		if result.ModifyBody {
+			t.Fatalf("expected chunk %d to pass through unmodified", i)
+		}
+		if i < len(chunks)-1 && len(result.Metadata) != 0 {
+			t.Fatalf("expected no metadata before the end of the stream, got %v", result.Metadata)
+		}
+		if i == len(chunks)-1 {
+			if got := result.Metadata[constants.PromptTokenCountIDMetadataKey].GetNumberValue(); got != 5 {
+				t.Errorf("expected 5 prompt tokens, got %v", got)
+			}
+			if got := result.Metadata[constants.TotalTokenCountIDMetadataKey].GetNumberValue(); got != 6 {
+				t.Errorf("expected 6 total tokens, got %v", got)
+			}
+			if got := result.Metadata[analytics.ModelIDMetadataKey].GetStringValue(); got != "gpt-4o" {
+				t.Errorf("expected model gpt-4o, got %q", got)
+			}
+		}
+	}
+	if holder.AI.StreamTokenCounter != nil {
+		t.Errorf("expected the token counter to be released at the end of the stream")
+	}
+}
@@ -0,0 +1,229 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	"github.com/wso2/apk/gateway/enforcer/internal/aitranslation"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+// AIProviderTranslation translates OpenAI compatible chat completion requests to the schema of the
+// provider serving the selected upstream cluster and translates the responses back, so clients
+// and the other AI policies only deal with the OpenAI schema.
+//
+// The target is the one configured for the cluster chosen by the AI routing policies, or the
+// target without a cluster name. Requests without a target are passed through.
+type AIProviderTranslation struct {
+	PolicyName    string
+	PolicyVersion string
+	PolicyID      string
+	Enabled       bool
+	Targets       []aitranslation.Target
+	logger        *logging.Logger
+}
+
+const (
+	// MediationAIProviderTranslation holds the name of the AI Provider Translation mediation policy.
+	MediationAIProviderTranslation = constantscommon.MediationAIProviderTranslation
+	// AIProviderTranslationPolicyKeyEnabled is the key for enabling/disabling the translation.
+	AIProviderTranslationPolicyKeyEnabled = "Enabled"
+	// AIProviderTranslationPolicyKeyTargets is the key for specifying the JSON list of translation targets.
+	AIProviderTranslationPolicyKeyTargets = constantscommon.AIProviderTranslationPolicyKeyTargets
+
+	// aiProviderTranslationPriority runs the translation after the other request policies and
+	// before the other response policies.
+	aiProviderTranslationPriority = 100
+	clusterHeader                 = "x-wso2-cluster-header"
+)
+
+// NewAIProviderTranslation creates a new AIProviderTranslation instance.
+func NewAIProviderTranslation(mediation *dpv2alpha1.Mediation) *AIProviderTranslation {
+	p := &AIProviderTranslation{
+		PolicyName:    MediationAIProviderTranslation,
+		PolicyVersion: mediation.PolicyVersion,
+		PolicyID:      mediation.PolicyID,
+		Enabled:       true,
+		logger:        &config.GetConfig().Logger,
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, AIProviderTranslationPolicyKeyEnabled); ok && val == "false" {
+		p.Enabled = false
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, AIProviderTranslationPolicyKeyTargets); ok && val != "" {
+		if err := json.Unmarshal([]byte(val), &p.Targets); err != nil {
+			p.logger.Sugar().Errorf("Invalid AI provider translation targets: %v", err)
+		}
+	}
+	return p
+}
+
+// Process processes the request configuration for AI provider translation.
+func (p *AIProviderTranslation) Process(requestConfig *requestconfig.Holder) *Result {
+	result := NewResult()
+	if !p.Enabled {
+		return result
+	}
+	switch requestConfig.ProcessingPhase {
+	case requestconfig.ProcessingPhaseRequestBody:
+		p.translateRequest(requestConfig, result)
+	case requestconfig.ProcessingPhaseResponseHeaders:
+		p.translateResponseHeaders(requestConfig, result)
+	case requestconfig.ProcessingPhaseResponseBody:
+		p.translateResponseBody(requestConfig, result)
+	}
+	return result
+}
+
+func (p *AIProviderTranslation) translateRequest(requestConfig *requestconfig.Holder, result *Result) {
+	body := requestConfig.RequestBody.GetBody()
+	if len(body) == 0 {
+		return
+	}
+	target := p.resolveTarget(requestConfig)
+	if target == nil {
+		p.logger.Sugar().Debug("No AI provider translation target for the request, passing it through")
+		return
+	}
+	upstream, exchange, err := aitranslation.TranslateRequest(body, target)
+	if err != nil {
+		p.logger.Sugar().Errorf("Failed to translate the request for AI provider %s: %v", target.Provider, err)
+		return
+	}
+	requestConfig.AI.Translation = exchange
+	for key, value := range upstream.Headers {
+		result.AddHeaders[key] = value
+	}
+	if upstream.Path != "" {
+		result.AddHeaders[":path"] = upstream.Path
+	}
+	if !bytes.Equal(upstream.Body, body) {
+		result.AddHeaders["Content-Length"] = strconv.Itoa(len(upstream.Body))
+		result.ModifyBody = true
+		result.Body = string(upstream.Body)
+	}
+	p.logger.Sugar().Debugf("Translated the request for AI provider %s, model %s", target.Provider, exchange.Model)
+}
+
+func (p *AIProviderTranslation) translateResponseHeaders(requestConfig *requestconfig.Holder, result *Result) {
+	exchange := requestConfig.AI.Translation
+	if exchange == nil || !exchange.TranslatesResponse() {
+		return
+	}
+	// The translated body has a different length, and Envoy sends it chunked without one.
+	result.RemoveHeaders = append(result.RemoveHeaders, "content-length")
+	if responseStatus(requestConfig) >= 400 {
+		return
+	}
+	contentType := responseHeader(requestConfig, "content-type")
+	if translator := aitranslation.NewStreamTranslator(contentType, exchange); translator != nil {
+		exchange.StreamTranslator = translator
+		if !strings.HasPrefix(strings.ToLower(contentType), aitranslation.EventStreamContentType) {
+			result.AddHeaders["content-type"] = aitranslation.EventStreamContentType
+		}
+	}
+}
+
+func (p *AIProviderTranslation) translateResponseBody(requestConfig *requestconfig.Holder, result *Result) {
+	exchange := requestConfig.AI.Translation
+	if exchange == nil || requestConfig.ResponseBody == nil {
+		return
+	}
+	if translator := exchange.StreamTranslator; translator != nil {
+		translated := translator.Write(requestConfig.ResponseBody.GetBody())
+		if requestConfig.ResponseBody.GetEndOfStream() {
+			translated = append(translated, translator.Close()...)
+			requestConfig.AI.Translation = nil
+		}
+		result.ModifyBody = true
+		result.Body = string(translated)
+		return
+	}
+	if !exchange.TranslatesResponse() {
+		return
+	}
+	translated, err := aitranslation.TranslateResponse(responseStatus(requestConfig), requestConfig.ResponseBody.GetBody(), exchange)
+	if err != nil {
+		p.logger.Sugar().Errorf("Failed to translate the response of AI provider %s: %v", exchange.Target.Provider, err)
+		return
+	}
+	if translated != nil {
+		result.ModifyBody = true
+		result.Body = string(translated)
+	}
+}
+
+// resolveTarget returns the target of the cluster selected for the request, or the default target.
+func (p *AIProviderTranslation) resolveTarget(requestConfig *requestconfig.Holder) *aitranslation.Target {
+	cluster := requestConfig.AI.TargetCluster
+	if cluster == "" {
+		cluster = requestHeader(requestConfig, clusterHeader)
+	}
+	var fallback *aitranslation.Target
+	for i := range p.Targets {
+		target := &p.Targets[i]
+		if cluster != "" && target.ClusterName == cluster {
+			return target
+		}
+		if target.ClusterName == "" && fallback == nil {
+			fallback = target
+		}
+	}
+	return fallback
+}
+
+func requestHeader(requestConfig *requestconfig.Holder, name string) string {
+	for _, header := range requestConfig.RequestHeaders.GetHeaders().GetHeaders() {
+		if strings.EqualFold(header.GetKey(), name) {
+			return headerValue(header.GetValue(), header.GetRawValue())
+		}
+	}
+	return ""
+}
+
+func responseHeader(requestConfig *requestconfig.Holder, name string) string {
+	for _, header := range requestConfig.ResponseHeaders.GetHeaders().GetHeaders() {
+		if strings.EqualFold(header.GetKey(), name) {
+			return headerValue(header.GetValue(), header.GetRawValue())
+		}
+	}
+	return ""
+}
+
+func headerValue(value string, rawValue []byte) string {
+	if value == "" {
+		return string(rawValue)
+	}
+	return value
+}
+
+// responseStatus returns the status of the upstream response, 200 when it is unknown.
+func responseStatus(requestConfig *requestconfig.Holder) int {
+	status, err := strconv.Atoi(responseHeader(requestConfig, ":status"))
+	if err != nil {
+		return 200
+	}
+	return status
+}
@@ -0,0 +1,142 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+const testTranslationTargets = `[
+	{"provider": "openai"},
+	{"provider": "anthropic", "clusterName": "anthropic-cluster", "model": "claude-sonnet"}
+]`
+
+func createTestAIProviderTranslation() *AIProviderTranslation {
+	return NewAIProviderTranslation(&dpv2alpha1.Mediation{
+		PolicyName:    MediationAIProviderTranslation,
+		PolicyVersion: "v1",
+		PolicyID:      "test-ai-provider-translation-policy-id",
+		Parameters: []*dpv2alpha1.Parameter{
+			{Key: AIProviderTranslationPolicyKeyTargets, Value: testTranslationTargets},
+		},
+	})
+}
+
+func newTranslationRequestHolder(cluster string) *requestconfig.Holder {
+	holder := &requestconfig.Holder{
+		ProcessingPhase: requestconfig.ProcessingPhaseRequestBody,
+		RequestBody: &envoy_service_proc_v3.HttpBody{
+			Body:        []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"Hi"}]}`),
+			EndOfStream: true,
+		},
+	}
+	holder.AI.TargetCluster = cluster
+	return holder
+}
+
+func TestAIProviderTranslation_Buffered(t *testing.T) {
+	p := createTestAIProviderTranslation()
+	holder := newTranslationRequestHolder("anthropic-cluster")
+	result := p.Process(holder)
+	if !result.ModifyBody || !strings.Contains(result.Body, `"max_tokens":4096`) || !strings.Contains(result.Body, `"model":"claude-sonnet"`) {
+		t.Fatalf("expected the request translated for Anthropic, got %s", result.Body)
+	}
+	if result.AddHeaders[":path"] != "/v1/messages" || result.AddHeaders["anthropic-version"] == "" {
+		t.Errorf("unexpected headers %v", result.AddHeaders)
+	}
+	if result.AddHeaders["Content-Length"] != strconv.Itoa(len(result.Body)) {
+		t.Errorf("unexpected content length %s", result.AddHeaders["Content-Length"])
+	}
+
+	holder.ProcessingPhase = requestconfig.ProcessingPhaseResponseHeaders
+	holder.ResponseHeaders = newAIResponseHolder("application/json").ResponseHeaders
+	result = p.Process(holder)
+	if len(result.RemoveHeaders) != 1 || result.RemoveHeaders[0] != "content-length" {
+		t.Errorf("expected the content length removed, got %v", result.RemoveHeaders)
+	}
+
+	holder.ProcessingPhase = requestconfig.ProcessingPhaseResponseBody
+	holder.ResponseBody = &envoy_service_proc_v3.HttpBody{
+		Body:        []byte(`{"id":"msg_1","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`),
+		EndOfStream: true,
+	}
+	result = p.Process(holder)
+	if !result.ModifyBody || !strings.Contains(result.Body, `"content":"Hello"`) || !strings.Contains(result.Body, `"total_tokens":2`) {
+		t.Errorf("expected the response translated back, got %s", result.Body)
+	}
+}
+
+func TestAIProviderTranslation_EventStream(t *testing.T) {
+	p := createTestAIProviderTranslation()
+	holder := newTranslationRequestHolder("")
+	holder.RequestHeaders = &envoy_service_proc_v3.HttpHeaders{
+		Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: clusterHeader, RawValue: []byte("anthropic-cluster")}}},
+	}
+	p.Process(holder)
+	if holder.AI.Translation == nil || holder.AI.Translation.Target.Provider != "anthropic" {
+		t.Fatalf("expected the cluster header to select the Anthropic target, got %+v", holder.AI.Translation)
+	}
+
+	holder.ProcessingPhase = requestconfig.ProcessingPhaseResponseHeaders
+	holder.ResponseHeaders = newAIResponseHolder("text/event-stream").ResponseHeaders
+	p.Process(holder)
+	if holder.AI.Translation.StreamTranslator == nil {
+		t.Fatal("expected a stream translator for the event stream")
+	}
+
+	holder.ProcessingPhase = requestconfig.ProcessingPhaseResponseBody
+	var out strings.Builder
+	for i, chunk := range []string{
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",",
+		"\"text\":\"Hi\"}}\n\n",
+		"",
+	} {
+		holder.ResponseBody = &envoy_service_proc_v3.HttpBody{Body: []byte(chunk), EndOfStream: i == 2}
+		result := p.Process(holder)
+		if !result.ModifyBody {
+			t.Fatalf("chunk %d: expected the body replaced", i)
+		}
+		out.WriteString(result.Body)
+	}
+	if !strings.Contains(out.String(), `"content":"Hi"`) || !strings.HasSuffix(out.String(), "data: [DONE]\n\n") {
+		t.Errorf("unexpected translated stream %q", out.String())
+	}
+	if holder.AI.Translation != nil {
+		t.Error("expected the translation cleared at the end of the stream")
+	}
+}
+
+func TestAIProviderTranslation_DefaultTargetPassthrough(t *testing.T) {
+	p := createTestAIProviderTranslation()
+	holder := newTranslationRequestHolder("other-cluster")
+	if result := p.Process(holder); result.ModifyBody || len(result.AddHeaders) != 0 {
+		t.Errorf("expected the OpenAI request passed through, got %+v", result)
+	}
+	holder.ProcessingPhase = requestconfig.ProcessingPhaseResponseHeaders
+	holder.ResponseHeaders = newAIResponseHolder("text/event-stream").ResponseHeaders
+	if result := p.Process(holder); len(result.RemoveHeaders) != 0 || holder.AI.Translation.StreamTranslator != nil {
+		t.Errorf("expected the OpenAI response passed through, got %+v", result)
+	}
+}
@@ -1,8 +1,13 @@
 package mediation
 
 import (
+	"encoding/json"
+	"strings"
+
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/analytics"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics/redaction"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
@@ -15,13 +20,21 @@ type Analytics struct {
 	PolicyVersion string `json:"policyVersion"`
 	PolicyID      string `json:"policyID"`
 	Enabled       bool   `json:"enabled"`
-	logger        *logging.Logger
-	cfg           *config.Server
+	// redactionPolicy is the JSON redaction policy of the API, handed to the access log service.
+	redactionPolicy string
+	logger          *logging.Logger
+	cfg             *config.Server
 }
 
 const (
 	// MediationAnalyticsPolicyKeyEnabled is the key for enabling/disabling the Analytics policy.
 	MediationAnalyticsPolicyKeyEnabled = "Enabled"
+	// MediationAnalyticsPolicyKeyFieldPolicies is the key for the actions applied to the event fields,
+	// a JSON object such as {"userIp": "truncate", "userName": "hash", "userAgentHeader": "drop"}.
+	MediationAnalyticsPolicyKeyFieldPolicies = constantscommon.MediationAnalyticsPolicyKeyFieldPolicies
+	// MediationAnalyticsPolicyKeyAllowedProperties is the key for the comma separated custom properties
+	// kept in the events.
+	MediationAnalyticsPolicyKeyAllowedProperties = constantscommon.MediationAnalyticsPolicyKeyAllowedProperties
 )
 
 // NewAnalytics creates a new Analytics instance with default values.
@@ -35,15 +48,50 @@ func NewAnalytics(mediation *dpv2alpha1.Mediation) *Analytics {
 	cfg := config.GetConfig()
 	logger := cfg.Logger
 	return &Analytics{
-		PolicyName:    "Analytics",
-		PolicyVersion: mediation.PolicyVersion,
-		PolicyID:      mediation.PolicyID,
-		Enabled:       enabled,
-		logger:        &logger,
-		cfg:           cfg,
+		PolicyName:      "Analytics",
+		PolicyVersion:   mediation.PolicyVersion,
+		PolicyID:        mediation.PolicyID,
+		Enabled:         enabled,
+		redactionPolicy: newAnalyticsRedactionPolicy(mediation, &logger),
+		logger:          &logger,
+		cfg:             cfg,
 	}
 }
 
+// newAnalyticsRedactionPolicy returns the redaction policy of the API as JSON. A policy that cannot
+// be read drops every field it could act on, so a mistake does not leak personal data.
+func newAnalyticsRedactionPolicy(mediation *dpv2alpha1.Mediation, logger *logging.Logger) string {
+	policy := &redaction.Policy{}
+	if val, ok := extractPolicyValue(mediation.Parameters, MediationAnalyticsPolicyKeyFieldPolicies); ok && val != "" {
+		if err := json.Unmarshal([]byte(val), &policy.Fields); err != nil {
+			logger.Sugar().Errorf("Invalid analytics field policies: %v", err)
+			policy = redaction.DropAll()
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, MediationAnalyticsPolicyKeyAllowedProperties); ok {
+		for _, property := range strings.Split(val, ",") {
+			if property = strings.TrimSpace(property); property != "" {
+				policy.AllowedProperties = append(policy.AllowedProperties, property)
+			}
+		}
+	}
+	if err := policy.Validate(); err != nil {
+		logger.Sugar().Errorf("Invalid analytics field policies: %v", err)
+		allowedProperties := policy.AllowedProperties
+		policy = redaction.DropAll()
+		policy.AllowedProperties = allowedProperties
+	}
+	if policy.IsEmpty() {
+		return ""
+	}
+	encoded, err := json.Marshal(policy)
+	if err != nil {
+		logger.Sugar().Errorf("Error encoding the analytics redaction policy: %v", err)
+		return ""
+	}
+	return string(encoded)
+}
+
 // Process processes the request configuration for analytics.
 func (a *Analytics) Process(requestConfig *requestconfig.Holder) *Result {
 	result := NewResult()
@@ -88,6 +136,9 @@ func (a *Analytics) Process(requestConfig *requestconfig.Holder) *Result {
 	// Region
 	addMetadata(analytics.RegionKey, a.cfg.EnforcerRegionID)
 
+	// Redaction policy applied before the event is published
+	addMetadata(analytics.RedactionPolicyMetadataKey, a.redactionPolicy)
+
 	// Application info
 	if requestConfig.MatchedApplication != nil {
 		app := requestConfig.MatchedApplication
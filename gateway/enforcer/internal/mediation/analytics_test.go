@@ -0,0 +1,96 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"encoding/json"
+	"testing"
+
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics/redaction"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+func newTestAnalyticsMediation(params map[string]string) *dpv2alpha1.Mediation {
+	var parameters []*dpv2alpha1.Parameter
+	for key, value := range params {
+		parameters = append(parameters, &dpv2alpha1.Parameter{Key: key, Value: value})
+	}
+	return &dpv2alpha1.Mediation{PolicyName: MediationAnalytics, Parameters: parameters}
+}
+
+func TestNewAnalyticsRedactionPolicy(t *testing.T) {
+	logger := logging.DefaultLogger(egv1a1.LogLevelError)
+	tests := []struct {
+		name     string
+		params   map[string]string
+		expected *redaction.Policy
+	}{
+		{name: "no policy", params: map[string]string{MediationAnalyticsPolicyKeyEnabled: "true"}},
+		{
+			name: "field policies and allowed properties",
+			params: map[string]string{
+				MediationAnalyticsPolicyKeyFieldPolicies:     `{"userIp": "truncate", "userName": "hash"}`,
+				MediationAnalyticsPolicyKeyAllowedProperties: "apiContext, responseSize",
+			},
+			expected: &redaction.Policy{
+				Fields:            map[string]redaction.Action{redaction.FieldUserIP: redaction.ActionTruncate, redaction.FieldUserName: redaction.ActionHash},
+				AllowedProperties: []string{"apiContext", "responseSize"},
+			},
+		},
+		{
+			name:     "unknown action",
+			params:   map[string]string{MediationAnalyticsPolicyKeyFieldPolicies: `{"userIp": "encrypt"}`},
+			expected: redaction.DropAll(),
+		},
+		{
+			name:     "not json",
+			params:   map[string]string{MediationAnalyticsPolicyKeyFieldPolicies: `userIp=drop`},
+			expected: redaction.DropAll(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := newAnalyticsRedactionPolicy(newTestAnalyticsMediation(tt.params), &logger)
+			if tt.expected == nil {
+				if encoded != "" {
+					t.Errorf("expected no policy, got %s", encoded)
+				}
+				return
+			}
+			expected, _ := json.Marshal(tt.expected)
+			if encoded != string(expected) {
+				t.Errorf("expected %s, got %s", expected, encoded)
+			}
+		})
+	}
+}
+
+func TestAnalytics_ProcessAddsRedactionPolicy(t *testing.T) {
+	policy := NewAnalytics(newTestAnalyticsMediation(map[string]string{
+		MediationAnalyticsPolicyKeyFieldPolicies: `{"userAgentHeader": "drop"}`,
+	}))
+	result := policy.Process(&requestconfig.Holder{})
+	value, ok := result.Metadata[analytics.RedactionPolicyMetadataKey]
+	if !ok || value.GetStringValue() != `{"fields":{"userAgentHeader":"drop"}}` {
+		t.Errorf("expected the redaction policy in the metadata, got %v", value)
+	}
+}
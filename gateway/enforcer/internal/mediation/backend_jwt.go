@@ -1,18 +1,19 @@
 package mediation
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"strconv"
+	"sync"
 	"time"
 
-	"crypto/rsa"
-
 	"github.com/golang-jwt/jwt/v5"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/jwtbackend"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
-	"github.com/wso2/apk/gateway/enforcer/internal/util"
 )
 
 var restrictedClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti", "application", "tierInfo", "subscribedAPIs", "aut"}
@@ -29,9 +30,13 @@ type BackendJWT struct {
 	TokenTTL         int                    `json:"tokenTTL"`
 	CustomClaims     map[string]interface{} `json:"customClaims"`
 	ClaimMapping     map[string]string      `json:"claimMapping"`
-	UseKid           bool                   `json:"useKid"`
-	PrivateKey       *rsa.PrivateKey
-	cfg              *config.Server
+	// UseKid is kept for compatibility. The kid header is always set, so that the backends can
+	// pick the key to verify with while the keys are rotated.
+	UseKid        bool `json:"useKid"`
+	signingMethod jwt.SigningMethod
+	keySet        *jwtbackend.KeySet
+	cache         *backendJWTCache
+	cfg           *config.Server
 }
 
 const (
@@ -41,14 +46,14 @@ const (
 	BackendJWTPolicyKeyEncoding = "Encoding"
 	// BackendJWTPolicyKeyHeader is the key for specifying the JWT header.
 	BackendJWTPolicyKeyHeader = "Header"
-	// BackendJWTPolicyKeySigningAlgorithm is the key for specifying the signing algorithm (e.g., "HS256").
+	// BackendJWTPolicyKeySigningAlgorithm is the key for specifying the signing algorithm (e.g., "RS256", "PS256" or "ES256").
 	BackendJWTPolicyKeySigningAlgorithm = "SigningAlgorithm"
 	// BackendJWTPolicyKeyTokenTTL is the key for specifying the token time-to-live (TTL) in seconds.
-	BackendJWTPolicyKeyTokenTTL = "TokenTTL"
+	BackendJWTPolicyKeyTokenTTL = constantscommon.BackendJWTPolicyKeyTokenTTL
 	// BackendJWTPolicyKeyCustomClaims is the key for specifying custom claims in the JWT.
-	BackendJWTPolicyKeyCustomClaims = "CustomClaims"
+	BackendJWTPolicyKeyCustomClaims = constantscommon.BackendJWTPolicyKeyCustomClaims
 	// BackendJWTPolicyKeyClaimMapping is the key for specifying claim mapping in the JWT.
-	BackendJWTPolicyKeyClaimMapping = "ClaimMapping"
+	BackendJWTPolicyKeyClaimMapping = constantscommon.BackendJWTPolicyKeyClaimMapping
 )
 
 // NewBackendJWT creates a new BackendJWT instance with default values.
@@ -69,7 +74,7 @@ func NewBackendJWT(mediation *dpv2alpha1.Mediation) *BackendJWT {
 	if val, ok := extractPolicyValue(mediation.Parameters, BackendJWTPolicyKeyHeader); ok {
 		header = val
 	}
-	signingAlgorithm := "HS256"
+	signingAlgorithm := sha256WithRSA
 	if val, ok := extractPolicyValue(mediation.Parameters, BackendJWTPolicyKeySigningAlgorithm); ok {
 		signingAlgorithm = val
 	}
@@ -104,9 +109,10 @@ func NewBackendJWT(mediation *dpv2alpha1.Mediation) *BackendJWT {
 			useKid = true
 		}
 	}
-	privateKey, err := util.LoadPrivateKey(cfg.JWTGeneratorPrivateKeyPath)
-	if err != nil {
-		logger.Errorf("Failed to load private key for Backend JWT: %v", err)
+	signingMethod := backendJWTSigningMethod(signingAlgorithm)
+	if signingMethod == nil {
+		logger.Warnf("Unsupported Backend JWT signing algorithm %s, using RS256", signingAlgorithm)
+		signingMethod = jwt.SigningMethodRS256
 	}
 
 	return &BackendJWT{
@@ -121,7 +127,9 @@ func NewBackendJWT(mediation *dpv2alpha1.Mediation) *BackendJWT {
 		CustomClaims:     customClaims,
 		ClaimMapping:     claimMapping,
 		UseKid:           useKid,
-		PrivateKey:       privateKey,
+		signingMethod:    signingMethod,
+		keySet:           jwtbackend.GetKeySet(),
+		cache:            newBackendJWTCache(backendJWTCacheMaxEntries),
 		cfg:              cfg,
 	}
 }
@@ -150,8 +158,30 @@ const (
 	apiGatewayID  = "wso2.org/products/am"
 	dialectURI    = "http://wso2.org/claims/"
 	sha256WithRSA = "SHA256withRSA"
+	noneAlgorithm = "NONE"
+
+	backendJWTCacheMaxEntries = 10000
+	// backendJWTRefreshRatio is the part of the token lifetime after which a cached token is
+	// replaced, so that the backend never receives a token about to expire.
+	backendJWTRefreshRatio = 0.8
 )
 
+// backendJWTSigningMethod returns the signing method of the algorithm, or nil if it is not supported.
+func backendJWTSigningMethod(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case noneAlgorithm:
+		return jwt.SigningMethodNone
+	case sha256WithRSA:
+		return jwt.SigningMethodRS256
+	}
+	switch method := jwt.GetSigningMethod(algorithm).(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		return method
+	default:
+		return nil
+	}
+}
+
 func (b *BackendJWT) createJWT(rch *requestconfig.Holder) string {
 	application := rch.MatchedApplication
 	subscription := rch.MatchedSubscription
@@ -159,10 +189,6 @@ func (b *BackendJWT) createJWT(rch *requestconfig.Holder) string {
 
 	customClaims := b.CustomClaims
 	jwtClaims["iss"] = apiGatewayID
-	currentTime := time.Now().Unix()
-	expireIn := currentTime + int64(b.TokenTTL)
-	jwtClaims["exp"] = expireIn
-	jwtClaims["iat"] = currentTime
 	jwtClaims[dialectURI+"apiname"] = rch.RouteMetadata.Spec.API.Name
 	jwtClaims[dialectURI+"apicontext"] = rch.RouteMetadata.Spec.API.Context
 	jwtClaims[dialectURI+"version"] = rch.RouteMetadata.Spec.API.Version
@@ -192,24 +218,127 @@ func (b *BackendJWT) createJWT(rch *requestconfig.Holder) string {
 			jwtClaims[claim] = claimValue
 		}
 	}
-	var signingMethod jwt.SigningMethod
-	signatureAlgorithm := b.SigningAlgorithm
-	if signatureAlgorithm != "NONE" && signatureAlgorithm != sha256WithRSA {
-		signingMethod = jwt.SigningMethodRS256
-	} else if signatureAlgorithm == sha256WithRSA {
-		signingMethod = jwt.SigningMethodRS256
-	} else {
-		signingMethod = jwt.SigningMethodNone
+	return b.signJWT(jwtClaims)
+}
+
+// signJWT signs the claims with the active key, reusing the token signed for the same claims
+// until it is close to expiry.
+func (b *BackendJWT) signJWT(jwtClaims jwt.MapClaims) string {
+	var key *jwtbackend.SigningKey
+	if b.signingMethod != jwt.SigningMethodNone {
+		var err error
+		if key, err = b.keySet.SigningKey(b.signingMethod); err != nil {
+			b.cfg.Logger.Sugar().Errorf("Failed to get the Backend JWT signing key: %v", err)
+			return ""
+		}
 	}
-	token := jwt.NewWithClaims(signingMethod, jwtClaims)
-	if b.UseKid {
-		token.Header["kid"] = jwtbackend.JWKKEy.KeyID()
+	cacheKey, err := backendJWTCacheKey(jwtClaims, b.signingMethod, key)
+	if err != nil {
+		b.cfg.Logger.Sugar().Errorf("Failed to create the Backend JWT cache key: %v", err)
+	}
+	if token, found := b.cache.get(cacheKey); found {
+		return token
 	}
 
-	signedToken, err := token.SignedString(b.PrivateKey)
+	issuedAt := time.Now()
+	ttl := time.Duration(b.TokenTTL) * time.Second
+	jwtClaims["iat"] = issuedAt.Unix()
+	jwtClaims["exp"] = issuedAt.Add(ttl).Unix()
+	token := jwt.NewWithClaims(b.signingMethod, jwtClaims)
+	var signedToken string
+	if key != nil {
+		token.Header["kid"] = key.KeyID
+		signedToken, err = token.SignedString(key.Signer)
+	} else {
+		signedToken, err = token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	}
 	if err != nil {
 		b.cfg.Logger.Sugar().Errorf("Failed to sign the JWT token: %v", err)
 		return ""
 	}
+	if cacheKey != "" {
+		b.cache.put(cacheKey, signedToken, issuedAt.Add(time.Duration(float64(ttl)*backendJWTRefreshRatio)))
+	}
 	return signedToken
 }
+
+// backendJWTCacheKey identifies a token by its claims other than the times, and the algorithm
+// and key it is signed with. The claims are serialized with sorted keys, so equal claim sets
+// produce the same key.
+func backendJWTCacheKey(jwtClaims jwt.MapClaims, method jwt.SigningMethod, key *jwtbackend.SigningKey) (string, error) {
+	claims := make(map[string]interface{}, len(jwtClaims))
+	for claim, value := range jwtClaims {
+		if claim != "iat" && claim != "exp" {
+			claims[claim] = value
+		}
+	}
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write([]byte(method.Alg()))
+	if key != nil {
+		hash.Write([]byte(key.KeyID))
+	}
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// backendJWTCache caches the signed tokens until they are due for refresh. A nil cache
+// caches nothing.
+type backendJWTCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]backendJWTCachedToken
+}
+
+type backendJWTCachedToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+func newBackendJWTCache(maxEntries int) *backendJWTCache {
+	return &backendJWTCache{maxEntries: maxEntries, entries: map[string]backendJWTCachedToken{}}
+}
+
+func (c *backendJWTCache) get(key string) (string, bool) {
+	if c == nil || key == "" {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[key]
+	if !found {
+		return "", false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.token, true
+}
+
+func (c *backendJWTCache) put(key string, token string, expiresAt time.Time) {
+	if c == nil || !time.Now().Before(expiresAt) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		// Evict an arbitrary entry when none has expired.
+		for k := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = backendJWTCachedToken{token: token, expiresAt: expiresAt}
+}
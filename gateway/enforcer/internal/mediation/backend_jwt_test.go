@@ -0,0 +1,188 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/jwtbackend"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+// newTestBackendJWT creates a Backend JWT policy signing with the keys written to a temporary directory.
+func newTestBackendJWT(t *testing.T, algorithm string, keys map[string]crypto.Signer) *BackendJWT {
+	t.Helper()
+	dir := t.TempDir()
+	for kid, key := range keys {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, kid+".key"), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &BackendJWT{
+		Enabled:          true,
+		SigningAlgorithm: algorithm,
+		TokenTTL:         3600,
+		signingMethod:    backendJWTSigningMethod(algorithm),
+		keySet:           jwtbackend.NewKeySet(dir, ""),
+		cache:            newBackendJWTCache(backendJWTCacheMaxEntries),
+		cfg:              config.GetConfig(),
+	}
+}
+
+func newBackendJWTHolder(applicationUUID string) *requestconfig.Holder {
+	routeMetadata := &dpv2alpha1.RouteMetadata{}
+	routeMetadata.Spec.API.Name = "PetStore"
+	routeMetadata.Spec.API.Context = "/petstore/1.0.0"
+	routeMetadata.Spec.API.Version = "1.0.0"
+	return &requestconfig.Holder{
+		RouteMetadata:      routeMetadata,
+		MatchedApplication: &subscription_model.Application{UUID: applicationUUID, Name: "app", Owner: "admin"},
+	}
+}
+
+// parseBackendJWT verifies the token with the public key of the kid header.
+func parseBackendJWT(t *testing.T, token string, keys map[string]crypto.Signer) *jwt.Token {
+	t.Helper()
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keys[kid].Public(), nil
+	})
+	if err != nil {
+		t.Fatalf("failed to verify the token: %v", err)
+	}
+	return parsed
+}
+
+func TestBackendJWT_SigningAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]crypto.Signer{"rsa": rsaKey, "ec": ecKey}
+
+	tests := []struct {
+		algorithm string
+		alg       string
+		kid       string
+	}{
+		{sha256WithRSA, "RS256", "rsa"},
+		{"RS384", "RS384", "rsa"},
+		{"PS256", "PS256", "rsa"},
+		{"ES256", "ES256", "ec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			backendJWT := newTestBackendJWT(t, tt.algorithm, keys)
+			token := parseBackendJWT(t, backendJWT.createJWT(newBackendJWTHolder("app-1")), keys)
+			if token.Method.Alg() != tt.alg {
+				t.Errorf("expected %s, got %s", tt.alg, token.Method.Alg())
+			}
+			if token.Header["kid"] != tt.kid {
+				t.Errorf("expected kid %s, got %v", tt.kid, token.Header["kid"])
+			}
+			claims := token.Claims.(jwt.MapClaims)
+			if claims[dialectURI+"applicationid"] != "app-1" {
+				t.Errorf("expected the application claim, got %v", claims)
+			}
+		})
+	}
+
+	if backendJWTSigningMethod("HS256") != nil {
+		t.Error("expected HMAC algorithms to be unsupported")
+	}
+	unsigned := newTestBackendJWT(t, noneAlgorithm, nil).createJWT(newBackendJWTHolder("app-1"))
+	if unsigned == "" || !strings.HasSuffix(unsigned, ".") {
+		t.Errorf("expected an unsigned token, got %q", unsigned)
+	}
+}
+
+func TestBackendJWT_CachesTokensByClaims(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backendJWT := newTestBackendJWT(t, "RS256", map[string]crypto.Signer{"rsa": rsaKey})
+
+	first := backendJWT.createJWT(newBackendJWTHolder("app-1"))
+	if first == "" {
+		t.Fatal("expected a token")
+	}
+	if second := backendJWT.createJWT(newBackendJWTHolder("app-1")); second != first {
+		t.Error("expected the token to be reused for the same claims")
+	}
+	if other := backendJWT.createJWT(newBackendJWTHolder("app-2")); other == first {
+		t.Error("expected a new token for different claims")
+	}
+
+	// A token due for refresh is replaced.
+	for key, entry := range backendJWT.cache.entries {
+		entry.expiresAt = time.Now().Add(-time.Second)
+		backendJWT.cache.entries[key] = entry
+	}
+	time.Sleep(time.Second)
+	if refreshed := backendJWT.createJWT(newBackendJWTHolder("app-1")); refreshed == first {
+		t.Error("expected a new token once the cached token is due for refresh")
+	}
+}
+
+func TestBackendJWTCache_Eviction(t *testing.T) {
+	cache := newBackendJWTCache(2)
+	expiresAt := time.Now().Add(time.Minute)
+	cache.put("a", "token-a", expiresAt)
+	cache.put("b", "token-b", expiresAt)
+	cache.put("c", "token-c", expiresAt)
+	if len(cache.entries) != 2 {
+		t.Errorf("expected the cache to be bounded, got %d entries", len(cache.entries))
+	}
+	if token, found := cache.get("c"); !found || token != "token-c" {
+		t.Errorf("expected the last token to be cached, got %q", token)
+	}
+	cache.put("d", "token-d", time.Now().Add(-time.Second))
+	if _, found := cache.get("d"); found {
+		t.Error("expected an expired token not to be cached")
+	}
+	var nilCache *backendJWTCache
+	nilCache.put("a", "token-a", expiresAt)
+	if _, found := nilCache.get("a"); found {
+		t.Error("expected a nil cache to cache nothing")
+	}
+}
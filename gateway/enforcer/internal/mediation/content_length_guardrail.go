@@ -29,6 +29,7 @@ import (
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/tidwall/gjson"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
@@ -53,13 +54,13 @@ const (
 	// ContentLengthGuardrailPolicyKeyName is the key for specifying the name of the guardrail.
 	ContentLengthGuardrailPolicyKeyName = "name"
 	// ContentLengthGuardrailPolicyKeyMin is the key for specifying the minimum content length.
-	ContentLengthGuardrailPolicyKeyMin = "min"
+	ContentLengthGuardrailPolicyKeyMin = constantscommon.ContentLengthGuardrailPolicyKeyMin
 	// ContentLengthGuardrailPolicyKeyMax is the key for specifying the maximum content length.
-	ContentLengthGuardrailPolicyKeyMax = "max"
+	ContentLengthGuardrailPolicyKeyMax = constantscommon.ContentLengthGuardrailPolicyKeyMax
 	// ContentLengthGuardrailPolicyKeyJSONPath is the key for specifying the JSON path to extract content.
 	ContentLengthGuardrailPolicyKeyJSONPath = "jsonPath"
 	// ContentLengthGuardrailPolicyKeyInverted is the key for specifying if the validation should be inverted.
-	ContentLengthGuardrailPolicyKeyInverted = "invert"
+	ContentLengthGuardrailPolicyKeyInverted = constantscommon.ContentLengthGuardrailPolicyKeyInverted
 	// ContentLengthGuardrailPolicyKeyShowAssessment is the key for specifying if assessment should be shown.
 	ContentLengthGuardrailPolicyKeyShowAssessment = "showAssessment"
 
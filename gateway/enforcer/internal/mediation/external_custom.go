@@ -19,17 +19,23 @@ import (
 
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
-// ExternalCustom is a mediation that delegates processing to an external plugin runner subprocess.
+// ExternalCustom is a mediation that delegates processing to an external plugin runner, either a
+// long-lived runner sidecar reached over gRPC or a runner subprocess spawned per call.
 type ExternalCustom struct {
 	policy *dpv2alpha1.Mediation
 	// configuration derived from parameters
+	pluginPath      string
 	symbol          string
+	runnerAddress   string
 	runnerPath      string
 	runnerURL       string
 	timeout         time.Duration
@@ -38,19 +44,28 @@ type ExternalCustom struct {
 }
 
 const (
-	paramPluginPath        = "pluginPath"        // required: path to .so (or desired destination path if pluginURL provided)
-	paramPluginURL         = "pluginURL"         // optional: URL to download the .so if pluginPath is missing
-	paramSymbol            = "symbol"            // optional: function symbol, defaults to ProcessJSON
-	paramRunnerPath        = "runnerPath"        // optional: path to runner binary, defaults to APK_PLUGIN_RUNNER or "apk-plugin-runner" in PATH
-	paramRunnerURL         = "runnerURL"         // optional: URL to download the runner binary if not present
-	paramTimeoutMs         = "timeoutMs"         // optional: int milliseconds
-	paramDownloadTimeoutMs = "downloadTimeoutMs" // optional: int milliseconds for downloading pluginURL
+	paramPluginPath        = "pluginPath"                                             // required: path to .so (or desired destination path if pluginURL provided)
+	paramPluginURL         = "pluginURL"                                              // optional: URL to download the .so if pluginPath is missing
+	paramSymbol            = "symbol"                                                 // optional: function symbol, defaults to ProcessJSON
+	paramRunnerPath        = "runnerPath"                                             // optional: path to runner binary, defaults to APK_PLUGIN_RUNNER or "apk-plugin-runner" in PATH
+	paramRunnerURL         = "runnerURL"                                              // optional: URL to download the runner binary if not present
+	paramTimeoutMs         = constantscommon.ExternalCustomPolicyKeyTimeoutMs         // optional: int milliseconds
+	paramDownloadTimeoutMs = constantscommon.ExternalCustomPolicyKeyDownloadTimeoutMs // optional: int milliseconds for downloading pluginURL
+	paramRunnerAddress     = "runnerAddress"                                          // optional: address of a long-lived runner, defaults to PLUGIN_RUNNER_ADDRESS
 )
 
 // NewExternalCustom constructs an ExternalCustom mediation from the cluster policy.
 func NewExternalCustom(m *dpv2alpha1.Mediation) *ExternalCustom {
 	ec := &ExternalCustom{policy: m}
 	ec.cfg = config.GetConfig()
+	if v, ok := extractPolicyValue(m.Parameters, paramPluginPath); ok {
+		ec.pluginPath = v
+	}
+	if v, ok := extractPolicyValue(m.Parameters, paramRunnerAddress); ok && v != "" {
+		ec.runnerAddress = v
+	} else {
+		ec.runnerAddress = ec.cfg.PluginRunnerAddress
+	}
 	if v, ok := extractPolicyValue(m.Parameters, paramSymbol); ok && v != "" {
 		ec.symbol = v
 	} else {
@@ -83,8 +98,10 @@ func NewExternalCustom(m *dpv2alpha1.Mediation) *ExternalCustom {
 		ec.downloadTimeout = 15 * time.Second
 	}
 
-	// Best-effort: ensure runner binary is available locally as well.
-	if localRunner, err := ec.ensureRunnerLocal(); err == nil && localRunner != "" {
+	// Best-effort: ensure runner binary is available locally as well. Not needed when a runner sidecar is used.
+	if ec.runnerAddress != "" {
+		ec.dbg("ExternalCustom using plugin runner at %s", ec.runnerAddress)
+	} else if localRunner, err := ec.ensureRunnerLocal(); err == nil && localRunner != "" {
 		ec.runnerPath = localRunner
 	}
 	// Debug summary of constructed mediation
@@ -116,7 +133,18 @@ func (e *ExternalCustom) Process(h *requestconfig.Holder) *Result {
 	}
 	e.dbg("Input JSON size=%d bytes, sample=%q", len(payload), truncate(string(payload), 256))
 
-	outPayload, err := e.invokeRunner(payload)
+	if e.runnerAddress != "" {
+		extOut, err := e.invokeRunnerServer(h.Context(), in)
+		if err != nil {
+			e.cfg.Logger.Sugar().Errorf("ExternalCustom plugin runner call error: %v", err)
+			// On runner errors, fail open.
+			return NewResult()
+		}
+		e.dbg("Process end in %s", time.Since(start))
+		return mapExternalOutputToResult(extOut)
+	}
+
+	outPayload, err := e.invokeRunner(h.Context(), payload)
 	if err != nil {
 		e.cfg.Logger.Sugar().Errorf("ExternalCustom runner invocation error: %v", err)
 		// On runner errors, fail open.
@@ -143,9 +171,19 @@ func (e *ExternalCustom) Process(h *requestconfig.Holder) *Result {
 // externalOutput now lives in common-go-libs as mediation.ExternalOutput
 
 func (e *ExternalCustom) buildInput(h *requestconfig.Holder) *commonmediation.ExternalInput {
+	in := buildExternalInput(e.policy, h)
+	// Debug summary for input (with redactions)
+	e.dbg("buildInput: phase=%s, params=%d, attrs=%d, reqHdrs=%v, respHdrs=%v, reqBody=%q, respBody=%q",
+		in.Phase, len(in.Parameters), len(in.Attributes), redactAndSampleHeaders(in.RequestHeaders, 5), redactAndSampleHeaders(in.ResponseHeaders, 5),
+		truncate(in.RequestBody, 128), truncate(in.ResponseBody, 128))
+	return in
+}
+
+// buildExternalInput builds the JSON contract input shared by the external mediation types.
+func buildExternalInput(policy *dpv2alpha1.Mediation, h *requestconfig.Holder) *commonmediation.ExternalInput {
 	params := map[string]string{}
-	if e.policy != nil {
-		for _, p := range e.policy.Parameters {
+	if policy != nil {
+		for _, p := range policy.Parameters {
 			if p != nil && p.Key != "" && p.Value != "" {
 				params[p.Key] = p.Value
 			}
@@ -190,11 +228,11 @@ func (e *ExternalCustom) buildInput(h *requestconfig.Holder) *commonmediation.Ex
 
 	phase := string(h.ProcessingPhase)
 
-	in := &commonmediation.ExternalInput{
+	return &commonmediation.ExternalInput{
 		Phase:           phase,
-		PolicyName:      e.policy.PolicyName,
-		PolicyID:        e.policy.PolicyID,
-		PolicyVersion:   e.policy.PolicyVersion,
+		PolicyName:      policy.PolicyName,
+		PolicyID:        policy.PolicyID,
+		PolicyVersion:   policy.PolicyVersion,
 		Parameters:      params,
 		Attributes:      attrs,
 		RequestHeaders:  nilIfEmptyMap(reqHeaders),
@@ -202,11 +240,6 @@ func (e *ExternalCustom) buildInput(h *requestconfig.Holder) *commonmediation.Ex
 		RequestBody:     reqBody,
 		ResponseBody:    respBody,
 	}
-	// Debug summary for input (with redactions)
-	e.dbg("buildInput: phase=%s, params=%d, attrs=%d, reqHdrs=%v, respHdrs=%v, reqBody=%q, respBody=%q",
-		phase, len(params), len(attrs), redactAndSampleHeaders(reqHeaders, 5), redactAndSampleHeaders(respHeaders, 5),
-		truncate(reqBody, 128), truncate(respBody, 128))
-	return in
 }
 
 func nilIfEmptyMap(m map[string]string) map[string]string {
@@ -216,14 +249,38 @@ func nilIfEmptyMap(m map[string]string) map[string]string {
 	return m
 }
 
-func (e *ExternalCustom) invokeRunner(input []byte) ([]byte, error) {
+// invokeRunnerServer calls the plugin through the pooled client of the long-lived runner.
+func (e *ExternalCustom) invokeRunnerServer(parent context.Context, in *commonmediation.ExternalInput) (out *commonmediation.ExternalOutput, err error) {
+	if e.pluginPath == "" {
+		return nil, fmt.Errorf("parameter %s is required when using a plugin runner server", paramPluginPath)
+	}
+	client, err := GetPluginRunnerClient(e.cfg, e.runnerAddress)
+	if err != nil {
+		return nil, err
+	}
+	parent, span := tracing.StartClient(parent, "plugin runner "+e.symbol, e.pluginAttributes()...)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := context.WithTimeout(parent, e.timeout)
+	defer cancel()
+	t0 := time.Now()
+	out, err = client.Invoke(ctx, e.pluginPath, e.symbol, in)
+	if err != nil {
+		return nil, err
+	}
+	e.dbg("Runner server call finished in %s", time.Since(t0))
+	return out, nil
+}
+
+func (e *ExternalCustom) invokeRunner(parent context.Context, input []byte) (output []byte, err error) {
 	// Ensure the runner binary is available locally; if not, try to download/resolve.
 	localRunner, err := e.ensureRunnerLocal()
 	if err != nil {
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	parent, span := tracing.StartClient(parent, "plugin runner "+e.symbol, e.pluginAttributes()...)
+	defer func() { tracing.End(span, err) }()
+	ctx, cancel := context.WithTimeout(parent, e.timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, localRunner)
@@ -247,6 +304,16 @@ func (e *ExternalCustom) invokeRunner(input []byte) ([]byte, error) {
 	return stdout.Bytes(), nil
 }
 
+// pluginAttributes describes the plugin on the spans of the runner calls.
+func (e *ExternalCustom) pluginAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		tracing.AttributePolicyName.String(e.policy.PolicyName),
+		tracing.AttributePolicyID.String(e.policy.PolicyID),
+		tracing.AttributePluginPath.String(e.pluginPath),
+		tracing.AttributePluginSymbol.String(e.symbol),
+	}
+}
+
 func isHTTPURL(s string) bool {
 	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
 }
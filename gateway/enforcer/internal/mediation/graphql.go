@@ -6,10 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
-	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 	"github.com/vektah/gqlparser/v2/parser"
 	"github.com/vektah/gqlparser/v2/validator"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
@@ -19,23 +20,41 @@ import (
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/types"
 	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	graphql "github.com/wso2/apk/common-go-libs/graphql"
 )
 
 // GraphQL represents the configuration for GraphQL policy in the API Gateway.
 type GraphQL struct {
-	PolicyName    string `json:"policyName"`
-	PolicyVersion string `json:"policyVersion"`
-	PolicyID      string `json:"policyID"`
-	Operations    string `json:"schema"`
-	logger        *logging.Logger
-	cfg           *config.Server
+	PolicyName       string `json:"policyName"`
+	PolicyVersion    string `json:"policyVersion"`
+	PolicyID         string `json:"policyID"`
+	Operations       string `json:"schema"`
+	Limits           GraphQLQueryLimits
+	PersistedQueries GraphQLPersistedQueries
+	operations       []*graphql.Operation
+	operationsErr    error
+	logger           *logging.Logger
+	cfg              *config.Server
 }
 
 const (
 	// GraphQLPolicyKeySchema is the key for specifying the GraphQL schema.
 	GraphQLPolicyKeySchema = constantscommon.GraphQLPolicyKeySchema
+	// GraphQLPolicyKeyMaxDepth is the key for specifying the maximum depth of a query.
+	GraphQLPolicyKeyMaxDepth = constantscommon.GraphQLPolicyKeyMaxDepth
+	// GraphQLPolicyKeyMaxComplexity is the key for specifying the maximum complexity of a query.
+	GraphQLPolicyKeyMaxComplexity = constantscommon.GraphQLPolicyKeyMaxComplexity
+	// GraphQLPolicyKeyMaxAliases is the key for specifying the maximum number of aliases in a query.
+	GraphQLPolicyKeyMaxAliases = constantscommon.GraphQLPolicyKeyMaxAliases
+	// GraphQLPolicyKeyMaxRootFields is the key for specifying the maximum number of root fields in a query.
+	GraphQLPolicyKeyMaxRootFields = constantscommon.GraphQLPolicyKeyMaxRootFields
+	// GraphQLPolicyKeyFieldCosts is the key for specifying the JSON map of field costs keyed by Type.field.
+	GraphQLPolicyKeyFieldCosts = constantscommon.GraphQLPolicyKeyFieldCosts
+	// GraphQLPolicyKeyIntrospectionDisabledEnvironments is the key for specifying the comma separated
+	// environment types that reject introspection queries.
+	GraphQLPolicyKeyIntrospectionDisabledEnvironments = constantscommon.GraphQLPolicyKeyIntrospectionDisabledEnvironments
 )
 
 // NewGraphQL creates a new GraphQL instance with default values.
@@ -46,7 +65,7 @@ func NewGraphQL(mediation *dpv2alpha1.Mediation) *GraphQL {
 	}
 	cfg := config.GetConfig()
 	logger := cfg.Logger
-	return &GraphQL{
+	g := &GraphQL{
 		PolicyName:    "GraphQL",
 		PolicyVersion: "v1",
 		PolicyID:      "graphql",
@@ -54,97 +73,132 @@ func NewGraphQL(mediation *dpv2alpha1.Mediation) *GraphQL {
 		logger:        &logger,
 		cfg:           cfg,
 	}
+	// The operations only change with the policy, so they are parsed once.
+	g.operations, g.operationsErr = parseOperations([]byte(Operations))
+	for key, limit := range map[string]*int{
+		GraphQLPolicyKeyMaxDepth:      &g.Limits.MaxDepth,
+		GraphQLPolicyKeyMaxComplexity: &g.Limits.MaxComplexity,
+		GraphQLPolicyKeyMaxAliases:    &g.Limits.MaxAliases,
+		GraphQLPolicyKeyMaxRootFields: &g.Limits.MaxRootFields,
+	} {
+		if val, ok := extractPolicyValue(mediation.Parameters, key); ok && val != "" {
+			if intVal, err := strconv.Atoi(val); err == nil {
+				*limit = intVal
+			} else {
+				g.logger.Sugar().Errorf("Invalid value %q for GraphQL policy parameter %s: %v", val, key, err)
+			}
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, GraphQLPolicyKeyFieldCosts); ok && val != "" {
+		if err := json.Unmarshal([]byte(val), &g.Limits.FieldCosts); err != nil {
+			g.logger.Sugar().Errorf("Invalid GraphQL field costs: %v", err)
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, GraphQLPolicyKeyIntrospectionDisabledEnvironments); ok {
+		for _, env := range strings.Split(val, ",") {
+			if env = strings.TrimSpace(env); env != "" {
+				g.Limits.IntrospectionDisabledEnvironments = append(g.Limits.IntrospectionDisabledEnvironments, env)
+			}
+		}
+	}
+	g.PersistedQueries = newGraphQLPersistedQueries(mediation, cfg, g.logger)
+	return g
 }
 
 // Process processes the request configuration for GraphQL.
 func (g *GraphQL) Process(requestConfig *requestconfig.Holder) *Result {
-	// Implement the logic to process the requestConfig for GraphQL
-	// This is a placeholder implementation
 	result := NewResult()
-	operations, err := parseOperations([]byte(g.Operations))
-	if err != nil {
-		g.logger.Sugar().Errorf("failed to parse operations: %v", err)
-		result.ImmediateResponse = true
-		result.ImmediateResponseBody = "failed to parse operations: " + err.Error()
-		result.ImmediateResponseCode = 500
-		result.ImmediateResponseDetail = "failed to parse operations: " + err.Error()
-		return result
-	}
-
-	schemaBytes := []byte(requestConfig.RouteMetadata.Spec.API.Definition)
-	var sdl string
-	schemaString := ""
-	if schemaString, err = unzipGzip(schemaBytes); err != nil {
-		g.logger.Sugar().Errorf("error while unzipping the GraphQL SDL: %v, Sending internal server error", err)
+	if g.operationsErr != nil {
+		g.logger.Sugar().Errorf("failed to parse operations: %v", g.operationsErr)
 		result.ImmediateResponse = true
-		result.ImmediateResponseBody = "Related API definition is not configured properly"
+		result.ImmediateResponseBody = "failed to parse operations: " + g.operationsErr.Error()
 		result.ImmediateResponseCode = 500
-		result.ImmediateResponseDetail = "error while unzipping the GraphQL SDL"
+		result.ImmediateResponseDetail = "failed to parse operations: " + g.operationsErr.Error()
 		return result
 	}
-	sdl = schemaString
-
-	if sdl == "" {
-		g.logger.Sugar().Error("GraphQL SDL is empty, Sending internal server error")
-		result.ImmediateResponse = true
-		result.ImmediateResponseBody = "Related API definition is not configured properly"
-		result.ImmediateResponseCode = 500
-		result.ImmediateResponseDetail = "GraphQL SDL is empty"
-	}
 
-	// Parse the schema into a graphql object
-	schema, err := gqlparser.LoadSchema(&ast.Source{Input: sdl})
+	schema, err := loadGraphQLSchema(requestConfig.RouteMetadata)
 	if err != nil {
-		g.logger.Sugar().Errorf("error while parsing the GraphQL SDL: %v", err)
+		g.logger.Sugar().Errorf("%v, Sending internal server error", err)
 		result.ImmediateResponse = true
 		result.ImmediateResponseBody = "Related API definition is not configured properly"
 		result.ImmediateResponseCode = 500
-		result.ImmediateResponseDetail = "error while parsing the GraphQL SDL"
+		result.ImmediateResponseDetail = err.Error()
 		return result
 	}
 
 	// Decode the json into a graphql req
 	var gqlReq GQLRequest
-	requestBody := string(requestConfig.RequestBody.Body)
-	if err := json.Unmarshal([]byte(requestBody), &gqlReq); err != nil {
+	if err := json.Unmarshal(requestConfig.RequestBody.GetBody(), &gqlReq); err != nil {
 		g.logger.Sugar().Errorf("failed to parse GraphQL request: %v", err)
 	}
 
-	cleanedQuery := strings.TrimSpace(gqlReq.Query)
+	partition := types.NamespacedName{Namespace: requestConfig.RouteMetadata.Namespace, Name: requestConfig.RouteMetadata.Name}.String()
+	persisted, persistedErr := g.PersistedQueries.resolve(requestConfig.Context(), partition, &gqlReq, g.logger)
+	if persistedErr != nil {
+		g.logger.Sugar().Debugf("Rejecting GraphQL request: %v", persistedErr)
+		result = graphQLErrorResult(gqlerror.List{persistedErr})
+		if persistedErr.Extensions["code"] == GraphQLErrorCodeOperationNotAllowed {
+			result.ImmediateResponseCode = 403
+		}
+		return result
+	}
+
+	cleanedQuery := strings.TrimSpace(persisted.query)
 
 	// parse the query
-	document, err := parser.ParseQuery(&ast.Source{
+	document, parseErr := parser.ParseQuery(&ast.Source{
 		Input: cleanedQuery,
 	})
 
-	if err != nil {
-		g.logger.Sugar().Errorf("invalid query: %v", err)
-		result.ImmediateResponse = true
-		result.ImmediateResponseBody = "invalid query: " + err.Error()
-		result.ImmediateResponseCode = 400
-		result.ImmediateResponseDetail = "invalid query: " + err.Error()
-		return result
+	if parseErr != nil {
+		g.logger.Sugar().Errorf("invalid query: %v", parseErr)
+		queryErr := gqlerror.WrapIfUnwrapped(parseErr)
+		queryErr.Extensions = map[string]interface{}{"code": GraphQLErrorCodeParseFailed}
+		return graphQLErrorResult(gqlerror.List{queryErr})
 	}
 
 	// validate query against graphql sdl
 	validationErrors := validator.Validate(schema, document)
 	if len(validationErrors) > 0 {
-		g.logger.Sugar().Error("Validation Errors:")
-		validationErrorsString := ""
-		for _, err := range validationErrors {
-			g.logger.Sugar().Error("-", err.Message)
-			validationErrorsString += err.Message + "; "
+		g.logger.Sugar().Errorf("Validation errors: %v", validationErrors)
+		for _, validationErr := range validationErrors {
+			validationErr.Extensions = map[string]interface{}{"code": GraphQLErrorCodeValidationFailed}
+		}
+		return graphQLErrorResult(validationErrors)
+	}
+
+	for _, operation := range document.Operations {
+		stats, analyzeErr := analyzeGraphQLOperation(operation, g.Limits.FieldCosts, gqlReq.Variables)
+		if analyzeErr != nil {
+			g.logger.Sugar().Debugf("Rejecting GraphQL operation %s: %v", operation.Name, analyzeErr)
+			return graphQLErrorResult(gqlerror.List{analyzeErr})
+		}
+		g.logger.Sugar().Debugf("GraphQL operation %s: %+v", operation.Name, stats)
+		if limitErr := g.Limits.check(stats, requestConfig.EnvType); limitErr != nil {
+			g.logger.Sugar().Debugf("Rejecting GraphQL operation %s: %v", operation.Name, limitErr)
+			return graphQLErrorResult(gqlerror.List{limitErr})
+		}
+	}
+
+	if persisted.rewrite {
+		// The backend may not support persisted queries, so it is sent the resolved query.
+		body, err := withGraphQLQuery(requestConfig.RequestBody.GetBody(), persisted.query)
+		if err != nil {
+			g.logger.Sugar().Errorf("failed to add the persisted query to the request body: %v", err)
+		} else {
+			result.ModifyBody = true
+			result.Body = body
 		}
-		result.ImmediateResponse = true
-		result.ImmediateResponseBody = "validation error: " + validationErrorsString
-		result.ImmediateResponseCode = 400
-		result.ImmediateResponseDetail = "validation error: " + validationErrorsString
-		return result
 	}
 
 	for _, operation := range document.Operations {
 		for _, selection := range operation.SelectionSet {
-			res := findMatchedOperation(operations, operation, selection)
+			if isGraphQLIntrospectionField(selection) {
+				// Introspection is not an operation of the API, and is limited by the environments instead.
+				continue
+			}
+			res := findMatchedOperation(g.operations, operation, selection)
 			if res == nil {
 				g.logger.Sugar().Errorf("no matching operation found for selection: %+v", selection)
 				result.ImmediateResponse = true
@@ -188,6 +242,9 @@ func (g *GraphQL) Process(requestConfig *requestconfig.Holder) *Result {
 		}
 	}
 
+	if persisted.register {
+		g.PersistedQueries.register(requestConfig.Context(), partition, persisted.query, g.logger)
+	}
 	return result
 }
 
@@ -211,6 +268,7 @@ type GQLRequest struct {
 	Query         string                 `json:"query"`
 	OperationName string                 `json:"operationName"`
 	Variables     map[string]interface{} `json:"variables"`
+	Extensions    *GQLRequestExtensions  `json:"extensions,omitempty"`
 }
 
 func findMatchedOperation(operations []*graphql.Operation, operation *ast.OperationDefinition, selection ast.Selection) *graphql.Operation {
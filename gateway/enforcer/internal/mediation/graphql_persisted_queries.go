@@ -0,0 +1,217 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/persistedquery"
+)
+
+const (
+	// GraphQLPolicyKeyPersistedQueries is the key for enabling Automatic Persisted Queries.
+	GraphQLPolicyKeyPersistedQueries = constantscommon.GraphQLPolicyKeyPersistedQueries
+	// GraphQLPolicyKeyAllowListOnly is the key for only running the operations in the allow-list.
+	GraphQLPolicyKeyAllowListOnly = constantscommon.GraphQLPolicyKeyAllowListOnly
+	// GraphQLPolicyKeyOperationAllowList is the key for specifying the registered operations, usually
+	// resolved from a ConfigMap via valueRef.
+	GraphQLPolicyKeyOperationAllowList = constantscommon.GraphQLPolicyKeyOperationAllowList
+	// GraphQLPolicyKeyPersistedQueryTTLSeconds is the key for specifying how long registered queries are kept.
+	GraphQLPolicyKeyPersistedQueryTTLSeconds = constantscommon.GraphQLPolicyKeyPersistedQueryTTLSeconds
+	// GraphQLPolicyKeyPersistedQueryBackend is the key for specifying the store of the registered queries,
+	// redis or memory.
+	GraphQLPolicyKeyPersistedQueryBackend = constantscommon.GraphQLPolicyKeyPersistedQueryBackend
+
+	persistedQueryBackendMemory = "memory"
+	persistedQueryBackendRedis  = "redis"
+
+	persistedQueryVersion          = 1
+	persistedQueryDefaultTTL       = 24 * time.Hour
+	persistedQueryMemoryMaxEntries = 10000
+	persistedQueryTimeout          = 500 * time.Millisecond
+)
+
+// Error codes reported in the extensions of rejected persisted queries. The messages of the not found
+// and not supported errors are the ones the Apollo clients expect before resending the full query.
+const (
+	GraphQLErrorCodePersistedQueryNotFound     = "PERSISTED_QUERY_NOT_FOUND"
+	GraphQLErrorCodePersistedQueryNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+	GraphQLErrorCodePersistedQueryInvalid      = "PERSISTED_QUERY_INVALID"
+	GraphQLErrorCodeOperationNotAllowed        = "OPERATION_NOT_ALLOWED"
+)
+
+// GQLRequestExtensions holds the extensions of a GraphQL request used by the gateway.
+type GQLRequestExtensions struct {
+	PersistedQuery *GQLPersistedQuery `json:"persistedQuery,omitempty"`
+}
+
+// GQLPersistedQuery is the Automatic Persisted Queries extension of a GraphQL request.
+type GQLPersistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// GraphQLPersistedQueries configures how a GraphQL API runs persisted queries.
+type GraphQLPersistedQueries struct {
+	// Automatic lets clients register queries by hash through Automatic Persisted Queries.
+	Automatic bool
+	// AllowListOnly rejects the operations missing from the allow-list, and disables registration.
+	AllowListOnly bool
+	AllowList     *persistedquery.AllowList
+	TTL           time.Duration
+	store         persistedquery.Store
+}
+
+// persistedQueryResolution is the query of a request once its persisted query has been resolved.
+type persistedQueryResolution struct {
+	query string
+	// register is set for a new query that should be registered once it is known to be valid.
+	register bool
+	// rewrite is set when the request only carried the hash, so the backend needs the query added.
+	rewrite bool
+}
+
+// newGraphQLPersistedQueries reads the persisted query configuration of a GraphQL policy.
+func newGraphQLPersistedQueries(mediation *dpv2alpha1.Mediation, cfg *config.Server, logger *logging.Logger) GraphQLPersistedQueries {
+	p := GraphQLPersistedQueries{TTL: persistedQueryDefaultTTL}
+	if val, ok := extractPolicyValue(mediation.Parameters, GraphQLPolicyKeyPersistedQueries); ok {
+		p.Automatic = strings.EqualFold(val, "true")
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, GraphQLPolicyKeyAllowListOnly); ok {
+		p.AllowListOnly = strings.EqualFold(val, "true")
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, GraphQLPolicyKeyOperationAllowList); ok {
+		allowList, err := persistedquery.ParseAllowList(val)
+		if err != nil {
+			// The allow-list is left empty, so an allow-list only API rejects every operation.
+			logger.Sugar().Errorf("Invalid GraphQL operation allow-list: %v", err)
+		} else {
+			p.AllowList = allowList
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, GraphQLPolicyKeyPersistedQueryTTLSeconds); ok {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+			p.TTL = time.Duration(seconds) * time.Second
+		}
+	}
+	if !p.Automatic || p.AllowListOnly {
+		return p
+	}
+	backend, _ := extractPolicyValue(mediation.Parameters, GraphQLPolicyKeyPersistedQueryBackend)
+	switch strings.ToLower(backend) {
+	case "", persistedQueryBackendRedis:
+		p.store = persistedquery.NewRedisStore(getMediationRedisClient(cfg), p.TTL)
+	case persistedQueryBackendMemory:
+		p.store = persistedquery.NewMemoryStore(persistedQueryMemoryMaxEntries, p.TTL)
+	default:
+		logger.Sugar().Warnf("Unknown persisted query backend %s, falling back to %s", backend, persistedQueryBackendRedis)
+		p.store = persistedquery.NewRedisStore(getMediationRedisClient(cfg), p.TTL)
+	}
+	return p
+}
+
+// resolve returns the query to run for the request. A query sent by hash only is looked up in the
+// allow-list and then in the store of registered queries. Unless the API is allow-list only, a query
+// sent along with its hash is to be registered.
+func (p *GraphQLPersistedQueries) resolve(ctx context.Context, partition string, gqlReq *GQLRequest, logger *logging.Logger) (*persistedQueryResolution, *gqlerror.Error) {
+	resolution := &persistedQueryResolution{query: gqlReq.Query}
+	var extension *GQLPersistedQuery
+	if gqlReq.Extensions != nil {
+		extension = gqlReq.Extensions.PersistedQuery
+	}
+	if extension != nil {
+		if !p.Automatic && p.AllowList.Len() == 0 {
+			return nil, graphQLError(GraphQLErrorCodePersistedQueryNotSupported, "PersistedQueryNotSupported")
+		}
+		if extension.Version != persistedQueryVersion {
+			return nil, graphQLError(GraphQLErrorCodePersistedQueryInvalid, "unsupported persisted query version %d", extension.Version)
+		}
+		hash := strings.ToLower(extension.Sha256Hash)
+		if resolution.query == "" {
+			query, found := p.lookup(ctx, partition, hash, logger)
+			if !found {
+				return nil, graphQLError(GraphQLErrorCodePersistedQueryNotFound, "PersistedQueryNotFound")
+			}
+			resolution.query = query
+			resolution.rewrite = true
+		} else {
+			if persistedquery.Hash(resolution.query) != hash {
+				return nil, graphQLError(GraphQLErrorCodePersistedQueryInvalid, "provided sha does not match query")
+			}
+			resolution.register = p.store != nil
+		}
+	}
+	if p.AllowListOnly && !p.AllowList.Allows(resolution.query) {
+		return nil, graphQLError(GraphQLErrorCodeOperationNotAllowed, "operation is not in the allow-list of the API")
+	}
+	return resolution, nil
+}
+
+func (p *GraphQLPersistedQueries) lookup(ctx context.Context, partition string, hash string, logger *logging.Logger) (string, bool) {
+	if query, ok := p.AllowList.Lookup(hash); ok {
+		return query, true
+	}
+	if p.store == nil {
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(ctx, persistedQueryTimeout)
+	defer cancel()
+	query, ok, err := p.store.Get(ctx, partition, hash)
+	if err != nil {
+		// The client sends the full query on a miss, so a store outage only costs a round trip.
+		logger.Sugar().Errorf("Failed to look up the persisted query %s: %v", hash, err)
+		return "", false
+	}
+	return query, ok
+}
+
+// register adds a validated query to the store, so that other replicas can resolve its hash.
+func (p *GraphQLPersistedQueries) register(ctx context.Context, partition string, query string, logger *logging.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, persistedQueryTimeout)
+	defer cancel()
+	if err := p.store.Put(ctx, partition, persistedquery.Hash(query), query); err != nil {
+		logger.Sugar().Errorf("Failed to register the persisted query: %v", err)
+	}
+}
+
+// withGraphQLQuery returns the request body with the query set, keeping the other members intact.
+func withGraphQLQuery(body []byte, query string) (string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	members["query"] = encoded
+	rewritten, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	return string(rewritten), nil
+}
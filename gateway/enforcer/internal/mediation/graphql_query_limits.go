@@ -0,0 +1,292 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	// graphQLCostDirective sets the cost of a field in the SDL, e.g. `posts: [Post] @cost(weight: 5)`.
+	graphQLCostDirective = "cost"
+	// graphQLCostWeightArgument is the argument of the cost directive holding the cost.
+	graphQLCostWeightArgument = "weight"
+	// maxGraphQLComplexity caps the computed complexity so that list multipliers cannot overflow it.
+	maxGraphQLComplexity = 1 << 31
+)
+
+// graphQLListSizeArguments are the arguments whose value multiplies the cost of the selections of a field.
+var graphQLListSizeArguments = []string{"first", "last", "limit"}
+
+// Error codes reported in the extensions of rejected GraphQL queries.
+const (
+	GraphQLErrorCodeParseFailed            = "GRAPHQL_PARSE_FAILED"
+	GraphQLErrorCodeValidationFailed       = "GRAPHQL_VALIDATION_FAILED"
+	GraphQLErrorCodeQueryTooDeep           = "QUERY_TOO_DEEP"
+	GraphQLErrorCodeQueryTooComplex        = "QUERY_TOO_COMPLEX"
+	GraphQLErrorCodeTooManyAliases         = "TOO_MANY_ALIASES"
+	GraphQLErrorCodeTooManyRootFields      = "TOO_MANY_ROOT_FIELDS"
+	GraphQLErrorCodeIntrospectionForbidden = "INTROSPECTION_DISABLED"
+)
+
+// GraphQLQueryLimits bounds the queries a GraphQL API accepts. A zero limit is not enforced.
+type GraphQLQueryLimits struct {
+	MaxDepth      int
+	MaxComplexity int
+	MaxAliases    int
+	MaxRootFields int
+	// FieldCosts holds the cost of fields keyed by Type.field. It takes precedence over the cost
+	// directive in the SDL; other fields cost 1.
+	FieldCosts map[string]int
+	// IntrospectionDisabledEnvironments lists the environment types, PRODUCTION or SANDBOX, that
+	// reject introspection queries. "*" disables introspection in all environments.
+	IntrospectionDisabledEnvironments []string
+}
+
+// graphQLQueryStats describes the shape of a validated query operation.
+type graphQLQueryStats struct {
+	Depth         int
+	Complexity    int
+	Aliases       int
+	RootFields    int
+	Introspection bool
+}
+
+// analyzeGraphQLOperation walks a validated operation, expanding its fragments. Introspection
+// fields count towards the limits like any other field and also flag the operation, so that the
+// environments that disable introspection can reject it. Fragments that spread themselves,
+// directly or through other fragments, are rejected.
+func analyzeGraphQLOperation(operation *ast.OperationDefinition, fieldCosts map[string]int, variables map[string]interface{}) (graphQLQueryStats, *gqlerror.Error) {
+	a := &graphQLQueryAnalyzer{
+		fieldCosts: fieldCosts,
+		variables:  variables,
+		fragments:  map[string]graphQLSelectionStats{},
+		visiting:   map[string]bool{},
+	}
+	selections, err := a.selectionSet(operation.SelectionSet)
+	if err != nil {
+		return graphQLQueryStats{}, err
+	}
+	return graphQLQueryStats{
+		Depth:         selections.Depth,
+		Complexity:    selections.Complexity,
+		Aliases:       selections.Aliases,
+		RootFields:    selections.Fields,
+		Introspection: selections.Introspection,
+	}, nil
+}
+
+// graphQLSelectionStats describes a selection set independently of the depth it is selected at,
+// so that the stats of a fragment are computed once however many times it is spread.
+type graphQLSelectionStats struct {
+	// Depth is the number of nested field levels of the selections.
+	Depth      int
+	Complexity int
+	Aliases    int
+	// Fields is the number of fields selected at the top level of the selections.
+	Fields        int
+	Introspection bool
+}
+
+func (s *graphQLSelectionStats) add(other graphQLSelectionStats) {
+	s.Depth = max(s.Depth, other.Depth)
+	s.Complexity = capComplexity(s.Complexity + other.Complexity)
+	s.Aliases += other.Aliases
+	s.Fields += other.Fields
+	s.Introspection = s.Introspection || other.Introspection
+}
+
+type graphQLQueryAnalyzer struct {
+	fieldCosts map[string]int
+	variables  map[string]interface{}
+	// fragments memoizes the stats of the fragments analyzed so far.
+	fragments map[string]graphQLSelectionStats
+	// visiting holds the fragments being expanded, to detect fragment cycles.
+	visiting map[string]bool
+}
+
+// selectionSet returns the stats of the selections.
+func (a *graphQLQueryAnalyzer) selectionSet(selections ast.SelectionSet) (graphQLSelectionStats, *gqlerror.Error) {
+	stats := graphQLSelectionStats{}
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.Name == "__typename" {
+				continue
+			}
+			children, err := a.selectionSet(s.SelectionSet)
+			if err != nil {
+				return stats, err
+			}
+			field := graphQLSelectionStats{
+				Depth:         children.Depth + 1,
+				Complexity:    capComplexity(a.fieldCost(s) + capComplexity(a.listSize(s)*children.Complexity)),
+				Aliases:       children.Aliases,
+				Fields:        1,
+				Introspection: children.Introspection || s.Name == "__schema" || s.Name == "__type",
+			}
+			if s.Alias != "" && s.Alias != s.Name {
+				field.Aliases++
+			}
+			stats.add(field)
+		case *ast.InlineFragment:
+			fragment, err := a.selectionSet(s.SelectionSet)
+			if err != nil {
+				return stats, err
+			}
+			stats.add(fragment)
+		case *ast.FragmentSpread:
+			fragment, err := a.fragmentSpread(s)
+			if err != nil {
+				return stats, err
+			}
+			stats.add(fragment)
+		}
+	}
+	return stats, nil
+}
+
+// fragmentSpread returns the stats of the spread fragment, analyzing it on its first spread.
+func (a *graphQLQueryAnalyzer) fragmentSpread(spread *ast.FragmentSpread) (graphQLSelectionStats, *gqlerror.Error) {
+	if spread.Definition == nil {
+		return graphQLSelectionStats{}, nil
+	}
+	if stats, ok := a.fragments[spread.Name]; ok {
+		return stats, nil
+	}
+	if a.visiting[spread.Name] {
+		return graphQLSelectionStats{}, graphQLError(GraphQLErrorCodeValidationFailed, "cannot spread fragment %s within itself", spread.Name)
+	}
+	a.visiting[spread.Name] = true
+	stats, err := a.selectionSet(spread.Definition.SelectionSet)
+	delete(a.visiting, spread.Name)
+	if err != nil {
+		return stats, err
+	}
+	a.fragments[spread.Name] = stats
+	return stats, nil
+}
+
+// fieldCost returns the cost of the field from the cost map, the cost directive or the default of 1.
+func (a *graphQLQueryAnalyzer) fieldCost(field *ast.Field) int {
+	if field.ObjectDefinition != nil {
+		if cost, ok := a.fieldCosts[field.ObjectDefinition.Name+"."+field.Name]; ok {
+			return cost
+		}
+	}
+	if field.Definition != nil {
+		if directive := field.Definition.Directives.ForName(graphQLCostDirective); directive != nil {
+			if weight := directive.Arguments.ForName(graphQLCostWeightArgument); weight != nil && weight.Value != nil {
+				if cost, err := strconv.Atoi(weight.Value.Raw); err == nil {
+					return cost
+				}
+			}
+		}
+	}
+	return 1
+}
+
+// listSize returns the page size requested through the list size arguments of the field, or 1.
+func (a *graphQLQueryAnalyzer) listSize(field *ast.Field) int {
+	for _, name := range graphQLListSizeArguments {
+		argument := field.Arguments.ForName(name)
+		if argument == nil || argument.Value == nil {
+			continue
+		}
+		value, err := argument.Value.Value(a.variables)
+		if err != nil {
+			continue
+		}
+		var size int64
+		switch v := value.(type) {
+		case int64:
+			size = v
+		case float64:
+			size = int64(v)
+		case json.Number:
+			size, _ = v.Int64()
+		}
+		if size > 1 {
+			return capComplexity(int(min(size, maxGraphQLComplexity)))
+		}
+	}
+	return 1
+}
+
+// isGraphQLIntrospectionField reports whether the selection is one of the introspection meta fields.
+func isGraphQLIntrospectionField(selection ast.Selection) bool {
+	field, ok := selection.(*ast.Field)
+	return ok && (field.Name == "__schema" || field.Name == "__type" || field.Name == "__typename")
+}
+
+func capComplexity(value int) int {
+	if value > maxGraphQLComplexity || value < 0 {
+		return maxGraphQLComplexity
+	}
+	return value
+}
+
+// check returns the error of the first limit the query exceeds, nil when it is within the limits.
+func (l *GraphQLQueryLimits) check(stats graphQLQueryStats, envType string) *gqlerror.Error {
+	switch {
+	case stats.Introspection && l.introspectionDisabled(envType):
+		return graphQLError(GraphQLErrorCodeIntrospectionForbidden, "GraphQL introspection is not allowed")
+	case l.MaxDepth > 0 && stats.Depth > l.MaxDepth:
+		return graphQLError(GraphQLErrorCodeQueryTooDeep, "query depth %d exceeds the maximum allowed depth %d", stats.Depth, l.MaxDepth)
+	case l.MaxComplexity > 0 && stats.Complexity > l.MaxComplexity:
+		return graphQLError(GraphQLErrorCodeQueryTooComplex, "query complexity %d exceeds the maximum allowed complexity %d", stats.Complexity, l.MaxComplexity)
+	case l.MaxAliases > 0 && stats.Aliases > l.MaxAliases:
+		return graphQLError(GraphQLErrorCodeTooManyAliases, "query uses %d aliases, more than the maximum of %d", stats.Aliases, l.MaxAliases)
+	case l.MaxRootFields > 0 && stats.RootFields > l.MaxRootFields:
+		return graphQLError(GraphQLErrorCodeTooManyRootFields, "query selects %d root fields, more than the maximum of %d", stats.RootFields, l.MaxRootFields)
+	}
+	return nil
+}
+
+func (l *GraphQLQueryLimits) introspectionDisabled(envType string) bool {
+	for _, env := range l.IntrospectionDisabledEnvironments {
+		if env == "*" || strings.EqualFold(env, envType) {
+			return true
+		}
+	}
+	return false
+}
+
+func graphQLError(code, message string, args ...interface{}) *gqlerror.Error {
+	err := gqlerror.Errorf(message, args...)
+	err.Extensions = map[string]interface{}{"code": code}
+	return err
+}
+
+// graphQLErrorResult rejects the request with a GraphQL error response body.
+func graphQLErrorResult(errs gqlerror.List) *Result {
+	result := NewResult()
+	body, _ := json.Marshal(map[string]gqlerror.List{"errors": errs})
+	result.ImmediateResponse = true
+	result.ImmediateResponseCode = 400
+	result.ImmediateResponseBody = string(body)
+	result.ImmediateResponseContentType = "application/json"
+	result.ImmediateResponseDetail = errs.Error()
+	result.StopFurtherProcessing = true
+	return result
+}
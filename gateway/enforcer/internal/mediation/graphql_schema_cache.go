@@ -0,0 +1,89 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// graphQLSchemaEntry holds the schema parsed from one generation of a RouteMetadata.
+type graphQLSchemaEntry struct {
+	generation int64
+	definition string
+	schema     *ast.Schema
+	err        error
+}
+
+var (
+	graphQLSchemaCacheMu sync.RWMutex
+	graphQLSchemaCache   = map[string]*graphQLSchemaEntry{}
+)
+
+// loadGraphQLSchema returns the schema of the API described by the route metadata. The gzipped
+// SDL is decompressed and parsed once per RouteMetadata generation; later requests reuse the
+// parsed schema, or the error when the definition is invalid.
+func loadGraphQLSchema(routeMetadata *dpv2alpha1.RouteMetadata) (*ast.Schema, error) {
+	if routeMetadata == nil {
+		return nil, fmt.Errorf("route metadata of the GraphQL API is not available")
+	}
+	key := types.NamespacedName{Namespace: routeMetadata.Namespace, Name: routeMetadata.Name}.String()
+	definition := routeMetadata.Spec.API.Definition
+
+	graphQLSchemaCacheMu.RLock()
+	entry := graphQLSchemaCache[key]
+	graphQLSchemaCacheMu.RUnlock()
+	// The definition is compared as well, since objects built without a generation share one.
+	if entry != nil && entry.generation == routeMetadata.Generation && entry.definition == definition {
+		return entry.schema, entry.err
+	}
+
+	entry = &graphQLSchemaEntry{generation: routeMetadata.Generation, definition: definition}
+	entry.schema, entry.err = parseGraphQLSchema(definition)
+	graphQLSchemaCacheMu.Lock()
+	graphQLSchemaCache[key] = entry
+	graphQLSchemaCacheMu.Unlock()
+	return entry.schema, entry.err
+}
+
+func parseGraphQLSchema(definition string) (*ast.Schema, error) {
+	sdl, err := unzipGzip([]byte(definition))
+	if err != nil {
+		return nil, fmt.Errorf("error while unzipping the GraphQL SDL: %w", err)
+	}
+	if sdl == "" {
+		return nil, fmt.Errorf("GraphQL SDL is empty")
+	}
+	schema, err := gqlparser.LoadSchema(&ast.Source{Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing the GraphQL SDL: %w", err)
+	}
+	return schema, nil
+}
+
+// EvictGraphQLSchema drops the cached schema of a deleted RouteMetadata.
+func EvictGraphQLSchema(namespacedName string) {
+	graphQLSchemaCacheMu.Lock()
+	delete(graphQLSchemaCache, namespacedName)
+	graphQLSchemaCacheMu.Unlock()
+}
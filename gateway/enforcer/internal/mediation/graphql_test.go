@@ -0,0 +1,352 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"github.com/wso2/apk/gateway/enforcer/internal/persistedquery"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testGraphQLSDL = `
+directive @cost(weight: Int!) on FIELD_DEFINITION
+
+type Query {
+	author(id: ID!): Author
+	authors(first: Int): [Author]
+}
+
+type Author {
+	name: String
+	books(first: Int): [Book] @cost(weight: 3)
+	friends: [Author]
+}
+
+type Book {
+	title: String
+	author: Author
+}
+`
+
+const testGraphQLOperations = `[
+	{"target": "author", "verb": "query"},
+	{"target": "authors", "verb": "query"}
+]`
+
+func gzipString(t *testing.T, s string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func newTestGraphQL(params map[string]string) *GraphQL {
+	parameters := []*dpv2alpha1.Parameter{{Key: GraphQLPolicyKeySchema, Value: testGraphQLOperations}}
+	for key, value := range params {
+		parameters = append(parameters, &dpv2alpha1.Parameter{Key: key, Value: value})
+	}
+	return NewGraphQL(&dpv2alpha1.Mediation{PolicyName: MediationGraphQL, Parameters: parameters})
+}
+
+func newGraphQLHolder(t *testing.T, routeMetadata *dpv2alpha1.RouteMetadata, query string, variables map[string]interface{}) *requestconfig.Holder {
+	body, err := json.Marshal(GQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &requestconfig.Holder{
+		ProcessingPhase: requestconfig.ProcessingPhaseRequestBody,
+		RouteMetadata:   routeMetadata,
+		RequestBody:     &envoy_service_proc_v3.HttpBody{Body: body, EndOfStream: true},
+		EnvType:         "PRODUCTION",
+	}
+}
+
+func newGraphQLRouteMetadata(t *testing.T, name string, generation int64, sdl string) *dpv2alpha1.RouteMetadata {
+	routeMetadata := &dpv2alpha1.RouteMetadata{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Generation: generation},
+	}
+	routeMetadata.Spec.API.Definition = gzipString(t, sdl)
+	return routeMetadata
+}
+
+// graphQLErrorCode returns the extension code of the first error of a GraphQL error body.
+func graphQLErrorCode(t *testing.T, result *Result) string {
+	var body struct {
+		Errors []struct {
+			Message    string                 `json:"message"`
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(result.ImmediateResponseBody), &body); err != nil || len(body.Errors) == 0 {
+		t.Fatalf("expected a GraphQL error body, got %q", result.ImmediateResponseBody)
+	}
+	code, _ := body.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func TestGraphQL_QueryLimits(t *testing.T) {
+	routeMetadata := newGraphQLRouteMetadata(t, "graphql-limits", 1, testGraphQLSDL)
+	g := newTestGraphQL(map[string]string{
+		GraphQLPolicyKeyMaxDepth:                          "3",
+		GraphQLPolicyKeyMaxComplexity:                     "50",
+		GraphQLPolicyKeyMaxAliases:                        "2",
+		GraphQLPolicyKeyMaxRootFields:                     "2",
+		GraphQLPolicyKeyFieldCosts:                        `{"Query.authors": 2}`,
+		GraphQLPolicyKeyIntrospectionDisabledEnvironments: "PRODUCTION",
+	})
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		envType   string
+		code      string
+	}{
+		{name: "within limits", query: `{ author(id: "1") { name books(first: 5) { title } } }`},
+		{name: "typename is free", query: `{ author(id: "1") { __typename name } }`},
+		{name: "too deep", query: `{ author(id: "1") { friends { friends { name } } } }`, code: GraphQLErrorCodeQueryTooDeep},
+		{name: "too deep through fragments", query: `query { author(id: "1") { ...F } } fragment F on Author { friends { friends { name } } }`, code: GraphQLErrorCodeQueryTooDeep},
+		{name: "list size multiplies the cost", query: `{ authors(first: 60) { name } }`, code: GraphQLErrorCodeQueryTooComplex},
+		{name: "list size from variables", query: `query Q($n: Int) { authors(first: $n) { books(first: 10) { title } } }`, variables: map[string]interface{}{"n": 5}, code: GraphQLErrorCodeQueryTooComplex},
+		{name: "too many aliases", query: `{ a: author(id: "1") { name } b: author(id: "2") { name } c: author(id: "3") { name } }`, code: GraphQLErrorCodeTooManyAliases},
+		{name: "too many root fields", query: `{ author(id: "1") { name } authors { name } again: authors { name } }`, code: GraphQLErrorCodeTooManyRootFields},
+		{name: "introspection in production", query: `{ __schema { types { name } } }`, code: GraphQLErrorCodeIntrospectionForbidden},
+		{name: "introspection in sandbox", query: `{ __schema { types { name } } }`, envType: "SANDBOX"},
+		{name: "introspection counts towards the depth", query: `{ __schema { types { fields { type { name } } } } }`, envType: "SANDBOX", code: GraphQLErrorCodeQueryTooDeep},
+		{name: "invalid query", query: `{ author(id: "1") { name `, code: GraphQLErrorCodeParseFailed},
+		{name: "unknown field", query: `{ author(id: "1") { age } }`, code: GraphQLErrorCodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holder := newGraphQLHolder(t, routeMetadata, tt.query, tt.variables)
+			if tt.envType != "" {
+				holder.EnvType = tt.envType
+			}
+			result := g.Process(holder)
+			if tt.code == "" {
+				if result.ImmediateResponse {
+					t.Fatalf("expected the query accepted, got %d %s", result.ImmediateResponseCode, result.ImmediateResponseBody)
+				}
+				return
+			}
+			if !result.ImmediateResponse || result.ImmediateResponseCode != 400 || result.ImmediateResponseContentType != "application/json" {
+				t.Fatalf("expected a 400 GraphQL error response, got %+v", result)
+			}
+			if code := graphQLErrorCode(t, result); code != tt.code {
+				t.Errorf("expected error code %s, got %s in %s", tt.code, code, result.ImmediateResponseBody)
+			}
+		})
+	}
+}
+
+func TestGraphQL_AnalyzeCost(t *testing.T) {
+	schema, err := loadGraphQLSchema(newGraphQLRouteMetadata(t, "graphql-cost", 1, testGraphQLSDL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := parser.ParseQuery(&ast.Source{Input: `{ authors(first: 4) { name books(first: 2) { title } } }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := validator.Validate(schema, document); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	stats, analyzeErr := analyzeGraphQLOperation(document.Operations[0], map[string]int{"Query.authors": 2}, nil)
+	if analyzeErr != nil {
+		t.Fatal(analyzeErr)
+	}
+	// authors costs 2 and each of the 4 authors costs 1 for the name, 3 for the books and 1 for each of the 2 titles.
+	if stats.Complexity != 2+4*(1+3+2*1) || stats.Depth != 3 || stats.RootFields != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestGraphQL_AnalyzeIntrospectionAndFragments(t *testing.T) {
+	schema, err := loadGraphQLSchema(newGraphQLRouteMetadata(t, "graphql-fragments", 1, testGraphQLSDL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := parser.ParseQuery(&ast.Source{Input: `
+		query { a: author(id: "1") { ...F } b: author(id: "2") { ...F } __type(name: "Author") { fields { name } } }
+		fragment F on Author { name friends { ...G } }
+		fragment G on Author { name }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := validator.Validate(schema, document); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	stats, analyzeErr := analyzeGraphQLOperation(document.Operations[0], nil, nil)
+	if analyzeErr != nil {
+		t.Fatal(analyzeErr)
+	}
+	// Each author costs 1, F costs 1 for the name, 1 for friends and 1 for the name of G, and
+	// __type costs 1 with 1 for fields and 1 for the name.
+	if stats.Complexity != 2*(1+3)+3 || stats.Depth != 3 || stats.RootFields != 3 || stats.Aliases != 2 || !stats.Introspection {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// The validator rejects fragment cycles, the analyzer must not loop on them either.
+	cyclic := &ast.FragmentDefinition{Name: "C"}
+	cyclic.SelectionSet = ast.SelectionSet{&ast.Field{Name: "friends", SelectionSet: ast.SelectionSet{
+		&ast.FragmentSpread{Name: "C", Definition: cyclic},
+	}}}
+	operation := &ast.OperationDefinition{SelectionSet: ast.SelectionSet{
+		&ast.Field{Name: "author", SelectionSet: ast.SelectionSet{&ast.FragmentSpread{Name: "C", Definition: cyclic}}},
+	}}
+	if _, analyzeErr := analyzeGraphQLOperation(operation, nil, nil); analyzeErr == nil ||
+		analyzeErr.Extensions["code"] != GraphQLErrorCodeValidationFailed {
+		t.Errorf("expected the fragment cycle to be rejected, got %v", analyzeErr)
+	}
+}
+
+func TestGraphQL_SchemaCache(t *testing.T) {
+	routeMetadata := newGraphQLRouteMetadata(t, "graphql-cache", 1, testGraphQLSDL)
+	first, err := loadGraphQLSchema(routeMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := loadGraphQLSchema(routeMetadata)
+	if first != second {
+		t.Error("expected the schema reused for the same generation")
+	}
+
+	updated := newGraphQLRouteMetadata(t, "graphql-cache", 2, testGraphQLSDL+"\nextend type Book { isbn: String }")
+	third, err := loadGraphQLSchema(updated)
+	if err != nil || third == first || third.Types["Book"].Fields.ForName("isbn") == nil {
+		t.Errorf("expected the schema reparsed for the new generation, got %v", err)
+	}
+
+	EvictGraphQLSchema("default/graphql-cache")
+	if fourth, _ := loadGraphQLSchema(updated); fourth == third {
+		t.Error("expected the schema reparsed after the eviction")
+	}
+
+	broken := newGraphQLRouteMetadata(t, "graphql-broken", 1, "type Query {")
+	g := newTestGraphQL(nil)
+	result := g.Process(newGraphQLHolder(t, broken, `{ author(id: "1") { name } }`, nil))
+	if !result.ImmediateResponse || result.ImmediateResponseCode != 500 {
+		t.Errorf("expected an internal error for an invalid SDL, got %+v", result)
+	}
+	if result := g.Process(newGraphQLHolder(t, nil, `{ author(id: "1") { name } }`, nil)); result.ImmediateResponseCode != 500 {
+		t.Errorf("expected an internal error without route metadata, got %+v", result)
+	}
+}
+
+func newPersistedQueryHolder(t *testing.T, routeMetadata *dpv2alpha1.RouteMetadata, query string, hash string) *requestconfig.Holder {
+	holder := newGraphQLHolder(t, routeMetadata, query, nil)
+	body, err := json.Marshal(GQLRequest{
+		Query:      query,
+		Extensions: &GQLRequestExtensions{PersistedQuery: &GQLPersistedQuery{Version: 1, Sha256Hash: hash}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder.RequestBody.Body = body
+	return holder
+}
+
+func TestGraphQL_AutomaticPersistedQueries(t *testing.T) {
+	routeMetadata := newGraphQLRouteMetadata(t, "graphql-apq", 1, testGraphQLSDL)
+	g := newTestGraphQL(map[string]string{
+		GraphQLPolicyKeyPersistedQueries:      "true",
+		GraphQLPolicyKeyPersistedQueryBackend: "memory",
+	})
+	query := `{ author(id: "1") { name } }`
+	hash := persistedquery.Hash(query)
+
+	result := g.Process(newPersistedQueryHolder(t, routeMetadata, "", hash))
+	if code := graphQLErrorCode(t, result); code != GraphQLErrorCodePersistedQueryNotFound {
+		t.Fatalf("expected the unknown hash not found, got %s", code)
+	}
+	result = g.Process(newPersistedQueryHolder(t, routeMetadata, query, persistedquery.Hash("{ authors { name } }")))
+	if code := graphQLErrorCode(t, result); code != GraphQLErrorCodePersistedQueryInvalid {
+		t.Fatalf("expected a hash mismatch rejected, got %s", code)
+	}
+	if result = g.Process(newPersistedQueryHolder(t, routeMetadata, query, hash)); result.ImmediateResponse {
+		t.Fatalf("expected the query registered, got %s", result.ImmediateResponseBody)
+	}
+
+	result = g.Process(newPersistedQueryHolder(t, routeMetadata, "", hash))
+	if result.ImmediateResponse || !result.ModifyBody {
+		t.Fatalf("expected the registered query resolved, got %+v", result)
+	}
+	var body GQLRequest
+	if err := json.Unmarshal([]byte(result.Body), &body); err != nil || body.Query != query {
+		t.Errorf("expected the query added to the request body, got %s", result.Body)
+	}
+
+	other := newGraphQLRouteMetadata(t, "graphql-apq-other", 1, testGraphQLSDL)
+	if result := g.Process(newPersistedQueryHolder(t, other, "", hash)); !result.ImmediateResponse {
+		t.Error("expected the registered queries isolated per API")
+	}
+
+	disabled := newTestGraphQL(nil)
+	result = disabled.Process(newPersistedQueryHolder(t, routeMetadata, "", hash))
+	if code := graphQLErrorCode(t, result); code != GraphQLErrorCodePersistedQueryNotSupported {
+		t.Errorf("expected persisted queries not supported, got %s", code)
+	}
+}
+
+func TestGraphQL_OperationAllowList(t *testing.T) {
+	routeMetadata := newGraphQLRouteMetadata(t, "graphql-allow-list", 1, testGraphQLSDL)
+	allowed := `query Author { author(id: "1") { name } }`
+	allowList, _ := json.Marshal(map[string]string{"author-v1": allowed})
+	g := newTestGraphQL(map[string]string{
+		GraphQLPolicyKeyPersistedQueries:      "true",
+		GraphQLPolicyKeyAllowListOnly:         "true",
+		GraphQLPolicyKeyOperationAllowList:    string(allowList),
+		GraphQLPolicyKeyPersistedQueryBackend: "memory",
+	})
+
+	if result := g.Process(newGraphQLHolder(t, routeMetadata, allowed, nil)); result.ImmediateResponse {
+		t.Fatalf("expected the allow-listed operation accepted, got %s", result.ImmediateResponseBody)
+	}
+	if result := g.Process(newPersistedQueryHolder(t, routeMetadata, "", "author-v1")); result.ImmediateResponse || !result.ModifyBody {
+		t.Fatalf("expected the allow-listed operation resolved by id, got %+v", result)
+	}
+
+	other := `{ authors { name } }`
+	for name, holder := range map[string]*requestconfig.Holder{
+		"full query":   newGraphQLHolder(t, routeMetadata, other, nil),
+		"registration": newPersistedQueryHolder(t, routeMetadata, other, persistedquery.Hash(other)),
+	} {
+		result := g.Process(holder)
+		if !result.ImmediateResponse || result.ImmediateResponseCode != 403 || graphQLErrorCode(t, result) != GraphQLErrorCodeOperationNotAllowed {
+			t.Errorf("%s: expected the operation rejected, got %+v", name, result)
+		}
+	}
+	if result := g.Process(newPersistedQueryHolder(t, routeMetadata, "", persistedquery.Hash(other))); graphQLErrorCode(t, result) != GraphQLErrorCodePersistedQueryNotFound {
+		t.Errorf("expected the rejected operation not registered, got %s", result.ImmediateResponseBody)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
 	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
 	"google.golang.org/protobuf/types/known/structpb"
 )
@@ -39,86 +40,153 @@ const (
 	MediationURLGuardrail = constantscommon.MediationURLGuardrail
 	// MediationRegexGuardrail holds the name of the Regex Guardrail mediation policy.
 	MediationRegexGuardrail = constantscommon.MediationRegexGuardrail
+	// MediationPromptInjectionGuardrail holds the name of the Prompt Injection Guardrail mediation policy.
+	MediationPromptInjectionGuardrail = constantscommon.MediationPromptInjectionGuardrail
 	// MediationExternalCustom is a sample external custom mediation policy that delegates to a subprocess runner.
-	MediationExternalCustom = "ExternalCustom"
+	MediationExternalCustom = constantscommon.MediationExternalCustom
+	// MediationWASMCustom is a custom mediation policy that runs a WebAssembly module in the enforcer.
+	MediationWASMCustom = constantscommon.MediationWASMCustom
 )
 
-// MediationAndRequestHeaderProcessing defines the mediation and request header processing
-var MediationAndRequestHeaderProcessing = map[string]bool{
-	MediationAITokenRatelimit:       false,
-	MediationSubscriptionRatelimit:  true,
-	MediationSubscriptionValidation: true,
-	MediationAIModelBasedRoundRobin: false,
-	MediationAnalytics:              true,
-	MediationBackendJWT:             true,
-	MediationGraphQL:                false,
-	MediationBackendAPIKey:          true,
-	MediationWordCountGuardrail:     false,
-	MediationSentenceCountGuardrail: false,
-	MediationContentLengthGuardrail: false,
-	MediationPIIMaskingGuardrail:    false,
-	MediationURLGuardrail:           false,
-	MediationRegexGuardrail:         false,
-	MediationExternalCustom:         true,
+// builtinRegistrations declares the mediation policies shipped with the enforcer.
+var builtinRegistrations = []Registration{
+	{
+		Name:              MediationAITokenRatelimit,
+		Phases:            PhaseResponseHeaders | PhaseResponseBody,
+		BufferBody:        true,
+		StreamEventStream: true,
+	},
+	{
+		Name:              MediationAIProvider,
+		Phases:            PhaseResponseHeaders | PhaseResponseBody,
+		BufferBody:        true,
+		StreamEventStream: true,
+		New:               func(m *dpv2alpha1.Mediation) Mediation { return NewAIProvider(m) },
+	},
+	{
+		Name:   MediationSubscriptionRatelimit,
+		Phases: PhaseRequestHeaders,
+		New:    func(m *dpv2alpha1.Mediation) Mediation { return NewSubscriptionRatelimit(m) },
+	},
+	{
+		Name:   MediationSubscriptionValidation,
+		Phases: PhaseRequestHeaders,
+		New:    func(m *dpv2alpha1.Mediation) Mediation { return NewSubscriptionValidation(m) },
+	},
+	{
+		Name:       MediationAIModelBasedRoundRobin,
+		Phases:     PhaseRequestBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewAIModelBasedRoundRobin(m) },
+	},
+	{
+		Name:   MediationAnalytics,
+		Phases: PhaseRequestHeaders,
+		New:    func(m *dpv2alpha1.Mediation) Mediation { return NewAnalytics(m) },
+	},
+	{
+		Name:   MediationBackendJWT,
+		Phases: PhaseRequestHeaders,
+		New:    func(m *dpv2alpha1.Mediation) Mediation { return NewBackendJWT(m) },
+	},
+	{
+		Name:       MediationGraphQL,
+		Phases:     PhaseRequestBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewGraphQL(m) },
+	},
+	{
+		Name:   MediationBackendAPIKey,
+		Phases: PhaseRequestHeaders,
+		New:    func(m *dpv2alpha1.Mediation) Mediation { return NewBackendAPIKey(m) },
+	},
+	{
+		Name:       MediationWordCountGuardrail,
+		Phases:     PhaseRequestBody | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewWordCountGuardrail(m) },
+	},
+	{
+		Name:       MediationSentenceCountGuardrail,
+		Phases:     PhaseRequestBody | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewSentenceCountGuardrail(m) },
+	},
+	{
+		Name:       MediationContentLengthGuardrail,
+		Phases:     PhaseRequestBody | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewContentLengthGuardrail(m) },
+	},
+	{
+		Name:       MediationPIIMaskingGuardrail,
+		Phases:     PhaseRequestBody | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewPIIMaskingGuardrail(m) },
+	},
+	{
+		Name:       MediationURLGuardrail,
+		Phases:     PhaseRequestBody | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewURLGuardrail(m) },
+	},
+	{
+		Name:       MediationRegexGuardrail,
+		Phases:     PhaseRequestBody | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewRegexGuardrail(m) },
+	},
+	{
+		Name:       MediationPromptInjectionGuardrail,
+		Phases:     PhaseRequestBody | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewPromptInjectionGuardrail(m) },
+	},
+	{
+		Name:              MediationAIProviderTranslation,
+		Phases:            PhaseRequestBody | PhaseResponseHeaders | PhaseResponseBody,
+		Priority:          aiProviderTranslationPriority,
+		BufferBody:        true,
+		StreamEventStream: true,
+		New:               func(m *dpv2alpha1.Mediation) Mediation { return NewAIProviderTranslation(m) },
+	},
+	{
+		Name:       MediationSemanticCache,
+		Phases:     PhaseRequestBody | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewSemanticCache(m) },
+	},
+	{
+		Name:     MediationOPAAuthorization,
+		Phases:   PhaseRequestHeaders,
+		Priority: opaAuthorizationPriority,
+		New:      func(m *dpv2alpha1.Mediation) Mediation { return NewOPAAuthorization(m) },
+	},
+	{
+		Name:     MediationSenderConstrainedToken,
+		Phases:   PhaseRequestHeaders,
+		Priority: senderConstrainedTokenPriority,
+		New:      func(m *dpv2alpha1.Mediation) Mediation { return NewSenderConstrainedToken(m) },
+	},
+	{
+		Name:       MediationExternalCustom,
+		Phases:     PhaseRequestHeaders | PhaseRequestBody | PhaseResponseHeaders | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewExternalCustom(m) },
+	},
+	{
+		Name:       MediationWASMCustom,
+		Phases:     PhaseRequestHeaders | PhaseRequestBody | PhaseResponseHeaders | PhaseResponseBody,
+		BufferBody: true,
+		New:        func(m *dpv2alpha1.Mediation) Mediation { return NewWASMCustom(m) },
+	},
 }
 
-// MediationAndRequestBodyProcessing defines the mediation and request header processing
-var MediationAndRequestBodyProcessing = map[string]bool{
-	MediationAITokenRatelimit:       false,
-	MediationSubscriptionRatelimit:  false,
-	MediationSubscriptionValidation: false,
-	MediationAIModelBasedRoundRobin: true,
-	MediationAnalytics:              false,
-	MediationBackendJWT:             false,
-	MediationGraphQL:                true,
-	MediationBackendAPIKey:          false,
-	MediationWordCountGuardrail:     true,
-	MediationSentenceCountGuardrail: true,
-	MediationContentLengthGuardrail: true,
-	MediationPIIMaskingGuardrail:    true,
-	MediationURLGuardrail:           true,
-	MediationRegexGuardrail:         true,
-	MediationExternalCustom:         true,
-}
-
-// MediationAndResponseHeaderProcessing defines the mediation and request header processing
-var MediationAndResponseHeaderProcessing = map[string]bool{
-	MediationAITokenRatelimit:       true,
-	MediationAIProvider:             true,
-	MediationSubscriptionRatelimit:  false,
-	MediationSubscriptionValidation: false,
-	MediationAIModelBasedRoundRobin: false,
-	MediationAnalytics:              false,
-	MediationBackendJWT:             false,
-	MediationGraphQL:                false,
-	MediationBackendAPIKey:          false,
-	MediationWordCountGuardrail:     false,
-	MediationSentenceCountGuardrail: false,
-	MediationContentLengthGuardrail: false,
-	MediationPIIMaskingGuardrail:    false,
-	MediationURLGuardrail:           false,
-	MediationRegexGuardrail:         false,
-	MediationExternalCustom:         true,
-}
-
-// MediationAndResponseBodyProcessing defines the mediation and response body processing
-var MediationAndResponseBodyProcessing = map[string]bool{
-	MediationAITokenRatelimit:       true,
-	MediationAIProvider:             true,
-	MediationSubscriptionRatelimit:  false,
-	MediationSubscriptionValidation: false,
-	MediationAIModelBasedRoundRobin: false,
-	MediationAnalytics:              false,
-	MediationBackendJWT:             false,
-	MediationGraphQL:                false,
-	MediationBackendAPIKey:          false,
-	MediationWordCountGuardrail:     true,
-	MediationSentenceCountGuardrail: true,
-	MediationContentLengthGuardrail: true,
-	MediationPIIMaskingGuardrail:    true,
-	MediationURLGuardrail:           true,
-	MediationRegexGuardrail:         true,
-	MediationExternalCustom:         true,
+func init() {
+	for _, r := range builtinRegistrations {
+		r.Parameters = commonmediation.BuiltinParameters[r.Name]
+		registerBuiltin(r)
+	}
 }
 
 // Result holds the result of mediation processing.
@@ -156,71 +224,18 @@ func CreateMediation(mediationFromCluster *dpv2alpha1.Mediation) Mediation {
 	if MediationMap[mediationFromCluster] != nil {
 		return MediationMap[mediationFromCluster]
 	}
-	switch mediationFromCluster.PolicyName {
-	case MediationAIProvider:
-		// Check if the mediation already exists
-		mediation := NewAIProvider(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationSubscriptionRatelimit:
-		mediation := NewSubscriptionRatelimit(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationSubscriptionValidation:
-		mediation := NewSubscriptionValidation(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationAIModelBasedRoundRobin:
-		mediation := NewAIModelBasedRoundRobin(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationAnalytics:
-		mediation := NewAnalytics(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationBackendJWT:
-		mediation := NewBackendJWT(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationGraphQL:
-		mediation := NewGraphQL(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationBackendAPIKey:
-		mediation := NewBackendAPIKey(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationWordCountGuardrail:
-		mediation := NewWordCountGuardrail(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationSentenceCountGuardrail:
-		mediation := NewSentenceCountGuardrail(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationContentLengthGuardrail:
-		mediation := NewContentLengthGuardrail(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationPIIMaskingGuardrail:
-		mediation := NewPIIMaskingGuardrail(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationURLGuardrail:
-		mediation := NewURLGuardrail(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationRegexGuardrail:
-		mediation := NewRegexGuardrail(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	case MediationExternalCustom:
-		mediation := NewExternalCustom(mediationFromCluster)
-		MediationMap[mediationFromCluster] = mediation
-		return mediation
-	default:
+	r, ok := GetRegistration(mediationFromCluster.PolicyName)
+	if !ok || r.New == nil {
 		return nil
 	}
+	var mediation Mediation
+	if err := ValidateParameters(mediationFromCluster); err != nil {
+		mediation = newRejectedMediation(mediationFromCluster, err)
+	} else {
+		mediation = r.New(mediationFromCluster)
+	}
+	MediationMap[mediationFromCluster] = mediation
+	return mediation
 }
 
 func extractPolicyValue(params []*dpv2alpha1.Parameter, key string) (string, bool) {
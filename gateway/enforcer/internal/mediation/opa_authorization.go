@@ -0,0 +1,484 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/dto"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/util"
+)
+
+// OPAAuthorization delegates the authorization of requests to an Open Policy Agent server. The
+// request, the JWT claims, the API and the matched subscription and application are sent to the
+// OPA data API as the input document, and the request is rejected unless the policy allows it.
+//
+// Only the request headers listed in the requestHeaders parameter are part of the input, so that
+// decisions cached by input are shared by requests that differ only in other headers.
+//
+// The policy result can be a boolean, or an object with an allow flag and the headers to add to
+// the request when it is allowed or to the error response when it is denied:
+//
+//	{"allow": true, "headers": {"x-user-tier": "gold"}, "reason": "..."}
+type OPAAuthorization struct {
+	PolicyName           string
+	PolicyVersion        string
+	PolicyID             string
+	Enabled              bool
+	ServerURL            string
+	Policy               string
+	Rule                 string
+	AccessKey            string
+	SendAccessToken      bool
+	RequestHeaders       map[string]bool
+	AllRequestHeaders    bool
+	AdditionalProperties map[string]string
+	Timeout              time.Duration
+	FailOpen             bool
+	CacheTTL             time.Duration
+	client               *http.Client
+	cache                *opaDecisionCache
+	logger               *logging.Logger
+	// initErr is set when the OPA client cannot be configured. Requests are then rejected,
+	// whether or not the policy fails open.
+	initErr error
+}
+
+const (
+	// MediationOPAAuthorization holds the name of the OPA Authorization mediation policy.
+	MediationOPAAuthorization = constantscommon.MediationOPAAuthorization
+	// OPAAuthorizationPolicyKeyEnabled is the key for enabling/disabling the OPA authorization.
+	OPAAuthorizationPolicyKeyEnabled = "Enabled"
+	// OPAAuthorizationPolicyKeyServerURL is the key for specifying the base URL of the OPA server.
+	OPAAuthorizationPolicyKeyServerURL = constantscommon.OPAAuthorizationPolicyKeyServerURL
+	// OPAAuthorizationPolicyKeyPolicy is the key for specifying the package path of the policy, such as apk/authz.
+	OPAAuthorizationPolicyKeyPolicy = constantscommon.OPAAuthorizationPolicyKeyPolicy
+	// OPAAuthorizationPolicyKeyRule is the key for specifying the rule of the policy to evaluate.
+	OPAAuthorizationPolicyKeyRule = constantscommon.OPAAuthorizationPolicyKeyRule
+	// OPAAuthorizationPolicyKeyAccessKey is the key for specifying the bearer token of the OPA server.
+	OPAAuthorizationPolicyKeyAccessKey = constantscommon.OPAAuthorizationPolicyKeyAccessKey
+	// OPAAuthorizationPolicyKeySendAccessToken is the key for specifying if the access token of the request is sent to OPA.
+	OPAAuthorizationPolicyKeySendAccessToken = constantscommon.OPAAuthorizationPolicyKeySendAccessToken
+	// OPAAuthorizationPolicyKeyRequestHeaders is the key for specifying a comma separated list of the request headers sent to OPA, or * to send every header.
+	OPAAuthorizationPolicyKeyRequestHeaders = constantscommon.OPAAuthorizationPolicyKeyRequestHeaders
+	// OPAAuthorizationPolicyKeyAdditionalProperties is the key for specifying a JSON object of static properties added to the input.
+	OPAAuthorizationPolicyKeyAdditionalProperties = constantscommon.OPAAuthorizationPolicyKeyAdditionalProperties
+	// OPAAuthorizationPolicyKeyTimeout is the key for specifying the OPA request timeout in milliseconds.
+	OPAAuthorizationPolicyKeyTimeout = constantscommon.OPAAuthorizationPolicyKeyTimeout
+	// OPAAuthorizationPolicyKeyFailOpen is the key for specifying if requests are allowed when OPA cannot be reached.
+	OPAAuthorizationPolicyKeyFailOpen = constantscommon.OPAAuthorizationPolicyKeyFailOpen
+	// OPAAuthorizationPolicyKeyCacheTTL is the key for specifying how long decisions are cached in seconds, 0 to disable caching.
+	OPAAuthorizationPolicyKeyCacheTTL = constantscommon.OPAAuthorizationPolicyKeyCacheTTL
+
+	// opaAuthorizationPriority runs the authorization after the subscription validation, so the
+	// matched subscription and application are part of the input.
+	opaAuthorizationPriority    = 10
+	defaultOPAAuthorizationRule = "allow"
+	defaultOPAAuthorizationTTL  = 30 * time.Second
+	defaultOPATimeout           = 500 * time.Millisecond
+	opaDecisionCacheMaxEntries  = 10000
+)
+
+var (
+	opaForbiddenMessage = dto.ErrorResponse{Code: 900808, ErrorMessage: "Resource forbidden",
+		ErrorDescription: "User is NOT authorized to access the Resource. OPA policy validation failed."}
+	opaFailureMessage = dto.ErrorResponse{Code: 900809, ErrorMessage: "Authorization failure",
+		ErrorDescription: "Error occurred while validating the request with the OPA policy."}
+)
+
+// OPAInput is the input document sent to OPA.
+type OPAInput struct {
+	Request              OPARequest                       `json:"request"`
+	Claims               map[string]interface{}           `json:"claims,omitempty"`
+	AccessToken          string                           `json:"accessToken,omitempty"`
+	API                  *OPAAPI                          `json:"api,omitempty"`
+	Subscription         *subscription_model.Subscription `json:"subscription,omitempty"`
+	Application          *subscription_model.Application  `json:"application,omitempty"`
+	AdditionalProperties map[string]string                `json:"additionalProperties,omitempty"`
+}
+
+// OPARequest is the request part of the OPA input document.
+type OPARequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Host    string            `json:"host"`
+	Headers map[string]string `json:"headers"`
+	EnvType string            `json:"envType,omitempty"`
+}
+
+// OPAAPI is the API part of the OPA input document.
+type OPAAPI struct {
+	RouteMetadata string `json:"routeMetadata"`
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	Context       string `json:"context"`
+	Organization  string `json:"organization"`
+	Environment   string `json:"environment,omitempty"`
+}
+
+// OPADecision is the decision of the OPA policy.
+type OPADecision struct {
+	Allow   bool              `json:"allow"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Reason  string            `json:"reason,omitempty"`
+}
+
+// NewOPAAuthorization creates a new OPAAuthorization instance.
+func NewOPAAuthorization(mediation *dpv2alpha1.Mediation) *OPAAuthorization {
+	cfg := config.GetConfig()
+	o := &OPAAuthorization{
+		PolicyName:    MediationOPAAuthorization,
+		PolicyVersion: mediation.PolicyVersion,
+		PolicyID:      mediation.PolicyID,
+		Enabled:       true,
+		Rule:          defaultOPAAuthorizationRule,
+		Timeout:       defaultOPATimeout,
+		CacheTTL:      defaultOPAAuthorizationTTL,
+		logger:        &cfg.Logger,
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyEnabled); ok && val == "false" {
+		o.Enabled = false
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyServerURL); ok {
+		o.ServerURL = strings.TrimSuffix(val, "/")
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyPolicy); ok {
+		o.Policy = strings.Trim(strings.ReplaceAll(val, ".", "/"), "/")
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyRule); ok && val != "" {
+		o.Rule = val
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyAccessKey); ok {
+		o.AccessKey = val
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeySendAccessToken); ok {
+		o.SendAccessToken = val == "true"
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyRequestHeaders); ok {
+		o.RequestHeaders = map[string]bool{}
+		for _, name := range strings.Split(val, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "*" {
+				o.AllRequestHeaders = true
+			} else if name != "" {
+				o.RequestHeaders[name] = true
+			}
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyAdditionalProperties); ok && val != "" {
+		if err := json.Unmarshal([]byte(val), &o.AdditionalProperties); err != nil {
+			o.logger.Sugar().Errorf("Invalid OPA authorization additional properties: %v", err)
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyTimeout); ok {
+		if ms, err := strconv.Atoi(val); err == nil && ms > 0 {
+			o.Timeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyFailOpen); ok {
+		o.FailOpen = val == "true"
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, OPAAuthorizationPolicyKeyCacheTTL); ok {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds >= 0 {
+			o.CacheTTL = time.Duration(seconds) * time.Second
+		}
+	}
+	if o.CacheTTL > 0 {
+		o.cache = newOPADecisionCache(o.CacheTTL, opaDecisionCacheMaxEntries)
+	}
+	tlsConfig, err := opaTLSConfig(cfg, o.ServerURL)
+	if err != nil {
+		o.initErr = err
+		o.logger.Sugar().Errorf("OPA authorization policy %s rejects every request: %v", o.PolicyID, err)
+	}
+	o.client = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	return o
+}
+
+// opaTLSConfig creates the TLS configuration of the OPA client. The enforcer authenticates to
+// HTTPS servers with the OPA client certificate and trusts the enforcer trust store. It fails if
+// the client certificate of an HTTPS server cannot be loaded, rather than connecting without
+// mutual TLS.
+func opaTLSConfig(cfg *config.Server, serverURL string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if !strings.HasPrefix(strings.ToLower(serverURL), "https://") {
+		return tlsConfig, nil
+	}
+	clientCert, err := util.LoadCertificates(cfg.OpaClientPublicKeyPath, cfg.OpaClientPrivateKeyPath)
+	if err != nil {
+		return tlsConfig, fmt.Errorf("OPA client certificate is not loaded: %w", err)
+	}
+	tlsConfig.Certificates = []tls.Certificate{clientCert}
+	if certPool, err := util.LoadCACertificates(cfg.TrustedAdapterCertsPath); err == nil {
+		tlsConfig.RootCAs = certPool
+	} else {
+		cfg.Logger.Sugar().Warnf("Trusted certificates are not loaded for OPA, using the system trust store: %v", err)
+	}
+	return tlsConfig, nil
+}
+
+// Process processes the request configuration for OPA authorization.
+func (o *OPAAuthorization) Process(requestConfig *requestconfig.Holder) *Result {
+	result := NewResult()
+	if !o.Enabled {
+		o.logger.Sugar().Debugf("OPA Authorization policy is disabled. Skipping processing.")
+		return result
+	}
+	if o.initErr != nil {
+		return rejectedOPAResult(result)
+	}
+	input := o.buildInput(requestConfig)
+	inputJSON, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		o.logger.Sugar().Errorf("Failed to marshal the OPA input: %v", err)
+		return o.failureResult(result)
+	}
+	cacheKey := util.ComputeSHA256Hash(string(inputJSON))
+	decision, cached := o.cache.get(cacheKey)
+	if !cached {
+		decision, err = o.evaluate(inputJSON)
+		if err != nil {
+			o.logger.Sugar().Errorf("OPA authorization failed for %s: %v", o.dataURL(), err)
+			return o.failureResult(result)
+		}
+		o.cache.put(cacheKey, decision)
+	}
+	if !decision.Allow {
+		errorMessage := opaForbiddenMessage
+		if decision.Reason != "" {
+			errorMessage.ErrorDescription = decision.Reason
+		}
+		body, _ := json.MarshalIndent(errorMessage, "", "  ")
+		result.StopFurtherProcessing = true
+		result.ImmediateResponse = true
+		result.ImmediateResponseCode = v32.StatusCode_Forbidden
+		result.ImmediateResponseBody = string(body)
+		result.ImmediateResponseDetail = errorMessage.ErrorDescription
+		result.ImmediateResponseContentType = "application/json"
+		for name, value := range decision.Headers {
+			result.ImmediateResponseHeaders[name] = value
+		}
+		return result
+	}
+	for name, value := range decision.Headers {
+		result.AddHeaders[name] = value
+	}
+	return result
+}
+
+// buildInput builds the input document of the request.
+func (o *OPAAuthorization) buildInput(requestConfig *requestconfig.Holder) *OPAInput {
+	input := &OPAInput{
+		Request: OPARequest{
+			Method:  requestHeader(requestConfig, ":method"),
+			Path:    requestHeader(requestConfig, ":path"),
+			Host:    requestHeader(requestConfig, ":authority"),
+			Headers: map[string]string{},
+			EnvType: requestConfig.EnvType,
+		},
+		Claims:               requestConfig.JWTAuthnPayloaClaims,
+		Subscription:         requestConfig.MatchedSubscription,
+		Application:          requestConfig.MatchedApplication,
+		AdditionalProperties: o.AdditionalProperties,
+	}
+	for _, header := range requestConfig.RequestHeaders.GetHeaders().GetHeaders() {
+		name := strings.ToLower(header.GetKey())
+		// Pseudo headers are part of the request and the request ID would defeat the decision cache.
+		if strings.HasPrefix(name, ":") || name == "x-request-id" {
+			continue
+		}
+		if name == "authorization" {
+			if o.SendAccessToken {
+				input.AccessToken = strings.TrimSpace(strings.TrimPrefix(headerValue(header.GetValue(), header.GetRawValue()), "Bearer"))
+			}
+			continue
+		}
+		if o.AllRequestHeaders || o.RequestHeaders[name] {
+			input.Request.Headers[name] = headerValue(header.GetValue(), header.GetRawValue())
+		}
+	}
+	if requestConfig.RouteMetadata != nil {
+		api := requestConfig.RouteMetadata.Spec.API
+		input.API = &OPAAPI{
+			RouteMetadata: requestConfig.RouteMetadata.Namespace + "/" + requestConfig.RouteMetadata.Name,
+			Name:          api.Name,
+			Version:       api.Version,
+			Context:       api.Context,
+			Organization:  api.Organization,
+			Environment:   api.Environment,
+		}
+	}
+	return input
+}
+
+// dataURL returns the URL of the rule in the OPA data API.
+func (o *OPAAuthorization) dataURL() string {
+	url := o.ServerURL + "/v1/data"
+	if o.Policy != "" {
+		url += "/" + o.Policy
+	}
+	return url + "/" + o.Rule
+}
+
+// evaluate queries the rule with the input document. An undefined rule denies the request.
+func (o *OPAAuthorization) evaluate(inputJSON []byte) (*OPADecision, error) {
+	if o.ServerURL == "" {
+		return nil, fmt.Errorf("OPA server URL is not configured")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.dataURL(), bytes.NewReader(inputJSON))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.AccessKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.AccessKey)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, truncate(string(body), 256))
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid OPA response: %w", err)
+	}
+	return parseOPADecision(response.Result)
+}
+
+// parseOPADecision parses a boolean or object rule result.
+func parseOPADecision(result json.RawMessage) (*OPADecision, error) {
+	decision := &OPADecision{}
+	if len(result) == 0 {
+		decision.Reason = "OPA policy rule is undefined"
+		return decision, nil
+	}
+	if err := json.Unmarshal(result, &decision.Allow); err == nil {
+		return decision, nil
+	}
+	if err := json.Unmarshal(result, decision); err != nil {
+		return nil, fmt.Errorf("unsupported OPA policy result %s", truncate(string(result), 256))
+	}
+	return decision, nil
+}
+
+// failureResult allows the request when the policy fails open, or rejects it otherwise.
+func (o *OPAAuthorization) failureResult(result *Result) *Result {
+	if o.FailOpen {
+		return result
+	}
+	return rejectedOPAResult(result)
+}
+
+// rejectedOPAResult rejects the request with the OPA authorization failure.
+func rejectedOPAResult(result *Result) *Result {
+	body, _ := json.MarshalIndent(opaFailureMessage, "", "  ")
+	result.StopFurtherProcessing = true
+	result.ImmediateResponse = true
+	result.ImmediateResponseCode = v32.StatusCode_InternalServerError
+	result.ImmediateResponseBody = string(body)
+	result.ImmediateResponseDetail = opaFailureMessage.ErrorDescription
+	result.ImmediateResponseContentType = "application/json"
+	return result
+}
+
+// opaDecisionCache caches the decisions of input documents until they expire. A nil cache
+// caches nothing.
+type opaDecisionCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]opaCachedDecision
+}
+
+type opaCachedDecision struct {
+	decision  *OPADecision
+	expiresAt time.Time
+}
+
+func newOPADecisionCache(ttl time.Duration, maxEntries int) *opaDecisionCache {
+	return &opaDecisionCache{ttl: ttl, maxEntries: maxEntries, entries: map[string]opaCachedDecision{}}
+}
+
+func (c *opaDecisionCache) get(key string) (*OPADecision, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.decision, true
+}
+
+func (c *opaDecisionCache) put(key string, decision *OPADecision) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		// Evict an arbitrary entry when none has expired.
+		for k := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = opaCachedDecision{decision: decision, expiresAt: now.Add(c.ttl)}
+}
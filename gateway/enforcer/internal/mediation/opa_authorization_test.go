@@ -0,0 +1,416 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeOPAServer serves the data API with the result returned by the handler and records the inputs.
+type fakeOPAServer struct {
+	*httptest.Server
+	calls  atomic.Int32
+	inputs chan map[string]interface{}
+	path   atomic.Value
+}
+
+func newFakeOPAServer(t *testing.T, result func(input map[string]interface{}) (int, string)) *fakeOPAServer {
+	t.Helper()
+	s := &fakeOPAServer{inputs: make(chan map[string]interface{}, 10)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls.Add(1)
+		s.path.Store(r.URL.Path)
+		var body struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.inputs <- body.Input
+		status, response := result(body.Input)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func createTestOPAAuthorization(serverURL string, params ...*dpv2alpha1.Parameter) *OPAAuthorization {
+	return NewOPAAuthorization(&dpv2alpha1.Mediation{
+		PolicyName:    MediationOPAAuthorization,
+		PolicyVersion: "v1",
+		PolicyID:      "test-opa-authorization-policy-id",
+		Parameters: append([]*dpv2alpha1.Parameter{
+			{Key: OPAAuthorizationPolicyKeyServerURL, Value: serverURL},
+			{Key: OPAAuthorizationPolicyKeyPolicy, Value: "apk.authz"},
+		}, params...),
+	})
+}
+
+func newOPARequestHolder(path string) *requestconfig.Holder {
+	return &requestconfig.Holder{
+		ProcessingPhase: requestconfig.ProcessingPhaseRequestHeaders,
+		RequestHeaders: &envoy_service_proc_v3.HttpHeaders{
+			Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+				{Key: ":method", Value: "GET"},
+				{Key: ":path", Value: path},
+				{Key: ":authority", Value: "gw.example.com"},
+				{Key: "authorization", Value: "Bearer test-token"},
+				{Key: "x-request-id", RawValue: []byte("request-1")},
+				{Key: "x-tenant", RawValue: []byte("acme")},
+			}},
+		},
+		RouteMetadata: &dpv2alpha1.RouteMetadata{
+			ObjectMeta: metav1.ObjectMeta{Name: "petstore", Namespace: "apk"},
+			Spec: dpv2alpha1.RouteMetadataSpec{API: dpv2alpha1.API{
+				Name: "PetStore", Version: "1.0.0", Context: "/pets/1.0.0", Organization: "org1",
+			}},
+		},
+		JWTAuthnPayloaClaims: map[string]interface{}{"sub": "alice", "scope": "read"},
+		MatchedApplication:   &subscription_model.Application{UUID: "app-1", Name: "PetApp"},
+		MatchedSubscription:  &subscription_model.Subscription{UUID: "sub-1"},
+		EnvType:              "PRODUCTION",
+	}
+}
+
+func TestOPAAuthorization_InputDocument(t *testing.T) {
+	server := newFakeOPAServer(t, func(map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"result": true}`
+	})
+	o := createTestOPAAuthorization(server.URL,
+		&dpv2alpha1.Parameter{Key: OPAAuthorizationPolicyKeyRequestHeaders, Value: "X-Tenant, authorization, x-request-id"})
+	result := o.Process(newOPARequestHolder("/pets/1.0.0/pets"))
+	if result.ImmediateResponse {
+		t.Fatalf("expected the request to be allowed, got %+v", result)
+	}
+	if path := server.path.Load(); path != "/v1/data/apk/authz/allow" {
+		t.Errorf("unexpected OPA data path %v", path)
+	}
+	input := <-server.inputs
+	request := input["request"].(map[string]interface{})
+	if request["method"] != "GET" || request["path"] != "/pets/1.0.0/pets" || request["host"] != "gw.example.com" || request["envType"] != "PRODUCTION" {
+		t.Errorf("unexpected request %v", request)
+	}
+	headers := request["headers"].(map[string]interface{})
+	if headers["x-tenant"] != "acme" {
+		t.Errorf("expected the raw header value, got %v", headers)
+	}
+	for _, name := range []string{"authorization", "x-request-id", ":path"} {
+		if _, found := headers[name]; found {
+			t.Errorf("expected header %s to be excluded from the input", name)
+		}
+	}
+	if _, found := input["accessToken"]; found {
+		t.Errorf("expected the access token to be excluded by default")
+	}
+	if claims := input["claims"].(map[string]interface{}); claims["sub"] != "alice" {
+		t.Errorf("unexpected claims %v", claims)
+	}
+	api := input["api"].(map[string]interface{})
+	if api["name"] != "PetStore" || api["organization"] != "org1" || api["routeMetadata"] != "apk/petstore" {
+		t.Errorf("unexpected api %v", api)
+	}
+	if application := input["application"].(map[string]interface{}); application["uuid"] != "app-1" {
+		t.Errorf("unexpected application %v", application)
+	}
+	if subscription := input["subscription"].(map[string]interface{}); subscription["uuid"] != "sub-1" {
+		t.Errorf("unexpected subscription %v", subscription)
+	}
+}
+
+func TestOPAAuthorization_SendAccessToken(t *testing.T) {
+	server := newFakeOPAServer(t, func(map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"result": true}`
+	})
+	o := createTestOPAAuthorization(server.URL,
+		&dpv2alpha1.Parameter{Key: OPAAuthorizationPolicyKeySendAccessToken, Value: "true"},
+		&dpv2alpha1.Parameter{Key: OPAAuthorizationPolicyKeyAdditionalProperties, Value: `{"tier": "gold"}`})
+	o.Process(newOPARequestHolder("/pets/1.0.0/pets"))
+	input := <-server.inputs
+	if input["accessToken"] != "test-token" {
+		t.Errorf("expected the access token in the input, got %v", input["accessToken"])
+	}
+	if properties := input["additionalProperties"].(map[string]interface{}); properties["tier"] != "gold" {
+		t.Errorf("unexpected additional properties %v", properties)
+	}
+}
+
+func TestOPAAuthorization_Decisions(t *testing.T) {
+	tests := []struct {
+		name           string
+		response       string
+		expectDenied   bool
+		expectHeaders  map[string]string
+		expectInDetail string
+	}{
+		{name: "boolean allow", response: `{"result": true}`},
+		{name: "boolean deny", response: `{"result": false}`, expectDenied: true},
+		{name: "undefined rule", response: `{}`, expectDenied: true, expectInDetail: "undefined"},
+		{
+			name:          "object allow with headers",
+			response:      `{"result": {"allow": true, "headers": {"x-user-tier": "gold"}}}`,
+			expectHeaders: map[string]string{"x-user-tier": "gold"},
+		},
+		{
+			name:           "object deny with reason",
+			response:       `{"result": {"allow": false, "reason": "outside business hours", "headers": {"x-reason": "hours"}}}`,
+			expectDenied:   true,
+			expectHeaders:  map[string]string{"x-reason": "hours"},
+			expectInDetail: "outside business hours",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeOPAServer(t, func(map[string]interface{}) (int, string) {
+				return http.StatusOK, tt.response
+			})
+			result := createTestOPAAuthorization(server.URL).Process(newOPARequestHolder("/pets/1.0.0/pets"))
+			if result.ImmediateResponse != tt.expectDenied {
+				t.Fatalf("expected denied=%v, got %+v", tt.expectDenied, result)
+			}
+			headers := result.AddHeaders
+			if tt.expectDenied {
+				headers = result.ImmediateResponseHeaders
+				if result.ImmediateResponseCode != 403 || !result.StopFurtherProcessing || !strings.Contains(result.ImmediateResponseBody, "900808") {
+					t.Errorf("unexpected deny response %+v", result)
+				}
+			}
+			for name, value := range tt.expectHeaders {
+				if headers[name] != value {
+					t.Errorf("expected header %s=%s, got %v", name, value, headers)
+				}
+			}
+			if tt.expectInDetail != "" && !strings.Contains(result.ImmediateResponseDetail, tt.expectInDetail) {
+				t.Errorf("expected the detail to contain %q, got %q", tt.expectInDetail, result.ImmediateResponseDetail)
+			}
+		})
+	}
+}
+
+func TestOPAAuthorization_DecisionCache(t *testing.T) {
+	server := newFakeOPAServer(t, func(input map[string]interface{}) (int, string) {
+		if input["request"].(map[string]interface{})["path"] == "/pets/1.0.0/pets" {
+			return http.StatusOK, `{"result": true}`
+		}
+		return http.StatusOK, `{"result": false}`
+	})
+	o := createTestOPAAuthorization(server.URL)
+	for i := 0; i < 3; i++ {
+		if result := o.Process(newOPARequestHolder("/pets/1.0.0/pets")); result.ImmediateResponse {
+			t.Fatalf("expected the request to be allowed")
+		}
+	}
+	if calls := server.calls.Load(); calls != 1 {
+		t.Errorf("expected identical inputs to be served from the cache, got %d calls", calls)
+	}
+	if result := o.Process(newOPARequestHolder("/pets/1.0.0/stores")); !result.ImmediateResponse {
+		t.Errorf("expected the other path to be denied")
+	}
+	if calls := server.calls.Load(); calls != 2 {
+		t.Errorf("expected a different input to be evaluated, got %d calls", calls)
+	}
+	holder := newOPARequestHolder("/pets/1.0.0/pets")
+	holder.RequestHeaders.Headers.Headers = append(holder.RequestHeaders.Headers.Headers,
+		&corev3.HeaderValue{Key: "user-agent", RawValue: []byte("curl/8.5.0")})
+	o.Process(holder)
+	if calls := server.calls.Load(); calls != 2 {
+		t.Errorf("expected headers outside the allow-list to share the cached decision, got %d calls", calls)
+	}
+
+	uncached := createTestOPAAuthorization(server.URL, &dpv2alpha1.Parameter{Key: OPAAuthorizationPolicyKeyCacheTTL, Value: "0"})
+	uncached.Process(newOPARequestHolder("/pets/1.0.0/pets"))
+	uncached.Process(newOPARequestHolder("/pets/1.0.0/pets"))
+	if calls := server.calls.Load(); calls != 4 {
+		t.Errorf("expected every request to be evaluated without a cache, got %d calls", calls)
+	}
+}
+
+func TestOPAAuthorization_RequestHeaders(t *testing.T) {
+	server := newFakeOPAServer(t, func(map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"result": true}`
+	})
+	for _, tt := range []struct {
+		name   string
+		params []*dpv2alpha1.Parameter
+		expect bool
+	}{
+		{name: "no headers by default", expect: false},
+		{name: "all headers", params: []*dpv2alpha1.Parameter{{Key: OPAAuthorizationPolicyKeyRequestHeaders, Value: "*"}}, expect: true},
+		{name: "other headers", params: []*dpv2alpha1.Parameter{{Key: OPAAuthorizationPolicyKeyRequestHeaders, Value: "x-user"}}, expect: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			createTestOPAAuthorization(server.URL, tt.params...).Process(newOPARequestHolder("/pets/1.0.0/pets"))
+			headers := (<-server.inputs)["request"].(map[string]interface{})["headers"].(map[string]interface{})
+			if _, found := headers["x-tenant"]; found != tt.expect {
+				t.Errorf("expected x-tenant in the input to be %v, got %v", tt.expect, headers)
+			}
+		})
+	}
+}
+
+func TestOPAAuthorization_Failures(t *testing.T) {
+	failing := newFakeOPAServer(t, func(map[string]interface{}) (int, string) {
+		return http.StatusInternalServerError, `{"code": "internal_error"}`
+	})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"result": true}`))
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		failOpen string
+	}{
+		{name: "server error", url: failing.URL, failOpen: "false"},
+		{name: "timeout", url: slow.URL, failOpen: "false"},
+		{name: "server error fail open", url: failing.URL, failOpen: "true"},
+		{name: "timeout fail open", url: slow.URL, failOpen: "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := createTestOPAAuthorization(tt.url,
+				&dpv2alpha1.Parameter{Key: OPAAuthorizationPolicyKeyTimeout, Value: "50"},
+				&dpv2alpha1.Parameter{Key: OPAAuthorizationPolicyKeyFailOpen, Value: tt.failOpen})
+			result := o.Process(newOPARequestHolder("/pets/1.0.0/pets"))
+			if tt.failOpen == "true" {
+				if result.ImmediateResponse {
+					t.Errorf("expected the request to be allowed when failing open")
+				}
+				return
+			}
+			if !result.ImmediateResponse || result.ImmediateResponseCode != 500 || !strings.Contains(result.ImmediateResponseBody, "900809") {
+				t.Errorf("expected the request to be rejected when failing closed, got %+v", result)
+			}
+		})
+	}
+	// Failed evaluations are not cached.
+	o := createTestOPAAuthorization(failing.URL)
+	o.Process(newOPARequestHolder("/pets/1.0.0/pets"))
+	before := failing.calls.Load()
+	o.Process(newOPARequestHolder("/pets/1.0.0/pets"))
+	if failing.calls.Load() != before+1 {
+		t.Errorf("expected failed evaluations to be retried")
+	}
+}
+
+func TestOPAAuthorization_Disabled(t *testing.T) {
+	o := createTestOPAAuthorization("http://127.0.0.1:1", &dpv2alpha1.Parameter{Key: OPAAuthorizationPolicyKeyEnabled, Value: "false"})
+	if result := o.Process(newOPARequestHolder("/pets")); result.ImmediateResponse {
+		t.Errorf("expected a disabled policy to allow the request")
+	}
+}
+
+// writeTestKeyPair writes a self-signed client certificate and its key, returning their paths.
+func writeTestKeyPair(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "opa.pem"), filepath.Join(dir, "opa.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certPath, keyPath
+}
+
+func TestOPATLSConfig(t *testing.T) {
+	certPath, keyPath := writeTestKeyPair(t)
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	tests := []struct {
+		name      string
+		serverURL string
+		certPath  string
+		wantCerts int
+		wantErr   bool
+	}{
+		{name: "HTTPS with the client key pair", serverURL: "https://opa:8181", certPath: certPath, wantCerts: 1},
+		{name: "HTTPS without the client key pair", serverURL: "https://opa:8181", certPath: missing, wantErr: true},
+		{name: "HTTP does not load the client key pair", serverURL: "http://opa:8181", certPath: missing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Server{
+				Logger:                  config.GetConfig().Logger,
+				OpaClientPublicKeyPath:  tt.certPath,
+				OpaClientPrivateKeyPath: keyPath,
+				TrustedAdapterCertsPath: t.TempDir(),
+			}
+			tlsConfig, err := opaTLSConfig(cfg, tt.serverURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(tlsConfig.Certificates) != tt.wantCerts {
+				t.Errorf("expected %d client certificates, got %d", tt.wantCerts, len(tlsConfig.Certificates))
+			}
+		})
+	}
+}
+
+func TestOPAAuthorization_MissingClientCertificateFailsClosed(t *testing.T) {
+	cfg := config.GetConfig()
+	certPath, keyPath := cfg.OpaClientPublicKeyPath, cfg.OpaClientPrivateKeyPath
+	cfg.OpaClientPublicKeyPath = filepath.Join(t.TempDir(), "missing.pem")
+	cfg.OpaClientPrivateKeyPath = filepath.Join(t.TempDir(), "missing.key")
+	t.Cleanup(func() { cfg.OpaClientPublicKeyPath, cfg.OpaClientPrivateKeyPath = certPath, keyPath })
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result": true}`))
+	}))
+	defer server.Close()
+	o := createTestOPAAuthorization(server.URL, &dpv2alpha1.Parameter{Key: OPAAuthorizationPolicyKeyFailOpen, Value: "true"})
+	result := o.Process(newOPARequestHolder("/pets/1.0.0/pets"))
+	if !result.ImmediateResponse || result.ImmediateResponseCode != 500 || !strings.Contains(result.ImmediateResponseBody, "900809") {
+		t.Errorf("expected the request to be rejected even though the policy fails open, got %+v", result)
+	}
+}
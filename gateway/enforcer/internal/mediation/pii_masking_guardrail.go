@@ -30,6 +30,7 @@ import (
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/tidwall/gjson"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
@@ -54,11 +55,11 @@ const (
 	// PIIMaskingGuardrailPolicyKeyName is the key for specifying the name of the guardrail.
 	PIIMaskingGuardrailPolicyKeyName = "name"
 	// PIIMaskingGuardrailPolicyKeyPiiEntities is the key for specifying the PII entities and their regex patterns.
-	PIIMaskingGuardrailPolicyKeyPiiEntities = "piiEntities"
+	PIIMaskingGuardrailPolicyKeyPiiEntities = constantscommon.PIIMaskingGuardrailPolicyKeyPiiEntities
 	// PIIMaskingGuardrailPolicyKeyJSONPath is the key for specifying the JSON path to extract content.
 	PIIMaskingGuardrailPolicyKeyJSONPath = "jsonPath"
 	// PIIMaskingGuardrailPolicyKeyRedactPII is the key for specifying if PII should be redacted instead of masked.
-	PIIMaskingGuardrailPolicyKeyRedactPII = "redactPII"
+	PIIMaskingGuardrailPolicyKeyRedactPII = constantscommon.PIIMaskingGuardrailPolicyKeyRedactPII
 	// PIIMaskingGuardrailPolicyKeyShowAssessment is the key for specifying if assessment should be shown.
 	PIIMaskingGuardrailPolicyKeyShowAssessment = "showAssessment"
 
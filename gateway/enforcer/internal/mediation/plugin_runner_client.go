@@ -0,0 +1,148 @@
+package mediation
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/keepalive"
+)
+
+// pluginRunnerJSONCodec marshals plugin runner messages as JSON over gRPC.
+type pluginRunnerJSONCodec struct{}
+
+func (pluginRunnerJSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (pluginRunnerJSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (pluginRunnerJSONCodec) Name() string                       { return commonmediation.PluginRunnerCodec }
+
+func init() {
+	encoding.RegisterCodec(pluginRunnerJSONCodec{})
+}
+
+// PluginRunnerClient is a pooled client to a long-lived plugin runner sidecar.
+// Calls are spread across the pooled connections in round-robin order.
+type PluginRunnerClient struct {
+	address string
+	conns   []*grpc.ClientConn
+	next    uint32
+}
+
+var (
+	pluginRunnerClients   = map[string]*PluginRunnerClient{}
+	pluginRunnerClientsMu sync.Mutex
+)
+
+// GetPluginRunnerClient returns the shared client for the address, creating it on first use.
+// The address can be unix:///path/to.sock, tcp://host:port or host:port. The runner only serves TCP
+// with mutual TLS, so TCP connections use the enforcer certificate.
+func GetPluginRunnerClient(cfg *config.Server, address string) (*PluginRunnerClient, error) {
+	pluginRunnerClientsMu.Lock()
+	defer pluginRunnerClientsMu.Unlock()
+	if c, ok := pluginRunnerClients[address]; ok {
+		return c, nil
+	}
+	var tlsConfig *tls.Config
+	if !isUnixPluginRunnerAddress(address) {
+		var err error
+		if tlsConfig, err = pluginRunnerTLSConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+	c, err := newPluginRunnerClient(address, cfg.PluginRunnerPoolSize, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	pluginRunnerClients[address] = c
+	return c, nil
+}
+
+// pluginRunnerTLSConfig returns the client TLS configuration of the enforcer certificate.
+func pluginRunnerTLSConfig(cfg *config.Server) (*tls.Config, error) {
+	cert, err := util.LoadCertificates(cfg.EnforcerPublicKeyPath, cfg.EnforcerPrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	certPool, err := util.LoadCACertificates(cfg.TrustedAdapterCertsPath)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := util.CreateTLSConfig(cert, certPool)
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return tlsConfig, nil
+}
+
+// newPluginRunnerClient creates a client, using TLS when tlsConfig is set. Unix sockets are plaintext.
+func newPluginRunnerClient(address string, poolSize int, tlsConfig *tls.Config) (*PluginRunnerClient, error) {
+	if poolSize <= 0 {
+		poolSize = 1
+	}
+	if tlsConfig == nil && !isUnixPluginRunnerAddress(address) {
+		return nil, fmt.Errorf("plugin runner at %s is not a unix socket and requires TLS", address)
+	}
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	target := pluginRunnerTarget(address)
+	kacp := keepalive.ClientParameters{
+		Time:                300 * time.Second,
+		PermitWithoutStream: true,
+	}
+	c := &PluginRunnerClient{address: address}
+	for i := 0; i < poolSize; i++ {
+		conn, err := grpc.NewClient(target,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithKeepaliveParams(kacp),
+			grpc.WithDefaultCallOptions(grpc.CallContentSubtype(commonmediation.PluginRunnerCodec)),
+		)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to create plugin runner connection to %s: %v", address, err)
+		}
+		c.conns = append(c.conns, conn)
+	}
+	return c, nil
+}
+
+func isUnixPluginRunnerAddress(address string) bool {
+	return strings.HasPrefix(address, "unix:")
+}
+
+// pluginRunnerTarget converts the configured address to a gRPC target.
+func pluginRunnerTarget(address string) string {
+	if isUnixPluginRunnerAddress(address) {
+		return address
+	}
+	return "passthrough:///" + strings.TrimPrefix(address, "tcp://")
+}
+
+// Invoke calls the plugin symbol loaded from pluginPath in the runner with the given input.
+func (c *PluginRunnerClient) Invoke(ctx context.Context, pluginPath, symbol string,
+	in *commonmediation.ExternalInput) (*commonmediation.ExternalOutput, error) {
+	req := &commonmediation.PluginRunnerRequest{PluginPath: pluginPath, Symbol: symbol, Input: *in}
+	resp := &commonmediation.PluginRunnerResponse{}
+	conn := c.conns[atomic.AddUint32(&c.next, 1)%uint32(len(c.conns))]
+	if err := conn.Invoke(ctx, commonmediation.PluginRunnerProcessMethod, req, resp); err != nil {
+		return nil, fmt.Errorf("plugin runner call to %s failed: %w", c.address, err)
+	}
+	return &resp.Output, nil
+}
+
+// Close closes all pooled connections.
+func (c *PluginRunnerClient) Close() {
+	for _, conn := range c.conns {
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,100 @@
+package mediation
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"google.golang.org/grpc"
+)
+
+type fakePluginRunner interface{}
+
+// startFakePluginRunner serves the plugin runner Process method on a unix socket and echoes the request.
+func startFakePluginRunner(t *testing.T) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "runner.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: commonmediation.PluginRunnerServiceName,
+		HandlerType: (*fakePluginRunner)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Process",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := &commonmediation.PluginRunnerRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return &commonmediation.PluginRunnerResponse{Output: commonmediation.ExternalOutput{
+					AddHeaders: map[string]string{
+						"x-plugin": req.PluginPath + "#" + req.Symbol,
+						"x-phase":  req.Input.Phase,
+					},
+				}}, nil
+			},
+		}},
+	}, struct{}{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return "unix://" + socket
+}
+
+func TestPluginRunnerClient_Invoke(t *testing.T) {
+	address := startFakePluginRunner(t)
+	client, err := newPluginRunnerClient(address, 2, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 4; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		out, err := client.Invoke(ctx, "/plugins/p.so", "ProcessJSON", &commonmediation.ExternalInput{Phase: "REQUEST_HEADERS"})
+		cancel()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.AddHeaders["x-plugin"] != "/plugins/p.so#ProcessJSON" || out.AddHeaders["x-phase"] != "REQUEST_HEADERS" {
+			t.Fatalf("unexpected output: %+v", out.AddHeaders)
+		}
+	}
+}
+
+func TestPluginRunnerClient_Unavailable(t *testing.T) {
+	client, err := newPluginRunnerClient("unix://"+filepath.Join(t.TempDir(), "missing.sock"), 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := client.Invoke(ctx, "/plugins/p.so", "ProcessJSON", &commonmediation.ExternalInput{}); err == nil {
+		t.Fatalf("expected error for unavailable runner")
+	}
+}
+
+func TestPluginRunnerClient_TCPRequiresTLS(t *testing.T) {
+	if _, err := newPluginRunnerClient("tcp://localhost:50061", 1, nil); err == nil {
+		t.Fatalf("expected error for a TCP runner without TLS")
+	}
+}
+
+func TestPluginRunnerTarget(t *testing.T) {
+	tests := map[string]string{
+		"unix:///var/run/apk/runner.sock": "unix:///var/run/apk/runner.sock",
+		"tcp://localhost:50061":           "passthrough:///localhost:50061",
+		"plugin-runner:50061":             "passthrough:///plugin-runner:50061",
+	}
+	for in, want := range tests {
+		if got := pluginRunnerTarget(in); got != want {
+			t.Errorf("pluginRunnerTarget(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/tidwall/gjson"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+// PromptInjectionGuardrail represents the configuration for Prompt Injection Guardrail policy in the API Gateway.
+// The content extracted with the JSONPath is scored by a PromptInjectionScorer and the payload is
+// rejected when the score reaches the threshold.
+type PromptInjectionGuardrail struct {
+	PolicyName     string        `json:"policyName"`
+	PolicyVersion  string        `json:"policyVersion"`
+	PolicyID       string        `json:"policyID"`
+	Name           string        `json:"name"`
+	JSONPath       string        `json:"jsonPath"`
+	Threshold      float64       `json:"threshold"`
+	Scorer         string        `json:"scorer"`
+	Timeout        time.Duration `json:"timeout"`
+	FailOpen       bool          `json:"failOpen"`
+	ShowAssessment bool          `json:"showAssessment"`
+	scorer         PromptInjectionScorer
+	scorerErr      error
+	logger         *logging.Logger
+	cfg            *config.Server
+}
+
+const (
+	// PromptInjectionGuardrailPolicyKeyName is the key for specifying the name of the guardrail.
+	PromptInjectionGuardrailPolicyKeyName = "name"
+	// PromptInjectionGuardrailPolicyKeyJSONPath is the key for specifying the JSON path to extract content.
+	PromptInjectionGuardrailPolicyKeyJSONPath = "jsonPath"
+	// PromptInjectionGuardrailPolicyKeyThreshold is the key for specifying the score at which content is rejected.
+	PromptInjectionGuardrailPolicyKeyThreshold = constantscommon.PromptInjectionGuardrailPolicyKeyThreshold
+	// PromptInjectionGuardrailPolicyKeyScorer is the key for specifying the scorer used to score the content.
+	PromptInjectionGuardrailPolicyKeyScorer = constantscommon.PromptInjectionGuardrailPolicyKeyScorer
+	// PromptInjectionGuardrailPolicyKeyPatterns is the key for specifying additional patterns for the heuristic scorer.
+	PromptInjectionGuardrailPolicyKeyPatterns = constantscommon.PromptInjectionGuardrailPolicyKeyPatterns
+	// PromptInjectionGuardrailPolicyKeyClassifierEndpoint is the key for specifying the external classifier endpoint.
+	PromptInjectionGuardrailPolicyKeyClassifierEndpoint = constantscommon.PromptInjectionGuardrailPolicyKeyClassifierEndpoint
+	// PromptInjectionGuardrailPolicyKeyTimeout is the key for specifying the classifier timeout in milliseconds.
+	PromptInjectionGuardrailPolicyKeyTimeout = constantscommon.PromptInjectionGuardrailPolicyKeyTimeout
+	// PromptInjectionGuardrailPolicyKeyFailOpen is the key for specifying if payloads pass when scoring fails.
+	PromptInjectionGuardrailPolicyKeyFailOpen = constantscommon.PromptInjectionGuardrailPolicyKeyFailOpen
+	// PromptInjectionGuardrailPolicyKeyShowAssessment is the key for specifying if assessment should be shown.
+	PromptInjectionGuardrailPolicyKeyShowAssessment = "showAssessment"
+	// PromptInjectionGuardrailConstant is the identifier for prompt injection guardrail errors.
+	PromptInjectionGuardrailConstant = "PROMPT_INJECTION_GUARDRAIL"
+
+	defaultPromptInjectionThreshold = 0.5
+	defaultPromptInjectionTimeout   = time.Second
+)
+
+// NewPromptInjectionGuardrail creates a new PromptInjectionGuardrail instance.
+func NewPromptInjectionGuardrail(mediation *dpv2alpha1.Mediation) *PromptInjectionGuardrail {
+	cfg := config.GetConfig()
+	logger := cfg.Logger
+
+	name := "PromptInjectionGuardrail"
+	if val, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyName); ok {
+		name = val
+	}
+
+	jsonPath := "$.content"
+	if val, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyJSONPath); ok {
+		jsonPath = val
+	}
+
+	threshold := defaultPromptInjectionThreshold
+	if val, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyThreshold); ok {
+		if parsed, err := strconv.ParseFloat(val, 64); err == nil && parsed > 0 {
+			threshold = parsed
+		}
+	}
+
+	scorer := PromptInjectionScorerHeuristic
+	if val, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyScorer); ok && val != "" {
+		scorer = val
+	}
+
+	timeout := defaultPromptInjectionTimeout
+	if val, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyTimeout); ok {
+		if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 {
+			timeout = time.Duration(parsed) * time.Millisecond
+		}
+	}
+
+	failOpen := false
+	if val, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyFailOpen); ok {
+		failOpen = val == "true"
+	}
+
+	showAssessment := false
+	if val, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyShowAssessment); ok {
+		showAssessment = val == "true"
+	}
+
+	guardrail := &PromptInjectionGuardrail{
+		PolicyName:     "PromptInjectionGuardrail",
+		PolicyVersion:  mediation.PolicyVersion,
+		PolicyID:       mediation.PolicyID,
+		Name:           name,
+		JSONPath:       jsonPath,
+		Threshold:      threshold,
+		Scorer:         scorer,
+		Timeout:        timeout,
+		FailOpen:       failOpen,
+		ShowAssessment: showAssessment,
+		logger:         &logger,
+		cfg:            cfg,
+	}
+	guardrail.scorer, guardrail.scorerErr = newPromptInjectionScorer(scorer, mediation)
+	if guardrail.scorerErr != nil {
+		logger.Sugar().Errorf("Error creating the %s scorer for PromptInjectionGuardrail policy %s: %v", scorer, name, guardrail.scorerErr)
+	}
+	return guardrail
+}
+
+// Process processes the request configuration for Prompt Injection Guardrail.
+func (p *PromptInjectionGuardrail) Process(requestConfig *requestconfig.Holder) *Result {
+	result := NewResult()
+
+	// Handle request body processing
+	if requestConfig.ProcessingPhase == requestconfig.ProcessingPhaseRequestBody {
+		p.logger.Sugar().Debugf("Beginning request payload validation for PromptInjectionGuardrail policy: %s", p.Name)
+
+		if requestConfig.RequestBody == nil || requestConfig.RequestBody.Body == nil {
+			p.logger.Sugar().Debug("No request body found, skipping prompt injection validation")
+			return result
+		}
+
+		validationResult, score, err := p.validatePayload(requestConfig.RequestBody.Body, false)
+		if !validationResult {
+			p.logger.Sugar().Debugf("Request payload validation failed for PromptInjectionGuardrail policy: %s", p.Name)
+			return p.buildErrorResponse(false, score, err)
+		}
+		p.logger.Sugar().Debugf("Request payload validation passed for PromptInjectionGuardrail policy: %s", p.Name)
+		return result
+	}
+
+	// Handle response body processing
+	if requestConfig.ProcessingPhase == requestconfig.ProcessingPhaseResponseBody {
+		p.logger.Sugar().Debugf("Beginning response body validation for PromptInjectionGuardrail policy: %s", p.Name)
+
+		if requestConfig.ResponseBody == nil || requestConfig.ResponseBody.Body == nil {
+			p.logger.Sugar().Debug("No response body found, skipping prompt injection validation")
+			return result
+		}
+
+		validationResult, score, err := p.validatePayload(requestConfig.ResponseBody.Body, true)
+		if !validationResult {
+			p.logger.Sugar().Debugf("Response body validation failed for PromptInjectionGuardrail policy: %s", p.Name)
+			return p.buildErrorResponse(true, score, err)
+		}
+		p.logger.Sugar().Debugf("Response body validation passed for PromptInjectionGuardrail policy: %s", p.Name)
+		return result
+	}
+
+	return result
+}
+
+// validatePayload validates the payload against the PromptInjectionGuardrail policy. The score is
+// returned when the content was scored, and the error when the content could not be extracted or scored.
+func (p *PromptInjectionGuardrail) validatePayload(payload []byte, isResponse bool) (bool, *PromptInjectionScore, error) {
+	// Decompress response body if needed
+	if isResponse {
+		bodyStr, err := p.decompressLLMResp(payload)
+		if err == nil {
+			payload = []byte(bodyStr)
+		}
+	}
+
+	// Extract value using JSONPath
+	extractedValue, err := p.extractStringValueFromJsonpath(payload, p.JSONPath)
+	if err != nil {
+		p.logger.Error(err, "Error extracting value from JSON using JSONPath")
+		return false, nil, err
+	}
+	if extractedValue == "" {
+		p.logger.Sugar().Debugf("No content found at JSONPath %s, skipping prompt injection scoring", p.JSONPath)
+		return true, nil, nil
+	}
+
+	score, err := p.score(extractedValue)
+	if err != nil {
+		if p.FailOpen {
+			p.logger.Sugar().Warnf("Prompt injection scoring failed for PromptInjectionGuardrail policy %s, allowing payload: %v", p.Name, err)
+			return true, nil, nil
+		}
+		p.logger.Error(err, "Error scoring content for prompt injection")
+		return false, nil, &promptInjectionScoringError{err: err}
+	}
+
+	if score.Score >= p.Threshold {
+		p.logger.Sugar().Debugf("Prompt injection score %.2f reached threshold %.2f, returning false", score.Score, p.Threshold)
+		return false, score, nil
+	}
+
+	p.logger.Sugar().Debugf("Prompt injection score %.2f is below threshold %.2f, returning true", score.Score, p.Threshold)
+	return true, score, nil
+}
+
+// score scores the content with the configured scorer, bounded by the timeout.
+func (p *PromptInjectionGuardrail) score(content string) (*PromptInjectionScore, error) {
+	if p.scorer == nil {
+		if p.scorerErr != nil {
+			return nil, p.scorerErr
+		}
+		return nil, fmt.Errorf("no prompt injection scorer configured")
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultPromptInjectionTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return p.scorer.Score(ctx, content)
+}
+
+// decompressLLMResp decompresses the response body if it's gzip compressed.
+func (p *PromptInjectionGuardrail) decompressLLMResp(body []byte) (string, error) {
+	reader := bytes.NewReader(body)
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		// If it's not gzip compressed, return the original body as string
+		return string(body), nil
+	}
+	defer gzipReader.Close()
+
+	decompressed, err := io.ReadAll(gzipReader)
+	if err != nil {
+		return "", err
+	}
+	return string(decompressed), nil
+}
+
+// extractStringValueFromJsonpath extracts a string value from JSON using the provided JSONPath.
+func (p *PromptInjectionGuardrail) extractStringValueFromJsonpath(payload []byte, jsonPath string) (string, error) {
+	bodyString := string(payload)
+	// Convert JSONPath to gjson compatible path
+	gjsonPath := convertJSONPathToGjsonPath(removeDollarPrefix(jsonPath))
+	result := gjson.Get(bodyString, gjsonPath)
+
+	if !result.Exists() {
+		return "", nil
+	}
+
+	return result.String(), nil
+}
+
+// buildErrorResponse builds an error response for the PromptInjectionGuardrail policy.
+func (p *PromptInjectionGuardrail) buildErrorResponse(isResponse bool, score *PromptInjectionScore, validationError error) *Result {
+	result := NewResult()
+
+	responseBody := make(map[string]interface{})
+	responseBody[RegexErrorCode] = RegexGuardrailAPIMExceptionCode
+	responseBody[RegexErrorType] = PromptInjectionGuardrailConstant
+	responseBody[RegexErrorMessage] = p.buildAssessmentObject(isResponse, score, validationError)
+
+	bodyBytes, err := json.Marshal(responseBody)
+	if err != nil {
+		p.logger.Error(err, "Error marshaling response body to JSON")
+		return result
+	}
+
+	result.ImmediateResponse = true
+	result.ImmediateResponseCode = v32.StatusCode(RegexGuardrailErrorCode)
+	result.ImmediateResponseBody = string(bodyBytes)
+	result.ImmediateResponseContentType = "application/json"
+	result.StopFurtherProcessing = true
+
+	return result
+}
+
+// buildAssessmentObject builds the assessment object for the PromptInjectionGuardrail policy.
+func (p *PromptInjectionGuardrail) buildAssessmentObject(isResponse bool, score *PromptInjectionScore, validationError error) map[string]interface{} {
+	p.logger.Sugar().Debugf("Building assessment object for PromptInjectionGuardrail policy: %s", p.Name)
+	assessment := make(map[string]interface{})
+	assessment[RegexAssessmentAction] = "GUARDRAIL_INTERVENED"
+	assessment[RegexInterveningGuardrail] = p.Name
+	if isResponse {
+		assessment[RegexDirection] = "RESPONSE"
+	} else {
+		assessment[RegexDirection] = "REQUEST"
+	}
+
+	if scoringError, ok := validationError.(*promptInjectionScoringError); ok {
+		assessment[RegexAssessmentReason] = "Error scoring content for prompt injection."
+		if p.ShowAssessment {
+			assessment[RegexAssessments] = "Prompt injection scoring failed: " + scoringError.Error()
+		}
+	} else if validationError != nil {
+		assessment[RegexAssessmentReason] = "Error extracting content from payload using JSONPath."
+		if p.ShowAssessment {
+			assessmentMessage := "JSONPath extraction failed: " + validationError.Error() + ". Please check the JSONPath configuration: " + p.JSONPath
+			assessment[RegexAssessments] = assessmentMessage
+		}
+	} else {
+		assessment[RegexAssessmentReason] = "Violation of prompt injection detection."
+		if p.ShowAssessment && score != nil {
+			assessmentMessage := fmt.Sprintf("Prompt injection score %.2f reached the threshold %.2f", score.Score, p.Threshold)
+			if len(score.Reasons) > 0 {
+				assessmentMessage += ": " + strings.Join(score.Reasons, "; ")
+			}
+			assessment[RegexAssessments] = assessmentMessage
+		}
+	}
+	return assessment
+}
+
+// promptInjectionScoringError marks a failure of the scorer, as opposed to a JSONPath extraction failure.
+type promptInjectionScoringError struct {
+	err error
+}
+
+func (e *promptInjectionScoringError) Error() string { return e.err.Error() }
+
+func (e *promptInjectionScoringError) Unwrap() error { return e.err }
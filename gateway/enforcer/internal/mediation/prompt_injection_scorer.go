@@ -0,0 +1,303 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// PromptInjectionScorerHeuristic scores content with the built-in patterns.
+	PromptInjectionScorerHeuristic = "heuristic"
+	// PromptInjectionScorerHTTP scores content with an external classifier called over HTTP.
+	PromptInjectionScorerHTTP = "http"
+	// PromptInjectionScorerGRPC scores content with an external classifier called over gRPC.
+	PromptInjectionScorerGRPC = "grpc"
+
+	// PromptInjectionClassifierServiceName is the fully qualified gRPC service name of an external classifier.
+	// Like the plugin runner, the classifier serves a unary method carrying JSON payloads.
+	PromptInjectionClassifierServiceName = "wso2.apk.guardrail.PromptInjectionClassifier"
+	// PromptInjectionClassifierMethod is the full gRPC method path used to classify content.
+	PromptInjectionClassifierMethod = "/" + PromptInjectionClassifierServiceName + "/Classify"
+)
+
+// PromptInjectionScore is the outcome of scoring content for prompt injection.
+type PromptInjectionScore struct {
+	// Score is the likelihood of the content being a prompt injection, between 0 and 1.
+	Score float64 `json:"score"`
+	// Label is an optional classification returned by external classifiers.
+	Label string `json:"label,omitempty"`
+	// Reasons describe the signals that contributed to the score.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// PromptInjectionScorer scores content for prompt injection and jailbreak attempts.
+type PromptInjectionScorer interface {
+	Score(ctx context.Context, content string) (*PromptInjectionScore, error)
+}
+
+// PromptInjectionScorerFactory creates a scorer from the guardrail policy.
+type PromptInjectionScorerFactory func(mediation *dpv2alpha1.Mediation) (PromptInjectionScorer, error)
+
+var (
+	promptInjectionScorers = map[string]PromptInjectionScorerFactory{
+		PromptInjectionScorerHeuristic: newHeuristicPromptInjectionScorerFromPolicy,
+		PromptInjectionScorerHTTP:      newHTTPPromptInjectionScorerFromPolicy,
+		PromptInjectionScorerGRPC:      newGRPCPromptInjectionScorerFromPolicy,
+	}
+	promptInjectionScorersMu sync.RWMutex
+)
+
+// RegisterPromptInjectionScorer registers a scorer selectable with the scorer parameter of the
+// PromptInjectionGuardrail policy. Registering an existing name replaces the scorer.
+func RegisterPromptInjectionScorer(name string, factory PromptInjectionScorerFactory) {
+	promptInjectionScorersMu.Lock()
+	defer promptInjectionScorersMu.Unlock()
+	promptInjectionScorers[name] = factory
+}
+
+func newPromptInjectionScorer(name string, mediation *dpv2alpha1.Mediation) (PromptInjectionScorer, error) {
+	promptInjectionScorersMu.RLock()
+	factory, ok := promptInjectionScorers[name]
+	promptInjectionScorersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt injection scorer %q", name)
+	}
+	return factory(mediation)
+}
+
+// PromptInjectionPattern is a weighted pattern of the heuristic scorer.
+type PromptInjectionPattern struct {
+	Pattern string  `json:"pattern"`
+	Weight  float64 `json:"weight"`
+	Reason  string  `json:"reason"`
+	regex   *regexp.Regexp
+}
+
+// defaultPromptInjectionPatterns are common injection and jailbreak phrasings. The patterns are
+// matched case-insensitively against the content with whitespace collapsed.
+var defaultPromptInjectionPatterns = []PromptInjectionPattern{
+	{
+		Pattern: `\b(ignore|disregard|forget|skip|override)\b.{0,40}\b(previous|prior|above|earlier|preceding|all|your|system)\b.{0,20}\b(instructions?|prompts?|rules|directions|guidelines|context)\b`,
+		Weight:  0.9,
+		Reason:  "Instruction override attempt",
+	},
+	{
+		Pattern: `\b(reveal|show|print|repeat|output|display|leak|tell me)\b.{0,30}\b(system|hidden|initial|original|secret)\s+(prompt|instructions?|message)`,
+		Weight:  0.8,
+		Reason:  "System prompt extraction attempt",
+	},
+	{
+		Pattern: `\b(do anything now|dan mode|developer mode|god mode|jailbr(eak|oken))\b`,
+		Weight:  0.8,
+		Reason:  "Known jailbreak persona",
+	},
+	{
+		Pattern: `\b(bypass|disable|turn off|remove|circumvent)\b.{0,30}\b(safety|content|security|ethical|moderation)\s+(filters?|guidelines|restrictions|policies|rules|checks)`,
+		Weight:  0.75,
+		Reason:  "Safety bypass attempt",
+	},
+	{
+		Pattern: `\b(pretend|act as if|imagine|roleplay as|you are now)\b.{0,60}\b(no|without|free of|unbound by)\s+(restrictions|limitations|filters|rules|guidelines|censorship)`,
+		Weight:  0.7,
+		Reason:  "Unrestricted role-play attempt",
+	},
+	{
+		Pattern: `(<\|im_start\|>\s*system|\[/?inst\]|<<sys>>|###\s*(system|instruction)|\bnew (system )?instructions\s*:)`,
+		Weight:  0.6,
+		Reason:  "Prompt delimiter injection",
+	},
+	{
+		Pattern: `\bfrom now on\b.{0,40}\b(you (will|must|are)|respond|answer|always)\b`,
+		Weight:  0.4,
+		Reason:  "Persistent behaviour change request",
+	},
+}
+
+var promptInjectionWhitespace = regexp.MustCompile(`\s+`)
+
+// HeuristicPromptInjectionScorer scores content by matching weighted patterns. The score combines the
+// weights of the matched patterns as independent signals, so that it grows with every match.
+type HeuristicPromptInjectionScorer struct {
+	patterns []PromptInjectionPattern
+}
+
+// NewHeuristicPromptInjectionScorer creates a heuristic scorer with the default patterns and the
+// given additional patterns. A pattern without a weight counts as a certain match.
+func NewHeuristicPromptInjectionScorer(additional []PromptInjectionPattern) (*HeuristicPromptInjectionScorer, error) {
+	patterns := make([]PromptInjectionPattern, 0, len(defaultPromptInjectionPatterns)+len(additional))
+	for _, pattern := range append(append([]PromptInjectionPattern{}, defaultPromptInjectionPatterns...), additional...) {
+		regex, err := regexp.Compile("(?i)" + pattern.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt injection pattern %q: %w", pattern.Pattern, err)
+		}
+		pattern.regex = regex
+		if pattern.Weight <= 0 || pattern.Weight > 1 {
+			pattern.Weight = 1
+		}
+		if pattern.Reason == "" {
+			pattern.Reason = "Matched pattern " + pattern.Pattern
+		}
+		patterns = append(patterns, pattern)
+	}
+	return &HeuristicPromptInjectionScorer{patterns: patterns}, nil
+}
+
+// Score implements PromptInjectionScorer.
+func (h *HeuristicPromptInjectionScorer) Score(_ context.Context, content string) (*PromptInjectionScore, error) {
+	normalized := promptInjectionWhitespace.ReplaceAllString(content, " ")
+	score := &PromptInjectionScore{}
+	remaining := 1.0
+	for _, pattern := range h.patterns {
+		if pattern.regex.MatchString(normalized) {
+			remaining *= 1 - pattern.Weight
+			score.Reasons = append(score.Reasons, pattern.Reason)
+		}
+	}
+	score.Score = 1 - remaining
+	return score, nil
+}
+
+func newHeuristicPromptInjectionScorerFromPolicy(mediation *dpv2alpha1.Mediation) (PromptInjectionScorer, error) {
+	var additional []PromptInjectionPattern
+	if val, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyPatterns); ok && val != "" {
+		if err := json.Unmarshal([]byte(val), &additional); err != nil {
+			return nil, fmt.Errorf("invalid %s parameter: %w", PromptInjectionGuardrailPolicyKeyPatterns, err)
+		}
+	}
+	return NewHeuristicPromptInjectionScorer(additional)
+}
+
+// promptInjectionClassifierRequest is the request sent to external classifiers.
+type promptInjectionClassifierRequest struct {
+	Content string `json:"content"`
+}
+
+// HTTPPromptInjectionScorer scores content with an external classifier. The content is posted as
+// {"content": "..."} and the classifier responds with a PromptInjectionScore.
+type HTTPPromptInjectionScorer struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTPPromptInjectionScorer creates a scorer calling the classifier at the endpoint.
+func NewHTTPPromptInjectionScorer(endpoint string) *HTTPPromptInjectionScorer {
+	return &HTTPPromptInjectionScorer{endpoint: endpoint, client: &http.Client{}}
+}
+
+// Score implements PromptInjectionScorer.
+func (h *HTTPPromptInjectionScorer) Score(ctx context.Context, content string) (*PromptInjectionScore, error) {
+	payload, err := json.Marshal(promptInjectionClassifierRequest{Content: content})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("prompt injection classifier call to %s failed: %w", h.endpoint, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("prompt injection classifier %s responded with status %d", h.endpoint, resp.StatusCode)
+	}
+	score := &PromptInjectionScore{}
+	if err := json.Unmarshal(body, score); err != nil {
+		return nil, fmt.Errorf("invalid prompt injection classifier response: %w", err)
+	}
+	return score, nil
+}
+
+func newHTTPPromptInjectionScorerFromPolicy(mediation *dpv2alpha1.Mediation) (PromptInjectionScorer, error) {
+	endpoint, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyClassifierEndpoint)
+	if !ok || endpoint == "" {
+		return nil, fmt.Errorf("the %s parameter is required for the %s scorer", PromptInjectionGuardrailPolicyKeyClassifierEndpoint, PromptInjectionScorerHTTP)
+	}
+	return NewHTTPPromptInjectionScorer(endpoint), nil
+}
+
+// GRPCPromptInjectionScorer scores content with an external classifier serving
+// PromptInjectionClassifierMethod with the plugin runner JSON codec.
+type GRPCPromptInjectionScorer struct {
+	address string
+	conn    *grpc.ClientConn
+}
+
+var (
+	promptInjectionClassifierConns   = map[string]*grpc.ClientConn{}
+	promptInjectionClassifierConnsMu sync.Mutex
+)
+
+// NewGRPCPromptInjectionScorer creates a scorer calling the classifier at the address, sharing the
+// connection with other scorers of the same address. The address can be unix:///path/to.sock,
+// tcp://host:port or host:port.
+func NewGRPCPromptInjectionScorer(address string) (*GRPCPromptInjectionScorer, error) {
+	promptInjectionClassifierConnsMu.Lock()
+	defer promptInjectionClassifierConnsMu.Unlock()
+	conn, ok := promptInjectionClassifierConns[address]
+	if !ok {
+		var err error
+		// The classifier is expected on the pod network or a local socket, like the plugin runner.
+		conn, err = grpc.NewClient(pluginRunnerTarget(address),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.CallContentSubtype(commonmediation.PluginRunnerCodec)),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create prompt injection classifier connection to %s: %v", address, err)
+		}
+		promptInjectionClassifierConns[address] = conn
+	}
+	return &GRPCPromptInjectionScorer{address: address, conn: conn}, nil
+}
+
+// Score implements PromptInjectionScorer.
+func (g *GRPCPromptInjectionScorer) Score(ctx context.Context, content string) (*PromptInjectionScore, error) {
+	score := &PromptInjectionScore{}
+	if err := g.conn.Invoke(ctx, PromptInjectionClassifierMethod, &promptInjectionClassifierRequest{Content: content}, score); err != nil {
+		return nil, fmt.Errorf("prompt injection classifier call to %s failed: %w", g.address, err)
+	}
+	return score, nil
+}
+
+func newGRPCPromptInjectionScorerFromPolicy(mediation *dpv2alpha1.Mediation) (PromptInjectionScorer, error) {
+	address, ok := extractPolicyValue(mediation.Parameters, PromptInjectionGuardrailPolicyKeyClassifierEndpoint)
+	if !ok || address == "" {
+		return nil, fmt.Errorf("the %s parameter is required for the %s scorer", PromptInjectionGuardrailPolicyKeyClassifierEndpoint, PromptInjectionScorerGRPC)
+	}
+	return NewGRPCPromptInjectionScorer(strings.TrimSpace(address))
+}
@@ -27,6 +27,7 @@ import (
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/tidwall/gjson"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
@@ -50,11 +51,11 @@ const (
 	// RegexGuardrailPolicyKeyName is the key for specifying the name of the guardrail.
 	RegexGuardrailPolicyKeyName = "name"
 	// RegexGuardrailPolicyKeyRegex is the key for specifying the regex pattern.
-	RegexGuardrailPolicyKeyRegex = "regex"
+	RegexGuardrailPolicyKeyRegex = constantscommon.RegexGuardrailPolicyKeyRegex
 	// RegexGuardrailPolicyKeyJSONPath is the key for specifying the JSON path to extract content.
 	RegexGuardrailPolicyKeyJSONPath = "jsonPath"
 	// RegexGuardrailPolicyKeyInverted is the key for specifying if the validation should be inverted.
-	RegexGuardrailPolicyKeyInverted = "invert"
+	RegexGuardrailPolicyKeyInverted = constantscommon.RegexGuardrailPolicyKeyInverted
 	// RegexGuardrailPolicyKeyShowAssessment is the key for specifying if assessment should be shown.
 	RegexGuardrailPolicyKeyShowAssessment = "showAssessment"
 	// RegexGuardrailAPIMExceptionCode is the error code used when an API-level exception occurs due to regex guardrails.
@@ -0,0 +1,241 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"encoding/json"
+	"sort"
+
+	v31 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/dto"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	pubmediation "github.com/wso2/apk/gateway/enforcer/pkg/mediation"
+)
+
+// Phase is a set of ext_proc processing phases a mediation policy runs in. It is shared with the
+// control plane, which derives the processing mode of each route from the same phases.
+type Phase = commonmediation.Phase
+
+const (
+	// PhaseRequestHeaders is the request headers phase.
+	PhaseRequestHeaders = commonmediation.PhaseRequestHeaders
+	// PhaseRequestBody is the request body phase.
+	PhaseRequestBody = commonmediation.PhaseRequestBody
+	// PhaseResponseHeaders is the response headers phase.
+	PhaseResponseHeaders = commonmediation.PhaseResponseHeaders
+	// PhaseResponseBody is the response body phase.
+	PhaseResponseBody = commonmediation.PhaseResponseBody
+)
+
+// ParameterType is the type a mediation policy parameter value must parse as.
+type ParameterType = pubmediation.ParameterType
+
+const (
+	// ParameterTypeString accepts any value.
+	ParameterTypeString = pubmediation.ParameterTypeString
+	// ParameterTypeBool accepts values parsed by strconv.ParseBool.
+	ParameterTypeBool = pubmediation.ParameterTypeBool
+	// ParameterTypeInt accepts values parsed by strconv.Atoi.
+	ParameterTypeInt = pubmediation.ParameterTypeInt
+	// ParameterTypeFloat accepts values parsed by strconv.ParseFloat.
+	ParameterTypeFloat = pubmediation.ParameterTypeFloat
+	// ParameterTypeJSON accepts valid JSON documents.
+	ParameterTypeJSON = pubmediation.ParameterTypeJSON
+)
+
+// ParameterSchema describes a parameter accepted by a mediation policy.
+type ParameterSchema = pubmediation.ParameterSchema
+
+// Registration is a mediation policy registration with the factory the enforcer creates it with.
+// The registrations themselves live in the importable pkg/mediation registry, which also holds
+// the policies registered outside the enforcer.
+type Registration struct {
+	// Name is the policy name referenced by RoutePolicy mediations.
+	Name string
+	// Phases are the processing phases the policy runs in.
+	Phases Phase
+	// Priority orders policies within a phase, see pkg/mediation.Registration.
+	Priority int
+	// BufferBody asks Envoy to buffer the whole body for the body phases of the policy.
+	BufferBody bool
+	// StreamEventStream marks policies that handle text/event-stream response bodies chunk by chunk.
+	StreamEventStream bool
+	// Parameters is the parameter schema. Parameters missing from the schema are not validated.
+	Parameters []ParameterSchema
+	// New creates the policy. Registrations without a factory only affect the processing modes.
+	New func(*dpv2alpha1.Mediation) Mediation
+}
+
+// builtinFactories holds the factories of the built-in policies, which implement the internal
+// Mediation interface rather than the one of pkg/mediation.
+var builtinFactories = map[string]func(*dpv2alpha1.Mediation) Mediation{}
+
+// registerBuiltin adds a built-in policy to the pkg/mediation registry.
+func registerBuiltin(r Registration) {
+	pubmediation.MustRegister(pubmediation.Registration{
+		Name:              r.Name,
+		Phases:            r.Phases,
+		Priority:          r.Priority,
+		BufferBody:        r.BufferBody,
+		StreamEventStream: r.StreamEventStream,
+		Parameters:        r.Parameters,
+	})
+	if r.New != nil {
+		builtinFactories[r.Name] = r.New
+	}
+}
+
+// GetRegistration returns the registration of the named mediation policy.
+func GetRegistration(name string) (Registration, bool) {
+	p, ok := pubmediation.Lookup(name)
+	if !ok {
+		return Registration{}, false
+	}
+	r := Registration{
+		Name:              p.Name,
+		Phases:            p.Phases,
+		Priority:          p.Priority,
+		BufferBody:        p.BufferBody,
+		StreamEventStream: p.StreamEventStream,
+		Parameters:        p.Parameters,
+		New:               builtinFactories[name],
+	}
+	if r.New == nil && p.New != nil {
+		factory := p.New
+		r.New = func(m *dpv2alpha1.Mediation) Mediation {
+			mediation, err := factory(m)
+			if err != nil {
+				return newRejectedMediation(m, err)
+			}
+			return &registeredMediation{policy: m, mediation: mediation}
+		}
+	}
+	return r, true
+}
+
+// RunsInPhase reports whether the named mediation policy runs in the phase.
+func RunsInPhase(name string, phase Phase) bool {
+	r, ok := GetRegistration(name)
+	return ok && r.Phases&phase != 0
+}
+
+// PoliciesForPhase returns the policies that run in the phase, ordered by priority. Response
+// phases run higher priorities first.
+func PoliciesForPhase(policies []*dpv2alpha1.Mediation, phase Phase) []*dpv2alpha1.Mediation {
+	var selected []*dpv2alpha1.Mediation
+	priorities := map[*dpv2alpha1.Mediation]int{}
+	for _, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		if r, ok := GetRegistration(policy.PolicyName); ok && r.Phases&phase != 0 {
+			selected = append(selected, policy)
+			priorities[policy] = r.Priority
+		}
+	}
+	response := phase&(PhaseResponseHeaders|PhaseResponseBody) != 0
+	sort.SliceStable(selected, func(i, j int) bool {
+		if response {
+			return priorities[selected[i]] > priorities[selected[j]]
+		}
+		return priorities[selected[i]] < priorities[selected[j]]
+	})
+	return selected
+}
+
+// BodySendMode returns the mode Envoy should use to send the body of the given body phase.
+// The body is buffered when any policy in the phase needs it buffered, streamed when the
+// policies in the phase accept chunks, and not sent at all otherwise.
+func BodySendMode(policies []*dpv2alpha1.Mediation, phase Phase) v31.ProcessingMode_BodySendMode {
+	mode := v31.ProcessingMode_NONE
+	for _, policy := range PoliciesForPhase(policies, phase) {
+		r, _ := GetRegistration(policy.PolicyName)
+		if r.BufferBody {
+			return v31.ProcessingMode_BUFFERED
+		}
+		mode = v31.ProcessingMode_STREAMED
+	}
+	return mode
+}
+
+// EventStreamBodySendMode returns the mode Envoy should use to send an event stream response body.
+// The stream is passed through in chunks when every response body policy handles event streams,
+// otherwise the mode is the same as for other responses.
+func EventStreamBodySendMode(policies []*dpv2alpha1.Mediation) v31.ProcessingMode_BodySendMode {
+	selected := PoliciesForPhase(policies, PhaseResponseBody)
+	if len(selected) == 0 {
+		return v31.ProcessingMode_NONE
+	}
+	for _, policy := range selected {
+		if r, _ := GetRegistration(policy.PolicyName); !r.StreamEventStream {
+			return BodySendMode(policies, PhaseResponseBody)
+		}
+	}
+	return v31.ProcessingMode_STREAMED
+}
+
+// ValidateParameters validates the parameters of a mediation against the schema of its policy.
+func ValidateParameters(m *dpv2alpha1.Mediation) error {
+	return pubmediation.ValidateParameters(m)
+}
+
+// registeredMediation runs a policy registered through pkg/mediation.
+type registeredMediation struct {
+	policy    *dpv2alpha1.Mediation
+	mediation pubmediation.Mediation
+}
+
+// Process calls the registered policy with the same input as the external policies. Errors fail
+// the policy open.
+func (r *registeredMediation) Process(h *requestconfig.Holder) *Result {
+	out, err := r.mediation.Process(h.Context(), buildExternalInput(r.policy, h))
+	if err != nil {
+		config.GetConfig().Logger.Sugar().Errorf("Mediation policy %s failed: %v", r.policy.PolicyName, err)
+		return NewResult()
+	}
+	return mapExternalOutputToResult(out)
+}
+
+var invalidMediationMessage = dto.ErrorResponse{Code: 900970, ErrorMessage: "Invalid mediation policy",
+	ErrorDescription: "The mediation policy of the resource is not configured correctly."}
+
+// rejectedMediation stands in for a mediation that could not be created, so the routes it is
+// attached to fail closed instead of skipping the policy.
+type rejectedMediation struct{}
+
+func newRejectedMediation(m *dpv2alpha1.Mediation, err error) Mediation {
+	config.GetConfig().Logger.Sugar().Errorf("Rejecting mediation policy %s: %v", m.PolicyName, err)
+	return &rejectedMediation{}
+}
+
+// Process rejects the request with an internal server error.
+func (r *rejectedMediation) Process(*requestconfig.Holder) *Result {
+	body, _ := json.MarshalIndent(invalidMediationMessage, "", "  ")
+	result := NewResult()
+	result.StopFurtherProcessing = true
+	result.ImmediateResponse = true
+	result.ImmediateResponseCode = v32.StatusCode_InternalServerError
+	result.ImmediateResponseBody = string(body)
+	result.ImmediateResponseDetail = invalidMediationMessage.ErrorDescription
+	result.ImmediateResponseContentType = "application/json"
+	return result
+}
@@ -0,0 +1,252 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	v31 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	pubmediation "github.com/wso2/apk/gateway/enforcer/pkg/mediation"
+)
+
+func TestRegister_BuiltinNames(t *testing.T) {
+	if err := pubmediation.Register(pubmediation.Registration{Name: MediationGraphQL, Phases: PhaseRequestBody}); err == nil {
+		t.Errorf("expected an error when registering a built-in policy name")
+	}
+}
+
+func TestBuiltinRegistrations(t *testing.T) {
+	tests := []struct {
+		name  string
+		phase Phase
+		want  bool
+	}{
+		{MediationSubscriptionValidation, PhaseRequestHeaders, true},
+		{MediationSubscriptionValidation, PhaseRequestBody, false},
+		{MediationGraphQL, PhaseRequestBody, true},
+		{MediationAIProvider, PhaseRequestHeaders, false},
+		{MediationAIProvider, PhaseResponseBody, true},
+		{MediationRegexGuardrail, PhaseResponseBody, true},
+		{"UnknownPolicy", PhaseRequestHeaders, false},
+	}
+	for _, tt := range tests {
+		if got := RunsInPhase(tt.name, tt.phase); got != tt.want {
+			t.Errorf("RunsInPhase(%s, %d) = %v, want %v", tt.name, tt.phase, got, tt.want)
+		}
+	}
+}
+
+func TestBuiltinRegistrations_MatchSharedPhases(t *testing.T) {
+	// The registry holds what registerBuiltin registered, so it is checked rather than the
+	// registrations the enforcer starts from.
+	for _, builtin := range builtinRegistrations {
+		r, ok := GetRegistration(builtin.Name)
+		if !ok {
+			t.Errorf("built-in mediation policy %s is not registered", builtin.Name)
+			continue
+		}
+		shared, ok := commonmediation.BuiltinPolicies[r.Name]
+		if !ok {
+			t.Errorf("mediation policy %s is missing from the shared policy phases", r.Name)
+			continue
+		}
+		if shared.Phases != r.Phases || shared.BufferBody != r.BufferBody {
+			t.Errorf("shared phases of %s = %+v, want phases %d, buffer body %v", r.Name, shared, r.Phases, r.BufferBody)
+		}
+	}
+	for name, shared := range commonmediation.BuiltinPolicies {
+		r, ok := GetRegistration(name)
+		if !ok {
+			t.Errorf("shared policy phases list %s, which is not registered by the enforcer", name)
+			continue
+		}
+		if !slices.ContainsFunc(builtinRegistrations, func(builtin Registration) bool { return builtin.Name == name }) {
+			t.Errorf("shared policy phases list %s, which is not a built-in policy of the enforcer", name)
+		}
+		if shared.Phases != r.Phases || shared.BufferBody != r.BufferBody {
+			t.Errorf("registered phases of %s = %d, buffer body %v, want %+v", name, r.Phases, r.BufferBody, shared)
+		}
+	}
+	for name := range commonmediation.BuiltinParameters {
+		if _, ok := commonmediation.BuiltinPolicies[name]; !ok {
+			t.Errorf("shared parameter schemas list %s, which is not a built-in policy", name)
+		}
+	}
+}
+
+func TestPoliciesForPhase_Priority(t *testing.T) {
+	pubmediation.MustRegister(pubmediation.Registration{Name: "TestPriorityFirst", Phases: PhaseRequestHeaders, Priority: -10})
+	pubmediation.MustRegister(pubmediation.Registration{Name: "TestPriorityLast", Phases: PhaseRequestHeaders, Priority: 10})
+
+	last := &dpv2alpha1.Mediation{PolicyName: "TestPriorityLast"}
+	validation := &dpv2alpha1.Mediation{PolicyName: MediationSubscriptionValidation}
+	analytics := &dpv2alpha1.Mediation{PolicyName: MediationAnalytics}
+	graphql := &dpv2alpha1.Mediation{PolicyName: MediationGraphQL}
+	first := &dpv2alpha1.Mediation{PolicyName: "TestPriorityFirst"}
+
+	got := PoliciesForPhase([]*dpv2alpha1.Mediation{last, validation, analytics, graphql, first}, PhaseRequestHeaders)
+	want := []*dpv2alpha1.Mediation{first, validation, analytics, last}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d policies, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("policy %d: expected %s, got %s", i, want[i].PolicyName, got[i].PolicyName)
+		}
+	}
+}
+
+func TestPoliciesForPhase_ResponsePriority(t *testing.T) {
+	pubmediation.MustRegister(pubmediation.Registration{Name: "TestResponsePriorityInner", Phases: PhaseRequestBody | PhaseResponseBody, Priority: 10})
+
+	inner := &dpv2alpha1.Mediation{PolicyName: "TestResponsePriorityInner"}
+	provider := &dpv2alpha1.Mediation{PolicyName: MediationAIProvider}
+	cache := &dpv2alpha1.Mediation{PolicyName: MediationSemanticCache}
+	policies := []*dpv2alpha1.Mediation{inner, provider, cache}
+
+	if got := PoliciesForPhase(policies, PhaseRequestBody); len(got) != 2 || got[0] != cache || got[1] != inner {
+		t.Errorf("expected the higher priority last in the request phase, got %v", policyNames(got))
+	}
+	if got := PoliciesForPhase(policies, PhaseResponseBody); len(got) != 3 || got[0] != inner || got[1] != provider || got[2] != cache {
+		t.Errorf("expected the higher priority first in the response phase, got %v", policyNames(got))
+	}
+}
+
+func policyNames(policies []*dpv2alpha1.Mediation) []string {
+	names := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		names = append(names, policy.PolicyName)
+	}
+	return names
+}
+
+func TestBodySendMode(t *testing.T) {
+	pubmediation.MustRegister(pubmediation.Registration{Name: "TestStreamedBody", Phases: PhaseRequestBody})
+
+	streamed := &dpv2alpha1.Mediation{PolicyName: "TestStreamedBody"}
+	buffered := &dpv2alpha1.Mediation{PolicyName: MediationGraphQL}
+	headersOnly := &dpv2alpha1.Mediation{PolicyName: MediationBackendJWT}
+
+	if mode := BodySendMode([]*dpv2alpha1.Mediation{headersOnly}, PhaseRequestBody); mode != v31.ProcessingMode_NONE {
+		t.Errorf("expected NONE for header only policies, got %s", mode)
+	}
+	if mode := BodySendMode([]*dpv2alpha1.Mediation{streamed}, PhaseRequestBody); mode != v31.ProcessingMode_STREAMED {
+		t.Errorf("expected STREAMED, got %s", mode)
+	}
+	if mode := BodySendMode([]*dpv2alpha1.Mediation{streamed, buffered}, PhaseRequestBody); mode != v31.ProcessingMode_BUFFERED {
+		t.Errorf("expected BUFFERED when any policy needs the body buffered, got %s", mode)
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	valid := createTestRegexMediation(map[string]string{"regex": "^a", "invert": "true"})
+	if err := ValidateParameters(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	missing := createTestRegexMediation(map[string]string{"invert": "true"})
+	if err := ValidateParameters(missing); err == nil {
+		t.Errorf("expected an error for a missing required parameter")
+	}
+	invalid := createTestRegexMediation(map[string]string{"regex": "^a", "invert": "maybe"})
+	if err := ValidateParameters(invalid); err == nil {
+		t.Errorf("expected an error for an invalid bool parameter")
+	}
+	if err := ValidateParameters(&dpv2alpha1.Mediation{PolicyName: "UnknownPolicy"}); err == nil {
+		t.Errorf("expected an error for an unknown policy")
+	}
+}
+
+func TestEventStreamBodySendMode(t *testing.T) {
+	aiProvider := &dpv2alpha1.Mediation{PolicyName: MediationAIProvider}
+	guardrail := &dpv2alpha1.Mediation{PolicyName: MediationRegexGuardrail}
+
+	if mode := EventStreamBodySendMode([]*dpv2alpha1.Mediation{aiProvider}); mode != v31.ProcessingMode_STREAMED {
+		t.Errorf("expected STREAMED when all policies handle event streams, got %s", mode)
+	}
+	if mode := EventStreamBodySendMode([]*dpv2alpha1.Mediation{aiProvider, guardrail}); mode != v31.ProcessingMode_BUFFERED {
+		t.Errorf("expected BUFFERED when a policy needs the whole body, got %s", mode)
+	}
+	if mode := EventStreamBodySendMode(nil); mode != v31.ProcessingMode_NONE {
+		t.Errorf("expected NONE without response body policies, got %s", mode)
+	}
+}
+
+type headerStampMediation struct {
+	value string
+	err   error
+}
+
+func (m *headerStampMediation) Process(_ context.Context, in *commonmediation.ExternalInput) (*commonmediation.ExternalOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &commonmediation.ExternalOutput{AddHeaders: map[string]string{"x-stamp": m.value + ":" + in.Phase}}, nil
+}
+
+func TestCreateMediation_RegisteredPolicy(t *testing.T) {
+	pubmediation.MustRegister(pubmediation.Registration{
+		Name:       "TestHeaderStamp",
+		Phases:     PhaseRequestHeaders,
+		Parameters: []ParameterSchema{{Key: "value", Type: ParameterTypeString, Required: true}},
+		New: func(m *dpv2alpha1.Mediation) (pubmediation.Mediation, error) {
+			value, _ := extractPolicyValue(m.Parameters, "value")
+			if value == "fail" {
+				return &headerStampMediation{err: errors.New("stamp failed")}, nil
+			}
+			if value == "invalid" {
+				return nil, errors.New("invalid stamp")
+			}
+			return &headerStampMediation{value: value}, nil
+		},
+	})
+	holder := &requestconfig.Holder{ProcessingPhase: requestconfig.ProcessingPhaseRequestHeaders}
+	stamp := func(value string) *dpv2alpha1.Mediation {
+		return &dpv2alpha1.Mediation{PolicyName: "TestHeaderStamp", Parameters: []*dpv2alpha1.Parameter{{Key: "value", Value: value}}}
+	}
+
+	result := CreateMediation(stamp("v1")).Process(holder)
+	if got := result.AddHeaders["x-stamp"]; got != "v1:"+string(requestconfig.ProcessingPhaseRequestHeaders) {
+		t.Errorf("expected the registered policy to add its header, got %q", got)
+	}
+	if result := CreateMediation(stamp("fail")).Process(holder); result.ImmediateResponse || len(result.AddHeaders) != 0 {
+		t.Errorf("expected a failing registered policy to fail open, got %+v", result)
+	}
+	if result := CreateMediation(stamp("invalid")).Process(holder); !result.ImmediateResponse {
+		t.Errorf("expected a policy that cannot be created to reject requests")
+	}
+}
+
+func TestCreateMediation_RejectsInvalidParameters(t *testing.T) {
+	missing := createTestRegexMediation(map[string]string{"invert": "true"})
+	defer DeleteMediation(missing)
+	result := CreateMediation(missing).Process(&requestconfig.Holder{})
+	if !result.ImmediateResponse || !result.StopFurtherProcessing {
+		t.Fatalf("expected a mediation with invalid parameters to reject requests")
+	}
+	if result.ImmediateResponseCode != v32.StatusCode(http.StatusInternalServerError) {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, result.ImmediateResponseCode)
+	}
+}
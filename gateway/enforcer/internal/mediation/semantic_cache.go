@@ -0,0 +1,359 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"context"
+	"crypto/x509"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tidwall/gjson"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/semanticcache"
+	"github.com/wso2/apk/gateway/enforcer/internal/util"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// SemanticCache serves completions of AI APIs from a cache when a prompt is similar enough to a
+// previous prompt of the same route. Prompts are looked up in the request body phase and the
+// completions of cache misses are stored in the response body phase.
+type SemanticCache struct {
+	PolicyName     string
+	PolicyVersion  string
+	PolicyID       string
+	Enabled        bool
+	PromptPath     string
+	TotalTokenPath string
+	ModelPath      string
+	Threshold      float64
+	TTL            time.Duration
+	Scope          string
+	store          semanticcache.Store
+	embedder       semanticcache.Embedder
+	timeout        time.Duration
+	logger         *logging.Logger
+}
+
+const (
+	// MediationSemanticCache holds the name of the Semantic Cache mediation policy.
+	MediationSemanticCache = constantscommon.MediationSemanticCache
+
+	// SemanticCachePolicyKeyEnabled is the key for enabling/disabling the semantic cache.
+	SemanticCachePolicyKeyEnabled = "Enabled"
+	// SemanticCachePolicyKeyPromptPath is the key for specifying the JSONPath of the prompt in the request body.
+	SemanticCachePolicyKeyPromptPath = "PromptPath"
+	// SemanticCachePolicyKeyTotalTokenPath is the key for specifying the JSONPath of the total token count in the response body.
+	SemanticCachePolicyKeyTotalTokenPath = "TotalTokenPath"
+	// SemanticCachePolicyKeyModelPath is the key for specifying the JSONPath of the model in the request body.
+	SemanticCachePolicyKeyModelPath = "ModelPath"
+	// SemanticCachePolicyKeyThreshold is the key for specifying the minimum cosine similarity of a cache hit.
+	SemanticCachePolicyKeyThreshold = constantscommon.SemanticCachePolicyKeyThreshold
+	// SemanticCachePolicyKeyTTLSeconds is the key for specifying how long completions are cached.
+	SemanticCachePolicyKeyTTLSeconds = constantscommon.SemanticCachePolicyKeyTTLSeconds
+	// SemanticCachePolicyKeyMaxEntries is the key for specifying the maximum number of cached completions per route.
+	SemanticCachePolicyKeyMaxEntries = constantscommon.SemanticCachePolicyKeyMaxEntries
+	// SemanticCachePolicyKeyBackend is the key for specifying the vector store, memory or redis.
+	SemanticCachePolicyKeyBackend = "Backend"
+	// SemanticCachePolicyKeyEmbeddingEndpoint is the key for specifying an OpenAI compatible embeddings endpoint.
+	SemanticCachePolicyKeyEmbeddingEndpoint = "EmbeddingEndpoint"
+	// SemanticCachePolicyKeyEmbeddingModel is the key for specifying the embedding model.
+	SemanticCachePolicyKeyEmbeddingModel = "EmbeddingModel"
+	// SemanticCachePolicyKeyEmbeddingAPIKey is the key for specifying the API key of the embeddings endpoint.
+	SemanticCachePolicyKeyEmbeddingAPIKey = "EmbeddingAPIKey"
+	// SemanticCachePolicyKeyTimeoutMs is the key for specifying the time budget of embedding and lookups.
+	SemanticCachePolicyKeyTimeoutMs = constantscommon.SemanticCachePolicyKeyTimeoutMs
+	// SemanticCachePolicyKeyScope is the key for specifying who shares the cached completions, application or route.
+	SemanticCachePolicyKeyScope = constantscommon.SemanticCachePolicyKeyScope
+
+	// SemanticCacheScopeApplication shares the cached completions among the requests of an application.
+	SemanticCacheScopeApplication = "application"
+	// SemanticCacheScopeRoute shares the cached completions among all the consumers of a route.
+	SemanticCacheScopeRoute = "route"
+
+	semanticCacheBackendMemory = "memory"
+	semanticCacheBackendRedis  = "redis"
+
+	semanticCacheDefaultPromptPath     = "$.messages"
+	semanticCacheDefaultTotalTokenPath = "$.usage.total_tokens"
+	semanticCacheDefaultModelPath      = "$.model"
+	semanticCacheDefaultThreshold      = 0.95
+	semanticCacheDefaultTTL            = time.Hour
+	semanticCacheDefaultMaxEntries     = 1000
+	semanticCacheDefaultTimeout        = 2 * time.Second
+	semanticCacheHashDimensions        = 512
+)
+
+var (
+	mediationRedisClient     *redis.Client
+	mediationRedisClientOnce sync.Once
+)
+
+// NewSemanticCache creates a new SemanticCache instance.
+func NewSemanticCache(mediation *dpv2alpha1.Mediation) *SemanticCache {
+	cfg := config.GetConfig()
+	s := &SemanticCache{
+		PolicyName:     MediationSemanticCache,
+		PolicyVersion:  mediation.PolicyVersion,
+		PolicyID:       mediation.PolicyID,
+		Enabled:        true,
+		PromptPath:     semanticCacheDefaultPromptPath,
+		TotalTokenPath: semanticCacheDefaultTotalTokenPath,
+		ModelPath:      semanticCacheDefaultModelPath,
+		Threshold:      semanticCacheDefaultThreshold,
+		TTL:            semanticCacheDefaultTTL,
+		Scope:          SemanticCacheScopeApplication,
+		timeout:        semanticCacheDefaultTimeout,
+		logger:         &cfg.Logger,
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyEnabled); ok && val == "false" {
+		s.Enabled = false
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyPromptPath); ok && val != "" {
+		s.PromptPath = val
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyTotalTokenPath); ok && val != "" {
+		s.TotalTokenPath = val
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyModelPath); ok && val != "" {
+		s.ModelPath = val
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyThreshold); ok {
+		if threshold, err := strconv.ParseFloat(val, 64); err == nil && threshold > 0 && threshold <= 1 {
+			s.Threshold = threshold
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyTTLSeconds); ok {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+			s.TTL = time.Duration(seconds) * time.Second
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyTimeoutMs); ok {
+		if ms, err := strconv.Atoi(val); err == nil && ms > 0 {
+			s.timeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+	maxEntries := semanticCacheDefaultMaxEntries
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyMaxEntries); ok {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			maxEntries = n
+		}
+	}
+
+	if val, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyScope); ok && val != "" {
+		switch val {
+		case SemanticCacheScopeApplication, SemanticCacheScopeRoute:
+			s.Scope = val
+		default:
+			s.logger.Sugar().Warnf("Unknown semantic cache scope %s, caching completions per application", val)
+		}
+	}
+	backend, _ := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyBackend)
+	switch strings.ToLower(backend) {
+	case semanticCacheBackendRedis:
+		s.store = semanticcache.NewRedisStore(getMediationRedisClient(cfg), maxEntries, s.TTL)
+	case "", semanticCacheBackendMemory:
+		s.store = semanticcache.NewMemoryStore(maxEntries)
+	default:
+		s.logger.Sugar().Warnf("Unknown semantic cache backend %s, falling back to %s", backend, semanticCacheBackendMemory)
+		s.store = semanticcache.NewMemoryStore(maxEntries)
+	}
+
+	if endpoint, ok := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyEmbeddingEndpoint); ok && endpoint != "" {
+		model, _ := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyEmbeddingModel)
+		apiKey, _ := extractPolicyValue(mediation.Parameters, SemanticCachePolicyKeyEmbeddingAPIKey)
+		s.embedder = semanticcache.NewHTTPEmbedder(endpoint, model, apiKey, s.timeout)
+	} else {
+		s.embedder = semanticcache.NewHashEmbedder(semanticCacheHashDimensions)
+	}
+	return s
+}
+
+// getMediationRedisClient returns the Redis client shared by the mediations that keep state in Redis.
+func getMediationRedisClient(cfg *config.Server) *redis.Client {
+	mediationRedisClientOnce.Do(func() {
+		address := cfg.RedisHost + ":" + strconv.Itoa(cfg.RedisPort)
+		if !cfg.IsRedisTLSEnabled {
+			mediationRedisClient = util.CreateRedisClient(address, cfg.RedisUsername, cfg.RedisPassword, nil)
+			return
+		}
+		cert, err := util.LoadCertificates(cfg.RedisCertFile, cfg.RedisKeyFile)
+		if err != nil {
+			cfg.Logger.Sugar().Errorf("Failed to load the Redis client certificate: %v", err)
+		}
+		certPool := x509.NewCertPool()
+		if caCert, err := util.LoadCertificate(cfg.RedisCaCertFile); err == nil {
+			certPool.AddCert(caCert)
+		} else {
+			cfg.Logger.Sugar().Errorf("Failed to load the Redis CA certificate: %v", err)
+		}
+		mediationRedisClient = util.CreateRedisClient(address, cfg.RedisUsername, cfg.RedisPassword, util.CreateTLSConfig(cert, certPool))
+	})
+	return mediationRedisClient
+}
+
+// Process looks up the prompt in the request body phase and caches the completion in the response body phase.
+func (s *SemanticCache) Process(requestConfig *requestconfig.Holder) *Result {
+	result := NewResult()
+	if !s.Enabled || requestConfig.RequestAttributes == nil {
+		return result
+	}
+	switch requestConfig.ProcessingPhase {
+	case requestconfig.ProcessingPhaseRequestBody:
+		return s.lookup(requestConfig)
+	case requestconfig.ProcessingPhaseResponseBody:
+		s.save(requestConfig)
+	}
+	return result
+}
+
+func (s *SemanticCache) lookup(requestConfig *requestconfig.Holder) *Result {
+	result := NewResult()
+	requestConfig.AI.SemanticCacheVector = nil
+	requestConfig.AI.SemanticCachePartition = ""
+	if requestConfig.RequestBody == nil {
+		return result
+	}
+	prompt := gjson.GetBytes(requestConfig.RequestBody.Body, removeDollarPrefix(s.PromptPath))
+	if !prompt.Exists() {
+		s.logger.Sugar().Debugf("Prompt not found in request body at %s, skipping semantic cache", s.PromptPath)
+		return result
+	}
+	ctx, cancel := context.WithTimeout(requestConfig.Context(), s.timeout)
+	defer cancel()
+	vector, err := s.embedder.Embed(ctx, prompt.String())
+	if err != nil {
+		s.logger.Sugar().Errorf("Failed to compute the prompt embedding: %v", err)
+		return result
+	}
+	partition, ok := s.partition(requestConfig)
+	if !ok {
+		s.logger.Sugar().Debugf("The application of the request is not known, skipping semantic cache")
+		return result
+	}
+	entry, score, err := s.store.Search(ctx, partition, vector, s.Threshold)
+	if err != nil {
+		s.logger.Sugar().Errorf("Failed to search the semantic cache: %v", err)
+		return result
+	}
+	if entry == nil {
+		// Remember the embedding so that the completion can be cached in the response body phase.
+		requestConfig.AI.SemanticCacheVector = vector
+		requestConfig.AI.SemanticCachePartition = partition
+		return result
+	}
+	s.logger.Sugar().Debugf("Semantic cache hit with similarity %f", score)
+	result.ImmediateResponse = true
+	result.ImmediateResponseCode = 200
+	result.ImmediateResponseBody = string(entry.Body)
+	result.ImmediateResponseContentType = entry.ContentType
+	if entry.ContentEncoding != "" {
+		result.ImmediateResponseHeaders["content-encoding"] = entry.ContentEncoding
+	}
+	result.ImmediateResponseDetail = "semantic_cache_hit"
+	result.StopFurtherProcessing = true
+	result.Metadata[analytics.SemanticCacheHitMetadataKey] = structpb.NewStringValue("true")
+	result.Metadata[analytics.SemanticCacheSavedTokenCountMetadataKey] = structpb.NewStringValue(strconv.FormatInt(entry.TotalTokens, 10))
+	return result
+}
+
+func (s *SemanticCache) save(requestConfig *requestconfig.Holder) {
+	vector := requestConfig.AI.SemanticCacheVector
+	if vector == nil || requestConfig.ResponseBody == nil || requestConfig.ResponseHeaders == nil || requestConfig.ResponseHeaders.Headers == nil {
+		return
+	}
+	// Only streams that missed the cache reach here, so clear the vector to cache each completion once.
+	requestConfig.AI.SemanticCacheVector = nil
+	entry := &semanticcache.Entry{
+		Vector:    vector,
+		Body:      requestConfig.ResponseBody.Body,
+		ExpiresAt: time.Now().Add(s.TTL),
+	}
+	for _, header := range requestConfig.ResponseHeaders.Headers.Headers {
+		value := string(header.GetRawValue())
+		switch strings.ToLower(header.GetKey()) {
+		case ":status":
+			if value != "200" {
+				return
+			}
+		case "content-type":
+			entry.ContentType = value
+		case "content-encoding":
+			entry.ContentEncoding = value
+		}
+	}
+	body := string(entry.Body)
+	if entry.ContentEncoding == "gzip" {
+		decompressed, err := unzipGzip(entry.Body)
+		if err != nil {
+			s.logger.Sugar().Errorf("Failed to decompress the response body: %v", err)
+			return
+		}
+		body = decompressed
+	}
+	entry.TotalTokens = gjson.Get(body, removeDollarPrefix(s.TotalTokenPath)).Int()
+
+	ctx, cancel := context.WithTimeout(requestConfig.Context(), s.timeout)
+	defer cancel()
+	if err := s.store.Put(ctx, requestConfig.AI.SemanticCachePartition, entry); err != nil {
+		s.logger.Sugar().Errorf("Failed to store the completion in the semantic cache: %v", err)
+	}
+}
+
+// partition isolates the cached completions of a route and model, so that the completion of one
+// model is not served for a prompt sent to another. Unless the scope is the route, completions are
+// also isolated by application, so that a consumer is not served the completion of another. It
+// returns false if the application of the request is not known.
+func (s *SemanticCache) partition(requestConfig *requestconfig.Holder) (string, bool) {
+	model := gjson.GetBytes(requestConfig.RequestBody.Body, removeDollarPrefix(s.ModelPath)).String()
+	partition := s.PolicyID + ":" + requestConfig.RequestAttributes.RouteName + ":" + model
+	if s.Scope == SemanticCacheScopeRoute {
+		return partition, true
+	}
+	consumer := semanticCacheConsumer(requestConfig)
+	if consumer == "" {
+		return "", false
+	}
+	return partition + ":" + consumer, true
+}
+
+// semanticCacheConsumer identifies the consumer of a request by the application subscription
+// validation matched, or otherwise by the client or subject of the token.
+func semanticCacheConsumer(requestConfig *requestconfig.Holder) string {
+	if requestConfig.MatchedApplication != nil && requestConfig.MatchedApplication.UUID != "" {
+		return "app=" + requestConfig.MatchedApplication.UUID
+	}
+	claims := requestConfig.JWTAuthnPayloaClaims
+	for _, claim := range []string{"client_id", "azp"} {
+		if value, ok := claims[claim].(string); ok && value != "" {
+			return "client=" + value
+		}
+	}
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		return "sub=" + sub
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+const testCompletion = `{"choices":[{"message":{"content":"Paris"}}],"usage":{"total_tokens":42}}`
+
+func createTestSemanticCacheMediation(params map[string]string) *dpv2alpha1.Mediation {
+	var parameters []*dpv2alpha1.Parameter
+	for key, value := range params {
+		parameters = append(parameters, &dpv2alpha1.Parameter{Key: key, Value: value})
+	}
+	return &dpv2alpha1.Mediation{
+		PolicyName:    MediationSemanticCache,
+		PolicyVersion: "v1",
+		PolicyID:      "test-semantic-cache-policy-id",
+		Parameters:    parameters,
+	}
+}
+
+func newSemanticCacheHolder(route, prompt string) *requestconfig.Holder {
+	return &requestconfig.Holder{
+		ProcessingPhase:    requestconfig.ProcessingPhaseRequestBody,
+		RequestAttributes:  &requestconfig.Attributes{RouteName: route},
+		MatchedApplication: &subscription_model.Application{UUID: "app-1"},
+		RequestBody: &envoy_service_proc_v3.HttpBody{
+			Body: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"` + prompt + `"}]}`),
+		},
+	}
+}
+
+func completeSemanticCacheRequest(s *SemanticCache, holder *requestconfig.Holder, status string) {
+	holder.ProcessingPhase = requestconfig.ProcessingPhaseResponseBody
+	holder.ResponseHeaders = &envoy_service_proc_v3.HttpHeaders{
+		Headers: &corev3.HeaderMap{
+			Headers: []*corev3.HeaderValue{
+				{Key: ":status", RawValue: []byte(status)},
+				{Key: "content-type", RawValue: []byte("application/json")},
+			},
+		},
+	}
+	holder.ResponseBody = &envoy_service_proc_v3.HttpBody{Body: []byte(testCompletion)}
+	s.Process(holder)
+}
+
+func TestSemanticCache_MissThenHit(t *testing.T) {
+	s := NewSemanticCache(createTestSemanticCacheMediation(map[string]string{"Threshold": "0.9"}))
+
+	first := newSemanticCacheHolder("chat", "What is the capital of France?")
+	if result := s.Process(first); result.ImmediateResponse {
+		t.Fatalf("expected a cache miss on an empty cache")
+	}
+	if first.AI.SemanticCacheVector == nil {
+		t.Fatalf("expected the prompt embedding to be kept for the response phase")
+	}
+	completeSemanticCacheRequest(s, first, "200")
+
+	second := newSemanticCacheHolder("chat", "what is the capital of france")
+	result := s.Process(second)
+	if !result.ImmediateResponse || result.ImmediateResponseCode != 200 {
+		t.Fatalf("expected a cache hit, got %+v", result)
+	}
+	if result.ImmediateResponseBody != testCompletion || result.ImmediateResponseContentType != "application/json" {
+		t.Errorf("unexpected cached response %q with content type %q", result.ImmediateResponseBody, result.ImmediateResponseContentType)
+	}
+	if result.Metadata[analytics.SemanticCacheHitMetadataKey].GetStringValue() != "true" ||
+		result.Metadata[analytics.SemanticCacheSavedTokenCountMetadataKey].GetStringValue() != "42" {
+		t.Errorf("unexpected analytics metadata: %v", result.Metadata)
+	}
+
+	if result := s.Process(newSemanticCacheHolder("other", "What is the capital of France?")); result.ImmediateResponse {
+		t.Errorf("expected routes not to share cached completions")
+	}
+	otherModel := newSemanticCacheHolder("chat", "What is the capital of France?")
+	otherModel.RequestBody.Body = []byte(`{"model":"gpt-4o-mini","messages":[{"role":"user","content":"What is the capital of France?"}]}`)
+	if result := s.Process(otherModel); result.ImmediateResponse {
+		t.Errorf("expected models not to share cached completions")
+	}
+	if result := s.Process(newSemanticCacheHolder("chat", "Write a haiku about autumn leaves")); result.ImmediateResponse {
+		t.Errorf("expected an unrelated prompt to miss the cache")
+	}
+}
+
+func TestSemanticCache_Scope(t *testing.T) {
+	s := NewSemanticCache(createTestSemanticCacheMediation(nil))
+	first := newSemanticCacheHolder("chat", "What is the capital of Italy?")
+	s.Process(first)
+	completeSemanticCacheRequest(s, first, "200")
+
+	otherApplication := newSemanticCacheHolder("chat", "What is the capital of Italy?")
+	otherApplication.MatchedApplication = &subscription_model.Application{UUID: "app-2"}
+	if result := s.Process(otherApplication); result.ImmediateResponse {
+		t.Errorf("expected applications not to share cached completions")
+	}
+	sameClient := newSemanticCacheHolder("chat", "What is the capital of Italy?")
+	sameClient.MatchedApplication = nil
+	sameClient.JWTAuthnPayloaClaims = map[string]interface{}{"azp": "client-1", "sub": "alice"}
+	if result := s.Process(sameClient); result.ImmediateResponse {
+		t.Errorf("expected a client without a matched application not to be served the application's completions")
+	}
+	anonymous := newSemanticCacheHolder("chat", "What is the capital of Italy?")
+	anonymous.MatchedApplication = nil
+	if result := s.Process(anonymous); result.ImmediateResponse || anonymous.AI.SemanticCacheVector != nil {
+		t.Errorf("expected requests of an unknown application to skip the cache")
+	}
+
+	shared := NewSemanticCache(createTestSemanticCacheMediation(map[string]string{SemanticCachePolicyKeyScope: SemanticCacheScopeRoute}))
+	first = newSemanticCacheHolder("chat", "What is the capital of Spain?")
+	shared.Process(first)
+	completeSemanticCacheRequest(shared, first, "200")
+	otherApplication = newSemanticCacheHolder("chat", "What is the capital of Spain?")
+	otherApplication.MatchedApplication = nil
+	if result := shared.Process(otherApplication); !result.ImmediateResponse {
+		t.Errorf("expected the consumers of a route to share cached completions with the route scope")
+	}
+}
+
+func TestSemanticCache_SkipsFailedResponses(t *testing.T) {
+	s := NewSemanticCache(createTestSemanticCacheMediation(nil))
+
+	holder := newSemanticCacheHolder("chat", "Tell me a joke")
+	s.Process(holder)
+	completeSemanticCacheRequest(s, holder, "500")
+
+	if result := s.Process(newSemanticCacheHolder("chat", "Tell me a joke")); result.ImmediateResponse {
+		t.Errorf("expected failed responses not to be cached")
+	}
+}
+
+func TestSemanticCache_Disabled(t *testing.T) {
+	s := NewSemanticCache(createTestSemanticCacheMediation(map[string]string{"Enabled": "false"}))
+	holder := newSemanticCacheHolder("chat", "Tell me a joke")
+	s.Process(holder)
+	if holder.AI.SemanticCacheVector != nil {
+		t.Errorf("expected a disabled cache not to embed prompts")
+	}
+}
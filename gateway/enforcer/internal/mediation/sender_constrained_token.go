@@ -0,0 +1,454 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"container/heap"
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/dto"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+// SenderConstrainedToken checks that the access token of the request is presented by the client
+// it was issued to. The binding is read from the cnf claim of the token verified by Envoy:
+//
+//   - x5t#S256 binds the token to a client certificate (RFC 8705). The SHA-256 thumbprint must
+//     match the certificate the client authenticated the TLS connection with.
+//   - jkt binds the token to a key (RFC 9449). The request must carry a DPoP proof signed with
+//     the key, for the method and URL of the request, that has not been used before.
+//
+// Tokens without a binding are accepted unless the policy requires one.
+type SenderConstrainedToken struct {
+	PolicyName               string
+	PolicyVersion            string
+	PolicyID                 string
+	Enabled                  bool
+	RequiredBinding          string
+	DPoPProofMaxAge          time.Duration
+	TrustForwardedClientCert bool
+	replayCache              *dpopReplayCache
+	logger                   *logging.Logger
+	now                      func() time.Time
+}
+
+const (
+	// MediationSenderConstrainedToken holds the name of the Sender Constrained Token mediation policy.
+	MediationSenderConstrainedToken = constantscommon.MediationSenderConstrainedToken
+	// SenderConstrainedTokenPolicyKeyEnabled is the key for enabling/disabling the policy.
+	SenderConstrainedTokenPolicyKeyEnabled = "Enabled"
+	// SenderConstrainedTokenPolicyKeyRequiredBinding is the key for specifying the binding the
+	// tokens must have: mtls, dpop, any, or empty to accept unbound tokens.
+	SenderConstrainedTokenPolicyKeyRequiredBinding = constantscommon.SenderConstrainedTokenPolicyKeyRequiredBinding
+	// SenderConstrainedTokenPolicyKeyDPoPProofMaxAge is the key for specifying how long after it
+	// was issued a DPoP proof is accepted, in seconds.
+	SenderConstrainedTokenPolicyKeyDPoPProofMaxAge = constantscommon.SenderConstrainedTokenPolicyKeyDPoPProofMaxAge
+	// SenderConstrainedTokenPolicyKeyTrustForwardedClientCert is the key for specifying if the
+	// client certificate hash is read from the x-forwarded-client-cert header when Envoy does not
+	// report the certificate of the connection, such as behind a TLS terminating proxy.
+	SenderConstrainedTokenPolicyKeyTrustForwardedClientCert = constantscommon.SenderConstrainedTokenPolicyKeyTrustForwardedClientCert
+
+	// Bindings of the requiredBinding parameter.
+	senderConstraintMTLS = "mtls"
+	senderConstraintDPoP = "dpop"
+	senderConstraintAny  = "any"
+
+	// senderConstrainedTokenPriority checks the token binding before the policies that trust the token.
+	senderConstrainedTokenPriority = -10
+	defaultDPoPProofMaxAge         = 60 * time.Second
+	// dpopProofClockSkew is how far in the future the issued time of a DPoP proof may be.
+	dpopProofClockSkew        = 5 * time.Second
+	dpopReplayCacheMaxEntries = 100000
+	dpopHeader                = "dpop"
+	dpopProofType             = "dpop+jwt"
+	forwardedClientCertHeader = "x-forwarded-client-cert"
+)
+
+// dpopSigningAlgorithms are the asymmetric algorithms DPoP proofs can be signed with.
+var dpopSigningAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// sharedDPoPReplayCache holds the DPoP proofs seen by every policy, so that a proof replayed to
+// another route is rejected as well.
+var sharedDPoPReplayCache = newDPoPReplayCache(dpopReplayCacheMaxEntries)
+
+// senderConstraintError is a failed token binding check.
+type senderConstraintError struct {
+	// scheme is the authentication scheme of the WWW-Authenticate challenge.
+	scheme string
+	// code is the OAuth error code of the challenge.
+	code        string
+	description string
+}
+
+func (e *senderConstraintError) Error() string {
+	return e.description
+}
+
+func invalidToken(format string, args ...interface{}) *senderConstraintError {
+	return &senderConstraintError{scheme: "Bearer", code: "invalid_token", description: fmt.Sprintf(format, args...)}
+}
+
+func invalidDPoPProof(format string, args ...interface{}) *senderConstraintError {
+	return &senderConstraintError{scheme: "DPoP", code: "invalid_dpop_proof", description: fmt.Sprintf(format, args...)}
+}
+
+// NewSenderConstrainedToken creates a new SenderConstrainedToken instance.
+func NewSenderConstrainedToken(mediation *dpv2alpha1.Mediation) *SenderConstrainedToken {
+	cfg := config.GetConfig()
+	s := &SenderConstrainedToken{
+		PolicyName:      MediationSenderConstrainedToken,
+		PolicyVersion:   mediation.PolicyVersion,
+		PolicyID:        mediation.PolicyID,
+		Enabled:         true,
+		DPoPProofMaxAge: defaultDPoPProofMaxAge,
+		replayCache:     sharedDPoPReplayCache,
+		logger:          &cfg.Logger,
+		now:             time.Now,
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SenderConstrainedTokenPolicyKeyEnabled); ok && val == "false" {
+		s.Enabled = false
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SenderConstrainedTokenPolicyKeyRequiredBinding); ok {
+		switch binding := strings.ToLower(val); binding {
+		case "", senderConstraintMTLS, senderConstraintDPoP, senderConstraintAny:
+			s.RequiredBinding = binding
+		default:
+			s.logger.Sugar().Errorf("Invalid sender constrained token binding %s, requiring any binding", val)
+			s.RequiredBinding = senderConstraintAny
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SenderConstrainedTokenPolicyKeyDPoPProofMaxAge); ok {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+			s.DPoPProofMaxAge = time.Duration(seconds) * time.Second
+		}
+	}
+	if val, ok := extractPolicyValue(mediation.Parameters, SenderConstrainedTokenPolicyKeyTrustForwardedClientCert); ok {
+		s.TrustForwardedClientCert = val == "true"
+	}
+	return s
+}
+
+// Process processes the request configuration for the sender constrained token check.
+func (s *SenderConstrainedToken) Process(requestConfig *requestconfig.Holder) *Result {
+	result := NewResult()
+	if !s.Enabled {
+		s.logger.Sugar().Debugf("Sender Constrained Token policy is disabled. Skipping processing.")
+		return result
+	}
+	if err := s.validate(requestConfig); err != nil {
+		s.logger.Sugar().Debugf("Sender constrained token validation failed: %v", err)
+		errorMessage := dto.ErrorResponse{Code: 900901, ErrorMessage: "Invalid Credentials", ErrorDescription: err.description}
+		body, _ := json.MarshalIndent(errorMessage, "", "  ")
+		result.StopFurtherProcessing = true
+		result.ImmediateResponse = true
+		result.ImmediateResponseCode = v32.StatusCode_Unauthorized
+		result.ImmediateResponseBody = string(body)
+		result.ImmediateResponseDetail = err.description
+		result.ImmediateResponseContentType = "application/json"
+		result.ImmediateResponseHeaders["WWW-Authenticate"] = fmt.Sprintf("%s error=%q, error_description=%q", err.scheme, err.code, err.description)
+		if err.scheme == "DPoP" {
+			result.ImmediateResponseHeaders["WWW-Authenticate"] += fmt.Sprintf(", algs=%q", strings.Join(dpopSigningAlgorithms, " "))
+		}
+	}
+	return result
+}
+
+// validate checks the bindings of the token of the request.
+func (s *SenderConstrainedToken) validate(requestConfig *requestconfig.Holder) *senderConstraintError {
+	claims := requestConfig.JWTAuthnPayloaClaims
+	if claims == nil {
+		if s.RequiredBinding != "" {
+			return invalidToken("The request has no verified access token")
+		}
+		return nil
+	}
+	confirmation, _ := claims["cnf"].(map[string]interface{})
+	certThumbprint, _ := confirmation["x5t#S256"].(string)
+	keyThumbprint, _ := confirmation["jkt"].(string)
+	switch {
+	case s.RequiredBinding == senderConstraintMTLS && certThumbprint == "":
+		return invalidToken("The access token is not bound to a client certificate")
+	case s.RequiredBinding == senderConstraintDPoP && keyThumbprint == "":
+		return invalidToken("The access token is not bound to a DPoP key")
+	case s.RequiredBinding == senderConstraintAny && certThumbprint == "" && keyThumbprint == "":
+		return invalidToken("The access token is not sender constrained")
+	}
+	if certThumbprint != "" {
+		if err := s.validateCertificateBinding(requestConfig, certThumbprint); err != nil {
+			return err
+		}
+	}
+	if keyThumbprint != "" {
+		if err := s.validateDPoPProof(requestConfig, keyThumbprint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCertificateBinding compares the thumbprint the token is bound to with the hash of the
+// client certificate of the connection.
+func (s *SenderConstrainedToken) validateCertificateBinding(requestConfig *requestconfig.Holder, thumbprint string) *senderConstraintError {
+	digest := ""
+	if requestConfig.RequestAttributes != nil {
+		digest = requestConfig.RequestAttributes.PeerCertificateDigest
+	}
+	if digest == "" && s.TrustForwardedClientCert {
+		digest = forwardedClientCertHash(requestHeader(requestConfig, forwardedClientCertHeader))
+	}
+	if digest == "" {
+		return invalidToken("The access token is bound to a client certificate, but none was presented")
+	}
+	hash, err := hex.DecodeString(digest)
+	if err != nil {
+		return invalidToken("The client certificate hash is invalid")
+	}
+	if !constantTimeEqual(base64.RawURLEncoding.EncodeToString(hash), thumbprint) {
+		return invalidToken("The access token is not bound to the client certificate")
+	}
+	return nil
+}
+
+// forwardedClientCertHash returns the hash of the certificate of the client closest to Envoy
+// from an x-forwarded-client-cert header.
+func forwardedClientCertHash(header string) string {
+	if header == "" {
+		return ""
+	}
+	elements := strings.Split(header, ",")
+	for _, pair := range strings.Split(elements[len(elements)-1], ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && strings.EqualFold(key, "Hash") {
+			return strings.Trim(value, "\"")
+		}
+	}
+	return ""
+}
+
+// validateDPoPProof validates the DPoP proof of the request as described in RFC 9449 section 4.3.
+func (s *SenderConstrainedToken) validateDPoPProof(requestConfig *requestconfig.Holder, keyThumbprint string) *senderConstraintError {
+	scheme, accessToken, _ := strings.Cut(requestHeader(requestConfig, "authorization"), " ")
+	if !strings.EqualFold(scheme, "DPoP") {
+		return &senderConstraintError{scheme: "DPoP", code: "invalid_token", description: "The DPoP bound access token must be sent with the DPoP authorization scheme"}
+	}
+	var proofs []string
+	for _, header := range requestConfig.RequestHeaders.GetHeaders().GetHeaders() {
+		if strings.EqualFold(header.GetKey(), dpopHeader) {
+			proofs = append(proofs, headerValue(header.GetValue(), header.GetRawValue()))
+		}
+	}
+	if len(proofs) != 1 || strings.Contains(proofs[0], ",") {
+		return invalidDPoPProof("The request must carry exactly one DPoP proof")
+	}
+
+	var proofKey jwk.Key
+	parser := jwt.NewParser(jwt.WithValidMethods(dpopSigningAlgorithms), jwt.WithoutClaimsValidation())
+	proof, err := parser.Parse(proofs[0], func(token *jwt.Token) (interface{}, error) {
+		if token.Header["typ"] != dpopProofType {
+			return nil, errors.New("the proof type is not " + dpopProofType)
+		}
+		keyJSON, err := json.Marshal(token.Header["jwk"])
+		if err != nil || token.Header["jwk"] == nil {
+			return nil, errors.New("the proof has no jwk header")
+		}
+		if proofKey, err = jwk.ParseKey(keyJSON); err != nil {
+			return nil, fmt.Errorf("the proof jwk header is invalid: %w", err)
+		}
+		if _, private := proofKey.(interface{ D() []byte }); private {
+			return nil, errors.New("the proof jwk header is a private key")
+		}
+		var publicKey interface{}
+		if err := proofKey.Raw(&publicKey); err != nil {
+			return nil, err
+		}
+		return publicKey, nil
+	})
+	if err != nil {
+		return invalidDPoPProof("The DPoP proof is invalid: %v", err)
+	}
+
+	claims, _ := proof.Claims.(jwt.MapClaims)
+	jti, _ := claims["jti"].(string)
+	htm, _ := claims["htm"].(string)
+	htu, _ := claims["htu"].(string)
+	issuedAt, iatErr := claims.GetIssuedAt()
+	if jti == "" || htm == "" || htu == "" || iatErr != nil || issuedAt == nil {
+		return invalidDPoPProof("The DPoP proof must have the jti, htm, htu and iat claims")
+	}
+	if htm != requestHeader(requestConfig, ":method") {
+		return invalidDPoPProof("The DPoP proof is not for the method of the request")
+	}
+	if !sameHTTPURI(htu, requestURI(requestConfig)) {
+		return invalidDPoPProof("The DPoP proof is not for the URL of the request")
+	}
+	now := s.now()
+	if issuedAt.After(now.Add(dpopProofClockSkew)) || issuedAt.Before(now.Add(-s.DPoPProofMaxAge)) {
+		return invalidDPoPProof("The DPoP proof is expired or issued in the future")
+	}
+	accessTokenHash := sha256.Sum256([]byte(strings.TrimSpace(accessToken)))
+	if ath, _ := claims["ath"].(string); !constantTimeEqual(ath, base64.RawURLEncoding.EncodeToString(accessTokenHash[:])) {
+		return invalidDPoPProof("The DPoP proof is not for the access token of the request")
+	}
+	thumbprint, err := proofKey.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return invalidDPoPProof("The DPoP proof key thumbprint cannot be computed: %v", err)
+	}
+	if !constantTimeEqual(base64.RawURLEncoding.EncodeToString(thumbprint), keyThumbprint) {
+		return invalidDPoPProof("The DPoP proof is not signed with the key the access token is bound to")
+	}
+	// The proof is kept until it would be rejected as expired anyway.
+	if err := s.replayCache.add(keyThumbprint+":"+jti, issuedAt.Add(s.DPoPProofMaxAge+dpopProofClockSkew), now); err != nil {
+		return invalidDPoPProof("The DPoP proof is rejected: %v", err)
+	}
+	return nil
+}
+
+// requestURI returns the URL of the request without the query and fragment.
+func requestURI(requestConfig *requestconfig.Holder) string {
+	scheme := requestHeader(requestConfig, ":scheme")
+	if scheme == "" {
+		scheme = "https"
+	}
+	path, _, _ := strings.Cut(requestHeader(requestConfig, ":path"), "?")
+	return scheme + "://" + requestHeader(requestConfig, ":authority") + path
+}
+
+// sameHTTPURI compares two HTTP URIs ignoring the query and fragment, the case of the scheme
+// and host, and the default port, as RFC 9449 asks.
+func sameHTTPURI(a string, b string) bool {
+	normalize := func(raw string) (string, bool) {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return "", false
+		}
+		scheme := strings.ToLower(u.Scheme)
+		host := strings.ToLower(u.Hostname())
+		port := u.Port()
+		if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+			port = ""
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		path := u.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+		return scheme + "://" + host + path, true
+	}
+	normalizedA, okA := normalize(a)
+	normalizedB, okB := normalize(b)
+	return okA && okB && normalizedA == normalizedB
+}
+
+func constantTimeEqual(a string, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Results of recording a DPoP proof in the replay cache.
+var (
+	errDPoPProofReplayed   = errors.New("the DPoP proof was already used")
+	errDPoPReplayCacheFull = errors.New("too many DPoP proofs are in use")
+)
+
+// dpopReplayCache remembers the DPoP proofs that were used until they expire. A nil cache
+// remembers nothing. The cache is local to the enforcer, so a proof can be replayed once to
+// every replica. A full cache rejects new proofs rather than forgetting a live one, which
+// could then be replayed.
+type dpopReplayCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]time.Time
+	// expiries orders the entries by expiry, so expired proofs are removed oldest first.
+	expiries dpopExpiryHeap
+}
+
+func newDPoPReplayCache(maxEntries int) *dpopReplayCache {
+	return &dpopReplayCache{maxEntries: maxEntries, entries: map[string]time.Time{}}
+}
+
+// add records the proof. It fails if the proof was recorded before and has not expired, or if
+// the cache is full of proofs that have not expired.
+func (c *dpopReplayCache) add(key string, expiresAt time.Time, now time.Time) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeExpired(now)
+	if _, found := c.entries[key]; found {
+		return errDPoPProofReplayed
+	}
+	if len(c.entries) >= c.maxEntries {
+		return errDPoPReplayCacheFull
+	}
+	c.entries[key] = expiresAt
+	heap.Push(&c.expiries, dpopExpiry{key: key, expiresAt: expiresAt})
+	return nil
+}
+
+// removeExpired removes the proofs that expired by now.
+func (c *dpopReplayCache) removeExpired(now time.Time) {
+	for len(c.expiries) > 0 && !now.Before(c.expiries[0].expiresAt) {
+		expired := heap.Pop(&c.expiries).(dpopExpiry)
+		delete(c.entries, expired.key)
+	}
+}
+
+// dpopExpiry is the expiry of a proof in the replay cache.
+type dpopExpiry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// dpopExpiryHeap is a min-heap of proof expiries.
+type dpopExpiryHeap []dpopExpiry
+
+func (h dpopExpiryHeap) Len() int           { return len(h) }
+func (h dpopExpiryHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h dpopExpiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *dpopExpiryHeap) Push(x interface{}) { *h = append(*h, x.(dpopExpiry)) }
+
+func (h *dpopExpiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
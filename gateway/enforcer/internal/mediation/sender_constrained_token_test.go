@@ -0,0 +1,266 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+const testAccessToken = "header.payload.signature"
+
+func newSenderConstrainedToken(t *testing.T, params map[string]string) *SenderConstrainedToken {
+	t.Helper()
+	mediation := &dpv2alpha1.Mediation{PolicyName: MediationSenderConstrainedToken}
+	for key, value := range params {
+		mediation.Parameters = append(mediation.Parameters, &dpv2alpha1.Parameter{Key: key, Value: value})
+	}
+	s := NewSenderConstrainedToken(mediation)
+	s.replayCache = newDPoPReplayCache(dpopReplayCacheMaxEntries)
+	return s
+}
+
+func newSenderConstrainedHolder(claims map[string]interface{}, headers map[string]string) *requestconfig.Holder {
+	requestHeaders := []*corev3.HeaderValue{
+		{Key: ":method", RawValue: []byte("GET")},
+		{Key: ":scheme", RawValue: []byte("https")},
+		{Key: ":authority", RawValue: []byte("api.example.com")},
+		{Key: ":path", RawValue: []byte("/orders/1?expand=items")},
+	}
+	for key, value := range headers {
+		requestHeaders = append(requestHeaders, &corev3.HeaderValue{Key: key, RawValue: []byte(value)})
+	}
+	return &requestconfig.Holder{
+		RequestHeaders:       &envoy_service_proc_v3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: requestHeaders}},
+		RequestAttributes:    &requestconfig.Attributes{},
+		JWTAuthnPayloaClaims: claims,
+	}
+}
+
+// dpopKey is the key of a DPoP client.
+type dpopKey struct {
+	private    *ecdsa.PrivateKey
+	jwk        map[string]interface{}
+	thumbprint string
+}
+
+func newDPoPKey(t *testing.T) *dpopKey {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := jwk.FromRaw(private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, err := public.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(public)
+	key := &dpopKey{private: private, thumbprint: base64.RawURLEncoding.EncodeToString(thumbprint)}
+	_ = json.Unmarshal(data, &key.jwk)
+	return key
+}
+
+// proof signs a DPoP proof, with the claims overriding the defaults for the test request.
+func (k *dpopKey) proof(t *testing.T, overrides jwt.MapClaims) string {
+	t.Helper()
+	hash := sha256.Sum256([]byte(testAccessToken))
+	claims := jwt.MapClaims{
+		"jti": time.Now().String(),
+		"htm": "GET",
+		"htu": "https://api.example.com/orders/1",
+		"iat": time.Now().Unix(),
+		"ath": base64.RawURLEncoding.EncodeToString(hash[:]),
+	}
+	for claim, value := range overrides {
+		claims[claim] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = dpopProofType
+	token.Header["jwk"] = k.jwk
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func expectUnauthorized(t *testing.T, result *Result, scheme string, description string) {
+	t.Helper()
+	if !result.ImmediateResponse || result.ImmediateResponseCode != v32.StatusCode_Unauthorized {
+		t.Fatalf("expected the request to be rejected, got %+v", result)
+	}
+	challenge := result.ImmediateResponseHeaders["WWW-Authenticate"]
+	if !strings.HasPrefix(challenge, scheme+" ") {
+		t.Errorf("expected a %s challenge, got %q", scheme, challenge)
+	}
+	if !strings.Contains(result.ImmediateResponseDetail, description) {
+		t.Errorf("expected %q in the error, got %q", description, result.ImmediateResponseDetail)
+	}
+}
+
+func TestSenderConstrainedToken_CertificateBinding(t *testing.T) {
+	certificate := []byte("client certificate")
+	hash := sha256.Sum256(certificate)
+	digest := hex.EncodeToString(hash[:])
+	claims := map[string]interface{}{
+		"sub": "alice",
+		"cnf": map[string]interface{}{"x5t#S256": base64.RawURLEncoding.EncodeToString(hash[:])},
+	}
+	policy := newSenderConstrainedToken(t, nil)
+
+	holder := newSenderConstrainedHolder(claims, nil)
+	holder.RequestAttributes.PeerCertificateDigest = digest
+	if result := policy.Process(holder); result.ImmediateResponse {
+		t.Errorf("expected the bound certificate to be accepted, got %s", result.ImmediateResponseDetail)
+	}
+
+	otherHash := sha256.Sum256([]byte("other certificate"))
+	holder.RequestAttributes.PeerCertificateDigest = hex.EncodeToString(otherHash[:])
+	expectUnauthorized(t, policy.Process(holder), "Bearer", "not bound to the client certificate")
+
+	xfcc := map[string]string{forwardedClientCertHeader: `By=spiffe://gw;Hash=` + digest + `;Subject="CN=alice"`}
+	expectUnauthorized(t, policy.Process(newSenderConstrainedHolder(claims, xfcc)), "Bearer", "none was presented")
+
+	trusting := newSenderConstrainedToken(t, map[string]string{SenderConstrainedTokenPolicyKeyTrustForwardedClientCert: "true"})
+	if result := trusting.Process(newSenderConstrainedHolder(claims, xfcc)); result.ImmediateResponse {
+		t.Errorf("expected the forwarded certificate hash to be accepted, got %s", result.ImmediateResponseDetail)
+	}
+}
+
+func TestSenderConstrainedToken_DPoP(t *testing.T) {
+	key := newDPoPKey(t)
+	claims := map[string]interface{}{"sub": "alice", "cnf": map[string]interface{}{"jkt": key.thumbprint}}
+	policy := newSenderConstrainedToken(t, nil)
+	request := func(proof string) *requestconfig.Holder {
+		return newSenderConstrainedHolder(claims, map[string]string{"authorization": "DPoP " + testAccessToken, "DPoP": proof})
+	}
+
+	proof := key.proof(t, nil)
+	if result := policy.Process(request(proof)); result.ImmediateResponse {
+		t.Fatalf("expected the DPoP proof to be accepted, got %s", result.ImmediateResponseDetail)
+	}
+	expectUnauthorized(t, policy.Process(request(proof)), "DPoP", "already used")
+
+	tests := []struct {
+		name        string
+		proof       string
+		description string
+	}{
+		{"method", key.proof(t, jwt.MapClaims{"htm": "POST"}), "method"},
+		{"url", key.proof(t, jwt.MapClaims{"htu": "https://api.example.com/orders/2"}), "URL"},
+		{"expired", key.proof(t, jwt.MapClaims{"iat": time.Now().Add(-5 * time.Minute).Unix()}), "expired"},
+		{"access token", key.proof(t, jwt.MapClaims{"ath": "other"}), "access token"},
+		{"key", newDPoPKey(t).proof(t, nil), "not signed with the key"},
+		{"malformed", "not-a-jwt", "invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectUnauthorized(t, policy.Process(request(tt.proof)), "DPoP", tt.description)
+		})
+	}
+
+	bearer := newSenderConstrainedHolder(claims, map[string]string{"authorization": "Bearer " + testAccessToken, "DPoP": key.proof(t, nil)})
+	expectUnauthorized(t, policy.Process(bearer), "DPoP", "DPoP authorization scheme")
+	noProof := newSenderConstrainedHolder(claims, map[string]string{"authorization": "DPoP " + testAccessToken})
+	expectUnauthorized(t, policy.Process(noProof), "DPoP", "exactly one DPoP proof")
+}
+
+func TestSenderConstrainedToken_RequiredBinding(t *testing.T) {
+	unbound := newSenderConstrainedHolder(map[string]interface{}{"sub": "alice"}, nil)
+	if result := newSenderConstrainedToken(t, nil).Process(unbound); result.ImmediateResponse {
+		t.Errorf("expected an unbound token to be accepted by default, got %s", result.ImmediateResponseDetail)
+	}
+	for _, binding := range []string{senderConstraintAny, senderConstraintMTLS, senderConstraintDPoP} {
+		policy := newSenderConstrainedToken(t, map[string]string{SenderConstrainedTokenPolicyKeyRequiredBinding: binding})
+		expectUnauthorized(t, policy.Process(unbound), "Bearer", "not")
+	}
+	disabled := newSenderConstrainedToken(t, map[string]string{
+		SenderConstrainedTokenPolicyKeyEnabled:         "false",
+		SenderConstrainedTokenPolicyKeyRequiredBinding: senderConstraintAny,
+	})
+	if result := disabled.Process(unbound); result.ImmediateResponse {
+		t.Error("expected a disabled policy to accept the request")
+	}
+}
+
+func TestSameHTTPURI(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"https://API.example.com:443/orders", "https://api.example.com/orders", true},
+		{"https://api.example.com/orders?x=1#y", "https://api.example.com/orders", true},
+		{"http://api.example.com:8080/orders", "http://api.example.com:8080/orders", true},
+		{"https://api.example.com/orders", "http://api.example.com/orders", false},
+		{"https://api.example.com/orders", "https://api.example.com/Orders", false},
+		{"/orders", "/orders", false},
+	}
+	for _, tt := range tests {
+		if same := sameHTTPURI(tt.a, tt.b); same != tt.same {
+			t.Errorf("sameHTTPURI(%q, %q) = %v, expected %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
+
+func TestDPoPReplayCache(t *testing.T) {
+	cache := newDPoPReplayCache(2)
+	now := time.Now()
+	if cache.add("a", now.Add(time.Minute), now) != nil || cache.add("a", now.Add(time.Minute), now) != errDPoPProofReplayed {
+		t.Error("expected a proof to be accepted once")
+	}
+	if err := cache.add("a", now.Add(2*time.Minute), now.Add(time.Minute)); err != nil {
+		t.Errorf("expected an expired proof to be forgotten, got %v", err)
+	}
+	if err := cache.add("b", now.Add(3*time.Minute), now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.add("c", now.Add(3*time.Minute), now.Add(time.Minute)); err != errDPoPReplayCacheFull {
+		t.Errorf("expected a full cache to reject new proofs, got %v", err)
+	}
+	if cache.add("a", now.Add(3*time.Minute), now.Add(time.Minute)) != errDPoPProofReplayed {
+		t.Error("expected a full cache to remember its proofs")
+	}
+	// The oldest proof expires first and makes room for a new one.
+	if err := cache.add("c", now.Add(4*time.Minute), now.Add(2*time.Minute)); err != nil {
+		t.Errorf("expected the expired proof to be removed, got %v", err)
+	}
+	if len(cache.entries) != 2 || len(cache.expiries) != 2 {
+		t.Errorf("expected the cache to be bounded, got %d entries", len(cache.entries))
+	}
+}
@@ -30,6 +30,7 @@ import (
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/tidwall/gjson"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
@@ -54,13 +55,13 @@ const (
 	// SentenceCountGuardrailPolicyKeyName is the key for specifying the name of the guardrail.
 	SentenceCountGuardrailPolicyKeyName = "name"
 	// SentenceCountGuardrailPolicyKeyMin is the key for specifying the minimum sentence count.
-	SentenceCountGuardrailPolicyKeyMin = "min"
+	SentenceCountGuardrailPolicyKeyMin = constantscommon.SentenceCountGuardrailPolicyKeyMin
 	// SentenceCountGuardrailPolicyKeyMax is the key for specifying the maximum sentence count.
-	SentenceCountGuardrailPolicyKeyMax = "max"
+	SentenceCountGuardrailPolicyKeyMax = constantscommon.SentenceCountGuardrailPolicyKeyMax
 	// SentenceCountGuardrailPolicyKeyJSONPath is the key for specifying the JSON path to extract content.
 	SentenceCountGuardrailPolicyKeyJSONPath = "jsonPath"
 	// SentenceCountGuardrailPolicyKeyInverted is the key for specifying if the validation should be inverted.
-	SentenceCountGuardrailPolicyKeyInverted = "invert"
+	SentenceCountGuardrailPolicyKeyInverted = constantscommon.SentenceCountGuardrailPolicyKeyInverted
 	// SentenceCountGuardrailPolicyKeyShowAssessment is the key for specifying if assessment should be shown.
 	SentenceCountGuardrailPolicyKeyShowAssessment = "showAssessment"
 
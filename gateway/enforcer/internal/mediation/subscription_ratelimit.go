@@ -5,7 +5,9 @@ import (
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/tracing"
 	"github.com/wso2/apk/common-go-libs/constants"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SubscriptionRatelimit represents the configuration for subscription rate limiting in the API Gateway.
@@ -48,6 +50,11 @@ func (s *SubscriptionRatelimit) Process(requestConfig *requestconfig.Holder) *Re
 	if requestConfig.MatchedSubscription != nil {
 		// Add subscription rate limit headers to the requestConfig
 		result.AddHeaders[constants.SubscriptionUUIDHeaderName] = requestConfig.MatchedSubscription.UUID
+		// The rate limit service is called by Envoy with the descriptor built from the header.
+		trace.SpanFromContext(requestConfig.Context()).SetAttributes(
+			tracing.AttributeRatelimitDescriptor.String(constants.SubscriptionUUIDHeaderName),
+			tracing.AttributeRatelimitSubscription.String(requestConfig.MatchedSubscription.UUID),
+		)
 	} else {
 		s.logger.Sugar().Errorf("No subscription found for the request. Hence not adding any subscription rate limit headers.")
 	}
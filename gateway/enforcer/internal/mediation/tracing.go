@@ -0,0 +1,63 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProcessTraced runs the mediation policy in a span of its own, a child of the span of the
+// processing phase, and records the outcome of the policy on the span. The calls the policy makes
+// while processing become children of the policy span.
+func ProcessTraced(policy *dpv2alpha1.Mediation, m Mediation, requestConfig *requestconfig.Holder) *Result {
+	parent := requestConfig.TraceContext
+	ctx, span := tracing.Start(requestConfig.Context(), "mediation "+policy.PolicyName, trace.WithAttributes(
+		tracing.AttributePolicyName.String(policy.PolicyName),
+		tracing.AttributePolicyID.String(policy.PolicyID),
+		tracing.AttributePolicyVersion.String(policy.PolicyVersion),
+		tracing.AttributePhase.String(string(requestConfig.ProcessingPhase)),
+	))
+	requestConfig.TraceContext = ctx
+	defer func() {
+		requestConfig.TraceContext = parent
+		span.End()
+	}()
+
+	result := m.Process(requestConfig)
+	if result == nil {
+		return result
+	}
+	span.SetAttributes(
+		tracing.AttributeImmediateResponse.Bool(result.ImmediateResponse),
+		tracing.AttributeStopFurtherProcessing.Bool(result.StopFurtherProcessing),
+		tracing.AttributeModifyBody.Bool(result.ModifyBody),
+		tracing.AttributeAddedHeaders.Int(len(result.AddHeaders)),
+		tracing.AttributeRemovedHeaders.Int(len(result.RemoveHeaders)),
+	)
+	if result.ImmediateResponse {
+		span.SetAttributes(tracing.AttributeImmediateResponseCode.Int(int(result.ImmediateResponseCode)))
+		if result.ImmediateResponseCode >= 500 {
+			span.SetStatus(codes.Error, result.ImmediateResponseDetail)
+		}
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"context"
+	"testing"
+
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// mediationFunc adapts a function to the Mediation interface.
+type mediationFunc func(*requestconfig.Holder) *Result
+
+func (f mediationFunc) Process(requestConfig *requestconfig.Holder) *Result {
+	return f(requestConfig)
+}
+
+func TestProcessTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	phaseCtx, phaseSpan := tracing.Start(context.Background(), "ext_proc request_headers")
+	holder := &requestconfig.Holder{
+		ProcessingPhase: requestconfig.ProcessingPhaseRequestHeaders,
+		TraceContext:    phaseCtx,
+	}
+	var policyCtx context.Context
+	deny := mediationFunc(func(requestConfig *requestconfig.Holder) *Result {
+		policyCtx = requestConfig.Context()
+		result := NewResult()
+		result.ImmediateResponse = true
+		result.ImmediateResponseCode = v32.StatusCode_Forbidden
+		result.StopFurtherProcessing = true
+		return result
+	})
+	policy := &dpv2alpha1.Mediation{PolicyName: MediationOPAAuthorization, PolicyID: "opa-policy-id", PolicyVersion: "v1"}
+
+	ProcessTraced(policy, deny, holder)
+	phaseSpan.End()
+
+	if holder.TraceContext != phaseCtx {
+		t.Error("expected the phase context restored after the mediation")
+	}
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(ended))
+	}
+	span := ended[0]
+	if span.Name() != "mediation "+MediationOPAAuthorization || span.Parent().SpanID() != phaseSpan.SpanContext().SpanID() {
+		t.Errorf("unexpected span %s with parent %v", span.Name(), span.Parent().SpanID())
+	}
+	if trace.SpanContextFromContext(policyCtx).SpanID() != span.SpanContext().SpanID() {
+		t.Error("expected the mediation to run in the context of its span")
+	}
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	for key, want := range map[attribute.Key]string{
+		tracing.AttributePolicyName:            MediationOPAAuthorization,
+		tracing.AttributePolicyID:              "opa-policy-id",
+		tracing.AttributePhase:                 string(requestconfig.ProcessingPhaseRequestHeaders),
+		tracing.AttributeImmediateResponse:     "true",
+		tracing.AttributeImmediateResponseCode: "403",
+		tracing.AttributeStopFurtherProcessing: "true",
+		tracing.AttributeModifyBody:            "false",
+	} {
+		if got := attributes[key].Emit(); got != want {
+			t.Errorf("attribute %s: expected %q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestProcessTraced_SubscriptionRatelimit(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	phaseCtx, phaseSpan := tracing.Start(context.Background(), "ext_proc request_headers")
+	holder := &requestconfig.Holder{
+		ProcessingPhase:     requestconfig.ProcessingPhaseRequestHeaders,
+		TraceContext:        phaseCtx,
+		MatchedSubscription: &subscription_model.Subscription{UUID: "sub-1"},
+	}
+	policy := &dpv2alpha1.Mediation{PolicyName: MediationSubscriptionRatelimit}
+	ProcessTraced(policy, NewSubscriptionRatelimit(policy), holder)
+	phaseSpan.End()
+
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range recorder.Ended()[0].Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	if got := attributes[tracing.AttributeRatelimitSubscription].Emit(); got != "sub-1" {
+		t.Errorf("expected the rate limited subscription on the policy span, got %q", got)
+	}
+}
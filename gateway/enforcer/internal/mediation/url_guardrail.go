@@ -35,6 +35,7 @@ import (
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/tidwall/gjson"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
 	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
@@ -60,9 +61,9 @@ const (
 	// URLGuardrailPolicyKeyJSONPath is the key for specifying the JSON path to extract content.
 	URLGuardrailPolicyKeyJSONPath = "jsonPath"
 	// URLGuardrailPolicyKeyOnlyDNS is the key for specifying if only DNS validation should be performed.
-	URLGuardrailPolicyKeyOnlyDNS = "onlyDNS"
+	URLGuardrailPolicyKeyOnlyDNS = constantscommon.URLGuardrailPolicyKeyOnlyDNS
 	// URLGuardrailPolicyKeyTimeout is the key for specifying the timeout for URL validation.
-	URLGuardrailPolicyKeyTimeout = "timeout"
+	URLGuardrailPolicyKeyTimeout = constantscommon.URLGuardrailPolicyKeyTimeout
 	// URLGuardrailPolicyKeyShowAssessment is the key for specifying if assessment should be shown.
 	URLGuardrailPolicyKeyShowAssessment = "showAssessment"
 
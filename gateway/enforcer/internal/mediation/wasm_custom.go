@@ -0,0 +1,367 @@
+package mediation
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+	"github.com/wso2/apk/gateway/enforcer/internal/wasmmetering"
+)
+
+// WASMCustom is a mediation that runs the ExternalInput/ExternalOutput JSON contract inside an
+// embedded WebAssembly runtime. Unlike ExternalCustom, modules do not depend on the enforcer toolchain.
+//
+// Module ABI:
+//   - export "memory": the linear memory used to exchange data.
+//   - export "allocate(size i32) i32": returns a pointer to size bytes the host can write the input to.
+//   - export "<function>(ptr i32, len i32) i64": processes the input JSON and returns the output JSON
+//     location packed as (ptr << 32) | len. A zero length means no change.
+//
+// Modules may import WASI (wasi_snapshot_preview1) and the host function "apk.log(ptr i32, len i32)".
+// Modules are instrumented when compiled so that every call runs within a fuel budget, counted in
+// instructions, and must not export a global named apk_fuel.
+type WASMCustom struct {
+	policy   *dpv2alpha1.Mediation
+	binary   []byte
+	digest   string
+	pages    uint32
+	function string
+	timeout  time.Duration
+	fuel     int64
+	failOpen bool
+	cfg      *config.Server
+	initErr  error
+}
+
+const (
+	paramWASMModule        = constantscommon.WASMCustomPolicyKeyModule        // required: base64 encoded module, usually resolved from a ConfigMap via valueRef
+	paramWASMModuleDigest  = "moduleDigest"                                   // optional: expected sha256 of the module, hex with optional "sha256:" prefix
+	paramWASMFunction      = "function"                                       // optional: exported entrypoint, defaults to process
+	paramWASMMemoryLimitMB = constantscommon.WASMCustomPolicyKeyMemoryLimitMB // optional: max linear memory per instance in MiB, defaults to 16
+	paramWASMTimeoutMs     = constantscommon.WASMCustomPolicyKeyTimeoutMs     // optional: wall-clock timeout per call in milliseconds, defaults to 100
+	paramWASMFuelLimit     = constantscommon.WASMCustomPolicyKeyFuelLimit     // optional: instructions a call may run, defaults to 100000000
+	paramWASMFailOpen      = constantscommon.WASMCustomPolicyKeyFailOpen      // optional: continue without changes when the module fails, defaults to true
+
+	wasmDefaultFunction      = "process"
+	wasmAllocateFunction     = "allocate"
+	wasmDefaultMemoryLimitMB = 16
+	wasmDefaultTimeout       = 100 * time.Millisecond
+	wasmDefaultFuel          = 100_000_000
+	wasmPageSize             = 64 * 1024
+	wasmMaxPages             = 65536
+	wasmHostModuleName       = "apk"
+)
+
+// wasmModule is a compiled module along with the runtime that enforces its memory limit.
+// Modules are shared across policies by digest and memory limit.
+type wasmModule struct {
+	key      string
+	digest   string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	// refs counts the calls using the module. An evicted module is closed when its last call returns.
+	refs    int
+	evicted bool
+	elem    *list.Element
+}
+
+var (
+	// wasmModules caches compiled modules by key, with wasmModulesLRU ordering them from the most
+	// recently used. Modules of policies that changed or were removed age out of the cache, as the
+	// enforcer is not told which mediations are no longer used when a RoutePolicy is updated.
+	wasmModules    = map[string]*wasmModule{}
+	wasmModulesLRU = list.New()
+	wasmModulesMu  sync.Mutex
+)
+
+// NewWASMCustom constructs a WASMCustom mediation from the cluster policy.
+// Module compilation errors are recorded and reported on every call, honouring failOpen.
+func NewWASMCustom(m *dpv2alpha1.Mediation) *WASMCustom {
+	w := &WASMCustom{
+		policy:   m,
+		function: wasmDefaultFunction,
+		timeout:  wasmDefaultTimeout,
+		fuel:     wasmDefaultFuel,
+		failOpen: true,
+		cfg:      config.GetConfig(),
+	}
+	if v, ok := extractPolicyValue(m.Parameters, paramWASMFunction); ok && v != "" {
+		w.function = v
+	}
+	if v, ok := extractPolicyValue(m.Parameters, paramWASMTimeoutMs); ok && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			w.timeout = time.Duration(n) * time.Millisecond
+		}
+	}
+	if v, ok := extractPolicyValue(m.Parameters, paramWASMFuelLimit); ok && v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			w.fuel = n
+		}
+	}
+	if v, ok := extractPolicyValue(m.Parameters, paramWASMFailOpen); ok && v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			w.failOpen = b
+		}
+	}
+	memoryLimitMB := wasmDefaultMemoryLimitMB
+	if v, ok := extractPolicyValue(m.Parameters, paramWASMMemoryLimitMB); ok && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			memoryLimitMB = n
+		}
+	}
+	pages := uint32(min(memoryLimitMB*(1024*1024/wasmPageSize), wasmMaxPages))
+
+	encoded, _ := extractPolicyValue(m.Parameters, paramWASMModule)
+	binary, err := decodeWASMModule(encoded)
+	if err != nil {
+		w.initErr = err
+		w.cfg.Logger.Sugar().Errorf("WASMCustom policy %s: %v", m.PolicyName, err)
+		return w
+	}
+	digest := sha256Hex(binary)
+	w.binary, w.digest, w.pages = binary, digest, pages
+	if v, ok := extractPolicyValue(m.Parameters, paramWASMModuleDigest); ok && v != "" {
+		if !strings.EqualFold(strings.TrimPrefix(v, "sha256:"), digest) {
+			w.initErr = fmt.Errorf("module digest mismatch: expected %s, got sha256:%s", v, digest)
+			w.cfg.Logger.Sugar().Errorf("WASMCustom policy %s: %v", m.PolicyName, w.initErr)
+			return w
+		}
+	}
+	// Compile up front so that invalid modules are reported when the policy is created.
+	module, err := getWASMModule(binary, digest, pages, w.cfg.WASMModuleCacheSize, &w.cfg.Logger)
+	if err != nil {
+		w.initErr = err
+		w.cfg.Logger.Sugar().Errorf("WASMCustom policy %s: %v", m.PolicyName, w.initErr)
+		return w
+	}
+	module.release()
+	return w
+}
+
+// Process implements the Mediation interface by running the module entrypoint.
+func (w *WASMCustom) Process(h *requestconfig.Holder) *Result {
+	if !w.cfg.WASMMediationEnabled {
+		return NewResult()
+	}
+	out, err := w.invoke(h)
+	if err != nil {
+		w.cfg.Logger.Sugar().Errorf("WASMCustom policy %s failed: %v", w.policy.PolicyName, err)
+		if w.failOpen {
+			return NewResult()
+		}
+		result := NewResult()
+		result.ImmediateResponse = true
+		result.ImmediateResponseCode = 500
+		result.ImmediateResponseContentType = "application/json"
+		result.ImmediateResponseBody = `{"error":"Internal Server Error","message":"Mediation policy execution failed"}`
+		result.StopFurtherProcessing = true
+		return result
+	}
+	return mapExternalOutputToResult(out)
+}
+
+func (w *WASMCustom) invoke(h *requestconfig.Holder) (*commonmediation.ExternalOutput, error) {
+	if w.initErr != nil {
+		return nil, w.initErr
+	}
+	in := buildExternalInput(w.policy, h)
+	// The module itself is not useful to the module and can be large.
+	delete(in.Parameters, paramWASMModule)
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	// The module is looked up on every call as it may have been evicted from the cache since.
+	module, err := getWASMModule(w.binary, w.digest, w.pages, w.cfg.WASMModuleCacheSize, &w.cfg.Logger)
+	if err != nil {
+		return nil, err
+	}
+	defer module.release()
+	output, err := module.call(w.function, payload, w.timeout, w.fuel)
+	if err != nil {
+		return nil, err
+	}
+	var extOut commonmediation.ExternalOutput
+	if len(output) == 0 {
+		return &extOut, nil
+	}
+	if err := json.Unmarshal(output, &extOut); err != nil {
+		return nil, fmt.Errorf("invalid module output: %w", err)
+	}
+	return &extOut, nil
+}
+
+// call runs the function in a fresh module instance so that calls are isolated and can run concurrently.
+// The allocation and the function share the fuel of the call, and the module traps once it runs out.
+// The wall-clock timeout also bounds the time spent in host functions, which is not metered.
+func (m *wasmModule) call(function string, input []byte, timeout time.Duration, fuel int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	instance, err := m.runtime.InstantiateModule(ctx, m.compiled,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module %s: %w", m.digest, err)
+	}
+	defer instance.Close(context.Background())
+
+	allocate := instance.ExportedFunction(wasmAllocateFunction)
+	process := instance.ExportedFunction(function)
+	if allocate == nil || process == nil || instance.Memory() == nil {
+		return nil, fmt.Errorf("module %s must export memory, %q and %q", m.digest, wasmAllocateFunction, function)
+	}
+	fuelGlobal, ok := instance.ExportedGlobal(wasmmetering.FuelGlobal).(api.MutableGlobal)
+	if !ok {
+		return nil, fmt.Errorf("module %s is not metered", m.digest)
+	}
+	fuelGlobal.Set(uint64(fuel))
+	res, err := allocate.Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, wasmCallError(ctx, err, timeout, fuelGlobal, fuel)
+	}
+	ptr := uint32(res[0])
+	if !instance.Memory().Write(ptr, input) {
+		return nil, fmt.Errorf("allocated region [%d, %d) is out of the module memory", ptr, ptr+uint32(len(input)))
+	}
+	res, err = process.Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, wasmCallError(ctx, err, timeout, fuelGlobal, fuel)
+	}
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+	if outLen == 0 {
+		return nil, nil
+	}
+	out, ok := instance.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("output region [%d, %d) is out of the module memory", outPtr, outPtr+outLen)
+	}
+	// Copy before the instance memory is released.
+	return append([]byte(nil), out...), nil
+}
+
+func wasmCallError(ctx context.Context, err error, timeout time.Duration, fuelGlobal api.Global, fuel int64) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("module timed out after %s", timeout)
+	}
+	if int64(fuelGlobal.Get()) < 0 {
+		return fmt.Errorf("module ran out of fuel after %d instructions", fuel)
+	}
+	return err
+}
+
+// getWASMModule returns the cached module for the digest and memory limit, compiling it on first use.
+// At most cacheSize modules are kept, evicting the least recently used. The caller must release the
+// returned module when its call returns.
+func getWASMModule(binary []byte, digest string, memoryLimitPages uint32, cacheSize int,
+	logger *logging.Logger) (*wasmModule, error) {
+	key := fmt.Sprintf("%s/%d", digest, memoryLimitPages)
+	wasmModulesMu.Lock()
+	defer wasmModulesMu.Unlock()
+	if m, ok := wasmModules[key]; ok {
+		wasmModulesLRU.MoveToFront(m.elem)
+		m.refs++
+		return m, nil
+	}
+	ctx := context.Background()
+	// Each runtime owns its compiled code, so closing an evicted runtime frees it.
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(memoryLimitPages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	if _, err := rt.NewHostModuleBuilder(wasmHostModuleName).
+		NewFunctionBuilder().WithFunc(newWASMHostLog(logger)).Export("log").
+		Instantiate(ctx); err != nil {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate host module: %w", err)
+	}
+	metered, err := wasmmetering.Instrument(binary)
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("failed to meter module sha256:%s: %w", digest, err)
+	}
+	compiled, err := rt.CompileModule(ctx, metered)
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("failed to compile module sha256:%s: %w", digest, err)
+	}
+	m := &wasmModule{key: key, digest: digest, runtime: rt, compiled: compiled, refs: 1}
+	m.elem = wasmModulesLRU.PushFront(m)
+	wasmModules[key] = m
+	for cacheSize > 0 && wasmModulesLRU.Len() > cacheSize {
+		oldest := wasmModulesLRU.Remove(wasmModulesLRU.Back()).(*wasmModule)
+		delete(wasmModules, oldest.key)
+		oldest.evicted = true
+		if oldest.refs == 0 {
+			oldest.close()
+		}
+	}
+	return m, nil
+}
+
+// release ends a call using the module, closing it if it was evicted in the meantime.
+func (m *wasmModule) release() {
+	wasmModulesMu.Lock()
+	defer wasmModulesMu.Unlock()
+	m.refs--
+	if m.evicted && m.refs == 0 {
+		m.close()
+	}
+}
+
+func (m *wasmModule) close() {
+	_ = m.runtime.Close(context.Background())
+}
+
+// newWASMHostLog returns the host function that lets modules write debug logs through the enforcer logger.
+func newWASMHostLog(logger *logging.Logger) func(context.Context, api.Module, uint32, uint32) {
+	return func(_ context.Context, mod api.Module, ptr, length uint32) {
+		if mod.Memory() == nil {
+			return
+		}
+		if msg, ok := mod.Memory().Read(ptr, length); ok {
+			logger.Sugar().Debugf("WASMCustom module log: %s", truncate(string(msg), 1024))
+		}
+	}
+}
+
+// decodeWASMModule decodes a base64 encoded module. ConfigMap binaryData values are base64 encoded by
+// the common controller when resolving valueRef parameters.
+func decodeWASMModule(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, fmt.Errorf("parameter %s is required", paramWASMModule)
+	}
+	if strings.HasPrefix(encoded, "\x00asm") {
+		return []byte(encoded), nil
+	}
+	binary, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %s is not a base64 encoded module: %w", paramWASMModule, err)
+	}
+	return binary, nil
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
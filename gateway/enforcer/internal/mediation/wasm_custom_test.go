@@ -0,0 +1,295 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/requestconfig"
+)
+
+const testWASMOutput = `{"addHeaders":{"x-wasm":"ok"}}`
+
+// uleb128 encodes v as an unsigned LEB128 integer.
+func uleb128(v uint32) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func wasmSection(id byte, payload ...byte) []byte {
+	return append(append([]byte{id}, uleb128(uint32(len(payload)))...), payload...)
+}
+
+func wasmName(name string) []byte {
+	return append(uleb128(uint32(len(name))), name...)
+}
+
+func wasmBody(code ...byte) []byte {
+	body := append([]byte{0x00}, code...) // no locals
+	return append(uleb128(uint32(len(body))), body...)
+}
+
+// buildTestWASMModule hand encodes a module implementing the WASMCustom ABI:
+//   - allocate: bump allocator starting at 1024
+//   - process: returns testWASMOutput stored in a data segment at offset 0
+//   - loop: never returns
+//   - grow: grows memory by 100 pages and traps if that fails
+func buildTestWASMModule() []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// types: 0 = (i32) -> i32, 1 = (i32, i32) -> i64
+	module = append(module, wasmSection(0x01, 0x02,
+		0x60, 0x01, 0x7f, 0x01, 0x7f,
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e)...)
+	module = append(module, wasmSection(0x03, 0x04, 0x00, 0x01, 0x01, 0x01)...)
+	module = append(module, wasmSection(0x05, 0x01, 0x00, 0x01)...)
+	// mutable i32 heap pointer initialised to 1024
+	module = append(module, wasmSection(0x06, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b)...)
+
+	exports := []byte{0x05}
+	exports = append(append(exports, wasmName("memory")...), 0x02, 0x00)
+	exports = append(append(exports, wasmName("allocate")...), 0x00, 0x00)
+	exports = append(append(exports, wasmName("process")...), 0x00, 0x01)
+	exports = append(append(exports, wasmName("loop")...), 0x00, 0x02)
+	exports = append(append(exports, wasmName("grow")...), 0x00, 0x03)
+	module = append(module, wasmSection(0x07, exports...)...)
+
+	code := []byte{0x04}
+	// allocate: old = heap; heap += size; return old
+	code = append(code, wasmBody(0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b)...)
+	// process: return (0 << 32) | len(testWASMOutput)
+	code = append(code, wasmBody(0x42, byte(len(testWASMOutput)), 0x0b)...)
+	// loop: loop { br 0 }; return 0
+	code = append(code, wasmBody(0x03, 0x40, 0x0c, 0x00, 0x0b, 0x42, 0x00, 0x0b)...)
+	// grow: if memory.grow(100) == -1 { unreachable }; return 0
+	code = append(code, wasmBody(0x41, 0xe4, 0x00, 0x40, 0x00, 0x41, 0x7f, 0x46, 0x04, 0x40, 0x00, 0x0b, 0x42, 0x00, 0x0b)...)
+	module = append(module, wasmSection(0x0a, code...)...)
+
+	data := []byte{0x01, 0x00, 0x41, 0x00, 0x0b}
+	data = append(append(data, uleb128(uint32(len(testWASMOutput)))...), testWASMOutput...)
+	return append(module, wasmSection(0x0b, data...)...)
+}
+
+func createTestWASMMediation(params map[string]string) *dpv2alpha1.Mediation {
+	var parameters []*dpv2alpha1.Parameter
+	for key, value := range params {
+		parameters = append(parameters, &dpv2alpha1.Parameter{Key: key, Value: value})
+	}
+	return &dpv2alpha1.Mediation{
+		PolicyName:    MediationWASMCustom,
+		PolicyVersion: "v1",
+		PolicyID:      "test-wasm-policy-id",
+		Parameters:    parameters,
+	}
+}
+
+func TestWASMCustom_Process(t *testing.T) {
+	module := buildTestWASMModule()
+	encoded := base64.StdEncoding.EncodeToString(module)
+
+	tests := []struct {
+		name          string
+		params        map[string]string
+		wantHeader    bool
+		wantImmediate bool
+	}{
+		{
+			name:       "Module output is applied",
+			params:     map[string]string{"module": encoded},
+			wantHeader: true,
+		},
+		{
+			name:       "Matching digest",
+			params:     map[string]string{"module": encoded, "moduleDigest": "sha256:" + sha256Hex(module)},
+			wantHeader: true,
+		},
+		{
+			name:   "Digest mismatch fails open",
+			params: map[string]string{"module": encoded, "moduleDigest": "sha256:deadbeef"},
+		},
+		{
+			name:          "Digest mismatch fails closed",
+			params:        map[string]string{"module": encoded, "moduleDigest": "deadbeef", "failOpen": "false"},
+			wantImmediate: true,
+		},
+		{
+			name:   "Missing module fails open",
+			params: map[string]string{},
+		},
+		{
+			name:          "Invalid module fails closed",
+			params:        map[string]string{"module": base64.StdEncoding.EncodeToString([]byte("not wasm")), "failOpen": "false"},
+			wantImmediate: true,
+		},
+		{
+			name:          "Missing entrypoint fails closed",
+			params:        map[string]string{"module": encoded, "function": "missing", "failOpen": "false"},
+			wantImmediate: true,
+		},
+		{
+			name:          "Timeout exceeded",
+			params:        map[string]string{"module": encoded, "function": "loop", "timeoutMs": "20", "failOpen": "false"},
+			wantImmediate: true,
+		},
+		{
+			name:          "Fuel exhausted",
+			params:        map[string]string{"module": encoded, "function": "loop", "fuelLimit": "1000", "timeoutMs": "60000", "failOpen": "false"},
+			wantImmediate: true,
+		},
+		{
+			name:          "Memory limit exceeded",
+			params:        map[string]string{"module": encoded, "function": "grow", "memoryLimitMb": "1", "failOpen": "false"},
+			wantImmediate: true,
+		},
+		{
+			name:       "Memory within limit",
+			params:     map[string]string{"module": encoded, "function": "grow", "memoryLimitMb": "8"},
+			wantHeader: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWASMCustom(createTestWASMMediation(tt.params))
+			result := w.Process(&requestconfig.Holder{ProcessingPhase: requestconfig.ProcessingPhaseRequestHeaders})
+			if got := result.AddHeaders["x-wasm"] == "ok"; got != tt.wantHeader {
+				t.Errorf("expected header applied=%v, got headers %v", tt.wantHeader, result.AddHeaders)
+			}
+			if result.ImmediateResponse != tt.wantImmediate {
+				t.Errorf("expected immediate response=%v, got %v", tt.wantImmediate, result.ImmediateResponse)
+			}
+			if tt.wantImmediate && result.ImmediateResponseCode != 500 {
+				t.Errorf("expected status 500, got %v", result.ImmediateResponseCode)
+			}
+		})
+	}
+}
+
+// cachedWASMModule returns the cached module of a policy without compiling it.
+func cachedWASMModule(w *WASMCustom) *wasmModule {
+	wasmModulesMu.Lock()
+	defer wasmModulesMu.Unlock()
+	return wasmModules[fmt.Sprintf("%s/%d", w.digest, w.pages)]
+}
+
+func TestWASMCustom_ModuleCache(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(buildTestWASMModule())
+	first := NewWASMCustom(createTestWASMMediation(map[string]string{"module": encoded}))
+	second := NewWASMCustom(createTestWASMMediation(map[string]string{"module": encoded, "function": "loop"}))
+	other := NewWASMCustom(createTestWASMMediation(map[string]string{"module": encoded, "memoryLimitMb": "2"}))
+	if cachedWASMModule(first) == nil || cachedWASMModule(first) != cachedWASMModule(second) {
+		t.Fatalf("expected policies with the same module and limits to share the compiled module")
+	}
+	if cachedWASMModule(other) == nil || cachedWASMModule(other) == cachedWASMModule(first) {
+		t.Fatalf("expected a different memory limit to use a separate runtime")
+	}
+}
+
+func TestWASMCustom_ModuleCacheEviction(t *testing.T) {
+	binary := buildTestWASMModule()
+	digest := sha256Hex(binary)
+	logger := &config.GetConfig().Logger
+
+	// A module in use when it is evicted stays usable until it is released.
+	inUse, err := getWASMModule(binary, digest, 100, 1, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	evictor, err := getWASMModule(binary, digest, 101, 1, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	evictor.release()
+	if !inUse.evicted {
+		t.Fatalf("expected the least recently used module to be evicted")
+	}
+	if _, err := inUse.call("process", []byte(`{}`), time.Second, wasmDefaultFuel); err != nil {
+		t.Fatalf("expected an evicted module to serve its in-flight call, got %v", err)
+	}
+	inUse.release()
+
+	wasmModulesMu.Lock()
+	_, cached := wasmModules[inUse.key]
+	wasmModulesMu.Unlock()
+	if cached {
+		t.Fatalf("expected the evicted module to be removed from the cache")
+	}
+
+	// Policies whose module was evicted compile it again on their next call.
+	w := NewWASMCustom(createTestWASMMediation(map[string]string{"module": base64.StdEncoding.EncodeToString(binary)}))
+	evictor, err = getWASMModule(binary, digest, 102, 1, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	evictor.release()
+	if cachedWASMModule(w) != nil {
+		t.Fatalf("expected the module of the policy to be evicted")
+	}
+	result := w.Process(&requestconfig.Holder{ProcessingPhase: requestconfig.ProcessingPhaseRequestHeaders})
+	if result.AddHeaders["x-wasm"] != "ok" {
+		t.Fatalf("expected the module to be compiled again, got headers %v", result.AddHeaders)
+	}
+}
+
+func TestWASMCustom_FuelLimit(t *testing.T) {
+	binary := buildTestWASMModule()
+	module, err := getWASMModule(binary, sha256Hex(binary), 16, 0, &config.GetConfig().Logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer module.release()
+	if _, err := module.call("process", []byte(`{}`), time.Minute, 100); err != nil {
+		t.Fatalf("expected the call to run within its fuel, got %v", err)
+	}
+	_, err = module.call("loop", []byte(`{}`), time.Minute, 10000)
+	if err == nil || !strings.Contains(err.Error(), "ran out of fuel") {
+		t.Fatalf("expected the module to run out of fuel, got %v", err)
+	}
+}
+
+func TestWASMCustom_ConcurrentAccess(t *testing.T) {
+	w := NewWASMCustom(createTestWASMMediation(map[string]string{
+		"module": base64.StdEncoding.EncodeToString(buildTestWASMModule()),
+	}))
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := w.Process(&requestconfig.Holder{ProcessingPhase: requestconfig.ProcessingPhaseRequestHeaders})
+			if result.AddHeaders["x-wasm"] != "ok" {
+				t.Errorf("unexpected result headers: %v", result.AddHeaders)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -29,6 +29,7 @@ import (
 
 	v32 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/tidwall/gjson"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/logging"
@@ -54,13 +55,13 @@ const (
 	// WordCountGuardrailPolicyKeyName is the key for specifying the name of the guardrail.
 	WordCountGuardrailPolicyKeyName = "name"
 	// WordCountGuardrailPolicyKeyMin is the key for specifying the minimum word count.
-	WordCountGuardrailPolicyKeyMin = "min"
+	WordCountGuardrailPolicyKeyMin = constantscommon.WordCountGuardrailPolicyKeyMin
 	// WordCountGuardrailPolicyKeyMax is the key for specifying the maximum word count.
-	WordCountGuardrailPolicyKeyMax = "max"
+	WordCountGuardrailPolicyKeyMax = constantscommon.WordCountGuardrailPolicyKeyMax
 	// WordCountGuardrailPolicyKeyJSONPath is the key for specifying the JSON path to extract content.
 	WordCountGuardrailPolicyKeyJSONPath = "jsonPath"
 	// WordCountGuardrailPolicyKeyInverted is the key for specifying if the validation should be inverted.
-	WordCountGuardrailPolicyKeyInverted = "invert"
+	WordCountGuardrailPolicyKeyInverted = constantscommon.WordCountGuardrailPolicyKeyInverted
 	// WordCountGuardrailPolicyKeyShowAssessment is the key for specifying if assessment should be shown.
 	WordCountGuardrailPolicyKeyShowAssessment = "showAssessment"
 
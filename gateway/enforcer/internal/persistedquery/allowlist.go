@@ -0,0 +1,118 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package persistedquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// apolloManifestFormat is the format of the persisted query manifests generated by Apollo tooling.
+const apolloManifestFormat = "apollo-persisted-query-manifest"
+
+// AllowList holds the operations registered ahead of time for an API.
+type AllowList struct {
+	// byID maps the operation ids used by the clients to the operation documents.
+	byID map[string]string
+	// hashes holds the hashes of the operation documents, so that operations sent in full are
+	// allowed whatever id they were registered with.
+	hashes map[string]struct{}
+}
+
+type apolloManifest struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// ParseAllowList parses the registered operations. Both the Apollo persisted query manifest and a
+// JSON object mapping operation ids to documents, as generated by Relay, are accepted. Operations
+// without an id are registered under the hash of their document.
+func ParseAllowList(data string) (*AllowList, error) {
+	allowList := &AllowList{byID: map[string]string{}, hashes: map[string]struct{}{}}
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return allowList, nil
+	}
+	var manifest apolloManifest
+	if err := json.Unmarshal([]byte(data), &manifest); err == nil && manifest.Format == apolloManifestFormat {
+		if manifest.Version != 1 {
+			return nil, fmt.Errorf("unsupported persisted query manifest version %d", manifest.Version)
+		}
+		for _, operation := range manifest.Operations {
+			if err := allowList.add(operation.ID, operation.Body); err != nil {
+				return nil, fmt.Errorf("invalid operation %s: %w", operation.Name, err)
+			}
+		}
+		return allowList, nil
+	}
+	var operations map[string]string
+	if err := json.Unmarshal([]byte(data), &operations); err != nil {
+		return nil, fmt.Errorf("error parsing the operation allow-list: %w", err)
+	}
+	for id, body := range operations {
+		if err := allowList.add(id, body); err != nil {
+			return nil, fmt.Errorf("invalid operation %s: %w", id, err)
+		}
+	}
+	return allowList, nil
+}
+
+func (a *AllowList) add(id string, body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("operation document is empty")
+	}
+	hash := Hash(body)
+	if id == "" {
+		id = hash
+	}
+	a.byID[id] = body
+	a.hashes[hash] = struct{}{}
+	return nil
+}
+
+// Lookup returns the operation document registered under the id.
+func (a *AllowList) Lookup(id string) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	body, ok := a.byID[id]
+	return body, ok
+}
+
+// Allows reports whether the operation document is registered.
+func (a *AllowList) Allows(query string) bool {
+	if a == nil {
+		return false
+	}
+	_, ok := a.hashes[Hash(query)]
+	return ok
+}
+
+// Len returns the number of registered operations.
+func (a *AllowList) Len() int {
+	if a == nil {
+		return 0
+	}
+	return len(a.byID)
+}
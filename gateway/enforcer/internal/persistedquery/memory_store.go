@@ -0,0 +1,89 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package persistedquery
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	query     string
+	expiresAt time.Time
+}
+
+// MemoryStore is a Store that keeps the queries in the enforcer memory, so registrations are not
+// shared across replicas. Each partition holds at most maxEntries queries and the entry closest to
+// its expiry is evicted first.
+type MemoryStore struct {
+	maxEntries int
+	ttl        time.Duration
+	partitions map[string]map[string]*memoryEntry
+
+	mutex sync.RWMutex
+}
+
+// NewMemoryStore creates a new instance of MemoryStore.
+func NewMemoryStore(maxEntries int, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		partitions: make(map[string]map[string]*memoryEntry),
+	}
+}
+
+// Get implements Store.
+func (m *MemoryStore) Get(_ context.Context, partition string, hash string) (string, bool, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	entry, ok := m.partitions[partition][hash]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false, nil
+	}
+	return entry.query, true, nil
+}
+
+// Put implements Store.
+func (m *MemoryStore) Put(_ context.Context, partition string, hash string, query string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entries, ok := m.partitions[partition]
+	if !ok {
+		entries = make(map[string]*memoryEntry)
+		m.partitions[partition] = entries
+	}
+	now := time.Now()
+	if _, exists := entries[hash]; !exists && m.maxEntries > 0 && len(entries) >= m.maxEntries {
+		var oldest string
+		for key, entry := range entries {
+			if now.After(entry.expiresAt) {
+				delete(entries, key)
+				continue
+			}
+			if oldest == "" || entry.expiresAt.Before(entries[oldest].expiresAt) {
+				oldest = key
+			}
+		}
+		if len(entries) >= m.maxEntries {
+			delete(entries, oldest)
+		}
+	}
+	entries[hash] = &memoryEntry{query: query, expiresAt: now.Add(m.ttl)}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package persistedquery
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHash(t *testing.T) {
+	// The hash Apollo clients send for the query.
+	if hash := Hash("{__typename}"); hash != "ecf4edb46db40b5132295c0291d62fb65d6759a9eedfa4d5d612dd5ec54a6b38" {
+		t.Errorf("unexpected hash %s", hash)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2, time.Hour)
+	_ = store.Put(ctx, "route", "first", "{ a }")
+	_ = store.Put(ctx, "route", "second", "{ b }")
+
+	if query, ok, _ := store.Get(ctx, "route", "second"); !ok || query != "{ b }" {
+		t.Fatalf("expected the second query, got %q", query)
+	}
+	if _, ok, _ := store.Get(ctx, "other", "second"); ok {
+		t.Errorf("expected partitions to be isolated")
+	}
+
+	// Registering a third query evicts the oldest one.
+	time.Sleep(time.Millisecond)
+	_ = store.Put(ctx, "route", "third", "{ c }")
+	if _, ok, _ := store.Get(ctx, "route", "first"); ok {
+		t.Errorf("expected the oldest query to be evicted")
+	}
+	if _, ok, _ := store.Get(ctx, "route", "third"); !ok {
+		t.Errorf("expected the third query to be registered")
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10, -time.Second)
+	_ = store.Put(ctx, "route", "hash", "{ a }")
+	if _, ok, _ := store.Get(ctx, "route", "hash"); ok {
+		t.Errorf("expected expired queries to be ignored")
+	}
+}
+
+func TestParseAllowList(t *testing.T) {
+	query := "query Author { author(id: 1) { name } }"
+	tests := []struct {
+		name    string
+		data    string
+		id      string
+		wantErr bool
+	}{
+		{
+			name: "apollo manifest",
+			data: `{"format": "apollo-persisted-query-manifest", "version": 1, "operations": [
+				{"id": "author-v1", "name": "Author", "type": "query", "body": "query Author { author(id: 1) { name } }"}]}`,
+			id: "author-v1",
+		},
+		{
+			name: "relay map",
+			data: `{"5d4e0b5ed0b6bd8a0ae3bc9b8a1f8b6c": "query Author { author(id: 1) { name } }"}`,
+			id:   "5d4e0b5ed0b6bd8a0ae3bc9b8a1f8b6c",
+		},
+		{
+			name: "apollo manifest without ids",
+			data: `{"format": "apollo-persisted-query-manifest", "version": 1, "operations": [
+				{"name": "Author", "type": "query", "body": "query Author { author(id: 1) { name } }"}]}`,
+			id: Hash(query),
+		},
+		{name: "unsupported manifest version", data: `{"format": "apollo-persisted-query-manifest", "version": 2}`, wantErr: true},
+		{name: "empty document", data: `{"id": " "}`, wantErr: true},
+		{name: "not json", data: `query Author { author(id: 1) { name } }`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowList, err := ParseAllowList(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if body, ok := allowList.Lookup(tt.id); !ok || body != query {
+				t.Errorf("expected the operation registered under %s, got %q", tt.id, body)
+			}
+			if !allowList.Allows(query) || allowList.Allows("{ __schema { types { name } } }") || allowList.Len() != 1 {
+				t.Errorf("expected only the registered operation allowed")
+			}
+		})
+	}
+
+	var empty *AllowList
+	if empty.Allows(query) {
+		t.Error("expected a missing allow-list to allow nothing")
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package persistedquery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "wso2-apk-persisted-query:"
+
+// RedisStore is a Store that shares the registered queries across enforcer replicas through Redis.
+// Each query is a Redis string that expires ttl after it was last registered.
+type RedisStore struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+// NewRedisStore creates a new instance of RedisStore.
+func NewRedisStore(client redis.UniversalClient, ttl time.Duration) *RedisStore {
+	return &RedisStore{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// Get implements Store.
+func (r *RedisStore) Get(ctx context.Context, partition string, hash string) (string, bool, error) {
+	query, err := r.client.Get(ctx, redisKey(partition, hash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return query, true, nil
+}
+
+// Put implements Store.
+func (r *RedisStore) Put(ctx context.Context, partition string, hash string, query string) error {
+	return r.client.Set(ctx, redisKey(partition, hash), query, r.ttl).Err()
+}
+
+func redisKey(partition string, hash string) string {
+	return redisKeyPrefix + partition + ":" + hash
+}
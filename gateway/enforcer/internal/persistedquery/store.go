@@ -0,0 +1,40 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package persistedquery provides the stores and allow-lists used to run GraphQL persisted queries.
+package persistedquery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Store holds the queries registered through Automatic Persisted Queries in isolated partitions,
+// keyed by the sha256 hash of the query.
+type Store interface {
+	// Get returns the query registered under the hash, and false when there is none.
+	Get(ctx context.Context, partition string, hash string) (string, bool, error)
+	// Put registers the query under the hash.
+	Put(ctx context.Context, partition string, hash string, query string) error
+}
+
+// Hash returns the hex encoded sha256 hash identifying a query, as computed by the clients.
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
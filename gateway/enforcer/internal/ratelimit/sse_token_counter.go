@@ -0,0 +1,177 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ratelimit
+
+import (
+	"bytes"
+	"strings"
+
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// EventStreamContentType is the content type of server-sent event streams.
+	EventStreamContentType = "text/event-stream"
+
+	sseDataPrefix    = "data:"
+	sseDoneSentinel  = "[DONE]"
+	sseDefaultModel  = "model"
+	maxSSELineLength = 1 << 20
+)
+
+// IsEventStream reports whether the headers declare a server-sent event stream.
+func IsEventStream(headers *envoy_service_proc_v3.HttpHeaders) bool {
+	for _, header := range headers.GetHeaders().GetHeaders() {
+		if strings.EqualFold(header.GetKey(), "content-type") {
+			value := header.GetValue()
+			if value == "" {
+				value = string(header.GetRawValue())
+			}
+			return strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), EventStreamContentType)
+		}
+	}
+	return false
+}
+
+// SSETokenCounter counts the tokens of a streamed (text/event-stream) AI response incrementally.
+// Chunks are parsed as they arrive and only an incomplete trailing line is kept between chunks.
+//
+// Usage reported by the frames carrying the token paths wins, later frames overriding earlier
+// ones, as providers send the totals in a final usage frame. When no frame reports usage, every
+// data frame is counted as one completion token.
+type SSETokenCounter struct {
+	promptPath     string
+	completionPath string
+	totalPath      string
+	modelPath      string
+
+	pending        []byte
+	usage          *TokenCountAndModel
+	totalReported  bool
+	model          string
+	frames         int
+	discardingLine bool
+}
+
+// NewSSETokenCounter creates a new instance of SSETokenCounter. The paths are JSONPaths into the
+// data frames, as configured for buffered responses.
+func NewSSETokenCounter(promptPath, completionPath, totalPath, modelPath string) *SSETokenCounter {
+	if modelPath == "" {
+		modelPath = sseDefaultModel
+	}
+	return &SSETokenCounter{
+		promptPath:     trimJSONPath(promptPath),
+		completionPath: trimJSONPath(completionPath),
+		totalPath:      trimJSONPath(totalPath),
+		modelPath:      trimJSONPath(modelPath),
+	}
+}
+
+// Write consumes the next chunk of the stream.
+func (s *SSETokenCounter) Write(chunk []byte) {
+	for len(chunk) > 0 {
+		newline := bytes.IndexByte(chunk, '\n')
+		if newline < 0 {
+			if !s.discardingLine {
+				s.pending = append(s.pending, chunk...)
+				if len(s.pending) > maxSSELineLength {
+					// Guard against unbounded growth on malformed streams.
+					s.pending, s.discardingLine = nil, true
+				}
+			}
+			return
+		}
+		line := chunk[:newline]
+		if len(s.pending) > 0 {
+			line = append(s.pending, line...)
+			s.pending = nil
+		}
+		if !s.discardingLine {
+			s.processLine(line)
+		}
+		s.discardingLine = false
+		chunk = chunk[newline+1:]
+	}
+}
+
+// Close flushes the last line when the stream does not end with a newline.
+func (s *SSETokenCounter) Close() {
+	if len(s.pending) > 0 && !s.discardingLine {
+		s.processLine(s.pending)
+	}
+	s.pending = nil
+}
+
+// TokenCount returns the token counts of the stream so far, or nil when no data frame was seen.
+// Unlike ExtractTokenCountFromExternalProcessingResponseBody the counts are not adjusted for the
+// hit already accounted by the rate limit filter.
+func (s *SSETokenCounter) TokenCount() *TokenCountAndModel {
+	if s.usage != nil {
+		usage := *s.usage
+		usage.Model = s.model
+		return &usage
+	}
+	if s.frames == 0 {
+		return nil
+	}
+	return &TokenCountAndModel{
+		Completion: s.frames,
+		Total:      s.frames,
+		Model:      s.model,
+	}
+}
+
+func (s *SSETokenCounter) processLine(line []byte) {
+	text := strings.TrimSpace(string(bytes.TrimSuffix(line, []byte("\r"))))
+	if !strings.HasPrefix(text, sseDataPrefix) {
+		return
+	}
+	data := strings.TrimSpace(strings.TrimPrefix(text, sseDataPrefix))
+	if data == "" || data == sseDoneSentinel || !gjson.Valid(data) {
+		return
+	}
+	results := gjson.GetMany(data, s.promptPath, s.completionPath, s.totalPath, s.modelPath)
+	if model := results[3].String(); model != "" {
+		s.model = model
+	}
+	if !results[0].Exists() && !results[1].Exists() && !results[2].Exists() {
+		s.frames++
+		return
+	}
+	if s.usage == nil {
+		s.usage = &TokenCountAndModel{}
+	}
+	// Providers may report the prompt and completion usage in different frames.
+	if results[0].Exists() {
+		s.usage.Prompt = int(results[0].Int())
+	}
+	if results[1].Exists() {
+		s.usage.Completion = int(results[1].Int())
+	}
+	if results[2].Exists() {
+		s.usage.Total = int(results[2].Int())
+		s.totalReported = true
+	} else if !s.totalReported {
+		s.usage.Total = s.usage.Prompt + s.usage.Completion
+	}
+}
+
+func trimJSONPath(path string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+}
@@ -0,0 +1,98 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package ratelimit
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+)
+
+const openAIStream = "data: {\"model\":\"gpt-4o\",\"choices\":[{\"delta\":{\"content\":\"Hel\"}}],\"usage\":null}\n\n" +
+	"data: {\"model\":\"gpt-4o\",\"choices\":[{\"delta\":{\"content\":\"lo\"}}],\"usage\":null}\n\n" +
+	"data: {\"model\":\"gpt-4o\",\"choices\":[],\"usage\":{\"prompt_tokens\":9,\"completion_tokens\":2,\"total_tokens\":11}}\n\n" +
+	"data: [DONE]\n\n"
+
+func newOpenAICounter() *SSETokenCounter {
+	return NewSSETokenCounter("$.usage.prompt_tokens", "$.usage.completion_tokens", "$.usage.total_tokens", "")
+}
+
+func TestSSETokenCounter_UsageFrame(t *testing.T) {
+	// Split the stream at every few bytes to exercise frames spanning chunks.
+	for _, size := range []int{1, 7, 64, len(openAIStream)} {
+		counter := newOpenAICounter()
+		for i := 0; i < len(openAIStream); i += size {
+			end := min(i+size, len(openAIStream))
+			counter.Write([]byte(openAIStream[i:end]))
+		}
+		counter.Close()
+		got := counter.TokenCount()
+		want := TokenCountAndModel{Prompt: 9, Completion: 2, Total: 11, Model: "gpt-4o"}
+		if got == nil || *got != want {
+			t.Errorf("chunk size %d: expected %+v, got %+v", size, want, got)
+		}
+	}
+}
+
+func TestSSETokenCounter_CountsFramesWithoutUsage(t *testing.T) {
+	counter := newOpenAICounter()
+	counter.Write([]byte("data: {\"model\":\"llama3\",\"choices\":[{\"delta\":{\"content\":\"a\"}}]}\n\n"))
+	counter.Write([]byte(": keep-alive\n\nevent: message\ndata: {\"choices\":[{\"delta\":{\"content\":\"b\"}}]}\r\n\r\n"))
+	counter.Write([]byte("data: [DONE]"))
+	counter.Close()
+	got := counter.TokenCount()
+	want := TokenCountAndModel{Completion: 2, Total: 2, Model: "llama3"}
+	if got == nil || *got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestSSETokenCounter_UsageAcrossFrames(t *testing.T) {
+	counter := NewSSETokenCounter("$.usage.input_tokens", "$.usage.output_tokens", "$.usage.total_tokens", "$.message.model")
+	counter.Write([]byte("event: message_start\ndata: {\"message\":{\"model\":\"claude\"},\"usage\":{\"input_tokens\":12}}\n\n"))
+	counter.Write([]byte("event: content_block_delta\ndata: {\"delta\":{\"text\":\"Hi\"}}\n\n"))
+	counter.Write([]byte("event: message_delta\ndata: {\"usage\":{\"output_tokens\":5}}\n\n"))
+	got := counter.TokenCount()
+	want := TokenCountAndModel{Prompt: 12, Completion: 5, Total: 17, Model: "claude"}
+	if got == nil || *got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestSSETokenCounter_Empty(t *testing.T) {
+	counter := newOpenAICounter()
+	counter.Write([]byte("data: [DONE]\n\n"))
+	if got := counter.TokenCount(); got != nil {
+		t.Errorf("expected no token count, got %+v", got)
+	}
+}
+
+func TestIsEventStream(t *testing.T) {
+	headers := func(value string) *envoy_service_proc_v3.HttpHeaders {
+		return &envoy_service_proc_v3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: "content-type", RawValue: []byte(value)},
+		}}}
+	}
+	if !IsEventStream(headers("text/event-stream; charset=utf-8")) {
+		t.Errorf("expected an event stream")
+	}
+	if IsEventStream(headers("application/json")) || IsEventStream(nil) {
+		t.Errorf("expected no event stream")
+	}
+}
@@ -18,10 +18,14 @@
 package requestconfig
 
 import (
+	"context"
+
 	envoy_service_proc_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
 	subscription_model "github.com/wso2/apk/common-go-libs/pkg/server/model"
+	"github.com/wso2/apk/gateway/enforcer/internal/aitranslation"
 	"github.com/wso2/apk/gateway/enforcer/internal/dto"
+	"github.com/wso2/apk/gateway/enforcer/internal/ratelimit"
 )
 
 // Holder is a struct that holds the request configuration.
@@ -42,6 +46,16 @@ type Holder struct {
 	RequestAttributes               *Attributes
 	JWTAuthnPayloaClaims            map[string]interface{}
 	EnvType                         string
+	TraceContext                    context.Context
+}
+
+// Context returns the context of the span the request is being processed in, used as the parent
+// of the calls the mediation policies make.
+func (h *Holder) Context() context.Context {
+	if h.TraceContext == nil {
+		return context.Background()
+	}
+	return h.TraceContext
 }
 
 // Attributes holds the attributes related to the request configuration.
@@ -50,6 +64,9 @@ type Attributes struct {
 	RouteName string
 	// RequestID is the ID of the request.
 	RequestID string
+	// PeerCertificateDigest is the hex encoded SHA-256 hash of the client certificate of the
+	// connection, empty if the client did not present one.
+	PeerCertificateDigest string
 }
 
 // ProcessingPhase represents the phase of processing in the request configuration.
@@ -66,8 +83,18 @@ const (
 	ProcessingPhaseResponseBody ProcessingPhase = "response_body"
 )
 
-// AIConfig holds the configuration for AI model suspension.
+// AIConfig holds the per request state of the AI mediation policies.
 type AIConfig struct {
 	// SuspendModel indicates whether the AI model should be suspended.
 	SuspendModel bool
+	// StreamTokenCounter counts the tokens of an event stream response across its chunks.
+	StreamTokenCounter *ratelimit.SSETokenCounter
+	// SemanticCacheVector is the prompt embedding of a semantic cache miss, used to cache the completion.
+	SemanticCacheVector []float32
+	// SemanticCachePartition is the semantic cache partition the completion of a miss is cached in.
+	SemanticCachePartition string
+	// TargetCluster is the upstream cluster selected for the request by the AI routing policies.
+	TargetCluster string
+	// Translation is the provider translation of the request, used to translate the response back.
+	Translation *aitranslation.Exchange
 }
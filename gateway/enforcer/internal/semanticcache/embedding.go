@@ -0,0 +1,135 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package semanticcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Embedder computes the embedding of a prompt.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// HashEmbedder is an Embedder that runs in the enforcer without a model. It hashes the words and
+// word pairs of the prompt into a fixed size vector, so prompts that differ only in letter case,
+// punctuation or a few words remain close to each other.
+type HashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder creates a new instance of HashEmbedder.
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+// Embed implements Embedder.
+func (h *HashEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	vector := make([]float32, h.dimensions)
+	for i, word := range words {
+		vector[h.bucket(word)]++
+		if i > 0 {
+			vector[h.bucket(words[i-1]+" "+word)] += 0.5
+		}
+	}
+	return vector, nil
+}
+
+func (h *HashEmbedder) bucket(token string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(token))
+	return int(hash.Sum32() % uint32(h.dimensions))
+}
+
+// HTTPEmbedder is an Embedder backed by an OpenAI compatible embeddings endpoint.
+type HTTPEmbedder struct {
+	endpoint string
+	model    string
+	apiKey   string
+	client   *http.Client
+}
+
+// NewHTTPEmbedder creates a new instance of HTTPEmbedder.
+func NewHTTPEmbedder(endpoint, model, apiKey string, timeout time.Duration) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		endpoint: endpoint,
+		model:    model,
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+type embeddingRequest struct {
+	Model string `json:"model,omitempty"`
+	Input string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed implements Embedder.
+func (h *HTTPEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	payload, err := json.Marshal(embeddingRequest{Model: h.model, Input: text})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings endpoint returned status %d", resp.StatusCode)
+	}
+	var decoded embeddingResponse
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", err)
+	}
+	if len(decoded.Data) == 0 || len(decoded.Data[0].Embedding) == 0 {
+		return nil, errors.New("embeddings response has no embedding")
+	}
+	return decoded.Data[0].Embedding, nil
+}
@@ -0,0 +1,68 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package semanticcache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that keeps entries in the enforcer memory. Each partition holds at most
+// maxEntries entries and the oldest entry is evicted first.
+type MemoryStore struct {
+	maxEntries int
+	partitions map[string][]*Entry
+
+	mutex sync.RWMutex
+}
+
+// NewMemoryStore creates a new instance of MemoryStore.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		partitions: make(map[string][]*Entry),
+	}
+}
+
+// Search implements Store.
+func (m *MemoryStore) Search(_ context.Context, partition string, vector []float32, threshold float64) (*Entry, float64, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	entry, score := bestMatch(m.partitions[partition], vector, threshold, time.Now())
+	return entry, score, nil
+}
+
+// Put implements Store.
+func (m *MemoryStore) Put(_ context.Context, partition string, entry *Entry) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	entries := make([]*Entry, 0, len(m.partitions[partition])+1)
+	for _, existing := range m.partitions[partition] {
+		if now.Before(existing.ExpiresAt) {
+			entries = append(entries, existing)
+		}
+	}
+	entries = append(entries, entry)
+	if m.maxEntries > 0 && len(entries) > m.maxEntries {
+		entries = entries[len(entries)-m.maxEntries:]
+	}
+	m.partitions[partition] = entries
+	return nil
+}
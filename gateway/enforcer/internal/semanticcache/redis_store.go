@@ -0,0 +1,150 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package semanticcache
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisKeyPrefix = "wso2-apk-semantic-cache:"
+	// redisShardBits is the number of random hyperplanes that shard a partition, giving
+	// 2^redisShardBits shards.
+	redisShardBits = 4
+	redisShards    = 1 << redisShardBits
+)
+
+// redisHyperplanes holds the random hyperplanes of each vector dimension.
+var redisHyperplanes sync.Map
+
+// RedisStore is a Store that shares entries across enforcer replicas through Redis. Similarity is
+// computed in the enforcer so that a plain Redis deployment is sufficient, which is why each
+// partition is sharded by the signs of the entry vectors against a set of random hyperplanes.
+// Similar vectors fall into the same shard or into a shard whose signature differs in one bit, so a
+// search reads those redisShardBits+1 shards rather than the whole partition. Each shard is a Redis
+// list of at most maxEntries/redisShards JSON encoded entries, newest first.
+type RedisStore struct {
+	client        redis.UniversalClient
+	shardCapacity int64
+	ttl           time.Duration
+}
+
+// NewRedisStore creates a new instance of RedisStore. The ttl bounds the lifetime of a partition
+// that stops receiving entries.
+func NewRedisStore(client redis.UniversalClient, maxEntries int, ttl time.Duration) *RedisStore {
+	r := &RedisStore{
+		client: client,
+		ttl:    ttl,
+	}
+	if maxEntries > 0 {
+		r.shardCapacity = int64((maxEntries + redisShards - 1) / redisShards)
+	}
+	return r
+}
+
+// Search implements Store.
+func (r *RedisStore) Search(ctx context.Context, partition string, vector []float32, threshold float64) (*Entry, float64, error) {
+	shard := redisShard(vector)
+	pipe := r.client.Pipeline()
+	commands := make([]*redis.StringSliceCmd, 0, redisShardBits+1)
+	commands = append(commands, pipe.LRange(ctx, redisShardKey(partition, shard), 0, r.shardCapacity-1))
+	for bit := 0; bit < redisShardBits; bit++ {
+		commands = append(commands, pipe.LRange(ctx, redisShardKey(partition, shard^(1<<bit)), 0, r.shardCapacity-1))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+	var entries []*Entry
+	for _, command := range commands {
+		// Read the shards oldest first so that the newest entry wins ties, as in MemoryStore.
+		values := command.Val()
+		for i := len(values) - 1; i >= 0; i-- {
+			var entry Entry
+			if err := json.Unmarshal([]byte(values[i]), &entry); err != nil {
+				continue
+			}
+			entries = append(entries, &entry)
+		}
+	}
+	entry, score := bestMatch(entries, vector, threshold, time.Now())
+	return entry, score, nil
+}
+
+// Put implements Store.
+func (r *RedisStore) Put(ctx context.Context, partition string, entry *Entry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := redisShardKey(partition, redisShard(entry.Vector))
+	pipe := r.client.TxPipeline()
+	pipe.LPush(ctx, key, value)
+	if r.shardCapacity > 0 {
+		pipe.LTrim(ctx, key, 0, r.shardCapacity-1)
+	}
+	if r.ttl > 0 {
+		pipe.Expire(ctx, key, r.ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func redisShardKey(partition string, shard int) string {
+	return redisKeyPrefix + partition + ":" + strconv.Itoa(shard)
+}
+
+// redisShard returns the signature of the vector, a bit per hyperplane set when the vector lies on
+// its positive side.
+func redisShard(vector []float32) int {
+	shard := 0
+	for bit, plane := range redisHyperplanesFor(len(vector)) {
+		var dot float64
+		for i, v := range vector {
+			dot += float64(v) * float64(plane[i])
+		}
+		if dot >= 0 {
+			shard |= 1 << bit
+		}
+	}
+	return shard
+}
+
+// redisHyperplanesFor returns the hyperplanes of the dimension. They are generated from a seed
+// derived from the dimension, so every enforcer replica shards vectors alike.
+func redisHyperplanesFor(dimensions int) [][]float32 {
+	if planes, ok := redisHyperplanes.Load(dimensions); ok {
+		return planes.([][]float32)
+	}
+	random := rand.New(rand.NewSource(int64(dimensions)))
+	planes := make([][]float32, redisShardBits)
+	for i := range planes {
+		planes[i] = make([]float32, dimensions)
+		for j := range planes[i] {
+			planes[i][j] = float32(random.NormFloat64())
+		}
+	}
+	actual, _ := redisHyperplanes.LoadOrStore(dimensions, planes)
+	return actual.([][]float32)
+}
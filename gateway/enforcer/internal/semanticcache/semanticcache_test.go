@@ -0,0 +1,174 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package semanticcache
+
+import (
+	"context"
+	"encoding/json"
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"scaled", []float32{1, 2, 3}, []float32{2, 4, 6}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"length mismatch", []float32{1, 0}, []float32{1, 0, 0}, 0},
+		{"zero vector", []float32{0, 0}, []float32{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CosineSimilarity(tt.a, tt.b); got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("CosineSimilarity() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashEmbedder_Similarity(t *testing.T) {
+	embedder := NewHashEmbedder(512)
+	ctx := context.Background()
+	original, _ := embedder.Embed(ctx, "What is the capital city of France?")
+	similar, _ := embedder.Embed(ctx, "what is the capital city of france")
+	different, _ := embedder.Embed(ctx, "Write a poem about the ocean at night")
+
+	if score := CosineSimilarity(original, similar); score < 0.99 {
+		t.Errorf("expected prompts differing in case and punctuation to match, got %f", score)
+	}
+	if score := CosineSimilarity(original, different); score > 0.5 {
+		t.Errorf("expected unrelated prompts not to match, got %f", score)
+	}
+}
+
+func TestHTTPEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "model" || req.Input != "hello" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[0.1,0.2,0.3]}]}`))
+	}))
+	defer server.Close()
+
+	vector, err := NewHTTPEmbedder(server.URL, "model", "key", time.Second).Embed(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vector) != 3 || vector[2] != 0.3 {
+		t.Errorf("unexpected embedding: %v", vector)
+	}
+	if _, err := NewHTTPEmbedder(server.URL, "model", "wrong", time.Second).Embed(context.Background(), "hello"); err == nil {
+		t.Errorf("expected an error for a failed request")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+	expiresAt := time.Now().Add(time.Hour)
+	_ = store.Put(ctx, "route", &Entry{Vector: []float32{1, 0, 0}, Body: []byte("first"), ExpiresAt: expiresAt})
+	_ = store.Put(ctx, "route", &Entry{Vector: []float32{0, 1, 0}, Body: []byte("second"), ExpiresAt: expiresAt})
+
+	entry, score, _ := store.Search(ctx, "route", []float32{0.1, 1, 0}, 0.9)
+	if entry == nil || string(entry.Body) != "second" || score < 0.9 {
+		t.Fatalf("expected a hit on the second entry, got %v with %f", entry, score)
+	}
+	if entry, _, _ := store.Search(ctx, "other", []float32{0, 1, 0}, 0.9); entry != nil {
+		t.Errorf("expected partitions to be isolated")
+	}
+
+	// Adding a third entry evicts the oldest one.
+	_ = store.Put(ctx, "route", &Entry{Vector: []float32{0, 0, 1}, Body: []byte("third"), ExpiresAt: expiresAt})
+	if entry, _, _ := store.Search(ctx, "route", []float32{1, 0, 0}, 0.9); entry != nil {
+		t.Errorf("expected the oldest entry to be evicted")
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10)
+	_ = store.Put(ctx, "route", &Entry{Vector: []float32{1, 0}, ExpiresAt: time.Now().Add(-time.Second)})
+	if entry, _, _ := store.Search(ctx, "route", []float32{1, 0}, 0.5); entry != nil {
+		t.Errorf("expected expired entries to be ignored")
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	ctx := context.Background()
+	store := NewRedisStore(client, 2*redisShards, time.Hour)
+	embedder := NewHashEmbedder(64)
+	expiresAt := time.Now().Add(time.Hour)
+
+	prompts := []string{"What is the capital of France?", "Write a poem about the ocean", "How do I bake bread?"}
+	for _, prompt := range prompts {
+		vector, _ := embedder.Embed(ctx, prompt)
+		_ = store.Put(ctx, "route", &Entry{Vector: vector, Body: []byte(prompt), ExpiresAt: expiresAt})
+	}
+	for _, prompt := range prompts {
+		vector, _ := embedder.Embed(ctx, prompt)
+		entry, score, err := store.Search(ctx, "route", vector, 0.99)
+		if err != nil || entry == nil || string(entry.Body) != prompt || score < 0.99 {
+			t.Errorf("expected a hit on %q, got %v with %f (%v)", prompt, entry, score, err)
+		}
+	}
+	vector, _ := embedder.Embed(ctx, prompts[0])
+	if entry, _, _ := store.Search(ctx, "other", vector, 0.9); entry != nil {
+		t.Errorf("expected partitions to be isolated")
+	}
+
+	// Each shard holds at most maxEntries/shards entries, newest first.
+	for i := 0; i < 3; i++ {
+		_ = store.Put(ctx, "bounded", &Entry{Vector: vector, Body: []byte(strconv.Itoa(i)), ExpiresAt: expiresAt})
+	}
+	values, _ := server.List(redisShardKey("bounded", redisShard(vector)))
+	if len(values) != 2 {
+		t.Errorf("expected the shard to be trimmed to 2 entries, got %d", len(values))
+	}
+	if entry, _, _ := store.Search(ctx, "bounded", vector, 0.99); entry == nil || string(entry.Body) != "2" {
+		t.Errorf("expected the newest entry, got %v", entry)
+	}
+}
+
+func TestRedisShard_NeighbouringVectors(t *testing.T) {
+	embedder := NewHashEmbedder(512)
+	ctx := context.Background()
+	original, _ := embedder.Embed(ctx, "What is the capital city of France?")
+	similar, _ := embedder.Embed(ctx, "what is the capital city of france")
+	if distance := bits.OnesCount(uint(redisShard(original) ^ redisShard(similar))); distance > 1 {
+		t.Errorf("expected similar vectors to be searched together, shards differ in %d bits", distance)
+	}
+}
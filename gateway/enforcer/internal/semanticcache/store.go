@@ -0,0 +1,80 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package semanticcache provides the vector stores and embedders used to cache AI responses by
+// prompt similarity.
+package semanticcache
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Entry is a cached completion along with the embedding of the prompt that produced it.
+type Entry struct {
+	Vector          []float32 `json:"vector"`
+	Body            []byte    `json:"body"`
+	ContentType     string    `json:"contentType,omitempty"`
+	ContentEncoding string    `json:"contentEncoding,omitempty"`
+	TotalTokens     int64     `json:"totalTokens,omitempty"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+// Store is a vector store holding cached entries in isolated partitions.
+type Store interface {
+	// Search returns the entry in the partition most similar to the vector along with its cosine
+	// similarity, or nil when no entry reaches the threshold.
+	Search(ctx context.Context, partition string, vector []float32, threshold float64) (*Entry, float64, error)
+	// Put adds an entry to the partition.
+	Put(ctx context.Context, partition string, entry *Entry) error
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors, or 0 when they cannot be compared.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// bestMatch returns the unexpired entry most similar to the vector that reaches the threshold.
+func bestMatch(entries []*Entry, vector []float32, threshold float64, now time.Time) (*Entry, float64) {
+	var best *Entry
+	bestScore := threshold
+	for _, entry := range entries {
+		if entry == nil || now.After(entry.ExpiresAt) {
+			continue
+		}
+		if score := CosineSimilarity(entry.Vector, vector); score >= bestScore {
+			best, bestScore = entry, score
+		}
+	}
+	if best == nil {
+		return nil, 0
+	}
+	return best, bestScore
+}
@@ -20,113 +20,283 @@ package tokenrevocation
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/wso2/apk/gateway/enforcer/internal/config"
 	"github.com/wso2/apk/gateway/enforcer/internal/datastore"
 	"github.com/wso2/apk/gateway/enforcer/internal/util"
 )
 
 const (
-	jtiTimeStampSeperator = "_##_"
+	// streamFieldJTI and streamFieldExpiry are the fields of a revoked token entry in the stream.
+	streamFieldJTI    = "jti"
+	streamFieldExpiry = "expiry"
+	// streamFieldRule is the field of a revocation rule entry in the stream.
+	streamFieldRule = "rule"
+	// streamStart is the offset that reads the stream from its first entry.
+	streamStart = "0-0"
+
+	snapshotScanCount  = 1000
+	streamReadCount    = 500
+	streamReadBlock    = 5 * time.Second
+	maxRetryInterval   = time.Minute
+	minRetryIntervalMs = 100
 )
 
-// RevokedTokenFetcher fetches revoked tokens from the Redis datastore.
+// errSnapshotStale is returned when stream entries after the consumer offset were trimmed, so
+// the revocations they carried can only be recovered from a new snapshot.
+var errSnapshotStale = errors.New("revoked tokens stream was trimmed past the consumer offset")
+
+// RevokedTokenFetcher keeps the revoked JTI and revocation rule stores in sync with Redis.
+//
+// Each revoked token is stored in Redis under the key prefix with its expiry as the value, and
+// each revocation rule under the rule key prefix as JSON. Both are also appended to a stream. The fetcher loads a snapshot of the keys, versioned by the last stream
+// entry at the time it was taken, and then consumes the stream from that version. The offset of
+// the last consumed entry is kept across reconnects, so the entries added while Redis was
+// unreachable are replayed. If the stream was trimmed past the offset, a new snapshot is loaded.
 type RevokedTokenFetcher struct {
-	user          string
-	password      string
-	address       string
-	tlsConfig     *tls.Config
+	client        redis.UniversalClient
 	jtiDatastore  *datastore.RevokedJTIStore
+	ruleDatastore *datastore.RevocationRuleStore
 	cfg           *config.Server
-	channelName   string
+	streamName    string
+	keyPrefix     string
+	ruleKeyPrefix string
 	retryInterval time.Duration
+	readBlock     time.Duration
+	// offset is the ID of the last stream entry applied to the store. It is empty until a
+	// snapshot is loaded.
+	offset string
+	// consumed is set once the stream was read since the last failure.
+	consumed bool
 }
 
 // NewRevokedTokenFetcher creates a new instance of RevokedTokenFetcher.
-func NewRevokedTokenFetcher(cfg *config.Server, jtiDatastore *datastore.RevokedJTIStore, tlsConfig *tls.Config) *RevokedTokenFetcher {
+func NewRevokedTokenFetcher(cfg *config.Server, jtiDatastore *datastore.RevokedJTIStore,
+	ruleDatastore *datastore.RevocationRuleStore, tlsConfig *tls.Config) *RevokedTokenFetcher {
+	address := cfg.RedisHost + ":" + strconv.Itoa(cfg.RedisPort)
+	client := util.CreateRedisClient(address, cfg.RedisUsername, cfg.RedisPassword, tlsConfig)
+	return newRevokedTokenFetcher(cfg, jtiDatastore, ruleDatastore, client)
+}
+
+func newRevokedTokenFetcher(cfg *config.Server, jtiDatastore *datastore.RevokedJTIStore,
+	ruleDatastore *datastore.RevocationRuleStore, client redis.UniversalClient) *RevokedTokenFetcher {
 	return &RevokedTokenFetcher{
-		user:         cfg.RedisUsername,
-		password:     cfg.RedisPassword,
-		address:      cfg.RedisHost + ":" + strconv.Itoa(cfg.RedisPort),
-		tlsConfig:    tlsConfig,
-		jtiDatastore: jtiDatastore,
-		cfg:          cfg,
-		channelName:  cfg.RevokedTokensRedisChannel,
+		client:        client,
+		jtiDatastore:  jtiDatastore,
+		ruleDatastore: ruleDatastore,
+		cfg:           cfg,
+		streamName:    cfg.RevokedTokensRedisStream,
+		keyPrefix:     cfg.RevokedTokensRedisKeyPrefix,
+		ruleKeyPrefix: cfg.RevocationRulesRedisKeyPrefix,
+		retryInterval: time.Duration(max(cfg.RevokedTokensRetryInterval, minRetryIntervalMs)) * time.Millisecond,
+		readBlock:     streamReadBlock,
 	}
 }
 
 // Start starts the revoked token fetcher.
 func (r *RevokedTokenFetcher) Start() {
-	go r.fetchRevokedTokens()
-	go r.subscribe()
+	go r.run(context.Background())
 }
 
-// fetchRevokedTokens fetches revoked tokens from the Redis datastore.
-func (r *RevokedTokenFetcher) fetchRevokedTokens() {
-	client := util.CreateRedisClient(r.address, r.user, r.password, r.tlsConfig)
+// run loads a snapshot and consumes the stream until the context is cancelled, backing off
+// while Redis is unreachable.
+func (r *RevokedTokenFetcher) run(ctx context.Context) {
+	retryInterval := r.retryInterval
+	for ctx.Err() == nil {
+		err := r.sync(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errSnapshotStale) {
+			r.cfg.Logger.Sugar().Infof("Reloading the revoked tokens: %v", err)
+			r.offset = ""
+			continue
+		}
+		if r.consumed {
+			r.consumed = false
+			retryInterval = r.retryInterval
+		}
+		r.cfg.Logger.Error(err, "Error syncing revoked tokens, retrying", "retryInterval", retryInterval.String())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+		retryInterval = min(2*retryInterval, maxRetryInterval)
+	}
+}
+
+// sync loads a snapshot if none was loaded and consumes the stream until an error occurs.
+func (r *RevokedTokenFetcher) sync(ctx context.Context) error {
+	if r.offset == "" {
+		if err := r.loadSnapshot(ctx); err != nil {
+			return err
+		}
+	}
+	if err := r.checkOffset(ctx); err != nil {
+		return err
+	}
+	for {
+		if err := r.consume(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// loadSnapshot loads every revoked token and revocation rule stored under the key prefixes. The
+// stream position is read before the keys are scanned, so the revocations made during the scan
+// are consumed from the stream afterwards.
+func (r *RevokedTokenFetcher) loadSnapshot(ctx context.Context) error {
+	version := streamStart
+	latest, err := r.client.XRevRangeN(ctx, r.streamName, "+", "-", 1).Result()
+	if err != nil {
+		return fmt.Errorf("error reading the revoked tokens stream: %w", err)
+	}
+	if len(latest) > 0 {
+		version = latest[0].ID
+	}
+	tokens, err := r.scanKeys(ctx, r.keyPrefix, func(key string, value string) bool {
+		return r.addJTI(strings.TrimPrefix(key, r.keyPrefix), value)
+	})
+	if err != nil {
+		return err
+	}
+	rules, err := r.scanKeys(ctx, r.ruleKeyPrefix, r.addRule)
+	if err != nil {
+		return err
+	}
+	r.offset = version
+	r.cfg.Logger.Sugar().Infof("Loaded %d revoked tokens and %d revocation rules at stream version %s", tokens, rules, version)
+	return nil
+}
+
+// scanKeys passes every key under the prefix with its value to add, returning the number of keys added.
+func (r *RevokedTokenFetcher) scanKeys(ctx context.Context, prefix string, add func(key string, value string) bool) (int, error) {
+	loaded := 0
 	var cursor uint64
 	for {
-		scanResult, cursor, err := client.Scan(context.Background(), cursor, "", 0).Result()
+		keys, next, err := r.client.Scan(ctx, cursor, prefix+"*", snapshotScanCount).Result()
 		if err != nil {
-			r.cfg.Logger.Error(err, "Error fetching revoked tokens")
-			time.Sleep(r.retryInterval)
-			r.cfg.Logger.Sugar().Debug("Retrying to fetch revoked tokens")
-			r.fetchRevokedTokens()
-			return
+			return 0, fmt.Errorf("error scanning the revocations: %w", err)
 		}
-		for _, key := range scanResult {
-			value, err := client.Get(context.Background(), key).Result()
+		if len(keys) > 0 {
+			values, err := r.client.MGet(ctx, keys...).Result()
 			if err != nil {
-				r.cfg.Logger.Error(err, fmt.Sprintf("Error fetching token value for key %s", key))
-				continue
+				return 0, fmt.Errorf("error fetching the revocations: %w", err)
 			}
-			// Process the key and value as needed
-			expirationTime, err := strconv.ParseInt(value, 10, 64)
-			if err != nil {
-				r.cfg.Logger.Error(err, fmt.Sprintf("Error parsing expiration time for key %s", key))
-				continue
+			for i, value := range values {
+				// The key expires with the revocation, so it may be gone by the time it is fetched.
+				if s, ok := value.(string); ok && add(keys[i], s) {
+					loaded++
+				}
 			}
-			r.cfg.Logger.Sugar().Debug(fmt.Sprintf("Fetched revoked token: key=%s, expirationTime=%d", key, expirationTime))
-			r.jtiDatastore.AddJTI(key, time.Unix(expirationTime, 0))
 		}
+		cursor = next
 		if cursor == 0 {
-			break
+			return loaded, nil
 		}
 	}
 }
 
-// subscribe subscribes to the Redis channel for revoked tokens.
-func (r *RevokedTokenFetcher) subscribe() {
-	ctx := context.Background()
-	client := util.CreateRedisClient(r.address, r.user, r.password, r.tlsConfig)
-	subscriber := client.Subscribe(ctx, r.channelName)
-	for {
-		msg, err := subscriber.ReceiveMessage(ctx)
-		if err != nil {
-			r.cfg.Logger.Error(err, "Error receiving message from the Redis channel")
-			client.Close()
-			time.Sleep(r.retryInterval)
-			r.cfg.Logger.Sugar().Debug("Retrying to subscribe to the Redis channel")
-			r.subscribe()
-		}
-		r.cfg.Logger.Sugar().Debug(fmt.Sprintf("Received message: %s", msg.Payload))
-		parts := strings.Split(msg.Payload, jtiTimeStampSeperator)
-		if len(parts) != 2 {
-			r.cfg.Logger.Error(fmt.Errorf("invalid message format"), "Error splitting message payload")
-			continue
-		}
-		jti := parts[0]
-		expirationTime, err := strconv.ParseInt(parts[1], 10, 64)
-		if err != nil {
-			r.cfg.Logger.Error(err, fmt.Sprintf("Error parsing expiration time for JTI %s", jti))
-			continue
+// checkOffset returns errSnapshotStale if the entries after the offset may no longer be in the
+// stream. An oldest entry newer than the offset means entries up to it were trimmed, and it is
+// not known whether any of them came after the offset.
+func (r *RevokedTokenFetcher) checkOffset(ctx context.Context) error {
+	oldest, err := r.client.XRangeN(ctx, r.streamName, "-", "+", 1).Result()
+	if err != nil {
+		return fmt.Errorf("error reading the revoked tokens stream: %w", err)
+	}
+	if len(oldest) > 0 && compareStreamIDs(oldest[0].ID, r.offset) > 0 {
+		return errSnapshotStale
+	}
+	return nil
+}
+
+// consume applies the stream entries after the offset, waiting for new entries if there are none.
+func (r *RevokedTokenFetcher) consume(ctx context.Context) error {
+	streams, err := r.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{r.streamName, r.offset},
+		Count:   streamReadCount,
+		Block:   r.readBlock,
+	}).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("error reading the revoked tokens stream: %w", err)
+	}
+	r.consumed = true
+	for _, stream := range streams {
+		for _, message := range stream.Messages {
+			if rule, ok := message.Values[streamFieldRule].(string); ok {
+				r.addRule(message.ID, rule)
+			} else {
+				jti, _ := message.Values[streamFieldJTI].(string)
+				expiry, _ := message.Values[streamFieldExpiry].(string)
+				r.addJTI(jti, expiry)
+			}
+			r.offset = message.ID
 		}
-		r.cfg.Logger.Sugar().Debug(fmt.Sprintf("Received revoked token: jti=%s, expirationTime=%d", jti, expirationTime))
-		r.jtiDatastore.AddJTI(jti, time.Unix(expirationTime, 0))
 	}
+	return nil
+}
+
+// addJTI adds the revoked token to the store, reporting whether it was valid.
+func (r *RevokedTokenFetcher) addJTI(jti string, expiry string) bool {
+	if jti == "" {
+		r.cfg.Logger.Sugar().Errorf("Ignoring a revoked token without a JTI")
+		return false
+	}
+	expirationTime, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		r.cfg.Logger.Error(err, fmt.Sprintf("Error parsing expiration time for JTI %s", jti))
+		return false
+	}
+	r.cfg.Logger.Sugar().Debug(fmt.Sprintf("Revoked token: jti=%s, expirationTime=%d", jti, expirationTime))
+	r.jtiDatastore.AddJTI(jti, time.Unix(expirationTime, 0))
+	return true
+}
+
+// addRule adds the revocation rule to the store, reporting whether it was valid. The source is
+// the key or stream entry the rule was read from.
+func (r *RevokedTokenFetcher) addRule(source string, value string) bool {
+	rule, err := datastore.ParseRevocationRule(value)
+	if err != nil {
+		r.cfg.Logger.Error(err, fmt.Sprintf("Ignoring the invalid revocation rule %s", source))
+		return false
+	}
+	r.cfg.Logger.Sugar().Debugf("Revocation rule: %s=%s, issuedBefore=%d, expiry=%d", rule.Type, rule.Value, rule.IssuedBefore, rule.Expiry)
+	r.ruleDatastore.AddRule(rule)
+	return true
+}
+
+// compareStreamIDs compares two stream entry IDs of the form <milliseconds>-<sequence>.
+func compareStreamIDs(a string, b string) int {
+	aTime, aSeq := parseStreamID(a)
+	bTime, bSeq := parseStreamID(b)
+	if aTime != bTime {
+		return compareUint64(aTime, bTime)
+	}
+	return compareUint64(aSeq, bSeq)
+}
 
+func parseStreamID(id string) (uint64, uint64) {
+	timePart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(timePart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
+
+func compareUint64(a uint64, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
 }
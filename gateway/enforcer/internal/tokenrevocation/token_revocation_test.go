@@ -0,0 +1,194 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package tokenrevocation
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	"github.com/redis/go-redis/v9"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/datastore"
+	"github.com/wso2/apk/gateway/enforcer/internal/logging"
+)
+
+const (
+	testStream        = "wso2:apk:revoked_tokens"
+	testKeyPrefix     = "wso2:apk:revoked_token:"
+	testRuleKeyPrefix = "wso2:apk:revocation_rule:"
+)
+
+func newTestFetcher(t *testing.T) (*miniredis.Miniredis, *RevokedTokenFetcher, *datastore.RevokedJTIStore) {
+	server := miniredis.RunT(t)
+	cfg := &config.Server{
+		Logger:                        logging.DefaultLogger(egv1a1.LogLevelError),
+		RevokedTokensRedisStream:      testStream,
+		RevokedTokensRedisKeyPrefix:   testKeyPrefix,
+		RevocationRulesRedisKeyPrefix: testRuleKeyPrefix,
+		RevokedTokensRetryInterval:    minRetryIntervalMs,
+	}
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	store := datastore.NewRevokedJTIStore()
+	fetcher := newRevokedTokenFetcher(cfg, store, datastore.NewRevocationRuleStore(), client)
+	fetcher.readBlock = 100 * time.Millisecond
+	return server, fetcher, store
+}
+
+func revoke(t *testing.T, server *miniredis.Miniredis, jti string) {
+	t.Helper()
+	expiry := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	if err := server.Set(testKeyPrefix+jti, expiry); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.XAdd(testStream, "*", []string{streamFieldJTI, jti, streamFieldExpiry, expiry}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitForRevocation(t *testing.T, store *datastore.RevokedJTIStore, jti string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !store.IsJTIRevoked(jti) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s to be revoked", jti)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitForRule(t *testing.T, fetcher *RevokedTokenFetcher, claims map[string]interface{}) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for fetcher.ruleDatastore.MatchClaims(claims, nil) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a rule revoking %v", claims)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRevokedTokenFetcher_SnapshotAndStream(t *testing.T) {
+	server, fetcher, store := newTestFetcher(t)
+	revoke(t, server, "snapshot-jti")
+	// Keys outside the prefix belong to other components.
+	_ = server.Set("ratelimit:orders", "10")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fetcher.run(ctx)
+
+	waitForRevocation(t, store, "snapshot-jti")
+	revoke(t, server, "stream-jti")
+	waitForRevocation(t, store, "stream-jti")
+	if store.Len() != 2 || store.IsJTIRevoked("ratelimit:orders") || store.IsJTIRevoked(testKeyPrefix+"snapshot-jti") {
+		t.Errorf("expected only the revoked JTIs in the store, got %v", store.ListRevokedJTIs())
+	}
+}
+
+func TestRevokedTokenFetcher_ReplaysAfterReconnect(t *testing.T) {
+	server, fetcher, store := newTestFetcher(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fetcher.run(ctx)
+
+	revoke(t, server, "before-outage")
+	waitForRevocation(t, store, "before-outage")
+
+	// Revocations made while the enforcer cannot read from Redis, which it retries with backoff.
+	server.SetError("LOADING Redis is loading the dataset in memory")
+	revoke(t, server, "during-outage-1")
+	revoke(t, server, "during-outage-2")
+	time.Sleep(300 * time.Millisecond)
+	server.SetError("")
+	waitForRevocation(t, store, "during-outage-1")
+	waitForRevocation(t, store, "during-outage-2")
+}
+
+func TestRevokedTokenFetcher_ReloadsTrimmedStream(t *testing.T) {
+	server, fetcher, store := newTestFetcher(t)
+	if err := fetcher.loadSnapshot(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := fetcher.checkOffset(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	revoke(t, server, "trimmed-1")
+	revoke(t, server, "trimmed-2")
+	revoke(t, server, "kept")
+	if err := fetcher.client.XTrimMaxLen(context.Background(), testStream, 1).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fetcher.sync(context.Background()); !errors.Is(err, errSnapshotStale) {
+		t.Fatalf("expected the snapshot to be stale, got %v", err)
+	}
+	fetcher.offset = ""
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fetcher.run(ctx)
+	waitForRevocation(t, store, "trimmed-1")
+	waitForRevocation(t, store, "trimmed-2")
+	waitForRevocation(t, store, "kept")
+}
+
+func TestCompareStreamIDs(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1700000000000-0", "1700000000000-0", 0},
+		{"1700000000000-1", "1700000000000-0", 1},
+		{"999-5", "1000-0", -1},
+		{"0-0", "1-0", -1},
+	}
+	for _, tt := range tests {
+		if result := compareStreamIDs(tt.a, tt.b); result != tt.expected {
+			t.Errorf("compareStreamIDs(%s, %s): expected %d, got %d", tt.a, tt.b, tt.expected, result)
+		}
+	}
+}
+
+func TestRevokedTokenFetcher_RevocationRules(t *testing.T) {
+	server, fetcher, _ := newTestFetcher(t)
+	now := time.Now().Unix()
+	snapshotRule := `{"type": "sub", "value": "alice", "issuer": "https://idp.example.com", "issuedBefore": ` + strconv.FormatInt(now, 10) +
+		`, "expiry": ` + strconv.FormatInt(now+3600, 10) + `}`
+	_ = server.Set(testRuleKeyPrefix+"sub:https%3A%2F%2Fidp.example.com:alice", snapshotRule)
+	_ = server.Set(testRuleKeyPrefix+"sub:broken", "{")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fetcher.run(ctx)
+	waitForRule(t, fetcher, map[string]interface{}{"iss": "https://idp.example.com", "sub": "alice", "iat": float64(now - 60)})
+
+	streamRule := `{"type": "application", "value": "app-uuid", "issuedBefore": ` + strconv.FormatInt(now, 10) +
+		`, "expiry": ` + strconv.FormatInt(now+3600, 10) + `}`
+	if _, err := server.XAdd(testStream, "*", []string{streamFieldRule, streamRule}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRule(t, fetcher, map[string]interface{}{"sub": "bob", "iat": float64(now - 60), "application": map[string]interface{}{"uuid": "app-uuid"}})
+	if rules := fetcher.ruleDatastore.ListRules(); len(rules) != 2 {
+		t.Errorf("expected the invalid rule to be ignored, got %v", rules)
+	}
+}
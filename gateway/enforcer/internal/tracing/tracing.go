@@ -0,0 +1,190 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package tracing instruments the enforcer with OpenTelemetry. Spans continue the trace that Envoy
+// propagates in the request headers, so the enforcer shows up inside the gateway traces.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/wso2/apk/gateway/enforcer"
+
+	// ExporterOTLPGRPC exports the spans with OTLP over gRPC.
+	ExporterOTLPGRPC = "otlpgrpc"
+	// ExporterOTLPHTTP exports the spans with OTLP over HTTP.
+	ExporterOTLPHTTP = "otlphttp"
+)
+
+// Attribute keys recorded on the enforcer spans.
+const (
+	AttributePhase                 = attribute.Key("apk.processing_phase")
+	AttributeRouteName             = attribute.Key("apk.route.name")
+	AttributeRequestID             = attribute.Key("apk.request.id")
+	AttributePolicyName            = attribute.Key("apk.policy.name")
+	AttributePolicyID              = attribute.Key("apk.policy.id")
+	AttributePolicyVersion         = attribute.Key("apk.policy.version")
+	AttributeImmediateResponse     = attribute.Key("apk.result.immediate_response")
+	AttributeImmediateResponseCode = attribute.Key("apk.result.immediate_response_code")
+	AttributeStopFurtherProcessing = attribute.Key("apk.result.stop_further_processing")
+	AttributeModifyBody            = attribute.Key("apk.result.modify_body")
+	AttributeAddedHeaders          = attribute.Key("apk.result.added_headers")
+	AttributeRemovedHeaders        = attribute.Key("apk.result.removed_headers")
+	AttributePluginPath            = attribute.Key("apk.plugin.path")
+	AttributePluginSymbol          = attribute.Key("apk.plugin.symbol")
+	AttributeRatelimitDescriptor   = attribute.Key("apk.ratelimit.descriptor")
+	AttributeRatelimitSubscription = attribute.Key("apk.ratelimit.subscription")
+	AttributePromptTokens          = attribute.Key("apk.ai.prompt_tokens")
+	AttributeCompletionTokens      = attribute.Key("apk.ai.completion_tokens")
+	AttributeTotalTokens           = attribute.Key("apk.ai.total_tokens")
+	AttributeModel                 = attribute.Key("apk.ai.model")
+)
+
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Init installs the tracer provider and propagator described by the configuration. When tracing
+// is disabled the global no-op provider is kept, which makes every span free. The returned
+// function flushes the pending spans and stops the exporter.
+func Init(cfg *config.Server) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+	if !cfg.Tracing.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.Tracing.ServiceName),
+		semconv.ServiceInstanceID(cfg.InstanceIdentifier),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg.Tracing.SamplingRatio)),
+	)
+	otel.SetTracerProvider(provider)
+	cfg.Logger.Sugar().Infof("Exporting traces with %s to %s", cfg.Tracing.Exporter, cfg.Tracing.Endpoint)
+	return provider.Shutdown, nil
+}
+
+func newExporter(cfg *config.Server) (sdktrace.SpanExporter, error) {
+	ctx := context.Background()
+	switch strings.ToLower(cfg.Tracing.Exporter) {
+	case ExporterOTLPGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Tracing.Endpoint)}
+		if cfg.Tracing.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Tracing.Endpoint)}
+		if cfg.Tracing.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %q, expected %s or %s", cfg.Tracing.Exporter, ExporterOTLPGRPC, ExporterOTLPHTTP)
+	}
+}
+
+// newSampler keeps the sampling decision of the incoming trace and samples the traces started by
+// the enforcer with the given ratio.
+func newSampler(ratio float64) sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// Tracer returns the tracer of the enforcer.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span that is a child of the span in the context.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// StartClient starts a span for a call the enforcer makes to another service. No span is started
+// outside of a trace, so background work does not produce root spans.
+func StartClient(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract returns a context carrying the trace context propagated in the request headers.
+func Extract(ctx context.Context, headers *corev3.HeaderMap) context.Context {
+	return propagator.Extract(ctx, headerCarrier{headers})
+}
+
+// headerCarrier reads the propagation fields from the headers Envoy sends to the enforcer.
+type headerCarrier struct {
+	headers *corev3.HeaderMap
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, header := range c.headers.GetHeaders() {
+		if strings.EqualFold(header.GetKey(), key) {
+			if header.GetValue() != "" {
+				return header.GetValue()
+			}
+			return string(header.GetRawValue())
+		}
+	}
+	return ""
+}
+
+// Set is a no-op since the enforcer only reads the headers.
+func (c headerCarrier) Set(string, string) {}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.headers.GetHeaders()))
+	for _, header := range c.headers.GetHeaders() {
+		keys = append(keys, header.GetKey())
+	}
+	return keys
+}
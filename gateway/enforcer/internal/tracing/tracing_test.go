@@ -0,0 +1,121 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestExtract(t *testing.T) {
+	headers := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+		{Key: ":path", RawValue: []byte("/orders")},
+		{Key: "Traceparent", RawValue: []byte(testTraceParent)},
+	}}
+	spanContext := trace.SpanContextFromContext(Extract(context.Background(), headers))
+	if !spanContext.IsRemote() || spanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		spanContext.SpanID().String() != "00f067aa0ba902b7" || !spanContext.IsSampled() {
+		t.Errorf("expected the propagated trace context, got %+v", spanContext)
+	}
+
+	if spanContext := trace.SpanContextFromContext(Extract(context.Background(), &corev3.HeaderMap{})); spanContext.IsValid() {
+		t.Errorf("expected no trace context without the traceparent header, got %+v", spanContext)
+	}
+}
+
+func TestStartClient(t *testing.T) {
+	recorder := newRecorder(t)
+
+	_, span := StartClient(context.Background(), "redis get")
+	End(span, nil)
+	if len(recorder.Ended()) != 0 {
+		t.Fatal("expected no span outside of a trace")
+	}
+
+	ctx := Extract(context.Background(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+		{Key: "traceparent", RawValue: []byte(testTraceParent)},
+	}})
+	ctx, parent := Start(ctx, "ext_proc request_headers")
+	_, span = StartClient(ctx, "redis get", AttributePolicyName.String("SemanticCache"))
+	End(span, errors.New("connection refused"))
+	parent.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(ended))
+	}
+	client := ended[0]
+	if client.SpanKind() != trace.SpanKindClient || client.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected a client span under the phase span, got kind %v and parent %v", client.SpanKind(), client.Parent().SpanID())
+	}
+	if client.Status().Code != codes.Error || client.Status().Description != "connection refused" {
+		t.Errorf("expected the error recorded, got %+v", client.Status())
+	}
+	if ended[1].Parent().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the phase span to continue the incoming trace, got %v", ended[1].Parent().TraceID())
+	}
+}
+
+func TestInit(t *testing.T) {
+	cfg := &config.Server{}
+	shutdown, err := Init(cfg)
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Fatalf("expected disabled tracing to initialize, got %v", err)
+	}
+
+	cfg.Tracing.Enabled = true
+	cfg.Tracing.Exporter = "zipkin"
+	if _, err := Init(cfg); err == nil {
+		t.Error("expected an error for an unsupported exporter")
+	}
+}
+
+func TestSampler(t *testing.T) {
+	sampler := newSampler(0)
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	root := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: traceID})
+	if root.Decision != sdktrace.Drop {
+		t.Errorf("expected a root span dropped with ratio 0, got %v", root.Decision)
+	}
+	ctx := Extract(context.Background(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+		{Key: "traceparent", RawValue: []byte(testTraceParent)},
+	}})
+	child := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: ctx, TraceID: traceID})
+	if child.Decision != sdktrace.RecordAndSample {
+		t.Errorf("expected the sampled parent decision kept, got %v", child.Decision)
+	}
+}
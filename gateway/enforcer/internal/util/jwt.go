@@ -1,6 +1,8 @@
 package util
 
 import (
+	"crypto"
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
@@ -41,6 +43,36 @@ func LoadPrivateKey(filename string) (*rsa.PrivateKey, error) {
 	return rsaKey, nil
 }
 
+// LoadSigningKey reads an RSA or ECDSA private key from a PEM file, in PKCS#1, SEC 1 or PKCS#8 form.
+func LoadSigningKey(filename string) (crypto.Signer, error) {
+	keyBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	if privateKey, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("private key is not an RSA or ECDSA key")
+	}
+}
+
 // Base64Encode encodes a byte slice to a base64 string.
 func Base64Encode(input []byte) string {
 	return base64.StdEncoding.EncodeToString(input)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/wso2/apk/gateway/enforcer/internal/tracing"
 )
 
 // CreateRedisClient creates a new Redis client with the given parameters.
@@ -21,6 +22,8 @@ func CreateRedisClient(address string, user string, password string, tlsConfig *
 	if tlsConfig != nil {
 		options.TLSConfig = tlsConfig
 	}
-	return redis.NewClient(options)
+	client := redis.NewClient(options)
+	client.AddHook(tracing.RedisHook{})
+	return client
 
 }
@@ -0,0 +1,534 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package wasmmetering instruments WebAssembly modules so that the instructions they run are
+// charged against a fuel budget set by the host. Modules are stopped after a deterministic amount
+// of work, whatever the load of the host, rather than only after a wall-clock timeout.
+package wasmmetering
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// FuelGlobal is the name of the exported mutable i64 global holding the fuel left in an instance.
+// The host sets it before calling the module, which traps with unreachable once it drops below zero.
+const FuelGlobal = "apk_fuel"
+
+const (
+	sectionCustom = 0
+	sectionImport = 2
+	sectionGlobal = 6
+	sectionExport = 7
+	sectionCode   = 10
+
+	externalGlobal = 0x03
+	valueTypeI64   = 0x7e
+)
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// sectionOrder is the position of each known section in a module. Custom sections may appear anywhere.
+var sectionOrder = map[byte]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 13: 6, 6: 7, 7: 8, 8: 9, 9: 10, 12: 11, 10: 12, 11: 13}
+
+type section struct {
+	id      byte
+	payload []byte
+}
+
+// Instrument returns a copy of the module that charges fuel for the instructions it runs. A function
+// charges the instructions outside its loops when it is entered, and a loop charges the instructions
+// of its body, outside nested loops, on every iteration. Code is only run again through calls and
+// loop iterations, so the fuel charged is an upper bound of the instructions run.
+func Instrument(binary []byte) ([]byte, error) {
+	if !bytes.HasPrefix(binary, wasmHeader) {
+		return nil, errors.New("not a WebAssembly 1.0 binary module")
+	}
+	var sections []section
+	r := &reader{b: binary, pos: len(wasmHeader)}
+	for r.pos < len(r.b) {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.vec()
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", id, err)
+		}
+		sections = append(sections, section{id: id, payload: payload})
+	}
+
+	// The fuel global is appended to the globals defined by the module, so its index follows the
+	// imported and defined globals and the indices the code refers to are unchanged.
+	var fuelIndex uint32
+	for _, s := range sections {
+		var count uint32
+		var err error
+		switch s.id {
+		case sectionImport:
+			count, err = importedGlobals(s.payload)
+		case sectionGlobal:
+			count, err = (&reader{b: s.payload}).u32()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", s.id, err)
+		}
+		fuelIndex += count
+	}
+
+	fuelGlobal := []byte{valueTypeI64, 0x01, 0x42, 0x00, 0x0b} // mut i64 initialised with i64.const 0
+	fuelExport := append(appendName(nil, FuelGlobal), externalGlobal)
+	fuelExport = appendU32(fuelExport, fuelIndex)
+	var err error
+	if sections, err = appendToVector(sections, sectionGlobal, fuelGlobal, nil); err != nil {
+		return nil, err
+	}
+	if sections, err = appendToVector(sections, sectionExport, fuelExport, checkExports); err != nil {
+		return nil, err
+	}
+	for i, s := range sections {
+		if s.id != sectionCode {
+			continue
+		}
+		if sections[i].payload, err = instrumentCode(s.payload, fuelIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	out := append([]byte(nil), wasmHeader...)
+	for _, s := range sections {
+		out = append(out, s.id)
+		out = appendU32(out, uint32(len(s.payload)))
+		out = append(out, s.payload...)
+	}
+	return out, nil
+}
+
+// appendToVector appends an entry to the vector of the section, creating the section if the module
+// has none. The check, when set, validates the entries the section already has.
+func appendToVector(sections []section, id byte, entry []byte, check func([]byte, uint32) error) ([]section, error) {
+	for i, s := range sections {
+		if s.id != id {
+			continue
+		}
+		r := &reader{b: s.payload}
+		count, err := r.u32()
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", id, err)
+		}
+		if check != nil {
+			if err := check(s.payload[r.pos:], count); err != nil {
+				return nil, err
+			}
+		}
+		payload := appendU32(nil, count+1)
+		payload = append(payload, s.payload[r.pos:]...)
+		sections[i].payload = append(payload, entry...)
+		return sections, nil
+	}
+	created := section{id: id, payload: append([]byte{0x01}, entry...)}
+	for i, s := range sections {
+		if s.id != sectionCustom && sectionOrder[s.id] > sectionOrder[id] {
+			return append(sections[:i], append([]section{created}, sections[i:]...)...), nil
+		}
+	}
+	return append(sections, created), nil
+}
+
+// checkExports rejects modules that already export the fuel global name.
+func checkExports(entries []byte, count uint32) error {
+	r := &reader{b: entries}
+	for i := uint32(0); i < count; i++ {
+		name, err := r.vec()
+		if err != nil {
+			return fmt.Errorf("export section: %w", err)
+		}
+		if string(name) == FuelGlobal {
+			return fmt.Errorf("module already exports %s", FuelGlobal)
+		}
+		if err := r.skip(1); err != nil {
+			return fmt.Errorf("export section: %w", err)
+		}
+		if err := r.skipLEB(); err != nil {
+			return fmt.Errorf("export section: %w", err)
+		}
+	}
+	return nil
+}
+
+// importedGlobals counts the globals among the imports of the module.
+func importedGlobals(payload []byte) (uint32, error) {
+	r := &reader{b: payload}
+	count, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	var globals uint32
+	for i := uint32(0); i < count; i++ {
+		for j := 0; j < 2; j++ { // module and field names
+			if _, err := r.vec(); err != nil {
+				return 0, err
+			}
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case 0x00: // function type index
+			err = r.skipLEB()
+		case 0x01: // table reference type and limits
+			if err = r.skip(1); err == nil {
+				err = r.skipLimits()
+			}
+		case 0x02: // memory limits
+			err = r.skipLimits()
+		case externalGlobal: // value type and mutability
+			globals++
+			err = r.skip(2)
+		default:
+			err = fmt.Errorf("unsupported import kind %d", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return globals, nil
+}
+
+// instrumentCode instruments the function bodies of the code section.
+func instrumentCode(payload []byte, fuelIndex uint32) ([]byte, error) {
+	r := &reader{b: payload}
+	count, err := r.u32()
+	if err != nil {
+		return nil, fmt.Errorf("code section: %w", err)
+	}
+	out := appendU32(nil, count)
+	for i := uint32(0); i < count; i++ {
+		body, err := r.vec()
+		if err != nil {
+			return nil, fmt.Errorf("code section: %w", err)
+		}
+		instrumented, err := instrumentBody(body, fuelIndex)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		out = appendU32(out, uint32(len(instrumented)))
+		out = append(out, instrumented...)
+	}
+	return out, nil
+}
+
+// meteredRegion is the code charged at once: a function outside its loops or a loop body.
+type meteredRegion struct {
+	// at is the offset in the function body the charge is inserted at.
+	at   int
+	cost int64
+}
+
+// instrumentBody inserts the fuel charges at the start of the function and of each loop body.
+func instrumentBody(body []byte, fuelIndex uint32) ([]byte, error) {
+	r := &reader{b: body}
+	groups, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < groups; i++ {
+		if err := r.skipLEB(); err != nil {
+			return nil, err
+		}
+		if err := r.skip(1); err != nil {
+			return nil, err
+		}
+	}
+	regions := []meteredRegion{{at: r.pos}}
+	// blocks holds the region of each open block, starting with the function itself.
+	blocks := []int{0}
+	for len(blocks) > 0 {
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		regions[blocks[len(blocks)-1]].cost++
+		switch op {
+		case 0x02, 0x04: // block, if
+			if err := r.skipBlockType(); err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, blocks[len(blocks)-1])
+		case 0x03: // loop
+			if err := r.skipBlockType(); err != nil {
+				return nil, err
+			}
+			regions = append(regions, meteredRegion{at: r.pos})
+			blocks = append(blocks, len(regions)-1)
+		case 0x0b: // end
+			blocks = blocks[:len(blocks)-1]
+		default:
+			if err := r.skipImmediates(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if r.pos != len(body) {
+		return nil, errors.New("unexpected code after the end of the function")
+	}
+
+	out := make([]byte, 0, len(body)+len(regions)*24)
+	start := 0
+	for _, region := range regions {
+		out = append(out, body[start:region.at]...)
+		out = appendCharge(out, fuelIndex, region.cost)
+		start = region.at
+	}
+	return append(out, body[start:]...), nil
+}
+
+// appendCharge appends the code subtracting the cost from the fuel, trapping once it is exhausted.
+// The code leaves the operand stack as it found it and opens no block that existing branches cross.
+func appendCharge(out []byte, fuelIndex uint32, cost int64) []byte {
+	out = appendU32(append(out, 0x23), fuelIndex) // global.get
+	out = appendS64(append(out, 0x42), cost)      // i64.const
+	out = append(out, 0x7d)                       // i64.sub
+	out = appendU32(append(out, 0x24), fuelIndex) // global.set
+	out = appendU32(append(out, 0x23), fuelIndex) // global.get
+	out = append(out, 0x42, 0x00)                 // i64.const 0
+	out = append(out, 0x53)                       // i64.lt_s
+	return append(out, 0x04, 0x40, 0x00, 0x0b)    // if unreachable end
+}
+
+// skipImmediates skips the immediate arguments of an instruction.
+func (r *reader) skipImmediates(op byte) error {
+	switch {
+	case op == 0x00 || op == 0x01 || op == 0x05 || op == 0x0f || op == 0x1a || op == 0x1b || op == 0xd1:
+		return nil // unreachable, nop, else, return, drop, select, ref.is_null
+	case op >= 0x45 && op <= 0xc4:
+		return nil // numeric instructions
+	case op == 0x0c || op == 0x0d || op == 0x10 || op == 0xd2 || (op >= 0x20 && op <= 0x26):
+		return r.skipLEB() // br, br_if, call, ref.func, variable and table accesses
+	case op == 0x0e: // br_table
+		count, err := r.u32()
+		if err != nil {
+			return err
+		}
+		return r.skipLEBs(int(count) + 1)
+	case op == 0x11: // call_indirect
+		return r.skipLEBs(2)
+	case op == 0x1c: // select with types
+		count, err := r.u32()
+		if err != nil {
+			return err
+		}
+		return r.skip(int(count))
+	case op >= 0x28 && op <= 0x3e: // loads and stores
+		return r.skipLEBs(2)
+	case op == 0x3f || op == 0x40 || op == 0xd0: // memory.size, memory.grow, ref.null
+		return r.skip(1)
+	case op == 0x41 || op == 0x42: // i32.const, i64.const
+		return r.skipLEB()
+	case op == 0x43: // f32.const
+		return r.skip(4)
+	case op == 0x44: // f64.const
+		return r.skip(8)
+	case op == 0xfc:
+		return r.skipMiscImmediates()
+	case op == 0xfd:
+		return r.skipVectorImmediates()
+	}
+	return fmt.Errorf("unsupported instruction 0x%02x", op)
+}
+
+// skipMiscImmediates skips the immediates of the saturating truncation, bulk memory and table
+// instructions.
+func (r *reader) skipMiscImmediates() error {
+	op, err := r.u32()
+	if err != nil {
+		return err
+	}
+	switch {
+	case op <= 7: // trunc_sat
+		return nil
+	case op == 8: // memory.init
+		if err := r.skipLEB(); err != nil {
+			return err
+		}
+		return r.skip(1)
+	case op == 9 || op == 13 || (op >= 15 && op <= 17): // data.drop, elem.drop, table.grow, table.size, table.fill
+		return r.skipLEB()
+	case op == 10: // memory.copy
+		return r.skip(2)
+	case op == 11: // memory.fill
+		return r.skip(1)
+	case op == 12 || op == 14: // table.init, table.copy
+		return r.skipLEBs(2)
+	}
+	return fmt.Errorf("unsupported instruction 0xfc %d", op)
+}
+
+// skipVectorImmediates skips the immediates of the SIMD instructions.
+func (r *reader) skipVectorImmediates() error {
+	op, err := r.u32()
+	if err != nil {
+		return err
+	}
+	switch {
+	case op <= 11 || op == 92 || op == 93: // loads and stores
+		return r.skipLEBs(2)
+	case op == 12 || op == 13: // v128.const, i8x16.shuffle
+		return r.skip(16)
+	case op >= 21 && op <= 34: // extract and replace lane
+		return r.skip(1)
+	case op >= 84 && op <= 91: // lane loads and stores
+		if err := r.skipLEBs(2); err != nil {
+			return err
+		}
+		return r.skip(1)
+	case op <= 255:
+		return nil
+	}
+	return fmt.Errorf("unsupported instruction 0xfd %d", op)
+}
+
+// reader decodes the values of a WebAssembly binary.
+type reader struct {
+	b   []byte
+	pos int
+}
+
+var errUnexpectedEnd = errors.New("unexpected end of module")
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, errUnexpectedEnd
+	}
+	r.pos++
+	return r.b[r.pos-1], nil
+}
+
+func (r *reader) skip(n int) error {
+	if n < 0 || n > len(r.b)-r.pos {
+		return errUnexpectedEnd
+	}
+	r.pos += n
+	return nil
+}
+
+// u32 reads an unsigned LEB128 32-bit integer.
+func (r *reader) u32() (uint32, error) {
+	var value uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, errors.New("integer too large")
+}
+
+// skipLEB skips a LEB128 integer of up to 64 bits.
+func (r *reader) skipLEB() error {
+	for i := 0; i < 10; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			return nil
+		}
+	}
+	return errors.New("integer too large")
+}
+
+func (r *reader) skipLEBs(n int) error {
+	for i := 0; i < n; i++ {
+		if err := r.skipLEB(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vec reads a length prefixed byte vector.
+func (r *reader) vec() ([]byte, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	start := r.pos
+	if err := r.skip(int(n)); err != nil {
+		return nil, err
+	}
+	return r.b[start:r.pos], nil
+}
+
+func (r *reader) skipLimits() error {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if err := r.skipLEB(); err != nil {
+		return err
+	}
+	if flags&0x01 != 0 {
+		return r.skipLEB()
+	}
+	return nil
+}
+
+// skipBlockType skips the type of a block, which is empty, a value type or a type index.
+func (r *reader) skipBlockType() error {
+	if r.pos >= len(r.b) {
+		return errUnexpectedEnd
+	}
+	switch r.b[r.pos] {
+	case 0x40, 0x7f, 0x7e, 0x7d, 0x7c, 0x7b, 0x70, 0x6f:
+		r.pos++
+		return nil
+	}
+	return r.skipLEB()
+}
+
+func appendU32(out []byte, v uint32) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func appendS64(out []byte, v int64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func appendName(out []byte, name string) []byte {
+	return append(appendU32(out, uint32(len(name))), name...)
+}
@@ -0,0 +1,206 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package wasmmetering
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+func testSection(id byte, payload ...byte) []byte {
+	return append(appendU32([]byte{id}, uint32(len(payload))), payload...)
+}
+
+func testBody(code ...byte) []byte {
+	body := append([]byte{0x00}, code...) // no locals
+	return append(appendU32(nil, uint32(len(body))), body...)
+}
+
+// buildTestModule hand encodes a module, importing a global from importModule when set, exporting:
+//   - count(n i32): loops n times, counting down n
+//   - nested(n i32): runs count(n) in a block and an inner loop that runs once
+//   - get: returns the value of its own global, which follows the imported one
+func buildTestModule(importModule string) []byte {
+	module := append([]byte(nil), wasmHeader...)
+	// types: 0 = (i32) -> (), 1 = () -> i32
+	module = append(module, testSection(0x01, 0x02, 0x60, 0x01, 0x7f, 0x00, 0x60, 0x00, 0x01, 0x7f)...)
+	ownGlobal := byte(0)
+	if importModule != "" {
+		imports := []byte{0x01}
+		imports = appendName(imports, importModule)
+		imports = appendName(imports, "base")
+		imports = append(imports, externalGlobal, 0x7f, 0x00)
+		module = append(module, testSection(0x02, imports...)...)
+		ownGlobal = 1
+	}
+	module = append(module, testSection(0x03, 0x03, 0x00, 0x00, 0x01)...)
+	module = append(module, testSection(0x06, 0x01, 0x7f, 0x00, 0x41, 0x2a, 0x0b)...)
+	exports := []byte{0x03}
+	exports = append(appendName(exports, "count"), 0x00, 0x00)
+	exports = append(appendName(exports, "nested"), 0x00, 0x01)
+	exports = append(appendName(exports, "get"), 0x00, 0x02)
+	module = append(module, testSection(0x07, exports...)...)
+
+	code := []byte{0x03}
+	// count: loop { n = n - 1; br_if 0 (n) }
+	code = append(code, testBody(0x03, 0x40, 0x20, 0x00, 0x41, 0x01, 0x6b, 0x22, 0x00, 0x0d, 0x00, 0x0b, 0x0b)...)
+	// nested: block { call count(n) }; loop { nop }
+	code = append(code, testBody(0x02, 0x40, 0x20, 0x00, 0x10, 0x00, 0x0b, 0x03, 0x40, 0x01, 0x0b, 0x0b)...)
+	// get: global.get own
+	code = append(code, testBody(0x23, ownGlobal, 0x0b)...)
+	return append(module, testSection(0x0a, code...)...)
+}
+
+// instantiate compiles the instrumented module and returns the instance with its fuel global.
+func instantiate(t *testing.T, binary []byte) (api.Module, api.MutableGlobal) {
+	t.Helper()
+	ctx := context.Background()
+	rt := wazero.NewRuntime(ctx)
+	t.Cleanup(func() { _ = rt.Close(ctx) })
+	metered, err := Instrument(binary)
+	if err != nil {
+		t.Fatalf("failed to instrument module: %v", err)
+	}
+	instance, err := rt.Instantiate(ctx, metered)
+	if err != nil {
+		t.Fatalf("failed to instantiate instrumented module: %v", err)
+	}
+	fuel, ok := instance.ExportedGlobal(FuelGlobal).(api.MutableGlobal)
+	if !ok {
+		t.Fatalf("expected the instrumented module to export the mutable global %s", FuelGlobal)
+	}
+	return instance, fuel
+}
+
+func TestInstrument_ChargesInstructions(t *testing.T) {
+	instance, fuel := instantiate(t, buildTestModule(""))
+	tests := []struct {
+		name     string
+		function string
+		n        uint64
+		want     int64
+	}{
+		// The loop and the end of the function, then 6 instructions for each iteration.
+		{name: "one iteration", function: "count", n: 1, want: 2 + 6},
+		{name: "many iterations", function: "count", n: 1000, want: 2 + 6*1000},
+		// block, local.get, call, end, loop and end, the count call, then nop and end for the inner loop.
+		{name: "nested blocks and loops", function: "nested", n: 10, want: 6 + 2 + 6*10 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fuel.Set(1 << 40)
+			if _, err := instance.ExportedFunction(tt.function).Call(context.Background(), tt.n); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if used := int64(1<<40) - int64(fuel.Get()); used != tt.want {
+				t.Errorf("expected %d instructions to be charged, got %d", tt.want, used)
+			}
+		})
+	}
+}
+
+func TestInstrument_TrapsWhenFuelIsExhausted(t *testing.T) {
+	instance, fuel := instantiate(t, buildTestModule(""))
+	fuel.Set(2 + 6*100)
+	if _, err := instance.ExportedFunction("count").Call(context.Background(), 100); err != nil {
+		t.Fatalf("expected the call to run within its fuel, got %v", err)
+	}
+	fuel.Set(2 + 6*100)
+	_, err := instance.ExportedFunction("count").Call(context.Background(), 101)
+	if err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Fatalf("expected the module to trap once its fuel is exhausted, got %v", err)
+	}
+	if int64(fuel.Get()) >= 0 {
+		t.Errorf("expected the fuel to be exhausted, got %d", int64(fuel.Get()))
+	}
+}
+
+func TestInstrument_KeepsGlobalIndices(t *testing.T) {
+	ctx := context.Background()
+	rt := wazero.NewRuntime(ctx)
+	defer rt.Close(ctx)
+	// A module exporting the imported global, so that the instrumented module can be linked.
+	env := append([]byte(nil), wasmHeader...)
+	env = append(env, testSection(0x06, 0x01, 0x7f, 0x00, 0x41, 0x07, 0x0b)...)
+	env = append(env, testSection(0x07, append(appendName([]byte{0x01}, "base"), externalGlobal, 0x00)...)...)
+	if _, err := rt.InstantiateWithConfig(ctx, env, wazero.NewModuleConfig().WithName("env")); err != nil {
+		t.Fatalf("failed to instantiate the imported module: %v", err)
+	}
+	metered, err := Instrument(buildTestModule("env"))
+	if err != nil {
+		t.Fatalf("failed to instrument module: %v", err)
+	}
+	instance, err := rt.Instantiate(ctx, metered)
+	if err != nil {
+		t.Fatalf("failed to instantiate instrumented module: %v", err)
+	}
+	instance.ExportedGlobal(FuelGlobal).(api.MutableGlobal).Set(100)
+	res, err := instance.ExportedFunction("get").Call(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res[0] != 42 {
+		t.Errorf("expected the module to read its own global, got %d", res[0])
+	}
+}
+
+func TestInstrument_InvalidModules(t *testing.T) {
+	exportsFuel := append([]byte(nil), wasmHeader...)
+	exportsFuel = append(exportsFuel, testSection(0x06, 0x01, 0x7f, 0x00, 0x41, 0x00, 0x0b)...)
+	exportsFuel = append(exportsFuel, testSection(0x07, append(appendName([]byte{0x01}, FuelGlobal), externalGlobal, 0x00)...)...)
+	truncated := buildTestModule("")
+	truncated = truncated[:len(truncated)-3]
+	unknownInstruction := append([]byte(nil), wasmHeader...)
+	unknownInstruction = append(unknownInstruction, testSection(0x01, 0x01, 0x60, 0x00, 0x00)...)
+	unknownInstruction = append(unknownInstruction, testSection(0x03, 0x01, 0x00)...)
+	unknownInstruction = append(unknownInstruction, testSection(0x0a, append([]byte{0x01}, testBody(0x06, 0x0b)...)...)...)
+
+	tests := []struct {
+		name   string
+		binary []byte
+	}{
+		{name: "not a module", binary: []byte("not wasm")},
+		{name: "exports the fuel global", binary: exportsFuel},
+		{name: "truncated", binary: truncated},
+		{name: "unknown instruction", binary: unknownInstruction},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Instrument(tt.binary); err == nil {
+				t.Errorf("expected the module to be rejected")
+			}
+		})
+	}
+}
+
+func TestInstrument_AddsSectionsInOrder(t *testing.T) {
+	// A module without globals or exports gets both sections, before its code section.
+	module := append([]byte(nil), wasmHeader...)
+	module = append(module, testSection(0x01, 0x01, 0x60, 0x00, 0x00)...)
+	module = append(module, testSection(0x03, 0x01, 0x00)...)
+	module = append(module, testSection(0x0a, append([]byte{0x01}, testBody(0x0b)...)...)...)
+	_, fuel := instantiate(t, module)
+	fuel.Set(1)
+	if fuel.Get() != 1 {
+		t.Errorf("expected the fuel global to be settable")
+	}
+}
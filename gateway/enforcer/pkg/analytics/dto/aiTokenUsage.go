@@ -22,5 +22,7 @@ type AITokenUsage struct {
 	TotalToken      int  `json:"totalTokens"`
 	PromptToken     int  `json:"promptTokens"`
 	CompletionToken int  `json:"completionTokens"`
+	CacheHit        bool `json:"cacheHit"`
+	SavedToken      int  `json:"savedTokens"`
 	Hour            *int `json:"hour"`
 }
@@ -0,0 +1,147 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package analytics is the registry of the analytics publishers the enforcer sends events to.
+// Publishers are registered from an init function and built into an enforcer binary that runs
+// enforcer.Run, the same way as mediation policies:
+//
+//	package main
+//
+//	import (
+//		_ "example.com/analytics/splunk" // calls analytics.MustRegister in init
+//		"github.com/wso2/apk/gateway/enforcer/pkg/enforcer"
+//	)
+//
+//	func main() {
+//		enforcer.Run()
+//	}
+//
+// A registered publisher is enabled by referencing its type in the analytics publishers
+// configuration file, and receives the properties of the configured instance.
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+// Types of the publishers built into the enforcer.
+const (
+	TypeChoreo  = "choreo"
+	TypeMoesif  = "moesif"
+	TypeELK     = "elk"
+	TypeKafka   = "kafka"
+	TypeOTLP    = "otlp"
+	TypeWebhook = "webhook"
+)
+
+// builtinTypes cannot be registered, they are provided by the enforcer.
+var builtinTypes = map[string]bool{
+	TypeChoreo: true, TypeMoesif: true, TypeELK: true, TypeKafka: true, TypeOTLP: true, TypeWebhook: true,
+}
+
+// Publisher sends analytics events to a sink. Each instance runs on its own goroutine behind a
+// bounded queue, so Publish may block without holding up the gateway.
+type Publisher interface {
+	Publish(event *dto.Event)
+}
+
+// BatchPublisher is a Publisher that sends several events at once. The queue hands it batches
+// instead of single events.
+type BatchPublisher interface {
+	Publisher
+	PublishBatch(events []*dto.Event) error
+}
+
+// Closer is implemented by the publishers that hold resources to release on shutdown.
+type Closer interface {
+	Close() error
+}
+
+// Instance is the configuration of a publisher instance.
+type Instance struct {
+	// Name identifies the instance in the logs.
+	Name string
+	// Type is the registered publisher type.
+	Type string
+	// Properties are the settings of the instance, with the environment variables they
+	// reference expanded.
+	Properties map[string]string
+}
+
+// Property returns the value of the property, or the default value if it is not set.
+func (i Instance) Property(key string, defaultValue string) string {
+	if value, ok := i.Properties[key]; ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// Factory creates a publisher instance from its configuration.
+type Factory func(instance Instance) (Publisher, error)
+
+// Registration declares a publisher type that can be referenced from the publishers configuration.
+type Registration struct {
+	// Type is the publisher type referenced by the publishers configuration.
+	Type string
+	// New creates an instance of the publisher.
+	New Factory
+}
+
+var (
+	registrations   = map[string]Registration{}
+	registrationsMu sync.RWMutex
+)
+
+// Register adds a publisher type to the registry. Types must be unique and must not be one of
+// the types built into the enforcer.
+func Register(r Registration) error {
+	if r.Type == "" {
+		return errors.New("analytics publisher type is required")
+	}
+	if r.New == nil {
+		return fmt.Errorf("analytics publisher %s must declare a factory", r.Type)
+	}
+	if builtinTypes[r.Type] {
+		return fmt.Errorf("analytics publisher %s is built into the enforcer", r.Type)
+	}
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+	if _, exists := registrations[r.Type]; exists {
+		return fmt.Errorf("analytics publisher %s is already registered", r.Type)
+	}
+	registrations[r.Type] = r
+	return nil
+}
+
+// MustRegister is like Register but panics if the publisher cannot be registered.
+func MustRegister(r Registration) {
+	if err := Register(r); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the registration of the publisher type.
+func Lookup(publisherType string) (Registration, bool) {
+	registrationsMu.RLock()
+	defer registrationsMu.RUnlock()
+	r, ok := registrations[publisherType]
+	return r, ok
+}
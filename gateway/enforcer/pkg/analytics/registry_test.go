@@ -0,0 +1,58 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package analytics
+
+import (
+	"testing"
+
+	"github.com/wso2/apk/gateway/enforcer/pkg/analytics/dto"
+)
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(*dto.Event) {}
+
+func TestRegister_Validation(t *testing.T) {
+	factory := func(Instance) (Publisher, error) { return nopPublisher{}, nil }
+	if err := Register(Registration{New: factory}); err == nil {
+		t.Errorf("expected an error for a registration without a type")
+	}
+	if err := Register(Registration{Type: "TestRegisterNoFactory"}); err == nil {
+		t.Errorf("expected an error for a registration without a factory")
+	}
+	if err := Register(Registration{Type: TypeKafka, New: factory}); err == nil {
+		t.Errorf("expected an error for a built-in type")
+	}
+	MustRegister(Registration{Type: "TestRegisterDuplicate", New: factory})
+	if err := Register(Registration{Type: "TestRegisterDuplicate", New: factory}); err == nil {
+		t.Errorf("expected an error for a duplicate registration")
+	}
+	if _, ok := Lookup("TestRegisterDuplicate"); !ok {
+		t.Errorf("expected the registration to be found")
+	}
+}
+
+func TestInstance_Property(t *testing.T) {
+	instance := Instance{Properties: map[string]string{"url": "http://sink", "empty": ""}}
+	if got := instance.Property("url", "default"); got != "http://sink" {
+		t.Errorf("expected the property value, got %q", got)
+	}
+	if got := instance.Property("empty", "default"); got != "default" {
+		t.Errorf("expected the default for an empty property, got %q", got)
+	}
+}
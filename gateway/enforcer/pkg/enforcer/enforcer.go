@@ -0,0 +1,124 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package enforcer runs the enforcer. Binaries that add mediation policies to the enforcer import
+// the packages registering them with pkg/mediation and call Run.
+package enforcer
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/wso2/apk/gateway/enforcer/internal/admin"
+	"github.com/wso2/apk/gateway/enforcer/internal/analytics"
+	"github.com/wso2/apk/gateway/enforcer/internal/config"
+	"github.com/wso2/apk/gateway/enforcer/internal/datastore"
+	"github.com/wso2/apk/gateway/enforcer/internal/extproc"
+	"github.com/wso2/apk/gateway/enforcer/internal/grpc"
+	"github.com/wso2/apk/gateway/enforcer/internal/jwtbackend"
+	metrics "github.com/wso2/apk/gateway/enforcer/internal/metrics"
+	"github.com/wso2/apk/gateway/enforcer/internal/tokenrevocation"
+	"github.com/wso2/apk/gateway/enforcer/internal/tracing"
+	"github.com/wso2/apk/gateway/enforcer/internal/util"
+)
+
+// shutdownTimeout bounds the time spent flushing telemetry when the enforcer stops.
+const shutdownTimeout = 10 * time.Second
+
+// Run starts the enforcer servers and blocks until the enforcer receives SIGINT or SIGTERM.
+func Run() {
+	cfg := config.GetConfig()
+	if shutdownTracing, err := tracing.Init(cfg); err != nil {
+		cfg.Logger.Sugar().Errorf("Failed to initialize tracing: %v", err)
+	} else {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				cfg.Logger.Sugar().Errorf("Failed to flush the pending spans: %v", err)
+			}
+		}()
+	}
+	port := cfg.CommonControllerXdsPort
+	host := cfg.CommonControllerHostname
+	clientCert, err := util.LoadCertificates(cfg.EnforcerPublicKeyPath, cfg.EnforcerPrivateKeyPath)
+	if err != nil {
+		panic(err)
+	}
+
+	// Load the trusted CA certificates
+	certPool, err := util.LoadCACertificates(cfg.TrustedAdapterCertsPath)
+	if err != nil {
+		panic(err)
+	}
+
+	//Create the TLS configuration
+	tlsConfig := util.CreateTLSConfig(clientCert, certPool)
+	subAppDatastore := datastore.GetSubAppDataStore(cfg)
+	routePolicyAndMetadataDS := datastore.NewRoutePolicyAndMetadataDataStore(cfg)
+	jwtIssuerStore := datastore.NewJWTIssuerStore()
+	routePolicyAndMetadataDS.TrackJWTIssuers(jwtIssuerStore)
+	client := grpc.NewEventingGRPCClient(host, port, cfg.XdsMaxRetries, time.Duration(cfg.XdsRetryPeriod)*time.Millisecond, tlsConfig, cfg, subAppDatastore, routePolicyAndMetadataDS)
+	// Start the connection
+	client.InitiateEventingGRPCConnection()
+
+	var revokedJTIStore *datastore.RevokedJTIStore
+	var revocationRuleStore *datastore.RevocationRuleStore
+	if cfg.TokenRevocationEnabled {
+		revokedJTIStore = datastore.NewRevokedJTIStore()
+		revokedJTIStore.StartRevokedJTIStoreCleanup(time.Duration(cfg.RevokedTokenCleanupInterval) * time.Second)
+		revocationRuleStore = datastore.NewRevocationRuleStore()
+		revocationRuleStore.StartRevocationRuleStoreCleanup(time.Duration(cfg.RevokedTokenCleanupInterval) * time.Second)
+		if cfg.IsRedisTLSEnabled {
+			tokenrevocation.NewRevokedTokenFetcher(cfg, revokedJTIStore, revocationRuleStore, tlsConfig).Start()
+		} else {
+			tokenrevocation.NewRevokedTokenFetcher(cfg, revokedJTIStore, revocationRuleStore, nil).Start()
+		}
+	}
+	// Load the backend JWT signing keys before the servers that use them start
+	jwtbackend.GetKeySet()
+	// Start the external processing server
+	go extproc.StartExternalProcessingServer(cfg, subAppDatastore, routePolicyAndMetadataDS, revokedJTIStore, revocationRuleStore)
+	go jwtbackend.StartJWKSServer(cfg)
+
+	if cfg.AnalyticsEnabled {
+		// Start the access log service server, publishing the queued events on shutdown
+		publisher := analytics.NewAnalytics(cfg)
+		defer publisher.Close()
+		go grpc.StartAccessLogServiceServer(cfg, publisher)
+	}
+
+	// Start the admin API server
+	if cfg.AdminAPIEnabled {
+		go admin.NewServer(cfg, subAppDatastore, routePolicyAndMetadataDS, jwtIssuerStore, revokedJTIStore).Start()
+	}
+
+	// Start the metrics server
+	if cfg.Metrics.Enabled && strings.EqualFold(cfg.Metrics.Type, "prometheus") {
+		metrics.RegisterDataSources(subAppDatastore)
+		go metrics.StartPrometheusMetricsServer(cfg.Metrics.Port)
+	}
+	// Wait for a termination signal, then flush the telemetry with the deferred functions
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	cfg.Logger.Sugar().Infof("Received %s, shutting down the enforcer", sig)
+}
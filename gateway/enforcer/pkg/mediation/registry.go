@@ -0,0 +1,179 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+// Package mediation is the registry of the mediation policies the enforcer runs. Policies are
+// registered from an init function and built into an enforcer binary that runs enforcer.Run:
+//
+//	package main
+//
+//	import (
+//		_ "example.com/mediations/headerstamp" // calls mediation.MustRegister in init
+//		"github.com/wso2/apk/gateway/enforcer/pkg/enforcer"
+//	)
+//
+//	func main() {
+//		enforcer.Run()
+//	}
+//
+// Registered policies exchange the same ExternalInput and ExternalOutput documents as the
+// ExternalCustom and WASMCustom policies.
+package mediation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
+)
+
+// Phase is a set of ext_proc processing phases a mediation policy runs in. It is shared with the
+// control plane, which derives the processing mode of each route from the same phases.
+type Phase = commonmediation.Phase
+
+const (
+	// PhaseRequestHeaders is the request headers phase.
+	PhaseRequestHeaders = commonmediation.PhaseRequestHeaders
+	// PhaseRequestBody is the request body phase.
+	PhaseRequestBody = commonmediation.PhaseRequestBody
+	// PhaseResponseHeaders is the response headers phase.
+	PhaseResponseHeaders = commonmediation.PhaseResponseHeaders
+	// PhaseResponseBody is the response body phase.
+	PhaseResponseBody = commonmediation.PhaseResponseBody
+)
+
+// ParameterType is the type a mediation policy parameter value must parse as.
+type ParameterType = commonmediation.ParameterType
+
+const (
+	// ParameterTypeString accepts any value.
+	ParameterTypeString = commonmediation.ParameterTypeString
+	// ParameterTypeBool accepts values parsed by strconv.ParseBool.
+	ParameterTypeBool = commonmediation.ParameterTypeBool
+	// ParameterTypeInt accepts values parsed by strconv.Atoi.
+	ParameterTypeInt = commonmediation.ParameterTypeInt
+	// ParameterTypeFloat accepts values parsed by strconv.ParseFloat.
+	ParameterTypeFloat = commonmediation.ParameterTypeFloat
+	// ParameterTypeJSON accepts valid JSON documents.
+	ParameterTypeJSON = commonmediation.ParameterTypeJSON
+)
+
+// ParameterSchema describes a parameter accepted by a mediation policy.
+type ParameterSchema = commonmediation.ParameterSchema
+
+// Mediation is a mediation policy instance created for a RoutePolicy mediation.
+type Mediation interface {
+	// Process runs the policy in the phase of the input. An error fails the policy open, leaving
+	// the request unchanged.
+	Process(ctx context.Context, in *commonmediation.ExternalInput) (*commonmediation.ExternalOutput, error)
+}
+
+// Registration declares a mediation policy and how the enforcer runs it.
+type Registration struct {
+	// Name is the policy name referenced by RoutePolicy mediations.
+	Name string
+	// Phases are the processing phases the policy runs in.
+	Phases Phase
+	// Priority orders policies within a phase, lower values first in the request phases and last
+	// in the response phases, so the policy closest to the upstream sees the request last and the
+	// response first. Policies with the same priority run in the order they are listed in the
+	// RoutePolicy.
+	Priority int
+	// BufferBody asks Envoy to buffer the whole body for the body phases of the policy.
+	// Otherwise the body is streamed and the policy is called for every chunk.
+	BufferBody bool
+	// StreamEventStream marks policies that handle text/event-stream response bodies chunk by chunk.
+	// Event streams are only streamed through when every response body policy of the route does.
+	StreamEventStream bool
+	// Parameters is the parameter schema. Mediations with parameters that do not match it are
+	// rejected. Parameters missing from the schema are not validated.
+	Parameters []ParameterSchema
+	// New creates the policy for a mediation. Registrations without a factory only affect the
+	// processing modes.
+	New func(*dpv2alpha1.Mediation) (Mediation, error)
+}
+
+var (
+	registrations   = map[string]Registration{}
+	registrationsMu sync.RWMutex
+)
+
+// Register adds a mediation policy to the registry. Policy names must be unique, including the
+// names of the policies built into the enforcer.
+func Register(r Registration) error {
+	if r.Name == "" {
+		return errors.New("mediation policy name is required")
+	}
+	if r.Phases == 0 {
+		return fmt.Errorf("mediation policy %s must declare at least one phase", r.Name)
+	}
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+	if _, exists := registrations[r.Name]; exists {
+		return fmt.Errorf("mediation policy %s is already registered", r.Name)
+	}
+	registrations[r.Name] = r
+	return nil
+}
+
+// MustRegister is like Register but panics if the policy cannot be registered.
+func MustRegister(r Registration) {
+	if err := Register(r); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the registration of the named mediation policy.
+func Lookup(name string) (Registration, bool) {
+	registrationsMu.RLock()
+	defer registrationsMu.RUnlock()
+	r, ok := registrations[name]
+	return r, ok
+}
+
+// ValidateParameters validates the parameters of a mediation against the schema of its policy.
+func ValidateParameters(m *dpv2alpha1.Mediation) error {
+	r, ok := Lookup(m.PolicyName)
+	if !ok {
+		return fmt.Errorf("unknown mediation policy %s", m.PolicyName)
+	}
+	var errs []error
+	for _, schema := range r.Parameters {
+		value, ok := parameterValue(m.Parameters, schema.Key)
+		if !ok {
+			if schema.Required {
+				errs = append(errs, fmt.Errorf("parameter %s is required", schema.Key))
+			}
+			continue
+		}
+		if err := schema.Validate(value); err != nil {
+			errs = append(errs, fmt.Errorf("parameter %s: %w", schema.Key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parameterValue(params []*dpv2alpha1.Parameter, key string) (string, bool) {
+	for _, param := range params {
+		if param != nil && param.Key == key {
+			return param.Value, true
+		}
+	}
+	return "", false
+}
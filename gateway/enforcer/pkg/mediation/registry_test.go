@@ -0,0 +1,84 @@
+/*
+ *  Copyright (c) 2025, WSO2 LLC. (http://www.wso2.org) All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package mediation
+
+import (
+	"testing"
+
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+)
+
+func TestRegister_Validation(t *testing.T) {
+	if err := Register(Registration{Phases: PhaseRequestHeaders}); err == nil {
+		t.Errorf("expected an error for a registration without a name")
+	}
+	if err := Register(Registration{Name: "TestRegisterNoPhase"}); err == nil {
+		t.Errorf("expected an error for a registration without phases")
+	}
+	MustRegister(Registration{Name: "TestRegisterDuplicate", Phases: PhaseRequestBody})
+	if err := Register(Registration{Name: "TestRegisterDuplicate", Phases: PhaseRequestBody}); err == nil {
+		t.Errorf("expected an error for a duplicate registration")
+	}
+	if r, ok := Lookup("TestRegisterDuplicate"); !ok || r.Phases != PhaseRequestBody {
+		t.Errorf("expected the first registration to be kept, got %+v", r)
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	MustRegister(Registration{
+		Name:   "TestValidateParameters",
+		Phases: PhaseRequestHeaders,
+		Parameters: []ParameterSchema{
+			{Key: "name", Type: ParameterTypeString, Required: true},
+			{Key: "enabled", Type: ParameterTypeBool},
+			{Key: "limit", Type: ParameterTypeInt},
+			{Key: "threshold", Type: ParameterTypeFloat},
+			{Key: "config", Type: ParameterTypeJSON},
+		},
+	})
+	mediation := func(params map[string]string) *dpv2alpha1.Mediation {
+		m := &dpv2alpha1.Mediation{PolicyName: "TestValidateParameters"}
+		for key, value := range params {
+			m.Parameters = append(m.Parameters, &dpv2alpha1.Parameter{Key: key, Value: value})
+		}
+		return m
+	}
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr bool
+	}{
+		{"valid", map[string]string{"name": "a", "enabled": "true", "limit": "3", "threshold": "0.5", "config": `{"a":1}`}, false},
+		{"unknown parameters are ignored", map[string]string{"name": "a", "other": "x"}, false},
+		{"missing required", map[string]string{"enabled": "true"}, true},
+		{"invalid bool", map[string]string{"name": "a", "enabled": "maybe"}, true},
+		{"invalid int", map[string]string{"name": "a", "limit": "1.5"}, true},
+		{"invalid float", map[string]string{"name": "a", "threshold": "high"}, true},
+		{"invalid json", map[string]string{"name": "a", "config": "{"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateParameters(mediation(tt.params)); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateParameters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if err := ValidateParameters(&dpv2alpha1.Mediation{PolicyName: "UnknownPolicy"}); err == nil {
+		t.Errorf("expected an error for an unknown policy")
+	}
+}
@@ -140,6 +140,76 @@ Common prefix prepended to Kubernetes resources of this chart
 
 
 
+{{/*
+Analytics publishers handed to the enforcer in the publishers configuration file. These are the
+enabled publishers other than the default, moesif and elk ones, which use environment variables.
+Secret properties are replaced with references to the environment variables listed by
+kubernetes-gateway-helm.analytics.publisherSecrets, which the enforcer expands on load.
+*/}}
+{{- define "kubernetes-gateway-helm.analytics.publishers" -}}
+{{- $publishers := list -}}
+{{- $secretKeys := include "kubernetes-gateway-helm.analytics.publisherSecretKeys" . | fromYamlArray -}}
+{{- range $i, $p := .Values.wso2.kgw.dp.gatewayRuntime.analytics.publishers | default list -}}
+{{- if and $p.enabled (not (has $p.type (list "default" "moesif" "elk"))) -}}
+{{- $publisher := pick $p "name" "type" "queueSize" "batchSize" "flushIntervalMs" "filter" "redaction" -}}
+{{- $properties := dict -}}
+{{- range $key, $value := $p.properties | default dict -}}
+{{- if has $key $secretKeys -}}
+{{- $_ := set $properties $key (printf "${%s}" (include "kubernetes-gateway-helm.analytics.publisherSecretEnv" (list $i $key))) -}}
+{{- else -}}
+{{- $_ := set $properties $key $value -}}
+{{- end -}}
+{{- end -}}
+{{- if $properties -}}
+{{- $_ := set $publisher "properties" $properties -}}
+{{- end -}}
+{{- $publishers = append $publishers $publisher -}}
+{{- end -}}
+{{- end -}}
+{{- toYaml $publishers -}}
+{{- end -}}
+
+{{/*
+Publisher properties that hold credentials and are never rendered into the publishers ConfigMap.
+*/}}
+{{- define "kubernetes-gateway-helm.analytics.publisherSecretKeys" -}}
+{{- toYaml (list "saslPassword" "headers" "authToken") -}}
+{{- end -}}
+
+{{/*
+Environment variable carrying a secret property of the publisher at the given index.
+*/}}
+{{- define "kubernetes-gateway-helm.analytics.publisherSecretEnv" -}}
+{{- printf "ANALYTICS_PUBLISHER_%d_%s" (index . 0) (index . 1 | snakecase | upper) -}}
+{{- end -}}
+
+{{/*
+Secret properties of the publishers in the publishers configuration file, keyed by environment
+variable. A publisher with a secretName reads them from that Secret, using the property names as
+keys. Otherwise the values are taken from the publisher properties and stored in the chart's
+enforcer-analytics-publishers-secret.
+*/}}
+{{- define "kubernetes-gateway-helm.analytics.publisherSecrets" -}}
+{{- $secrets := dict -}}
+{{- $secretKeys := include "kubernetes-gateway-helm.analytics.publisherSecretKeys" . | fromYamlArray -}}
+{{- $chartSecret := printf "%s-enforcer-analytics-publishers-secret" (include "kubernetes-gateway-helm.resource.prefix" .) -}}
+{{- range $i, $p := .Values.wso2.kgw.dp.gatewayRuntime.analytics.publishers | default list -}}
+{{- if and $p.enabled (not (has $p.type (list "default" "moesif" "elk"))) -}}
+{{- range $key, $value := $p.properties | default dict -}}
+{{- if has $key $secretKeys -}}
+{{- $env := include "kubernetes-gateway-helm.analytics.publisherSecretEnv" (list $i $key) -}}
+{{- if $p.secretName -}}
+{{- $_ := set $secrets $env (dict "secretName" $p.secretName "key" $key) -}}
+{{- else -}}
+{{- $_ := set $secrets $env (dict "secretName" $chartSecret "key" $env "value" (toString $value)) -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- toYaml $secrets -}}
+{{- end -}}
+
 {{- define "kubernetes-gateway-helm.deployment.env" -}}
 env:
 {{- if . -}}
@@ -12,7 +12,7 @@ RUNNER_DIR="${REPO_ROOT}/plugin-runner"
 DIST_DIR="${RUNNER_DIR}/dist"
 mkdir -p "${DIST_DIR}"
 
-GO_VERSION=${GO_VERSION:-"1.22"}
+GO_VERSION=${GO_VERSION:-"1.24"}
 GO_IMAGE="golang:${GO_VERSION}-bullseye"
 
 build_arch() {
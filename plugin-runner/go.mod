@@ -1,7 +1,13 @@
 module github.com/wso2/apk/plugin-runner
 
-go 1.22
+go 1.24.6
+
+require google.golang.org/grpc v1.74.2
 
 require (
-	google.golang.org/protobuf v1.36.6 // not strictly required; placeholder if needed later
+	golang.org/x/net v0.40.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.25.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250528174236-200df99c418a // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
 )
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -8,7 +9,10 @@ import (
 	"io"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"plugin"
+	"syscall"
 	"time"
 )
 
@@ -21,11 +25,26 @@ func main() {
 	var symbol string
 	var timeoutMs int
 	var verbose bool
+	var listenAddr string
+	var reloadInterval time.Duration
+	var maxConcurrency int
+	var cacheDir string
+	var pluginDir string
+	var tlsOpts tlsOptions
 	flag.StringVar(&soPath, "so", "", "Path to the plugin .so file")
 	// Symbol is optional only when --so is also omitted. If one is provided without the other, we fail.
 	flag.StringVar(&symbol, "symbol", "", "Plugin function symbol to call (required if --so is provided)")
 	flag.IntVar(&timeoutMs, "timeout", 0, "Timeout in milliseconds (0 = no timeout)")
 	flag.BoolVar(&verbose, "verbose", false, "Enable verbose logging to stderr")
+	// Server mode: keep plugins loaded and serve calls over gRPC instead of running once per request.
+	flag.StringVar(&listenAddr, "listen", "", "Run as a long-lived gRPC server on the given address (unix:///path.sock, or host:port with mutual TLS)")
+	flag.DurationVar(&reloadInterval, "reload-interval", 5*time.Second, "Interval to check loaded plugins for changes in server mode (0 = disabled)")
+	flag.IntVar(&maxConcurrency, "max-concurrency", 64, "Maximum concurrent plugin calls in server mode (0 = unlimited)")
+	flag.StringVar(&cacheDir, "plugin-cache-dir", filepath.Join(os.TempDir(), "apk-plugin-runner"), "Directory to stage plugin versions for hot reload in server mode")
+	flag.StringVar(&pluginDir, "plugin-dir", "", "Directory plugins can be loaded from in server mode (required)")
+	flag.StringVar(&tlsOpts.certFile, "tls-cert", "", "Server certificate used when listening on TCP")
+	flag.StringVar(&tlsOpts.keyFile, "tls-key", "", "Server private key used when listening on TCP")
+	flag.StringVar(&tlsOpts.clientCAFile, "tls-client-ca", "", "CA certificates that sign the client certificates accepted on TCP")
 	flag.Parse()
 
 	// Configure logging; ensure timestamps for easier tracing.
@@ -37,6 +56,19 @@ func main() {
 		}
 	}
 
+	if listenAddr != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		svc, err := newRunnerService(openPlugin, pluginDir, cacheDir, time.Duration(timeoutMs)*time.Millisecond, maxConcurrency, vprintf)
+		if err != nil {
+			fail(2, err)
+		}
+		if err := serve(ctx, listenAddr, svc, reloadInterval, tlsOpts); err != nil {
+			fail(10, err)
+		}
+		return
+	}
+
 	vprintf("runner start: so=%s symbol=%s timeoutMs=%d pid=%d", soPath, symbol, timeoutMs, os.Getpid())
 
 	// Validate pairing of --so and --symbol. Both required together, or neither.
@@ -137,6 +169,23 @@ func main() {
 	vprintf("output write complete")
 }
 
+// openPlugin opens the plugin at path and resolves symbol to the plugin function signature.
+func openPlugin(path, symbol string) (pluginFunc, error) {
+	plg, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin: %w", err)
+	}
+	sym, err := plg.Lookup(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup symbol %q: %w", symbol, err)
+	}
+	fn, ok := sym.(func([]byte) ([]byte, error))
+	if !ok {
+		return nil, fmt.Errorf("symbol %q has incompatible type; expected func([]byte) ([]byte, error)", symbol)
+	}
+	return fn, nil
+}
+
 func fail(code int, err error) {
 	log.SetFlags(0)
 	_, _ = fmt.Fprintf(os.Stderr, "error: %v\n", err)
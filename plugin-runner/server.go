@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Server mode contract. These values must stay in sync with the enforcer client
+// (common-go-libs/pkg/mediation). The runner intentionally does not import common-go-libs
+// so that plugins can be built against this module alone.
+const (
+	serviceName   = "wso2.apk.pluginrunner.PluginRunner"
+	processMethod = "Process"
+	codecName     = "json"
+)
+
+// processRequest is the request accepted by the Process RPC.
+// Input is forwarded to the plugin as-is (ExternalInput JSON).
+type processRequest struct {
+	PluginPath string          `json:"pluginPath"`
+	Symbol     string          `json:"symbol"`
+	Input      json.RawMessage `json:"input"`
+}
+
+// processResponse is the response returned by the Process RPC.
+// Output is the plugin result (ExternalOutput JSON).
+type processResponse struct {
+	Output json.RawMessage `json:"output"`
+}
+
+// pluginFunc is the signature every plugin symbol must satisfy.
+type pluginFunc func([]byte) ([]byte, error)
+
+// pluginLoader opens a plugin file and resolves the given symbol.
+type pluginLoader func(path, symbol string) (pluginFunc, error)
+
+// jsonCodec lets gRPC carry the JSON contract without generated protobuf stubs.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return codecName }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// pluginRunnerServer is the interface gRPC uses to type-check the service implementation.
+type pluginRunnerServer interface {
+	process(ctx context.Context, req *processRequest) (*processResponse, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*pluginRunnerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: processMethod,
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := new(processRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(pluginRunnerServer).process(ctx, req)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + processMethod}
+				handler := func(ctx context.Context, req any) (any, error) {
+					return srv.(pluginRunnerServer).process(ctx, req.(*processRequest))
+				}
+				return interceptor(ctx, req, info, handler)
+			},
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pluginrunner",
+}
+
+// loadedPlugin is a resolved plugin symbol along with the file state it was loaded from.
+type loadedPlugin struct {
+	fn      pluginFunc
+	modTime time.Time
+	size    int64
+	digest  string
+}
+
+// runnerService keeps plugins loaded across calls and reloads them when the .so changes on disk.
+type runnerService struct {
+	loader         pluginLoader
+	pluginDir      string
+	cacheDir       string
+	defaultTimeout time.Duration
+	sem            chan struct{}
+	vprintf        func(format string, args ...any)
+
+	mu      sync.RWMutex
+	plugins map[pluginKey]*loadedPlugin
+	// loadMu serialises plugin loading so that concurrent first calls open a file only once.
+	loadMu sync.Mutex
+}
+
+// newRunnerService creates the service. Only plugins inside pluginDir can be loaded.
+func newRunnerService(loader pluginLoader, pluginDir, cacheDir string, defaultTimeout time.Duration, maxConcurrency int,
+	vprintf func(format string, args ...any)) (*runnerService, error) {
+	if pluginDir == "" {
+		return nil, errors.New("a plugin directory is required in server mode")
+	}
+	// Resolve symlinks once so that plugin paths can be compared against the real directory.
+	pluginDir, err := filepath.EvalSymlinks(pluginDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve plugin directory: %w", err)
+	}
+	s := &runnerService{
+		loader:         loader,
+		pluginDir:      pluginDir,
+		cacheDir:       cacheDir,
+		defaultTimeout: defaultTimeout,
+		vprintf:        vprintf,
+		plugins:        make(map[pluginKey]*loadedPlugin),
+	}
+	if maxConcurrency > 0 {
+		s.sem = make(chan struct{}, maxConcurrency)
+	}
+	return s, nil
+}
+
+// resolvePluginPath returns the real path of a requested plugin, which must be a file inside the
+// plugin directory. Relative paths are resolved against the plugin directory.
+func (s *runnerService) resolvePluginPath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.pluginDir, path)
+	}
+	path = filepath.Clean(path)
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// A removed plugin keeps serving its loaded version, so only its directory has to exist.
+		var dir string
+		if dir, err = filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+			resolved = filepath.Join(dir, filepath.Base(path))
+		}
+	}
+	if err != nil {
+		return "", status.Errorf(codes.FailedPrecondition, "failed to resolve plugin %s: %v", path, err)
+	}
+	rel, err := filepath.Rel(s.pluginDir, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", status.Errorf(codes.PermissionDenied, "plugin %s is outside the plugin directory", path)
+	}
+	return resolved, nil
+}
+
+// pluginKey identifies a loaded plugin by its resolved path and entrypoint symbol.
+type pluginKey struct {
+	path   string
+	symbol string
+}
+
+// process implements the Process RPC.
+func (s *runnerService) process(ctx context.Context, req *processRequest) (*processResponse, error) {
+	if req.PluginPath == "" || req.Symbol == "" {
+		return nil, status.Error(codes.InvalidArgument, "pluginPath and symbol are required")
+	}
+	if s.defaultTimeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.defaultTimeout)
+			defer cancel()
+		}
+	}
+	pluginPath, err := s.resolvePluginPath(req.PluginPath)
+	if err != nil {
+		return nil, err
+	}
+	// The slot is released by the plugin goroutine, not when the call returns, so plugin calls that
+	// outlive their deadline still count against the limit.
+	release := func() {}
+	if s.sem != nil {
+		select {
+		case s.sem <- struct{}{}:
+			release = func() { <-s.sem }
+		case <-ctx.Done():
+			return nil, status.Error(codes.ResourceExhausted, "timed out waiting for a free plugin slot")
+		}
+	}
+
+	p, err := s.get(pluginPath, req.Symbol)
+	if err != nil {
+		release()
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	input := []byte(req.Input)
+	if len(input) == 0 {
+		input = []byte("{}")
+	}
+	type callResult struct {
+		out []byte
+		err error
+	}
+	done := make(chan callResult, 1)
+	start := time.Now()
+	go func() {
+		defer release()
+		defer func() {
+			if r := recover(); r != nil {
+				done <- callResult{err: fmt.Errorf("plugin panicked: %v", r)}
+			}
+		}()
+		out, err := p.fn(input)
+		done <- callResult{out: out, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		s.vprintf("plugin %s#%s finished in %s (err=%v)", req.PluginPath, req.Symbol, time.Since(start), res.err)
+		if res.err != nil {
+			return nil, status.Errorf(codes.Internal, "plugin returned error: %v", res.err)
+		}
+		return &processResponse{Output: normaliseOutput(res.out)}, nil
+	case <-ctx.Done():
+		// The plugin call cannot be interrupted; it keeps running in the background holding its slot
+		// and its result is dropped.
+		s.vprintf("plugin %s#%s timed out after %s", req.PluginPath, req.Symbol, time.Since(start))
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// get returns the loaded plugin for the path and symbol, loading or reloading it when needed.
+func (s *runnerService) get(path, symbol string) (*loadedPlugin, error) {
+	key := pluginKey{path: path, symbol: symbol}
+	s.mu.RLock()
+	p := s.plugins[key]
+	s.mu.RUnlock()
+	if p != nil {
+		return p, nil
+	}
+
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	s.mu.RLock()
+	p = s.plugins[key]
+	s.mu.RUnlock()
+	if p != nil {
+		return p, nil
+	}
+	p, err := s.load(path, symbol)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.plugins[key] = p
+	s.mu.Unlock()
+	return p, nil
+}
+
+// load opens the plugin from a content addressed copy of the .so file.
+// Go keeps opened plugins resident and returns the cached instance for a path that was
+// opened before, so each version is copied to a unique path to allow hot reloading.
+func (s *runnerService) load(path, symbol string) (*loadedPlugin, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat plugin %s: %w", path, err)
+	}
+	digest, err := fileDigest(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin %s: %w", path, err)
+	}
+	openPath := path
+	if s.cacheDir != "" {
+		openPath = filepath.Join(s.cacheDir, digest+filepath.Ext(path))
+		if _, err := os.Stat(openPath); errors.Is(err, os.ErrNotExist) {
+			if err := copyFile(path, openPath); err != nil {
+				return nil, fmt.Errorf("failed to stage plugin %s: %w", path, err)
+			}
+		}
+	}
+	fn, err := s.loader(openPath, symbol)
+	if err != nil {
+		return nil, err
+	}
+	s.vprintf("plugin loaded: path=%s symbol=%s digest=%s", path, symbol, digest)
+	return &loadedPlugin{fn: fn, modTime: fi.ModTime(), size: fi.Size(), digest: digest}, nil
+}
+
+// reloadChanged reloads every loaded plugin whose file has changed on disk.
+// A failed reload keeps the previously loaded version serving traffic.
+func (s *runnerService) reloadChanged() {
+	s.mu.RLock()
+	snapshot := make(map[pluginKey]*loadedPlugin, len(s.plugins))
+	for k, v := range s.plugins {
+		snapshot[k] = v
+	}
+	s.mu.RUnlock()
+
+	for key, current := range snapshot {
+		path, symbol := key.path, key.symbol
+		fi, err := os.Stat(path)
+		if err != nil || (fi.ModTime().Equal(current.modTime) && fi.Size() == current.size) {
+			continue
+		}
+		digest, err := fileDigest(path)
+		if err != nil || digest == current.digest {
+			continue
+		}
+		s.loadMu.Lock()
+		reloaded, err := s.load(path, symbol)
+		s.loadMu.Unlock()
+		if err != nil {
+			log.Printf("plugin reload failed, keeping previous version: path=%s symbol=%s err=%v", path, symbol, err)
+			continue
+		}
+		s.mu.Lock()
+		s.plugins[key] = reloaded
+		s.mu.Unlock()
+		log.Printf("plugin reloaded: path=%s symbol=%s digest=%s", path, symbol, reloaded.digest)
+	}
+}
+
+// watch periodically checks loaded plugins for changes until ctx is cancelled.
+func (s *runnerService) watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reloadChanged()
+		}
+	}
+}
+
+// normaliseOutput applies the same output rules as the subprocess mode.
+func normaliseOutput(out []byte) []byte {
+	if len(out) == 0 {
+		return []byte("{}")
+	}
+	if !json.Valid(out) {
+		wrapped, _ := json.Marshal(map[string]string{"result": string(out)})
+		return wrapped
+	}
+	return out
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".plugin-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() { _ = os.Remove(tmpName) }()
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, dest)
+}
+
+// tlsOptions are the certificates used to serve over TCP. Clients must present a certificate signed
+// by the client CA.
+type tlsOptions struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+}
+
+// serverCredentials returns mutual TLS credentials for the server.
+func (o tlsOptions) serverCredentials() (credentials.TransportCredentials, error) {
+	if o.certFile == "" || o.keyFile == "" || o.clientCAFile == "" {
+		return nil, errors.New("a certificate, key and client CA are required to serve over TCP")
+	}
+	cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	ca, err := os.ReadFile(o.clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in client CA %s", o.clientCAFile)
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+func isUnixAddress(address string) bool {
+	return strings.HasPrefix(address, "unix://")
+}
+
+// listen creates a listener for addresses in the form unix:///path/to.sock, tcp://host:port or host:port.
+func listen(address string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(address, "unix://"); ok {
+		// Remove a stale socket left behind by a previous run.
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		lis, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		// Only the runner user and its group (the enforcer sharing the volume) may connect.
+		if err := os.Chmod(path, 0o660); err != nil {
+			_ = lis.Close()
+			return nil, err
+		}
+		return lis, nil
+	}
+	return net.Listen("tcp", strings.TrimPrefix(address, "tcp://"))
+}
+
+// serve runs the runner as a long-lived gRPC server until ctx is cancelled. Unix sockets are
+// served in plaintext, TCP addresses only with mutual TLS.
+func serve(ctx context.Context, address string, svc *runnerService, reloadInterval time.Duration, tlsOpts tlsOptions) error {
+	var opts []grpc.ServerOption
+	if !isUnixAddress(address) {
+		creds, err := tlsOpts.serverCredentials()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	lis, err := listen(address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	srv := grpc.NewServer(opts...)
+	srv.RegisterService(&serviceDesc, svc)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(serviceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthServer)
+
+	go svc.watch(ctx, reloadInterval)
+	go func() {
+		<-ctx.Done()
+		healthServer.Shutdown()
+		srv.GracefulStop()
+	}()
+	log.Printf("plugin runner serving on %s", address)
+	return srv.Serve(lis)
+}
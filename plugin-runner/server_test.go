@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubLoader returns a plugin function that echoes the contents of the plugin file it was loaded from,
+// so tests can observe which version of a plugin served a call.
+func stubLoader(loads *int32) pluginLoader {
+	return func(path, symbol string) (pluginFunc, error) {
+		atomic.AddInt32(loads, 1)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		switch symbol {
+		case "Fail":
+			return func([]byte) ([]byte, error) { return nil, errors.New("boom") }, nil
+		case "Slow":
+			return func([]byte) ([]byte, error) {
+				time.Sleep(200 * time.Millisecond)
+				return []byte(`{}`), nil
+			}, nil
+		case "Plain":
+			return func([]byte) ([]byte, error) { return []byte("not json"), nil }, nil
+		}
+		return func([]byte) ([]byte, error) { return content, nil }, nil
+	}
+}
+
+func writePlugin(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+}
+
+func newTestService(t *testing.T, pluginDir string, loads *int32, timeout time.Duration, maxConcurrency int) *runnerService {
+	t.Helper()
+	svc, err := newRunnerService(stubLoader(loads), pluginDir, t.TempDir(), timeout, maxConcurrency, func(string, ...any) {})
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	return svc
+}
+
+func TestRunnerService_ProcessCachesPlugin(t *testing.T) {
+	dir := t.TempDir()
+	so := filepath.Join(dir, "p.so")
+	writePlugin(t, so, `{"version":1}`)
+	var loads int32
+	svc := newTestService(t, dir, &loads, time.Second, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := svc.process(context.Background(), &processRequest{PluginPath: so, Symbol: "ProcessJSON"})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if string(resp.Output) != `{"version":1}` {
+				t.Errorf("unexpected output: %s", resp.Output)
+			}
+		}()
+	}
+	wg.Wait()
+	if loads != 1 {
+		t.Fatalf("expected plugin to be loaded once, got %d", loads)
+	}
+}
+
+func TestRunnerService_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	so := filepath.Join(dir, "p.so")
+	writePlugin(t, so, `{"version":1}`)
+	var loads int32
+	svc := newTestService(t, dir, &loads, time.Second, 0)
+
+	req := &processRequest{PluginPath: so, Symbol: "ProcessJSON"}
+	if _, err := svc.process(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Unchanged file must not trigger a reload
+	svc.reloadChanged()
+	if loads != 1 {
+		t.Fatalf("expected no reload for unchanged plugin, got %d loads", loads)
+	}
+
+	writePlugin(t, so, `{"version":2}`)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(so, future, future); err != nil {
+		t.Fatalf("failed to touch plugin: %v", err)
+	}
+	svc.reloadChanged()
+	resp, err := svc.process(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.Output) != `{"version":2}` {
+		t.Fatalf("expected reloaded plugin output, got %s", resp.Output)
+	}
+
+	// A failed reload keeps serving the previous version
+	if err := os.Remove(so); err != nil {
+		t.Fatalf("failed to remove plugin: %v", err)
+	}
+	svc.reloadChanged()
+	resp, err = svc.process(context.Background(), req)
+	if err != nil || string(resp.Output) != `{"version":2}` {
+		t.Fatalf("expected previous version to keep serving, got %s, %v", resp.Output, err)
+	}
+}
+
+func TestRunnerService_HotReloadPathWithHash(t *testing.T) {
+	dir := t.TempDir()
+	so := filepath.Join(dir, "release#2", "p#1.so")
+	if err := os.Mkdir(filepath.Dir(so), 0o755); err != nil {
+		t.Fatalf("failed to create plugin directory: %v", err)
+	}
+	writePlugin(t, so, `{"version":1}`)
+	var loads int32
+	svc := newTestService(t, dir, &loads, time.Second, 0)
+
+	req := &processRequest{PluginPath: so, Symbol: "ProcessJSON"}
+	if _, err := svc.process(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writePlugin(t, so, `{"version":2}`)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(so, future, future); err != nil {
+		t.Fatalf("failed to touch plugin: %v", err)
+	}
+	svc.reloadChanged()
+	resp, err := svc.process(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.Output) != `{"version":2}` || loads != 2 {
+		t.Fatalf("expected the plugin to be reloaded once, got %s after %d loads", resp.Output, loads)
+	}
+}
+
+func TestRunnerService_Errors(t *testing.T) {
+	dir := t.TempDir()
+	so := filepath.Join(dir, "p.so")
+	writePlugin(t, so, `{}`)
+
+	tests := []struct {
+		name     string
+		req      *processRequest
+		timeout  time.Duration
+		wantCode codes.Code
+	}{
+		{name: "Missing symbol", req: &processRequest{PluginPath: so}, wantCode: codes.InvalidArgument},
+		{name: "Missing plugin file", req: &processRequest{PluginPath: filepath.Join(dir, "missing.so"), Symbol: "ProcessJSON"}, wantCode: codes.FailedPrecondition},
+		{name: "Plugin error", req: &processRequest{PluginPath: so, Symbol: "Fail"}, wantCode: codes.Internal},
+		{name: "Plugin timeout", req: &processRequest{PluginPath: so, Symbol: "Slow"}, timeout: 20 * time.Millisecond, wantCode: codes.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loads int32
+			svc := newTestService(t, dir, &loads, tt.timeout, 0)
+			_, err := svc.process(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("expected code %v, got %v (%v)", tt.wantCode, status.Code(err), err)
+			}
+		})
+	}
+}
+
+func TestRunnerService_WrapsNonJSONOutput(t *testing.T) {
+	dir := t.TempDir()
+	so := filepath.Join(dir, "p.so")
+	writePlugin(t, so, `{}`)
+	var loads int32
+	svc := newTestService(t, dir, &loads, time.Second, 0)
+	resp, err := svc.process(context.Background(), &processRequest{PluginPath: so, Symbol: "Plain"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.Output) != `{"result":"not json"}` {
+		t.Fatalf("unexpected output: %s", resp.Output)
+	}
+}
+
+func TestRunnerService_ConcurrencyLimit(t *testing.T) {
+	dir := t.TempDir()
+	so := filepath.Join(dir, "p.so")
+	writePlugin(t, so, `{}`)
+	var loads int32
+	svc := newTestService(t, dir, &loads, 0, 1)
+
+	go func() {
+		_, _ = svc.process(context.Background(), &processRequest{PluginPath: so, Symbol: "Slow"})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := svc.process(ctx, &processRequest{PluginPath: so, Symbol: "ProcessJSON"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestRunnerService_RejectsPluginsOutsidePluginDir(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, filepath.Join(dir, "p.so"), `{"version":1}`)
+	outside := t.TempDir()
+	so := filepath.Join(outside, "evil.so")
+	writePlugin(t, so, `{}`)
+	if err := os.Symlink(so, filepath.Join(dir, "link.so")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	var loads int32
+	svc := newTestService(t, dir, &loads, time.Second, 0)
+
+	for _, path := range []string{so, filepath.Join(dir, "..", filepath.Base(outside), "evil.so"), "link.so", dir} {
+		_, err := svc.process(context.Background(), &processRequest{PluginPath: path, Symbol: "ProcessJSON"})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("expected PermissionDenied for %s, got %v", path, err)
+		}
+	}
+	if loads != 0 {
+		t.Fatalf("expected no plugin to be loaded, got %d loads", loads)
+	}
+
+	// Relative paths resolve against the plugin directory.
+	resp, err := svc.process(context.Background(), &processRequest{PluginPath: "p.so", Symbol: "ProcessJSON"})
+	if err != nil || string(resp.Output) != `{"version":1}` {
+		t.Fatalf("expected relative plugin path to load, got %v", err)
+	}
+}
+
+func TestRunnerService_TimedOutCallHoldsSlot(t *testing.T) {
+	dir := t.TempDir()
+	so := filepath.Join(dir, "p.so")
+	writePlugin(t, so, `{}`)
+	var loads int32
+	svc := newTestService(t, dir, &loads, 0, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := svc.process(ctx, &processRequest{PluginPath: so, Symbol: "Slow"}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	// The slow plugin is still running, so its slot is not free yet.
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := svc.process(ctx, &processRequest{PluginPath: so, Symbol: "ProcessJSON"}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted while the timed out call runs, got %v", err)
+	}
+	// The slot is freed once the plugin returns.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := svc.process(ctx, &processRequest{PluginPath: so, Symbol: "ProcessJSON"}); err != nil {
+		t.Fatalf("expected the slot to be released, got %v", err)
+	}
+}
+
+func TestNewRunnerService_RequiresPluginDir(t *testing.T) {
+	if _, err := newRunnerService(stubLoader(new(int32)), "", t.TempDir(), 0, 0, func(string, ...any) {}); err == nil {
+		t.Fatalf("expected error without a plugin directory")
+	}
+}
+
+func TestServe_RequiresTLSForTCP(t *testing.T) {
+	var loads int32
+	svc := newTestService(t, t.TempDir(), &loads, 0, 0)
+	err := serve(context.Background(), "127.0.0.1:0", svc, 0, tlsOptions{})
+	if err == nil || !strings.Contains(err.Error(), "mutual TLS") && !strings.Contains(err.Error(), "client CA") {
+		t.Fatalf("expected TCP without TLS to be refused, got %v", err)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
@@ -143,6 +144,18 @@ func (apiClient *APIClient) PrepareArtifact(apkConfiguration dto.FileData, defin
 		} else {
 			return nil, "", fmt.Errorf("invalid gRPC API definition file type provided: %s", definitionFile.FileName)
 		}
+	} else if slices.Contains(constants.ASYNC_API_TYPES, apiType) {
+		definitionFileContent := string(definitionFile.FileContent)
+		if strings.HasSuffix(definitionFile.FileName, ".yaml") || strings.HasSuffix(definitionFile.FileName, ".yml") {
+			apiDefinition, err = util.YamlToJSON(definitionFileContent)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid API definiton provided. Failed to convert YAML definition to JSON: %w", err)
+			}
+		} else if strings.HasSuffix(definitionFile.FileName, ".json") {
+			apiDefinition = definitionFileContent
+		} else {
+			return nil, "", fmt.Errorf("invalid AsyncAPI definition file type provided: %s", definitionFile.FileName)
+		}
 	}
 	return apkConf, apiDefinition, nil
 }
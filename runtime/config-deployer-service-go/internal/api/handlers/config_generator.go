@@ -31,6 +31,7 @@ import (
 	// "github.com/lestrrat-go/jwx/v2/jwk"
 	// "github.com/wso2/apk/common-go-libs/loggers"
 	"github.com/wso2/apk/config-deployer-service-go/internal/constants"
+	"github.com/wso2/apk/config-deployer-service-go/internal/crbuilder"
 	"github.com/wso2/apk/config-deployer-service-go/internal/dto"
 )
 
@@ -239,6 +240,13 @@ func GetGeneratedK8sResources(cxt *gin.Context, organization *dto.Organization,
 		})
 		return
 	}
+	if err := crbuilder.ValidateRoutePolicies(apiArtifact.K8sArtifacts); err != nil {
+		cxt.JSON(http.StatusBadRequest, gin.H{
+			"code":    90091,
+			"message": "Error while validating the mediation policies: " + err.Error(),
+		})
+		return
+	}
 
 	zipName, err := apiClient.ZipAPIArtifact(apiArtifact)
 	if err != nil {
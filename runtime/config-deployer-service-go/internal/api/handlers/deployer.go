@@ -23,6 +23,7 @@ import (
 	"github.com/gin-gonic/gin"
 	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	"github.com/wso2/apk/config-deployer-service-go/internal/config"
+	"github.com/wso2/apk/config-deployer-service-go/internal/crbuilder"
 	"github.com/wso2/apk/config-deployer-service-go/internal/dto"
 	"github.com/wso2/apk/config-deployer-service-go/internal/util"
 	"io"
@@ -66,6 +67,13 @@ func HandleAPIDeployment(cxt *gin.Context, organization *dto.Organization, cpIni
 		})
 		return
 	}
+	if err := crbuilder.ValidateRoutePolicies(apiArtifact.K8sArtifacts); err != nil {
+		cxt.JSON(http.StatusBadRequest, gin.H{
+			"code":    90091,
+			"message": "Error while validating the mediation policies: " + err.Error(),
+		})
+		return
+	}
 	k8sClient := config.GetManager().GetClient()
 	routeMetadata, err := apiClient.DeployAPIToK8s(apiArtifact, namespace, k8sClient)
 	if err != nil {
@@ -30,6 +30,25 @@ import (
 	// "github.com/wso2/apk/gateway/enforcer/internal/util"
 )
 
+// artifactOperations are the operations that guard the endpoints of the config deployer API
+type artifactOperations struct {
+	generateConfiguration util.Operation
+	generateK8sResources  util.Operation
+	deploy                util.Operation
+	undeploy              util.Operation
+}
+
+// newArtifactOperations maps the endpoints of the config deployer API to the configured scopes.
+// Generating an APK configuration only reads the API definition, so it needs the read scope.
+func newArtifactOperations(cfg *config.Server) artifactOperations {
+	return artifactOperations{
+		generateConfiguration: util.Operation{Name: "read", Scope: cfg.ReadScope},
+		generateK8sResources:  util.Operation{Name: "generate", Scope: cfg.GenerateScope, Namespaced: true},
+		deploy:                util.Operation{Name: "deploy", Scope: cfg.DeployScope, Namespaced: true},
+		undeploy:              util.Operation{Name: "undeploy", Scope: cfg.UndeployScope, Namespaced: true},
+	}
+}
+
 // StartArtifactGeneratorServer sets up and starts the HTTP server for artifact generation APIs.
 func StartArtifactGeneratorServer(cfg *config.Server) {
 	r := gin.Default()
@@ -42,26 +61,31 @@ func StartArtifactGeneratorServer(cfg *config.Server) {
 		c.JSON(http.StatusOK, status)
 	})
 
+	authenticator, err := util.NewAuthenticator(cfg)
+	if err != nil {
+		cfg.Logger.Error(err, "Failed to initialize authentication of the config deployer API. Set AUTH_ENABLED and JWKS_URL consistently")
+		os.Exit(1)
+	}
+	operations := newArtifactOperations(cfg)
+
 	artifactGeneratorApi := r.Group("/api/configurator")
 	{
 		// Create API configuration file from api specification.
-		artifactGeneratorApi.POST("/apis/generate-configuration", func(c *gin.Context) {
+		artifactGeneratorApi.POST("/apis/generate-configuration", authenticator.Authorize(operations.generateConfiguration), func(c *gin.Context) {
 			handlers.GetGeneratedAPKConf(c)
 		})
 
 		// Generate K8s Resources
-		artifactGeneratorApi.POST("/apis/generate-k8s-resources", func(c *gin.Context) {
-			organization := c.Query("organization")
-			if organization == "" {
-				organization = config.GetConfig().DefaultOrganization
+		artifactGeneratorApi.POST("/apis/generate-k8s-resources", authenticator.Authorize(operations.generateK8sResources), func(c *gin.Context) {
+			organizationObj, ok := authenticatedOrganization(c)
+			if !ok {
+				return
 			}
 			cpInitiated := c.Query("cpInitiated")
 			if cpInitiated == "" {
 				cpInitiated = "false"
 			}
 			namespace := util.GetNamespace(c)
-			organizationObj := dto.NewOrganization("", organization, "default",
-				"default", true)
 			handlers.GetGeneratedK8sResources(c, organizationObj, cpInitiated, namespace)
 		})
 	}
@@ -69,41 +93,21 @@ func StartArtifactGeneratorServer(cfg *config.Server) {
 	artifactDeployerApi := r.Group("/api/deployer")
 	{
 		// Create and deploy API from api specification and apk configuration.
-		artifactDeployerApi.POST("/apis/deploy", func(c *gin.Context) {
-			//authenticatedUserContext, err := util.GetAuthenticatedUserContext(c)
-			//if err != nil {
-			//	c.JSON(http.StatusUnauthorized, gin.H{
-			//		"code":    900905,
-			//		"message": "Invalid Credentials",
-			//	})
-			//}
-			//organizationObj := authenticatedUserContext.Organization
-			organization := c.Query("organization")
-			if organization == "" {
-				organization = config.GetConfig().DefaultOrganization
+		artifactDeployerApi.POST("/apis/deploy", authenticator.Authorize(operations.deploy), func(c *gin.Context) {
+			organizationObj, ok := authenticatedOrganization(c)
+			if !ok {
+				return
 			}
-			organizationObj := dto.NewOrganization("", organization, "default",
-				"default", true)
 			namespace := util.GetNamespace(c)
 			handlers.HandleAPIDeployment(c, organizationObj, "false", namespace)
 		})
 
 		// Undeploy API and remove K8s resources.
-		artifactDeployerApi.POST("/apis/undeploy", func(c *gin.Context) {
-			//authenticatedUserContext, err := util.GetAuthenticatedUserContext(c)
-			//if err != nil {
-			//	c.JSON(http.StatusUnauthorized, gin.H{
-			//		"code":    900905,
-			//		"message": "Invalid Credentials",
-			//	})
-			//}
-			//organizationObj := authenticatedUserContext.Organization
-			organization := c.Query("organization")
-			if organization == "" {
-				organization = config.GetConfig().DefaultOrganization
+		artifactDeployerApi.POST("/apis/undeploy", authenticator.Authorize(operations.undeploy), func(c *gin.Context) {
+			organizationObj, ok := authenticatedOrganization(c)
+			if !ok {
+				return
 			}
-			organizationObj := dto.NewOrganization("", organization, "default",
-				"default", true)
 			namespace := util.GetNamespace(c)
 			apiId := c.Query("apiId")
 			handlers.HandleAPIUndeployment(c, apiId, organizationObj, namespace)
@@ -131,3 +135,17 @@ func StartArtifactGeneratorServer(cfg *config.Server) {
 	//	panic("Failed to start API server: " + err.Error())
 	//}
 }
+
+// authenticatedOrganization returns the organization of the authenticated user, responding with
+// an error when the request is not authenticated.
+func authenticatedOrganization(c *gin.Context) (*dto.Organization, bool) {
+	authenticatedUserContext, err := util.GetAuthenticatedUserContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    900905,
+			"message": "Invalid Credentials",
+		})
+		return nil, false
+	}
+	return authenticatedUserContext.Organization, true
+}
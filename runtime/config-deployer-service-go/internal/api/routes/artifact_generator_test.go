@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package routes
+
+import (
+	"testing"
+
+	"github.com/wso2/apk/config-deployer-service-go/internal/config"
+	"github.com/wso2/apk/config-deployer-service-go/internal/util"
+)
+
+func TestNewArtifactOperations(t *testing.T) {
+	operations := newArtifactOperations(&config.Server{
+		ReadScope:     "apk:api_view",
+		GenerateScope: "apk:api_generate",
+		DeployScope:   "apk:api_create",
+		UndeployScope: "apk:api_delete",
+	})
+	tests := []struct {
+		endpoint       string
+		operation      util.Operation
+		wantScope      string
+		wantNamespaced bool
+	}{
+		{endpoint: "/api/configurator/apis/generate-configuration", operation: operations.generateConfiguration,
+			wantScope: "apk:api_view"},
+		{endpoint: "/api/configurator/apis/generate-k8s-resources", operation: operations.generateK8sResources,
+			wantScope: "apk:api_generate", wantNamespaced: true},
+		{endpoint: "/api/deployer/apis/deploy", operation: operations.deploy,
+			wantScope: "apk:api_create", wantNamespaced: true},
+		{endpoint: "/api/deployer/apis/undeploy", operation: operations.undeploy,
+			wantScope: "apk:api_delete", wantNamespaced: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			if tt.operation.Scope != tt.wantScope || tt.operation.Namespaced != tt.wantNamespaced {
+				t.Errorf("expected scope %q (namespaced %v), got %q (namespaced %v)", tt.wantScope, tt.wantNamespaced,
+					tt.operation.Scope, tt.operation.Namespaced)
+			}
+		})
+	}
+}
@@ -41,6 +41,20 @@ type Server struct {
 	K8sReleaseName           string `envconfig:"K8S_RELEASE_NAME" default:"kgw"`
 	K8sResourcePrefix        string `envconfig:"K8S_RESOURCE_PREFIX" default:"wso2-kgw"`
 	ConfigDSServerPort       string `envconfig:"CONFIG_DS_SERVER_PORT" default:"9443"`
+	AuthEnabled              bool   `envconfig:"AUTH_ENABLED" default:"true"`
+	JWKSURL                  string `envconfig:"JWKS_URL" default:""`
+	JWKSCACertPath           string `envconfig:"JWKS_CA_CERT_PATH" default:""`
+	JWKSRefreshInterval      int    `envconfig:"JWKS_REFRESH_INTERVAL" default:"300"`
+	TokenIssuer              string `envconfig:"TOKEN_ISSUER" default:""`
+	TokenAudience            string `envconfig:"TOKEN_AUDIENCE" default:""`
+	TokenClockSkew           int    `envconfig:"TOKEN_CLOCK_SKEW" default:"30"`
+	OrganizationClaim        string `envconfig:"ORGANIZATION_CLAIM" default:"organization"`
+	ScopeClaim               string `envconfig:"SCOPE_CLAIM" default:"scope"`
+	OrganizationPoliciesPath string `envconfig:"ORGANIZATION_POLICIES_PATH" default:""`
+	ReadScope                string `envconfig:"READ_SCOPE" default:"apk:api_view"`
+	GenerateScope            string `envconfig:"GENERATE_SCOPE" default:"apk:api_generate"`
+	DeployScope              string `envconfig:"DEPLOY_SCOPE" default:"apk:api_create"`
+	UndeployScope            string `envconfig:"UNDEPLOY_SCOPE" default:"apk:api_create"`
 }
 
 type metrics struct {
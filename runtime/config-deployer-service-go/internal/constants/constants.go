@@ -34,6 +34,17 @@ var ALLOWED_API_TYPES = []string{
 	API_TYPE_REST,
 	API_TYPE_GRAPHQL,
 	API_TYPE_GRPC,
+	API_TYPE_WS,
+	API_TYPE_SSE,
+	API_TYPE_WEBSUB,
+}
+
+// ASYNC_API_TYPES is a list of API types that are defined using an AsyncAPI definition.
+var ASYNC_API_TYPES = []string{
+	API_TYPE_ASYNC,
+	API_TYPE_WS,
+	API_TYPE_SSE,
+	API_TYPE_WEBSUB,
 }
 
 const (
@@ -50,6 +61,17 @@ const (
 	GRAPHQL_SUBSCRIPTION = "SUBSCRIPTION"
 )
 
+// AsyncAPI related constants.
+const (
+	ASYNCAPI_VERSION_2_PREFIX = "2."
+	ASYNCAPI_VERSION_3_PREFIX = "3."
+	ASYNCAPI_ACTION_SEND      = "send"
+	ASYNCAPI_ACTION_RECEIVE   = "receive"
+	ASYNC_VERB_SUBSCRIBE      = "SUBSCRIBE"
+	ASYNC_VERB_PUBLISH        = "PUBLISH"
+	WEBSOCKET_UPGRADE_TYPE    = "websocket"
+)
+
 // SupportedMethods Supported HTTP methods
 var SupportedMethods = map[string]bool{
 	"get":     true,
@@ -18,6 +18,7 @@ import (
 	constantscommon "github.com/wso2/apk/common-go-libs/constants"
 	gqlCommon "github.com/wso2/apk/common-go-libs/graphql"
 	"github.com/wso2/apk/common-go-libs/pkg/logging"
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
 	utilscommon "github.com/wso2/apk/common-go-libs/utils"
 	"github.com/wso2/apk/config-deployer-service-go/internal/config"
 	"github.com/wso2/apk/config-deployer-service-go/internal/constants"
@@ -127,6 +128,16 @@ func safeHTTPMethod(verb *string) *gatewayv1.HTTPMethod {
 	return &fallback
 }
 
+// asyncHTTPMethod returns the HTTP method used by clients to connect to a channel of an async API.
+func asyncHTTPMethod(apiType string) *gatewayv1.HTTPMethod {
+	if apiType == constants.API_TYPE_WEBSUB {
+		// WebSub subscription requests and content distribution are delivered as POST requests
+		return ptrTo(gatewayv1.HTTPMethodPost)
+	}
+	// WebSocket handshakes and SSE streams are initiated with GET requests
+	return ptrTo(gatewayv1.HTTPMethodGet)
+}
+
 func safeGRPCMethod(verb *string, service *string) *gatewayv1.GRPCMethodMatch {
 	if verb == nil {
 		return nil
@@ -267,6 +278,65 @@ func createRouteMetadataList(apiResourceBundle *dto.APIResourceBundle, environme
 	return routeMetadataList
 }
 
+// addRouteMetadataOperations records the matches of a route rule and the RoutePolicies attached to
+// it on the route metadata, so that the enforcer can tell the policies of a request.
+func addRouteMetadataOperations(routeMetadataList []*dpv2alpha1.RouteMetadata, hostnames []gatewayv1.Hostname,
+	rule gatewayv1.HTTPRouteRule) {
+	routePolicies := make([]string, 0)
+	for _, filter := range rule.Filters {
+		if filter.Type == gatewayv1.HTTPRouteFilterExtensionRef && filter.ExtensionRef != nil &&
+			filter.ExtensionRef.Kind == constantscommon.KindRoutePolicy {
+			routePolicies = append(routePolicies, string(filter.ExtensionRef.Name))
+		}
+	}
+	hosts := make([]string, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		hosts = append(hosts, string(hostname))
+	}
+	for _, metadata := range routeMetadataList {
+		addRouteMetadataHostnames(metadata, hostnames)
+		for _, match := range rule.Matches {
+			operation := dpv2alpha1.Operation{Hostnames: hosts, RoutePolicies: routePolicies}
+			if match.Method != nil {
+				operation.Method = string(*match.Method)
+			}
+			if match.Path != nil {
+				if match.Path.Value != nil {
+					operation.Path = *match.Path.Value
+				}
+				if match.Path.Type != nil {
+					operation.PathMatchType = string(*match.Path.Type)
+				}
+			}
+			metadata.Spec.Operations = append(metadata.Spec.Operations, operation)
+		}
+	}
+}
+
+// addRouteMetadataHostnames adds the hostnames of a route to the route metadata.
+func addRouteMetadataHostnames(metadata *dpv2alpha1.RouteMetadata, hostnames []gatewayv1.Hostname) {
+	for _, hostname := range hostnames {
+		if !slices.Contains(metadata.Spec.Hostnames, string(hostname)) {
+			metadata.Spec.Hostnames = append(metadata.Spec.Hostnames, string(hostname))
+		}
+	}
+}
+
+// addRouteMetadataJWTIssuers adds the JWT providers of a SecurityPolicy to the route metadata.
+func addRouteMetadataJWTIssuers(routeMetadataList []*dpv2alpha1.RouteMetadata, sp *eg.SecurityPolicy) {
+	if sp == nil || sp.Spec.JWT == nil {
+		return
+	}
+	for _, metadata := range routeMetadataList {
+		for _, provider := range sp.Spec.JWT.Providers {
+			issuer := dpv2alpha1.JWTIssuer{Name: provider.Name, Issuer: provider.Issuer}
+			if !slices.Contains(metadata.Spec.JWTIssuers, issuer) {
+				metadata.Spec.JWTIssuers = append(metadata.Spec.JWTIssuers, issuer)
+			}
+		}
+	}
+}
+
 // createResourcesForEnvironment creates the necessary Kubernetes resources for a given environment
 func createResourcesForEnvironment(apiResourceBundle *dto.APIResourceBundle, environment string,
 	routeMetadataList []*dpv2alpha1.RouteMetadata) ([]client.Object, error) {
@@ -346,6 +416,10 @@ func createResourcesForEnvironment(apiResourceBundle *dto.APIResourceBundle, env
 				},
 			},
 		})
+		if persistedQueries := apiResourceBundle.APKConf.PersistedQueries; persistedQueries != nil {
+			gqlMediation := routePolicy.Spec.RequestMediation[len(routePolicy.Spec.RequestMediation)-1]
+			gqlMediation.Parameters = append(gqlMediation.Parameters, createPersistedQueryParameters(persistedQueries)...)
+		}
 		gqlOperations := make([]*gqlCommon.Operation, 0)
 		for _, operation := range apiResourceBundle.APKConf.Operations {
 			gqlOperations = append(gqlOperations, &gqlCommon.Operation{
@@ -437,6 +511,30 @@ func createResourcesForEnvironment(apiResourceBundle *dto.APIResourceBundle, env
 		}
 	}
 
+	if slices.Contains(constants.ASYNC_API_TYPES, apiResourceBundle.APKConf.Type) {
+		var targetRefs []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName
+		for _, httpRoutes := range routes {
+			for _, httpRoute := range httpRoutes {
+				targetRefs = append(targetRefs, gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+					LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+						Name:  gwapiv1a2.ObjectName(httpRoute.GetName()),
+						Kind:  constantscommon.KindHTTPRoute,
+						Group: constantscommon.K8sGroupNetworking,
+					},
+				})
+			}
+		}
+		// Generate BackendTrafficPolicy for the long-lived connections of async APIs
+		btpName := util.GenerateCRName(apiResourceBundle.APKConf.Name, environment, apiResourceBundle.APKConf.Version,
+			apiResourceBundle.Organization)
+		backendTrafficPolicy := generateBackendTrafficPolicyForAsync(btpName, targetRefs, apiResourceBundle.APKConf.Type)
+		if existing, ok := btpByName[btpName]; ok {
+			mergeBTP(existing, backendTrafficPolicy)
+		} else {
+			btpByName[btpName] = backendTrafficPolicy.DeepCopy()
+		}
+	}
+
 	kindType := constantscommon.KindHTTPRoute
 	if apiResourceBundle.APKConf.Type == constants.API_TYPE_GRPC {
 		kindType = constantscommon.KindGRPCRoute
@@ -577,6 +675,7 @@ func createResourcesForEnvironment(apiResourceBundle *dto.APIResourceBundle, env
 		sp := generateSecurityPolicy(spName, isSecured, scopes, targetRefs, cors, apiResourceBundle.APKConf.KeyManagers,
 			apiResourceBundle.APKConf.Authentication, apiResourceBundle.Namespace)
 		objects = append(objects, sp)
+		addRouteMetadataJWTIssuers(routeMetadataList, sp)
 	}
 
 	// Append all BackendTrafficPolicy objects
@@ -694,6 +793,9 @@ func GenerateGRPCRoutes(bundle *dto.APIResourceBundle, withVersion bool, environ
 				}
 				route.Spec.Rules = append(route.Spec.Rules, rule)
 			}
+			for _, metadata := range routeMetadataList {
+				addRouteMetadataHostnames(metadata, route.Spec.Hostnames)
+			}
 			routesMap[i] = append(routesMap[i], route)
 			objects = append(objects, &route)
 		}
@@ -748,6 +850,11 @@ func mergeBTP(dst, src *eg.BackendTrafficPolicy) {
 		}
 	}
 
+	// Merge HTTP upgrades
+	if src.Spec.HTTPUpgrade != nil {
+		dst.Spec.HTTPUpgrade = src.Spec.HTTPUpgrade
+	}
+
 	// Merge Cluster Settings
 	if src.Spec.ClusterSettings != (eg.ClusterSettings{}) {
 		if dst.Spec.ClusterSettings == (eg.ClusterSettings{}) {
@@ -816,6 +923,8 @@ func GenerateHTTPRoutes(bundle *dto.APIResourceBundle, withVersion bool, environ
 	backendMap := make(map[string]map[string]*eg.Backend)
 	envoyExtensionPolicyMap := make(map[string]*eg.EnvoyExtensionPolicy)
 	mapOfLuaSourceCodeConfigMap := make(map[string]*corev1.ConfigMap)
+	isAsyncAPI := slices.Contains(constants.ASYNC_API_TYPES, bundle.APKConf.Type)
+	asyncRouteMatches := make(map[string]bool)
 	crName := util.GenerateCRName(bundle.APKConf.Name, environment, bundle.APKConf.Version, bundle.Organization)
 	for i, combined := range bundle.CombinedResources {
 		batches := chunkOperations(combined.APKOperations, 16)
@@ -881,6 +990,9 @@ func GenerateHTTPRoutes(bundle *dto.APIResourceBundle, withVersion bool, environ
 					continue
 				}
 				method := safeHTTPMethod(op.Verb)
+				if isAsyncAPI {
+					method = asyncHTTPMethod(bundle.APKConf.Type)
+				}
 				apiBasePath := bundle.APKConf.BasePath
 				if withVersion {
 					version := bundle.APKConf.Version
@@ -894,6 +1006,14 @@ func GenerateHTTPRoutes(bundle *dto.APIResourceBundle, withVersion bool, environ
 					strings.TrimSuffix(apiBasePath, "/"),
 					strings.TrimSuffix(strings.TrimPrefix(*op.Target, "/"), "*"),
 				)
+				if isAsyncAPI {
+					// Publish and subscribe operations of a channel share the same connection
+					matchKey := fmt.Sprintf("%s %s", string(*method), path)
+					if asyncRouteMatches[matchKey] {
+						continue
+					}
+					asyncRouteMatches[matchKey] = true
+				}
 				serviceContractPath := fmt.Sprintf("%s/%s",
 					strings.TrimSuffix(backendBasePath, "/"),
 					strings.TrimSuffix(strings.TrimPrefix(*op.Target, "/"), "*"),
@@ -1228,6 +1348,7 @@ func GenerateHTTPRoutes(bundle *dto.APIResourceBundle, withVersion bool, environ
 					})
 				}
 				route.Spec.Rules = append(route.Spec.Rules, rule)
+				addRouteMetadataOperations(routeMetadataList, route.Spec.Hostnames, rule)
 			}
 
 			for _, interceptorPolicy := range interceptorPolicyList {
@@ -1245,6 +1366,10 @@ func GenerateHTTPRoutes(bundle *dto.APIResourceBundle, withVersion bool, environ
 				}
 			}
 
+			if isAsyncAPI && len(route.Spec.Rules) == 0 {
+				// All channels of this batch are already exposed by a previous route
+				continue
+			}
 			routesMap[i] = append(routesMap[i], route)
 			objects = append(objects, &route)
 		}
@@ -1589,6 +1714,44 @@ func generateBackendTrafficPolicyForGRPC(name string, targetRefs []gwapiv1a2.Loc
 	}
 }
 
+// Generate BackendTrafficPolicy for async APIs
+func generateBackendTrafficPolicyForAsync(name string, targetRefs []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName,
+	apiType string) *eg.BackendTrafficPolicy {
+	backendTrafficPolicy := &eg.BackendTrafficPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       constantscommon.KindBackendTrafficPolicy,
+			APIVersion: constantscommon.EnvoyGatewayV1Alpha1,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: eg.BackendTrafficPolicySpec{
+			PolicyTargetReferences: eg.PolicyTargetReferences{
+				TargetRefs: targetRefs,
+			},
+			MergeType: ptrTo(eg.StrategicMerge),
+		},
+	}
+	if apiType == constants.API_TYPE_WS || apiType == constants.API_TYPE_SSE {
+		// Streams are kept open for as long as the client is connected
+		backendTrafficPolicy.Spec.ClusterSettings = eg.ClusterSettings{
+			Timeout: &eg.Timeout{
+				HTTP: &eg.HTTPTimeout{
+					RequestTimeout: ptrTo(gatewayv1.Duration("0s")),
+				},
+			},
+		}
+	}
+	if apiType == constants.API_TYPE_WS {
+		backendTrafficPolicy.Spec.HTTPUpgrade = []*eg.ProtocolUpgradeConfig{
+			{
+				Type: constants.WEBSOCKET_UPGRADE_TYPE,
+			},
+		}
+	}
+	return backendTrafficPolicy
+}
+
 // generateRatelimitRules generates the rate limit rules based on the RatelimitConfiguration.
 func generateRatelimitRules(rlConf *model.RateLimit) []eg.RateLimitRule {
 	var ratelimitRules []eg.RateLimitRule
@@ -1938,6 +2101,12 @@ func extractSchemeHostPort(endpoint interface{}) (scheme, host string, port int,
 		}
 		scheme = parsed.Scheme
 		host = parsed.Hostname()
+		// WebSocket endpoints are reached through an HTTP upgrade
+		if scheme == "ws" {
+			scheme = "http"
+		} else if scheme == "wss" {
+			scheme = "https"
+		}
 
 		// Determine port
 		if parsed.Port() != "" {
@@ -2640,17 +2809,24 @@ func generateModelBasedRoundRobinPolicy(policy *model.ModelBasedRoundRobinPolicy
 // generateModelClusterPairs generates an array of model-cluster pairs.
 func generateModelClusterPairs(routing []model.ModelRouting) (string, error) {
 	type ModelClusterPair struct {
-		ModelName   string `json:"modelName"`
-		ClusterName string `json:"clusterName"`
-		Weight      int    `json:"weight"`
+		ModelName   string                            `json:"modelName"`
+		ClusterName string                            `json:"clusterName"`
+		Weight      int                               `json:"weight"`
+		Upstream    *commonmediation.UpstreamSettings `json:"upstream,omitempty"`
 	}
 
 	pairs := make([]ModelClusterPair, 0, len(routing))
 	for _, route := range routing {
+		if route.Upstream != nil {
+			if err := route.Upstream.Validate(); err != nil {
+				return "", fmt.Errorf("invalid upstream of model %s: %w", route.Model, err)
+			}
+		}
 		pair := ModelClusterPair{
 			ModelName:   route.Model,
 			ClusterName: route.Endpoint,
 			Weight:      route.Weight,
+			Upstream:    route.Upstream,
 		}
 		pairs = append(pairs, pair)
 	}
@@ -2719,6 +2895,8 @@ func getAIGuardrailPolicyName(policyName model.PolicyName) string {
 		return constantscommon.MediationURLGuardrail
 	case model.PolicyNameRegexGuardrail:
 		return constantscommon.MediationRegexGuardrail
+	case model.PolicyNamePromptInjectionGuardrail:
+		return constantscommon.MediationPromptInjectionGuardrail
 	default:
 		return string(policyName)
 	}
@@ -2831,3 +3009,34 @@ func generateHTTPRouteAnnotations(httpRouteNames []string) map[string]string {
 	}
 	return annotations
 }
+
+// createPersistedQueryParameters creates the GraphQL mediation parameters enabling persisted queries.
+// The allow-list is read by the gateway from the OperationAllowList key of the referenced ConfigMap.
+func createPersistedQueryParameters(persistedQueries *model.PersistedQueries) []*dpv2alpha1.Parameter {
+	parameters := []*dpv2alpha1.Parameter{
+		{
+			Key:   constantscommon.GraphQLPolicyKeyPersistedQueries,
+			Value: strconv.FormatBool(persistedQueries.Enabled),
+		},
+		{
+			Key:   constantscommon.GraphQLPolicyKeyAllowListOnly,
+			Value: strconv.FormatBool(persistedQueries.AllowListOnly),
+		},
+	}
+	if persistedQueries.TTLSeconds != nil {
+		parameters = append(parameters, &dpv2alpha1.Parameter{
+			Key:   constantscommon.GraphQLPolicyKeyPersistedQueryTTLSeconds,
+			Value: strconv.Itoa(*persistedQueries.TTLSeconds),
+		})
+	}
+	if persistedQueries.AllowListConfigMap != "" {
+		parameters = append(parameters, &dpv2alpha1.Parameter{
+			Key: constantscommon.GraphQLPolicyKeyOperationAllowList,
+			ValueRef: &gwapiv1a2.LocalObjectReference{
+				Name: gwapiv1a2.ObjectName(persistedQueries.AllowListConfigMap),
+				Kind: constantscommon.KindConfigMap,
+			},
+		})
+	}
+	return parameters
+}
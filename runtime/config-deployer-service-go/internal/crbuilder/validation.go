@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package crbuilder
+
+import (
+	"errors"
+	"fmt"
+
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ValidateRoutePolicies validates the mediations of the generated RoutePolicies against the
+// parameter schemas of the built-in mediation policies, so that a policy the enforcer would
+// reject is refused before it is applied.
+func ValidateRoutePolicies(objects []client.Object) error {
+	var errs []error
+	for _, object := range objects {
+		routePolicy, ok := object.(*dpv2alpha1.RoutePolicy)
+		if !ok {
+			continue
+		}
+		mediations := append(append([]*dpv2alpha1.Mediation(nil), routePolicy.Spec.RequestMediation...),
+			routePolicy.Spec.ResponseMediation...)
+		for _, mediation := range mediations {
+			if mediation == nil {
+				continue
+			}
+			if err := commonmediation.ValidateBuiltinParameters(mediation); err != nil {
+				errs = append(errs, fmt.Errorf("RoutePolicy %s: %w", routePolicy.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
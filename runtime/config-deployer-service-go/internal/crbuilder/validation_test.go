@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package crbuilder
+
+import (
+	"strings"
+	"testing"
+
+	dpv2alpha1 "github.com/wso2/apk/common-go-libs/apis/dp/v2alpha1"
+	constantscommon "github.com/wso2/apk/common-go-libs/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func testRoutePolicy(request, response *dpv2alpha1.Mediation) *dpv2alpha1.RoutePolicy {
+	routePolicy := &dpv2alpha1.RoutePolicy{ObjectMeta: metav1.ObjectMeta{Name: "test-route-policy"}}
+	if request != nil {
+		routePolicy.Spec.RequestMediation = []*dpv2alpha1.Mediation{request}
+	}
+	if response != nil {
+		routePolicy.Spec.ResponseMediation = []*dpv2alpha1.Mediation{response}
+	}
+	return routePolicy
+}
+
+func testMediation(policyName string, params ...*dpv2alpha1.Parameter) *dpv2alpha1.Mediation {
+	return &dpv2alpha1.Mediation{PolicyName: policyName, Parameters: params}
+}
+
+func TestValidateRoutePolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []client.Object
+		wantErr string
+	}{
+		{
+			name: "valid mediations",
+			objects: []client.Object{testRoutePolicy(
+				testMediation(constantscommon.MediationWordCountGuardrail,
+					&dpv2alpha1.Parameter{Key: constantscommon.WordCountGuardrailPolicyKeyMax, Value: "100"},
+					&dpv2alpha1.Parameter{Key: constantscommon.GuardrailPolicyKeyShowAssessment, Value: "true"}),
+				testMediation(constantscommon.MediationBackendJWT,
+					&dpv2alpha1.Parameter{Key: constantscommon.BackendJWTPolicyKeyCustomClaims, Value: `{"tier":"gold"}`}),
+			)},
+		},
+		{
+			name: "invalid request mediation",
+			objects: []client.Object{testRoutePolicy(
+				testMediation(constantscommon.MediationWordCountGuardrail,
+					&dpv2alpha1.Parameter{Key: constantscommon.WordCountGuardrailPolicyKeyMax, Value: "many"}), nil)},
+			wantErr: `parameter max: "many" is not an int`,
+		},
+		{
+			name: "invalid response mediation",
+			objects: []client.Object{testRoutePolicy(nil, testMediation(constantscommon.MediationSemanticCache,
+				&dpv2alpha1.Parameter{Key: constantscommon.SemanticCachePolicyKeyThreshold, Value: "high"}))},
+			wantErr: `parameter Threshold: "high" is not a float`,
+		},
+		{
+			name:    "missing required parameter",
+			objects: []client.Object{testRoutePolicy(testMediation(constantscommon.MediationRegexGuardrail), nil)},
+			wantErr: "parameter regex is required",
+		},
+		{
+			name: "required parameter from a ConfigMap",
+			objects: []client.Object{testRoutePolicy(testMediation(constantscommon.MediationWASMCustom,
+				&dpv2alpha1.Parameter{Key: constantscommon.WASMCustomPolicyKeyModule,
+					ValueRef: &gwapiv1.LocalObjectReference{Kind: "ConfigMap", Name: "module"}}), nil)},
+		},
+		{
+			name: "policy registered at runtime",
+			objects: []client.Object{testRoutePolicy(testMediation("HeaderStamp",
+				&dpv2alpha1.Parameter{Key: "Enabled", Value: "sometimes"}), nil)},
+		},
+		{
+			name:    "other resources",
+			objects: []client.Object{&dpv2alpha1.RouteMetadata{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRoutePolicies(tt.objects)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(err.Error(), "RoutePolicy test-route-policy") {
+				t.Errorf("expected the error to name the RoutePolicy, got %v", err)
+			}
+		})
+	}
+}
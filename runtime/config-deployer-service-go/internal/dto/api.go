@@ -19,18 +19,19 @@ package dto
 
 // API represents the structure of an API definition
 type API struct {
-	Name              string        `json:"name" yaml:"name"`                           // api name
-	BasePath          string        `json:"basePath" yaml:"basePath"`                   // api base path
-	Version           string        `json:"version" yaml:"version"`                     // api version
-	Type              string        `json:"type" yaml:"type"`                           // api type (e.g., REST, GraphQL)
-	Endpoint          string        `json:"endpoint" yaml:"endpoint"`                   // Endpoint URL
-	URITemplates      []URITemplate `json:"uriTemplates" yaml:"uriTemplates"`           // Array of URI templates
-	APISecurity       string        `json:"apiSecurity" yaml:"apiSecurity"`             // Security definition
-	Scopes            []string      `json:"scopes" yaml:"scopes"`                       // Array of scopes
-	GraphQLSchema     string        `json:"graphQLSchema" yaml:"graphQLSchema"`         // GraphQL schema string
-	ProtoDefinition   string        `json:"protoDefinition" yaml:"protoDefinition"`     // gRPC proto content
-	SwaggerDefinition string        `json:"swaggerDefinition" yaml:"swaggerDefinition"` // Swagger/OpenAPI content
-	Environment       string        `json:"environment" yaml:"environment"`             // Deployment environment
+	Name               string        `json:"name" yaml:"name"`                             // api name
+	BasePath           string        `json:"basePath" yaml:"basePath"`                     // api base path
+	Version            string        `json:"version" yaml:"version"`                       // api version
+	Type               string        `json:"type" yaml:"type"`                             // api type (e.g., REST, GraphQL)
+	Endpoint           string        `json:"endpoint" yaml:"endpoint"`                     // Endpoint URL
+	URITemplates       []URITemplate `json:"uriTemplates" yaml:"uriTemplates"`             // Array of URI templates
+	APISecurity        string        `json:"apiSecurity" yaml:"apiSecurity"`               // Security definition
+	Scopes             []string      `json:"scopes" yaml:"scopes"`                         // Array of scopes
+	GraphQLSchema      string        `json:"graphQLSchema" yaml:"graphQLSchema"`           // GraphQL schema string
+	ProtoDefinition    string        `json:"protoDefinition" yaml:"protoDefinition"`       // gRPC proto content
+	SwaggerDefinition  string        `json:"swaggerDefinition" yaml:"swaggerDefinition"`   // Swagger/OpenAPI content
+	AsyncAPIDefinition string        `json:"asyncAPIDefinition" yaml:"asyncAPIDefinition"` // AsyncAPI content
+	Environment        string        `json:"environment" yaml:"environment"`               // Deployment environment
 }
 
 // URITemplate represents a URI template
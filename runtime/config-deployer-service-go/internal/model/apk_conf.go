@@ -19,6 +19,8 @@ package model
 
 import (
 	"encoding/json"
+
+	commonmediation "github.com/wso2/apk/common-go-libs/pkg/mediation"
 	"github.com/wso2/apk/config-deployer-service-go/internal/constants"
 )
 
@@ -42,6 +44,7 @@ type APKConf struct {
 	AdditionalProperties   []APKConfAdditionalProperties `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
 	CorsConfiguration      *CORSConfiguration            `json:"corsConfiguration,omitempty" yaml:"corsConfiguration,omitempty"`
 	KeyManagers            []KeyManager                  `json:"keyManagers,omitempty" yaml:"keyManagers,omitempty"`
+	PersistedQueries       *PersistedQueries             `json:"persistedQueries,omitempty" yaml:"persistedQueries,omitempty"`
 }
 
 // NewAPKConf creates a new APKConf with default values
@@ -279,21 +282,22 @@ func NewAPKOperations() *APKOperations {
 type PolicyName string
 
 const (
-	PolicyNameBackendJWT             PolicyName = "BackendJwt"
-	PolicyLuaInterceptor             PolicyName = "LuaInterceptor"
-	PolicyWASMInterceptor            PolicyName = "WASMInterceptor"
-	PolicyNameAddHeader              PolicyName = "AddHeader"
-	PolicyNameSetHeader              PolicyName = "SetHeader"
-	PolicyNameRemoveHeader           PolicyName = "RemoveHeader"
-	PolicyNameRequestMirror          PolicyName = "RequestMirror"
-	PolicyNameRequestRedirect        PolicyName = "RequestRedirect"
-	PolicyNameModelBasedRoundRobin   PolicyName = "ModelBasedRoundRobin"
-	PolicyNameWordCountGuardrail     PolicyName = "WordCountGuardrail"
-	PolicyNameSentenceCountGuardrail PolicyName = "SentenceCountGuardrail"
-	PolicyNameContentLengthGuardrail PolicyName = "ContentLengthGuardrail"
-	PolicyNamePIIMaskingGuardrail    PolicyName = "PIIMaskingGuardrail"
-	PolicyNameURLGuardrail           PolicyName = "URLGuardrail"
-	PolicyNameRegexGuardrail         PolicyName = "RegexGuardrail"
+	PolicyNameBackendJWT               PolicyName = "BackendJwt"
+	PolicyLuaInterceptor               PolicyName = "LuaInterceptor"
+	PolicyWASMInterceptor              PolicyName = "WASMInterceptor"
+	PolicyNameAddHeader                PolicyName = "AddHeader"
+	PolicyNameSetHeader                PolicyName = "SetHeader"
+	PolicyNameRemoveHeader             PolicyName = "RemoveHeader"
+	PolicyNameRequestMirror            PolicyName = "RequestMirror"
+	PolicyNameRequestRedirect          PolicyName = "RequestRedirect"
+	PolicyNameModelBasedRoundRobin     PolicyName = "ModelBasedRoundRobin"
+	PolicyNameWordCountGuardrail       PolicyName = "WordCountGuardrail"
+	PolicyNameSentenceCountGuardrail   PolicyName = "SentenceCountGuardrail"
+	PolicyNameContentLengthGuardrail   PolicyName = "ContentLengthGuardrail"
+	PolicyNamePIIMaskingGuardrail      PolicyName = "PIIMaskingGuardrail"
+	PolicyNameURLGuardrail             PolicyName = "URLGuardrail"
+	PolicyNameRegexGuardrail           PolicyName = "RegexGuardrail"
+	PolicyNamePromptInjectionGuardrail PolicyName = "PromptInjectionGuardrail"
 )
 
 // BaseOperationPolicy represents common configuration of all policies.
@@ -463,6 +467,8 @@ type ModelRouting struct {
 	Model    string `json:"model" yaml:"model"`
 	Endpoint string `json:"endpoint" yaml:"endpoint"`
 	Weight   int    `json:"weight" yaml:"weight"`
+	// Upstream tunes the cluster of the endpoint.
+	Upstream *commonmediation.UpstreamSettings `json:"upstream,omitempty" yaml:"upstream,omitempty"`
 }
 
 // CommonPolicy represents AI Guardrail policy configuration for an operation.
@@ -509,7 +515,8 @@ func (p *APKRequestOperationPolicy) UnmarshalJSON(data []byte) error {
 		p.ModelBasedRoundRobinPolicy = &ModelBasedRoundRobinPolicy{}
 		return json.Unmarshal(data, p.ModelBasedRoundRobinPolicy)
 	case PolicyNameWordCountGuardrail, PolicyNameSentenceCountGuardrail, PolicyNameContentLengthGuardrail,
-		PolicyNamePIIMaskingGuardrail, PolicyNameURLGuardrail, PolicyNameRegexGuardrail:
+		PolicyNamePIIMaskingGuardrail, PolicyNameURLGuardrail, PolicyNameRegexGuardrail,
+		PolicyNamePromptInjectionGuardrail:
 		p.AIGuardrailPolicy = &CommonPolicy{}
 		return json.Unmarshal(data, p.AIGuardrailPolicy)
 	}
@@ -535,7 +542,8 @@ func (p *APKResponseOperationPolicy) UnmarshalJSON(data []byte) error {
 		p.HeaderModifierPolicy = &HeaderModifierPolicy{}
 		return json.Unmarshal(data, p.HeaderModifierPolicy)
 	case PolicyNameWordCountGuardrail, PolicyNameSentenceCountGuardrail, PolicyNameContentLengthGuardrail,
-		PolicyNamePIIMaskingGuardrail, PolicyNameURLGuardrail, PolicyNameRegexGuardrail:
+		PolicyNamePIIMaskingGuardrail, PolicyNameURLGuardrail, PolicyNameRegexGuardrail,
+		PolicyNamePromptInjectionGuardrail:
 		p.AIGuardrailPolicy = &CommonPolicy{}
 		return json.Unmarshal(data, p.AIGuardrailPolicy)
 	}
@@ -816,6 +824,14 @@ func NewCORSConfiguration() *CORSConfiguration {
 	}
 }
 
+// PersistedQueries represents the persisted query configuration of a GraphQL API
+type PersistedQueries struct {
+	Enabled            bool   `json:"enabled" yaml:"enabled"`
+	AllowListOnly      bool   `json:"allowListOnly" yaml:"allowListOnly"`
+	AllowListConfigMap string `json:"allowListConfigMap,omitempty" yaml:"allowListConfigMap,omitempty"`
+	TTLSeconds         *int   `json:"ttlSeconds,omitempty" yaml:"ttlSeconds,omitempty"`
+}
+
 // KeyManager represents configuration for a Key Manager
 type KeyManager struct {
 	Name         string      `json:"name" yaml:"name"`
@@ -19,12 +19,395 @@ package parsers
 
 import (
 	"fmt"
+	"github.com/wso2/apk/config-deployer-service-go/internal/constants"
 	"github.com/wso2/apk/config-deployer-service-go/internal/dto"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
 )
 
 type AsyncApiParser struct{}
 
-// GetAPIFromDefinition is a method that should be implemented by AsyncApiParser to parse an AsyncAPI definition
+// AsyncAPIDocument represents the subset of an AsyncAPI 2.x/3.x document used by the deployer
+type AsyncAPIDocument struct {
+	AsyncAPI   string                       `yaml:"asyncapi"`
+	Info       AsyncAPIInfo                 `yaml:"info"`
+	Servers    map[string]AsyncAPIServer    `yaml:"servers"`
+	Channels   map[string]AsyncAPIChannel   `yaml:"channels"`
+	Operations map[string]AsyncAPIOperation `yaml:"operations"`
+	Components AsyncAPIComponents           `yaml:"components"`
+}
+
+// AsyncAPIInfo represents the info block of an AsyncAPI document
+type AsyncAPIInfo struct {
+	Title       string `yaml:"title"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+}
+
+// AsyncAPIServer represents a server entry. 2.x documents use URL while 3.x documents use Host and Pathname.
+type AsyncAPIServer struct {
+	URL      string `yaml:"url"`
+	Host     string `yaml:"host"`
+	Pathname string `yaml:"pathname"`
+	Protocol string `yaml:"protocol"`
+}
+
+// AsyncAPIChannel represents a channel. Subscribe and Publish are only present in 2.x documents.
+type AsyncAPIChannel struct {
+	Ref       string             `yaml:"$ref"`
+	Address   *string            `yaml:"address"`
+	Subscribe *AsyncAPIOperation `yaml:"subscribe"`
+	Publish   *AsyncAPIOperation `yaml:"publish"`
+}
+
+// AsyncAPIOperation represents an operation. Action and Channel are only present in 3.x documents.
+type AsyncAPIOperation struct {
+	Ref         string                   `yaml:"$ref"`
+	OperationID string                   `yaml:"operationId"`
+	Action      string                   `yaml:"action"`
+	Channel     AsyncAPIChannelReference `yaml:"channel"`
+	Security    []map[string]interface{} `yaml:"security"`
+	XScopes     []string                 `yaml:"x-scopes"`
+	XAuthType   string                   `yaml:"x-auth-type"`
+}
+
+// AsyncAPIChannelReference represents a 3.x reference from an operation to a channel
+type AsyncAPIChannelReference struct {
+	Ref string `yaml:"$ref"`
+}
+
+// AsyncAPIComponents represents the components block of an AsyncAPI document
+type AsyncAPIComponents struct {
+	SecuritySchemes map[string]AsyncAPISecurityScheme `yaml:"securitySchemes"`
+	Channels        map[string]AsyncAPIChannel        `yaml:"channels"`
+	Operations      map[string]AsyncAPIOperation      `yaml:"operations"`
+}
+
+// AsyncAPISecurityScheme represents a security scheme. Scopes is only present in 3.x documents.
+type AsyncAPISecurityScheme struct {
+	Ref    string                       `yaml:"$ref"`
+	Type   string                       `yaml:"type"`
+	Flows  map[string]AsyncAPIOAuthFlow `yaml:"flows"`
+	Scopes []string                     `yaml:"scopes"`
+}
+
+// AsyncAPIOAuthFlow represents an OAuth flow. 2.x uses Scopes while 3.x uses AvailableScopes.
+type AsyncAPIOAuthFlow struct {
+	Scopes          map[string]string `yaml:"scopes"`
+	AvailableScopes map[string]string `yaml:"availableScopes"`
+}
+
+// GetAPIFromDefinition parses an AsyncAPI 2.x or 3.x definition and returns API object
 func (asyncParser *AsyncApiParser) GetAPIFromDefinition(definition string) (*dto.API, error) {
-	return nil, fmt.Errorf("unimplemented method 'getAPIFromDefinition' in AsyncApiParser")
+	document, err := asyncParser.ParseAsyncAPI(definition)
+	if err != nil {
+		return nil, err
+	}
+	api := &dto.API{}
+	api.Name = document.Info.Title
+	api.Version = document.Info.Version
+	api.AsyncAPIDefinition = definition
+	api.Endpoint = asyncParser.getEndpoint(document)
+	scopes := asyncParser.getScopes(document)
+	api.Scopes = scopes
+
+	var uriTemplates []dto.URITemplate
+	if asyncParser.isVersion3(document) {
+		uriTemplates, err = asyncParser.getURITemplatesV3(document, scopes)
+	} else {
+		uriTemplates, err = asyncParser.getURITemplatesV2(document, scopes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	api.URITemplates = uriTemplates
+	return api, nil
+}
+
+// ParseAsyncAPI parses an AsyncAPI definition in JSON or YAML format and checks the specification version
+func (asyncParser *AsyncApiParser) ParseAsyncAPI(definition string) (*AsyncAPIDocument, error) {
+	if strings.TrimSpace(definition) == "" {
+		return nil, fmt.Errorf("AsyncAPI definition cannot be empty")
+	}
+	var document AsyncAPIDocument
+	if err := yaml.Unmarshal([]byte(definition), &document); err != nil {
+		return nil, fmt.Errorf("errors found when parsing AsyncAPI definition: %w", err)
+	}
+	if document.AsyncAPI == "" {
+		return nil, fmt.Errorf("invalid AsyncAPI definition provided: 'asyncapi' version field is missing")
+	}
+	if !strings.HasPrefix(document.AsyncAPI, constants.ASYNCAPI_VERSION_2_PREFIX) &&
+		!strings.HasPrefix(document.AsyncAPI, constants.ASYNCAPI_VERSION_3_PREFIX) {
+		return nil, fmt.Errorf("unsupported AsyncAPI version: %s", document.AsyncAPI)
+	}
+	if len(document.Channels) == 0 {
+		return nil, fmt.Errorf("AsyncAPI definition does not contain any channels")
+	}
+	return &document, nil
+}
+
+// isVersion3 checks whether the given document follows the AsyncAPI 3.x specification
+func (asyncParser *AsyncApiParser) isVersion3(document *AsyncAPIDocument) bool {
+	return strings.HasPrefix(document.AsyncAPI, constants.ASYNCAPI_VERSION_3_PREFIX)
+}
+
+// getURITemplatesV2 returns URI templates for the publish and subscribe operations of an AsyncAPI 2.x document
+func (asyncParser *AsyncApiParser) getURITemplatesV2(document *AsyncAPIDocument, apiScopes []string) ([]dto.URITemplate,
+	error) {
+	var uriTemplates []dto.URITemplate
+	for _, channelName := range sortedKeys(document.Channels) {
+		channel, err := asyncParser.resolveChannel(document, document.Channels[channelName])
+		if err != nil {
+			return nil, err
+		}
+		operations := []struct {
+			verb      string
+			operation *AsyncAPIOperation
+		}{
+			{constants.ASYNC_VERB_SUBSCRIBE, channel.Subscribe},
+			{constants.ASYNC_VERB_PUBLISH, channel.Publish},
+		}
+		for _, entry := range operations {
+			if entry.operation == nil {
+				continue
+			}
+			template, err := asyncParser.createURITemplate(document, channelName, entry.verb, entry.operation, apiScopes)
+			if err != nil {
+				return nil, err
+			}
+			uriTemplates = append(uriTemplates, *template)
+		}
+	}
+	return uriTemplates, nil
+}
+
+// getURITemplatesV3 returns URI templates for the send and receive operations of an AsyncAPI 3.x document
+func (asyncParser *AsyncApiParser) getURITemplatesV3(document *AsyncAPIDocument, apiScopes []string) ([]dto.URITemplate,
+	error) {
+	var uriTemplates []dto.URITemplate
+	usedChannels := make(map[string]bool)
+	for _, operationName := range sortedKeys(document.Operations) {
+		operation := document.Operations[operationName]
+		if operation.Ref != "" {
+			resolved, err := resolveLocalReference(operation.Ref, "#/components/operations/", document.Components.Operations)
+			if err != nil {
+				return nil, err
+			}
+			operation = resolved
+		}
+		channelName, err := channelNameFromReference(operation.Channel.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel reference in operation '%s': %w", operationName, err)
+		}
+		channel, exists := document.Channels[channelName]
+		if !exists {
+			return nil, fmt.Errorf("channel '%s' referenced by operation '%s' not found", channelName, operationName)
+		}
+		channel, err = asyncParser.resolveChannel(document, channel)
+		if err != nil {
+			return nil, err
+		}
+		address := channelName
+		if channel.Address != nil && *channel.Address != "" {
+			address = *channel.Address
+		}
+		var verb string
+		switch strings.ToLower(operation.Action) {
+		case constants.ASYNCAPI_ACTION_RECEIVE:
+			verb = constants.ASYNC_VERB_SUBSCRIBE
+		case constants.ASYNCAPI_ACTION_SEND:
+			verb = constants.ASYNC_VERB_PUBLISH
+		default:
+			return nil, fmt.Errorf("unsupported action '%s' in operation '%s'", operation.Action, operationName)
+		}
+		template, err := asyncParser.createURITemplate(document, address, verb, &operation, apiScopes)
+		if err != nil {
+			return nil, err
+		}
+		uriTemplates = append(uriTemplates, *template)
+		usedChannels[channelName] = true
+	}
+
+	// Channels without any operation are still exposed so that clients are able to connect to them
+	for _, channelName := range sortedKeys(document.Channels) {
+		if usedChannels[channelName] {
+			continue
+		}
+		channel, err := asyncParser.resolveChannel(document, document.Channels[channelName])
+		if err != nil {
+			return nil, err
+		}
+		address := channelName
+		if channel.Address != nil && *channel.Address != "" {
+			address = *channel.Address
+		}
+		uriTemplates = append(uriTemplates, dto.URITemplate{
+			URITemplate: normalizeChannelPath(address),
+			Verb:        constants.ASYNC_VERB_SUBSCRIBE,
+			AuthEnabled: true,
+			Scopes:      []string{},
+		})
+	}
+	return uriTemplates, nil
+}
+
+// createURITemplate creates a URI template for an AsyncAPI operation on the given channel
+func (asyncParser *AsyncApiParser) createURITemplate(document *AsyncAPIDocument, channel string, verb string,
+	operation *AsyncAPIOperation, apiScopes []string) (*dto.URITemplate, error) {
+	template := &dto.URITemplate{
+		URITemplate: normalizeChannelPath(channel),
+		Verb:        verb,
+		AuthEnabled: !strings.EqualFold(operation.XAuthType, "none"),
+		Scopes:      []string{},
+	}
+	opScopes, err := asyncParser.getScopeOfOperation(document, operation)
+	if err != nil {
+		return nil, err
+	}
+	if len(opScopes) > 0 {
+		return setScopesToTemplate(template, opScopes, apiScopes)
+	}
+	return template, nil
+}
+
+// getScopeOfOperation returns the scopes required by an operation using its security requirements or x-scopes extension
+func (asyncParser *AsyncApiParser) getScopeOfOperation(document *AsyncAPIDocument,
+	operation *AsyncAPIOperation) ([]string, error) {
+	var scopes []string
+	for _, requirement := range operation.Security {
+		if ref, ok := requirement["$ref"].(string); ok {
+			// 3.x security reference to a component security scheme
+			scheme, err := resolveLocalReference(ref, "#/components/securitySchemes/",
+				document.Components.SecuritySchemes)
+			if err != nil {
+				return nil, err
+			}
+			scopes = append(scopes, scheme.Scopes...)
+		} else if _, ok := requirement["type"]; ok {
+			// 3.x inline security scheme
+			scopes = append(scopes, toStringSlice(requirement["scopes"])...)
+		} else {
+			// 2.x security requirement map of scheme name to scopes
+			for _, schemeName := range sortedKeys(requirement) {
+				scopes = append(scopes, toStringSlice(requirement[schemeName])...)
+			}
+		}
+	}
+	if len(scopes) == 0 {
+		scopes = append(scopes, operation.XScopes...)
+	}
+	return scopes, nil
+}
+
+// getScopes extracts all OAuth scopes declared in the security schemes of an AsyncAPI document
+func (asyncParser *AsyncApiParser) getScopes(document *AsyncAPIDocument) []string {
+	scopeSet := make(map[string]bool)
+	for _, scheme := range document.Components.SecuritySchemes {
+		for _, flow := range scheme.Flows {
+			for scope := range flow.Scopes {
+				scopeSet[scope] = true
+			}
+			for scope := range flow.AvailableScopes {
+				scopeSet[scope] = true
+			}
+		}
+		for _, scope := range scheme.Scopes {
+			scopeSet[scope] = true
+		}
+	}
+	return sortScopes(scopeSet)
+}
+
+// getEndpoint returns the URL of the first server of an AsyncAPI document in name order
+func (asyncParser *AsyncApiParser) getEndpoint(document *AsyncAPIDocument) string {
+	serverNames := sortedKeys(document.Servers)
+	if len(serverNames) == 0 {
+		return ""
+	}
+	server := document.Servers[serverNames[0]]
+	if server.URL != "" {
+		if strings.Contains(server.URL, "://") || server.Protocol == "" {
+			return server.URL
+		}
+		return fmt.Sprintf("%s://%s", server.Protocol, server.URL)
+	}
+	if server.Host == "" {
+		return ""
+	}
+	endpoint := server.Host
+	if server.Protocol != "" {
+		endpoint = fmt.Sprintf("%s://%s", server.Protocol, server.Host)
+	}
+	if server.Pathname != "" {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/" + strings.TrimPrefix(server.Pathname, "/")
+	}
+	return endpoint
+}
+
+// resolveChannel resolves a channel that is defined as a reference to a component channel
+func (asyncParser *AsyncApiParser) resolveChannel(document *AsyncAPIDocument, channel AsyncAPIChannel) (AsyncAPIChannel,
+	error) {
+	if channel.Ref == "" {
+		return channel, nil
+	}
+	return resolveLocalReference(channel.Ref, "#/components/channels/", document.Components.Channels)
+}
+
+// resolveLocalReference resolves a local JSON reference with the given prefix against the provided components
+func resolveLocalReference[T any](ref string, prefix string, components map[string]T) (T, error) {
+	var empty T
+	if !strings.HasPrefix(ref, prefix) {
+		return empty, fmt.Errorf("unsupported reference '%s'", ref)
+	}
+	component, exists := components[strings.TrimPrefix(ref, prefix)]
+	if !exists {
+		return empty, fmt.Errorf("reference '%s' could not be resolved", ref)
+	}
+	return component, nil
+}
+
+// channelNameFromReference extracts the channel name from a 3.x channel reference
+func channelNameFromReference(ref string) (string, error) {
+	if !strings.HasPrefix(ref, "#/channels/") {
+		return "", fmt.Errorf("unsupported reference '%s'", ref)
+	}
+	// JSON pointer escaping for '/' and '~'
+	name := strings.TrimPrefix(ref, "#/channels/")
+	name = strings.ReplaceAll(name, "~1", "/")
+	name = strings.ReplaceAll(name, "~0", "~")
+	return name, nil
+}
+
+// normalizeChannelPath makes sure the channel is represented as an absolute resource path
+func normalizeChannelPath(channel string) string {
+	if strings.HasPrefix(channel, "/") {
+		return channel
+	}
+	return "/" + channel
+}
+
+// sortedKeys returns the keys of the given map in sorted order
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toStringSlice converts a decoded YAML sequence to a slice of strings
+func toStringSlice(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
 }
@@ -35,7 +35,7 @@ func GetParser(apiType string) APIDefinition {
 		return &GraphQLParser{}
 	case constants.API_TYPE_GRPC:
 		return &ProtoParser{}
-	case constants.API_TYPE_ASYNC:
+	case constants.API_TYPE_ASYNC, constants.API_TYPE_WS, constants.API_TYPE_SSE, constants.API_TYPE_WEBSUB:
 		return &AsyncApiParser{}
 	default:
 		return nil
@@ -67,6 +67,12 @@ func validateAPIDefinitionFromURL(apiType string, apiDefinition string,
 	case constants.API_TYPE_GRPC:
 		// TODO - Handle gRPC definition from url
 		return nil, fmt.Errorf("handling gRPC definition from URL is not implemented yet")
+	case constants.API_TYPE_ASYNC, constants.API_TYPE_WS, constants.API_TYPE_SSE, constants.API_TYPE_WEBSUB:
+		asyncAPIValidator := &validators.AsyncAPIValidator{}
+		validationResponse, err = asyncAPIValidator.ValidateAsyncAPIDefinition(apiDefinition, returnContent)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return validationResponse, nil
@@ -114,6 +120,17 @@ func validateAPIDefinitionFromFile(apiType string, fileName string, inputByteArr
 		} else {
 			return nil, fmt.Errorf("invalid definition file type provided: %s, for gRPC API", fileName)
 		}
+	case constants.API_TYPE_ASYNC, constants.API_TYPE_WS, constants.API_TYPE_SSE, constants.API_TYPE_WEBSUB:
+		if strings.HasSuffix(fileName, ".yaml") || strings.HasSuffix(fileName, ".yml") ||
+			strings.HasSuffix(fileName, ".json") {
+			asyncAPIValidator := &validators.AsyncAPIValidator{}
+			validationResponse, err = asyncAPIValidator.ValidateAsyncAPIDefinition(string(inputByteArray), returnContent)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, fmt.Errorf("invalid definition file type provided: %s, for %s API", fileName, apiType)
+		}
 	}
 
 	return validationResponse, nil
@@ -136,6 +153,9 @@ func ValidateAndRetrieveAPKConfiguration(apkConfJson string) (*model.APKConf, er
 		if len(errors) > 0 {
 			return nil, fmt.Errorf("APK configuration endpoint validation failed: %v", errors)
 		}
+		if err := apkConfValidator.ValidatePersistedQueries(&apkConf); err != nil {
+			return nil, fmt.Errorf("APK configuration is not valid: %w", err)
+		}
 		return &apkConf, nil
 	} else {
 		if validationResponse.ErrorItems != nil && len(validationResponse.ErrorItems) > 0 {
@@ -2,6 +2,7 @@ package validators
 
 import (
 	"fmt"
+	"github.com/wso2/apk/config-deployer-service-go/internal/constants"
 	"github.com/wso2/apk/config-deployer-service-go/internal/dto"
 	"github.com/wso2/apk/config-deployer-service-go/internal/model"
 	"github.com/xeipuuv/gojsonschema"
@@ -82,6 +83,21 @@ func (apkConfValidator *APKConfValidator) ValidateEndpointConfigurations(apkConf
 	return errors
 }
 
+// ValidatePersistedQueries validates the persisted query configuration of a GraphQL API
+func (apkConfValidator *APKConfValidator) ValidatePersistedQueries(apkConf *model.APKConf) error {
+	persistedQueries := apkConf.PersistedQueries
+	if persistedQueries == nil {
+		return nil
+	}
+	if apkConf.Type != constants.API_TYPE_GRAPHQL {
+		return fmt.Errorf("persisted queries are only supported for GraphQL APIs")
+	}
+	if persistedQueries.AllowListOnly && persistedQueries.AllowListConfigMap == "" {
+		return fmt.Errorf("allowListConfigMap is required when allowListOnly is enabled")
+	}
+	return nil
+}
+
 // ValidateRateLimit validates the rate limit configuration for APK operations
 func (apkConfValidator *APKConfValidator) ValidateRateLimit(apiRateLimit *model.RateLimit, operations []model.APKOperations) error {
 	if apiRateLimit == nil {
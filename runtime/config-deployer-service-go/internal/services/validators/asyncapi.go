@@ -0,0 +1,98 @@
+/* Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package validators
+
+import (
+	"fmt"
+	"github.com/wso2/apk/config-deployer-service-go/internal/dto"
+	"github.com/wso2/apk/config-deployer-service-go/internal/parsers"
+	"github.com/wso2/apk/config-deployer-service-go/internal/util"
+	"regexp"
+	"strings"
+)
+
+type AsyncAPIValidator struct{}
+
+// ValidateAsyncAPIDefinition validates an AsyncAPI 2.x or 3.x definition and returns the validation response.
+func (asyncAPIValidator *AsyncAPIValidator) ValidateAsyncAPIDefinition(apiDefinition string,
+	returnJsonContent bool) (*dto.APIDefinitionValidationResponse, error) {
+	validationResponse := &dto.APIDefinitionValidationResponse{}
+	if strings.TrimSpace(apiDefinition) == "" {
+		validationResponse.IsValid = false
+		return validationResponse, fmt.Errorf("AsyncAPI definition cannot be empty or null")
+	}
+
+	asyncParser := parsers.AsyncApiParser{}
+	document, err := asyncParser.ParseAsyncAPI(apiDefinition)
+	if err != nil {
+		validationResponse.IsValid = false
+		return validationResponse, fmt.Errorf("invalid AsyncAPI definition found: %w", err)
+	}
+	if document.Info.Title == "" || document.Info.Version == "" {
+		validationResponse.IsValid = false
+		return validationResponse, fmt.Errorf("invalid AsyncAPI definition found: info title and version are required")
+	}
+	// Parsing the operations validates channel references, actions and scopes
+	api, err := asyncParser.GetAPIFromDefinition(apiDefinition)
+	if err != nil {
+		validationResponse.IsValid = false
+		return validationResponse, fmt.Errorf("invalid AsyncAPI definition found: %w", err)
+	}
+
+	var endpoints []string
+	if api.Endpoint != "" {
+		endpoints = append(endpoints, api.Endpoint)
+	}
+	re := regexp.MustCompile(`\s+`)
+	context := strings.ToLower(re.ReplaceAllString(document.Info.Title, ""))
+
+	validationResponse.IsValid = true
+	validationResponse.Content = apiDefinition
+	validationResponse.Name = document.Info.Title
+	validationResponse.Version = document.Info.Version
+	validationResponse.Context = context
+	validationResponse.Description = document.Info.Description
+	validationResponse.Endpoints = endpoints
+	validationResponse.Protocol = asyncAPIValidator.getProtocol(document)
+
+	if returnJsonContent {
+		if !strings.HasPrefix(strings.TrimSpace(apiDefinition), "{") {
+			jsonContent, err := util.YamlToJSON(apiDefinition)
+			if err != nil {
+				return nil, fmt.Errorf("error while reading AsyncAPI definition yaml: %w", err)
+			}
+			validationResponse.JSONContent = jsonContent
+		} else {
+			validationResponse.JSONContent = apiDefinition
+		}
+	}
+	return validationResponse, nil
+}
+
+// getProtocol returns the protocol of the servers in the AsyncAPI definition if all of them agree on one
+func (asyncAPIValidator *AsyncAPIValidator) getProtocol(document *parsers.AsyncAPIDocument) string {
+	protocol := ""
+	for _, server := range document.Servers {
+		if protocol == "" {
+			protocol = strings.ToLower(server.Protocol)
+		} else if protocol != strings.ToLower(server.Protocol) {
+			return ""
+		}
+	}
+	return protocol
+}
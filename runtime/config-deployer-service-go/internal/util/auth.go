@@ -19,7 +19,14 @@ package util
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/wso2/apk/common-go-libs/pkg/logging"
+	"github.com/wso2/apk/config-deployer-service-go/internal/config"
 	"github.com/wso2/apk/config-deployer-service-go/internal/constants"
 	"github.com/wso2/apk/config-deployer-service-go/internal/dto"
 )
@@ -29,6 +36,7 @@ type UserContext struct {
 	Username     string                 `json:"username"`
 	UserID       *string                `json:"userId,omitempty"`
 	Organization *dto.Organization      `json:"organization"`
+	Scopes       []string               `json:"scopes"`
 	Claims       map[string]interface{} `json:"claims"`
 }
 
@@ -44,3 +52,206 @@ func GetAuthenticatedUserContext(cxt *gin.Context) (*UserContext, error) {
 	}
 	return userContext, nil
 }
+
+// Operation represents a config deployer operation guarded by a scope
+type Operation struct {
+	Name  string
+	Scope string
+	// Namespaced operations are only allowed in the namespaces of the organization.
+	Namespaced bool
+}
+
+// Authenticator validates the bearer tokens of config deployer requests and authorizes the
+// requested operations
+type Authenticator struct {
+	cfg           *config.Server
+	keyStore      *JWKSKeyStore
+	organizations *OrganizationPolicies
+	parser        *jwt.Parser
+	auditLogger   logging.Logger
+}
+
+// NewAuthenticator creates an authenticator from the server configuration
+func NewAuthenticator(cfg *config.Server) (*Authenticator, error) {
+	authenticator := &Authenticator{cfg: cfg, auditLogger: cfg.Logger.WithName("audit")}
+	if !cfg.AuthEnabled {
+		return authenticator, nil
+	}
+	if cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("JWKS_URL is required when authentication is enabled")
+	}
+	keyStore, err := NewJWKSKeyStore(cfg.JWKSURL, cfg.JWKSCACertPath, time.Duration(cfg.JWKSRefreshInterval)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	organizations, err := LoadOrganizationPolicies(cfg.OrganizationPoliciesPath, cfg.DefaultOrganization, GetDefaultNamespace())
+	if err != nil {
+		return nil, err
+	}
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Duration(cfg.TokenClockSkew) * time.Second),
+	}
+	if cfg.TokenIssuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(cfg.TokenIssuer))
+	}
+	if cfg.TokenAudience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(cfg.TokenAudience))
+	}
+	authenticator.keyStore = keyStore
+	authenticator.organizations = organizations
+	authenticator.parser = jwt.NewParser(parserOptions...)
+	return authenticator, nil
+}
+
+// Authorize returns a handler that authenticates the request and authorizes the operation. The
+// validated user context is stored in the request context for the handlers that follow. When
+// authentication is disabled, the organization is taken from the organization query parameter.
+func (a *Authenticator) Authorize(operation Operation) gin.HandlerFunc {
+	return func(cxt *gin.Context) {
+		namespace := ""
+		if operation.Namespaced {
+			namespace = GetNamespace(cxt)
+		}
+		if !a.cfg.AuthEnabled {
+			organization := cxt.Query("organization")
+			if organization == "" {
+				organization = a.cfg.DefaultOrganization
+			}
+			cxt.Set(constants.ValidatedUserContext, &UserContext{
+				Organization: dto.NewOrganization("", organization, "default", "default", true),
+				Claims:       map[string]interface{}{},
+			})
+			a.audit(cxt, operation, nil, namespace, true, "authentication disabled")
+			cxt.Next()
+			return
+		}
+		userContext, policy, err := a.authenticate(cxt)
+		if err != nil {
+			a.audit(cxt, operation, nil, namespace, false, err.Error())
+			cxt.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    900905,
+				"message": "Invalid Credentials",
+			})
+			return
+		}
+		if !hasScope(userContext.Scopes, operation.Scope) {
+			a.audit(cxt, operation, userContext, namespace, false, "missing scope "+operation.Scope)
+			cxt.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    900910,
+				"message": "The access token does not allow you to access the requested resource",
+			})
+			return
+		}
+		if operation.Namespaced && !policy.AllowsNamespace(namespace) {
+			a.audit(cxt, operation, userContext, namespace, false, "namespace not allowed for organization")
+			cxt.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    900911,
+				"message": "Organization " + policy.Name + " is not allowed to access namespace " + namespace,
+			})
+			return
+		}
+		cxt.Set(constants.ValidatedUserContext, userContext)
+		a.audit(cxt, operation, userContext, namespace, true, "")
+		cxt.Next()
+	}
+}
+
+// authenticate validates the bearer token of the request and maps its claims to a user context
+func (a *Authenticator) authenticate(cxt *gin.Context) (*UserContext, *OrganizationPolicy, error) {
+	authorization := cxt.GetHeader("Authorization")
+	scheme, tokenString, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || tokenString == "" {
+		return nil, nil, fmt.Errorf("missing bearer token")
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.keyStore.GetKey(kid)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid token: %w", err)
+	}
+	claimValue := a.cfg.DefaultOrganization
+	if value, ok := claims[a.cfg.OrganizationClaim].(string); ok && value != "" {
+		claimValue = value
+	}
+	policy, err := a.organizations.Resolve(claimValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	organization := policy.Organization
+	userContext := &UserContext{
+		Organization: &organization,
+		Scopes:       scopesOf(claims[a.cfg.ScopeClaim]),
+		Claims:       claims,
+	}
+	if subject, err := claims.GetSubject(); err == nil && subject != "" {
+		userContext.Username = subject
+		userContext.UserID = &subject
+	}
+	if username, ok := claims["username"].(string); ok && username != "" {
+		userContext.Username = username
+	}
+	return userContext, policy, nil
+}
+
+// audit logs the authorization decision of a request
+func (a *Authenticator) audit(cxt *gin.Context, operation Operation, userContext *UserContext, namespace string,
+	allowed bool, reason string) {
+	decision := "DENY"
+	if allowed {
+		decision = "ALLOW"
+	}
+	keysAndValues := []interface{}{
+		"decision", decision,
+		"operation", operation.Name,
+		"method", cxt.Request.Method,
+		"path", cxt.Request.URL.Path,
+		"clientIP", cxt.ClientIP(),
+	}
+	if namespace != "" {
+		keysAndValues = append(keysAndValues, "namespace", namespace)
+	}
+	if userContext != nil {
+		keysAndValues = append(keysAndValues, "user", userContext.Username, "organization", userContext.Organization.Name)
+	}
+	if apiID := cxt.Query("apiId"); apiID != "" {
+		keysAndValues = append(keysAndValues, "apiId", apiID)
+	}
+	if reason != "" {
+		keysAndValues = append(keysAndValues, "reason", reason)
+	}
+	a.auditLogger.Sugar().Infow("Config deployer authorization decision", keysAndValues...)
+}
+
+// scopesOf returns the scopes of a space separated scope claim or a list of scopes
+func scopesOf(claim interface{}) []string {
+	switch scopes := claim.(type) {
+	case string:
+		return strings.Fields(scopes)
+	case []interface{}:
+		values := make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			if value, ok := scope.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func hasScope(scopes []string, scope string) bool {
+	if scope == "" {
+		return true
+	}
+	for _, value := range scopes {
+		if value == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/wso2/apk/common-go-libs/pkg/logging"
+	"github.com/wso2/apk/config-deployer-service-go/internal/config"
+	"github.com/wso2/apk/config-deployer-service-go/internal/constants"
+)
+
+const (
+	testIssuer        = "https://idp.example.com"
+	testAudience      = "config-deployer"
+	testReadScope     = "apk:api_view"
+	testGenerateScope = "apk:api_generate"
+)
+
+func newTestAuthenticator(t *testing.T, jwksURL string) *Authenticator {
+	t.Helper()
+	authenticator, err := NewAuthenticator(&config.Server{
+		Logger:                   logging.DefaultLogger(egv1a1.LogLevelError),
+		AuthEnabled:              true,
+		JWKSURL:                  jwksURL,
+		JWKSRefreshInterval:      300,
+		TokenClockSkew:           30,
+		TokenIssuer:              testIssuer,
+		TokenAudience:            testAudience,
+		OrganizationClaim:        "organization",
+		ScopeClaim:               "scope",
+		DefaultOrganization:      "default",
+		OrganizationPoliciesPath: writeOrganizationPolicies(t, testOrganizationPolicies),
+		ReadScope:                testReadScope,
+		GenerateScope:            testGenerateScope,
+	})
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	return authenticator
+}
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestAuthenticator_Authorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := newTestJWKSServer(t)
+	key := server.addRSAKey(t, "signing-key")
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	authenticator := newTestAuthenticator(t, server.URL)
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		values := jwt.MapClaims{
+			"sub":          "alice",
+			"iss":          testIssuer,
+			"aud":          testAudience,
+			"exp":          time.Now().Add(time.Hour).Unix(),
+			"organization": "acme",
+			"scope":        testReadScope + " " + testGenerateScope,
+		}
+		for claim, value := range overrides {
+			if value == nil {
+				delete(values, claim)
+				continue
+			}
+			values[claim] = value
+		}
+		return values
+	}
+	valid := func(overrides jwt.MapClaims) string {
+		return "Bearer " + signTestToken(t, jwt.SigningMethodRS256, key, "signing-key", claims(overrides))
+	}
+	readOperation := Operation{Name: "read", Scope: testReadScope}
+	generateOperation := Operation{Name: "generate", Scope: testGenerateScope, Namespaced: true}
+
+	tests := []struct {
+		name          string
+		operation     Operation
+		namespace     string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "valid token", operation: readOperation, authorization: valid(nil), wantStatus: http.StatusOK},
+		{name: "missing token", operation: readOperation, wantStatus: http.StatusUnauthorized},
+		{name: "basic credentials", operation: readOperation, authorization: "Basic YWRtaW46YWRtaW4=",
+			wantStatus: http.StatusUnauthorized},
+		{name: "malformed token", operation: readOperation, authorization: "Bearer not-a-token",
+			wantStatus: http.StatusUnauthorized},
+		{name: "signed with another key", operation: readOperation,
+			authorization: "Bearer " + signTestToken(t, jwt.SigningMethodRS256, otherKey, "signing-key", claims(nil)),
+			wantStatus:    http.StatusUnauthorized},
+		{name: "unknown key ID", operation: readOperation,
+			authorization: "Bearer " + signTestToken(t, jwt.SigningMethodRS256, key, "unknown-key", claims(nil)),
+			wantStatus:    http.StatusUnauthorized},
+		{name: "symmetric algorithm", operation: readOperation,
+			authorization: "Bearer " + signTestToken(t, jwt.SigningMethodHS256, []byte("secret"), "signing-key", claims(nil)),
+			wantStatus:    http.StatusUnauthorized},
+		{name: "expired token", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}), wantStatus: http.StatusUnauthorized},
+		{name: "expired within the clock skew", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"exp": time.Now().Add(-10 * time.Second).Unix()}), wantStatus: http.StatusOK},
+		{name: "token without expiry", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"exp": nil}), wantStatus: http.StatusUnauthorized},
+		{name: "token not yet valid", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()}), wantStatus: http.StatusUnauthorized},
+		{name: "other issuer", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"iss": "https://other.example.com"}), wantStatus: http.StatusUnauthorized},
+		{name: "missing issuer", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"iss": nil}), wantStatus: http.StatusUnauthorized},
+		{name: "other audience", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"aud": "other"}), wantStatus: http.StatusUnauthorized},
+		{name: "one of several audiences", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"aud": []string{"other", testAudience}}), wantStatus: http.StatusOK},
+		{name: "read scope only", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"scope": testReadScope}), wantStatus: http.StatusOK},
+		{name: "generate with the read scope only", operation: generateOperation, namespace: "acme",
+			authorization: valid(jwt.MapClaims{"scope": testReadScope}), wantStatus: http.StatusForbidden},
+		{name: "read with the generate scope only", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"scope": testGenerateScope}), wantStatus: http.StatusForbidden},
+		{name: "generate with the generate scope", operation: generateOperation, namespace: "acme",
+			authorization: valid(jwt.MapClaims{"scope": testGenerateScope}), wantStatus: http.StatusOK},
+		{name: "scopes as a list", operation: generateOperation, namespace: "acme",
+			authorization: valid(jwt.MapClaims{"scope": []string{testGenerateScope}}), wantStatus: http.StatusOK},
+		{name: "no scopes", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"scope": nil}), wantStatus: http.StatusForbidden},
+		{name: "namespace matching a prefix", operation: generateOperation, namespace: "team-a",
+			authorization: valid(nil), wantStatus: http.StatusOK},
+		{name: "namespace of another organization", operation: generateOperation, namespace: "legacy",
+			authorization: valid(nil), wantStatus: http.StatusForbidden},
+		{name: "namespace of any organization", operation: generateOperation, namespace: "legacy",
+			authorization: valid(jwt.MapClaims{"organization": "globex-claim"}), wantStatus: http.StatusOK},
+		{name: "namespace is not checked for operations that are not namespaced", operation: readOperation,
+			namespace: "legacy", authorization: valid(nil), wantStatus: http.StatusOK},
+		{name: "disabled organization", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"organization": "legacy"}), wantStatus: http.StatusUnauthorized},
+		{name: "unregistered organization", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"organization": "initech"}), wantStatus: http.StatusUnauthorized},
+		{name: "unregistered default organization", operation: readOperation,
+			authorization: valid(jwt.MapClaims{"organization": nil}), wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userContext *UserContext
+			router := gin.New()
+			router.POST("/operation", authenticator.Authorize(tt.operation), func(cxt *gin.Context) {
+				userContext, _ = GetAuthenticatedUserContext(cxt)
+				cxt.Status(http.StatusOK)
+			})
+			target := "/operation"
+			if tt.namespace != "" {
+				target += "?namespace=" + tt.namespace
+			}
+			request := httptest.NewRequest(http.MethodPost, target, nil)
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && (userContext == nil || userContext.Username != "alice") {
+				t.Errorf("expected the user context of the token, got %+v", userContext)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_AuthDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := NewAuthenticator(&config.Server{
+		Logger:              logging.DefaultLogger(egv1a1.LogLevelError),
+		DefaultOrganization: "default",
+	})
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: "default"},
+		{query: "?organization=acme", want: "acme"},
+	}
+	for _, tt := range tests {
+		var organization string
+		router := gin.New()
+		router.POST("/operation", authenticator.Authorize(Operation{Name: "read", Scope: testReadScope}), func(cxt *gin.Context) {
+			userContext := cxt.Value(constants.ValidatedUserContext).(*UserContext)
+			organization = userContext.Organization.Name
+			cxt.Status(http.StatusOK)
+		})
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/operation"+tt.query, nil))
+		if recorder.Code != http.StatusOK || organization != tt.want {
+			t.Errorf("expected organization %q to be allowed, got status %d and organization %q", tt.want, recorder.Code, organization)
+		}
+	}
+}
+
+func TestNewAuthenticator_RequiresJWKS(t *testing.T) {
+	if _, err := NewAuthenticator(&config.Server{
+		Logger:      logging.DefaultLogger(egv1a1.LogLevelError),
+		AuthEnabled: true,
+	}); err == nil {
+		t.Errorf("expected authentication without a JWKS URL to be rejected")
+	}
+}
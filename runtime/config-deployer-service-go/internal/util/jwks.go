@@ -0,0 +1,218 @@
+/* Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minJWKSRefetchInterval limits refetching the key set for tokens signed with an unknown key.
+const minJWKSRefetchInterval = 10 * time.Second
+
+// jsonWebKey represents a public key of a JSON Web Key Set
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKSKeyStore caches the signing keys of a JWKS endpoint
+type JWKSKeyStore struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	// refreshMu serializes the fetches of the key set, which are made without holding mu.
+	refreshMu sync.Mutex
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewJWKSKeyStore creates a key store for the JWKS endpoint. The CA certificate is used to verify
+// the endpoint when provided.
+func NewJWKSKeyStore(url, caCertPath string, refreshInterval time.Duration) (*JWKSKeyStore, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS CA certificate: %w", err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in %s", caCertPath)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: certPool, MinVersion: tls.VersionTLS12}
+	}
+	return &JWKSKeyStore{
+		url:             url,
+		client:          &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		refreshInterval: refreshInterval,
+		keys:            map[string]interface{}{},
+	}, nil
+}
+
+// GetKey returns the public key with the key ID. The key set is refetched when it is stale or
+// does not contain the key, so that rotated keys are picked up.
+func (s *JWKSKeyStore) GetKey(kid string) (interface{}, error) {
+	s.mu.RLock()
+	key, found := s.keys[kid]
+	fetchedAt := s.fetchedAt
+	s.mu.RUnlock()
+	if found && time.Since(fetchedAt) < s.refreshInterval {
+		return key, nil
+	}
+	if found || time.Since(fetchedAt) >= minJWKSRefetchInterval {
+		if err := s.refresh(fetchedAt); err != nil {
+			if found {
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key, found := s.keys[kid]; found {
+		return key, nil
+	}
+	// Tokens without a key ID are accepted when the key set has a single key.
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("signing key %q not found in JWKS", kid)
+}
+
+// refresh fetches the key set from the JWKS endpoint, unless it was fetched after the given time
+// by a concurrent refresh. The endpoint is called without holding the lock of the keys, so that
+// the keys that are already known can be read while the key set is fetched.
+func (s *JWKSKeyStore) refresh(since time.Time) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	s.mu.RLock()
+	fetchedAt := s.fetchedAt
+	s.mu.RUnlock()
+	if fetchedAt.After(since) {
+		return nil
+	}
+	keys, err := s.fetch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetchedAt = time.Now()
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	return nil
+}
+
+// fetch fetches and parses the key set of the JWKS endpoint
+func (s *JWKSKeyStore) fetch() (map[string]interface{}, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&keySet); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	keys := make(map[string]interface{}, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q in JWKS: %w", jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey converts the JSON Web Key to an RSA or ECDSA public key. Other key types are skipped.
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URLInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URLInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBase64URLInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URLInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBase64URLInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testJWKSServer serves the public keys of its signing keys as a JWKS
+type testJWKSServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    map[string]interface{}
+	fetches atomic.Int32
+	// block, when set, delays the responses until it is closed.
+	block chan struct{}
+}
+
+func newTestJWKSServer(t *testing.T) *testJWKSServer {
+	t.Helper()
+	server := &testJWKSServer{keys: map[string]interface{}{}}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.fetches.Add(1)
+		server.mu.Lock()
+		block := server.block
+		server.mu.Unlock()
+		if block != nil {
+			<-block
+		}
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		var keySet struct {
+			Keys []jsonWebKey `json:"keys"`
+		}
+		for kid, key := range server.keys {
+			keySet.Keys = append(keySet.Keys, toJSONWebKey(kid, key))
+		}
+		_ = json.NewEncoder(w).Encode(keySet)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// addRSAKey generates an RSA signing key and publishes its public key with the key ID
+func (s *testJWKSServer) addRSAKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[kid] = &key.PublicKey
+	return key
+}
+
+func (s *testJWKSServer) setBlock(block chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.block = block
+}
+
+func toJSONWebKey(kid string, key interface{}) jsonWebKey {
+	encode := func(value *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(value.Bytes())
+	}
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		return jsonWebKey{Kid: kid, Kty: "RSA", Use: "sig", N: encode(publicKey.N),
+			E: encode(big.NewInt(int64(publicKey.E)))}
+	case *ecdsa.PublicKey:
+		return jsonWebKey{Kid: kid, Kty: "EC", Use: "sig", Crv: publicKey.Curve.Params().Name,
+			X: encode(publicKey.X), Y: encode(publicKey.Y)}
+	}
+	return jsonWebKey{Kid: kid}
+}
+
+func TestJWKSKeyStore_GetKey(t *testing.T) {
+	server := newTestJWKSServer(t)
+	rsaKey := server.addRSAKey(t, "rsa-1")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	server.keys["ec-1"] = &ecKey.PublicKey
+	store, err := NewJWKSKeyStore(server.URL, "", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create key store: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		kid     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "RSA key", kid: "rsa-1", want: &rsaKey.PublicKey},
+		{name: "EC key", kid: "ec-1", want: &ecKey.PublicKey},
+		{name: "unknown key", kid: "unknown", wantErr: true},
+		{name: "no key ID with several keys", kid: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := store.GetKey(tt.kid)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got key %v", key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !key.(interface{ Equal(x crypto.PublicKey) bool }).Equal(tt.want) {
+				t.Errorf("unexpected key %v", key)
+			}
+		})
+	}
+	if fetches := server.fetches.Load(); fetches != 1 {
+		t.Errorf("expected unknown keys not to be refetched within %s, got %d fetches", minJWKSRefetchInterval, fetches)
+	}
+}
+
+func TestJWKSKeyStore_RotatedKey(t *testing.T) {
+	server := newTestJWKSServer(t)
+	server.addRSAKey(t, "old")
+	store, _ := NewJWKSKeyStore(server.URL, "", time.Hour)
+	if _, err := store.GetKey("old"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.addRSAKey(t, "new")
+	// Allow the unknown key to trigger a refetch.
+	store.mu.Lock()
+	store.fetchedAt = store.fetchedAt.Add(-minJWKSRefetchInterval)
+	store.mu.Unlock()
+	if _, err := store.GetKey("new"); err != nil {
+		t.Fatalf("expected the rotated key to be fetched: %v", err)
+	}
+}
+
+func TestJWKSKeyStore_ReadsDuringRefresh(t *testing.T) {
+	server := newTestJWKSServer(t)
+	server.addRSAKey(t, "known")
+	store, _ := NewJWKSKeyStore(server.URL, "", time.Hour)
+	if _, err := store.GetKey("known"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.mu.Lock()
+	store.fetchedAt = store.fetchedAt.Add(-minJWKSRefetchInterval)
+	store.mu.Unlock()
+
+	block := make(chan struct{})
+	server.setBlock(block)
+	defer close(block)
+	go func() {
+		_, _ = store.GetKey("unknown")
+	}()
+	for server.fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := store.GetKey("known")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected known keys to be read while the key set is fetched")
+	}
+}
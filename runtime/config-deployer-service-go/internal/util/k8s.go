@@ -187,16 +187,21 @@ func ApplyK8sResource(k8sClient client.Client, namespace string, object client.O
 
 // GetNamespace retrieves the namespace to be used for Kubernetes operations.
 func GetNamespace(c *gin.Context) string {
-	namespace := config.GetConfig().DefaultNamespace
-	currentNamespace, err := getCurrentNamespace()
-	if err == nil && currentNamespace != "" {
-		namespace = currentNamespace
-	}
 	queryNamespace := c.Query("namespace")
 	if queryNamespace != "" {
-		namespace = queryNamespace
+		return queryNamespace
+	}
+	return GetDefaultNamespace()
+}
+
+// GetDefaultNamespace returns the namespace of the service, or the configured default namespace
+// when it is not running in Kubernetes.
+func GetDefaultNamespace() string {
+	currentNamespace, err := getCurrentNamespace()
+	if err == nil && currentNamespace != "" {
+		return currentNamespace
 	}
-	return namespace
+	return config.GetConfig().DefaultNamespace
 }
 
 // getCurrentNamespace retrieves the current namespace of the pod from the service account token file.
@@ -0,0 +1,119 @@
+/* Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wso2/apk/config-deployer-service-go/internal/dto"
+	"gopkg.in/yaml.v3"
+)
+
+// OrganizationPolicy represents an organization and the namespaces its users can deploy APIs to
+type OrganizationPolicy struct {
+	dto.Organization `yaml:",inline"`
+	// AllowedNamespaces are namespace names, "*" or prefixes ending with "*".
+	AllowedNamespaces []string `json:"allowedNamespaces" yaml:"allowedNamespaces"`
+}
+
+// AllowsNamespace checks whether the organization can deploy APIs to the namespace
+func (p *OrganizationPolicy) AllowsNamespace(namespace string) bool {
+	for _, allowed := range p.AllowedNamespaces {
+		if allowed == "*" || allowed == namespace {
+			return true
+		}
+		if prefix, found := strings.CutSuffix(allowed, "*"); found && strings.HasPrefix(namespace, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// OrganizationPolicies maps organization claim values to organization policies
+type OrganizationPolicies struct {
+	byClaimValue map[string]*OrganizationPolicy
+}
+
+// LoadOrganizationPolicies reads the organization policies from a YAML file of the form
+//
+//	organizations:
+//	- name: acme
+//	  organizationClaimValue: acme
+//	  enabled: true
+//	  allowedNamespaces: ["acme", "acme-*"]
+//
+// When no file is configured, the default organization is allowed to deploy to the default
+// namespace only.
+func LoadOrganizationPolicies(path, defaultOrganization, defaultNamespace string) (*OrganizationPolicies, error) {
+	policies := &OrganizationPolicies{byClaimValue: map[string]*OrganizationPolicy{}}
+	if path == "" {
+		organization := dto.NewOrganization(defaultOrganization, defaultOrganization, defaultOrganization,
+			defaultOrganization, true)
+		policies.byClaimValue[defaultOrganization] = &OrganizationPolicy{
+			Organization:      *organization,
+			AllowedNamespaces: []string{defaultNamespace},
+		}
+		return policies, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read organization policies: %w", err)
+	}
+	var file struct {
+		Organizations []*OrganizationPolicy `yaml:"organizations"`
+	}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse organization policies: %w", err)
+	}
+	for _, policy := range file.Organizations {
+		if policy.Name == "" {
+			return nil, fmt.Errorf("organization policy without a name in %s", path)
+		}
+		if policy.OrganizationClaimValue == "" {
+			policy.OrganizationClaimValue = policy.Name
+		}
+		if policy.DisplayName == "" {
+			policy.DisplayName = policy.Name
+		}
+		if policy.ServiceListingNamespaces == nil {
+			policy.ServiceListingNamespaces = []string{"*"}
+		}
+		if policy.Properties == nil {
+			policy.Properties = []dto.OrganizationProperties{}
+		}
+		if _, exists := policies.byClaimValue[policy.OrganizationClaimValue]; exists {
+			return nil, fmt.Errorf("duplicate organization claim value %q in %s", policy.OrganizationClaimValue, path)
+		}
+		policies.byClaimValue[policy.OrganizationClaimValue] = policy
+	}
+	return policies, nil
+}
+
+// Resolve returns the enabled organization policy of the organization claim value
+func (p *OrganizationPolicies) Resolve(claimValue string) (*OrganizationPolicy, error) {
+	policy, found := p.byClaimValue[claimValue]
+	if !found {
+		return nil, fmt.Errorf("organization %q is not registered", claimValue)
+	}
+	if !policy.Enabled {
+		return nil, fmt.Errorf("organization %q is disabled", claimValue)
+	}
+	return policy, nil
+}
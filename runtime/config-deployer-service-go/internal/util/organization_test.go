@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2025 WSO2 LLC. (http://www.wso2.com) All Rights Reserved.
+ *
+ * WSO2 LLC. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testOrganizationPolicies = `organizations:
+- name: acme
+  enabled: true
+  allowedNamespaces: ["acme", "team-*"]
+- name: globex
+  organizationClaimValue: globex-claim
+  enabled: true
+  allowedNamespaces: ["*"]
+- name: legacy
+  enabled: false
+  allowedNamespaces: ["legacy"]
+`
+
+func writeOrganizationPolicies(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "organizations.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write organization policies: %v", err)
+	}
+	return path
+}
+
+func TestOrganizationPolicies_Resolve(t *testing.T) {
+	policies, err := LoadOrganizationPolicies(writeOrganizationPolicies(t, testOrganizationPolicies), "default", "default")
+	if err != nil {
+		t.Fatalf("failed to load organization policies: %v", err)
+	}
+	tests := []struct {
+		name       string
+		claimValue string
+		want       string
+		wantErr    bool
+	}{
+		{name: "claim value defaults to the name", claimValue: "acme", want: "acme"},
+		{name: "explicit claim value", claimValue: "globex-claim", want: "globex"},
+		{name: "name is not a claim value when one is set", claimValue: "globex", wantErr: true},
+		{name: "disabled organization", claimValue: "legacy", wantErr: true},
+		{name: "unregistered organization", claimValue: "default", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := policies.Resolve(tt.claimValue)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got organization %q", policy.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if policy.Name != tt.want || policy.DisplayName != tt.want {
+				t.Errorf("expected organization %q, got %q (%q)", tt.want, policy.Name, policy.DisplayName)
+			}
+		})
+	}
+}
+
+func TestOrganizationPolicies_Default(t *testing.T) {
+	policies, err := LoadOrganizationPolicies("", "default", "apk")
+	if err != nil {
+		t.Fatalf("failed to load organization policies: %v", err)
+	}
+	policy, err := policies.Resolve("default")
+	if err != nil {
+		t.Fatalf("expected the default organization to be registered: %v", err)
+	}
+	if !policy.AllowsNamespace("apk") || policy.AllowsNamespace("default") {
+		t.Errorf("expected the default organization to be allowed the default namespace only, got %v", policy.AllowedNamespaces)
+	}
+}
+
+func TestOrganizationPolicies_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "missing name", content: "organizations:\n- enabled: true\n"},
+		{name: "duplicate claim value", content: "organizations:\n- name: a\n  organizationClaimValue: x\n- name: b\n  organizationClaimValue: x\n"},
+		{name: "malformed YAML", content: "organizations: ["},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadOrganizationPolicies(writeOrganizationPolicies(t, tt.content), "default", "default"); err == nil {
+				t.Errorf("expected the organization policies to be rejected")
+			}
+		})
+	}
+	if _, err := LoadOrganizationPolicies(filepath.Join(t.TempDir(), "missing.yaml"), "default", "default"); err == nil {
+		t.Errorf("expected a missing organization policies file to be rejected")
+	}
+}
+
+func TestOrganizationPolicy_AllowsNamespace(t *testing.T) {
+	policy := &OrganizationPolicy{AllowedNamespaces: []string{"acme", "team-*"}}
+	tests := []struct {
+		namespace string
+		want      bool
+	}{
+		{namespace: "acme", want: true},
+		{namespace: "team-a", want: true},
+		{namespace: "team-", want: true},
+		{namespace: "acme-dev", want: false},
+		{namespace: "team", want: false},
+		{namespace: "default", want: false},
+		{namespace: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			if got := policy.AllowsNamespace(tt.namespace); got != tt.want {
+				t.Errorf("AllowsNamespace(%q) = %v, want %v", tt.namespace, got, tt.want)
+			}
+		})
+	}
+	all := &OrganizationPolicy{AllowedNamespaces: []string{"*"}}
+	if !all.AllowsNamespace("any") {
+		t.Errorf("expected \"*\" to allow every namespace")
+	}
+}
@@ -60,7 +60,7 @@ public class MTLSClientCertSteps {
                                 "Basic NDVmMWM1YzgtYTkyZS0xMWVkLWFmYTEtMDI0MmFjMTIwMDAyOjRmYmQ2MmVjLWE5MmUtMTFlZC1hZmExLTAyNDJhYzEyMDAwMg==");
 
                 HttpResponse httpResponse = httpClient.doPost(Utils.getTokenEndpointURL(), headers,
-                                "grant_type=client_credentials&scope=" + Constants.API_CREATE_SCOPE,
+                                "grant_type=client_credentials&scope=" + Constants.CONFIG_DEPLOYER_SCOPES,
                                 Constants.CONTENT_TYPES.APPLICATION_X_WWW_FORM_URLENCODED);
                 sharedContext.setAccessToken(Utils.extractToken(httpResponse));
                 sharedContext.addStoreValue("accessToken", sharedContext.getAccessToken());